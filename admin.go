@@ -0,0 +1,47 @@
+package gotth
+
+import (
+	"log/slog"
+
+	"github.com/ancalabrese/gotth/admin"
+)
+
+// enableAdmin serves the admin dashboard of cfg, listing the routes and scheduled tasks of ws and
+// collecting the errors of its logger. Its metrics middleware is installed by Handler.
+func (ws *WebServer) enableAdmin(cfg admin.Config) {
+	cfg.Routes = func() []admin.Route {
+		infos := ws.Routes()
+		list := make([]admin.Route, 0, len(infos))
+		for _, info := range infos {
+			list = append(list, admin.Route{Method: info.Method, Pattern: info.Pattern, Name: info.Name, Kind: info.Kind, Source: info.Source})
+		}
+		return list
+	}
+	if s := ws.config.Scheduler; s != nil {
+		cfg.Tasks = func() []admin.Task {
+			stats := s.Stats()
+			tasks := make([]admin.Task, 0, len(stats))
+			for _, t := range stats {
+				tasks = append(tasks, admin.Task{Name: t.Name, Schedule: t.Schedule, Runs: t.Runs, Failures: t.Failures,
+					Running: t.Running, LastRun: t.LastRun, LastError: t.LastError, Next: t.Next})
+			}
+			return tasks
+		}
+	}
+	d, err := admin.New(cfg)
+	if err != nil {
+		ws.registrationFailed(err)
+		return
+	}
+	ws.admin = d
+	ws.logger = slog.New(d.LogHandler(ws.logger.Handler()))
+	ws.handle("GET "+d.Path(), d.Handler(), RouteKindBuiltin, 0)
+}
+
+// Admin returns the admin dashboard, or nil without WebServerConfig.Admin, e.g. to collect the
+// errors of the default logger:
+//
+//	slog.SetDefault(slog.New(ws.Admin().LogHandler(slog.Default().Handler())))
+func (ws *WebServer) Admin() *admin.Dashboard {
+	return ws.admin
+}
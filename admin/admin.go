@@ -0,0 +1,231 @@
+// Package admin serves an operations dashboard for small apps: the registered routes, the
+// recent errors, request metrics per route, cache hit rates and active sessions, built with
+// gotth's own components. Enable it with WebServerConfig.Admin, behind a guard:
+//
+//	ws, _ := gotth.New(gotth.WebServerConfig{Admin: &admin.Config{
+//		Guard:    middlewares.BasicAuth("admin", middlewares.StaticCredentials(map[string]string{"admin": password})),
+//		Sessions: sessions.Len,
+//		Caches:   []admin.Cache{{Name: "pages", Stats: pageCache.Stats}},
+//	}}, nil)
+//
+// The dashboard page is styled with Tailwind: add this package to the sources of your
+// stylesheet, and the stylesheet to Config.Head.
+package admin
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"net/http"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ancalabrese/gotth/views/components/head"
+)
+
+// DefaultPath is where the dashboard is served when Config.Path is empty.
+const DefaultPath = "/_admin"
+
+// Route is a registered route, listed by the dashboard.
+type Route struct {
+	Method  string
+	Pattern string
+	Name    string
+	Kind    string
+	// Source is the file:line of the registration.
+	Source string
+}
+
+// Task is a scheduled task, listed by the dashboard with its runs.
+type Task struct {
+	Name     string
+	Schedule string
+	Runs     uint64
+	Failures uint64
+	Running  bool
+	LastRun  time.Time
+	// LastError is the error of the last run, empty when it succeeded.
+	LastError string
+	// Next is the time of the next run, zero when the scheduler isn't running.
+	Next time.Time
+}
+
+// CacheStats are the counters of a cache.
+type CacheStats struct {
+	Hits   uint64
+	Misses uint64
+	// Entries is the number of cached items, or -1 when unknown.
+	Entries int
+}
+
+// HitRate returns the fraction of the lookups that hit, between 0 and 1.
+func (s CacheStats) HitRate() float64 {
+	if s.Hits+s.Misses == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Hits+s.Misses)
+}
+
+// Cache is a cache whose hit rate is shown by the dashboard.
+type Cache struct {
+	Name  string
+	Stats func() CacheStats
+}
+
+// Config configures the [Dashboard].
+type Config struct {
+	// Path the dashboard is served at. Defaults to [DefaultPath].
+	Path string
+	// Required: Guard protects the dashboard, e.g. middlewares.BasicAuth or middlewares.IPFilter.
+	Guard func(http.Handler) http.Handler
+	// Routes lists the registered routes. Set by gotth.WebServer.
+	Routes func() []Route
+	// Optional: caches whose hit rates are shown.
+	Caches []Cache
+	// Optional: Sessions returns the number of active sessions, e.g. memory.Store.Len.
+	Sessions func() int
+	// Tasks lists the scheduled tasks. Set by gotth.WebServer with WebServerConfig.Scheduler.
+	Tasks func() []Task
+	// MaxErrors is the number of recent errors kept. Defaults to 50.
+	MaxErrors int
+	// Optional: head options of the dashboard page, e.g. the stylesheet of the site.
+	Head []head.Option
+}
+
+// ErrNoGuard is returned by [New] without a Config.Guard: the dashboard exposes the internals
+// of the app.
+var ErrNoGuard = errors.New("the admin dashboard requires a guard")
+
+// Dashboard collects the metrics and errors of the server and serves the dashboard page.
+type Dashboard struct {
+	cfg     Config
+	started time.Time
+
+	mu      sync.Mutex
+	metrics map[string]*routeMetrics
+	errs    []ErrorEntry // Ring of the last cfg.MaxErrors errors
+	nextErr int
+}
+
+// New returns a Dashboard for cfg.
+func New(cfg Config) (*Dashboard, error) {
+	if cfg.Guard == nil {
+		return nil, ErrNoGuard
+	}
+	if cfg.Path == "" {
+		cfg.Path = DefaultPath
+	}
+	if cfg.MaxErrors <= 0 {
+		cfg.MaxErrors = 50
+	}
+	return &Dashboard{cfg: cfg, started: time.Now(), metrics: map[string]*routeMetrics{}}, nil
+}
+
+// Path returns the path the dashboard is served at.
+func (d *Dashboard) Path() string {
+	return d.cfg.Path
+}
+
+// Handler serves the dashboard page behind the guard.
+func (d *Dashboard) Handler() http.Handler {
+	return d.cfg.Guard(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "no-store")
+		w.Header().Set("X-Robots-Tag", "noindex")
+		page(d, r).Render(r.Context(), w)
+	}))
+}
+
+// Overview is the state of the server shown by the dashboard.
+type Overview struct {
+	Uptime     time.Duration
+	Requests   uint64
+	Errors5xx  uint64
+	Goroutines int
+	// HeapBytes is the memory allocated on the heap.
+	HeapBytes uint64
+	// Sessions is the number of active sessions, or -1 without Config.Sessions.
+	Sessions int
+	Routes   []RouteStats
+	Caches   []CacheRow
+	Tasks    []Task
+	Errors   []ErrorEntry
+}
+
+// CacheRow is the stats of a cache.
+type CacheRow struct {
+	Name string
+	CacheStats
+}
+
+// Overview returns the current state of the server. The routes are sorted by request count,
+// the errors from the newest.
+func (d *Dashboard) Overview() Overview {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	o := Overview{
+		Uptime:     time.Since(d.started).Round(time.Second),
+		Goroutines: runtime.NumGoroutine(),
+		HeapBytes:  mem.HeapAlloc,
+		Sessions:   -1,
+	}
+	if d.cfg.Sessions != nil {
+		o.Sessions = d.cfg.Sessions()
+	}
+	for _, c := range d.cfg.Caches {
+		o.Caches = append(o.Caches, CacheRow{Name: c.Name, CacheStats: c.Stats()})
+	}
+	if d.cfg.Tasks != nil {
+		o.Tasks = d.cfg.Tasks()
+	}
+
+	d.mu.Lock()
+	for route, m := range d.metrics {
+		s := m.stats(route)
+		o.Requests += s.Count
+		o.Errors5xx += s.Status[4]
+		o.Routes = append(o.Routes, s)
+	}
+	for i := range len(d.errs) {
+		// From the newest, before nextErr, wrapping around the ring.
+		o.Errors = append(o.Errors, d.errs[(d.nextErr-1-i+len(d.errs))%len(d.errs)])
+	}
+	d.mu.Unlock()
+
+	slices.SortFunc(o.Routes, func(a, b RouteStats) int {
+		return cmp.Or(cmp.Compare(b.Count, a.Count), strings.Compare(a.Route, b.Route))
+	})
+	return o
+}
+
+// routes returns the registered routes, sorted by path.
+func (d *Dashboard) routes() []Route {
+	if d.cfg.Routes == nil {
+		return nil
+	}
+	routes := d.cfg.Routes()
+	slices.SortStableFunc(routes, func(a, b Route) int {
+		return strings.Compare(strings.TrimPrefix(a.Pattern, a.Method+" "), strings.TrimPrefix(b.Pattern, b.Method+" "))
+	})
+	return routes
+}
+
+func (d *Dashboard) head() head.HeadViewModel {
+	opts := append([]head.Option{head.WithPageCoreMetadata("Admin dashboard", "", "")}, d.cfg.Head...)
+	return head.NewHeadViewModel(opts...)
+}
+
+type routeCtxKey string
+
+const routeKey routeCtxKey = "gotth_admin_route"
+
+// SetRoute records the route pattern matched by the request, for the metrics of
+// [Dashboard.Middleware]: http.ServeMux sets http.Request.Pattern on the request it serves,
+// which the middlewares before it don't see. gotth.WebServer calls it.
+func SetRoute(ctx context.Context, pattern string) {
+	if route, ok := ctx.Value(routeKey).(*string); ok {
+		*route = pattern
+	}
+}
@@ -0,0 +1,164 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func allow(next http.Handler) http.Handler { return next }
+
+func TestNew_RequiresGuard(t *testing.T) {
+	if _, err := New(Config{}); !errors.Is(err, ErrNoGuard) {
+		t.Fatalf("New() error = %v, want %v", err, ErrNoGuard)
+	}
+	d, err := New(Config{Guard: allow})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if d.Path() != DefaultPath {
+		t.Errorf("Path() = %q, want %q", d.Path(), DefaultPath)
+	}
+}
+
+func TestDashboard_Middleware(t *testing.T) {
+	d, _ := New(Config{Guard: allow})
+	handler := d.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/boom":
+			SetRoute(r.Context(), "GET /boom")
+			w.WriteHeader(http.StatusInternalServerError)
+		case "/":
+			SetRoute(r.Context(), "GET /{$}")
+			w.Write([]byte("ok"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+
+	for _, path := range []string{"/", "/", "/boom", "/missing", "/.env"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	o := d.Overview()
+	if o.Requests != 5 || o.Errors5xx != 1 {
+		t.Errorf("Requests = %d, Errors5xx = %d, want 5 and 1", o.Requests, o.Errors5xx)
+	}
+	tests := []struct {
+		route  string
+		count  uint64
+		status [5]uint64
+	}{
+		{route: "(unmatched)", count: 2, status: [5]uint64{3: 2}},
+		{route: "GET /{$}", count: 2, status: [5]uint64{1: 2}},
+		{route: "GET /boom", count: 1, status: [5]uint64{4: 1}},
+	}
+	if len(o.Routes) != len(tests) {
+		t.Fatalf("Routes = %+v, want %d routes", o.Routes, len(tests))
+	}
+	for i, tt := range tests {
+		got := o.Routes[i]
+		if got.Route != tt.route || got.Count != tt.count || got.Status != tt.status {
+			t.Errorf("Routes[%d] = %+v, want route %q, count %d, status %v", i, got, tt.route, tt.count, tt.status)
+		}
+	}
+}
+
+func TestDashboard_LogHandler(t *testing.T) {
+	d, _ := New(Config{Guard: allow, MaxErrors: 2})
+	var out strings.Builder
+	logger := slog.New(d.LogHandler(slog.NewTextHandler(&out, &slog.HandlerOptions{Level: slog.LevelError + 4})))
+
+	logger.Info("ignored")
+	logger.Error("first")
+	logger.WithGroup("req").With("path", "/a").Error("second", "status", 500)
+	logger.Error("third")
+
+	o := d.Overview()
+	want := []ErrorEntry{{Message: "third"}, {Message: "second", Attrs: "req.path=/a req.status=500"}}
+	if len(o.Errors) != len(want) {
+		t.Fatalf("Errors = %+v, want %d errors", o.Errors, len(want))
+	}
+	for i, w := range want {
+		if o.Errors[i].Message != w.Message || o.Errors[i].Attrs != w.Attrs {
+			t.Errorf("Errors[%d] = %+v, want %+v", i, o.Errors[i], w)
+		}
+	}
+	if out.Len() != 0 {
+		t.Errorf("next handler got records above its level: %s", out.String())
+	}
+}
+
+func TestDashboard_Handler(t *testing.T) {
+	guard := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("X-Admin") == "" {
+				http.Error(w, "forbidden", http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+	d, _ := New(Config{
+		Guard: guard,
+		Routes: func() []Route {
+			return []Route{
+				{Method: "GET", Pattern: "GET /posts", Kind: "page"},
+				{Method: "POST", Pattern: "POST /contact", Kind: "handler"},
+			}
+		},
+		Caches:   []Cache{{Name: "pages", Stats: func() CacheStats { return CacheStats{Hits: 3, Misses: 1, Entries: 2} }}},
+		Sessions: func() int { return 7 },
+		Tasks: func() []Task {
+			return []Task{{Name: "sitemap", Schedule: "@hourly", Runs: 2, LastError: "disk full", LastRun: time.Now()}}
+		},
+	})
+	d.LogHandler(slog.DiscardHandler).Handle(context.Background(), slog.NewRecord(time.Now(), slog.LevelError, "db down", 0))
+
+	rr := httptest.NewRecorder()
+	d.Handler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/_admin", nil))
+	if rr.Code != http.StatusForbidden {
+		t.Fatalf("status without guard pass = %d, want %d", rr.Code, http.StatusForbidden)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/_admin?filter.pattern=contact", nil)
+	req.Header.Set("X-Admin", "1")
+	rr = httptest.NewRecorder()
+	d.Handler().ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rr.Code, http.StatusOK)
+	}
+	if got := rr.Header().Get("Cache-Control"); got != "no-store" {
+		t.Errorf("Cache-Control = %q, want no-store", got)
+	}
+	body := rr.Body.String()
+	for _, want := range []string{"Active sessions", "75.0%", "db down", "@hourly", "disk full", "POST /contact"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("page is missing %q", want)
+		}
+	}
+	if strings.Contains(body, "GET /posts") {
+		t.Errorf("page lists the route filtered out")
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	tests := []struct {
+		n    uint64
+		want string
+	}{
+		{n: 512, want: "512 B"},
+		{n: 1536, want: "1.5 KiB"},
+		{n: 12 << 20, want: "12.0 MiB"},
+	}
+	for _, tt := range tests {
+		if got := formatBytes(tt.n); got != tt.want {
+			t.Errorf("formatBytes(%d) = %q, want %q", tt.n, got, tt.want)
+		}
+	}
+}
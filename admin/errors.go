@@ -0,0 +1,94 @@
+package admin
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// ErrorEntry is an error logged by the server.
+type ErrorEntry struct {
+	Time    time.Time
+	Message string
+	// Attrs are the attributes of the record, formatted as key=value.
+	Attrs string
+}
+
+// LogHandler returns a slog.Handler passing the records on to next and keeping the errors for
+// the dashboard. gotth.WebServer wraps its logger with it: wrap the default logger too to see
+// the errors of the app.
+func (d *Dashboard) LogHandler(next slog.Handler) slog.Handler {
+	return &logHandler{d: d, next: next}
+}
+
+type logHandler struct {
+	d      *Dashboard
+	next   slog.Handler
+	attrs  string
+	prefix string // Group of the attributes added next
+}
+
+func (h *logHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= slog.LevelError || h.next.Enabled(ctx, level)
+}
+
+func (h *logHandler) Handle(ctx context.Context, r slog.Record) error {
+	if r.Level >= slog.LevelError {
+		var attrs strings.Builder
+		attrs.WriteString(h.attrs)
+		r.Attrs(func(a slog.Attr) bool {
+			writeAttr(&attrs, h.prefix, a)
+			return true
+		})
+		h.d.addError(ErrorEntry{Time: r.Time, Message: r.Message, Attrs: strings.TrimSpace(attrs.String())})
+	}
+	if !h.next.Enabled(ctx, r.Level) {
+		return nil
+	}
+	return h.next.Handle(ctx, r)
+}
+
+func (h *logHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	var b strings.Builder
+	for _, a := range attrs {
+		writeAttr(&b, h.prefix, a)
+	}
+	return &logHandler{d: h.d, next: h.next.WithAttrs(attrs), attrs: h.attrs + b.String(), prefix: h.prefix}
+}
+
+func (h *logHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &logHandler{d: h.d, next: h.next.WithGroup(name), attrs: h.attrs, prefix: h.prefix + name + "."}
+}
+
+func (d *Dashboard) addError(e ErrorEntry) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if len(d.errs) < d.cfg.MaxErrors {
+		d.errs = append(d.errs, e)
+		d.nextErr = len(d.errs) % d.cfg.MaxErrors
+		return
+	}
+	d.errs[d.nextErr] = e
+	d.nextErr = (d.nextErr + 1) % len(d.errs)
+}
+
+func writeAttr(b *strings.Builder, prefix string, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			prefix += a.Key + "."
+		}
+		for _, ga := range a.Value.Group() {
+			writeAttr(b, prefix, ga)
+		}
+		return
+	}
+	b.WriteString(" " + prefix + a.Key + "=" + a.Value.String())
+}
@@ -0,0 +1,100 @@
+package admin
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// unmatchedRoute groups the requests that didn't match a route, so that scanners don't add a
+// row per path.
+const unmatchedRoute = "(unmatched)"
+
+// RouteStats are the request metrics of a route.
+type RouteStats struct {
+	// Route is the pattern of the route, e.g. "GET /blog/{slug}".
+	Route string
+	Count uint64
+	// Status counts the responses by status class: 1xx, 2xx, 3xx, 4xx and 5xx.
+	Status [5]uint64
+	Mean   time.Duration
+	Max    time.Duration
+}
+
+type routeMetrics struct {
+	count  uint64
+	status [5]uint64
+	total  time.Duration
+	max    time.Duration
+}
+
+func (m *routeMetrics) stats(route string) RouteStats {
+	s := RouteStats{Route: route, Count: m.count, Status: m.status, Max: m.max}
+	if m.count > 0 {
+		s.Mean = m.total / time.Duration(m.count)
+	}
+	return s
+}
+
+// Middleware returns a new middleware (http.Handler) recording the count, status and duration
+// of the requests per route. gotth.WebServer installs it outside of the global middlewares.
+func (d *Dashboard) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		route := ""
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		defer func() {
+			d.record(route, rec.status, time.Since(start))
+		}()
+		next.ServeHTTP(rec, r.WithContext(context.WithValue(r.Context(), routeKey, &route)))
+	})
+}
+
+func (d *Dashboard) record(route string, status int, duration time.Duration) {
+	if route == "" {
+		route = unmatchedRoute
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	m, ok := d.metrics[route]
+	if !ok {
+		m = &routeMetrics{}
+		d.metrics[route] = m
+	}
+	m.count++
+	if class := status/100 - 1; class >= 0 && class < len(m.status) {
+		m.status[class]++
+	}
+	m.total += duration
+	m.max = max(m.max, duration)
+}
+
+// statusRecorder records the status of the response. Unwrap keeps http.ResponseController
+// working through it.
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (rec *statusRecorder) WriteHeader(status int) {
+	if !rec.wroteHeader {
+		rec.status = status
+		rec.wroteHeader = true
+	}
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *statusRecorder) Write(b []byte) (int, error) {
+	rec.wroteHeader = true
+	return rec.ResponseWriter.Write(b)
+}
+
+func (rec *statusRecorder) Flush() {
+	rec.wroteHeader = true
+	http.NewResponseController(rec.ResponseWriter).Flush()
+}
+
+func (rec *statusRecorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
@@ -0,0 +1,108 @@
+package admin
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/a-h/templ"
+	"github.com/ancalabrese/gotth/table"
+)
+
+var metricColumns = []table.Column[RouteStats]{
+	{Key: "route", Label: "Route", Cell: func(s RouteStats) templ.Component { return table.Text(s.Route) }},
+	{Key: "count", Label: "Requests", Cell: func(s RouteStats) templ.Component { return table.Text(strconv.FormatUint(s.Count, 10)) }},
+	{Key: "2xx", Label: "2xx", Cell: statusCell(1)},
+	{Key: "3xx", Label: "3xx", Cell: statusCell(2)},
+	{Key: "4xx", Label: "4xx", Cell: statusCell(3)},
+	{Key: "5xx", Label: "5xx", Cell: statusCell(4)},
+	{Key: "mean", Label: "Mean", Cell: func(s RouteStats) templ.Component { return table.Text(s.Mean.String()) }},
+	{Key: "max", Label: "Max", Cell: func(s RouteStats) templ.Component { return table.Text(s.Max.String()) }},
+}
+
+func statusCell(class int) func(RouteStats) templ.Component {
+	return func(s RouteStats) templ.Component { return table.Text(strconv.FormatUint(s.Status[class], 10)) }
+}
+
+var cacheColumns = []table.Column[CacheRow]{
+	{Key: "name", Label: "Cache", Cell: func(c CacheRow) templ.Component { return table.Text(c.Name) }},
+	{Key: "hits", Label: "Hits", Cell: func(c CacheRow) templ.Component { return table.Text(strconv.FormatUint(c.Hits, 10)) }},
+	{Key: "misses", Label: "Misses", Cell: func(c CacheRow) templ.Component { return table.Text(strconv.FormatUint(c.Misses, 10)) }},
+	{Key: "rate", Label: "Hit rate", Cell: func(c CacheRow) templ.Component { return table.Text(fmt.Sprintf("%.1f%%", c.HitRate()*100)) }},
+	{Key: "entries", Label: "Entries", Cell: func(c CacheRow) templ.Component {
+		if c.Entries < 0 {
+			return table.Text("-")
+		}
+		return table.Text(strconv.Itoa(c.Entries))
+	}},
+}
+
+var taskColumns = []table.Column[Task]{
+	{Key: "name", Label: "Task", Cell: func(t Task) templ.Component { return table.Text(t.Name) }},
+	{Key: "schedule", Label: "Schedule", Cell: func(t Task) templ.Component { return table.Text(t.Schedule) }},
+	{Key: "runs", Label: "Runs", Cell: func(t Task) templ.Component { return table.Text(strconv.FormatUint(t.Runs, 10)) }},
+	{Key: "failures", Label: "Failures", Cell: func(t Task) templ.Component { return table.Text(strconv.FormatUint(t.Failures, 10)) }},
+	{Key: "last", Label: "Last run", Cell: func(t Task) templ.Component {
+		switch {
+		case t.Running:
+			return table.Text("running")
+		case t.LastRun.IsZero():
+			return table.Text("-")
+		case t.LastError != "":
+			return table.Text(t.LastRun.Format(time.DateTime) + ": " + t.LastError)
+		}
+		return table.Text(t.LastRun.Format(time.DateTime))
+	}},
+	{Key: "next", Label: "Next run", Cell: func(t Task) templ.Component {
+		if t.Next.IsZero() {
+			return table.Text("-")
+		}
+		return table.Text(t.Next.Format(time.DateTime))
+	}},
+}
+
+var routeColumns = []table.Column[Route]{
+	{
+		Key: "pattern", Label: "Pattern", Sortable: true, Filterable: true,
+		Cell:    func(r Route) templ.Component { return table.Text(r.Pattern) },
+		Compare: func(a, b Route) int { return strings.Compare(a.Pattern, b.Pattern) },
+		Match:   func(r Route, q string) bool { return strings.Contains(r.Pattern, q) },
+	},
+	{
+		Key: "name", Label: "Name", Sortable: true, Filterable: true,
+		Cell:    func(r Route) templ.Component { return table.Text(r.Name) },
+		Compare: func(a, b Route) int { return strings.Compare(a.Name, b.Name) },
+		Match:   func(r Route, q string) bool { return strings.Contains(r.Name, q) },
+	},
+	{
+		Key: "kind", Label: "Kind", Sortable: true, Filterable: true,
+		Cell:    func(r Route) templ.Component { return table.Text(r.Kind) },
+		Compare: func(a, b Route) int { return strings.Compare(a.Kind, b.Kind) },
+		Match:   func(r Route, q string) bool { return r.Kind == q },
+	},
+	{Key: "source", Label: "Source", Cell: func(r Route) templ.Component { return table.Text(r.Source) }},
+}
+
+// routesView returns the table of the routes, sorted and filtered as requested by r.
+func routesView(d *Dashboard, r *http.Request) table.View {
+	state := table.ParseState(r, routeColumns)
+	v := table.New("admin-routes", d.cfg.Path, routeColumns, table.Apply(d.routes(), routeColumns, state), state)
+	v.Empty = "No routes"
+	return v
+}
+
+// formatBytes formats n with a binary unit, e.g. "12.3 MiB".
+func formatBytes(n uint64) string {
+	const unit = 1024
+	if n < unit {
+		return strconv.FormatUint(n, 10) + " B"
+	}
+	div, exp := uint64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,45 @@
+// Package alpine hydrates Alpine.js islands with server state. The state is JSON encoded into
+// an x-data expression, which templ escapes for the attribute context:
+//
+//	<div x-data={ alpine.XData(CartState{Items: items, Open: false}) }>
+//		<span x-text="items.length"></span>
+//	</div>
+//
+// encoding/json escapes "<", ">", "&", U+2028 and U+2029, so the state can't break out of the
+// attribute or the expression, whatever the user supplied strings it contains.
+package alpine
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// XData returns the x-data expression for v, a struct or map. Use json struct tags to name
+// the Alpine properties.
+func XData(v any) (string, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode x-data. err %w", err)
+	}
+	if len(b) == 0 || b[0] != '{' {
+		return "", fmt.Errorf("x-data must be an object, got %s", b)
+	}
+	return string(b), nil
+}
+
+// XDataWith returns the x-data expression for v extended with the client-side properties and
+// methods of js, written as the body of an object literal:
+//
+//	alpine.XDataWith(state, "toggle() { this.open = !this.open }")
+func XDataWith(v any, js string) (string, error) {
+	data, err := XData(v)
+	if err != nil {
+		return "", err
+	}
+	js = strings.TrimSpace(js)
+	if js == "" {
+		return data, nil
+	}
+	return "{ ..." + data + ", " + js + " }", nil
+}
@@ -0,0 +1,73 @@
+package alpine_test
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/a-h/templ"
+	"github.com/ancalabrese/gotth/alpine"
+)
+
+type cart struct {
+	Items []string `json:"items"`
+	Open  bool     `json:"open"`
+}
+
+func TestXData(t *testing.T) {
+	tests := []struct {
+		name     string
+		v        any
+		js       string
+		expected string
+		wantErr  bool
+	}{
+		{
+			name:     "Struct",
+			v:        cart{Items: []string{"tea"}, Open: true},
+			expected: `{"items":["tea"],"open":true}`,
+		},
+		{
+			name:     "Escaped markup",
+			v:        map[string]string{"name": `</div><script>alert("x")</script>` + "\u2028"},
+			expected: `{"name":"\u003c/div\u003e\u003cscript\u003ealert(\"x\")\u003c/script\u003e\u2028"}`,
+		},
+		{
+			name:     "With methods",
+			v:        cart{},
+			js:       "toggle() { this.open = !this.open }",
+			expected: `{ ...{"items":null,"open":false}, toggle() { this.open = !this.open } }`,
+		},
+		{name: "Not an object", v: []int{1}, wantErr: true},
+		{name: "Not encodable", v: map[string]any{"f": func() {}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := alpine.XDataWith(tt.v, tt.js)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("XDataWith() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.expected {
+				t.Errorf("XDataWith() = %s, want %s", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestXData_Attribute(t *testing.T) {
+	data, err := alpine.XData(map[string]string{"q": `"'&`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b strings.Builder
+	c := templ.ComponentFunc(func(ctx context.Context, w io.Writer) error {
+		return templ.RenderAttributes(ctx, w, templ.Attributes{"x-data": data})
+	})
+	if err := c.Render(context.Background(), &b); err != nil {
+		t.Fatal(err)
+	}
+	if expected := ` x-data="{&#34;q&#34;:&#34;\&#34;&#39;\u0026&#34;}"`; b.String() != expected {
+		t.Errorf("attribute = %s, want %s", b.String(), expected)
+	}
+}
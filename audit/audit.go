@@ -0,0 +1,175 @@
+// Package audit records security relevant actions (logins, logouts, admin actions, ...) to a
+// pluggable Sink, separately from the access log:
+//
+//	sink := audit.JSONLinesSink(auditFile)
+//	cfg.GlobalMiddlewares = append(cfg.GlobalMiddlewares, middlewares.RequestID, audit.Middleware(sink, nil))
+//
+// Handlers then call [Emit]; the request ID, client IP and user are attached automatically. The
+// auth package emits its events on its own.
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/ancalabrese/gotth/middlewares"
+)
+
+const (
+	auditorKey contextAuditorKeyType = "gotth_audit_key"
+	requestKey contextAuditorKeyType = "gotth_audit_request_key"
+)
+
+type contextAuditorKeyType string
+
+// EventType classifies audit events.
+type EventType string
+
+const (
+	Login              EventType = "login"
+	LoginFailed        EventType = "login_failed"
+	Logout             EventType = "logout"
+	SessionInvalidated EventType = "session_invalidated"
+	AdminAction        EventType = "admin_action"
+)
+
+// Event is an audit record. Emit fills Time, RequestID, ClientIP, Path and UserID when empty.
+type Event struct {
+	Type EventType `json:"type"`
+	// Action describes what happened for generic types, e.g. "delete-post" for AdminAction.
+	Action    string         `json:"action,omitempty"`
+	Time      time.Time      `json:"time"`
+	RequestID string         `json:"request_id,omitempty"`
+	ClientIP  string         `json:"client_ip,omitempty"`
+	Path      string         `json:"path,omitempty"`
+	UserID    string         `json:"user_id,omitempty"`
+	Details   map[string]any `json:"details,omitempty"`
+	// User is the subject of the event when it's not the user of the request, e.g. on login.
+	// Only its ID is recorded.
+	User any `json:"-"`
+}
+
+// Sink stores audit events.
+type Sink interface {
+	Write(ctx context.Context, e Event) error
+}
+
+// SinkFunc adapts a function to a Sink, e.g. to insert events into a database table.
+type SinkFunc func(ctx context.Context, e Event) error
+
+func (f SinkFunc) Write(ctx context.Context, e Event) error {
+	return f(ctx, e)
+}
+
+// SlogSink returns a Sink writing events to logger at info level.
+func SlogSink(logger *slog.Logger) Sink {
+	return SinkFunc(func(ctx context.Context, e Event) error {
+		attrs := []slog.Attr{
+			slog.String("type", string(e.Type)),
+			slog.Time("time", e.Time),
+		}
+		for name, value := range map[string]string{
+			"action": e.Action, "request_id": e.RequestID, "client_ip": e.ClientIP, "path": e.Path, "user_id": e.UserID,
+		} {
+			if value != "" {
+				attrs = append(attrs, slog.String(name, value))
+			}
+		}
+		if len(e.Details) > 0 {
+			attrs = append(attrs, slog.Any("details", e.Details))
+		}
+		logger.LogAttrs(ctx, slog.LevelInfo, "audit", attrs...)
+		return nil
+	})
+}
+
+// JSONLinesSink returns a Sink writing one JSON object per event to w, e.g. an append-only file.
+// Writes are serialized.
+func JSONLinesSink(w io.Writer) Sink {
+	var mu sync.Mutex
+	enc := json.NewEncoder(w)
+	return SinkFunc(func(ctx context.Context, e Event) error {
+		mu.Lock()
+		defer mu.Unlock()
+		return enc.Encode(e)
+	})
+}
+
+type auditor struct {
+	sink   Sink
+	userID func(user any) string
+}
+
+// Middleware returns a new middleware (http.Handler) that makes sink available to [Emit] for the
+// rest of the request. userID returns the ID recorded for a user; when nil, users implementing
+// fmt.Stringer are recorded with String() and others without ID.
+func Middleware(sink Sink, userID func(user any) string) func(http.Handler) http.Handler {
+	a := &auditor{sink: sink, userID: userID}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := context.WithValue(r.Context(), auditorKey, a)
+			ctx = context.WithValue(ctx, requestKey, r)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// Emit records e, filling in the time, the request ID (see middlewares.RequestID), the client IP,
+// the path and the ID of e.User or, when nil, of the user of the request (see
+// middlewares.GetUser). It's a no-op without [Middleware]. Sink errors are returned and logged.
+func Emit(ctx context.Context, e Event) error {
+	a, ok := ctx.Value(auditorKey).(*auditor)
+	if !ok {
+		return nil
+	}
+
+	if e.Time.IsZero() {
+		e.Time = time.Now().UTC()
+	}
+	if e.RequestID == "" {
+		e.RequestID = middlewares.GetRequestID(ctx)
+	}
+	if r, ok := ctx.Value(requestKey).(*http.Request); ok {
+		if e.ClientIP == "" {
+			if ip := middlewares.ClientIP(r); ip.IsValid() {
+				e.ClientIP = ip.String()
+			}
+		}
+		if e.Path == "" {
+			e.Path = r.URL.Path
+		}
+	}
+	if e.UserID == "" {
+		user := e.User
+		if user == nil {
+			user = middlewares.GetUser(ctx)
+		}
+		e.UserID = a.id(user)
+	}
+
+	if err := a.sink.Write(ctx, e); err != nil {
+		err = fmt.Errorf("failed to write audit event. err %w", err)
+		slog.ErrorContext(ctx, err.Error(), slog.String("type", string(e.Type)))
+		return err
+	}
+	return nil
+}
+
+func (a *auditor) id(user any) string {
+	if user == nil {
+		return ""
+	}
+	if a.userID != nil {
+		return a.userID(user)
+	}
+	if s, ok := user.(fmt.Stringer); ok {
+		return s.String()
+	}
+	return ""
+}
@@ -0,0 +1,104 @@
+package audit_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ancalabrese/gotth/audit"
+	"github.com/ancalabrese/gotth/middlewares"
+)
+
+type adminUser struct{ ID string }
+
+func (u adminUser) String() string { return u.ID }
+
+type sessions struct{}
+
+func (sessions) ExchangeSessionIDForUser(ctx context.Context, id string) (any, error) {
+	return adminUser{ID: "admin-1"}, nil
+}
+func (sessions) InvalidateSession(ctx context.Context, user any, id string) error { return nil }
+func (sessions) RefreshSession(ctx context.Context, id string, ttl time.Duration) error {
+	return nil
+}
+
+func TestEmit(t *testing.T) {
+	var buf bytes.Buffer
+	handler := middlewares.RequestID(audit.Middleware(audit.JSONLinesSink(&buf), nil)(
+		middlewares.SessionCheck(sessions{}, true, nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			audit.Emit(r.Context(), audit.Event{Type: audit.AdminAction, Action: "delete-post", Details: map[string]any{"post": "42"}})
+			audit.Emit(r.Context(), audit.Event{Type: audit.SessionInvalidated, User: adminUser{ID: "user-9"}})
+		}))))
+
+	req := httptest.NewRequest(http.MethodPost, "/admin/posts/42/delete", nil)
+	req.Header.Set(middlewares.REQUEST_ID_HEADER, "req-7")
+	req.RemoteAddr = "203.0.113.5:4000"
+	req.AddCookie(&http.Cookie{Name: middlewares.SESSION_COOKIE_NAME, Value: "s"})
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d events: %s", len(lines), buf.String())
+	}
+
+	var first, second audit.Event
+	if err := json.Unmarshal([]byte(lines[0]), &first); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal([]byte(lines[1]), &second); err != nil {
+		t.Fatal(err)
+	}
+	if first.Type != audit.AdminAction || first.Action != "delete-post" || first.RequestID != "req-7" ||
+		first.ClientIP != "203.0.113.5" || first.Path != "/admin/posts/42/delete" || first.UserID != "admin-1" ||
+		first.Details["post"] != "42" || first.Time.IsZero() {
+		t.Errorf("unexpected first event %+v", first)
+	}
+	if second.Type != audit.SessionInvalidated || second.UserID != "user-9" {
+		t.Errorf("explicit user not recorded: %+v", second)
+	}
+}
+
+func TestEmit_WithoutMiddleware(t *testing.T) {
+	if err := audit.Emit(context.Background(), audit.Event{Type: audit.Login}); err != nil {
+		t.Errorf("Emit() error = %v", err)
+	}
+}
+
+func TestEmit_SinkError(t *testing.T) {
+	sinkErr := errors.New("db down")
+	var err error
+	handler := audit.Middleware(audit.SinkFunc(func(ctx context.Context, e audit.Event) error { return sinkErr }), nil)(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			err = audit.Emit(r.Context(), audit.Event{Type: audit.Logout})
+		}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if !errors.Is(err, sinkErr) {
+		t.Errorf("Emit() error = %v, want %v", err, sinkErr)
+	}
+}
+
+func TestSlogSink(t *testing.T) {
+	var buf bytes.Buffer
+	handler := audit.Middleware(audit.SlogSink(slog.New(slog.NewJSONHandler(&buf, nil))), nil)(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			audit.Emit(r.Context(), audit.Event{Type: audit.Login, UserID: "ada"})
+		}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/login", nil))
+
+	var entry map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("invalid log entry %q: %v", buf.String(), err)
+	}
+	if entry["msg"] != "audit" || entry["type"] != "login" || entry["user_id"] != "ada" || entry["path"] != "/login" {
+		t.Errorf("unexpected entry %v", entry)
+	}
+}
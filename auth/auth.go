@@ -0,0 +1,142 @@
+// Package auth provides ready-made username/password login and logout handlers built on the
+// session middlewares, so that a working login flow is a few lines:
+//
+//	store := memory.New()
+//	ws.Handle("POST /login", auth.LoginHandler(checkPassword, store, auth.DefaultConfig(),
+//		http.RedirectHandler("/account", http.StatusSeeOther), showLoginError))
+//	ws.Handle("POST /logout", auth.LogoutHandler(store, auth.DefaultConfig(),
+//		http.RedirectHandler("/", http.StatusSeeOther), showLoginError))
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/ancalabrese/gotth/audit"
+	"github.com/ancalabrese/gotth/middlewares"
+)
+
+const (
+	DefaultUsernameField = "username"
+	DefaultPasswordField = "password"
+	DefaultRememberField = "remember_me"
+
+	// DefaultRememberCookieName is the name of the remember-me cookie.
+	DefaultRememberCookieName = "remember_me"
+	// DefaultRememberTTL is how long a remember-me login lasts.
+	DefaultRememberTTL = 30 * 24 * time.Hour
+)
+
+// ErrInvalidCredentials should be returned by a CredentialCheckerFunc when the username or
+// password is wrong.
+var ErrInvalidCredentials = errors.New("invalid credentials")
+
+// CredentialCheckerFunc verifies the submitted credentials and returns the corresponding user.
+// Compare passwords against a slow hash (e.g., bcrypt or argon2), never in plain text.
+type CredentialCheckerFunc[U any] func(ctx context.Context, username, password string) (U, error)
+
+// SessionStore is a middlewares.SessionStore that can also create sessions for users of type U.
+// The stores in the sessionstore packages implement it.
+type SessionStore[U any] interface {
+	middlewares.SessionStore
+	CreateSession(ctx context.Context, user U) (string, error)
+}
+
+// Config configures the login and logout handlers. Use [DefaultConfig] as a starting point.
+type Config struct {
+	// Names of the form fields holding the credentials.
+	UsernameField string
+	PasswordField string
+	// Name of the checkbox that opts into a remember-me login. See [RememberMe].
+	RememberField string
+	// Session cookie attributes. Use the same config for every session middleware.
+	Session middlewares.SessionConfig
+	// Remember-me cookie attributes. Its TTL sets how long a remember-me login lasts.
+	Remember middlewares.SessionConfig
+}
+
+// DefaultConfig returns the default Config.
+func DefaultConfig() Config {
+	return Config{
+		UsernameField: DefaultUsernameField,
+		PasswordField: DefaultPasswordField,
+		RememberField: DefaultRememberField,
+		Session:       middlewares.DefaultSessionConfig(),
+		Remember: middlewares.SessionConfig{
+			Name:     DefaultRememberCookieName,
+			Path:     "/",
+			Secure:   true,
+			SameSite: http.SameSiteLaxMode,
+			TTL:      DefaultRememberTTL,
+		},
+	}
+}
+
+// LoginHandler returns a handler that checks the submitted credentials, creates a new session in
+// store and sets the session cookie before calling onSuccess (e.g., a redirect).
+// onFailure is called with [ErrInvalidCredentials] (or the error returned by check) when the
+// credentials are rejected and with any other error creating the session.
+func LoginHandler[U any](check CredentialCheckerFunc[U], store SessionStore[U], cfg Config, onSuccess http.Handler, onFailure func(http.ResponseWriter, *http.Request, error)) http.Handler {
+	return loginHandler(check, store, cfg, nil, onSuccess, onFailure)
+}
+
+// loginHandler implements LoginHandler. When set, afterLogin is called with the authenticated
+// user once the session has been created.
+func loginHandler[U any](check CredentialCheckerFunc[U], store SessionStore[U], cfg Config, afterLogin func(http.ResponseWriter, *http.Request, U) error, onSuccess http.Handler, onFailure func(http.ResponseWriter, *http.Request, error)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		create := func(r *http.Request) (string, error) {
+			username := r.PostFormValue(cfg.UsernameField)
+			password := r.PostFormValue(cfg.PasswordField)
+			if username == "" || password == "" {
+				return "", ErrInvalidCredentials
+			}
+
+			user, err := check(r.Context(), username, password)
+			if err != nil {
+				audit.Emit(r.Context(), audit.Event{Type: audit.LoginFailed, Details: map[string]any{"username": username}})
+				return "", err
+			}
+			sessionID, err := store.CreateSession(r.Context(), user)
+			if err != nil {
+				return "", err
+			}
+			if afterLogin != nil {
+				if err := afterLogin(w, r, user); err != nil {
+					return "", err
+				}
+			}
+			audit.Emit(r.Context(), audit.Event{Type: audit.Login, User: user, Details: map[string]any{"method": "password"}})
+			return sessionID, nil
+		}
+
+		issue := middlewares.IssueSession(create, cfg.Session, func(w http.ResponseWriter, r *http.Request, err error) {
+			// Unwrap the error added by IssueSession so that callers can match ErrInvalidCredentials.
+			if inner := errors.Unwrap(err); inner != nil {
+				err = inner
+			}
+			onFailure(w, r, err)
+		})
+		issue(onSuccess).ServeHTTP(w, r)
+	})
+}
+
+// LogoutHandler returns a handler that invalidates the session of the request in store and
+// expires the session cookie before calling onSuccess (e.g., a redirect to the home page).
+// Logins and logouts are recorded as audit events when audit.Middleware is in use.
+// onFailure is called when the request has no valid session or the session can't be invalidated.
+func LogoutHandler(store middlewares.SessionStore, cfg Config, onSuccess http.Handler, onFailure func(http.ResponseWriter, *http.Request, error)) http.Handler {
+	invalidate := middlewares.InvalidateSessionWithConfig(store, cfg.Session, onFailure)
+	emit := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			audit.Emit(r.Context(), audit.Event{Type: audit.Logout})
+			next.ServeHTTP(w, r)
+		})
+	}
+	check := middlewares.SessionCheckWithConfig(store, cfg.Session, true, func(w http.ResponseWriter, r *http.Request, err error) {
+		onFailure(w, r, fmt.Errorf("no valid session to logout. err %w", err))
+	})
+	return check(invalidate(emit(onSuccess)))
+}
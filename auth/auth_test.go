@@ -0,0 +1,155 @@
+package auth_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/ancalabrese/gotth/audit"
+	"github.com/ancalabrese/gotth/auth"
+	"github.com/ancalabrese/gotth/middlewares"
+	"github.com/ancalabrese/gotth/sessionstore/cookie"
+	"github.com/ancalabrese/gotth/sessionstore/memory"
+	"github.com/ancalabrese/gotth/sessionstore/redis"
+	"github.com/ancalabrese/gotth/sessionstore/sql"
+)
+
+type user struct{ ID string }
+
+var (
+	_ auth.SessionStore[any]  = (*memory.Store)(nil)
+	_ auth.SessionStore[any]  = (*sql.Store)(nil)
+	_ auth.SessionStore[user] = (*redis.Store[user])(nil)
+	_ auth.SessionStore[user] = (*cookie.Store[user])(nil)
+)
+
+func checkPassword(ctx context.Context, username, password string) (any, error) {
+	if username == "ada" && password == "lovelace" {
+		return "ada", nil
+	}
+	return nil, auth.ErrInvalidCredentials
+}
+
+func postForm(target string, form url.Values, cookies ...*http.Cookie) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	for _, c := range cookies {
+		req.AddCookie(c)
+	}
+	return req
+}
+
+func TestLoginLogout(t *testing.T) {
+	store := memory.New()
+	defer store.Close()
+	cfg := auth.DefaultConfig()
+
+	var failure error
+	onFailure := func(w http.ResponseWriter, r *http.Request, err error) {
+		failure = err
+		w.WriteHeader(http.StatusUnauthorized)
+	}
+	login := auth.LoginHandler(checkPassword, store, cfg, http.RedirectHandler("/account", http.StatusSeeOther), onFailure)
+	logout := auth.LogoutHandler(store, cfg, http.RedirectHandler("/", http.StatusSeeOther), onFailure)
+
+	tests := []struct {
+		name string
+		form url.Values
+	}{
+		{name: "Wrong password", form: url.Values{"username": {"ada"}, "password": {"babbage"}}},
+		{name: "Missing password", form: url.Values{"username": {"ada"}}},
+		{name: "Unknown user", form: url.Values{"username": {"charles"}, "password": {"lovelace"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			failure = nil
+			rr := httptest.NewRecorder()
+			login.ServeHTTP(rr, postForm("/login", tt.form))
+			if rr.Code != http.StatusUnauthorized || !errors.Is(failure, auth.ErrInvalidCredentials) {
+				t.Errorf("status = %d, err = %v; want %d, %v", rr.Code, failure, http.StatusUnauthorized, auth.ErrInvalidCredentials)
+			}
+			if len(rr.Result().Cookies()) != 0 {
+				t.Errorf("no cookie should be set on failure")
+			}
+		})
+	}
+
+	// Successful login
+	rr := httptest.NewRecorder()
+	login.ServeHTTP(rr, postForm("/login", url.Values{"username": {"ada"}, "password": {"lovelace"}}))
+	if rr.Code != http.StatusSeeOther || rr.Header().Get("Location") != "/account" {
+		t.Fatalf("login: status = %d, location = %q", rr.Code, rr.Header().Get("Location"))
+	}
+	cookies := rr.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != middlewares.SESSION_COOKIE_NAME {
+		t.Fatalf("login: expected the session cookie, got %v", cookies)
+	}
+	session := cookies[0]
+	if user, err := store.ExchangeSessionIDForUser(context.Background(), session.Value); err != nil || user != "ada" {
+		t.Fatalf("session not created in the store: %v, %v", user, err)
+	}
+
+	// Logout
+	rr = httptest.NewRecorder()
+	logout.ServeHTTP(rr, postForm("/logout", nil, session))
+	if rr.Code != http.StatusSeeOther || rr.Header().Get("Location") != "/" {
+		t.Fatalf("logout: status = %d, location = %q", rr.Code, rr.Header().Get("Location"))
+	}
+	if _, err := store.ExchangeSessionIDForUser(context.Background(), session.Value); err == nil {
+		t.Error("session still valid after logout")
+	}
+
+	// Logout without a valid session
+	failure = nil
+	rr = httptest.NewRecorder()
+	logout.ServeHTTP(rr, postForm("/logout", nil, session))
+	if rr.Code != http.StatusUnauthorized || failure == nil {
+		t.Errorf("logout without session: status = %d, err = %v", rr.Code, failure)
+	}
+}
+
+func TestLoginLogout_AuditEvents(t *testing.T) {
+	store := memory.New()
+	defer store.Close()
+	cfg := auth.DefaultConfig()
+
+	var events []audit.Event
+	sink := audit.SinkFunc(func(ctx context.Context, e audit.Event) error {
+		events = append(events, e)
+		return nil
+	})
+	withAudit := audit.Middleware(sink, func(u any) string { return u.(string) })
+	onFailure := func(w http.ResponseWriter, r *http.Request, err error) { w.WriteHeader(http.StatusUnauthorized) }
+	login := withAudit(auth.LoginHandler(checkPassword, store, cfg, http.RedirectHandler("/account", http.StatusSeeOther), onFailure))
+	logout := withAudit(auth.LogoutHandler(store, cfg, http.RedirectHandler("/", http.StatusSeeOther), onFailure))
+
+	login.ServeHTTP(httptest.NewRecorder(), postForm("/login", url.Values{"username": {"ada"}, "password": {"babbage"}}))
+	rr := httptest.NewRecorder()
+	login.ServeHTTP(rr, postForm("/login", url.Values{"username": {"ada"}, "password": {"lovelace"}}))
+	logout.ServeHTTP(httptest.NewRecorder(), postForm("/logout", nil, rr.Result().Cookies()...))
+
+	expected := []struct {
+		typ    audit.EventType
+		userID string
+		path   string
+	}{
+		{typ: audit.LoginFailed, userID: "", path: "/login"},
+		{typ: audit.Login, userID: "ada", path: "/login"},
+		{typ: audit.Logout, userID: "ada", path: "/logout"},
+	}
+	if len(events) != len(expected) {
+		t.Fatalf("got %d events, want %d: %+v", len(events), len(expected), events)
+	}
+	for i, want := range expected {
+		if events[i].Type != want.typ || events[i].UserID != want.userID || events[i].Path != want.path {
+			t.Errorf("event %d = %+v, want %+v", i, events[i], want)
+		}
+	}
+	if events[0].Details["username"] != "ada" {
+		t.Errorf("failed login details = %v", events[0].Details)
+	}
+}
@@ -0,0 +1,223 @@
+package auth
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ancalabrese/gotth/audit"
+	"github.com/ancalabrese/gotth/middlewares"
+)
+
+const (
+	DefaultMagicLinkTTL = 15 * time.Minute
+	DefaultEmailField   = "email"
+	DefaultTokenParam   = "token"
+)
+
+var (
+	// ErrInvalidEmail is returned when the submitted email address can't be parsed.
+	ErrInvalidEmail = errors.New("invalid email address")
+	// ErrInvalidToken is returned when a magic link token is malformed or its signature is wrong.
+	ErrInvalidToken = errors.New("invalid login token")
+	// ErrExpiredToken is returned when a magic link token has expired.
+	ErrExpiredToken = errors.New("expired login token")
+)
+
+// MagicLinkSenderFunc delivers the login link to email, usually by sending an email.
+type MagicLinkSenderFunc func(ctx context.Context, email, link string) error
+
+// UserLookupFunc returns the user identified by key: the email address for [MagicLink], where it
+// can create the user on first login, and the user ID for [RememberMe].
+type UserLookupFunc[U any] func(ctx context.Context, key string) (U, error)
+
+// MagicLink implements passwordless login: users submit their email address and receive a
+// signed, expiring link that logs them in. Instantiate via NewMagicLink.
+//
+// Tokens are stateless and can be used more than once until they expire, so keep the TTL short.
+type MagicLink[U any] struct {
+	secret      []byte
+	callbackURL string
+	lookup      UserLookupFunc[U]
+	send        MagicLinkSenderFunc
+	store       SessionStore[U]
+	cfg         Config
+	ttl         time.Duration
+	emailField  string
+	tokenParam  string
+	now         func() time.Time
+}
+
+// MagicLinkOption defines a function that configures the MagicLink.
+type MagicLinkOption func(*magicLinkOptions)
+
+type magicLinkOptions struct {
+	ttl        time.Duration
+	emailField string
+	tokenParam string
+}
+
+// WithMagicLinkTTL sets how long a link is valid.
+func WithMagicLinkTTL(ttl time.Duration) MagicLinkOption {
+	return func(o *magicLinkOptions) {
+		if ttl > 0 {
+			o.ttl = ttl
+		}
+	}
+}
+
+// WithEmailField sets the name of the form field holding the email address.
+func WithEmailField(name string) MagicLinkOption {
+	return func(o *magicLinkOptions) { o.emailField = name }
+}
+
+// WithTokenParam sets the name of the query parameter holding the token in the link.
+func WithTokenParam(name string) MagicLinkOption {
+	return func(o *magicLinkOptions) { o.tokenParam = name }
+}
+
+// NewMagicLink creates a MagicLink. callbackURL is the absolute URL the CallbackHandler is
+// served at; secret signs the tokens and must be kept private.
+func NewMagicLink[U any](secret []byte, callbackURL string, lookup UserLookupFunc[U], send MagicLinkSenderFunc, store SessionStore[U], cfg Config, opts ...MagicLinkOption) (*MagicLink[U], error) {
+	if len(secret) == 0 {
+		return nil, errors.New("magic link secret is empty")
+	}
+	if _, err := url.Parse(callbackURL); err != nil {
+		return nil, fmt.Errorf("invalid callback URL. err %w", err)
+	}
+
+	o := magicLinkOptions{ttl: DefaultMagicLinkTTL, emailField: DefaultEmailField, tokenParam: DefaultTokenParam}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return &MagicLink[U]{
+		secret:      secret,
+		callbackURL: callbackURL,
+		lookup:      lookup,
+		send:        send,
+		store:       store,
+		cfg:         cfg,
+		ttl:         o.ttl,
+		emailField:  o.emailField,
+		tokenParam:  o.tokenParam,
+		now:         time.Now,
+	}, nil
+}
+
+// Link returns a new login link for email.
+func (m *MagicLink[U]) Link(email string) (string, error) {
+	u, err := url.Parse(m.callbackURL)
+	if err != nil {
+		return "", err
+	}
+	q := u.Query()
+	q.Set(m.tokenParam, m.token(email, m.now().Add(m.ttl)))
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+// Verify checks token and returns the email address it was issued for.
+func (m *MagicLink[U]) Verify(token string) (string, error) {
+	payload, sig, ok := strings.Cut(token, ".")
+	if !ok || !hmac.Equal([]byte(sig), []byte(m.sign(payload))) {
+		return "", ErrInvalidToken
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return "", ErrInvalidToken
+	}
+	email, exp, ok := strings.Cut(string(raw), "\n")
+	if !ok {
+		return "", ErrInvalidToken
+	}
+	expiresAt, err := strconv.ParseInt(exp, 10, 64)
+	if err != nil {
+		return "", ErrInvalidToken
+	}
+	if m.now().Unix() >= expiresAt {
+		return "", ErrExpiredToken
+	}
+	return email, nil
+}
+
+// RequestHandler returns a handler that sends a login link to the submitted email address and
+// then calls onSent (e.g., a "check your inbox" page). onFailure is called with
+// [ErrInvalidEmail] or the error returned by the sender.
+func (m *MagicLink[U]) RequestHandler(onSent http.Handler, onFailure func(http.ResponseWriter, *http.Request, error)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		addr, err := mail.ParseAddress(r.PostFormValue(m.emailField))
+		if err != nil {
+			onFailure(w, r, ErrInvalidEmail)
+			return
+		}
+		email := strings.ToLower(addr.Address)
+
+		link, err := m.Link(email)
+		if err != nil {
+			onFailure(w, r, err)
+			return
+		}
+		if err := m.send(r.Context(), email, link); err != nil {
+			onFailure(w, r, fmt.Errorf("failed to send login link. err %w", err))
+			return
+		}
+		onSent.ServeHTTP(w, r)
+	})
+}
+
+// CallbackHandler returns the handler for the login links: it verifies the token, looks up the
+// user, creates the session and sets the session cookie before calling onSuccess.
+// onFailure is called with [ErrInvalidToken], [ErrExpiredToken] or any error looking up the user
+// or creating the session.
+func (m *MagicLink[U]) CallbackHandler(onSuccess http.Handler, onFailure func(http.ResponseWriter, *http.Request, error)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		email, err := m.Verify(r.URL.Query().Get(m.tokenParam))
+		if err != nil {
+			onFailure(w, r, err)
+			return
+		}
+
+		user, err := m.lookup(r.Context(), email)
+		if err != nil {
+			onFailure(w, r, err)
+			return
+		}
+
+		sessionID, err := m.store.CreateSession(r.Context(), user)
+		if err != nil {
+			onFailure(w, r, fmt.Errorf("failed to create session. err %w", err))
+			return
+		}
+
+		audit.Emit(r.Context(), audit.Event{Type: audit.Login, User: user, Details: map[string]any{"method": "magic-link"}})
+
+		// Stop the token from leaking via the Referer header of the next page.
+		w.Header().Set("Referrer-Policy", "no-referrer")
+		middlewares.SetSession(w, sessionID, m.cfg.Session)
+		onSuccess.ServeHTTP(w, r)
+	})
+}
+
+// token returns "<base64(email\nexpiry)>.<signature>".
+func (m *MagicLink[U]) token(email string, expiresAt time.Time) string {
+	payload := base64.RawURLEncoding.EncodeToString([]byte(email + "\n" + strconv.FormatInt(expiresAt.Unix(), 10)))
+	return payload + "." + m.sign(payload)
+}
+
+func (m *MagicLink[U]) sign(payload string) string {
+	mac := hmac.New(sha256.New, m.secret)
+	mac.Write([]byte("gotth-magic-link\n"))
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,110 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ancalabrese/gotth/middlewares"
+	"github.com/ancalabrese/gotth/sessionstore/memory"
+)
+
+func TestMagicLink(t *testing.T) {
+	store := memory.New()
+	defer store.Close()
+
+	var sentTo, sentLink string
+	send := func(ctx context.Context, email, link string) error {
+		sentTo, sentLink = email, link
+		return nil
+	}
+	lookup := func(ctx context.Context, email string) (any, error) { return "user:" + email, nil }
+
+	ml, err := NewMagicLink([]byte("secret"), "https://example.com/login/callback", lookup, send, store, DefaultConfig())
+	if err != nil {
+		t.Fatalf("NewMagicLink() error = %v", err)
+	}
+	now := time.Now()
+	ml.now = func() time.Time { return now }
+
+	var failure error
+	onFailure := func(w http.ResponseWriter, r *http.Request, err error) {
+		failure = err
+		w.WriteHeader(http.StatusBadRequest)
+	}
+	request := ml.RequestHandler(http.RedirectHandler("/check-inbox", http.StatusSeeOther), onFailure)
+	callback := ml.CallbackHandler(http.RedirectHandler("/account", http.StatusSeeOther), onFailure)
+
+	// Invalid email
+	req := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader("email=not-an-email"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rr := httptest.NewRecorder()
+	request.ServeHTTP(rr, req)
+	if !errors.Is(failure, ErrInvalidEmail) {
+		t.Errorf("invalid email: err = %v, want %v", failure, ErrInvalidEmail)
+	}
+
+	// Link request
+	req = httptest.NewRequest(http.MethodPost, "/login", strings.NewReader("email=Ada@Example.com"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rr = httptest.NewRecorder()
+	request.ServeHTTP(rr, req)
+	if rr.Code != http.StatusSeeOther || sentTo != "ada@example.com" {
+		t.Fatalf("link request: status = %d, sent to %q", rr.Code, sentTo)
+	}
+	link, err := url.Parse(sentLink)
+	if err != nil || link.Host != "example.com" || link.Path != "/login/callback" {
+		t.Fatalf("unexpected link %q", sentLink)
+	}
+	token := link.Query().Get(DefaultTokenParam)
+
+	// Tampered token
+	failure = nil
+	rr = httptest.NewRecorder()
+	callback.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/login/callback?token="+url.QueryEscape(token+"x"), nil))
+	if !errors.Is(failure, ErrInvalidToken) {
+		t.Errorf("tampered token: err = %v, want %v", failure, ErrInvalidToken)
+	}
+
+	// Valid token
+	rr = httptest.NewRecorder()
+	callback.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/login/callback?"+link.RawQuery, nil))
+	if rr.Code != http.StatusSeeOther || rr.Header().Get("Location") != "/account" {
+		t.Fatalf("callback: status = %d, location = %q", rr.Code, rr.Header().Get("Location"))
+	}
+	cookies := rr.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != middlewares.SESSION_COOKIE_NAME {
+		t.Fatalf("callback: expected the session cookie, got %v", cookies)
+	}
+	if user, err := store.ExchangeSessionIDForUser(context.Background(), cookies[0].Value); err != nil || user != "user:ada@example.com" {
+		t.Errorf("session user = %v, %v", user, err)
+	}
+
+	// Expired token
+	now = now.Add(DefaultMagicLinkTTL)
+	failure = nil
+	rr = httptest.NewRecorder()
+	callback.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/login/callback?"+link.RawQuery, nil))
+	if !errors.Is(failure, ErrExpiredToken) {
+		t.Errorf("expired token: err = %v, want %v", failure, ErrExpiredToken)
+	}
+}
+
+func TestMagicLink_VerifyRejectsOtherSecrets(t *testing.T) {
+	a, _ := NewMagicLink[any]([]byte("a"), "https://example.com/cb", nil, nil, nil, DefaultConfig())
+	b, _ := NewMagicLink[any]([]byte("b"), "https://example.com/cb", nil, nil, nil, DefaultConfig())
+
+	link, _ := a.Link("ada@example.com")
+	u, _ := url.Parse(link)
+	if _, err := b.Verify(u.Query().Get(DefaultTokenParam)); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Verify() error = %v, want %v", err, ErrInvalidToken)
+	}
+	if email, err := a.Verify(u.Query().Get(DefaultTokenParam)); err != nil || email != "ada@example.com" {
+		t.Errorf("Verify() = %q, %v", email, err)
+	}
+}
@@ -0,0 +1,373 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ancalabrese/gotth/audit"
+	"github.com/ancalabrese/gotth/middlewares"
+)
+
+// rotationGrace is how long the previous verifier of a series stays valid after a rotation, so
+// that concurrent requests sent with the old cookie don't look like a stolen token.
+const rotationGrace = time.Minute
+
+var (
+	// ErrRememberTokenNotFound should be returned by a RememberStore when the selector is unknown.
+	ErrRememberTokenNotFound = errors.New("remember-me token not found")
+	// ErrRememberTokenTheft is reported when a known selector is presented with a wrong verifier:
+	// someone else used a copy of the cookie, so every remember-me login of the user is revoked.
+	ErrRememberTokenTheft = errors.New("remember-me token reused, possible theft")
+	// ErrRememberTokenRotated should be returned by a RememberStore when a concurrent request
+	// rotated the token first.
+	ErrRememberTokenRotated = errors.New("remember-me token already rotated")
+)
+
+// RememberToken is a remember-me login series as saved in a RememberStore. The cookie holds the
+// selector and the verifier; only a hash of the verifier is stored.
+type RememberToken struct {
+	// Selector identifies the series. It doesn't change when the verifier is rotated.
+	Selector string
+	// VerifierHash is the SHA-256 hash of the current verifier.
+	VerifierHash []byte
+	// PreviousVerifierHash is the hash of the verifier replaced at RotatedAt.
+	PreviousVerifierHash []byte
+	RotatedAt            time.Time
+	UserID               string
+	// ExpiresAt is the end of the series. Rotations don't extend it.
+	ExpiresAt time.Time
+}
+
+// RememberStore persists remember-me tokens.
+type RememberStore interface {
+	// SaveRememberToken creates or replaces the token with the same selector.
+	SaveRememberToken(ctx context.Context, token RememberToken) error
+	// FindRememberToken returns the token for selector or [ErrRememberTokenNotFound].
+	FindRememberToken(ctx context.Context, selector string) (RememberToken, error)
+	// RotateRememberToken replaces the token with the same selector only if its stored
+	// VerifierHash still equals previousHash, or returns [ErrRememberTokenRotated]. The check
+	// and the replacement must be atomic.
+	RotateRememberToken(ctx context.Context, token RememberToken, previousHash []byte) error
+	DeleteRememberToken(ctx context.Context, selector string) error
+	// DeleteUserRememberTokens deletes every token of the user, i.e. logs out all their devices.
+	DeleteUserRememberTokens(ctx context.Context, userID string) error
+}
+
+// RememberMe keeps users logged in across browser restarts with a long-lived cookie holding a
+// selector and a verifier. When a request arrives without a valid session, the middleware
+// exchanges the cookie for a new session and rotates the verifier, so a copied cookie works at
+// most once: when both the thief and the user present it, the series is revoked.
+// Instantiate via NewRememberMe.
+type RememberMe[U any] struct {
+	tokens   RememberStore
+	sessions SessionStore[U]
+	userID   func(U) string
+	lookup   UserLookupFunc[U]
+	cfg      Config
+	now      func() time.Time
+}
+
+// NewRememberMe creates a RememberMe. userID returns the stable ID of a user and lookup returns
+// the user for an ID.
+func NewRememberMe[U any](tokens RememberStore, sessions SessionStore[U], userID func(U) string, lookup UserLookupFunc[U], cfg Config) *RememberMe[U] {
+	return &RememberMe[U]{
+		tokens:   tokens,
+		sessions: sessions,
+		userID:   userID,
+		lookup:   lookup,
+		cfg:      cfg,
+		now:      time.Now,
+	}
+}
+
+// Remember starts a new remember-me series for user and sets the remember-me cookie.
+func (rm *RememberMe[U]) Remember(w http.ResponseWriter, r *http.Request, user U) error {
+	selector, err := randomToken(12)
+	if err != nil {
+		return err
+	}
+	verifier, err := randomToken(32)
+	if err != nil {
+		return err
+	}
+
+	token := RememberToken{
+		Selector:     selector,
+		VerifierHash: hashVerifier(verifier),
+		UserID:       rm.userID(user),
+		ExpiresAt:    rm.now().Add(rm.cfg.Remember.TTL),
+	}
+	if err := rm.tokens.SaveRememberToken(r.Context(), token); err != nil {
+		return fmt.Errorf("failed to save remember-me token. err %w", err)
+	}
+	rm.setCookie(w, token, verifier)
+	return nil
+}
+
+// Forget ends the remember-me series of the request, if any, and expires the cookie.
+func (rm *RememberMe[U]) Forget(w http.ResponseWriter, r *http.Request) error {
+	middlewares.ClearSession(w, rm.cfg.Remember)
+
+	selector, _, ok := rm.readCookie(r)
+	if !ok {
+		return nil
+	}
+	if err := rm.tokens.DeleteRememberToken(r.Context(), selector); err != nil {
+		return fmt.Errorf("failed to delete remember-me token. err %w", err)
+	}
+	return nil
+}
+
+// LoginHandler is like [LoginHandler] and also starts a remember-me series when the
+// [Config.RememberField] checkbox is ticked.
+func (rm *RememberMe[U]) LoginHandler(check CredentialCheckerFunc[U], onSuccess http.Handler, onFailure func(http.ResponseWriter, *http.Request, error)) http.Handler {
+	remember := func(w http.ResponseWriter, r *http.Request, user U) error {
+		if r.PostFormValue(rm.cfg.RememberField) == "" {
+			return nil
+		}
+		return rm.Remember(w, r, user)
+	}
+	return loginHandler(check, rm.sessions, rm.cfg, remember, onSuccess, onFailure)
+}
+
+// LogoutHandler is like [LogoutHandler] and also ends the remember-me series of the request.
+func (rm *RememberMe[U]) LogoutHandler(onSuccess http.Handler, onFailure func(http.ResponseWriter, *http.Request, error)) http.Handler {
+	logout := LogoutHandler(rm.sessions, rm.cfg, onSuccess, onFailure)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := rm.Forget(w, r); err != nil {
+			onFailure(w, r, err)
+			return
+		}
+		logout.ServeHTTP(w, r)
+	})
+}
+
+// Middleware returns a new middleware (http.Handler) that logs in requests that carry a valid
+// remember-me cookie but no valid session: it creates a new session, sets the session cookie and
+// rotates the remember-me cookie. Place it before [middlewares.SessionCheckWithConfig], which
+// then reuses the user set in the request context instead of exchanging the session ID again.
+// Requests without a remember-me cookie pass through. onError is called with
+// [ErrRememberTokenTheft] or any store error; the request then continues without a session, so
+// onError only needs to report.
+func (rm *RememberMe[U]) Middleware(onError func(http.ResponseWriter, *http.Request, error)) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if user, ok := rm.sessionUser(r); ok {
+				next.ServeHTTP(w, r.WithContext(middlewares.ContextWithUser(r.Context(), user)))
+				return
+			}
+			selector, verifier, ok := rm.readCookie(r)
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			sessionID, user, err := rm.restore(w, r, selector, verifier)
+			if err != nil {
+				onError(w, r, err)
+				next.ServeHTTP(w, r)
+				return
+			}
+			if sessionID == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			middlewares.SetSession(w, sessionID, rm.cfg.Session)
+			r = withSessionCookie(r, rm.cfg.Session.CookieName(), sessionID)
+			next.ServeHTTP(w, r.WithContext(middlewares.ContextWithUser(r.Context(), user)))
+		})
+	}
+}
+
+// restore exchanges a remember-me cookie for a new session and its user. It returns an empty
+// session ID without an error when the cookie is unknown or expired.
+func (rm *RememberMe[U]) restore(w http.ResponseWriter, r *http.Request, selector, verifier string) (sessionID string, user U, err error) {
+	ctx := r.Context()
+	token, err := rm.tokens.FindRememberToken(ctx, selector)
+	if errors.Is(err, ErrRememberTokenNotFound) {
+		middlewares.ClearSession(w, rm.cfg.Remember)
+		return "", user, nil
+	}
+	if err != nil {
+		return "", user, fmt.Errorf("failed to find remember-me token. err %w", err)
+	}
+
+	now := rm.now()
+	if !now.Before(token.ExpiresAt) {
+		middlewares.ClearSession(w, rm.cfg.Remember)
+		return "", user, rm.tokens.DeleteRememberToken(ctx, selector)
+	}
+
+	hash := hashVerifier(verifier)
+	rotate := true
+	switch {
+	case subtle.ConstantTimeCompare(hash, token.VerifierHash) == 1:
+	case token.PreviousVerifierHash != nil && now.Sub(token.RotatedAt) < rotationGrace &&
+		subtle.ConstantTimeCompare(hash, token.PreviousVerifierHash) == 1:
+		// A concurrent request already rotated the cookie and its response carries the new one.
+		rotate = false
+	default:
+		middlewares.ClearSession(w, rm.cfg.Remember)
+		if err := rm.tokens.DeleteUserRememberTokens(ctx, token.UserID); err != nil {
+			return "", user, fmt.Errorf("failed to revoke remember-me tokens. err %w", err)
+		}
+		audit.Emit(ctx, audit.Event{Type: audit.SessionInvalidated, Action: "remember-me-theft", UserID: token.UserID})
+		return "", user, ErrRememberTokenTheft
+	}
+
+	user, err = rm.lookup(ctx, token.UserID)
+	if err != nil {
+		return "", user, fmt.Errorf("failed to lookup remembered user. err %w", err)
+	}
+	sessionID, err = rm.sessions.CreateSession(ctx, user)
+	if err != nil {
+		return "", user, fmt.Errorf("failed to create session. err %w", err)
+	}
+
+	audit.Emit(ctx, audit.Event{Type: audit.Login, UserID: token.UserID, Details: map[string]any{"method": "remember-me"}})
+
+	if rotate {
+		newVerifier, err := randomToken(32)
+		if err != nil {
+			return "", user, err
+		}
+		previousHash := token.VerifierHash
+		token.PreviousVerifierHash = previousHash
+		token.VerifierHash = hashVerifier(newVerifier)
+		token.RotatedAt = now
+		err = rm.tokens.RotateRememberToken(ctx, token, previousHash)
+		switch {
+		case err == nil:
+			rm.setCookie(w, token, newVerifier)
+		case errors.Is(err, ErrRememberTokenRotated):
+			// A concurrent request won the rotation and its response carries the new cookie.
+		default:
+			return "", user, fmt.Errorf("failed to rotate remember-me token. err %w", err)
+		}
+	}
+	return sessionID, user, nil
+}
+
+// sessionUser returns the user of the request session, if it's valid.
+func (rm *RememberMe[U]) sessionUser(r *http.Request) (any, bool) {
+	c, err := r.Cookie(rm.cfg.Session.CookieName())
+	if err != nil || c.Value == "" {
+		return nil, false
+	}
+	user, err := rm.sessions.ExchangeSessionIDForUser(r.Context(), c.Value)
+	return user, err == nil
+}
+
+// setCookie sets the remember-me cookie. Its Max-Age is the remaining lifetime of the series.
+func (rm *RememberMe[U]) setCookie(w http.ResponseWriter, token RememberToken, verifier string) {
+	cfg := rm.cfg.Remember
+	cfg.TTL = token.ExpiresAt.Sub(rm.now())
+	middlewares.SetSession(w, token.Selector+":"+verifier, cfg)
+}
+
+func (rm *RememberMe[U]) readCookie(r *http.Request) (selector, verifier string, ok bool) {
+	c, err := r.Cookie(rm.cfg.Remember.CookieName())
+	if err != nil {
+		return "", "", false
+	}
+	selector, verifier, ok = strings.Cut(c.Value, ":")
+	return selector, verifier, ok && selector != "" && verifier != ""
+}
+
+// withSessionCookie returns a copy of r whose session cookie is replaced by sessionID.
+func withSessionCookie(r *http.Request, name, sessionID string) *http.Request {
+	cookies := r.Cookies()
+	r = r.Clone(r.Context())
+	r.Header.Del("Cookie")
+	for _, c := range cookies {
+		if c.Name != name {
+			r.AddCookie(c)
+		}
+	}
+	r.AddCookie(&http.Cookie{Name: name, Value: sessionID})
+	return r
+}
+
+func randomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate remember-me token. err %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func hashVerifier(verifier string) []byte {
+	sum := sha256.Sum256([]byte(verifier))
+	return sum[:]
+}
+
+// MemoryRememberStore is an in-memory RememberStore, suitable for development and single
+// instance deployments. Tokens are lost on restart.
+type MemoryRememberStore struct {
+	mu     sync.Mutex
+	tokens map[string]RememberToken
+}
+
+// NewMemoryRememberStore creates an empty MemoryRememberStore.
+func NewMemoryRememberStore() *MemoryRememberStore {
+	return &MemoryRememberStore{tokens: make(map[string]RememberToken)}
+}
+
+func (s *MemoryRememberStore) SaveRememberToken(ctx context.Context, token RememberToken) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokens[token.Selector] = token
+	return nil
+}
+
+func (s *MemoryRememberStore) FindRememberToken(ctx context.Context, selector string) (RememberToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	token, ok := s.tokens[selector]
+	if !ok {
+		return RememberToken{}, ErrRememberTokenNotFound
+	}
+	return token, nil
+}
+
+func (s *MemoryRememberStore) RotateRememberToken(ctx context.Context, token RememberToken, previousHash []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stored, ok := s.tokens[token.Selector]
+	if !ok {
+		return ErrRememberTokenNotFound
+	}
+	if subtle.ConstantTimeCompare(stored.VerifierHash, previousHash) != 1 {
+		return ErrRememberTokenRotated
+	}
+	s.tokens[token.Selector] = token
+	return nil
+}
+
+func (s *MemoryRememberStore) DeleteRememberToken(ctx context.Context, selector string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.tokens, selector)
+	return nil
+}
+
+func (s *MemoryRememberStore) DeleteUserRememberTokens(ctx context.Context, userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for selector, token := range s.tokens {
+		if token.UserID == userID {
+			delete(s.tokens, selector)
+		}
+	}
+	return nil
+}
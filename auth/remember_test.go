@@ -0,0 +1,198 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ancalabrese/gotth/middlewares"
+	"github.com/ancalabrese/gotth/sessionstore/memory"
+)
+
+func TestRememberMe(t *testing.T) {
+	sessions := memory.New()
+	defer sessions.Close()
+	tokens := NewMemoryRememberStore()
+	cfg := DefaultConfig()
+
+	userID := func(u any) string { return u.(string) }
+	lookup := func(ctx context.Context, id string) (any, error) { return id, nil }
+	rm := NewRememberMe[any](tokens, sessions, userID, lookup, cfg)
+	now := time.Now()
+	rm.now = func() time.Time { return now }
+
+	check := func(ctx context.Context, username, password string) (any, error) { return username, nil }
+	login := rm.LoginHandler(check, http.RedirectHandler("/account", http.StatusSeeOther), func(w http.ResponseWriter, r *http.Request, err error) {
+		t.Fatalf("login failed: %v", err)
+	})
+
+	var reported error
+	account := rm.Middleware(func(w http.ResponseWriter, r *http.Request, err error) { reported = err })(
+		middlewares.SessionCheckWithConfig(sessions, cfg.Session, true, func(w http.ResponseWriter, r *http.Request, err error) {
+			w.WriteHeader(http.StatusUnauthorized)
+		})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(middlewares.GetUser(r.Context()).(string)))
+		})))
+
+	visit := func(rememberCookie string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/account", nil)
+		req.AddCookie(&http.Cookie{Name: cfg.Session.CookieName(), Value: "stale-session"})
+		req.AddCookie(&http.Cookie{Name: cfg.Remember.CookieName(), Value: rememberCookie})
+		rr := httptest.NewRecorder()
+		account.ServeHTTP(rr, req)
+		return rr
+	}
+	cookie := func(rr *httptest.ResponseRecorder, name string) *http.Cookie {
+		for _, c := range rr.Result().Cookies() {
+			if c.Name == name {
+				return c
+			}
+		}
+		return nil
+	}
+
+	// Login without the checkbox doesn't remember.
+	req := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader("username=ada&password=pw"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rr := httptest.NewRecorder()
+	login.ServeHTTP(rr, req)
+	if cookie(rr, cfg.Remember.CookieName()) != nil {
+		t.Fatal("remember-me cookie set without the checkbox")
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/login", strings.NewReader("username=ada&password=pw&remember_me=on"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rr = httptest.NewRecorder()
+	login.ServeHTTP(rr, req)
+	first := cookie(rr, cfg.Remember.CookieName())
+	if first == nil || !first.HttpOnly || first.MaxAge != int(DefaultRememberTTL.Seconds()) {
+		t.Fatalf("unexpected remember-me cookie %v", first)
+	}
+
+	// The browser restarted: the session is gone but the remember-me cookie logs the user back in.
+	rr = visit(first.Value)
+	if rr.Code != http.StatusOK || rr.Body.String() != "ada" {
+		t.Fatalf("restore: status = %d, body = %q", rr.Code, rr.Body.String())
+	}
+	if cookie(rr, cfg.Session.CookieName()) == nil {
+		t.Error("restore: session cookie not set")
+	}
+	second := cookie(rr, cfg.Remember.CookieName())
+	if second == nil || second.Value == first.Value {
+		t.Fatalf("restore: remember-me cookie not rotated: %v", second)
+	}
+	if !strings.HasPrefix(second.Value, strings.Split(first.Value, ":")[0]+":") {
+		t.Error("restore: rotation changed the selector")
+	}
+
+	// A concurrent request with the old cookie is accepted during the grace period.
+	rr = visit(first.Value)
+	if rr.Code != http.StatusOK || cookie(rr, cfg.Remember.CookieName()) != nil {
+		t.Fatalf("grace: status = %d, cookies = %v", rr.Code, rr.Result().Cookies())
+	}
+
+	// Later, the old cookie means it was copied: the series is revoked.
+	now = now.Add(2 * rotationGrace)
+	rr = visit(first.Value)
+	if rr.Code != http.StatusUnauthorized || !errors.Is(reported, ErrRememberTokenTheft) {
+		t.Fatalf("theft: status = %d, err = %v", rr.Code, reported)
+	}
+	if rr = visit(second.Value); rr.Code != http.StatusUnauthorized {
+		t.Errorf("theft: revoked series still accepted, status = %d", rr.Code)
+	}
+}
+
+func TestRememberMe_Expiry(t *testing.T) {
+	sessions := memory.New()
+	defer sessions.Close()
+	tokens := NewMemoryRememberStore()
+	rm := NewRememberMe[any](tokens, sessions, func(u any) string { return u.(string) }, func(ctx context.Context, id string) (any, error) { return id, nil }, DefaultConfig())
+	now := time.Now()
+	rm.now = func() time.Time { return now }
+
+	rr := httptest.NewRecorder()
+	if err := rm.Remember(rr, httptest.NewRequest(http.MethodGet, "/", nil), "ada"); err != nil {
+		t.Fatalf("Remember() error = %v", err)
+	}
+	remembered := rr.Result().Cookies()[0]
+
+	now = now.Add(DefaultRememberTTL)
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(remembered)
+	rr = httptest.NewRecorder()
+	var sawSession bool
+	rm.Middleware(func(w http.ResponseWriter, r *http.Request, err error) {
+		t.Errorf("unexpected error %v", err)
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, err := r.Cookie(middlewares.SESSION_COOKIE_NAME)
+		sawSession = err == nil
+	})).ServeHTTP(rr, req)
+
+	if sawSession {
+		t.Error("expired remember-me cookie created a session")
+	}
+	if c := rr.Result().Cookies(); len(c) != 1 || c[0].MaxAge != -1 {
+		t.Errorf("expired remember-me cookie not cleared: %v", c)
+	}
+	if _, err := tokens.FindRememberToken(context.Background(), strings.Split(remembered.Value, ":")[0]); !errors.Is(err, ErrRememberTokenNotFound) {
+		t.Errorf("expired token not deleted: %v", err)
+	}
+}
+
+// staleRememberStore returns the tokens as first found, like a request that read the token
+// before a concurrent one rotated it.
+type staleRememberStore struct {
+	*MemoryRememberStore
+	found map[string]RememberToken
+}
+
+func (s *staleRememberStore) FindRememberToken(ctx context.Context, selector string) (RememberToken, error) {
+	if token, ok := s.found[selector]; ok {
+		return token, nil
+	}
+	token, err := s.MemoryRememberStore.FindRememberToken(ctx, selector)
+	if err == nil {
+		s.found[selector] = token
+	}
+	return token, err
+}
+
+func TestRememberMe_ConcurrentRotation(t *testing.T) {
+	sessions := memory.New()
+	defer sessions.Close()
+	tokens := &staleRememberStore{MemoryRememberStore: NewMemoryRememberStore(), found: map[string]RememberToken{}}
+	rm := NewRememberMe[any](tokens, sessions, func(u any) string { return u.(string) }, func(ctx context.Context, id string) (any, error) { return id, nil }, DefaultConfig())
+
+	rr := httptest.NewRecorder()
+	if err := rm.Remember(rr, httptest.NewRequest(http.MethodGet, "/", nil), "ada"); err != nil {
+		t.Fatalf("Remember() error = %v", err)
+	}
+	remembered := rr.Result().Cookies()[0]
+
+	mw := rm.Middleware(func(w http.ResponseWriter, r *http.Request, err error) {
+		t.Errorf("unexpected error %v", err)
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if middlewares.GetUser(r.Context()) != "ada" {
+			t.Errorf("user = %v, want ada", middlewares.GetUser(r.Context()))
+		}
+	}))
+	var rotated int
+	for range 2 {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.AddCookie(remembered)
+		rr := httptest.NewRecorder()
+		mw.ServeHTTP(rr, req)
+		for _, c := range rr.Result().Cookies() {
+			if c.Name == remembered.Name {
+				rotated++
+			}
+		}
+	}
+	if rotated != 1 {
+		t.Errorf("remember-me cookie rotated %d times, want 1", rotated)
+	}
+}
@@ -0,0 +1,337 @@
+package gotth
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/ancalabrese/gotth/forms"
+)
+
+// ErrInvalidQuery is wrapped by the errors of BindQuery for invalid query parameters.
+var ErrInvalidQuery = errors.New("invalid query")
+
+// QueryError reports the invalid query parameters of a request. It wraps [ErrInvalidQuery].
+type QueryError struct {
+	// Errors has an error per invalid parameter, in the order of the struct fields. Field is the
+	// name of the parameter.
+	Errors []forms.ValidationError
+}
+
+func (e *QueryError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, v := range e.Errors {
+		msgs[i] = v.Field + " " + v.Message
+	}
+	return ErrInvalidQuery.Error() + ": " + strings.Join(msgs, "; ")
+}
+
+func (e *QueryError) Unwrap() error {
+	return ErrInvalidQuery
+}
+
+// FieldErrors returns Errors, answered as the invalid fields of a 400 by problem.Write.
+func (e *QueryError) FieldErrors() []forms.ValidationError {
+	return e.Errors
+}
+
+// Pagination holds the page parameters of a list, to embed in the struct of BindQuery.
+type Pagination struct {
+	Page    int `query:"page" default:"1" validate:"min=1"`
+	PerPage int `query:"per_page" default:"20" validate:"min=1,max=100"`
+}
+
+// Offset returns the number of items before the page.
+func (p Pagination) Offset() int {
+	return (p.Page - 1) * p.PerPage
+}
+
+// BindQuery decodes the query parameters of r into the fields of the struct T, so that list
+// filters, pagination and sort parameters are parsed and validated the same way everywhere:
+//
+//	type postsQuery struct {
+//		gotth.Pagination
+//		Tags  []string `query:"tag"`
+//		Sort  string   `query:"sort" default:"-date" validate:"oneof=date -date title -title"`
+//		Draft *bool    `query:"draft"`
+//	}
+//	q, err := gotth.BindQuery[postsQuery](r)
+//
+// The query tag names the parameter, the lowercase field name by default, or "-" to skip the
+// field. Fields are strings, bools, integers, floats, time.Duration, time.Time (RFC 3339 or
+// 2006-01-02), encoding.TextUnmarshaler, slices of them, filled by the repeated and the comma
+// separated values, or pointers to them, nil when the parameter is missing. Embedded structs
+// are decoded in place. The default tag is the value of missing parameters, and the validate
+// tag holds comma separated rules checked on the values of the request:
+//
+//   - required: the parameter can't be missing or empty.
+//   - min=n, max=n: bounds of numbers, of the length of strings or of the number of items.
+//   - oneof=a b c: allowed values, e.g. of a sort parameter.
+//
+// Invalid parameters keep their default, and the returned *QueryError lists them, e.g. to
+// render the list with an error or answer with a 400. Other errors report a T BindQuery can't
+// decode into, e.g. an unsupported field type or a malformed tag.
+func BindQuery[T any](r *http.Request) (T, error) {
+	var dst T
+	v := reflect.ValueOf(&dst).Elem()
+	if v.Kind() != reflect.Struct {
+		return dst, fmt.Errorf("BindQuery needs a struct, not %s", v.Type())
+	}
+	qe := &QueryError{}
+	if err := bindQuery(v, r.URL.Query(), qe); err != nil {
+		return dst, err
+	}
+	if len(qe.Errors) > 0 {
+		return dst, qe
+	}
+	return dst, nil
+}
+
+func bindQuery(v reflect.Value, query map[string][]string, qe *QueryError) error {
+	for i := range v.NumField() {
+		field := v.Type().Field(i)
+		name, tagged := field.Tag.Lookup("query")
+		if name == "-" || !field.IsExported() {
+			continue
+		}
+		if field.Anonymous && !tagged && field.Type.Kind() == reflect.Struct {
+			if err := bindQuery(v.Field(i), query, qe); err != nil {
+				return err
+			}
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+		rules, err := parseRules(field.Tag.Get("validate"))
+		if err != nil {
+			return fmt.Errorf("%s.%s has an invalid validate tag. err %w", v.Type(), field.Name, err)
+		}
+
+		if def, ok := field.Tag.Lookup("default"); ok {
+			if msg, err := decodeQuery(v.Field(i), []string{def}); err != nil {
+				return fmt.Errorf("%s.%s has an unsupported type. err %w", v.Type(), field.Name, err)
+			} else if msg != "" {
+				return fmt.Errorf("%s.%s has an invalid default %q: it %s", v.Type(), field.Name, def, msg)
+			}
+		}
+		values := slices.DeleteFunc(slices.Clone(query[name]), func(value string) bool {
+			return strings.TrimSpace(value) == ""
+		})
+		if len(values) == 0 {
+			if rules.required {
+				qe.Errors = append(qe.Errors, forms.ValidationError{Field: name, Message: "is required"})
+			}
+			continue
+		}
+
+		// Decoded aside, so that invalid parameters keep the default.
+		decoded := reflect.New(field.Type).Elem()
+		msg, err := decodeQuery(decoded, values)
+		if err != nil {
+			return fmt.Errorf("%s.%s has an unsupported type. err %w", v.Type(), field.Name, err)
+		}
+		if msg == "" {
+			msg = rules.check(decoded)
+		}
+		if msg != "" {
+			qe.Errors = append(qe.Errors, forms.ValidationError{Field: name, Message: msg})
+			continue
+		}
+		v.Field(i).Set(decoded)
+	}
+	return nil
+}
+
+// splitValues splits the comma separated values, dropping the empty ones.
+func splitValues(values []string) []string {
+	var out []string
+	for _, value := range values {
+		for item := range strings.SplitSeq(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				out = append(out, item)
+			}
+		}
+	}
+	return out
+}
+
+var (
+	timeType            = reflect.TypeFor[time.Time]()
+	durationType        = reflect.TypeFor[time.Duration]()
+	textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+)
+
+// decodeQuery sets v from values. It returns the message of invalid values, e.g. "must be a
+// number", or an error when the type of v isn't supported.
+func decodeQuery(v reflect.Value, values []string) (string, error) {
+	switch {
+	case v.Kind() == reflect.Pointer:
+		elem := reflect.New(v.Type().Elem())
+		msg, err := decodeQuery(elem.Elem(), values)
+		if msg == "" && err == nil {
+			v.Set(elem)
+		}
+		return msg, err
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() != reflect.Uint8:
+		values = splitValues(values)
+		items := reflect.MakeSlice(v.Type(), len(values), len(values))
+		for i, value := range values {
+			if msg, err := decodeQuery(items.Index(i), []string{value}); msg != "" || err != nil {
+				return msg, err
+			}
+		}
+		v.Set(items)
+		return "", nil
+	}
+
+	// Scalars take the last value, like a form input repeated in the query.
+	value := strings.TrimSpace(values[len(values)-1])
+	switch {
+	case v.Type() == timeType:
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			if t, err = time.Parse(time.DateOnly, value); err != nil {
+				return "must be a date, e.g. 2006-01-02", nil
+			}
+		}
+		v.Set(reflect.ValueOf(t))
+	case v.Type() == durationType:
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return "must be a duration, e.g. 1h30m", nil
+		}
+		v.SetInt(int64(d))
+	case reflect.PointerTo(v.Type()).Implements(textUnmarshalerType):
+		if err := v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(value)); err != nil {
+			return "is invalid", nil
+		}
+	case v.Kind() == reflect.String:
+		v.SetString(value)
+	case v.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return "must be true or false", nil
+		}
+		v.SetBool(b)
+	case v.CanInt():
+		n, err := strconv.ParseInt(value, 10, v.Type().Bits())
+		if err != nil {
+			return numberMessage(err, "must be an integer"), nil
+		}
+		v.SetInt(n)
+	case v.CanUint():
+		n, err := strconv.ParseUint(value, 10, v.Type().Bits())
+		if err != nil {
+			return numberMessage(err, "must be a positive integer"), nil
+		}
+		v.SetUint(n)
+	case v.CanFloat():
+		f, err := strconv.ParseFloat(value, v.Type().Bits())
+		if err != nil {
+			return numberMessage(err, "must be a number"), nil
+		}
+		v.SetFloat(f)
+	default:
+		return "", fmt.Errorf("unsupported query parameter type %s", v.Type())
+	}
+	return "", nil
+}
+
+func numberMessage(err error, msg string) string {
+	if errors.Is(err, strconv.ErrRange) {
+		return "is out of range"
+	}
+	return msg
+}
+
+// queryRules are the rules of a validate tag.
+type queryRules struct {
+	required bool
+	min, max *float64
+	oneOf    []string
+}
+
+func parseRules(tag string) (queryRules, error) {
+	var rules queryRules
+	for rule := range strings.SplitSeq(tag, ",") {
+		key, arg, _ := strings.Cut(strings.TrimSpace(rule), "=")
+		switch key {
+		case "":
+		case "required":
+			rules.required = true
+		case "min", "max":
+			n, err := strconv.ParseFloat(arg, 64)
+			if err != nil {
+				return rules, fmt.Errorf("%s needs a number, not %q", key, arg)
+			}
+			if key == "min" {
+				rules.min = &n
+			} else {
+				rules.max = &n
+			}
+		case "oneof":
+			rules.oneOf = strings.Fields(arg)
+			if len(rules.oneOf) == 0 {
+				return rules, errors.New("oneof needs values")
+			}
+		default:
+			return rules, fmt.Errorf("unknown rule %q", key)
+		}
+	}
+	return rules, nil
+}
+
+// check returns the message of the first rule v breaks, or "".
+func (rules queryRules) check(v reflect.Value) string {
+	if v.Kind() == reflect.Pointer {
+		v = v.Elem()
+	}
+	// Sizes of the other types, e.g. time.Duration, aren't bounded.
+	size, unit, sized := 0.0, "", true
+	switch {
+	case v.Kind() == reflect.String:
+		size, unit = float64(utf8.RuneCountInString(v.String())), " characters"
+	case v.Kind() == reflect.Slice:
+		size, unit = float64(v.Len()), " values"
+	case v.Type() == durationType:
+		sized = false
+	case v.CanInt():
+		size = float64(v.Int())
+	case v.CanUint():
+		size = float64(v.Uint())
+	case v.CanFloat():
+		size = v.Float()
+	default:
+		sized = false
+	}
+	if sized && rules.min != nil && size < *rules.min {
+		return "must be at least " + strconv.FormatFloat(*rules.min, 'f', -1, 64) + unit
+	}
+	if sized && rules.max != nil && size > *rules.max {
+		return "must be at most " + strconv.FormatFloat(*rules.max, 'f', -1, 64) + unit
+	}
+
+	if len(rules.oneOf) > 0 {
+		values := []reflect.Value{v}
+		if v.Kind() == reflect.Slice {
+			values = values[:0]
+			for i := range v.Len() {
+				values = append(values, v.Index(i))
+			}
+		}
+		for _, item := range values {
+			if !slices.Contains(rules.oneOf, fmt.Sprint(item.Interface())) {
+				return "must be one of " + strings.Join(rules.oneOf, ", ")
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,111 @@
+package gotth_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ancalabrese/gotth"
+	"github.com/ancalabrese/gotth/forms"
+)
+
+type postsQuery struct {
+	gotth.Pagination
+	Search string        `query:"q" validate:"max=20"`
+	Tags   []string      `query:"tag" validate:"max=3"`
+	Sort   string        `query:"sort" default:"-date" validate:"oneof=date -date title -title"`
+	Draft  *bool         `query:"draft"`
+	Since  time.Time     `query:"since"`
+	MaxAge time.Duration `query:"max_age" default:"24h"`
+	Score  float64       `validate:"min=0,max=1"`
+	ignore string
+}
+
+func bindQuery[T any](t *testing.T, query string) (T, error) {
+	t.Helper()
+	return gotth.BindQuery[T](httptest.NewRequest(http.MethodGet, "/posts?"+query, nil))
+}
+
+func TestBindQuery(t *testing.T) {
+	q, err := bindQuery[postsQuery](t, "")
+	if err != nil {
+		t.Fatalf("BindQuery() error = %v", err)
+	}
+	want := postsQuery{Pagination: gotth.Pagination{Page: 1, PerPage: 20}, Sort: "-date", MaxAge: 24 * time.Hour}
+	if !reflect.DeepEqual(q, want) {
+		t.Errorf("BindQuery() = %+v, want the defaults %+v", q, want)
+	}
+
+	q, err = bindQuery[postsQuery](t, "page=3&per_page=10&q=hello,+world&tag=go&tag=templ,htmx&sort=title&draft=false&since=2026-01-02&max_age=1h&score=0.5")
+	if err != nil {
+		t.Fatalf("BindQuery() error = %v", err)
+	}
+	draft := false
+	want = postsQuery{
+		Pagination: gotth.Pagination{Page: 3, PerPage: 10},
+		Search:     "hello, world",
+		Tags:       []string{"go", "templ", "htmx"},
+		Sort:       "title",
+		Draft:      &draft,
+		Since:      time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC),
+		MaxAge:     time.Hour,
+		Score:      0.5,
+	}
+	if !reflect.DeepEqual(q, want) {
+		t.Errorf("BindQuery() = %+v, want %+v", q, want)
+	}
+	if q.Offset() != 20 {
+		t.Errorf("Offset() = %d, want 20", q.Offset())
+	}
+}
+
+func TestBindQuery_Invalid(t *testing.T) {
+	q, err := bindQuery[postsQuery](t, "page=0&per_page=x&tag=a,b,c,d&sort=author&draft=maybe&since=yesterday&score=2")
+	var qe *gotth.QueryError
+	if !errors.As(err, &qe) || !errors.Is(err, gotth.ErrInvalidQuery) {
+		t.Fatalf("BindQuery() error = %v, want a *QueryError", err)
+	}
+	want := []forms.ValidationError{
+		{Field: "page", Message: "must be at least 1"},
+		{Field: "per_page", Message: "must be an integer"},
+		{Field: "tag", Message: "must be at most 3 values"},
+		{Field: "sort", Message: "must be one of date, -date, title, -title"},
+		{Field: "draft", Message: "must be true or false"},
+		{Field: "since", Message: "must be a date, e.g. 2006-01-02"},
+		{Field: "score", Message: "must be at most 1"},
+	}
+	if !reflect.DeepEqual(qe.Errors, want) {
+		t.Errorf("Errors = %+v, want %+v", qe.Errors, want)
+	}
+	// Invalid parameters keep their default.
+	if q.Page != 1 || q.PerPage != 20 || q.Sort != "-date" || q.Draft != nil {
+		t.Errorf("BindQuery() = %+v, want the defaults", q)
+	}
+
+	type required struct {
+		ID int `query:"id" validate:"required"`
+	}
+	if _, err := bindQuery[required](t, "id="); !errors.Is(err, gotth.ErrInvalidQuery) {
+		t.Errorf("BindQuery() without a required parameter error = %v, want %v", err, gotth.ErrInvalidQuery)
+	}
+
+	// Structs BindQuery can't decode into aren't invalid queries.
+	type unsupported struct {
+		Filter map[string]string
+	}
+	type badTag struct {
+		Page int `validate:"positive"`
+	}
+	for name, err := range map[string]error{
+		"Unsupported type": func() error { _, err := bindQuery[unsupported](t, "filter=a"); return err }(),
+		"Unknown rule":     func() error { _, err := bindQuery[badTag](t, ""); return err }(),
+		"Not a struct":     func() error { _, err := bindQuery[int](t, ""); return err }(),
+	} {
+		if err == nil || errors.Is(err, gotth.ErrInvalidQuery) {
+			t.Errorf("%s: error = %v, want a non-query error", name, err)
+		}
+	}
+}
@@ -0,0 +1,470 @@
+// Package blog is a batteries-included blog on top of gotth: it loads Markdown posts with front
+// matter from an fs.FS and serves the paginated listing, the tag pages, the posts and their RSS
+// feed, with the head of every page filled from the front matter.
+//
+//	//go:embed posts
+//	var posts embed.FS
+//
+//	b, err := blog.New(blog.Config{FS: posts, Dir: "posts", BaseURL: "https://example.com", Title: "Notes"})
+//	...
+//	blog.Serve(ws, b) // /blog, /blog/tags/{tag}, /blog/{slug} and /blog/feed.xml
+//
+// A post is a .md file, its name being the slug, e.g. posts/hello-world.md:
+//
+//	---
+//	title: Hello, world
+//	description: The first post.
+//	date: 2025-06-01
+//	tags: [go, htmx]
+//	---
+//	Markdown content...
+package blog
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"iter"
+	"net/http"
+	"net/url"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/a-h/templ"
+	"github.com/ancalabrese/gotth/content"
+	"github.com/ancalabrese/gotth/preview"
+	"github.com/ancalabrese/gotth/search"
+	"github.com/ancalabrese/gotth/sitemap"
+	"github.com/ancalabrese/gotth/views/components/head"
+)
+
+// DefaultPath is the path of the blog when Config.Path is empty.
+const DefaultPath = "/blog"
+
+// Config configures a [Blog].
+type Config struct {
+	// FS holds the posts, e.g. an embed.FS.
+	FS fs.FS
+	// Optional: directory of the posts in FS, searched recursively. Defaults to the root.
+	Dir string
+	// Optional: path the blog is served under. Defaults to [DefaultPath].
+	Path string
+	// BaseURL of the site, e.g. "https://example.com", for the canonical URLs and the feed.
+	BaseURL string
+	// Title and Description of the listing and the feed.
+	Title       string
+	Description string
+	// Optional: author of the posts without one in their front matter.
+	Author string
+	// PageSize is the number of posts per listing page. Defaults to 10.
+	PageSize int
+	// Optional: renders the posts. Defaults to content.NewMarkdown().
+	Markdown *content.Markdown
+	// Drafts serves the posts marked as drafts too, e.g. in DevMode. They're never in the feed.
+	// Without it, drafts are only served to the requests of a preview, see the preview package.
+	Drafts bool
+	// Reload reads the posts again when their files change, e.g. in DevMode with an os.DirFS.
+	// See content.CollectionConfig.Reload.
+	Reload bool
+	// Optional: head options of every page, e.g. the site name and stylesheets.
+	Head []head.Option
+	// Optional: ListView and PostView replace the default views, rendered in the layout of the
+	// WebServer.
+	ListView func(ListPage) templ.Component
+	PostView func(*Post) templ.Component
+}
+
+// Post is a blog post.
+type Post struct {
+	// Slug is the name of the file without extension, unless set in the front matter.
+	Slug        string         `yaml:"slug" toml:"slug"`
+	Title       string         `yaml:"title" toml:"title" content:"required"`
+	Description string         `yaml:"description" toml:"description"`
+	Date        time.Time      `yaml:"date" toml:"date" content:"required"`
+	Updated     time.Time      `yaml:"updated" toml:"updated"`
+	Author      string         `yaml:"author" toml:"author"`
+	Tags        []string       `yaml:"tags" toml:"tags"`
+	Image       string         `yaml:"image" toml:"image"` // Social sharing image, absolute or relative to BaseURL
+	Draft       bool           `yaml:"draft" toml:"draft"`
+	Params      map[string]any `yaml:"params" toml:"params"` // Other values of the front matter
+
+	// URL is the path of the post, e.g. "/blog/hello-world".
+	URL string `yaml:"-" toml:"-"`
+	// Content is the rendered Markdown.
+	Content content.Document `yaml:"-" toml:"-"`
+	// File is the path of the post in Config.FS.
+	File string `yaml:"-" toml:"-"`
+
+	blogPath string
+}
+
+// TagURL returns the path of the page of tag, e.g. "/blog/tags/go".
+func (p *Post) TagURL(tag string) string {
+	return p.blogPath + "/tags/" + url.PathEscape(tag)
+}
+
+// ErrNotFound is wrapped by the errors of the providers for unknown posts, tags and pages. It's
+// content.ErrNotFound, which gotth answers with 404 Not Found.
+var ErrNotFound = content.ErrNotFound
+
+// Blog serves the posts of a Config.
+type Blog struct {
+	cfg   Config
+	posts *content.Collection[Post]
+	index atomic.Pointer[snapshot]
+}
+
+// snapshot is a load of the collection: the index of the published posts, and of all the posts
+// for the previews.
+type snapshot struct {
+	published *index
+	all       *index
+}
+
+// index is a set of posts.
+type index struct {
+	posts  []*Post // Newest first
+	bySlug map[string]*Post
+	byTag  map[string][]*Post
+}
+
+// New loads the posts of cfg. Posts without a title or a date, with invalid front matter or a
+// duplicate slug, drafts included, are errors.
+func New(cfg Config) (*Blog, error) {
+	if cfg.FS == nil {
+		return nil, errors.New("blog: Config.FS is required")
+	}
+	cfg.Dir = cmp.Or(cfg.Dir, ".")
+	cfg.Path = "/" + strings.Trim(cmp.Or(cfg.Path, DefaultPath), "/")
+	cfg.BaseURL = strings.TrimSuffix(cfg.BaseURL, "/")
+	if cfg.PageSize <= 0 {
+		cfg.PageSize = 10
+	}
+	if cfg.Markdown == nil {
+		cfg.Markdown = content.NewMarkdown()
+	}
+
+	b := &Blog{cfg: cfg}
+	posts, err := content.NewCollection(content.CollectionConfig[Post]{
+		FS:       cfg.FS,
+		Dir:      cfg.Dir,
+		Markdown: cfg.Markdown,
+		OnLoad:   b.load,
+		Reload:   cfg.Reload,
+	})
+	if err != nil {
+		return nil, err
+	}
+	b.posts = posts
+	return b, nil
+}
+
+// load indexes the posts of entries.
+func (b *Blog) load(entries []*content.Entry[Post]) error {
+	var all, published []*Post
+	bySlug := map[string]*Post{}
+	for _, e := range entries {
+		p := &e.Data
+		p.Slug = cmp.Or(p.Slug, path.Base(e.ID))
+		if other, ok := bySlug[p.Slug]; ok {
+			return fmt.Errorf("posts %s and %s have the same slug %q", other.File, e.File, p.Slug)
+		}
+		p.Author = cmp.Or(p.Author, b.cfg.Author)
+		p.URL = b.cfg.Path + "/" + p.Slug
+		p.blogPath = b.cfg.Path
+		p.File = e.File
+		p.Content = e.Content
+		bySlug[p.Slug] = p
+		all = append(all, p)
+		if !p.Draft {
+			published = append(published, p)
+		}
+	}
+
+	snap := &snapshot{published: newIndex(published), all: newIndex(all)}
+	if b.cfg.Drafts {
+		snap.published = snap.all
+	}
+	b.index.Store(snap)
+	return nil
+}
+
+func newIndex(posts []*Post) *index {
+	idx := &index{posts: posts, bySlug: map[string]*Post{}, byTag: map[string][]*Post{}}
+	slices.SortStableFunc(idx.posts, func(a, b *Post) int {
+		return cmp.Or(b.Date.Compare(a.Date), strings.Compare(a.Slug, b.Slug))
+	})
+	for _, p := range idx.posts {
+		idx.bySlug[p.Slug] = p
+		for _, tag := range p.Tags {
+			idx.byTag[tag] = append(idx.byTag[tag], p)
+		}
+	}
+	return idx
+}
+
+// current returns the index of the served posts, reloaded first when Config.Reload is set and
+// files changed.
+func (b *Blog) current() *index {
+	b.posts.Refresh()
+	return b.index.Load().published
+}
+
+// currentFor returns the index of the posts served to the request of ctx: all the posts,
+// drafts included, in a preview.
+func (b *Blog) currentFor(ctx context.Context) *index {
+	if !preview.Enabled(ctx) {
+		return b.current()
+	}
+	b.posts.Refresh()
+	return b.index.Load().all
+}
+
+// Path returns the path the blog is served under, e.g. "/blog".
+func (b *Blog) Path() string {
+	return b.cfg.Path
+}
+
+// Title returns the title of the blog.
+func (b *Blog) Title() string {
+	return b.cfg.Title
+}
+
+// Posts returns the posts, the newest first.
+func (b *Blog) Posts() []*Post {
+	return slices.Clone(b.current().posts)
+}
+
+// Post returns the post of slug.
+func (b *Blog) Post(slug string) (*Post, bool) {
+	p, ok := b.current().bySlug[slug]
+	return p, ok
+}
+
+// Tag is a tag of the posts.
+type Tag struct {
+	Name  string
+	Count int
+	URL   string
+}
+
+// Tags returns the tags of the posts, sorted by name.
+func (b *Blog) Tags() []Tag {
+	return b.tags(b.current())
+}
+
+func (b *Blog) tags(idx *index) []Tag {
+	byTag := idx.byTag
+	tags := make([]Tag, 0, len(byTag))
+	for name, posts := range byTag {
+		tags = append(tags, Tag{Name: name, Count: len(posts), URL: b.tagURL(name)})
+	}
+	slices.SortFunc(tags, func(a, b Tag) int { return strings.Compare(a.Name, b.Name) })
+	return tags
+}
+
+func (b *Blog) tagURL(tag string) string {
+	return b.cfg.Path + "/tags/" + url.PathEscape(tag)
+}
+
+// ListPage is a page of the listing of the posts, or of the posts of a tag.
+type ListPage struct {
+	Title string
+	// Tag is the tag of the posts, or "" for the listing of all the posts.
+	Tag   string
+	Posts []*Post
+	// URL is the path of the page, e.g. "/blog/tags/go?page=2".
+	URL string
+	// Page is the number of the page, from 1, of Pages.
+	Page  int
+	Pages int
+	// PrevURL and NextURL link the previous and next pages, "" on the first and last ones.
+	PrevURL string
+	NextURL string
+	// Tags are all the tags of the blog.
+	Tags []Tag
+}
+
+// List returns the page of the posts of tag, or of all the posts when tag is "". ok is false
+// for unknown tags and pages out of range.
+func (b *Blog) List(tag string, page int) (lp ListPage, ok bool) {
+	return b.list(b.current(), tag, page)
+}
+
+func (b *Blog) list(idx *index, tag string, page int) (lp ListPage, ok bool) {
+	posts, base, title := idx.posts, b.cfg.Path, b.cfg.Title
+	if tag != "" {
+		if posts, ok = idx.byTag[tag]; !ok {
+			return ListPage{}, false
+		}
+		base, title = b.tagURL(tag), "Posts tagged "+tag
+	}
+	pages := max(1, (len(posts)+b.cfg.PageSize-1)/b.cfg.PageSize)
+	if page < 1 || page > pages {
+		return ListPage{}, false
+	}
+
+	lp = ListPage{
+		Title: title,
+		Tag:   tag,
+		Posts: posts[(page-1)*b.cfg.PageSize : min(page*b.cfg.PageSize, len(posts))],
+		URL:   pageURL(base, page),
+		Page:  page,
+		Pages: pages,
+		Tags:  b.tags(idx),
+	}
+	if page > 1 {
+		lp.PrevURL = pageURL(base, page-1)
+	}
+	if page < pages {
+		lp.NextURL = pageURL(base, page+1)
+	}
+	return lp, true
+}
+
+func pageURL(base string, page int) string {
+	if page == 1 {
+		return base
+	}
+	return base + "?page=" + strconv.Itoa(page)
+}
+
+// ListProvider is the content provider of the listing, and of the tag pages with the "tag"
+// path value. The page number is in the "page" query parameter. Previews list the drafts too.
+func (b *Blog) ListProvider(r *http.Request) (head.HeadViewModel, templ.Component, error) {
+	page := 1
+	if v := r.URL.Query().Get("page"); v != "" {
+		var err error
+		if page, err = strconv.Atoi(v); err != nil {
+			return head.HeadViewModel{}, nil, fmt.Errorf("invalid page %q. err %w", v, ErrNotFound)
+		}
+	}
+	tag := r.PathValue("tag")
+	lp, ok := b.list(b.currentFor(r.Context()), tag, page)
+	if !ok {
+		return head.HeadViewModel{}, nil, fmt.Errorf("no page %d of tag %q. err %w", page, tag, ErrNotFound)
+	}
+
+	description := b.cfg.Description
+	if tag != "" {
+		description = lp.Title + "."
+	}
+	title := lp.Title
+	if page > 1 {
+		title += " (page " + strconv.Itoa(page) + ")"
+	}
+	opts := append(slices.Clone(b.cfg.Head),
+		head.WithPageCoreMetadata(title, description, b.cfg.BaseURL+lp.URL),
+	)
+
+	view := b.cfg.ListView
+	if view == nil {
+		view = ListView
+	}
+	return head.NewHeadViewModel(opts...), view(lp), nil
+}
+
+// PostProvider is the content provider of the post of the "slug" path value. The head gets
+// the metadata of the front matter and the BlogPosting JSON-LD of the post. Drafts are served to
+// previews only.
+func (b *Blog) PostProvider(r *http.Request) (head.HeadViewModel, templ.Component, error) {
+	slug := r.PathValue("slug")
+	p, ok := b.currentFor(r.Context()).bySlug[slug]
+	if !ok {
+		return head.HeadViewModel{}, nil, fmt.Errorf("no post %q. err %w", slug, ErrNotFound)
+	}
+
+	canonical := b.cfg.BaseURL + p.URL
+	image := b.absolute(p.Image)
+	opts := append(slices.Clone(b.cfg.Head),
+		head.WithPageCoreMetadata(p.Title, p.Description, canonical),
+		head.WithAuthor(p.Author),
+		head.WithKeywords(p.Tags),
+		head.WithSchemaImageURL(image),
+		head.WithOpenGraph("article", "", canonical, p.Title, p.Description, image, "", "", p.Title),
+		head.WithJSONLD(b.postingJSONLD(p, canonical, image)),
+	)
+
+	view := b.cfg.PostView
+	if view == nil {
+		view = PostView
+	}
+	return head.NewHeadViewModel(opts...), view(p), nil
+}
+
+// absolute resolves a URL relative to the site against the BaseURL.
+func (b *Blog) absolute(u string) string {
+	if u == "" || strings.Contains(u, "://") {
+		return u
+	}
+	return b.cfg.BaseURL + "/" + strings.TrimPrefix(u, "/")
+}
+
+func (b *Blog) postingJSONLD(p *Post, canonical, image string) head.JSONLDNode {
+	props := map[string]any{
+		"headline":         p.Title,
+		"datePublished":    p.Date.Format(time.RFC3339),
+		"mainEntityOfPage": canonical,
+	}
+	if p.Description != "" {
+		props["description"] = p.Description
+	}
+	if !p.Updated.IsZero() {
+		props["dateModified"] = p.Updated.Format(time.RFC3339)
+	}
+	if p.Author != "" {
+		props["author"] = map[string]any{"@type": "Person", "name": p.Author}
+	}
+	if image != "" {
+		props["image"] = image
+	}
+	if len(p.Tags) > 0 {
+		props["keywords"] = strings.Join(p.Tags, ", ")
+	}
+	return head.JSONLDNode{Context: "https://schema.org", Type: "BlogPosting", Properties: props}
+}
+
+// SearchSource returns the search.Source of the posts, for the site search. Drafts are left
+// out.
+func (b *Blog) SearchSource() search.Source {
+	return func() ([]search.Document, error) {
+		var docs []search.Document
+		for _, p := range b.Posts() {
+			if !p.Draft {
+				docs = append(docs, search.Document{URL: p.URL, Title: p.Title, Text: p.Description + " " + p.Content.HTML})
+			}
+		}
+		return docs, nil
+	}
+}
+
+// SitemapSource returns the sitemap.Source of the listing and the posts, last modified when
+// updated or published. Drafts are left out.
+func (b *Blog) SitemapSource() sitemap.Source {
+	return func(ctx context.Context) iter.Seq2[sitemap.URL, error] {
+		return func(yield func(sitemap.URL, error) bool) {
+			posts := b.Posts()
+			listing := sitemap.URL{Loc: b.cfg.Path}
+			for _, p := range posts {
+				if !p.Draft {
+					listing.LastMod = p.Date
+					break
+				}
+			}
+			if !yield(listing, nil) {
+				return
+			}
+			for _, p := range posts {
+				if !p.Draft && !yield(sitemap.URL{Loc: p.URL, LastMod: cmp.Or(p.Updated, p.Date)}, nil) {
+					return
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,204 @@
+package blog_test
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/ancalabrese/gotth/blog"
+	"github.com/ancalabrese/gotth/preview"
+)
+
+func newBlog(t *testing.T, cfg blog.Config) *blog.Blog {
+	t.Helper()
+	if cfg.FS == nil {
+		cfg.FS = os.DirFS("testdata")
+		cfg.Dir = "posts"
+	}
+	cfg.BaseURL = "https://example.com"
+	cfg.Title = "Notes"
+	b, err := blog.New(cfg)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	return b
+}
+
+func TestNew(t *testing.T) {
+	b := newBlog(t, blog.Config{})
+	var slugs []string
+	for _, p := range b.Posts() {
+		slugs = append(slugs, p.Slug)
+	}
+	if got, want := strings.Join(slugs, ","), "templ-tips,hello-world,older-post"; got != want {
+		t.Errorf("slugs = %s, want %s", got, want)
+	}
+
+	p, ok := b.Post("templ-tips")
+	if !ok {
+		t.Fatal("Post(templ-tips) not found")
+	}
+	if p.Author != "Ada" || p.URL != "/blog/templ-tips" || !strings.Contains(p.Content.HTML, "<code>templ generate</code>") {
+		t.Errorf("Post(templ-tips) = %+v", p)
+	}
+
+	drafts := newBlog(t, blog.Config{Drafts: true, Path: "/notes/"})
+	if p, ok := drafts.Post("upcoming"); !ok || p.URL != "/notes/upcoming" {
+		t.Errorf("Post(upcoming) with drafts = %+v, %v", p, ok)
+	}
+}
+
+func TestNew_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		fs   fstest.MapFS
+	}{
+		{name: "No title", fs: fstest.MapFS{"a.md": {Data: []byte("---\ndate: 2025-01-01\n---\n")}}},
+		{name: "Invalid front matter", fs: fstest.MapFS{"a.md": {Data: []byte("---\ntitle: [\n---\n")}}},
+		{name: "Duplicate slug", fs: fstest.MapFS{
+			"a.md":   {Data: []byte("---\ntitle: A\ndate: 2025-01-01\n---\n")},
+			"x/a.md": {Data: []byte("---\ntitle: A again\ndate: 2025-01-02\n---\n")},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := blog.New(blog.Config{FS: tt.fs}); err == nil {
+				t.Error("New() error = nil")
+			}
+		})
+	}
+}
+
+func TestBlog_List(t *testing.T) {
+	b := newBlog(t, blog.Config{PageSize: 2})
+	tests := []struct {
+		name      string
+		tag       string
+		page      int
+		wantOK    bool
+		wantPosts int
+		wantURL   string
+		wantPrev  string
+		wantNext  string
+	}{
+		{name: "First page", page: 1, wantOK: true, wantPosts: 2, wantURL: "/blog", wantNext: "/blog?page=2"},
+		{name: "Last page", page: 2, wantOK: true, wantPosts: 1, wantURL: "/blog?page=2", wantPrev: "/blog"},
+		{name: "Out of range", page: 3},
+		{name: "Tag", tag: "htmx", page: 1, wantOK: true, wantPosts: 2, wantURL: "/blog/tags/htmx"},
+		{name: "Unknown tag", tag: "rust", page: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lp, ok := b.List(tt.tag, tt.page)
+			if ok != tt.wantOK {
+				t.Fatalf("List() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if len(lp.Posts) != tt.wantPosts || lp.URL != tt.wantURL || lp.PrevURL != tt.wantPrev || lp.NextURL != tt.wantNext {
+				t.Errorf("List() = %d posts, URL %q, prev %q, next %q, want %d, %q, %q, %q",
+					len(lp.Posts), lp.URL, lp.PrevURL, lp.NextURL, tt.wantPosts, tt.wantURL, tt.wantPrev, tt.wantNext)
+			}
+		})
+	}
+}
+
+func TestBlog_PostProvider(t *testing.T) {
+	b := newBlog(t, blog.Config{})
+	req := httptest.NewRequest(http.MethodGet, "/blog/hello-world", nil)
+	req.SetPathValue("slug", "hello-world")
+	vm, _, err := b.PostProvider(req)
+	if err != nil {
+		t.Fatalf("PostProvider() error = %v", err)
+	}
+	if vm.Metadata.Title != "Hello, world" || vm.Metadata.URL != "https://example.com/blog/hello-world" ||
+		vm.OgType != "article" || vm.Metadata.OgImage != "https://example.com/static/hello.png" {
+		t.Errorf("head = %+v", vm)
+	}
+	if !strings.Contains(vm.PreparedJSONLD, `"@type":"BlogPosting"`) {
+		t.Errorf("JSON-LD = %s, want a BlogPosting", vm.PreparedJSONLD)
+	}
+
+	req.SetPathValue("slug", "missing")
+	if _, _, err := b.PostProvider(req); err == nil || !strings.Contains(err.Error(), "missing") {
+		t.Errorf("PostProvider() error = %v, want not found", err)
+	}
+}
+
+func TestBlog_Preview(t *testing.T) {
+	b := newBlog(t, blog.Config{})
+	if _, ok := b.Post("upcoming"); ok {
+		t.Error("Post(upcoming) found without drafts")
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/blog/upcoming", nil)
+	req.SetPathValue("slug", "upcoming")
+	if _, _, err := b.PostProvider(req); !errors.Is(err, blog.ErrNotFound) {
+		t.Errorf("PostProvider() error = %v, want %v", err, blog.ErrNotFound)
+	}
+	req = req.WithContext(preview.WithEnabled(req.Context()))
+	if vm, _, err := b.PostProvider(req); err != nil || vm.Metadata.URL != "https://example.com/blog/upcoming" {
+		t.Errorf("PostProvider() in preview = %+v, %v", vm.Metadata, err)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/blog", nil)
+	req = req.WithContext(preview.WithEnabled(req.Context()))
+	var out strings.Builder
+	_, view, err := b.ListProvider(req)
+	if err != nil {
+		t.Fatalf("ListProvider() in preview error = %v", err)
+	}
+	view.Render(req.Context(), &out)
+	if !strings.Contains(out.String(), `href="/blog/upcoming"`) {
+		t.Errorf("listing in preview misses the draft:\n%s", out.String())
+	}
+}
+
+func TestBlog_Feed(t *testing.T) {
+	b := newBlog(t, blog.Config{Drafts: true})
+	out, err := b.Feed()
+	if err != nil {
+		t.Fatalf("Feed() error = %v", err)
+	}
+	var feed struct {
+		Channel struct {
+			Link  string `xml:"link"`
+			Items []struct {
+				Title   string `xml:"title"`
+				Link    string `xml:"link"`
+				Content string `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+			} `xml:"item"`
+		} `xml:"channel"`
+	}
+	if err := xml.Unmarshal(out, &feed); err != nil {
+		t.Fatalf("invalid feed: %v\n%s", err, out)
+	}
+	if len(feed.Channel.Items) != 3 {
+		t.Fatalf("items = %d, want 3 without the draft", len(feed.Channel.Items))
+	}
+	first := feed.Channel.Items[0]
+	if first.Title != "Templ tips" || first.Link != "https://example.com/blog/templ-tips" || !strings.Contains(first.Content, "<code>") {
+		t.Errorf("first item = %+v", first)
+	}
+}
+
+func TestBlog_SitemapSource(t *testing.T) {
+	b := newBlog(t, blog.Config{})
+	var locs []string
+	for u, err := range b.SitemapSource()(context.Background()) {
+		if err != nil {
+			t.Fatalf("SitemapSource() error = %v", err)
+		}
+		locs = append(locs, u.Loc+" "+u.LastMod.Format(time.DateOnly))
+	}
+	// The listing was last modified by the newest post. No drafts.
+	want := "/blog 2025-06-10,/blog/templ-tips 2025-06-10,/blog/hello-world 2025-06-01,/blog/older-post 2024-12-24"
+	if got := strings.Join(locs, ","); got != want {
+		t.Errorf("URLs = %s, want %s", got, want)
+	}
+}
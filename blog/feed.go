@@ -0,0 +1,107 @@
+package blog
+
+import (
+	"encoding/xml"
+	"net/http"
+	"time"
+)
+
+// FeedSize is the number of posts in the feed.
+const FeedSize = 20
+
+type rss struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Atom    string     `xml:"xmlns:atom,attr"`
+	Content string     `xml:"xmlns:content,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	Self          atomLink  `xml:"atom:link"`
+	LastBuildDate string    `xml:"lastBuildDate,omitempty"`
+	Items         []rssItem `xml:"item"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+	Type string `xml:"type,attr"`
+}
+
+type rssItem struct {
+	Title       string   `xml:"title"`
+	Link        string   `xml:"link"`
+	GUID        string   `xml:"guid"`
+	PubDate     string   `xml:"pubDate"`
+	Categories  []string `xml:"category"`
+	Description string   `xml:"description,omitempty"`
+	Content     cdata    `xml:"content:encoded"`
+}
+
+type cdata struct {
+	Text string `xml:",cdata"`
+}
+
+// FeedURL returns the path of the RSS feed, e.g. "/blog/feed.xml".
+func (b *Blog) FeedURL() string {
+	return b.cfg.Path + "/feed.xml"
+}
+
+// Feed returns the RSS 2.0 feed of the last FeedSize published posts, with their full content.
+func (b *Blog) Feed() ([]byte, error) {
+	channel := rssChannel{
+		Title:       b.cfg.Title,
+		Link:        b.cfg.BaseURL + b.cfg.Path,
+		Description: b.cfg.Description,
+		Self:        atomLink{Href: b.cfg.BaseURL + b.FeedURL(), Rel: "self", Type: "application/rss+xml"},
+	}
+	for _, p := range b.Posts() {
+		if p.Draft {
+			continue
+		}
+		if len(channel.Items) == FeedSize {
+			break
+		}
+		link := b.cfg.BaseURL + p.URL
+		channel.Items = append(channel.Items, rssItem{
+			Title:       p.Title,
+			Link:        link,
+			GUID:        link,
+			PubDate:     p.Date.Format(time.RFC1123Z),
+			Categories:  p.Tags,
+			Description: p.Description,
+			Content:     cdata{Text: p.Content.HTML},
+		})
+		if channel.LastBuildDate == "" {
+			channel.LastBuildDate = p.Date.Format(time.RFC1123Z)
+		}
+	}
+
+	out, err := xml.MarshalIndent(rss{
+		Version: "2.0",
+		Atom:    "http://www.w3.org/2005/Atom",
+		Content: "http://purl.org/rss/1.0/modules/content/",
+		Channel: channel,
+	}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), out...), nil
+}
+
+// FeedHandler serves the RSS feed.
+func (b *Blog) FeedHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		feed, err := b.Feed()
+		if err != nil {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/rss+xml; charset=utf-8")
+		w.Write(feed)
+	})
+}
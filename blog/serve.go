@@ -0,0 +1,31 @@
+package blog
+
+import (
+	"net/http"
+
+	"github.com/ancalabrese/gotth"
+	"github.com/ancalabrese/gotth/routes"
+)
+
+// Serve serves the pages of b on ws under its path, e.g. /blog: the listing, the tag pages at
+// /blog/tags/{tag}, the posts at /blog/{slug} and the RSS feed at /blog/feed.xml. The listing
+// and the posts are named "blog" and "blog.post" for the breadcrumbs.
+func Serve(ws *gotth.WebServer, b *Blog) {
+	if b == nil {
+		ws.InvalidRoute("", "blog.Serve needs a blog")
+		return
+	}
+	path := b.Path()
+	ws.ServeContent("GET "+path, b.ListProvider)
+	ws.ServeContent("GET "+path+"/tags/{tag}", b.ListProvider)
+	ws.ServeContent("GET "+path+"/{slug}", b.PostProvider)
+	ws.Handle("GET "+b.FeedURL(), b.FeedHandler())
+
+	ws.SetRouteMeta("GET "+path, routes.Meta{Name: "blog", Title: b.Title()})
+	ws.SetRouteMeta("GET "+path+"/{slug}", routes.Meta{Name: "blog.post", TitleFunc: func(r *http.Request) string {
+		if p, ok := b.Post(r.PathValue("slug")); ok {
+			return p.Title
+		}
+		return r.PathValue("slug")
+	}})
+}
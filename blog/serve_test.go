@@ -0,0 +1,63 @@
+package blog_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/ancalabrese/gotth"
+	"github.com/ancalabrese/gotth/blog"
+)
+
+func TestServe(t *testing.T) {
+	b, err := blog.New(blog.Config{
+		FS: fstest.MapFS{
+			"hello.md": {Data: []byte("---\ntitle: Hello\ndate: 2025-06-01\ntags: [go]\n---\nHi **there**.\n")},
+		},
+		BaseURL: "https://example.com",
+		Title:   "Notes",
+	})
+	if err != nil {
+		t.Fatalf("blog.New() error = %v", err)
+	}
+	ws, err := gotth.New(gotth.WebServerConfig{}, nil)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	blog.Serve(ws, b)
+	if err := ws.Err(); err != nil {
+		t.Fatalf("Err() = %v", err)
+	}
+
+	tests := []struct {
+		path        string
+		wantStatus  int
+		wantType    string
+		wantContain string
+	}{
+		{path: "/blog", wantStatus: http.StatusOK, wantType: "text/html", wantContain: `href="/blog/hello"`},
+		{path: "/blog/tags/go", wantStatus: http.StatusOK, wantType: "text/html", wantContain: "Posts tagged go"},
+		{path: "/blog/hello", wantStatus: http.StatusOK, wantType: "text/html", wantContain: "<strong>there</strong>"},
+		{path: "/blog/feed.xml", wantStatus: http.StatusOK, wantType: "application/rss+xml", wantContain: "<title>Hello</title>"},
+		{path: "/blog/missing", wantStatus: http.StatusNotFound},
+		{path: "/blog/tags/rust", wantStatus: http.StatusNotFound},
+		{path: "/blog?page=2", wantStatus: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			ws.Handler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rr.Code, tt.wantStatus)
+			}
+			if !strings.HasPrefix(rr.Header().Get("Content-Type"), tt.wantType) && tt.wantType != "" {
+				t.Errorf("Content-Type = %q, want %q", rr.Header().Get("Content-Type"), tt.wantType)
+			}
+			if !strings.Contains(rr.Body.String(), tt.wantContain) {
+				t.Errorf("body is missing %s:\n%s", tt.wantContain, rr.Body.String())
+			}
+		})
+	}
+}
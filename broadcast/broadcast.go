@@ -0,0 +1,201 @@
+// Package broadcast fans out messages published to topics to all the subscribed SSE and
+// WebSocket clients:
+//
+//	hub := broadcast.New()
+//	ws.ServeSSE("GET /events/scores", hub.SSESource("scores"))
+//
+//	// Anywhere in the application:
+//	hub.PublishFragment(ctx, "scores", "score", views.Score(match))
+//
+// Publishing never blocks on slow clients: a subscriber whose buffer is full is evicted and its
+// connection closed, and the browser reconnects to catch up with the current state.
+package broadcast
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/a-h/templ"
+	"github.com/ancalabrese/gotth/sse"
+	"github.com/ancalabrese/gotth/websocket"
+)
+
+const DefaultSubscriberBuffer = 32
+
+// Message is a message published to a topic.
+type Message struct {
+	Topic string
+	// Name is the SSE event name, used by the htmx SSE extension to pick the element to swap.
+	Name string
+	Data string
+}
+
+// Subscription receives the messages published to its topics until it's closed or evicted.
+type Subscription struct {
+	hub    *Hub
+	topics []string
+	c      chan Message
+	done   chan struct{}
+	once   sync.Once
+}
+
+// C returns the channel delivering the messages.
+func (s *Subscription) C() <-chan Message {
+	return s.c
+}
+
+// Done returns a channel that's closed once the subscription is closed or evicted because it
+// didn't keep up with the published messages.
+func (s *Subscription) Done() <-chan struct{} {
+	return s.done
+}
+
+// Close unsubscribes from all the topics.
+func (s *Subscription) Close() {
+	s.hub.remove(s)
+}
+
+// Option configures a Hub.
+type Option func(*Hub)
+
+// WithSubscriberBuffer sets the number of messages buffered per subscriber before it's evicted.
+// Defaults to [DefaultSubscriberBuffer].
+func WithSubscriberBuffer(n int) Option {
+	return func(h *Hub) {
+		if n > 0 {
+			h.buffer = n
+		}
+	}
+}
+
+// Hub is a publish/subscribe hub. It's safe for concurrent use.
+type Hub struct {
+	buffer int
+
+	mu     sync.RWMutex
+	topics map[string]map[*Subscription]struct{}
+}
+
+// New returns an empty Hub.
+func New(opts ...Option) *Hub {
+	h := &Hub{buffer: DefaultSubscriberBuffer, topics: map[string]map[*Subscription]struct{}{}}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// Subscribe returns a subscription to topics. Close it when the client goes away.
+func (h *Hub) Subscribe(topics ...string) *Subscription {
+	s := &Subscription{hub: h, topics: topics, c: make(chan Message, h.buffer), done: make(chan struct{})}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, t := range topics {
+		if h.topics[t] == nil {
+			h.topics[t] = map[*Subscription]struct{}{}
+		}
+		h.topics[t][s] = struct{}{}
+	}
+	return s
+}
+
+// Subscribers returns the number of subscribers to topic.
+func (h *Hub) Subscribers(topic string) int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.topics[topic])
+}
+
+// Publish sends msg to the subscribers of msg.Topic and returns how many received it.
+// Subscribers whose buffer is full are evicted.
+func (h *Hub) Publish(msg Message) int {
+	var sent int
+	var slow []*Subscription
+
+	h.mu.RLock()
+	for s := range h.topics[msg.Topic] {
+		select {
+		case s.c <- msg:
+			sent++
+		default:
+			slow = append(slow, s)
+		}
+	}
+	h.mu.RUnlock()
+
+	for _, s := range slow {
+		h.remove(s)
+	}
+	return sent
+}
+
+// PublishFragment renders component once and publishes it to topic as an event named name.
+func (h *Hub) PublishFragment(ctx context.Context, topic, name string, component templ.Component) (int, error) {
+	var buf bytes.Buffer
+	if err := component.Render(ctx, &buf); err != nil {
+		return 0, fmt.Errorf("failed to render fragment. err %w", err)
+	}
+	return h.Publish(Message{Topic: topic, Name: name, Data: buf.String()}), nil
+}
+
+func (h *Hub) remove(s *Subscription) {
+	s.once.Do(func() {
+		h.mu.Lock()
+		for _, t := range s.topics {
+			delete(h.topics[t], s)
+			if len(h.topics[t]) == 0 {
+				delete(h.topics, t)
+			}
+		}
+		h.mu.Unlock()
+		close(s.done)
+	})
+}
+
+// SSESource returns an sse.Source streaming the messages published to topics. The stream ends
+// when the client is evicted, and the browser reconnects.
+func (h *Hub) SSESource(topics ...string) sse.Source {
+	return func(ctx context.Context, r *http.Request, lastEventID string, send sse.SendFunc) error {
+		sub := h.Subscribe(topics...)
+		defer sub.Close()
+		for {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-sub.Done():
+				return nil
+			case msg := <-sub.C():
+				if err := send(sse.Event{Name: msg.Name, Data: msg.Data}); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
+
+// Forward sends the messages published to topics to the WebSocket connection c until it's
+// closed, e.g. from websocket.Config.OnConnect. A connection that doesn't keep up is closed.
+func (h *Hub) Forward(c *websocket.Conn, topics ...string) {
+	sub := h.Subscribe(topics...)
+	go func() {
+		defer sub.Close()
+		for {
+			select {
+			case <-c.Context().Done():
+				return
+			case <-sub.Done():
+				c.Close()
+				return
+			case msg := <-sub.C():
+				if err := c.Send([]byte(msg.Data)); err != nil {
+					c.Close()
+					return
+				}
+			}
+		}
+	}()
+}
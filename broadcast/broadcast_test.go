@@ -0,0 +1,159 @@
+package broadcast_test
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/a-h/templ"
+	"github.com/ancalabrese/gotth/broadcast"
+	"github.com/ancalabrese/gotth/sse"
+	"github.com/ancalabrese/gotth/websocket"
+	cws "github.com/coder/websocket"
+)
+
+func text(s string) templ.Component {
+	return templ.ComponentFunc(func(ctx context.Context, w io.Writer) error {
+		_, err := io.WriteString(w, s)
+		return err
+	})
+}
+
+// waitSubscribers waits for the clients connecting in the background to subscribe.
+func waitSubscribers(t *testing.T, hub *broadcast.Hub, topic string, n int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for hub.Subscribers(topic) != n {
+		if time.Now().After(deadline) {
+			t.Fatalf("Subscribers(%q) = %d, want %d", topic, hub.Subscribers(topic), n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestHub_Publish(t *testing.T) {
+	hub := broadcast.New()
+	scores := hub.Subscribe("scores")
+	both := hub.Subscribe("scores", "chat")
+	defer both.Close()
+
+	if n := hub.Publish(broadcast.Message{Topic: "scores", Data: "1-0"}); n != 2 {
+		t.Errorf("Publish() = %d, want 2", n)
+	}
+	if n := hub.Publish(broadcast.Message{Topic: "chat", Data: "hi"}); n != 1 {
+		t.Errorf("Publish() = %d, want 1", n)
+	}
+	if n := hub.Publish(broadcast.Message{Topic: "weather", Data: "sunny"}); n != 0 {
+		t.Errorf("Publish() = %d, want 0", n)
+	}
+
+	if msg := <-scores.C(); msg.Data != "1-0" {
+		t.Errorf("scores got %q, want %q", msg.Data, "1-0")
+	}
+	for _, want := range []string{"1-0", "hi"} {
+		if msg := <-both.C(); msg.Data != want {
+			t.Errorf("both got %q, want %q", msg.Data, want)
+		}
+	}
+
+	scores.Close()
+	if n := hub.Subscribers("scores"); n != 1 {
+		t.Errorf("Subscribers() after Close = %d, want 1", n)
+	}
+}
+
+func TestHub_EvictsSlowSubscribers(t *testing.T) {
+	hub := broadcast.New(broadcast.WithSubscriberBuffer(2))
+	slow := hub.Subscribe("scores")
+	fast := hub.Subscribe("scores")
+	defer fast.Close()
+
+	for i := 0; i < 3; i++ {
+		hub.Publish(broadcast.Message{Topic: "scores", Data: "goal"})
+		<-fast.C()
+	}
+
+	select {
+	case <-slow.Done():
+	default:
+		t.Fatal("slow subscriber not evicted")
+	}
+	select {
+	case <-fast.Done():
+		t.Fatal("fast subscriber evicted")
+	default:
+	}
+	if n := hub.Subscribers("scores"); n != 1 {
+		t.Errorf("Subscribers() = %d, want 1", n)
+	}
+}
+
+func TestHub_SSESource(t *testing.T) {
+	hub := broadcast.New()
+	srv := httptest.NewServer(sse.Handler(hub.SSESource("scores"), sse.WithHeartbeat(0)))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	waitSubscribers(t, hub, "scores", 1)
+
+	if _, err := hub.PublishFragment(context.Background(), "scores", "score", text("<b>1-0</b>")); err != nil {
+		t.Fatalf("PublishFragment() error = %v", err)
+	}
+
+	body := bufio.NewReader(resp.Body)
+	var got []string
+	for {
+		line, err := body.ReadString('\n')
+		if err != nil || line == "\n" {
+			break
+		}
+		got = append(got, strings.TrimSuffix(line, "\n"))
+	}
+	if want := "event: score|data: <b>1-0</b>"; strings.Join(got, "|") != want {
+		t.Errorf("event = %q, want %q", strings.Join(got, "|"), want)
+	}
+
+	resp.Body.Close()
+	waitSubscribers(t, hub, "scores", 0)
+}
+
+func TestHub_Forward(t *testing.T) {
+	hub := broadcast.New()
+	srv := httptest.NewServer(websocket.Handler(websocket.Config{
+		OnConnect: func(c *websocket.Conn) error {
+			hub.Forward(c, "chat")
+			return nil
+		},
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	conn, _, err := cws.Dial(ctx, "ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	defer conn.CloseNow()
+	waitSubscribers(t, hub, "chat", 1)
+
+	hub.Publish(broadcast.Message{Topic: "chat", Data: `<p id="msg">hi</p>`})
+	_, data, err := conn.Read(ctx)
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	if string(data) != `<p id="msg">hi</p>` {
+		t.Errorf("message = %q", data)
+	}
+
+	conn.Close(cws.StatusNormalClosure, "")
+	waitSubscribers(t, hub, "chat", 0)
+}
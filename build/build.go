@@ -0,0 +1,214 @@
+// Package build bundles and minifies the JavaScript and CSS of a site with esbuild, at startup
+// or from go generate, and fingerprints the output so that it can be cached forever:
+//
+//	cfg := build.DefaultConfig()
+//	cfg.OutDir = "static/dist"
+//	cfg.Entries = []build.Entry{{In: "assets/app.js", Out: "app.js"}, {In: "assets/app.css", Out: "app.css"}}
+//	assets, err := build.Run(ctx, cfg)
+//	...
+//	head.WithStylesheet("/static/dist/"+assets.Path("app.css"), "", "", "")
+//
+// Serve the output directory with gotth.NewStaticAssetFS and middlewares.CacheImmutable.
+//
+// The default Bundler runs the esbuild binary, a single executable without the Node toolchain.
+// To use the esbuild Go API instead, add github.com/evanw/esbuild to your module and adapt it:
+//
+//	cfg.Bundler = build.BundlerFunc(func(ctx context.Context, e build.Entry, opts build.Options) ([]byte, error) {
+//		res := api.Build(api.BuildOptions{
+//			EntryPoints: []string{e.In}, Bundle: true, Write: false, Outfile: e.Out,
+//			MinifyWhitespace: opts.Minify, MinifyIdentifiers: opts.Minify, MinifySyntax: opts.Minify,
+//		})
+//		if len(res.Errors) > 0 {
+//			return nil, fmt.Errorf("%s", res.Errors[0].Text)
+//		}
+//		return res.OutputFiles[0].Contents, nil
+//	})
+package build
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// DefaultManifestName is the name of the manifest written in the output directory.
+	DefaultManifestName = "manifest.json"
+	// DefaultESBuildBinary is the esbuild executable looked up in the PATH.
+	DefaultESBuildBinary = "esbuild"
+
+	// hashLen is the number of hex characters of the content hash in fingerprinted names.
+	hashLen = 10
+)
+
+// ErrNoEntries is returned by Run when the Config has no entries.
+var ErrNoEntries = errors.New("no entries to build")
+
+// Entry is a bundle to build.
+type Entry struct {
+	// In is the path of the entry point, e.g. "assets/app.js".
+	In string
+	// Out is the name of the bundle in the output directory and manifest, e.g. "app.js" or
+	// "js/app.js".
+	Out string
+}
+
+// Options are the bundling options passed to the Bundler.
+type Options struct {
+	Minify bool
+	// Sourcemap inlines a source map in the bundle.
+	Sourcemap bool
+	// Target is the esbuild target, e.g. "es2020". The esbuild default when empty.
+	Target string
+}
+
+// Bundler bundles an entry point with its imports and returns the content of the bundle.
+type Bundler interface {
+	Bundle(ctx context.Context, e Entry, opts Options) ([]byte, error)
+}
+
+// BundlerFunc adapts a function to a Bundler.
+type BundlerFunc func(ctx context.Context, e Entry, opts Options) ([]byte, error)
+
+// Bundle calls f.
+func (f BundlerFunc) Bundle(ctx context.Context, e Entry, opts Options) ([]byte, error) {
+	return f(ctx, e, opts)
+}
+
+// ESBuild returns a Bundler running the esbuild executable at binary (looked up in the PATH
+// when it has no path separator).
+func ESBuild(binary string) Bundler {
+	return BundlerFunc(func(ctx context.Context, e Entry, opts Options) ([]byte, error) {
+		args := []string{e.In, "--bundle", "--log-level=warning"}
+		if opts.Minify {
+			args = append(args, "--minify")
+		}
+		if opts.Sourcemap {
+			args = append(args, "--sourcemap=inline")
+		}
+		if opts.Target != "" {
+			args = append(args, "--target="+opts.Target)
+		}
+
+		var stdout, stderr bytes.Buffer
+		cmd := exec.CommandContext(ctx, binary, args...)
+		cmd.Stdout, cmd.Stderr = &stdout, &stderr
+		if err := cmd.Run(); err != nil {
+			return nil, fmt.Errorf("failed to run esbuild on %s. err %w: %s", e.In, err, strings.TrimSpace(stderr.String()))
+		}
+		return stdout.Bytes(), nil
+	})
+}
+
+// Config configures Run. Use [DefaultConfig] as a starting point.
+type Config struct {
+	Entries []Entry
+	// OutDir is the directory the bundles and manifest are written to.
+	OutDir  string
+	Bundler Bundler
+	Options Options
+	// Fingerprint adds a hash of the content to the bundle names, e.g. "app.3f2a9c1b7e.js".
+	Fingerprint bool
+	// ManifestName is the name of the manifest in OutDir. No manifest is written when empty.
+	ManifestName string
+}
+
+// DefaultConfig returns the default Config: minified, fingerprinted bundles built by the esbuild
+// executable, with a manifest.json.
+func DefaultConfig() Config {
+	return Config{
+		Bundler:      ESBuild(DefaultESBuildBinary),
+		Options:      Options{Minify: true},
+		Fingerprint:  true,
+		ManifestName: DefaultManifestName,
+	}
+}
+
+// Run builds the entries of cfg into cfg.OutDir and returns the manifest of the bundle names.
+func Run(ctx context.Context, cfg Config) (Manifest, error) {
+	if len(cfg.Entries) == 0 {
+		return nil, ErrNoEntries
+	}
+	if cfg.Bundler == nil {
+		cfg.Bundler = ESBuild(DefaultESBuildBinary)
+	}
+
+	m := Manifest{}
+	for _, e := range cfg.Entries {
+		content, err := cfg.Bundler.Bundle(ctx, e, cfg.Options)
+		if err != nil {
+			return nil, fmt.Errorf("failed to bundle %s. err %w", e.In, err)
+		}
+
+		name := path.Clean(filepath.ToSlash(e.Out))
+		if strings.HasPrefix(name, "../") || path.IsAbs(name) {
+			return nil, fmt.Errorf("invalid output name %q", e.Out)
+		}
+		if cfg.Fingerprint {
+			name = fingerprint(name, content)
+		}
+
+		dst := filepath.Join(cfg.OutDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create the output directory. err %w", err)
+		}
+		if err := os.WriteFile(dst, content, 0o644); err != nil {
+			return nil, fmt.Errorf("failed to write %s. err %w", dst, err)
+		}
+		m[path.Clean(filepath.ToSlash(e.Out))] = name
+	}
+
+	if cfg.ManifestName != "" {
+		data, err := json.MarshalIndent(m, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode the manifest. err %w", err)
+		}
+		if err := os.WriteFile(filepath.Join(cfg.OutDir, cfg.ManifestName), data, 0o644); err != nil {
+			return nil, fmt.Errorf("failed to write the manifest. err %w", err)
+		}
+	}
+	return m, nil
+}
+
+// fingerprint inserts the content hash before the extension of name.
+func fingerprint(name string, content []byte) string {
+	sum := sha256.Sum256(content)
+	hash := hex.EncodeToString(sum[:])[:hashLen]
+	ext := path.Ext(name)
+	return strings.TrimSuffix(name, ext) + "." + hash + ext
+}
+
+// Manifest maps the bundle names to their fingerprinted names.
+type Manifest map[string]string
+
+// LoadManifest reads the manifest written by Run, e.g. from an embedded output directory.
+func LoadManifest(fsys fs.FS, name string) (Manifest, error) {
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the manifest. err %w", err)
+	}
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("failed to decode the manifest. err %w", err)
+	}
+	return m, nil
+}
+
+// Path returns the fingerprinted name of the bundle name, or name when it is not in the
+// manifest (e.g., built without fingerprints in development).
+func (m Manifest) Path(name string) string {
+	if p, ok := m[name]; ok {
+		return p
+	}
+	return name
+}
@@ -0,0 +1,115 @@
+package build_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/ancalabrese/gotth/build"
+)
+
+// upper is a fake Bundler returning the uppercased entry point.
+var upper = build.BundlerFunc(func(ctx context.Context, e build.Entry, opts build.Options) ([]byte, error) {
+	b, err := os.ReadFile(e.In)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(strings.ToUpper(string(b))), nil
+})
+
+func TestRun(t *testing.T) {
+	src := t.TempDir()
+	os.WriteFile(filepath.Join(src, "app.js"), []byte("console.log(1)"), 0o644)
+	os.WriteFile(filepath.Join(src, "app.css"), []byte("body{}"), 0o644)
+
+	tests := []struct {
+		name        string
+		fingerprint bool
+		wantName    *regexp.Regexp
+	}{
+		{name: "Fingerprinted", fingerprint: true, wantName: regexp.MustCompile(`^js/app\.[0-9a-f]{10}\.js$`)},
+		{name: "Plain", fingerprint: false, wantName: regexp.MustCompile(`^js/app\.js$`)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := build.DefaultConfig()
+			cfg.OutDir = t.TempDir()
+			cfg.Bundler = upper
+			cfg.Fingerprint = tt.fingerprint
+			cfg.Entries = []build.Entry{{In: filepath.Join(src, "app.js"), Out: "js/app.js"}, {In: filepath.Join(src, "app.css"), Out: "app.css"}}
+
+			m, err := build.Run(context.Background(), cfg)
+			if err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			name := m.Path("js/app.js")
+			if !tt.wantName.MatchString(name) {
+				t.Errorf("Path() = %q, want %s", name, tt.wantName)
+			}
+			if got, _ := os.ReadFile(filepath.Join(cfg.OutDir, name)); string(got) != "CONSOLE.LOG(1)" {
+				t.Errorf("bundle = %q", got)
+			}
+
+			loaded, err := build.LoadManifest(os.DirFS(cfg.OutDir), build.DefaultManifestName)
+			if err != nil {
+				t.Fatalf("LoadManifest() error = %v", err)
+			}
+			if loaded.Path("js/app.js") != name || loaded.Path("app.css") != m.Path("app.css") {
+				t.Errorf("loaded manifest = %v, want %v", loaded, m)
+			}
+			if loaded.Path("missing.js") != "missing.js" {
+				t.Errorf("Path() of a missing bundle = %q", loaded.Path("missing.js"))
+			}
+		})
+	}
+}
+
+func TestRun_Errors(t *testing.T) {
+	failing := build.BundlerFunc(func(ctx context.Context, e build.Entry, opts build.Options) ([]byte, error) {
+		return nil, errors.New("syntax error")
+	})
+
+	tests := []struct {
+		name    string
+		cfg     build.Config
+		wantErr string
+	}{
+		{name: "No entries", cfg: build.Config{}, wantErr: build.ErrNoEntries.Error()},
+		{name: "Bundler error", cfg: build.Config{Bundler: failing, Entries: []build.Entry{{In: "a.js", Out: "a.js"}}}, wantErr: "syntax error"},
+		{name: "Output outside of OutDir", cfg: build.Config{Bundler: upper, Entries: []build.Entry{{In: "build_test.go", Out: "../evil.js"}}}, wantErr: "invalid output name"},
+		{name: "Missing esbuild", cfg: build.Config{Bundler: build.ESBuild("gotth-missing-esbuild"), Entries: []build.Entry{{In: "a.js", Out: "a.js"}}}, wantErr: "failed to run esbuild"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.cfg.OutDir = t.TempDir()
+			_, err := build.Run(context.Background(), tt.cfg)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Run() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestESBuild(t *testing.T) {
+	if _, err := exec.LookPath(build.DefaultESBuildBinary); err != nil {
+		t.Skip("esbuild is not installed")
+	}
+	src := t.TempDir()
+	os.WriteFile(filepath.Join(src, "dep.js"), []byte("export const answer = 42;"), 0o644)
+	os.WriteFile(filepath.Join(src, "app.js"), []byte(`import { answer } from "./dep.js"; console.log(answer);`), 0o644)
+
+	out, err := build.ESBuild(build.DefaultESBuildBinary).Bundle(context.Background(), build.Entry{In: filepath.Join(src, "app.js"), Out: "app.js"}, build.Options{Minify: true})
+	if err != nil {
+		t.Fatalf("Bundle() error = %v", err)
+	}
+	if strings.Contains(string(out), "import") || !strings.Contains(string(out), "42") {
+		t.Errorf("bundle = %s", out)
+	}
+}
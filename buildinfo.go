@@ -0,0 +1,9 @@
+package gotth
+
+import "github.com/ancalabrese/gotth/buildinfo"
+
+// EnableBuildInfo serves the version of the binary as JSON at path, e.g. "/version", to check
+// what a deploy is running. See the buildinfo package.
+func (ws *WebServer) EnableBuildInfo(path string) {
+	ws.handle("GET "+path, buildinfo.Handler(), RouteKindBuiltin, 0)
+}
@@ -0,0 +1,145 @@
+// Package buildinfo exposes the version of the running binary: the module version, the VCS
+// revision and the build time, read from debug.ReadBuildInfo. Serve it to check what a deploy
+// is running, or show it in the footer:
+//
+//	ws, _ := gotth.New(gotth.WebServerConfig{BuildInfoPath: "/version"}, nil)
+//
+// and then, in a templ component:
+//
+//	<footer>{ buildinfo.FromContext(ctx).String() }</footer>
+//
+// Go doesn't record the build time: set it, or override the version, with the linker, e.g.
+//
+//	go build -ldflags "-X github.com/ancalabrese/gotth/buildinfo.BuildTime=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+package buildinfo
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Set with -ldflags "-X github.com/ancalabrese/gotth/buildinfo.Name=value".
+var (
+	// Version overrides the module version, e.g. with the tag of a release built outside of
+	// the module cache, which records "(devel)".
+	Version string
+	// BuildTime is the build time in RFC 3339, e.g. "2025-05-25T10:26:43Z".
+	BuildTime string
+)
+
+// Info describes the running binary.
+type Info struct {
+	// Module is the path of the main module, e.g. "github.com/you/site".
+	Module string `json:"module,omitempty"`
+	// Version is the module version, "(devel)" for the binaries built from a checkout.
+	Version   string `json:"version,omitempty"`
+	GoVersion string `json:"go_version,omitempty"`
+	// Revision is the VCS commit the binary was built from.
+	Revision string `json:"revision,omitempty"`
+	// RevisionTime is the time of the Revision commit.
+	RevisionTime time.Time `json:"revision_time,omitzero"`
+	// Modified reports uncommitted changes in the checkout the binary was built from.
+	Modified  bool      `json:"modified,omitempty"`
+	BuildTime time.Time `json:"build_time,omitzero"`
+}
+
+// Read returns the Info of the running binary. It's read once.
+var Read = sync.OnceValue(func() Info {
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return fromBuildInfo(nil)
+	}
+	return fromBuildInfo(bi)
+})
+
+func fromBuildInfo(bi *debug.BuildInfo) Info {
+	var info Info
+	if bi != nil {
+		info.Module = bi.Main.Path
+		info.Version = bi.Main.Version
+		info.GoVersion = bi.GoVersion
+		for _, s := range bi.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				info.Revision = s.Value
+			case "vcs.time":
+				info.RevisionTime, _ = time.Parse(time.RFC3339, s.Value)
+			case "vcs.modified":
+				info.Modified = s.Value == "true"
+			}
+		}
+	}
+	if Version != "" {
+		info.Version = Version
+	}
+	if BuildTime != "" {
+		info.BuildTime, _ = time.Parse(time.RFC3339, BuildTime)
+	}
+	return info
+}
+
+// ShortRevision returns the first 7 characters of the revision, as shown by git.
+func (i Info) ShortRevision() string {
+	if len(i.Revision) > 7 {
+		return i.Revision[:7]
+	}
+	return i.Revision
+}
+
+// String formats i for humans, e.g. "v1.2.0 (3f2a9c1, 2025-05-25)", with "-dirty" after the
+// revision when Modified.
+func (i Info) String() string {
+	var details []string
+	if rev := i.ShortRevision(); rev != "" {
+		if i.Modified {
+			rev += "-dirty"
+		}
+		details = append(details, rev)
+	}
+	if t := i.BuildTime; !t.IsZero() {
+		details = append(details, t.UTC().Format(time.DateOnly))
+	} else if t := i.RevisionTime; !t.IsZero() {
+		details = append(details, t.UTC().Format(time.DateOnly))
+	}
+
+	out := i.Version
+	if out == "" {
+		out = "unknown"
+	}
+	if len(details) > 0 {
+		out += " (" + strings.Join(details, ", ") + ")"
+	}
+	return out
+}
+
+type contextKey string
+
+const infoKey contextKey = "gotth_buildinfo_key"
+
+// WithInfo returns a copy of ctx carrying info instead of the one of the binary, e.g. to
+// render a stable version in snapshot tests.
+func WithInfo(ctx context.Context, info Info) context.Context {
+	return context.WithValue(ctx, infoKey, info)
+}
+
+// FromContext returns the Info set with [WithInfo], or the one of the binary.
+func FromContext(ctx context.Context) Info {
+	if info, ok := ctx.Value(infoKey).(Info); ok {
+		return info
+	}
+	return Read()
+}
+
+// Handler serves the Info of the request context as JSON, uncached.
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		json.NewEncoder(w).Encode(FromContext(r.Context()))
+	})
+}
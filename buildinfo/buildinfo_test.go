@@ -0,0 +1,101 @@
+package buildinfo
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"runtime/debug"
+	"testing"
+	"time"
+)
+
+func TestFromBuildInfo(t *testing.T) {
+	bi := &debug.BuildInfo{
+		GoVersion: "go1.24.1",
+		Main:      debug.Module{Path: "github.com/you/site", Version: "(devel)"},
+		Settings: []debug.BuildSetting{
+			{Key: "vcs", Value: "git"},
+			{Key: "vcs.revision", Value: "3f2a9c1d8e7b6a5f4e3d2c1b0a9f8e7d6c5b4a39"},
+			{Key: "vcs.time", Value: "2025-05-25T10:26:43Z"},
+			{Key: "vcs.modified", Value: "true"},
+		},
+	}
+
+	info := fromBuildInfo(bi)
+	want := Info{
+		Module:       "github.com/you/site",
+		Version:      "(devel)",
+		GoVersion:    "go1.24.1",
+		Revision:     "3f2a9c1d8e7b6a5f4e3d2c1b0a9f8e7d6c5b4a39",
+		RevisionTime: time.Date(2025, 5, 25, 10, 26, 43, 0, time.UTC),
+		Modified:     true,
+	}
+	if info != want {
+		t.Errorf("fromBuildInfo() = %+v, want %+v", info, want)
+	}
+
+	Version, BuildTime = "v1.2.0", "2025-06-01T08:00:00Z"
+	defer func() { Version, BuildTime = "", "" }()
+	info = fromBuildInfo(bi)
+	if info.Version != "v1.2.0" || !info.BuildTime.Equal(time.Date(2025, 6, 1, 8, 0, 0, 0, time.UTC)) {
+		t.Errorf("fromBuildInfo() with linker overrides = %q %v", info.Version, info.BuildTime)
+	}
+}
+
+func TestInfo_String(t *testing.T) {
+	tests := []struct {
+		name string
+		info Info
+		want string
+	}{
+		{name: "Empty", want: "unknown"},
+		{name: "Version only", info: Info{Version: "v1.2.0"}, want: "v1.2.0"},
+		{
+			name: "Revision and time",
+			info: Info{Version: "v1.2.0", Revision: "3f2a9c1d8e7b", RevisionTime: time.Date(2025, 5, 25, 10, 0, 0, 0, time.UTC)},
+			want: "v1.2.0 (3f2a9c1, 2025-05-25)",
+		},
+		{
+			name: "Build time wins, dirty checkout",
+			info: Info{Version: "(devel)", Revision: "3f2a9c1d8e7b", Modified: true, RevisionTime: time.Date(2025, 5, 25, 10, 0, 0, 0, time.UTC), BuildTime: time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)},
+			want: "(devel) (3f2a9c1-dirty, 2025-06-01)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.info.String(); got != tt.want {
+				t.Errorf("String() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandler(t *testing.T) {
+	info := Info{Version: "v1.2.0", Revision: "3f2a9c1"}
+	req := httptest.NewRequest(http.MethodGet, "/version", nil)
+	req = req.WithContext(WithInfo(req.Context(), info))
+	rr := httptest.NewRecorder()
+	Handler().ServeHTTP(rr, req)
+
+	if ct := rr.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	if rr.Body.String() != "{\"version\":\"v1.2.0\",\"revision\":\"3f2a9c1\"}\n" {
+		t.Errorf("body = %s", rr.Body.String())
+	}
+	var got Info
+	if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil || got != info {
+		t.Errorf("decoded body = %+v, %v, want %+v", got, err, info)
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	if got := FromContext(context.Background()); got != Read() {
+		t.Errorf("FromContext() without info = %+v, want Read() = %+v", got, Read())
+	}
+	if got := Read().GoVersion; got == "" {
+		t.Error("Read().GoVersion is empty in a test binary")
+	}
+}
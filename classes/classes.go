@@ -0,0 +1,115 @@
+// Package classes builds dynamic class lists for templ components, resolving conflicts between
+// Tailwind utilities so that overrides win:
+//
+//	<button class={ classes.Merge("px-4 py-2 bg-sky-700 text-white", map[string]bool{
+//		"bg-red-700": danger,
+//		"opacity-50 cursor-not-allowed": disabled,
+//	}) }>
+//
+// renders "px-4 py-2 text-white bg-red-700" when danger is true: the later class of each group
+// (background color, padding, font size, ...) wins, per variant, so "hover:bg-red-700" doesn't
+// override "bg-sky-700". Classes outside of the known groups are kept, without duplicates.
+package classes
+
+import (
+	"slices"
+	"strings"
+)
+
+// Merge returns base followed by the keys of conditionals whose value is true, each key
+// holding one or more classes. Conflicting utilities are resolved in favor of the last one,
+// so conditional classes override base. Conditionals are applied in sorted order so that the
+// result is deterministic.
+func Merge(base string, conditionals map[string]bool) string {
+	list := strings.Fields(base)
+	keys := make([]string, 0, len(conditionals))
+	for k, ok := range conditionals {
+		if ok {
+			keys = append(keys, k)
+		}
+	}
+	slices.Sort(keys)
+	for _, k := range keys {
+		list = append(list, strings.Fields(k)...)
+	}
+	return resolve(list)
+}
+
+// Join joins the class lists, resolving conflicts in favor of the last ones, e.g. to let the
+// caller of a component override its default classes: classes.Join(defaults, attrs.Class).
+func Join(lists ...string) string {
+	var list []string
+	for _, l := range lists {
+		list = append(list, strings.Fields(l)...)
+	}
+	return resolve(list)
+}
+
+// resolve drops the classes overridden by a later class of the same group and variant.
+func resolve(list []string) string {
+	claimed := map[string]bool{}
+	keep := make([]bool, len(list))
+	for i := len(list) - 1; i >= 0; i-- {
+		variant, g, covered := parse(list[i])
+		if claimed[variant+g] {
+			continue
+		}
+		keep[i] = true
+		claimed[variant+g] = true
+		for _, c := range covered {
+			claimed[variant+c] = true
+		}
+	}
+
+	var b strings.Builder
+	for i, c := range list {
+		if keep[i] {
+			if b.Len() > 0 {
+				b.WriteByte(' ')
+			}
+			b.WriteString(c)
+		}
+	}
+	return b.String()
+}
+
+// parse splits class into its variant (e.g., "md:hover:", with the important modifier) and the
+// conflict group of its utility, with the groups it overrides. Unknown utilities are their own
+// group.
+func parse(class string) (variant, group string, covered []string) {
+	utility := class
+	// The variants end at the last colon outside of an arbitrary value.
+	depth := 0
+	for i, r := range class {
+		switch r {
+		case '[':
+			depth++
+		case ']':
+			depth--
+		case ':':
+			if depth == 0 {
+				variant, utility = class[:i+1], class[i+1:]
+			}
+		}
+	}
+	if u, ok := strings.CutPrefix(utility, "!"); ok {
+		variant, utility = variant+"!", u
+	} else if u, ok := strings.CutSuffix(utility, "!"); ok {
+		variant, utility = variant+"!", u
+	}
+	utility = strings.TrimPrefix(utility, "-")
+
+	if g, ok := keywords[utility]; ok {
+		return variant, g, covers[g]
+	}
+	for _, p := range prefixes {
+		if value, ok := strings.CutPrefix(utility, p.prefix); ok {
+			g := p.group
+			if p.classify != nil {
+				g = p.classify(value)
+			}
+			return variant, g, covers[g]
+		}
+	}
+	return variant, "class:" + utility, nil
+}
@@ -0,0 +1,49 @@
+package classes_test
+
+import (
+	"testing"
+
+	"github.com/ancalabrese/gotth/classes"
+)
+
+func TestMerge(t *testing.T) {
+	tests := []struct {
+		name         string
+		base         string
+		conditionals map[string]bool
+		expected     string
+	}{
+		{name: "Base only", base: "  px-4   py-2 ", expected: "px-4 py-2"},
+		{name: "Conditional added", base: "px-4", conditionals: map[string]bool{"font-bold": true, "italic": false}, expected: "px-4 font-bold"},
+		{name: "Background color override", base: "bg-sky-700 text-white", conditionals: map[string]bool{"bg-red-700": true}, expected: "text-white bg-red-700"},
+		{name: "Text size and color don't conflict", base: "text-sm text-gray-700", conditionals: map[string]bool{"text-lg": true}, expected: "text-gray-700 text-lg"},
+		{name: "Text align", base: "text-left text-sm", conditionals: map[string]bool{"text-center": true}, expected: "text-sm text-center"},
+		{name: "Shorthand overrides sides", base: "px-2 pt-1 m-2", conditionals: map[string]bool{"p-4": true}, expected: "m-2 p-4"},
+		{name: "Side after shorthand is kept", base: "p-4", conditionals: map[string]bool{"px-2": true}, expected: "p-4 px-2"},
+		{name: "Variants are separate groups", base: "bg-white hover:bg-gray-100", conditionals: map[string]bool{"bg-black": true}, expected: "hover:bg-gray-100 bg-black"},
+		{name: "Same variant conflicts", base: "md:flex", conditionals: map[string]bool{"md:hidden": true}, expected: "md:hidden"},
+		{name: "Display", base: "flex items-center", conditionals: map[string]bool{"hidden": true}, expected: "items-center hidden"},
+		{name: "Border width, style and color", base: "border border-gray-200 border-solid", conditionals: map[string]bool{"border-2 border-red-500": true}, expected: "border-solid border-2 border-red-500"},
+		{name: "Rounded", base: "rounded-t-lg rounded", conditionals: map[string]bool{"rounded-full": true}, expected: "rounded-full"},
+		{name: "Font weight and family", base: "font-sans font-normal", conditionals: map[string]bool{"font-bold": true}, expected: "font-sans font-bold"},
+		{name: "Negative and arbitrary values", base: "-mt-2 w-[320px]", conditionals: map[string]bool{"mt-4 w-full": true}, expected: "mt-4 w-full"},
+		{name: "Arbitrary variant", base: "[&>*]:p-2", conditionals: map[string]bool{"[&>*]:p-4": true}, expected: "[&>*]:p-4"},
+		{name: "Unknown classes deduplicated", base: "card card-lg", conditionals: map[string]bool{"card": true}, expected: "card-lg card"},
+		{name: "Conditionals in sorted order", base: "", conditionals: map[string]bool{"opacity-75": true, "opacity-50": true}, expected: "opacity-75"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := classes.Merge(tt.base, tt.conditionals); got != tt.expected {
+				t.Errorf("Merge() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestJoin(t *testing.T) {
+	got := classes.Join("rounded px-4 py-2 bg-sky-700", "", "bg-emerald-700 px-6")
+	if want := "rounded py-2 bg-emerald-700 px-6"; got != want {
+		t.Errorf("Join() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,242 @@
+package classes
+
+import "strings"
+
+// keywords maps standalone utilities to their group.
+var keywords = map[string]string{}
+
+func init() {
+	for group, list := range map[string][]string{
+		"display":         {"block", "inline-block", "inline", "flex", "inline-flex", "grid", "inline-grid", "table", "inline-table", "table-row", "table-cell", "contents", "flow-root", "list-item", "hidden"},
+		"position":        {"static", "fixed", "absolute", "relative", "sticky"},
+		"visibility":      {"visible", "invisible", "collapse"},
+		"font-style":      {"italic", "not-italic"},
+		"text-decoration": {"underline", "overline", "line-through", "no-underline"},
+		"text-transform":  {"uppercase", "lowercase", "capitalize", "normal-case"},
+		"text-overflow":   {"truncate", "text-ellipsis", "text-clip"},
+		"flex-direction":  {"flex-row", "flex-row-reverse", "flex-col", "flex-col-reverse"},
+		"flex-wrap":       {"flex-wrap", "flex-wrap-reverse", "flex-nowrap"},
+		"flex":            {"flex-1", "flex-auto", "flex-initial", "flex-none"},
+		"grow":            {"grow", "grow-0"},
+		"shrink":          {"shrink", "shrink-0"},
+		"border-w":        {"border"},
+		"rounded":         {"rounded"},
+		"shadow":          {"shadow"},
+		"ring-w":          {"ring"},
+		"sr":              {"sr-only", "not-sr-only"},
+	} {
+		for _, k := range list {
+			keywords[k] = group
+		}
+	}
+}
+
+type prefix struct {
+	prefix string
+	group  string
+	// Optional: classify returns the group of the value when the prefix is shared by several
+	// groups, e.g. "text-" for font size, alignment and color.
+	classify func(value string) string
+}
+
+// prefixes lists the utilities taking a value, longest prefixes first.
+var prefixes = []prefix{
+	{prefix: "px-", group: "px"}, {prefix: "py-", group: "py"}, {prefix: "ps-", group: "ps"}, {prefix: "pe-", group: "pe"},
+	{prefix: "pt-", group: "pt"}, {prefix: "pr-", group: "pr"}, {prefix: "pb-", group: "pb"}, {prefix: "pl-", group: "pl"},
+	{prefix: "p-", group: "p"},
+	{prefix: "mx-", group: "mx"}, {prefix: "my-", group: "my"}, {prefix: "ms-", group: "ms"}, {prefix: "me-", group: "me"},
+	{prefix: "mt-", group: "mt"}, {prefix: "mr-", group: "mr"}, {prefix: "mb-", group: "mb"}, {prefix: "ml-", group: "ml"},
+	{prefix: "m-", group: "m"},
+	{prefix: "space-x-", group: "space-x"}, {prefix: "space-y-", group: "space-y"},
+	{prefix: "gap-x-", group: "gap-x"}, {prefix: "gap-y-", group: "gap-y"}, {prefix: "gap-", group: "gap"},
+	{prefix: "min-w-", group: "min-w"}, {prefix: "max-w-", group: "max-w"}, {prefix: "min-h-", group: "min-h"}, {prefix: "max-h-", group: "max-h"},
+	{prefix: "size-", group: "size"}, {prefix: "w-", group: "w"}, {prefix: "h-", group: "h"},
+	{prefix: "inset-x-", group: "inset-x"}, {prefix: "inset-y-", group: "inset-y"}, {prefix: "inset-", group: "inset"},
+	{prefix: "top-", group: "top"}, {prefix: "right-", group: "right"}, {prefix: "bottom-", group: "bottom"}, {prefix: "left-", group: "left"},
+	{prefix: "start-", group: "start"}, {prefix: "end-", group: "end"},
+	{prefix: "z-", group: "z"}, {prefix: "order-", group: "order"}, {prefix: "opacity-", group: "opacity"},
+	{prefix: "basis-", group: "basis"}, {prefix: "grow-", group: "grow"}, {prefix: "shrink-", group: "shrink"},
+	{prefix: "grid-cols-", group: "grid-cols"}, {prefix: "grid-rows-", group: "grid-rows"},
+	{prefix: "col-span-", group: "col-span"}, {prefix: "row-span-", group: "row-span"},
+	{prefix: "items-", group: "align-items"}, {prefix: "justify-items-", group: "justify-items"}, {prefix: "justify-self-", group: "justify-self"},
+	{prefix: "justify-", group: "justify-content"}, {prefix: "content-", group: "align-content"}, {prefix: "self-", group: "align-self"},
+	{prefix: "place-items-", group: "place-items"}, {prefix: "place-content-", group: "place-content"},
+	{prefix: "overflow-x-", group: "overflow-x"}, {prefix: "overflow-y-", group: "overflow-y"}, {prefix: "overflow-", group: "overflow"},
+	{prefix: "object-", group: "object"}, {prefix: "cursor-", group: "cursor"}, {prefix: "select-", group: "select"},
+	{prefix: "pointer-events-", group: "pointer-events"}, {prefix: "whitespace-", group: "whitespace"}, {prefix: "break-", group: "break"},
+	{prefix: "leading-", group: "leading"}, {prefix: "tracking-", group: "tracking"}, {prefix: "align-", group: "vertical-align"},
+	{prefix: "list-", group: "list"}, {prefix: "decoration-", group: "decoration"},
+	{prefix: "transition-", group: "transition"}, {prefix: "duration-", group: "duration"}, {prefix: "ease-", group: "ease"}, {prefix: "delay-", group: "delay"},
+	{prefix: "text-", classify: classifyText},
+	{prefix: "font-", classify: classifyFont},
+	{prefix: "bg-", classify: classifyBackground},
+	{prefix: "border-", classify: classifyBorder},
+	{prefix: "rounded-", classify: classifyRounded},
+	{prefix: "shadow-", classify: func(v string) string {
+		return sizedOrColor("shadow", v, []string{"sm", "md", "lg", "xl", "2xl", "inner", "none"})
+	}},
+	{prefix: "ring-", classify: classifyRing},
+	{prefix: "outline-", classify: func(v string) string {
+		return sizedOrColor("outline", v, []string{"none", "dashed", "dotted", "double", "0", "1", "2", "4", "8", "offset-0", "offset-1", "offset-2", "offset-4", "offset-8"})
+	}},
+	{prefix: "fill-", group: "fill"}, {prefix: "stroke-", group: "stroke"},
+}
+
+// covers lists the groups overridden by a shorthand, e.g. "p-4" overrides an earlier "px-2".
+var covers = map[string][]string{
+	"p":        {"px", "py", "ps", "pe", "pt", "pr", "pb", "pl"},
+	"px":       {"ps", "pe", "pr", "pl"},
+	"py":       {"pt", "pb"},
+	"m":        {"mx", "my", "ms", "me", "mt", "mr", "mb", "ml"},
+	"mx":       {"ms", "me", "mr", "ml"},
+	"my":       {"mt", "mb"},
+	"gap":      {"gap-x", "gap-y"},
+	"size":     {"w", "h"},
+	"inset":    {"inset-x", "inset-y", "top", "right", "bottom", "left", "start", "end"},
+	"inset-x":  {"right", "left", "start", "end"},
+	"inset-y":  {"top", "bottom"},
+	"overflow": {"overflow-x", "overflow-y"},
+	"rounded":  {"rounded-t", "rounded-r", "rounded-b", "rounded-l", "rounded-s", "rounded-e", "rounded-tl", "rounded-tr", "rounded-br", "rounded-bl", "rounded-ss", "rounded-se", "rounded-es", "rounded-ee"},
+	"border-w": {"border-w-x", "border-w-y", "border-w-t", "border-w-r", "border-w-b", "border-w-l", "border-w-s", "border-w-e"},
+}
+
+var (
+	fontSizes   = []string{"xs", "sm", "base", "lg", "xl", "2xl", "3xl", "4xl", "5xl", "6xl", "7xl", "8xl", "9xl"}
+	textAligns  = []string{"left", "center", "right", "justify", "start", "end"}
+	fontWeights = []string{"thin", "extralight", "light", "normal", "medium", "semibold", "bold", "extrabold", "black"}
+	fontFamily  = []string{"sans", "serif", "mono"}
+)
+
+func classifyText(v string) string {
+	switch {
+	case contains(fontSizes, v) || isArbitraryLength(v):
+		return "font-size"
+	case contains(textAligns, v):
+		return "text-align"
+	case contains([]string{"wrap", "nowrap", "balance", "pretty"}, v):
+		return "text-wrap"
+	default:
+		return "text-color"
+	}
+}
+
+func classifyFont(v string) string {
+	switch {
+	case contains(fontWeights, v):
+		return "font-weight"
+	case contains(fontFamily, v):
+		return "font-family"
+	default:
+		return "font:" + v
+	}
+}
+
+func classifyBackground(v string) string {
+	switch {
+	case contains([]string{"fixed", "local", "scroll"}, v):
+		return "bg-attachment"
+	case contains([]string{"auto", "cover", "contain"}, v):
+		return "bg-size"
+	case contains([]string{"repeat", "no-repeat", "repeat-x", "repeat-y", "repeat-round", "repeat-space"}, v):
+		return "bg-repeat"
+	case contains([]string{"bottom", "center", "left", "left-bottom", "left-top", "right", "right-bottom", "right-top", "top"}, v):
+		return "bg-position"
+	case v == "none" || strings.HasPrefix(v, "gradient-"):
+		return "bg-image"
+	case strings.HasPrefix(v, "clip-"):
+		return "bg-clip"
+	case strings.HasPrefix(v, "origin-"):
+		return "bg-origin"
+	default:
+		return "bg-color"
+	}
+}
+
+func classifyBorder(v string) string {
+	if contains([]string{"solid", "dashed", "dotted", "double", "hidden", "none"}, v) {
+		return "border-style"
+	}
+	if contains([]string{"collapse", "separate"}, v) {
+		return "border-collapse"
+	}
+	if isWidth(v) {
+		return "border-w"
+	}
+	side, rest, found := strings.Cut(v, "-")
+	if contains([]string{"x", "y", "t", "r", "b", "l", "s", "e"}, side) {
+		if !found || isWidth(rest) {
+			return "border-w-" + side
+		}
+		return "border-color-" + side
+	}
+	return "border-color"
+}
+
+func classifyRounded(v string) string {
+	side, _, _ := strings.Cut(v, "-")
+	if contains([]string{"t", "r", "b", "l", "s", "e", "tl", "tr", "br", "bl", "ss", "se", "es", "ee"}, side) {
+		return "rounded-" + side
+	}
+	return "rounded"
+}
+
+func classifyRing(v string) string {
+	if isWidth(v) || v == "inset" {
+		return "ring-w"
+	}
+	if strings.HasPrefix(v, "offset-") {
+		return sizedOrColor("ring-offset", strings.TrimPrefix(v, "offset-"), nil)
+	}
+	return "ring-color"
+}
+
+// sizedOrColor returns the group of utilities whose value is either one of sizes (or a width),
+// or a color.
+func sizedOrColor(name, v string, sizes []string) string {
+	if contains(sizes, v) || isWidth(v) {
+		return name
+	}
+	return name + "-color"
+}
+
+// isWidth reports whether v is a border or ring width: a number or an arbitrary length.
+func isWidth(v string) bool {
+	if v == "" {
+		return false
+	}
+	if isArbitraryLength(v) {
+		return true
+	}
+	for _, r := range v {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// isArbitraryLength reports whether v is an arbitrary value with a length unit, e.g. [3px].
+func isArbitraryLength(v string) bool {
+	if !strings.HasPrefix(v, "[") || !strings.HasSuffix(v, "]") {
+		return false
+	}
+	v = strings.TrimPrefix(strings.TrimSuffix(v, "]"), "[")
+	if strings.HasPrefix(v, "length:") {
+		return true
+	}
+	for _, unit := range []string{"px", "rem", "em", "%", "vh", "vw", "ch"} {
+		if strings.HasSuffix(v, unit) && len(v) > len(unit) && v[0] >= '0' && v[0] <= '9' {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(list []string, v string) bool {
+	for _, s := range list {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
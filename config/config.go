@@ -1 +1,392 @@
+// Package config loads the server settings from a gotth.yaml or gotth.toml file, with
+// 12-factor overrides from environment variables, e.g.:
+//
+//	# gotth.yaml
+//	addr: ":8080"
+//	read_timeout: 10s
+//	write_timeout: 10s
+//	canonical_host: https://example.com
+//	static:
+//	  - path: /static
+//	    dir: ./static/dist
+//	tls:
+//	  cert_file: /etc/gotth/cert.pem
+//	  key_file: /etc/gotth/key.pem
+//	head:
+//	  site_name: Example
+//	  favicon: /static/favicon.svg
+//	  stylesheets: [/static/style.css]
+//	  htmx: true
+//
+// Every setting can be overridden by the environment variable named after its key path:
+// GOTTH_ followed by the upper-cased keys joined by "_", e.g. GOTTH_ADDR, GOTTH_TLS_CERT_FILE or
+// GOTTH_HEAD_SITE_NAME, except tls.http_addr and tls.acme_challenge_dir, read from
+// GOTTH_HTTP_ADDR and GOTTH_ACME_CHALLENGE_DIR: the variables shared with gotth.ConfigFromEnv
+// have the same names and syntax. Lists are comma separated, and static mounts are written
+// path=dir, e.g. GOTTH_STATIC="/static=./static/dist,/img=./images". PORT sets the address when
+// GOTTH_ADDR is unset. The config then builds the server and the head defaults of the pages:
+//
+//	cfg, err := config.Load("") // gotth.yaml, gotth.yml or gotth.toml, if any
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	ws, err := gotth.NewWithOptions(append(cfg.Options(), gotth.WithMiddlewares(middlewares.RequestID))...)
+//	...
+//	headVM := head.NewHeadViewModel(append(cfg.HeadOptions(), head.WithPageCoreMetadata(title, description, path))...)
 package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/ancalabrese/gotth"
+	"github.com/ancalabrese/gotth/internal/envvar"
+	"github.com/ancalabrese/gotth/middlewares"
+	"github.com/ancalabrese/gotth/views/components/head"
+	"gopkg.in/yaml.v3"
+)
+
+// EnvPrefix prefixes the environment variables overriding the settings.
+const EnvPrefix = "GOTTH_"
+
+// DefaultFiles are the config files looked up in the working directory by Load("").
+var DefaultFiles = []string{"gotth.yaml", "gotth.yml", "gotth.toml"}
+
+// Format is the syntax of a config file.
+type Format string
+
+const (
+	YAML Format = "yaml"
+	TOML Format = "toml"
+)
+
+// Config holds the server settings.
+type Config struct {
+	// Address the server listens on, e.g. ":8080".
+	Addr              string        `yaml:"addr" toml:"addr"`
+	ReadTimeout       time.Duration `yaml:"read_timeout" toml:"read_timeout"`
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout" toml:"read_header_timeout"`
+	WriteTimeout      time.Duration `yaml:"write_timeout" toml:"write_timeout"`
+	IdleTimeout       time.Duration `yaml:"idle_timeout" toml:"idle_timeout"`
+	MaxHeaderBytes    int           `yaml:"max_header_bytes" toml:"max_header_bytes"`
+	// Static asset directories and the URL paths they are served under.
+	Static []Static `yaml:"static" toml:"static"`
+	// Requests for other hosts are redirected to it. See [middlewares.CanonicalHost].
+	CanonicalHost string `yaml:"canonical_host" toml:"canonical_host"`
+	TLS           TLS    `yaml:"tls" toml:"tls"`
+	Head          Head   `yaml:"head" toml:"head"`
+	Dev           bool   `yaml:"dev" toml:"dev"`
+	// Directories watched in dev mode.
+	DevWatch  []string `yaml:"dev_watch" toml:"dev_watch"`
+	LogRoutes bool     `yaml:"log_routes" toml:"log_routes"`
+	Log       Log      `yaml:"log" toml:"log"`
+	// Path serving the version of the binary, e.g. "/version".
+	BuildInfoPath string `yaml:"build_info_path" toml:"build_info_path"`
+	// Bind the address with SO_REUSEPORT. See [gotth.WebServerConfig.ReusePort].
+	ReusePort bool `yaml:"reuse_port" toml:"reuse_port"`
+	// Hand the listener to a new process on SIGHUP. See [gotth.WebServerConfig.RestartOnSIGHUP].
+	RestartOnSIGHUP bool `yaml:"restart_on_sighup" toml:"restart_on_sighup"`
+}
+
+// Static is a directory of static assets served under Path, e.g. "/static".
+type Static struct {
+	Path string `yaml:"path" toml:"path"`
+	Dir  string `yaml:"dir" toml:"dir"`
+}
+
+// TLS holds the PEM files of the certificate and private key. The server serves HTTPS when set.
+type TLS struct {
+	CertFile string `yaml:"cert_file" toml:"cert_file"`
+	KeyFile  string `yaml:"key_file" toml:"key_file"`
+	// Also listen for plain HTTP on this address, e.g. ":80", redirecting to HTTPS.
+	HTTPAddr string `yaml:"http_addr" toml:"http_addr" env:"GOTTH_HTTP_ADDR"`
+	// Directory of the ACME challenges served by the HTTP listener, e.g. for certbot.
+	ACMEChallengeDir string `yaml:"acme_challenge_dir" toml:"acme_challenge_dir" env:"GOTTH_ACME_CHALLENGE_DIR"`
+}
+
+// Head holds the head metadata shared by all the pages. See [Config.HeadOptions].
+type Head struct {
+	SiteName       string   `yaml:"site_name" toml:"site_name"`
+	Author         string   `yaml:"author" toml:"author"`
+	Favicon        string   `yaml:"favicon" toml:"favicon"`
+	FaviconType    string   `yaml:"favicon_type" toml:"favicon_type"`
+	AppleTouchIcon string   `yaml:"apple_touch_icon" toml:"apple_touch_icon"`
+	ThemeColor     string   `yaml:"theme_color" toml:"theme_color"`
+	ColorScheme    string   `yaml:"color_scheme" toml:"color_scheme"`
+	AnalyticsID    string   `yaml:"analytics_id" toml:"analytics_id"`
+	Fonts          []string `yaml:"fonts" toml:"fonts"`
+	Stylesheets    []string `yaml:"stylesheets" toml:"stylesheets"`
+	// Scripts are deferred.
+	Scripts     []string `yaml:"scripts" toml:"scripts"`
+	HTMX        bool     `yaml:"htmx" toml:"htmx"`
+	HTMXPreload bool     `yaml:"htmx_preload" toml:"htmx_preload"`
+	Alpine      bool     `yaml:"alpine" toml:"alpine"`
+}
+
+// Log configures the server logger. See [gotth.NewLogger].
+type Log struct {
+	// debug, info, warn or error.
+	Level string `yaml:"level" toml:"level"`
+	// text or json.
+	Format string `yaml:"format" toml:"format"`
+}
+
+// Load reads the config file at path, in the format of its extension (.yaml, .yml or .toml),
+// and applies the environment overrides. An empty path loads the first of DefaultFiles found
+// in the working directory, or only the environment when there is none.
+func Load(path string) (*Config, error) {
+	if path == "" {
+		for _, name := range DefaultFiles {
+			if _, err := os.Stat(name); err == nil {
+				path = name
+				break
+			}
+		}
+	}
+
+	cfg := &Config{}
+	if path != "" {
+		format, err := formatOf(path)
+		if err != nil {
+			return nil, err
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open config file. err %w", err)
+		}
+		defer f.Close()
+
+		if cfg, err = Parse(f, format); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := cfg.ApplyEnv(os.LookupEnv); err != nil {
+		return nil, err
+	}
+	return cfg, cfg.Validate()
+}
+
+// Parse decodes a config file in format, without the environment overrides.
+func Parse(r io.Reader, format Format) (*Config, error) {
+	cfg := &Config{}
+	var err error
+	switch format {
+	case YAML:
+		err = yaml.NewDecoder(r).Decode(cfg)
+		if errors.Is(err, io.EOF) { // Empty file
+			err = nil
+		}
+	case TOML:
+		_, err = toml.NewDecoder(r).Decode(cfg)
+	default:
+		return nil, fmt.Errorf("unknown config format %q", format)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s config. err %w", format, err)
+	}
+	return cfg, nil
+}
+
+func formatOf(path string) (Format, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return YAML, nil
+	case ".toml":
+		return TOML, nil
+	default:
+		return "", fmt.Errorf("unknown config file extension of %s: use .yaml, .yml or .toml", path)
+	}
+}
+
+// ApplyEnv overrides the settings with the environment variables found by lookup, e.g.
+// os.LookupEnv. See the package documentation for their names.
+func (c *Config) ApplyEnv(lookup func(key string) (string, bool)) error {
+	if _, ok := lookup(EnvPrefix + "ADDR"); !ok {
+		if port, ok := lookup("PORT"); ok && port != "" {
+			c.Addr = ":" + port
+		}
+	}
+	return applyEnv(reflect.ValueOf(c).Elem(), strings.TrimSuffix(EnvPrefix, "_"), lookup)
+}
+
+var staticType = reflect.TypeOf([]Static{})
+
+// applyEnv sets the fields of the struct v from the variables named prefix_<key>, or by their
+// env tag, recursing into nested structs.
+func applyEnv(v reflect.Value, prefix string, lookup func(string) (string, bool)) error {
+	for i := range v.NumField() {
+		field := v.Type().Field(i)
+		key, _, _ := strings.Cut(field.Tag.Get("toml"), ",")
+		if key == "" || key == "-" {
+			continue
+		}
+		name := prefix + "_" + strings.ToUpper(key)
+		if env := field.Tag.Get("env"); env != "" {
+			name = env
+		}
+		fv := v.Field(i)
+
+		if fv.Kind() == reflect.Struct {
+			if err := applyEnv(fv, name, lookup); err != nil {
+				return err
+			}
+			continue
+		}
+
+		value, ok := lookup(name)
+		if !ok {
+			continue
+		}
+		if err := setFromEnv(fv, value); err != nil {
+			return fmt.Errorf("failed to parse %s. err %w", name, err)
+		}
+	}
+	return nil
+}
+
+// setFromEnv parses value into v with the parser of gotth.ConfigFromEnv.
+func setFromEnv(v reflect.Value, value string) error {
+	if v.Type() != staticType {
+		return envvar.Set(v.Addr().Interface(), value)
+	}
+	mounts, err := envvar.Mounts(value)
+	if err != nil {
+		return err
+	}
+	static := make([]Static, 0, len(mounts))
+	for _, m := range mounts {
+		static = append(static, Static{Path: m.Path, Dir: m.Dir})
+	}
+	v.Set(reflect.ValueOf(static))
+	return nil
+}
+
+// Validate reports the settings the server can't start with.
+func (c *Config) Validate() error {
+	for _, s := range c.Static {
+		if s.Path == "" || s.Dir == "" {
+			return fmt.Errorf("static mount %+v needs both a path and a dir", s)
+		}
+	}
+	if (c.TLS.CertFile == "") != (c.TLS.KeyFile == "") {
+		return errors.New("tls needs both cert_file and key_file")
+	}
+	if c.TLS.HTTPAddr != "" && c.TLS.CertFile == "" {
+		return errors.New("tls.http_addr needs cert_file and key_file")
+	}
+	if _, err := gotth.NewLogger(io.Discard, c.Log.Level, c.Log.Format); err != nil {
+		return fmt.Errorf("invalid log settings. err %w", err)
+	}
+	return nil
+}
+
+// Options returns the server options of the settings, for [gotth.NewWithOptions]. Unset
+// settings keep the defaults of the server. Call [Config.Validate] first when the Config
+// doesn't come from Load.
+func (c *Config) Options() []gotth.Option {
+	var opts []gotth.Option
+	if c.Addr != "" {
+		opts = append(opts, gotth.WithAddr(c.Addr))
+	}
+	if c.ReadTimeout > 0 {
+		opts = append(opts, gotth.WithReadTimeout(c.ReadTimeout))
+	}
+	if c.ReadHeaderTimeout > 0 {
+		opts = append(opts, gotth.WithReadHeaderTimeout(c.ReadHeaderTimeout))
+	}
+	if c.WriteTimeout > 0 {
+		opts = append(opts, gotth.WithWriteTimeout(c.WriteTimeout))
+	}
+	if c.IdleTimeout > 0 {
+		opts = append(opts, gotth.WithIdleTimeout(c.IdleTimeout))
+	}
+	if c.MaxHeaderBytes > 0 {
+		opts = append(opts, gotth.WithMaxHeaderBytes(c.MaxHeaderBytes))
+	}
+	for _, s := range c.Static {
+		opts = append(opts, gotth.WithStatic(s.Path, http.Dir(s.Dir)))
+	}
+	if c.CanonicalHost != "" {
+		opts = append(opts, gotth.WithMiddlewares(middlewares.CanonicalHost(c.CanonicalHost)))
+	}
+	if c.TLS.CertFile != "" {
+		opts = append(opts, gotth.WithTLS(c.TLS.CertFile, c.TLS.KeyFile))
+	}
+	if c.TLS.HTTPAddr != "" {
+		opts = append(opts, gotth.WithHTTPRedirect(c.TLS.HTTPAddr, c.TLS.ACMEChallengeDir))
+	}
+	if c.Dev {
+		opts = append(opts, gotth.WithDevMode(c.DevWatch...))
+	}
+	if c.LogRoutes {
+		opts = append(opts, gotth.WithLogRoutes())
+	}
+	if c.BuildInfoPath != "" {
+		opts = append(opts, gotth.WithBuildInfo(c.BuildInfoPath))
+	}
+	if c.ReusePort {
+		opts = append(opts, gotth.WithReusePort())
+	}
+	if c.RestartOnSIGHUP {
+		opts = append(opts, gotth.WithRestartOnSIGHUP())
+	}
+	if c.Log.Level != "" || c.Log.Format != "" {
+		if logger, err := gotth.NewLogger(os.Stderr, c.Log.Level, c.Log.Format); err == nil {
+			opts = append(opts, gotth.WithLogger(logger))
+		}
+	}
+	return opts
+}
+
+// HeadOptions returns the head options of the Head settings, to pass before the page specific
+// ones to [head.NewHeadViewModel].
+func (c *Config) HeadOptions() []head.Option {
+	h := c.Head
+	var opts []head.Option
+	if h.SiteName != "" {
+		opts = append(opts, head.WithName(h.SiteName))
+	}
+	if h.Author != "" {
+		opts = append(opts, head.WithAuthor(h.Author))
+	}
+	if h.Favicon != "" {
+		opts = append(opts, head.WithFavicon(h.Favicon, h.FaviconType))
+	}
+	if h.AppleTouchIcon != "" {
+		opts = append(opts, head.WithAppleTouchIcon(h.AppleTouchIcon))
+	}
+	if h.ThemeColor != "" || h.ColorScheme != "" {
+		opts = append(opts, head.WithThemeing(h.ThemeColor, "", h.ColorScheme))
+	}
+	if h.AnalyticsID != "" {
+		opts = append(opts, head.WithAnalytics(true, h.AnalyticsID))
+	}
+	for _, href := range h.Fonts {
+		opts = append(opts, head.WithFont(href, false))
+	}
+	for _, href := range h.Stylesheets {
+		opts = append(opts, head.WithStylesheet(href, "", "", ""))
+	}
+	if h.HTMX {
+		opts = append(opts, head.WithHTMX(""))
+	}
+	if h.HTMXPreload {
+		opts = append(opts, head.WithHTMXPreloadExt(""))
+	}
+	if h.Alpine {
+		opts = append(opts, head.WithAlpine(""))
+	}
+	for _, src := range h.Scripts {
+		opts = append(opts, head.WithHeaderScript(src, false, true, "", "", ""))
+	}
+	return opts
+}
@@ -0,0 +1,180 @@
+package config_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ancalabrese/gotth"
+	"github.com/ancalabrese/gotth/config"
+	"github.com/ancalabrese/gotth/views/components/head"
+)
+
+func expectedConfig() *config.Config {
+	return &config.Config{
+		Addr:          ":3000",
+		ReadTimeout:   5 * time.Second,
+		IdleTimeout:   2 * time.Minute,
+		CanonicalHost: "example.com",
+		Static:        []config.Static{{Path: "/static", Dir: "./testdata"}},
+		Head: config.Head{
+			SiteName:    "Example",
+			Favicon:     "/static/favicon.svg",
+			FaviconType: "image/svg+xml",
+			Stylesheets: []string{"/static/style.css"},
+			HTMX:        true,
+		},
+		Log: config.Log{Level: "debug"},
+	}
+}
+
+func TestLoad(t *testing.T) {
+	for _, file := range []string{"testdata/gotth.yaml", "testdata/gotth.toml"} {
+		t.Run(file, func(t *testing.T) {
+			cfg, err := config.Load(file)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if want := expectedConfig(); !reflect.DeepEqual(cfg, want) {
+				t.Errorf("Load() = %+v, want %+v", cfg, want)
+			}
+		})
+	}
+}
+
+func TestLoad_Env(t *testing.T) {
+	t.Setenv("PORT", "9000")
+	t.Setenv("GOTTH_WRITE_TIMEOUT", "1s")
+	t.Setenv("GOTTH_STATIC", "/assets=./a, /img=./b")
+	t.Setenv("GOTTH_TLS_CERT_FILE", "cert.pem")
+	t.Setenv("GOTTH_TLS_KEY_FILE", "key.pem")
+	// The variables of gotth.ConfigFromEnv configure the same settings.
+	t.Setenv(gotth.EnvHTTPAddr, ":80")
+	t.Setenv(gotth.EnvACMEChallengeDir, "/var/www/acme")
+	t.Setenv("GOTTH_HEAD_SITE_NAME", "Prod")
+	t.Setenv("GOTTH_HEAD_STYLESHEETS", "/a.css,/b.css")
+	t.Setenv("GOTTH_HEAD_HTMX", "false")
+	t.Setenv("GOTTH_LOG_FORMAT", "json")
+
+	cfg, err := config.Load("testdata/gotth.yaml")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	want := expectedConfig()
+	want.Addr = ":9000"
+	want.WriteTimeout = time.Second
+	want.Static = []config.Static{{Path: "/assets", Dir: "./a"}, {Path: "/img", Dir: "./b"}}
+	want.TLS = config.TLS{CertFile: "cert.pem", KeyFile: "key.pem", HTTPAddr: ":80", ACMEChallengeDir: "/var/www/acme"}
+	want.Head.SiteName = "Prod"
+	want.Head.Stylesheets = []string{"/a.css", "/b.css"}
+	want.Head.HTMX = false
+	want.Log.Format = "json"
+	if !reflect.DeepEqual(cfg, want) {
+		t.Errorf("Load() = %+v, want %+v", cfg, want)
+	}
+
+	t.Setenv("GOTTH_ADDR", "127.0.0.1:8081")
+	if cfg, err = config.Load("testdata/gotth.yaml"); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Addr != "127.0.0.1:8081" {
+		t.Errorf("Addr = %q, want GOTTH_ADDR to win over PORT", cfg.Addr)
+	}
+}
+
+func TestLoad_DefaultFiles(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	cfg, err := config.Load("")
+	if err != nil {
+		t.Fatalf("Load() without a file error = %v", err)
+	}
+	if !reflect.DeepEqual(cfg, &config.Config{}) {
+		t.Errorf("Load() without a file = %+v, want the zero Config", cfg)
+	}
+
+	if err := os.WriteFile("gotth.toml", []byte(`addr = ":4000"`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if cfg, err = config.Load(""); err != nil || cfg.Addr != ":4000" {
+		t.Errorf("Load() = %+v, %v, want gotth.toml loaded", cfg, err)
+	}
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		env     map[string]string
+	}{
+		{name: "Unknown extension", file: "gotth.json", content: "{}"},
+		{name: "Bad YAML", file: "gotth.yaml", content: "addr: [1"},
+		{name: "Bad TOML", file: "gotth.toml", content: "addr ="},
+		{name: "Bad duration", file: "gotth.yaml", content: "read_timeout: soon"},
+		{name: "Static without dir", file: "gotth.yaml", content: "static: [{path: /static}]"},
+		{name: "TLS without key", file: "gotth.yaml", content: "tls: {cert_file: cert.pem}"},
+		{name: "Bad log level", file: "gotth.yaml", content: "log: {level: loud}"},
+		{name: "Bad env bool", file: "gotth.yaml", env: map[string]string{"GOTTH_DEV": "sure"}},
+		{name: "Bad env static", file: "gotth.yaml", env: map[string]string{"GOTTH_STATIC": "./static"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			path := filepath.Join(dir, tt.file)
+			if err := os.WriteFile(path, []byte(tt.content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := config.Load(path); err == nil {
+				t.Error("Load() expected an error")
+			}
+		})
+	}
+}
+
+func TestConfig_Options(t *testing.T) {
+	cfg, err := config.Load("testdata/gotth.yaml")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	ws, err := gotth.NewWithOptions(cfg.Options()...)
+	if err != nil {
+		t.Fatalf("NewWithOptions() error = %v", err)
+	}
+
+	rr := httptest.NewRecorder()
+	ws.Handler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "http://example.com/static/gotth.toml", nil))
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `site_name = "Example"`) {
+		t.Errorf("static mount: status = %d, body = %q", rr.Code, rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	ws.Handler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "http://www.example.com/static/gotth.toml", nil))
+	if rr.Code != http.StatusMovedPermanently || rr.Header().Get("Location") != "http://example.com/static/gotth.toml" {
+		t.Errorf("canonical host: status = %d, Location = %q", rr.Code, rr.Header().Get("Location"))
+	}
+}
+
+func TestConfig_HeadOptions(t *testing.T) {
+	cfg := expectedConfig()
+	vm := head.NewHeadViewModel(append(cfg.HeadOptions(), head.WithPageCoreMetadata("Title", "", "/"))...)
+
+	if vm.Name != "Example" || vm.FaviconPath != "/static/favicon.svg" || vm.FaviconType != "image/svg+xml" {
+		t.Errorf("head = %q %q %q", vm.Name, vm.FaviconPath, vm.FaviconType)
+	}
+	if len(vm.Stylesheets) != 1 || vm.Stylesheets[0].Href != "/static/style.css" {
+		t.Errorf("Stylesheets = %+v", vm.Stylesheets)
+	}
+	if len(vm.HeaderScripts) != 1 || !strings.Contains(vm.HeaderScripts[0].Src, "htmx.org") {
+		t.Errorf("HeaderScripts = %+v, want HTMX", vm.HeaderScripts)
+	}
+}
@@ -0,0 +1,306 @@
+package content
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"maps"
+	"path"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultReloadInterval is the minimum time between two checks for changed files of a
+// [Collection] with Reload.
+const DefaultReloadInterval = 500 * time.Millisecond
+
+// ErrMissingField is wrapped by the errors of the entries missing a field tagged
+// `content:"required"`.
+var ErrMissingField = errors.New("missing required field")
+
+// Entry is a file of a [Collection].
+type Entry[T any] struct {
+	// ID is the path of the file in the directory of the collection, without extension, e.g.
+	// "guides/install".
+	ID string
+	// File is the path of the file in the FS.
+	File string
+	// Data is the decoded front matter.
+	Data T
+	// Content is the rendered Markdown after the front matter.
+	Content Document
+}
+
+// CollectionConfig configures a [Collection] of entries with the front matter T.
+type CollectionConfig[T any] struct {
+	// FS holds the entries, e.g. an embed.FS or, to edit them live, os.DirFS.
+	FS fs.FS
+	// Optional: directory of the entries in FS, searched recursively. Defaults to the root.
+	Dir string
+	// Optional: extension of the entry files. Defaults to ".md".
+	Ext string
+	// Optional: renders the entries. Defaults to NewMarkdown().
+	Markdown *Markdown
+	// Strict fails on front matter keys without a field in T, catching typos.
+	Strict bool
+	// Optional: Validate checks an entry after the fields tagged `content:"required"` and the
+	// Validate method of *T, when it has one.
+	Validate func(*Entry[T]) error
+	// Optional: Compare sorts the entries. Defaults to the order of the IDs.
+	Compare func(a, b *Entry[T]) int
+	// Optional: OnLoad is called with the entries, sorted, every time they're loaded and before
+	// they're served, e.g. to build indexes. An error fails the load.
+	OnLoad func(entries []*Entry[T]) error
+	// Reload reads the entries again when their files change, e.g. in DevMode. The files are
+	// checked on reads, at most every Interval. A failed reload is logged and the previous
+	// entries are kept.
+	Reload bool
+	// Interval defaults to [DefaultReloadInterval].
+	Interval time.Duration
+	// Optional: Logger of the failed reloads. Defaults to slog.Default().
+	Logger *slog.Logger
+}
+
+// Collection is a set of Markdown files with typed front matter, loaded from an fs.FS and
+// indexed in memory by ID:
+//
+//	type Guide struct {
+//		Title string `yaml:"title" content:"required"`
+//		Order int    `yaml:"order"`
+//	}
+//
+//	guides, err := content.NewCollection(content.CollectionConfig[Guide]{FS: docs, Dir: "guides"})
+//	...
+//	g, ok := guides.Get("install")
+//
+// It's safe for concurrent use.
+type Collection[T any] struct {
+	cfg CollectionConfig[T]
+
+	mu      sync.RWMutex
+	entries []*Entry[T]
+	byID    map[string]*Entry[T]
+
+	reloadMu sync.Mutex
+	files    map[string]fileState // Files of the last load, to detect changes
+	checked  time.Time
+}
+
+// fileState is what a change of a file is detected from.
+type fileState struct {
+	size    int64
+	modTime time.Time
+}
+
+// NewCollection loads the entries of cfg. The errors of all the invalid entries are returned
+// together.
+func NewCollection[T any](cfg CollectionConfig[T]) (*Collection[T], error) {
+	if cfg.FS == nil {
+		return nil, errors.New("content: CollectionConfig.FS is required")
+	}
+	cfg.Dir = cmp.Or(cfg.Dir, ".")
+	cfg.Ext = cmp.Or(cfg.Ext, ".md")
+	if cfg.Markdown == nil {
+		cfg.Markdown = NewMarkdown()
+	}
+	if cfg.Compare == nil {
+		cfg.Compare = func(a, b *Entry[T]) int { return strings.Compare(a.ID, b.ID) }
+	}
+	cfg.Interval = cmp.Or(cfg.Interval, DefaultReloadInterval)
+	if cfg.Logger == nil {
+		cfg.Logger = slog.Default()
+	}
+
+	c := &Collection[T]{cfg: cfg}
+	files, err := c.scan()
+	if err != nil {
+		return nil, err
+	}
+	if err := c.load(files); err != nil {
+		return nil, err
+	}
+	c.files, c.checked = files, time.Now()
+	return c, nil
+}
+
+// All returns the entries, sorted.
+func (c *Collection[T]) All() []*Entry[T] {
+	c.Refresh()
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return slices.Clone(c.entries)
+}
+
+// Filter returns the entries keep reports true for, sorted.
+func (c *Collection[T]) Filter(keep func(*Entry[T]) bool) []*Entry[T] {
+	c.Refresh()
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	var entries []*Entry[T]
+	for _, e := range c.entries {
+		if keep(e) {
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
+
+// Get returns the entry of id.
+func (c *Collection[T]) Get(id string) (*Entry[T], bool) {
+	c.Refresh()
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	e, ok := c.byID[id]
+	return e, ok
+}
+
+// Len returns the number of entries.
+func (c *Collection[T]) Len() int {
+	c.Refresh()
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.entries)
+}
+
+// Refresh reloads the entries of a collection with Reload when a file was added, modified or
+// removed, checking at most every Interval. A failed reload is logged and the previous entries
+// are kept. The reads of the collection call it: call it before reading an index built by
+// OnLoad.
+func (c *Collection[T]) Refresh() {
+	if !c.cfg.Reload {
+		return
+	}
+	c.reloadMu.Lock()
+	defer c.reloadMu.Unlock()
+	if time.Since(c.checked) < c.cfg.Interval {
+		return
+	}
+	c.checked = time.Now()
+	if err := c.reload(); err != nil {
+		c.cfg.Logger.Error("failed to reload content collection", slog.String("dir", c.cfg.Dir), slog.Any("error", err))
+	}
+}
+
+func (c *Collection[T]) reload() error {
+	files, err := c.scan()
+	if err != nil {
+		return err
+	}
+	if maps.Equal(files, c.files) {
+		return nil
+	}
+	// Remember the files even when they're invalid: the next change reloads them.
+	c.files = files
+	return c.load(files)
+}
+
+// scan returns the entry files.
+func (c *Collection[T]) scan() (map[string]fileState, error) {
+	files := map[string]fileState{}
+	err := fs.WalkDir(c.cfg.FS, c.cfg.Dir, func(file string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || path.Ext(file) != c.cfg.Ext {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		files[file] = fileState{size: info.Size(), modTime: info.ModTime()}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s. err %w", c.cfg.Dir, err)
+	}
+	return files, nil
+}
+
+// load reads files and replaces the entries.
+func (c *Collection[T]) load(files map[string]fileState) error {
+	var errs []error
+	entries := make([]*Entry[T], 0, len(files))
+	for _, file := range slices.Sorted(maps.Keys(files)) {
+		e, err := c.loadEntry(file)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid entry %s. err %w", file, err))
+			continue
+		}
+		entries = append(entries, e)
+	}
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+
+	slices.SortStableFunc(entries, c.cfg.Compare)
+	if c.cfg.OnLoad != nil {
+		if err := c.cfg.OnLoad(entries); err != nil {
+			return err
+		}
+	}
+	byID := make(map[string]*Entry[T], len(entries))
+	for _, e := range entries {
+		byID[e.ID] = e
+	}
+
+	c.mu.Lock()
+	c.entries, c.byID = entries, byID
+	c.mu.Unlock()
+	return nil
+}
+
+func (c *Collection[T]) loadEntry(file string) (*Entry[T], error) {
+	src, err := fs.ReadFile(c.cfg.FS, file)
+	if err != nil {
+		return nil, err
+	}
+	id := strings.TrimSuffix(file, c.cfg.Ext)
+	if c.cfg.Dir != "." {
+		id = strings.TrimPrefix(id, c.cfg.Dir+"/")
+	}
+	e := &Entry[T]{ID: id, File: file}
+	body, err := parseFrontMatter(src, &e.Data, c.cfg.Strict)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkRequired(e.Data); err != nil {
+		return nil, err
+	}
+	if v, ok := any(&e.Data).(interface{ Validate() error }); ok {
+		if err := v.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	if c.cfg.Validate != nil {
+		if err := c.cfg.Validate(e); err != nil {
+			return nil, err
+		}
+	}
+	if e.Content, err = c.cfg.Markdown.Render(body); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// checkRequired returns an error naming the zero fields of data tagged `content:"required"`.
+func checkRequired(data any) error {
+	v := reflect.ValueOf(data)
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+	var missing []string
+	for i := range v.NumField() {
+		f := v.Type().Field(i)
+		if f.Tag.Get("content") == "required" && v.Field(i).IsZero() {
+			name, _, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+			missing = append(missing, cmp.Or(name, f.Name))
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%w %s", ErrMissingField, strings.Join(missing, ", "))
+	}
+	return nil
+}
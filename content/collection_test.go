@@ -0,0 +1,141 @@
+package content
+
+import (
+	"cmp"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+type guide struct {
+	Title string `yaml:"title" toml:"title" content:"required"`
+	Order int    `yaml:"order" toml:"order"`
+}
+
+func (g *guide) Validate() error {
+	if g.Order < 0 {
+		return errors.New("negative order")
+	}
+	return nil
+}
+
+func TestNewCollection(t *testing.T) {
+	fsys := fstest.MapFS{
+		"docs/install.md":      {Data: []byte("---\ntitle: Install\norder: 1\n---\n# Install\n")},
+		"docs/advanced/ssr.md": {Data: []byte("+++\ntitle = \"SSR\"\norder = 2\n+++\nBody")},
+		"docs/notes.txt":       {Data: []byte("not an entry")},
+		"other/skipped.md":     {Data: []byte("---\ntitle: Skipped\n---\n")},
+	}
+	c, err := NewCollection(CollectionConfig[guide]{
+		FS:      fsys,
+		Dir:     "docs",
+		Compare: func(a, b *Entry[guide]) int { return cmp.Compare(a.Data.Order, b.Data.Order) },
+	})
+	if err != nil {
+		t.Fatalf("NewCollection() error = %v", err)
+	}
+
+	var ids []string
+	for _, e := range c.All() {
+		ids = append(ids, e.ID)
+	}
+	if got, want := strings.Join(ids, ","), "install,advanced/ssr"; got != want {
+		t.Errorf("IDs = %s, want %s", got, want)
+	}
+	e, ok := c.Get("install")
+	if !ok || e.Data.Title != "Install" || e.File != "docs/install.md" || !strings.Contains(e.Content.HTML, `<h1 id="install">Install`) {
+		t.Errorf("Get(install) = %+v, %v", e, ok)
+	}
+	if got := c.Filter(func(e *Entry[guide]) bool { return e.Data.Order > 1 }); len(got) != 1 || got[0].ID != "advanced/ssr" {
+		t.Errorf("Filter() = %v", got)
+	}
+}
+
+func TestNewCollection_Validation(t *testing.T) {
+	tests := []struct {
+		name     string
+		src      string
+		strict   bool
+		validate func(*Entry[guide]) error
+		wantErr  string
+	}{
+		{name: "Required field", src: "---\norder: 1\n---\n", wantErr: "missing required field title"},
+		{name: "Validate method", src: "---\ntitle: A\norder: -1\n---\n", wantErr: "negative order"},
+		{name: "Unknown key when strict", src: "---\ntitle: A\ntitel: B\n---\n", strict: true, wantErr: "titel"},
+		{name: "Unknown TOML key when strict", src: "+++\ntitle = \"A\"\ntitel = \"B\"\n+++\n", strict: true, wantErr: "titel"},
+		{name: "Unknown key", src: "---\ntitle: A\ntitel: B\n---\n"},
+		{
+			name:     "Validate func",
+			src:      "---\ntitle: A\n---\n",
+			validate: func(e *Entry[guide]) error { return errors.New("rejected " + e.ID) },
+			wantErr:  "rejected a",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewCollection(CollectionConfig[guide]{
+				FS:       fstest.MapFS{"a.md": {Data: []byte(tt.src)}},
+				Strict:   tt.strict,
+				Validate: tt.validate,
+			})
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("NewCollection() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) || !strings.Contains(err.Error(), "a.md") {
+				t.Errorf("NewCollection() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	// All the invalid entries are reported.
+	_, err := NewCollection(CollectionConfig[guide]{FS: fstest.MapFS{
+		"a.md": {Data: []byte("---\n---\n")},
+		"b.md": {Data: []byte("no front matter")},
+	}})
+	if !errors.Is(err, ErrMissingField) || !strings.Contains(err.Error(), "a.md") || !strings.Contains(err.Error(), "b.md") {
+		t.Errorf("NewCollection() error = %v, want both entries", err)
+	}
+}
+
+func TestCollection_Reload(t *testing.T) {
+	fsys := fstest.MapFS{"a.md": {Data: []byte("---\ntitle: A\n---\n"), ModTime: time.Unix(1, 0)}}
+	loads := 0
+	c, err := NewCollection(CollectionConfig[guide]{
+		FS:       fsys,
+		Reload:   true,
+		Interval: time.Nanosecond,
+		Logger:   slog.New(slog.DiscardHandler),
+		OnLoad:   func([]*Entry[guide]) error { loads++; return nil },
+	})
+	if err != nil {
+		t.Fatalf("NewCollection() error = %v", err)
+	}
+
+	c.Len()
+	if loads != 1 {
+		t.Errorf("loads = %d without changes, want 1", loads)
+	}
+
+	fsys["a.md"] = &fstest.MapFile{Data: []byte("---\ntitle: A edited\n---\n"), ModTime: time.Unix(2, 0)}
+	fsys["b.md"] = &fstest.MapFile{Data: []byte("---\ntitle: B\n---\n")}
+	if e, ok := c.Get("a"); !ok || e.Data.Title != "A edited" || c.Len() != 2 {
+		t.Errorf("Get(a) after edit = %+v, %v, %d entries", e, ok, c.Len())
+	}
+
+	// An invalid edit keeps the previous entries.
+	fsys["b.md"] = &fstest.MapFile{Data: []byte("---\norder: 1\n---\n")}
+	if e, ok := c.Get("b"); !ok || e.Data.Title != "B" {
+		t.Errorf("Get(b) after invalid edit = %+v, %v", e, ok)
+	}
+
+	delete(fsys, "b.md")
+	if _, ok := c.Get("b"); ok {
+		t.Error("Get(b) after removal found the entry")
+	}
+}
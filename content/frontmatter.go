@@ -0,0 +1,91 @@
+package content
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// ErrUnterminatedFrontMatter is returned for front matter missing its closing delimiter.
+var ErrUnterminatedFrontMatter = errors.New("unterminated front matter")
+
+// ParseFrontMatter decodes the front matter at the top of src into v and returns the rest of
+// src. The front matter is YAML between "---" lines or TOML between "+++" lines:
+//
+//	---
+//	title: Hello
+//	date: 2025-06-01
+//	tags: [go, htmx]
+//	---
+//	# Hello
+//
+// src is returned whole, and v left as is, without front matter.
+func ParseFrontMatter(src []byte, v any) (body []byte, err error) {
+	return parseFrontMatter(src, v, false)
+}
+
+// parseFrontMatter is ParseFrontMatter, failing on the keys without a field in v when strict.
+func parseFrontMatter(src []byte, v any, strict bool) (body []byte, err error) {
+	src = bytes.TrimPrefix(src, []byte("\xef\xbb\xbf")) // UTF-8 BOM
+	for _, delim := range []string{"---", "+++"} {
+		meta, body, ok, err := cutFrontMatter(src, delim)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		if delim == "---" {
+			err = decodeYAML(meta, v, strict)
+		} else {
+			err = decodeTOML(meta, v, strict)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse front matter. err %w", err)
+		}
+		return body, nil
+	}
+	return src, nil
+}
+
+// cutFrontMatter returns the lines between the first line of src and the next one, when both
+// are delim, and what follows.
+func cutFrontMatter(src []byte, delim string) (meta, body []byte, ok bool, err error) {
+	first, rest, found := bytes.Cut(src, []byte("\n"))
+	if !found || string(bytes.TrimRight(first, " \t\r")) != delim {
+		return nil, src, false, nil
+	}
+	for offset := 0; offset < len(rest); {
+		line, _, _ := bytes.Cut(rest[offset:], []byte("\n"))
+		if string(bytes.TrimRight(line, " \t\r")) == delim {
+			end := min(offset+len(line)+1, len(rest))
+			return rest[:offset], rest[end:], true, nil
+		}
+		offset += len(line) + 1
+	}
+	return nil, nil, false, ErrUnterminatedFrontMatter
+}
+
+func decodeYAML(meta []byte, v any, strict bool) error {
+	dec := yaml.NewDecoder(bytes.NewReader(meta))
+	dec.KnownFields(strict)
+	if err := dec.Decode(v); err != nil && !errors.Is(err, io.EOF) { // io.EOF: empty front matter
+		return err
+	}
+	return nil
+}
+
+func decodeTOML(meta []byte, v any, strict bool) error {
+	md, err := toml.NewDecoder(bytes.NewReader(meta)).Decode(v)
+	if err != nil {
+		return err
+	}
+	if undecoded := md.Undecoded(); strict && len(undecoded) > 0 {
+		return fmt.Errorf("unknown keys %v", undecoded)
+	}
+	return nil
+}
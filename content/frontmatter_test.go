@@ -0,0 +1,74 @@
+package content
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseFrontMatter(t *testing.T) {
+	type meta struct {
+		Title string    `yaml:"title" toml:"title"`
+		Date  time.Time `yaml:"date" toml:"date"`
+		Tags  []string  `yaml:"tags" toml:"tags"`
+	}
+	date := time.Date(2025, time.June, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		src      string
+		want     meta
+		wantBody string
+		wantErr  error
+	}{
+		{
+			name:     "YAML",
+			src:      "---\ntitle: Hello\ndate: 2025-06-01\ntags: [go, htmx]\n---\n# Hello\n",
+			want:     meta{Title: "Hello", Date: date, Tags: []string{"go", "htmx"}},
+			wantBody: "# Hello\n",
+		},
+		{
+			name:     "TOML",
+			src:      "+++\r\ntitle = \"Hello\"\r\ndate = 2025-06-01T00:00:00Z\r\n+++\r\nBody",
+			want:     meta{Title: "Hello", Date: date},
+			wantBody: "Body",
+		},
+		{
+			name:     "BOM and empty front matter",
+			src:      "\xef\xbb\xbf---\n---\nBody",
+			wantBody: "Body",
+		},
+		{
+			name:     "No front matter",
+			src:      "# Title\n\n---\n",
+			wantBody: "# Title\n\n---\n",
+		},
+		{
+			name:    "Unterminated",
+			src:     "---\ntitle: Hello\n# Hello\n",
+			wantErr: ErrUnterminatedFrontMatter,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got meta
+			body, err := ParseFrontMatter([]byte(tt.src), &got)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ParseFrontMatter() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if string(body) != tt.wantBody {
+				t.Errorf("body = %q, want %q", body, tt.wantBody)
+			}
+			if got.Title != tt.want.Title || !got.Date.Equal(tt.want.Date) || len(got.Tags) != len(tt.want.Tags) {
+				t.Errorf("front matter = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := ParseFrontMatter([]byte("---\ntitle: [\n---\n"), &meta{}); err == nil {
+		t.Error("ParseFrontMatter() with invalid YAML returned no error")
+	}
+}
@@ -0,0 +1,324 @@
+// Package content renders Markdown into templ components, so that docs and blog pages can be
+// authored in Markdown:
+//
+//	doc, err := content.RenderMarkdown(src)
+//	...
+//	@layout.Article(post) { @doc }
+//
+// Rendering is backed by goldmark with the GitHub Flavored Markdown extensions. Headings get
+// IDs and anchor links, fenced code blocks go through a pluggable [Highlighter], and the HTML is
+// sanitized with a bluemonday policy.
+//
+// A [Collection] loads a directory of Markdown files with typed front matter, e.g. the pages of
+// the docs.
+package content
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"fmt"
+	"html"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/ancalabrese/gotth/routes"
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	gmhtml "github.com/yuin/goldmark/renderer/html"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// ErrNotFound is wrapped by the errors of content providers for missing content, e.g. an
+// unknown slug. gotth.WebServer answers them with 404 Not Found. It's [routes.ErrNotFound],
+// so that the server doesn't depend on this package.
+var ErrNotFound = routes.ErrNotFound
+
+// Highlighter writes the HTML of a code block of the language lang, e.g. "go", or "" when the
+// block has none. Its output isn't sanitized: it must escape code.
+type Highlighter interface {
+	Highlight(w io.Writer, code, lang string) error
+}
+
+// HighlighterFunc is a function implementing Highlighter.
+type HighlighterFunc func(w io.Writer, code, lang string) error
+
+// Highlight calls f.
+func (f HighlighterFunc) Highlight(w io.Writer, code, lang string) error {
+	return f(w, code, lang)
+}
+
+// PlainHighlighter writes the code without highlighting, in a <pre><code> block with the
+// "language-<lang>" class of client-side highlighters like Prism or highlight.js.
+var PlainHighlighter = HighlighterFunc(func(w io.Writer, code, lang string) error {
+	class := ""
+	if lang != "" {
+		class = ` class="language-` + html.EscapeString(lang) + `"`
+	}
+	_, err := io.WriteString(w, "<pre><code"+class+">"+html.EscapeString(code)+"</code></pre>\n")
+	return err
+})
+
+// Heading is a heading of a Document.
+type Heading struct {
+	Level int
+	ID    string
+	Text  string
+}
+
+// Document is rendered Markdown. It's a templ.Component writing its HTML.
+type Document struct {
+	HTML     string
+	Headings []Heading
+}
+
+// Render writes the HTML of d.
+func (d Document) Render(ctx context.Context, w io.Writer) error {
+	_, err := io.WriteString(w, d.HTML)
+	return err
+}
+
+// Markdown renders Markdown documents. It's safe for concurrent use.
+type Markdown struct {
+	md          goldmark.Markdown
+	highlighter Highlighter
+	policy      *bluemonday.Policy
+}
+
+type markdownOptions struct {
+	extensions  []goldmark.Extender
+	highlighter Highlighter
+	policy      *bluemonday.Policy
+	unsafeHTML  bool
+	noAnchors   bool
+}
+
+// MarkdownOption configures a [Markdown] renderer.
+type MarkdownOption func(*markdownOptions)
+
+// WithExtensions adds goldmark extensions, e.g. extension.Footnote or extension.Typographer.
+func WithExtensions(extensions ...goldmark.Extender) MarkdownOption {
+	return func(o *markdownOptions) {
+		o.extensions = append(o.extensions, extensions...)
+	}
+}
+
+// WithHighlighter renders the fenced code blocks with h. Defaults to [PlainHighlighter].
+func WithHighlighter(h Highlighter) MarkdownOption {
+	return func(o *markdownOptions) {
+		o.highlighter = h
+	}
+}
+
+// WithPolicy sanitizes the HTML with p instead of [DefaultPolicy]. The placeholders of the code
+// blocks are allowed on it.
+func WithPolicy(p *bluemonday.Policy) MarkdownOption {
+	return func(o *markdownOptions) {
+		o.policy = p
+	}
+}
+
+// WithUnsafeHTML keeps the raw HTML of the documents, which is dropped by default. It's still
+// sanitized by the policy.
+func WithUnsafeHTML() MarkdownOption {
+	return func(o *markdownOptions) {
+		o.unsafeHTML = true
+	}
+}
+
+// WithoutHeadingAnchors leaves out the anchor links of the headings. They keep their IDs.
+func WithoutHeadingAnchors() MarkdownOption {
+	return func(o *markdownOptions) {
+		o.noAnchors = true
+	}
+}
+
+// DefaultPolicy returns the sanitization policy of the documents: the bluemonday policy for
+// user generated content, without rel="nofollow", plus the IDs of the headings, the classes of
+// the anchor links and code blocks, and the checkboxes of task lists.
+func DefaultPolicy() *bluemonday.Policy {
+	p := bluemonday.UGCPolicy()
+	p.RequireNoFollowOnLinks(false)
+	p.AllowAttrs("id").Matching(bluemonday.Paragraph).OnElements("h1", "h2", "h3", "h4", "h5", "h6", "li", "sup")
+	p.AllowAttrs("class").Matching(regexp.MustCompile(`^[\w\- ]+$`)).OnElements("a", "code", "pre", "span", "div")
+	p.AllowAttrs("aria-hidden").Matching(regexp.MustCompile(`^true$`)).OnElements("a")
+	p.AllowAttrs("type").Matching(regexp.MustCompile(`^checkbox$`)).OnElements("input")
+	p.AllowAttrs("checked", "disabled").OnElements("input")
+	return p
+}
+
+// NewMarkdown returns a Markdown renderer configured with opts.
+func NewMarkdown(opts ...MarkdownOption) *Markdown {
+	o := markdownOptions{highlighter: PlainHighlighter}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.policy == nil {
+		o.policy = DefaultPolicy()
+	}
+	o.policy.AllowAttrs(codeAttr).Matching(regexp.MustCompile(`^[A-Z2-7]+-\d+$`)).OnElements("pre")
+
+	rendererOpts := []renderer.Option{
+		renderer.WithNodeRenderers(util.Prioritized(&nodeRenderer{anchors: !o.noAnchors}, 100)),
+	}
+	if o.unsafeHTML {
+		rendererOpts = append(rendererOpts, gmhtml.WithUnsafe())
+	}
+	md := goldmark.New(
+		goldmark.WithExtensions(append([]goldmark.Extender{extension.GFM}, o.extensions...)...),
+		goldmark.WithParserOptions(parser.WithAutoHeadingID(), parser.WithAttribute()),
+		goldmark.WithRendererOptions(rendererOpts...),
+	)
+	return &Markdown{md: md, highlighter: o.highlighter, policy: o.policy}
+}
+
+var defaultMarkdown = sync.OnceValue(func() *Markdown { return NewMarkdown() })
+
+// RenderMarkdown renders src with the default Markdown renderer.
+func RenderMarkdown(src []byte) (Document, error) {
+	return defaultMarkdown().Render(src)
+}
+
+// Render renders the Markdown document src.
+func (m *Markdown) Render(src []byte) (Document, error) {
+	root := m.md.Parser().Parse(text.NewReader(src))
+	return m.render(src, root)
+}
+
+// render renders the parsed document root. The code blocks are highlighted apart and put back
+// in place of their placeholders once the HTML is sanitized, as the markup of highlighters
+// doesn't survive sanitization. The placeholders are random, so that the raw HTML of the
+// document can't forge them.
+func (m *Markdown) render(src []byte, root ast.Node) (Document, error) {
+	var doc Document
+	nonce := rand.Text()
+	var blocks []ast.Node
+	ast.Walk(root, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch n := n.(type) {
+		case *ast.Heading:
+			id, _ := n.AttributeString("id")
+			idBytes, _ := id.([]byte)
+			doc.Headings = append(doc.Headings, Heading{Level: n.Level, ID: string(idBytes), Text: nodeText(n, src)})
+		case *ast.FencedCodeBlock, *ast.CodeBlock:
+			blocks = append(blocks, n)
+			return ast.WalkSkipChildren, nil
+		}
+		return ast.WalkContinue, nil
+	})
+
+	placeholders := make([]string, len(blocks))
+	highlighted := make([]string, len(blocks))
+	for i, n := range blocks {
+		var code strings.Builder
+		lines := n.Lines()
+		for j := range lines.Len() {
+			line := lines.At(j)
+			code.Write(line.Value(src))
+		}
+		lang := ""
+		if fenced, ok := n.(*ast.FencedCodeBlock); ok {
+			lang = string(fenced.Language(src))
+		}
+		var out strings.Builder
+		if err := m.highlighter.Highlight(&out, code.String(), lang); err != nil {
+			return Document{}, fmt.Errorf("failed to highlight %s code block. err %w", lang, err)
+		}
+		highlighted[i] = out.String()
+		placeholders[i] = "<pre " + codeAttr + `="` + nonce + "-" + strconv.Itoa(i) + `"></pre>`
+		n.Parent().ReplaceChild(n.Parent(), n, &codePlaceholder{html: placeholders[i]})
+	}
+
+	var out bytes.Buffer
+	if err := m.md.Renderer().Render(&out, src, root); err != nil {
+		return Document{}, fmt.Errorf("failed to render markdown. err %w", err)
+	}
+	doc.HTML = m.policy.Sanitize(out.String())
+	for i, h := range highlighted {
+		doc.HTML = strings.Replace(doc.HTML, placeholders[i], h, 1)
+	}
+	return doc, nil
+}
+
+// nodeText returns the text of the inline children of n.
+func nodeText(n ast.Node, src []byte) string {
+	var b strings.Builder
+	ast.Walk(n, func(c ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch c := c.(type) {
+		case *ast.Text:
+			b.Write(c.Segment.Value(src))
+			if c.SoftLineBreak() {
+				b.WriteByte(' ')
+			}
+		case *ast.String:
+			b.Write(c.Value)
+		}
+		return ast.WalkContinue, nil
+	})
+	return html.UnescapeString(b.String()) // Entity references, e.g. "&amp;", are kept as written
+}
+
+const codeAttr = "data-gotth-code"
+
+var kindCodePlaceholder = ast.NewNodeKind("CodePlaceholder")
+
+// codePlaceholder stands for a highlighted code block while the document is rendered.
+type codePlaceholder struct {
+	ast.BaseBlock
+	html string
+}
+
+func (n *codePlaceholder) Kind() ast.NodeKind { return kindCodePlaceholder }
+
+func (n *codePlaceholder) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, map[string]string{"HTML": n.html}, nil)
+}
+
+// nodeRenderer renders the headings with their anchor links, and the code placeholders.
+type nodeRenderer struct {
+	anchors bool
+}
+
+func (r *nodeRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(ast.KindHeading, r.renderHeading)
+	reg.Register(kindCodePlaceholder, r.renderCodePlaceholder)
+}
+
+func (r *nodeRenderer) renderHeading(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	n := node.(*ast.Heading)
+	if entering {
+		fmt.Fprintf(w, "<h%d", n.Level)
+		if n.Attributes() != nil {
+			gmhtml.RenderAttributes(w, n, gmhtml.HeadingAttributeFilter)
+		}
+		w.WriteByte('>')
+		return ast.WalkContinue, nil
+	}
+	if id, ok := n.AttributeString("id"); ok && r.anchors {
+		if idBytes, ok := id.([]byte); ok {
+			fmt.Fprintf(w, `<a class="heading-anchor" href="#%s" aria-hidden="true">#</a>`, util.EscapeHTML(idBytes))
+		}
+	}
+	fmt.Fprintf(w, "</h%d>\n", n.Level)
+	return ast.WalkContinue, nil
+}
+
+func (r *nodeRenderer) renderCodePlaceholder(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if entering {
+		w.WriteString(node.(*codePlaceholder).html + "\n")
+	}
+	return ast.WalkSkipChildren, nil
+}
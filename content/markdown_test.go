@@ -0,0 +1,132 @@
+package content
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestMarkdown_Render(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    []MarkdownOption
+		src     string
+		want    []string
+		notWant []string
+	}{
+		{
+			name: "Heading anchors",
+			src:  "# Hello *world*\n\n## Custom {#install}\n",
+			want: []string{
+				`<h1 id="hello-world">Hello <em>world</em><a class="heading-anchor" href="#hello-world" aria-hidden="true">#</a></h1>`,
+				`<h2 id="install">Custom<a class="heading-anchor" href="#install" aria-hidden="true">#</a></h2>`,
+			},
+		},
+		{
+			name:    "Without anchors",
+			opts:    []MarkdownOption{WithoutHeadingAnchors()},
+			src:     "## Setup\n",
+			want:    []string{`<h2 id="setup">Setup</h2>`},
+			notWant: []string{"heading-anchor"},
+		},
+		{
+			name:    "Raw HTML dropped",
+			src:     "Hi <script>alert(1)</script><b>there</b>\n",
+			want:    []string{"<p>Hi "},
+			notWant: []string{"<script", "<b>"},
+		},
+		{
+			name:    "Unsafe HTML sanitized",
+			opts:    []MarkdownOption{WithUnsafeHTML()},
+			src:     "Hi <script>alert(1)</script><b onclick=\"steal()\">there</b> [x](javascript:alert(1))\n",
+			want:    []string{"<b>there</b>"},
+			notWant: []string{"<script", "onclick", "javascript:"},
+		},
+		{
+			name: "Code blocks",
+			src:  "```go\nif a < b {}\n```\n\n    indented\n",
+			want: []string{
+				`<pre><code class="language-go">if a &lt; b {}` + "\n</code></pre>",
+				"<pre><code>indented\n</code></pre>",
+			},
+		},
+		{
+			name: "Custom highlighter",
+			opts: []MarkdownOption{WithHighlighter(HighlighterFunc(func(w io.Writer, code, lang string) error {
+				_, err := io.WriteString(w, `<div class="hl" style="color: red" data-lang="`+lang+`">highlighted</div>`)
+				return err
+			}))},
+			src:  "```sh\nls\n```\n",
+			want: []string{`<div class="hl" style="color: red" data-lang="sh">highlighted</div>`},
+		},
+		{
+			name: "Forged placeholder",
+			opts: []MarkdownOption{WithUnsafeHTML()},
+			src:  "<pre data-gotth-code=\"AAAA-0\"></pre>\n\n```\ncode\n```\n",
+			want: []string{`<pre data-gotth-code="AAAA-0"></pre>` + "\n<pre><code>code\n</code></pre>"},
+		},
+		{
+			name: "GFM",
+			src:  "- [x] done\n\n| a |\n|---|\n| 1 |\n\n~~old~~ https://example.com\n",
+			want: []string{
+				`<input checked="" disabled="" type="checkbox">`,
+				"<td>1</td>",
+				"<del>old</del>",
+				`<a href="https://example.com">https://example.com</a>`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := NewMarkdown(tt.opts...).Render([]byte(tt.src))
+			if err != nil {
+				t.Fatalf("Render() error = %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(doc.HTML, want) {
+					t.Errorf("HTML is missing %s:\n%s", want, doc.HTML)
+				}
+			}
+			for _, notWant := range tt.notWant {
+				if strings.Contains(doc.HTML, notWant) {
+					t.Errorf("HTML contains %s:\n%s", notWant, doc.HTML)
+				}
+			}
+		})
+	}
+}
+
+func TestMarkdown_Headings(t *testing.T) {
+	doc, err := RenderMarkdown([]byte("# Guide\n\nIntro\n\n## Install `gotth`\n\n### On *Linux*\n"))
+	if err != nil {
+		t.Fatalf("RenderMarkdown() error = %v", err)
+	}
+	want := []Heading{
+		{Level: 1, ID: "guide", Text: "Guide"},
+		{Level: 2, ID: "install-gotth", Text: "Install gotth"},
+		{Level: 3, ID: "on-linux", Text: "On Linux"},
+	}
+	if len(doc.Headings) != len(want) {
+		t.Fatalf("Headings = %+v, want %+v", doc.Headings, want)
+	}
+	for i := range want {
+		if doc.Headings[i] != want[i] {
+			t.Errorf("Headings[%d] = %+v, want %+v", i, doc.Headings[i], want[i])
+		}
+	}
+
+	var out strings.Builder
+	if err := doc.Render(context.Background(), &out); err != nil || out.String() != doc.HTML {
+		t.Errorf("Render() = %q, %v, want the HTML", out.String(), err)
+	}
+}
+
+func TestMarkdown_HighlighterError(t *testing.T) {
+	errBoom := errors.New("boom")
+	md := NewMarkdown(WithHighlighter(HighlighterFunc(func(w io.Writer, code, lang string) error { return errBoom })))
+	if _, err := md.Render([]byte("```go\nx\n```\n")); !errors.Is(err, errBoom) {
+		t.Errorf("Render() error = %v, want %v", err, errBoom)
+	}
+}
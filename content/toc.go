@@ -0,0 +1,121 @@
+package content
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// TOCEntry is a heading of a table of contents, with the headings of its section.
+type TOCEntry struct {
+	Heading
+	Children []TOCEntry
+}
+
+// TOC returns the table of contents of the headings from minLevel to maxLevel, e.g. 2 and 3
+// to leave out the title of the page. A heading is nested under the previous heading of a lower
+// level, whatever the levels skipped in between. Headings without an ID can't be linked and
+// are left out.
+func TOC(headings []Heading, minLevel, maxLevel int) []TOCEntry {
+	var kept []Heading
+	for _, h := range headings {
+		if h.ID != "" && h.Level >= minLevel && h.Level <= maxLevel {
+			kept = append(kept, h)
+		}
+	}
+	return nest(kept)
+}
+
+func nest(headings []Heading) []TOCEntry {
+	var entries []TOCEntry
+	for i := 0; i < len(headings); {
+		// The section of headings[i] runs to the next heading of the same or a lower level.
+		end := i + 1
+		for end < len(headings) && headings[end].Level > headings[i].Level {
+			end++
+		}
+		entries = append(entries, TOCEntry{Heading: headings[i], Children: nest(headings[i+1 : end])})
+		i = end
+	}
+	return entries
+}
+
+// TOC returns the table of contents of the headings of d from minLevel to maxLevel.
+func (d Document) TOC(minLevel, maxLevel int) []TOCEntry {
+	return TOC(d.Headings, minLevel, maxLevel)
+}
+
+// HeadingsFromHTML returns the h1 to h6 headings of src, for content not rendered from Markdown.
+// The text of their aria-hidden elements, e.g. the anchor links, is left out.
+func HeadingsFromHTML(src string) []Heading {
+	var (
+		headings []Heading
+		current  *Heading // Heading being read
+		text     strings.Builder
+		hidden   int // Depth in aria-hidden elements
+	)
+	z := html.NewTokenizer(strings.NewReader(src))
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return headings
+		case html.StartTagToken:
+			tok := z.Token()
+			if level := headingLevel(tok.DataAtom); level > 0 && current == nil {
+				current = &Heading{Level: level, ID: attr(tok, "id")}
+				text.Reset()
+			} else if current != nil && (hidden > 0 || attr(tok, "aria-hidden") == "true") && !voidElement(tok.DataAtom) {
+				hidden++
+			}
+		case html.EndTagToken:
+			tok := z.Token()
+			if current != nil && headingLevel(tok.DataAtom) == current.Level {
+				current.Text = strings.Join(strings.Fields(text.String()), " ")
+				headings = append(headings, *current)
+				current, hidden = nil, 0
+			} else if hidden > 0 {
+				hidden--
+			}
+		case html.TextToken:
+			if current != nil && hidden == 0 {
+				text.Write(z.Text())
+			}
+		}
+	}
+}
+
+func headingLevel(a atom.Atom) int {
+	switch a {
+	case atom.H1:
+		return 1
+	case atom.H2:
+		return 2
+	case atom.H3:
+		return 3
+	case atom.H4:
+		return 4
+	case atom.H5:
+		return 5
+	case atom.H6:
+		return 6
+	}
+	return 0
+}
+
+func voidElement(a atom.Atom) bool {
+	switch a {
+	case atom.Br, atom.Img, atom.Wbr, atom.Input, atom.Hr:
+		return true
+	}
+	return false
+}
+
+func attr(tok html.Token, name string) string {
+	for _, a := range tok.Attr {
+		if a.Key == name {
+			return a.Val
+		}
+	}
+	return ""
+}
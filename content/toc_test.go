@@ -0,0 +1,49 @@
+package content
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTOC(t *testing.T) {
+	headings := []Heading{
+		{Level: 1, ID: "title", Text: "Title"},
+		{Level: 2, ID: "install", Text: "Install"},
+		{Level: 3, ID: "linux", Text: "Linux"},
+		{Level: 4, ID: "deep", Text: "Too deep"},
+		{Level: 3, ID: "macos", Text: "macOS"},
+		{Level: 2, ID: "usage", Text: "Usage"},
+		{Level: 2, Text: "No ID"},
+		{Level: 4, ID: "skipped-level", Text: "Skipped level"},
+	}
+	got := TOC(headings, 2, 4)
+	want := []TOCEntry{
+		{Heading: headings[1], Children: []TOCEntry{
+			{Heading: headings[2], Children: []TOCEntry{{Heading: headings[3]}}},
+			{Heading: headings[4]},
+		}},
+		{Heading: headings[5], Children: []TOCEntry{{Heading: headings[7]}}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("TOC() = %+v, want %+v", got, want)
+	}
+	if got := TOC(headings, 1, 1); len(got) != 1 || got[0].ID != "title" || got[0].Children != nil {
+		t.Errorf("TOC(1, 1) = %+v", got)
+	}
+}
+
+func TestHeadingsFromHTML(t *testing.T) {
+	doc, err := RenderMarkdown([]byte("# Hello *world*\n\nText\n\n## Fish &amp; chips\n"))
+	if err != nil {
+		t.Fatalf("RenderMarkdown() error = %v", err)
+	}
+	if got := HeadingsFromHTML(doc.HTML); !reflect.DeepEqual(got, doc.Headings) {
+		t.Errorf("HeadingsFromHTML() = %+v, want %+v", got, doc.Headings)
+	}
+
+	got := HeadingsFromHTML(`<h2 id="a">A<br>line <span aria-hidden="true"><img src="x">icon</span>end</h2><h3>No ID</h3>`)
+	want := []Heading{{Level: 2, ID: "a", Text: "Aline end"}, {Level: 3, Text: "No ID"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("HeadingsFromHTML() = %+v, want %+v", got, want)
+	}
+}
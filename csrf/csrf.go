@@ -0,0 +1,243 @@
+// Package csrf protects state changing requests against cross-site request forgery with signed
+// double-submit tokens tied to the user session.
+//
+// The token is stored in a cookie and must be echoed back by unsafe requests (POST, PUT,
+// PATCH, DELETE) in a form field, rendered with [Input], or in a header, set for all the HTMX
+// requests of a page with [HXHeaders]. Tokens are signed together with the session ID, so a
+// token issued for one session (or before login) is rejected for another.
+//
+// Multipart forms, e.g. file uploads, must render [Input] before any other field: only their
+// first part is read, so that the body stays available to the handler.
+package csrf
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/ancalabrese/gotth/middlewares"
+)
+
+const (
+	DefaultCookieName = "csrf_token"
+	DefaultHeaderName = "X-CSRF-Token"
+	DefaultFieldName  = "csrf_token"
+
+	tokenKey contextTokenKeyType = "gotth_csrf_token_key"
+
+	// maxFirstPartSize bounds the bytes of a multipart body read to find the token.
+	maxFirstPartSize = 4 << 10
+)
+
+type contextTokenKeyType string
+
+var (
+	// ErrMissingToken is returned when an unsafe request doesn't carry the token.
+	ErrMissingToken = errors.New("missing CSRF token")
+	// ErrInvalidToken is returned when the token doesn't match the cookie or the session.
+	ErrInvalidToken = errors.New("invalid CSRF token")
+)
+
+// Config configures the CSRF protection. Use [DefaultConfig] as a starting point.
+type Config struct {
+	// Name of the cookie holding the token.
+	CookieName string
+	// Name of the header unsafe requests can send the token in.
+	HeaderName string
+	// Name of the form field unsafe requests can send the token in.
+	FieldName string
+	// Session cookie the tokens are tied to. Must match the one used by the session middlewares.
+	Session middlewares.SessionConfig
+	// Secure restricts the token cookie to HTTPS connections.
+	Secure bool
+	// Optional: path prefixes whose unsafe requests aren't checked, e.g. "/webhooks/" for the
+	// deliveries authenticated by their signature (see the webhook package).
+	ExemptPaths []string
+}
+
+// DefaultConfig returns the default Config, tied to the [middlewares.DefaultSessionConfig] session.
+func DefaultConfig() Config {
+	return Config{
+		CookieName: DefaultCookieName,
+		HeaderName: DefaultHeaderName,
+		FieldName:  DefaultFieldName,
+		Session:    middlewares.DefaultSessionConfig(),
+		Secure:     true,
+	}
+}
+
+type tokenInfo struct {
+	token      string
+	fieldName  string
+	headerName string
+}
+
+// Protect returns a new middleware (http.Handler) that issues the CSRF token on every request
+// and verifies it on unsafe ones. Use [Token], [Input] or [HXHeaders] to send it back.
+// It calls onError when the token is missing or invalid. When onError is nil a plain
+// 403 Forbidden is returned.
+func Protect(secret []byte, cfg Config, onError func(http.ResponseWriter, *http.Request, error)) func(http.Handler) http.Handler {
+	if onError == nil {
+		onError = func(w http.ResponseWriter, r *http.Request, err error) {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			sessionID := ""
+			if c, err := r.Cookie(cfg.Session.CookieName()); err == nil {
+				sessionID = c.Value
+			}
+
+			cookieToken := ""
+			if c, err := r.Cookie(cfg.CookieName); err == nil && verify(secret, sessionID, c.Value) {
+				cookieToken = c.Value
+			}
+
+			if !isSafeMethod(r.Method) && !isExempt(r.URL.Path, cfg.ExemptPaths) {
+				submitted := r.Header.Get(cfg.HeaderName)
+				if submitted == "" {
+					submitted = formToken(r, cfg.FieldName)
+				}
+				if submitted == "" {
+					onError(w, r, ErrMissingToken)
+					return
+				}
+				if cookieToken == "" || subtle.ConstantTimeCompare([]byte(submitted), []byte(cookieToken)) != 1 {
+					onError(w, r, ErrInvalidToken)
+					return
+				}
+			}
+
+			if cookieToken == "" {
+				token, err := newToken(secret, sessionID)
+				if err != nil {
+					onError(w, r, err)
+					return
+				}
+				cookieToken = token
+				http.SetCookie(w, &http.Cookie{
+					Name:     cfg.CookieName,
+					Value:    token,
+					Path:     "/",
+					Secure:   cfg.Secure,
+					HttpOnly: true,
+					SameSite: http.SameSiteLaxMode,
+				})
+			}
+
+			info := tokenInfo{token: cookieToken, fieldName: cfg.FieldName, headerName: cfg.HeaderName}
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), tokenKey, info)))
+		})
+	}
+}
+
+// formToken returns the token sent in the field of a form. Multipart bodies aren't parsed, so
+// that the handler can stream them: the token is read from the first part and the bytes read
+// are put back in front of the body.
+func formToken(r *http.Request, field string) string {
+	mediaType, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/form-data" {
+		return r.PostFormValue(field)
+	}
+	if params["boundary"] == "" || r.Body == nil {
+		return ""
+	}
+
+	body := r.Body
+	var read bytes.Buffer
+	defer func() {
+		r.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(&read, body), body}
+	}()
+	mr := multipart.NewReader(io.TeeReader(io.LimitReader(body, maxFirstPartSize), &read), params["boundary"])
+	part, err := mr.NextPart()
+	if err != nil || part.FormName() != field || part.FileName() != "" {
+		return ""
+	}
+	token, err := io.ReadAll(part)
+	if err != nil {
+		return ""
+	}
+	return string(token)
+}
+
+// Token returns the CSRF token of the request, or an empty string if [Protect] isn't in use.
+func Token(ctx context.Context) string {
+	info, _ := ctx.Value(tokenKey).(tokenInfo)
+	return info.token
+}
+
+// HXHeaders returns the JSON value for an hx-headers attribute that adds the CSRF header to all
+// the HTMX requests issued by the element and its children, e.g.
+//
+//	<body hx-headers={ csrf.HXHeaders(ctx) }>
+func HXHeaders(ctx context.Context) string {
+	info, ok := ctx.Value(tokenKey).(tokenInfo)
+	if !ok {
+		return "{}"
+	}
+	b, _ := json.Marshal(map[string]string{info.headerName: info.token})
+	return string(b)
+}
+
+func fieldName(ctx context.Context) string {
+	if info, ok := ctx.Value(tokenKey).(tokenInfo); ok {
+		return info.fieldName
+	}
+	return DefaultFieldName
+}
+
+func isExempt(path string, prefixes []string) bool {
+	return slices.ContainsFunc(prefixes, func(prefix string) bool { return strings.HasPrefix(path, prefix) })
+}
+
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+	return false
+}
+
+// newToken returns "<random>.<HMAC(sessionID, random)>".
+func newToken(secret []byte, sessionID string) (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	nonce := base64.RawURLEncoding.EncodeToString(b)
+	return nonce + "." + sign(secret, sessionID, nonce), nil
+}
+
+// verify reports whether token was issued for sessionID.
+func verify(secret []byte, sessionID, token string) bool {
+	nonce, sig, ok := strings.Cut(token, ".")
+	if !ok || nonce == "" {
+		return false
+	}
+	return hmac.Equal([]byte(sig), []byte(sign(secret, sessionID, nonce)))
+}
+
+func sign(secret []byte, sessionID, nonce string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(sessionID))
+	mac.Write([]byte{0})
+	mac.Write([]byte(nonce))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,196 @@
+package csrf_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/ancalabrese/gotth/csrf"
+	"github.com/ancalabrese/gotth/middlewares"
+	"github.com/ancalabrese/gotth/uploads"
+)
+
+var secret = []byte("csrf-test-secret")
+
+// issue performs a GET to obtain a token cookie for the given session.
+func issue(t *testing.T, h http.Handler, sessionID string) *http.Cookie {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if sessionID != "" {
+		req.AddCookie(&http.Cookie{Name: middlewares.SESSION_COOKIE_NAME, Value: sessionID})
+	}
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+	for _, c := range rr.Result().Cookies() {
+		if c.Name == csrf.DefaultCookieName {
+			return c
+		}
+	}
+	t.Fatal("no CSRF cookie issued")
+	return nil
+}
+
+func TestProtect(t *testing.T) {
+	var tokenInHandler string
+	h := csrf.Protect(secret, csrf.DefaultConfig(), nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tokenInHandler = csrf.Token(r.Context())
+	}))
+
+	cookie := issue(t, h, "session-1")
+	if tokenInHandler != cookie.Value {
+		t.Fatalf("Token() = %q, want the cookie value %q", tokenInHandler, cookie.Value)
+	}
+	otherSessionCookie := issue(t, h, "session-2")
+
+	tests := []struct {
+		name           string
+		sessionID      string
+		cookie         *http.Cookie
+		header         string
+		form           url.Values
+		expectedStatus int
+	}{
+		{name: "Valid header", sessionID: "session-1", cookie: cookie, header: cookie.Value, expectedStatus: http.StatusOK},
+		{name: "Valid form field", sessionID: "session-1", cookie: cookie, form: url.Values{csrf.DefaultFieldName: {cookie.Value}}, expectedStatus: http.StatusOK},
+		{name: "Missing token", sessionID: "session-1", cookie: cookie, expectedStatus: http.StatusForbidden},
+		{name: "Missing cookie", sessionID: "session-1", header: cookie.Value, expectedStatus: http.StatusForbidden},
+		{name: "Token mismatch", sessionID: "session-1", cookie: cookie, header: cookie.Value + "x", expectedStatus: http.StatusForbidden},
+		{name: "Token of another session", sessionID: "session-1", cookie: otherSessionCookie, header: otherSessionCookie.Value, expectedStatus: http.StatusForbidden},
+		{name: "Token issued before login", sessionID: "session-3", cookie: cookie, header: cookie.Value, expectedStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body *strings.Reader
+			if tt.form != nil {
+				body = strings.NewReader(tt.form.Encode())
+			} else {
+				body = strings.NewReader("")
+			}
+			req := httptest.NewRequest(http.MethodPost, "/", body)
+			if tt.form != nil {
+				req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			}
+			if tt.header != "" {
+				req.Header.Set(csrf.DefaultHeaderName, tt.header)
+			}
+			if tt.cookie != nil {
+				req.AddCookie(tt.cookie)
+			}
+			req.AddCookie(&http.Cookie{Name: middlewares.SESSION_COOKIE_NAME, Value: tt.sessionID})
+
+			rr := httptest.NewRecorder()
+			h.ServeHTTP(rr, req)
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("status = %d, want %d", rr.Code, tt.expectedStatus)
+			}
+		})
+	}
+}
+
+func TestProtect_OnError(t *testing.T) {
+	var gotErr error
+	h := csrf.Protect(secret, csrf.DefaultConfig(), func(w http.ResponseWriter, r *http.Request, err error) {
+		gotErr = err
+		w.WriteHeader(http.StatusTeapot)
+	})(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodDelete, "/", nil))
+	if rr.Code != http.StatusTeapot || !errors.Is(gotErr, csrf.ErrMissingToken) {
+		t.Errorf("status = %d, err = %v; want %d, %v", rr.Code, gotErr, http.StatusTeapot, csrf.ErrMissingToken)
+	}
+}
+
+func TestProtect_ExemptPaths(t *testing.T) {
+	cfg := csrf.DefaultConfig()
+	cfg.ExemptPaths = []string{"/webhooks/"}
+	h := csrf.Protect(secret, cfg, nil)(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+
+	for path, want := range map[string]int{"/webhooks/stripe": http.StatusOK, "/contact": http.StatusForbidden} {
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, path, nil))
+		if rr.Code != want {
+			t.Errorf("POST %s status = %d, want %d", path, rr.Code, want)
+		}
+	}
+}
+
+func TestComponents(t *testing.T) {
+	var input bytes.Buffer
+	var hxHeaders string
+	h := csrf.Protect(secret, csrf.DefaultConfig(), nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		csrf.Input().Render(r.Context(), &input)
+		hxHeaders = csrf.HXHeaders(r.Context())
+	}))
+	cookie := issue(t, h, "")
+
+	if want := `<input type="hidden" name="csrf_token" value="` + cookie.Value + `">`; input.String() != want {
+		t.Errorf("Input() = %s, want %s", input.String(), want)
+	}
+	if want := `{"X-CSRF-Token":"` + cookie.Value + `"}`; hxHeaders != want {
+		t.Errorf("HXHeaders() = %s, want %s", hxHeaders, want)
+	}
+	if got := csrf.HXHeaders(context.Background()); got != "{}" {
+		t.Errorf("HXHeaders() without middleware = %s, want {}", got)
+	}
+}
+
+func TestProtect_MultipartUpload(t *testing.T) {
+	dir := t.TempDir()
+	store, err := uploads.NewDiskStorage(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := csrf.Protect(secret, csrf.DefaultConfig(), nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			return
+		}
+		if _, err := uploads.Receive(w, r, store, uploads.DefaultConfig()); err != nil {
+			t.Errorf("Receive() error = %v", err)
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	cookie := issue(t, h, "session-1")
+
+	upload := func(tokenFirst bool) int {
+		var body bytes.Buffer
+		mw := multipart.NewWriter(&body)
+		writeToken := func() { mw.WriteField(csrf.DefaultFieldName, cookie.Value) }
+		if tokenFirst {
+			writeToken()
+		}
+		fw, _ := mw.CreateFormFile(uploads.DefaultField, "notes.txt")
+		fw.Write(bytes.Repeat([]byte("notes "), 2000))
+		if !tokenFirst {
+			writeToken()
+		}
+		mw.Close()
+
+		req := httptest.NewRequest(http.MethodPost, "/upload", &body)
+		req.Header.Set("Content-Type", mw.FormDataContentType())
+		req.AddCookie(cookie)
+		req.AddCookie(&http.Cookie{Name: middlewares.SESSION_COOKIE_NAME, Value: "session-1"})
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		return rr.Code
+	}
+
+	if status := upload(true); status != http.StatusOK {
+		t.Errorf("token first: status = %d, want %d", status, http.StatusOK)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("stored %d files, want 1", len(entries))
+	}
+	// Only the first part is read: a token after the file isn't found.
+	if status := upload(false); status != http.StatusForbidden {
+		t.Errorf("token last: status = %d, want %d", status, http.StatusForbidden)
+	}
+}
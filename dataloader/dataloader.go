@@ -0,0 +1,314 @@
+// Package dataloader batches and memoizes the lookups of a request, so that the components of a
+// page can load the data they need without issuing the same query twice, nor one query per
+// item of a list:
+//
+//	var Authors = dataloader.NewKey(func(ctx context.Context, ids []int) (map[int]User, error) {
+//		return db.UsersByID(ctx, ids) // SELECT ... WHERE id IN (...)
+//	})
+//
+//	// In the handler, before rendering the list:
+//	Authors.Queue(r.Context(), authorIDs...)
+//	// In the components, anywhere in the page:
+//	author, err := Authors.Load(ctx, post.AuthorID)
+//
+// The loaders live for a request: add [Middleware] to the global middlewares. Loads queue their
+// key and run the batch function with all the keys queued so far, so queue the keys of a list
+// ahead with [Loader.Queue] or load them with [Loader.LoadMany]. When the loads run
+// concurrently, [WithWait] makes them wait a moment for each other and share a batch.
+package dataloader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const scopeKey contextScopeKeyType = "gotth_dataloader_scope_key"
+
+type contextScopeKeyType string
+
+// ErrNotFound is returned for the keys missing from the results of the batch function.
+var ErrNotFound = errors.New("dataloader: key not found")
+
+// BatchFunc returns the values of keys, e.g. from a single query. Keys without a value are
+// left out of the map.
+type BatchFunc[K comparable, V any] func(ctx context.Context, keys []K) (map[K]V, error)
+
+// Option configures a [Loader].
+type Option func(*options)
+
+type options struct {
+	wait     time.Duration
+	maxBatch int
+}
+
+// WithWait makes a batch wait d after its first key before running, for the keys of concurrent
+// loads. By default batches run as soon as a key is loaded.
+func WithWait(d time.Duration) Option {
+	return func(o *options) {
+		o.wait = d
+	}
+}
+
+// WithMaxBatch caps the number of keys of a batch, e.g. to the parameters a query accepts. By
+// default batches aren't capped.
+func WithMaxBatch(n int) Option {
+	return func(o *options) {
+		o.maxBatch = n
+	}
+}
+
+// Loader batches and memoizes the loads of the values V of the keys K. It's safe for
+// concurrent use. Values are cached for the life of the Loader, failed loads are not.
+type Loader[K comparable, V any] struct {
+	batchFn BatchFunc[K, V]
+	opts    options
+
+	mu      sync.Mutex
+	cache   map[K]*call[V]
+	pending *batch[K, V] // Keys queued and not running yet
+}
+
+type call[V any] struct {
+	done  chan struct{}
+	value V
+	err   error
+}
+
+type batch[K comparable, V any] struct {
+	ctx   context.Context
+	keys  []K
+	calls []*call[V]
+}
+
+// New returns a Loader of the values returned by batchFn.
+func New[K comparable, V any](batchFn BatchFunc[K, V], opts ...Option) *Loader[K, V] {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &Loader[K, V]{batchFn: batchFn, opts: o, cache: map[K]*call[V]{}}
+}
+
+// Load returns the value of key, running the batch function with the queued keys unless it's
+// cached or being loaded. It returns [ErrNotFound] when the batch function has no value for key.
+func (l *Loader[K, V]) Load(ctx context.Context, key K) (V, error) {
+	c := l.enqueue(ctx, []K{key})[0]
+	l.flush(ctx)
+	return wait(ctx, c)
+}
+
+// LoadMany returns the values of keys, loaded in a single batch, and the errors of the others
+// joined. The keys not found are left out without error.
+func (l *Loader[K, V]) LoadMany(ctx context.Context, keys ...K) (map[K]V, error) {
+	calls := l.enqueue(ctx, keys)
+	l.flush(ctx)
+	values := make(map[K]V, len(keys))
+	var errs []error
+	for i, c := range calls {
+		v, err := wait(ctx, c)
+		switch {
+		case err == nil:
+			values[keys[i]] = v
+		case !errors.Is(err, ErrNotFound):
+			errs = append(errs, err)
+		}
+	}
+	return values, errors.Join(errs...)
+}
+
+// Queue adds keys to the next batch without loading them, so that the loads of the items of a
+// list, e.g. in their components, share a batch.
+func (l *Loader[K, V]) Queue(ctx context.Context, keys ...K) {
+	l.enqueue(ctx, keys)
+}
+
+// Prime caches the value of key, e.g. a value loaded along with others.
+func (l *Loader[K, V]) Prime(key K, value V) {
+	c := &call[V]{done: make(chan struct{}), value: value}
+	close(c.done)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.cache[key] = c
+}
+
+// Clear removes the value of key from the cache, e.g. after updating it.
+func (l *Loader[K, V]) Clear(key K) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.cache, key)
+}
+
+// enqueue returns the calls of keys, adding the keys neither cached nor being loaded to the
+// pending batch.
+func (l *Loader[K, V]) enqueue(ctx context.Context, keys []K) []*call[V] {
+	calls := make([]*call[V], len(keys))
+	var full []*batch[K, V]
+
+	l.mu.Lock()
+	for i, key := range keys {
+		if c, ok := l.cache[key]; ok {
+			calls[i] = c
+			continue
+		}
+		c := &call[V]{done: make(chan struct{})}
+		l.cache[key] = c
+		calls[i] = c
+
+		if l.pending == nil {
+			l.pending = &batch[K, V]{ctx: context.WithoutCancel(ctx)}
+			if l.opts.wait > 0 {
+				b := l.pending
+				time.AfterFunc(l.opts.wait, func() { l.flushBatch(b) })
+			}
+		}
+		l.pending.keys = append(l.pending.keys, key)
+		l.pending.calls = append(l.pending.calls, c)
+		if l.opts.maxBatch > 0 && len(l.pending.keys) >= l.opts.maxBatch {
+			full = append(full, l.pending)
+			l.pending = nil
+		}
+	}
+	l.mu.Unlock()
+
+	for _, b := range full {
+		go l.run(b)
+	}
+	return calls
+}
+
+// flush runs the pending batch, unless it waits for other keys.
+func (l *Loader[K, V]) flush(ctx context.Context) {
+	if l.opts.wait > 0 {
+		return
+	}
+	l.mu.Lock()
+	b := l.pending
+	l.pending = nil
+	l.mu.Unlock()
+	if b != nil {
+		b.ctx = ctx
+		l.run(b)
+	}
+}
+
+// flushBatch runs b when it's still pending, at the end of its wait.
+func (l *Loader[K, V]) flushBatch(b *batch[K, V]) {
+	l.mu.Lock()
+	if l.pending != b {
+		l.mu.Unlock()
+		return
+	}
+	l.pending = nil
+	l.mu.Unlock()
+	l.run(b)
+}
+
+// run calls the batch function with the keys of b and completes their calls.
+func (l *Loader[K, V]) run(b *batch[K, V]) {
+	values, err := l.call(b.ctx, b.keys)
+
+	l.mu.Lock()
+	for i, key := range b.keys {
+		c := b.calls[i]
+		switch v, ok := values[key]; {
+		case err != nil:
+			c.err = err
+			if l.cache[key] == c { // Failed loads are retried
+				delete(l.cache, key)
+			}
+		case ok:
+			c.value = v
+		default:
+			c.err = fmt.Errorf("%w: %v", ErrNotFound, key)
+		}
+	}
+	l.mu.Unlock()
+	for _, c := range b.calls {
+		close(c.done)
+	}
+}
+
+func (l *Loader[K, V]) call(ctx context.Context, keys []K) (values map[K]V, err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("dataloader: batch function panicked: %v", p)
+		}
+	}()
+	return l.batchFn(ctx, keys)
+}
+
+func wait[V any](ctx context.Context, c *call[V]) (V, error) {
+	select {
+	case <-c.done:
+		return c.value, c.err
+	case <-ctx.Done():
+		var zero V
+		return zero, ctx.Err()
+	}
+}
+
+// Key identifies the Loader of a batch function in the scope of a request. Declare the keys
+// once, e.g. as package variables.
+type Key[K comparable, V any] struct {
+	batchFn BatchFunc[K, V]
+	opts    []Option
+}
+
+// NewKey returns the Key of the loaders of batchFn.
+func NewKey[K comparable, V any](batchFn BatchFunc[K, V], opts ...Option) *Key[K, V] {
+	return &Key[K, V]{batchFn: batchFn, opts: opts}
+}
+
+// From returns the Loader of the scope of ctx, created on first use. Outside of a scope, it
+// returns a new Loader, whose values aren't shared.
+func (k *Key[K, V]) From(ctx context.Context) *Loader[K, V] {
+	s, ok := ctx.Value(scopeKey).(*scope)
+	if !ok {
+		return New(k.batchFn, k.opts...)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if l, ok := s.loaders[k]; ok {
+		return l.(*Loader[K, V])
+	}
+	l := New(k.batchFn, k.opts...)
+	s.loaders[k] = l
+	return l
+}
+
+// Load returns the value of key with the Loader of ctx. See [Loader.Load].
+func (k *Key[K, V]) Load(ctx context.Context, key K) (V, error) {
+	return k.From(ctx).Load(ctx, key)
+}
+
+// LoadMany returns the values of keys with the Loader of ctx. See [Loader.LoadMany].
+func (k *Key[K, V]) LoadMany(ctx context.Context, keys ...K) (map[K]V, error) {
+	return k.From(ctx).LoadMany(ctx, keys...)
+}
+
+// Queue adds keys to the next batch of the Loader of ctx. See [Loader.Queue].
+func (k *Key[K, V]) Queue(ctx context.Context, keys ...K) {
+	k.From(ctx).Queue(ctx, keys...)
+}
+
+// scope holds the loaders of a request, by Key.
+type scope struct {
+	mu      sync.Mutex
+	loaders map[any]any
+}
+
+// WithScope returns a copy of ctx with a new scope of loaders, e.g. for a background job.
+func WithScope(ctx context.Context) context.Context {
+	return context.WithValue(ctx, scopeKey, &scope{loaders: map[any]any{}})
+}
+
+// Middleware gives every request its scope of loaders, dropped at the end of the request.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(WithScope(r.Context())))
+	})
+}
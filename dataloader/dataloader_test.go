@@ -0,0 +1,148 @@
+package dataloader_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ancalabrese/gotth/dataloader"
+)
+
+// recorder is a batch function of the squares of the positive keys, recording its batches.
+type recorder struct {
+	mu      sync.Mutex
+	batches [][]int
+	err     error
+}
+
+func (r *recorder) load(ctx context.Context, keys []int) (map[int]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.batches = append(r.batches, slices.Sorted(slices.Values(keys)))
+	if r.err != nil {
+		return nil, r.err
+	}
+	values := map[int]string{}
+	for _, k := range keys {
+		if k > 0 {
+			values[k] = strconv.Itoa(k * k)
+		}
+	}
+	return values, nil
+}
+
+func (r *recorder) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.batches)
+}
+
+func TestLoader(t *testing.T) {
+	ctx := context.Background()
+	rec := &recorder{}
+	l := dataloader.New(rec.load)
+
+	l.Queue(ctx, 1, 2, 3)
+	if v, err := l.Load(ctx, 2); v != "4" || err != nil {
+		t.Errorf("Load(2) = %q, %v", v, err)
+	}
+	if v, err := l.Load(ctx, 3); v != "9" || err != nil {
+		t.Errorf("Load(3) = %q, %v", v, err)
+	}
+	if _, err := l.Load(ctx, -1); !errors.Is(err, dataloader.ErrNotFound) {
+		t.Errorf("Load(-1) error = %v, want %v", err, dataloader.ErrNotFound)
+	}
+	values, err := l.LoadMany(ctx, 1, 4, 5, -1)
+	if err != nil || len(values) != 3 || values[5] != "25" {
+		t.Errorf("LoadMany() = %v, %v", values, err)
+	}
+	l.Prime(6, "primed")
+	if v, _ := l.Load(ctx, 6); v != "primed" {
+		t.Errorf("Load(6) = %q, want the primed value", v)
+	}
+	l.Clear(1)
+	l.Load(ctx, 1)
+
+	want := [][]int{{1, 2, 3}, {-1}, {4, 5}, {1}}
+	if !slices.EqualFunc(rec.batches, want, slices.Equal) {
+		t.Errorf("batches = %v, want %v", rec.batches, want)
+	}
+}
+
+func TestLoader_Errors(t *testing.T) {
+	ctx := context.Background()
+	rec := &recorder{err: errors.New("db down")}
+	l := dataloader.New(rec.load)
+	if _, err := l.Load(ctx, 1); err == nil || err.Error() != "db down" {
+		t.Errorf("Load() error = %v, want db down", err)
+	}
+	rec.err = nil
+	if v, err := l.Load(ctx, 1); v != "1" || err != nil {
+		t.Errorf("Load() after a failure = %q, %v, want a retry", v, err)
+	}
+
+	panicky := dataloader.New(func(ctx context.Context, keys []int) (map[int]int, error) { panic("boom") })
+	if _, err := panicky.Load(ctx, 1); err == nil {
+		t.Error("Load() of a panicking batch error = nil")
+	}
+}
+
+func TestLoader_Wait(t *testing.T) {
+	rec := &recorder{}
+	l := dataloader.New(rec.load, dataloader.WithWait(20*time.Millisecond), dataloader.WithMaxBatch(3))
+	var wg sync.WaitGroup
+	for i := 1; i <= 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if v, err := l.Load(context.Background(), i%4+1); err != nil || v == "" {
+				t.Errorf("Load(%d) = %q, %v", i%4+1, v, err)
+			}
+		}()
+	}
+	wg.Wait()
+	// 4 distinct keys, in a full batch of 3 and a batch of the last one at the end of the wait.
+	if rec.count() != 2 || len(rec.batches[0])+len(rec.batches[1]) != 4 {
+		t.Errorf("batches = %v, want 2 batches of the 4 keys", rec.batches)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	slow := dataloader.New(rec.load, dataloader.WithWait(time.Hour))
+	if _, err := slow.Load(ctx, 1); !errors.Is(err, context.Canceled) {
+		t.Errorf("Load() with a canceled context error = %v", err)
+	}
+}
+
+func TestKey(t *testing.T) {
+	rec := &recorder{}
+	squares := dataloader.NewKey(rec.load)
+
+	var handler http.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		squares.Queue(r.Context(), 1, 2)
+		for _, k := range []int{1, 2, 1} {
+			if _, err := squares.Load(r.Context(), k); err != nil {
+				t.Errorf("Load(%d) error = %v", k, err)
+			}
+		}
+	})
+	handler = dataloader.Middleware(handler)
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.count() != 2 {
+		t.Errorf("batches = %v, want one per request", rec.batches)
+	}
+
+	// Outside of a scope, loads aren't shared.
+	squares.Load(context.Background(), 1)
+	squares.Load(context.Background(), 1)
+	if rec.count() != 4 {
+		t.Errorf("batches = %v, want one per load outside of a scope", rec.batches)
+	}
+}
@@ -0,0 +1,264 @@
+// Package dbtx gives the handlers and content providers of a request their database handle: a
+// transaction for the requests changing data, committed when the handler succeeds and rolled
+// back when it fails, or the pool for the others.
+//
+//	ws, err := gotth.New(cfg, nil, gotth.WithMiddlewares(dbtx.Middleware(pool, dbtx.Config{})))
+//
+//	func createPost(w http.ResponseWriter, r *http.Request) {
+//		_, err := dbtx.From(r.Context()).ExecContext(r.Context(), "INSERT INTO posts ...")
+//		...
+//	}
+//
+// The transaction is committed just before the response starts with a status under 400, so
+// that a failed commit still turns into an error response, and rolled back for other statuses
+// and panics. Once it's finished, [From] returns the pool, e.g. for the queries of the
+// templates rendering the response.
+package dbtx
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"sync"
+
+	"github.com/ancalabrese/gotth/middlewares"
+)
+
+const connKey contextConnKeyType = "gotth_dbtx_key"
+
+type contextConnKeyType string
+
+// ErrNoDB is returned by [InTx] outside of the middleware and [WithDB].
+var ErrNoDB = errors.New("dbtx: no database in the context")
+
+// Querier is the part of *sql.DB and *sql.Tx running queries.
+type Querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+}
+
+var (
+	_ Querier = (*sql.DB)(nil)
+	_ Querier = (*sql.Tx)(nil)
+)
+
+// Config configures the [Middleware].
+type Config struct {
+	// Methods of the requests run in a transaction. Defaults to POST, PUT, PATCH and DELETE: the
+	// other requests only get the pool.
+	Methods []string
+	// Optional: options of the transactions, e.g. their isolation level.
+	TxOptions *sql.TxOptions
+	// Logger receives the failures to begin, commit or roll back. Defaults to slog.Default().
+	Logger *slog.Logger
+}
+
+// conn is the database handle of a request.
+type conn struct {
+	pool *sql.DB
+
+	mu           sync.Mutex
+	tx           *sql.Tx // nil once finished
+	rollbackOnly bool
+}
+
+// Middleware returns a new middleware (http.Handler) attaching pool to the requests, in a
+// transaction for cfg.Methods. Requests whose transaction can't begin or commit get a 500.
+func Middleware(pool *sql.DB, cfg Config) func(http.Handler) http.Handler {
+	if cfg.Methods == nil {
+		cfg.Methods = []string{http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete}
+	}
+	logger := cfg.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			c := &conn{pool: pool}
+			ctx := context.WithValue(r.Context(), connKey, c)
+			if !slices.Contains(cfg.Methods, r.Method) {
+				next.ServeHTTP(w, r.WithContext(ctx))
+				return
+			}
+
+			tx, err := pool.BeginTx(ctx, cfg.TxOptions)
+			if err != nil {
+				logger.ErrorContext(ctx, "failed to begin transaction", slog.String("path", r.URL.Path), slog.Any("error", err))
+				middlewares.WriteErrorPage(w, r, http.StatusInternalServerError, nil)
+				return
+			}
+			c.tx = tx
+
+			tw := &txWriter{ResponseWriter: w, conn: c, logger: logger, r: r}
+			defer func() {
+				if p := recover(); p != nil {
+					c.finish(false)
+					panic(p)
+				}
+				if !tw.wroteHeader {
+					// Nothing written: the implicit 200 OK.
+					tw.WriteHeader(http.StatusOK)
+				}
+			}()
+			next.ServeHTTP(tw, r.WithContext(ctx))
+		})
+	}
+}
+
+// finish commits the transaction, or rolls it back when commit is false or the request is
+// rollback only. It's a no-op once the transaction is finished.
+func (c *conn) finish(commit bool) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.tx == nil {
+		return nil
+	}
+	tx := c.tx
+	c.tx = nil
+	if !commit || c.rollbackOnly {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			return fmt.Errorf("failed to roll back. err %w", err)
+		}
+		return nil
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit. err %w", err)
+	}
+	return nil
+}
+
+// txWriter finishes the transaction when the response starts, before its status is sent.
+type txWriter struct {
+	http.ResponseWriter
+	conn        *conn
+	logger      *slog.Logger
+	r           *http.Request
+	wroteHeader bool
+	failed      bool // The commit failed, the writes of the handler are dropped
+}
+
+func (tw *txWriter) WriteHeader(status int) {
+	if tw.wroteHeader {
+		return
+	}
+	tw.wroteHeader = true
+	if err := tw.conn.finish(status < http.StatusBadRequest); err != nil {
+		tw.logger.ErrorContext(tw.r.Context(), "failed to finish transaction", slog.String("path", tw.r.URL.Path), slog.Any("error", err))
+		tw.failed = true
+		for k := range tw.Header() {
+			tw.Header().Del(k)
+		}
+		middlewares.WriteErrorPage(tw.ResponseWriter, tw.r, http.StatusInternalServerError, nil)
+		return
+	}
+	tw.ResponseWriter.WriteHeader(status)
+}
+
+func (tw *txWriter) Write(b []byte) (int, error) {
+	if !tw.wroteHeader {
+		tw.WriteHeader(http.StatusOK)
+	}
+	if tw.failed {
+		return len(b), nil
+	}
+	return tw.ResponseWriter.Write(b)
+}
+
+func (tw *txWriter) Flush() {
+	if !tw.wroteHeader {
+		tw.WriteHeader(http.StatusOK)
+	}
+	if !tw.failed {
+		http.NewResponseController(tw.ResponseWriter).Flush()
+	}
+}
+
+func (tw *txWriter) Unwrap() http.ResponseWriter {
+	return tw.ResponseWriter
+}
+
+func connFrom(ctx context.Context) *conn {
+	c, _ := ctx.Value(connKey).(*conn)
+	return c
+}
+
+// From returns the database handle of the request of ctx: its transaction while it runs, the
+// pool otherwise. It returns nil outside of the middleware and [WithDB].
+func From(ctx context.Context) Querier {
+	c := connFrom(ctx)
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.tx != nil {
+		return c.tx
+	}
+	return c.pool
+}
+
+// Tx returns the transaction of the request of ctx, while it runs.
+func Tx(ctx context.Context) (*sql.Tx, bool) {
+	c := connFrom(ctx)
+	if c == nil {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.tx, c.tx != nil
+}
+
+// Pool returns the pool of the request of ctx, or nil outside of the middleware and [WithDB].
+func Pool(ctx context.Context) *sql.DB {
+	if c := connFrom(ctx); c != nil {
+		return c.pool
+	}
+	return nil
+}
+
+// RollbackOnly rolls back the transaction of the request of ctx whatever the status of the
+// response, e.g. for a form re-rendered with validation errors with a 200 OK.
+func RollbackOnly(ctx context.Context) {
+	if c := connFrom(ctx); c != nil {
+		c.mu.Lock()
+		c.rollbackOnly = true
+		c.mu.Unlock()
+	}
+}
+
+// WithDB returns a copy of ctx holding pool, for the code run outside of the requests, e.g.
+// jobs and tests.
+func WithDB(ctx context.Context, pool *sql.DB) context.Context {
+	return context.WithValue(ctx, connKey, &conn{pool: pool})
+}
+
+// InTx runs fn in a transaction of the pool of ctx, committed when fn returns nil, e.g. for the
+// writes of a GET request. [From] returns the transaction in fn. Within the transaction of the
+// request, fn simply runs in it.
+func InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := Tx(ctx); ok {
+		return fn(ctx)
+	}
+	pool := Pool(ctx)
+	if pool == nil {
+		return ErrNoDB
+	}
+	tx, err := pool.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction. err %w", err)
+	}
+	c := &conn{pool: pool, tx: tx}
+	defer c.finish(false) // Rolls back unless committed, e.g. after a panic
+
+	if err := fn(context.WithValue(ctx, connKey, c)); err != nil {
+		return err
+	}
+	return c.finish(true)
+}
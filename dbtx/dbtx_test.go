@@ -0,0 +1,166 @@
+package dbtx_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ancalabrese/gotth/dbtx"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func newDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("failed to open db: %v", err)
+	}
+	db.SetMaxOpenConns(1) // every connection to :memory: is a new database
+	t.Cleanup(func() { db.Close() })
+	if _, err := db.Exec("CREATE TABLE posts (title TEXT)"); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func count(t *testing.T, db *sql.DB) int {
+	t.Helper()
+	var n int
+	if err := db.QueryRow("SELECT COUNT(*) FROM posts").Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func TestMiddleware(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		handler    func(w http.ResponseWriter, r *http.Request)
+		wantStatus int
+		wantRows   int
+	}{
+		{
+			name:       "Committed on success",
+			method:     http.MethodPost,
+			handler:    func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusCreated) },
+			wantStatus: http.StatusCreated,
+			wantRows:   1,
+		},
+		{
+			name:       "Committed without a write",
+			method:     http.MethodDelete,
+			handler:    func(w http.ResponseWriter, r *http.Request) {},
+			wantStatus: http.StatusOK,
+			wantRows:   1,
+		},
+		{
+			name:       "Rolled back on error",
+			method:     http.MethodPost,
+			handler:    func(w http.ResponseWriter, r *http.Request) { http.Error(w, "invalid", http.StatusUnprocessableEntity) },
+			wantStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:   "Rolled back when rollback only",
+			method: http.MethodPost,
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				dbtx.RollbackOnly(r.Context())
+				w.Write([]byte("form with errors"))
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "Pool for GET",
+			method:     http.MethodGet,
+			handler:    func(w http.ResponseWriter, r *http.Request) {},
+			wantStatus: http.StatusOK,
+			wantRows:   1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newDB(t)
+			h := dbtx.Middleware(db, dbtx.Config{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, inTx := dbtx.Tx(r.Context())
+				if inTx != (tt.method != http.MethodGet) {
+					t.Errorf("in transaction = %v", inTx)
+				}
+				if _, err := dbtx.From(r.Context()).ExecContext(r.Context(), "INSERT INTO posts VALUES ('a')"); err != nil {
+					t.Fatalf("ExecContext() error = %v", err)
+				}
+				tt.handler(w, r)
+				// The transaction is finished once the response started: From falls back to the pool.
+				if w.Header().Get("Content-Type") != "" {
+					if _, inTx := dbtx.Tx(r.Context()); inTx || dbtx.From(r.Context()) != dbtx.Pool(r.Context()) {
+						t.Error("transaction still running after the response started")
+					}
+				}
+			}))
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest(tt.method, "/posts", nil))
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if n := count(t, db); n != tt.wantRows {
+				t.Errorf("rows = %d, want %d", n, tt.wantRows)
+			}
+		})
+	}
+}
+
+func TestMiddleware_Panic(t *testing.T) {
+	db := newDB(t)
+	h := dbtx.Middleware(db, dbtx.Config{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		dbtx.From(r.Context()).ExecContext(r.Context(), "INSERT INTO posts VALUES ('a')")
+		panic("boom")
+	}))
+	func() {
+		defer func() {
+			if p := recover(); p != "boom" {
+				t.Errorf("recover() = %v, want the panic of the handler", p)
+			}
+		}()
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/", nil))
+	}()
+	if n := count(t, db); n != 0 {
+		t.Errorf("rows = %d after a panic, want 0", n)
+	}
+}
+
+func TestMiddleware_CommitFailure(t *testing.T) {
+	db := newDB(t)
+	h := dbtx.Middleware(db, dbtx.Config{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tx, _ := dbtx.Tx(r.Context())
+		tx.Rollback() // The commit of the middleware fails
+		w.Write([]byte("created"))
+	}))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", nil))
+	if rec.Code != http.StatusInternalServerError || rec.Body.String() != "Internal Server Error\n" {
+		t.Errorf("response = %d %q, want a 500", rec.Code, rec.Body.String())
+	}
+}
+
+func TestInTx(t *testing.T) {
+	db := newDB(t)
+	ctx := dbtx.WithDB(context.Background(), db)
+	err := dbtx.InTx(ctx, func(ctx context.Context) error {
+		dbtx.From(ctx).ExecContext(ctx, "INSERT INTO posts VALUES ('a')")
+		return errors.New("invalid")
+	})
+	if err == nil || count(t, db) != 0 {
+		t.Errorf("InTx() = %v, %d rows, want the error and a rollback", err, count(t, db))
+	}
+	if err := dbtx.InTx(ctx, func(ctx context.Context) error {
+		_, err := dbtx.From(ctx).ExecContext(ctx, "INSERT INTO posts VALUES ('a')")
+		return err
+	}); err != nil || count(t, db) != 1 {
+		t.Errorf("InTx() = %v, %d rows, want a commit", err, count(t, db))
+	}
+	if err := dbtx.InTx(context.Background(), func(ctx context.Context) error { return nil }); !errors.Is(err, dbtx.ErrNoDB) {
+		t.Errorf("InTx() without a database error = %v, want %v", err, dbtx.ErrNoDB)
+	}
+}
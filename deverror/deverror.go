@@ -0,0 +1,120 @@
+// Package deverror renders the developer error pages of DevMode: the error chain, the stack
+// trace, the request details and the recent log lines of the server, instead of a bare 500.
+// gotth.WebServer renders them for content provider errors and panics when
+// WebServerConfig.DevMode is set. Never render them in production: they leak the internals of
+// the application.
+//
+//	logs := deverror.NewLogBuffer(slog.Default().Handler(), 50)
+//	slog.SetDefault(slog.New(logs))
+//	...
+//	deverror.Page(deverror.ForError(r, err, debug.Stack(), logs.Lines())).Render(ctx, w)
+package deverror
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// Link is an error of the chain, from the returned error to its causes.
+type Link struct {
+	// Type is the Go type of the error, e.g. "*fs.PathError".
+	Type    string
+	Message string
+	// Depth is the nesting of the error, for the causes of errors.Join.
+	Depth int
+}
+
+// Header is a request header. Credentials are redacted.
+type Header struct {
+	Name  string
+	Value string
+}
+
+// Info holds the details rendered by [Page].
+type Info struct {
+	// Title summarizes the failure, e.g. the panic value.
+	Title string
+	// Kind is "Error" or "Panic".
+	Kind   string
+	Chain  []Link
+	Stack  string
+	Method string
+	URL    string
+	Proto  string
+	Remote string
+	// Headers are sorted by name.
+	Headers []Header
+	// Logs are the recent log lines, the oldest first.
+	Logs []string
+}
+
+// redactedHeaders carry credentials, left out of the page in case it's shared.
+var redactedHeaders = map[string]bool{"Authorization": true, "Cookie": true, "Proxy-Authorization": true}
+
+// ForError returns the Info of err, returned while serving r. stack is where it was handled,
+// e.g. debug.Stack(), as Go errors don't carry their own.
+func ForError(r *http.Request, err error, stack []byte, logs []string) Info {
+	info := requestInfo(r, stack, logs)
+	info.Kind = "Error"
+	info.Title = err.Error()
+	info.Chain = Chain(err)
+	return info
+}
+
+// ForPanic returns the Info of the panic p recovered while serving r, with the stack of the
+// panicking goroutine.
+func ForPanic(r *http.Request, p any, stack []byte, logs []string) Info {
+	info := requestInfo(r, stack, logs)
+	info.Kind = "Panic"
+	info.Title = fmt.Sprint(p)
+	if err, ok := p.(error); ok {
+		info.Chain = Chain(err)
+	}
+	return info
+}
+
+func requestInfo(r *http.Request, stack []byte, logs []string) Info {
+	info := Info{
+		Stack:  string(stack),
+		Method: r.Method,
+		URL:    r.URL.String(),
+		Proto:  r.Proto,
+		Remote: r.RemoteAddr,
+		Logs:   logs,
+	}
+	for name, values := range r.Header {
+		value := strings.Join(values, ", ")
+		if redactedHeaders[name] {
+			value = "[redacted]"
+		}
+		info.Headers = append(info.Headers, Header{Name: name, Value: value})
+	}
+	if r.Host != "" {
+		info.Headers = append(info.Headers, Header{Name: "Host", Value: r.Host})
+	}
+	slices.SortFunc(info.Headers, func(a, b Header) int { return strings.Compare(a.Name, b.Name) })
+	return info
+}
+
+// Chain unwraps err into the list of its causes, depth-first through errors.Join.
+func Chain(err error) []Link {
+	var chain []Link
+	var walk func(err error, depth int)
+	walk = func(err error, depth int) {
+		for err != nil {
+			chain = append(chain, Link{Type: fmt.Sprintf("%T", err), Message: err.Error(), Depth: depth})
+			if joined, ok := err.(interface{ Unwrap() []error }); ok {
+				for _, e := range joined.Unwrap() {
+					walk(e, depth+1)
+				}
+				return
+			}
+			err = errors.Unwrap(err)
+		}
+	}
+	walk(err, 0)
+	return chain
+}
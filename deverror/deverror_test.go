@@ -0,0 +1,113 @@
+package deverror_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ancalabrese/gotth/deverror"
+)
+
+func TestChain(t *testing.T) {
+	pathErr := &fs.PathError{Op: "open", Path: "posts/1.md", Err: fs.ErrNotExist}
+	err := fmt.Errorf("failed to load post. err %w", errors.Join(pathErr, errors.New("cache miss")))
+
+	expected := []deverror.Link{
+		{Type: "*fmt.wrapError", Message: err.Error()},
+		{Type: "*errors.joinError", Message: "open posts/1.md: file does not exist\ncache miss"},
+		{Type: "*fs.PathError", Message: "open posts/1.md: file does not exist", Depth: 1},
+		{Type: "*errors.errorString", Message: "file does not exist", Depth: 1},
+		{Type: "*errors.errorString", Message: "cache miss", Depth: 1},
+	}
+	if got := deverror.Chain(err); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Chain() = %+v\nwant %+v", got, expected)
+	}
+}
+
+func TestForError(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/posts/1?draft=1", nil)
+	r.Header.Set("Cookie", "session=secret")
+	r.Header.Set("Authorization", "Bearer secret")
+	r.Header.Set("Accept", "text/html")
+
+	info := deverror.ForError(r, errors.New("boom"), []byte("goroutine 1"), []string{"line"})
+
+	if info.Kind != "Error" || info.Title != "boom" || info.Method != http.MethodPost || info.URL != "/posts/1?draft=1" || info.Stack != "goroutine 1" {
+		t.Errorf("ForError() = %+v", info)
+	}
+	expected := []deverror.Header{
+		{Name: "Accept", Value: "text/html"},
+		{Name: "Authorization", Value: "[redacted]"},
+		{Name: "Cookie", Value: "[redacted]"},
+		{Name: "Host", Value: "example.com"},
+	}
+	if !reflect.DeepEqual(info.Headers, expected) {
+		t.Errorf("Headers = %+v, want %+v", info.Headers, expected)
+	}
+}
+
+func TestPage(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/posts/1", nil)
+	err := fmt.Errorf("failed to load post. err %w", fs.ErrNotExist)
+	info := deverror.ForError(r, err, []byte("main.handler()\n\tmain.go:12"), []string{"12:00:00.000 INFO <started>"})
+
+	var b strings.Builder
+	if err := deverror.Page(info).Render(context.Background(), &b); err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	html := b.String()
+	for _, want := range []string{
+		"<title>Error: failed to load post. err file does not exist</title>",
+		"<code class=\"type\">*fmt.wrapError</code>",
+		"main.go:12",
+		"12:00:00.000 INFO &lt;started&gt;",
+		"<th>URL</th><td>/posts/1</td>",
+		"body { margin: 0;",
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("page doesn't contain %q:\n%s", want, html)
+		}
+	}
+}
+
+func TestForPanic(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	if info := deverror.ForPanic(r, "boom", nil, nil); info.Kind != "Panic" || info.Title != "boom" || info.Chain != nil {
+		t.Errorf("ForPanic(string) = %+v", info)
+	}
+	if info := deverror.ForPanic(r, fmt.Errorf("wrapped: %w", fs.ErrClosed), nil, nil); len(info.Chain) != 2 {
+		t.Errorf("ForPanic(error) chain = %+v, want 2 links", info.Chain)
+	}
+}
+
+func TestLogBuffer(t *testing.T) {
+	var out strings.Builder
+	buf := deverror.NewLogBuffer(slog.NewTextHandler(&out, &slog.HandlerOptions{Level: slog.LevelInfo}), 3)
+	logger := slog.New(buf).With("app", "blog").WithGroup("req")
+
+	logger.Debug("hidden")
+	for i := range 4 {
+		logger.Info("served", "n", i, slog.Group("user", "id", 7))
+	}
+
+	lines := buf.Lines()
+	if len(lines) != 3 {
+		t.Fatalf("Lines() = %q, want the last 3", lines)
+	}
+	for i, line := range lines {
+		want := fmt.Sprintf(" INFO served app=blog req.n=%d req.user.id=7", i+1)
+		if !strings.HasSuffix(line, want) {
+			t.Errorf("line %d = %q, want suffix %q", i, line, want)
+		}
+	}
+	if got := strings.Count(out.String(), "\n"); got != 4 {
+		t.Errorf("next handler got %d lines, want 4", got)
+	}
+}
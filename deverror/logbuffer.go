@@ -0,0 +1,100 @@
+package deverror
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LogBuffer is a slog.Handler keeping the last log lines for the error pages, and passing the
+// records on to the next handler.
+type LogBuffer struct {
+	next   slog.Handler
+	ring   *ring
+	attrs  string
+	prefix string // Group of the attributes added next
+}
+
+type ring struct {
+	mu    sync.Mutex
+	lines []string
+	next  int
+	full  bool
+}
+
+// NewLogBuffer returns a LogBuffer keeping the last size lines logged through it, at the level
+// of next.
+func NewLogBuffer(next slog.Handler, size int) *LogBuffer {
+	return &LogBuffer{next: next, ring: &ring{lines: make([]string, max(size, 1))}}
+}
+
+// Lines returns the buffered lines, the oldest first.
+func (b *LogBuffer) Lines() []string {
+	b.ring.mu.Lock()
+	defer b.ring.mu.Unlock()
+	if !b.ring.full {
+		return append([]string(nil), b.ring.lines[:b.ring.next]...)
+	}
+	return append(append([]string(nil), b.ring.lines[b.ring.next:]...), b.ring.lines[:b.ring.next]...)
+}
+
+// Enabled reports whether next handles level.
+func (b *LogBuffer) Enabled(ctx context.Context, level slog.Level) bool {
+	return b.next.Enabled(ctx, level)
+}
+
+// Handle buffers the record formatted as a text line and passes it to next.
+func (b *LogBuffer) Handle(ctx context.Context, r slog.Record) error {
+	var line strings.Builder
+	line.WriteString(r.Time.Format(time.TimeOnly + ".000"))
+	line.WriteString(" " + r.Level.String() + " " + r.Message)
+	line.WriteString(b.attrs)
+	r.Attrs(func(a slog.Attr) bool {
+		writeAttr(&line, b.prefix, a)
+		return true
+	})
+
+	b.ring.mu.Lock()
+	b.ring.lines[b.ring.next] = line.String()
+	b.ring.next = (b.ring.next + 1) % len(b.ring.lines)
+	b.ring.full = b.ring.full || b.ring.next == 0
+	b.ring.mu.Unlock()
+
+	return b.next.Handle(ctx, r)
+}
+
+// WithAttrs returns a LogBuffer sharing the lines of b, adding attrs to the records.
+func (b *LogBuffer) WithAttrs(attrs []slog.Attr) slog.Handler {
+	var line strings.Builder
+	for _, a := range attrs {
+		writeAttr(&line, b.prefix, a)
+	}
+	return &LogBuffer{next: b.next.WithAttrs(attrs), ring: b.ring, attrs: b.attrs + line.String(), prefix: b.prefix}
+}
+
+// WithGroup returns a LogBuffer sharing the lines of b, qualifying the attributes with name.
+func (b *LogBuffer) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return b
+	}
+	return &LogBuffer{next: b.next.WithGroup(name), ring: b.ring, attrs: b.attrs, prefix: b.prefix + name + "."}
+}
+
+func writeAttr(line *strings.Builder, prefix string, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			prefix += a.Key + "."
+		}
+		for _, ga := range a.Value.Group() {
+			writeAttr(line, prefix, ga)
+		}
+		return
+	}
+	line.WriteString(" " + prefix + a.Key + "=" + a.Value.String())
+}
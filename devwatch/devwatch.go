@@ -0,0 +1,196 @@
+// Package devwatch watches the sources of a site in development and runs the generators, such
+// as templ generate and the Tailwind CLI, when they change. With WebServerConfig.DevMode the
+// WebServer runs it and reloads the browser afterwards, handling the whole edit-refresh loop:
+//
+//	ws, _ := gotth.New(gotth.WebServerConfig{
+//		DevMode:  true,
+//		DevWatch: []string{"."},
+//		DevTasks: []devwatch.Task{
+//			devwatch.TemplTask(),
+//			devwatch.TailwindTask("static/tailwind.css", "static/dist/style.css"),
+//		},
+//	}, nil)
+//
+// Files are polled, which works the same on every platform and in containers with mounted
+// volumes.
+package devwatch
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// DefaultInterval is how often the directories are scanned.
+const DefaultInterval = 500 * time.Millisecond
+
+// Task is a command run when a matching file changes.
+type Task struct {
+	// Name identifies the task in the logs.
+	Name string
+	// Patterns are the filepath.Match patterns of the file names that trigger the task, e.g.
+	// "*.templ".
+	Patterns []string
+	// Command is the program and its arguments.
+	Command []string
+}
+
+// TemplTask returns the Task running templ generate when a .templ file changes.
+func TemplTask() Task {
+	return Task{Name: "templ", Patterns: []string{"*.templ"}, Command: []string{"templ", "generate"}}
+}
+
+// TailwindTask returns the Task running the Tailwind CLI on input when a stylesheet or a templ
+// file, where the classes are used, changes.
+func TailwindTask(input, output string) Task {
+	return Task{
+		Name:     "tailwind",
+		Patterns: []string{"*.css", "*.templ"},
+		Command:  []string{"tailwindcss", "-i", input, "-o", output},
+	}
+}
+
+// matches reports whether the name of any of the files matches the task patterns.
+func (t Task) matches(files []string) bool {
+	for _, f := range files {
+		for _, p := range t.Patterns {
+			if ok, _ := filepath.Match(p, filepath.Base(f)); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Config configures Run.
+type Config struct {
+	// Dirs are the watched directories. Hidden directories and node_modules are skipped.
+	Dirs []string
+	// Interval between scans. Defaults to [DefaultInterval].
+	Interval time.Duration
+	// Tasks run, in order, when a matching file changes.
+	Tasks []Task
+	// Optional: OnChange is called with the changed files once the matching tasks have
+	// succeeded, e.g. to reload the browser. The files written by the tasks are not reported.
+	OnChange func(changed []string)
+	// Output of the tasks. Default to os.Stdout and os.Stderr.
+	Stdout, Stderr io.Writer
+}
+
+// Run watches cfg.Dirs until ctx is done.
+func Run(ctx context.Context, cfg Config) {
+	if cfg.Interval <= 0 {
+		cfg.Interval = DefaultInterval
+	}
+	if cfg.Stdout == nil {
+		cfg.Stdout = os.Stdout
+	}
+	if cfg.Stderr == nil {
+		cfg.Stderr = os.Stderr
+	}
+
+	ticker := time.NewTicker(cfg.Interval)
+	defer ticker.Stop()
+
+	last := snapshot(cfg.Dirs)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		current := snapshot(cfg.Dirs)
+		changed := diff(last, current)
+		if len(changed) == 0 {
+			continue
+		}
+
+		ok := true
+		ran := false
+		for _, t := range cfg.Tasks {
+			if !t.matches(changed) {
+				continue
+			}
+			ran = true
+			if err := run(ctx, t, cfg.Stdout, cfg.Stderr); err != nil {
+				fmt.Fprintf(cfg.Stderr, "devwatch: task %s failed. err %v\n", t.Name, err)
+				ok = false
+				break
+			}
+		}
+		if ran {
+			// Skip the files written by the tasks, e.g. the Tailwind output, which would
+			// trigger them again.
+			current = snapshot(cfg.Dirs)
+		}
+		last = current
+
+		if ok && cfg.OnChange != nil {
+			cfg.OnChange(changed)
+		}
+	}
+}
+
+func run(ctx context.Context, t Task, stdout, stderr io.Writer) error {
+	if len(t.Command) == 0 {
+		return fmt.Errorf("no command")
+	}
+	cmd := exec.CommandContext(ctx, t.Command[0], t.Command[1:]...)
+	cmd.Stdout, cmd.Stderr = stdout, stderr
+	return cmd.Run()
+}
+
+// fileState is what a change of a file is detected from.
+type fileState struct {
+	size    int64
+	modTime int64
+}
+
+func snapshot(dirs []string) map[string]fileState {
+	files := map[string]fileState{}
+	for _, dir := range dirs {
+		filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+			if d.IsDir() {
+				if path != dir && (strings.HasPrefix(d.Name(), ".") || d.Name() == "node_modules") {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil {
+				return nil
+			}
+			files[path] = fileState{size: info.Size(), modTime: info.ModTime().UnixNano()}
+			return nil
+		})
+	}
+	return files
+}
+
+// diff returns the files added, modified or removed between the snapshots.
+func diff(before, after map[string]fileState) []string {
+	var changed []string
+	for path, s := range after {
+		if prev, ok := before[path]; !ok || prev != s {
+			changed = append(changed, path)
+		}
+	}
+	for path := range before {
+		if _, ok := after[path]; !ok {
+			changed = append(changed, path)
+		}
+	}
+	slices.Sort(changed)
+	return changed
+}
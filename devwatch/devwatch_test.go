@@ -0,0 +1,95 @@
+package devwatch_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ancalabrese/gotth/devwatch"
+)
+
+func TestRun(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh is not available")
+	}
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "page.templ"), []byte("a"), 0o644)
+	os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("a"), 0o644)
+	os.MkdirAll(filepath.Join(dir, "node_modules"), 0o755)
+
+	// The task writes a file matching its own pattern, which must not trigger it again.
+	out := filepath.Join(dir, "gen.templ")
+	changes := make(chan []string, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go devwatch.Run(ctx, devwatch.Config{
+		Dirs:     []string{dir},
+		Interval: 10 * time.Millisecond,
+		Tasks:    []devwatch.Task{{Name: "gen", Patterns: []string{"*.templ"}, Command: []string{"sh", "-c", "date +%N >> " + out}}},
+		OnChange: func(changed []string) { changes <- changed },
+		Stdout:   io.Discard,
+		Stderr:   io.Discard,
+	})
+
+	tests := []struct {
+		name  string
+		write string
+		want  []string
+	}{
+		{name: "Matching file runs the task", write: "page.templ", want: []string{filepath.Join(dir, "page.templ")}},
+		{name: "Other file", write: "notes.txt", want: []string{filepath.Join(dir, "notes.txt")}},
+		{name: "Skipped directory", write: "node_modules/lib.js"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			time.Sleep(30 * time.Millisecond)
+			os.WriteFile(filepath.Join(dir, tt.write), []byte(tt.name), 0o644)
+
+			select {
+			case got := <-changes:
+				if !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("changed = %v, want %v", got, tt.want)
+				}
+			case <-time.After(300 * time.Millisecond):
+				if tt.want != nil {
+					t.Errorf("no change reported, want %v", tt.want)
+				}
+			}
+		})
+	}
+
+	runs, _ := os.ReadFile(out)
+	if n := bytes.Count(runs, []byte("\n")); n != 1 {
+		t.Errorf("task ran %d times, want 1", n)
+	}
+}
+
+func TestRun_FailedTask(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "style.css"), []byte("a"), 0o644)
+
+	changes := make(chan []string, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go devwatch.Run(ctx, devwatch.Config{
+		Dirs:     []string{dir},
+		Interval: 10 * time.Millisecond,
+		Tasks:    []devwatch.Task{{Name: "missing", Patterns: []string{"*.css"}, Command: []string{"gotth-missing-command"}}},
+		OnChange: func(changed []string) { changes <- changed },
+		Stderr:   io.Discard,
+	})
+
+	time.Sleep(30 * time.Millisecond)
+	os.WriteFile(filepath.Join(dir, "style.css"), []byte("b"), 0o644)
+	select {
+	case got := <-changes:
+		t.Errorf("OnChange called after a failed task with %v", got)
+	case <-time.After(200 * time.Millisecond):
+	}
+}
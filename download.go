@@ -0,0 +1,131 @@
+package gotth
+
+import (
+	"bufio"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DownloadOption configures [ServeDownload].
+type DownloadOption func(*downloadOptions)
+
+type downloadOptions struct {
+	inline      bool
+	contentType string
+}
+
+// DownloadInline lets the browser display the file, e.g. a PDF or an image, instead of saving
+// it. Types that would run scripts in the origin of the site, e.g. HTML or SVG, are always
+// downloaded as attachments.
+func DownloadInline() DownloadOption {
+	return func(o *downloadOptions) {
+		o.inline = true
+	}
+}
+
+// DownloadContentType sets the Content-Type of the file instead of detecting it.
+func DownloadContentType(contentType string) DownloadOption {
+	return func(o *downloadOptions) {
+		o.contentType = contentType
+	}
+}
+
+// activeContentTypes are the types a browser would run in the origin of the site.
+var activeContentTypes = []string{
+	"text/html", "application/xhtml+xml", "image/svg+xml", "text/xml", "application/xml",
+	"text/javascript", "application/javascript",
+}
+
+// ServeDownload sends the file named name read from content, as an attachment unless
+// [DownloadInline], from a handler behind the usual middlewares, e.g. after checking that the
+// user may download it, instead of exposing the files through an http.FileServer.
+//
+// The Content-Type is detected from the extension of name, or else from the first bytes of
+// content. Range and conditional requests are supported when content is an io.ReadSeeker,
+// e.g. an *os.File, otherwise the file is streamed once. size is the length of content, or -1
+// when unknown, and modtime its modification time, zero when unknown. ServeDownload doesn't
+// close content.
+//
+//	f, err := os.Open(filepath.Join(dir, invoice.File))
+//	...
+//	defer f.Close()
+//	info, _ := f.Stat()
+//	gotth.ServeDownload(w, r, f, "invoice-2026-01.pdf", info.Size(), info.ModTime(), gotth.DownloadInline())
+func ServeDownload(w http.ResponseWriter, r *http.Request, content io.Reader, name string, size int64, modtime time.Time, opts ...DownloadOption) {
+	var o downloadOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	name = path.Base(strings.ReplaceAll(name, `\`, "/"))
+	if name == "." || name == "/" {
+		name = "download"
+	}
+
+	contentType := o.contentType
+	if contentType == "" {
+		contentType = mime.TypeByExtension(path.Ext(name))
+	}
+	if contentType == "" {
+		var err error
+		if contentType, content, err = sniff(content); err != nil {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	disposition := "attachment"
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if o.inline && !slices.Contains(activeContentTypes, mediaType) {
+		disposition = "inline"
+	}
+	h := w.Header()
+	h.Set("Content-Type", contentType)
+	h.Set("Content-Disposition", mime.FormatMediaType(disposition, map[string]string{"filename": name}))
+	h.Set("X-Content-Type-Options", "nosniff")
+
+	if rs, ok := content.(io.ReadSeeker); ok {
+		// Ranges, If-Modified-Since, If-Range and HEAD.
+		http.ServeContent(w, r, name, modtime, rs)
+		return
+	}
+
+	h.Set("Accept-Ranges", "none")
+	if !modtime.IsZero() {
+		h.Set("Last-Modified", modtime.UTC().Format(http.TimeFormat))
+	}
+	if size >= 0 {
+		h.Set("Content-Length", strconv.FormatInt(size, 10))
+	}
+	w.WriteHeader(http.StatusOK)
+	if r.Method != http.MethodHead {
+		io.Copy(w, content)
+	}
+}
+
+// sniff detects the Content-Type of the first bytes of content, and returns the content to
+// read from the start.
+func sniff(content io.Reader) (string, io.Reader, error) {
+	if rs, ok := content.(io.ReadSeeker); ok {
+		var buf [512]byte
+		n, err := io.ReadFull(rs, buf[:])
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return "", nil, err
+		}
+		if _, err := rs.Seek(int64(-n), io.SeekCurrent); err != nil {
+			return "", nil, err
+		}
+		return http.DetectContentType(buf[:n]), rs, nil
+	}
+	br := bufio.NewReaderSize(content, 512)
+	head, err := br.Peek(512)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return "", nil, err
+	}
+	return http.DetectContentType(head), br, nil
+}
@@ -0,0 +1,120 @@
+package gotth_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ancalabrese/gotth"
+)
+
+func TestServeDownload(t *testing.T) {
+	modtime := time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC)
+	tests := []struct {
+		name        string
+		content     func() io.Reader
+		file        string
+		opts        []gotth.DownloadOption
+		header      http.Header
+		status      int
+		body        string
+		contentType string
+		disposition string
+	}{
+		{
+			name:        "Attachment",
+			content:     func() io.Reader { return strings.NewReader(`{"total":3}`) },
+			file:        "report.json",
+			status:      http.StatusOK,
+			body:        `{"total":3}`,
+			contentType: "application/json",
+			disposition: `attachment; filename=report.json`,
+		},
+		{
+			name:        "Inline",
+			content:     func() io.Reader { return strings.NewReader("%PDF-1.7") },
+			file:        "invoice.pdf",
+			opts:        []gotth.DownloadOption{gotth.DownloadInline()},
+			status:      http.StatusOK,
+			body:        "%PDF-1.7",
+			contentType: "application/pdf",
+			disposition: `inline; filename=invoice.pdf`,
+		},
+		{
+			name:        "Active content is never inline",
+			content:     func() io.Reader { return strings.NewReader("<svg></svg>") },
+			file:        "logo.svg",
+			opts:        []gotth.DownloadOption{gotth.DownloadInline()},
+			status:      http.StatusOK,
+			body:        "<svg></svg>",
+			contentType: "image/svg+xml",
+			disposition: `attachment; filename=logo.svg`,
+		},
+		{
+			name:        "Range",
+			content:     func() io.Reader { return strings.NewReader("0123456789") },
+			file:        "digits.txt",
+			header:      http.Header{"Range": {"bytes=2-4"}},
+			status:      http.StatusPartialContent,
+			body:        "234",
+			contentType: "text/plain; charset=utf-8",
+			disposition: `attachment; filename=digits.txt`,
+		},
+		{
+			name:        "Not modified",
+			content:     func() io.Reader { return strings.NewReader("0123456789") },
+			file:        "digits.txt",
+			header:      http.Header{"If-Modified-Since": {modtime.Format(http.TimeFormat)}},
+			status:      http.StatusNotModified,
+			contentType: "text/plain; charset=utf-8",
+			disposition: `attachment; filename=digits.txt`,
+		},
+		{
+			name: "Sniffed stream with a unicode name",
+			content: func() io.Reader {
+				return io.MultiReader(strings.NewReader("\x89PNG\r\n\x1a\n"), strings.NewReader("data"))
+			},
+			file:        "../uploads/photo été",
+			status:      http.StatusOK,
+			body:        "\x89PNG\r\n\x1a\ndata",
+			contentType: "image/png",
+			disposition: `attachment; filename*=utf-8''photo%20%C3%A9t%C3%A9`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/download", nil)
+			for k, v := range tt.header {
+				req.Header[k] = v
+			}
+			rr := httptest.NewRecorder()
+			gotth.ServeDownload(rr, req, tt.content(), tt.file, -1, modtime, tt.opts...)
+
+			if rr.Code != tt.status || rr.Body.String() != tt.body {
+				t.Errorf("response = %d %q, want %d %q", rr.Code, rr.Body, tt.status, tt.body)
+			}
+			if got := rr.Header().Get("Content-Type"); tt.status != http.StatusNotModified && got != tt.contentType {
+				t.Errorf("Content-Type = %q, want %q", got, tt.contentType)
+			}
+			if got := rr.Header().Get("Content-Disposition"); got != tt.disposition {
+				t.Errorf("Content-Disposition = %q, want %q", got, tt.disposition)
+			}
+			if rr.Header().Get("X-Content-Type-Options") != "nosniff" {
+				t.Error("X-Content-Type-Options isn't nosniff")
+			}
+		})
+	}
+}
+
+func TestServeDownload_Stream(t *testing.T) {
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/download", nil)
+	req.Header.Set("Range", "bytes=0-1")
+	gotth.ServeDownload(rr, req, io.LimitReader(strings.NewReader("streamed"), 8), "log.txt", 8, time.Time{})
+	if rr.Code != http.StatusOK || rr.Body.String() != "streamed" || rr.Header().Get("Content-Length") != "8" || rr.Header().Get("Accept-Ranges") != "none" {
+		t.Errorf("response = %d %q %v, want the whole stream without ranges", rr.Code, rr.Body, rr.Header())
+	}
+}
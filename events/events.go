@@ -0,0 +1,346 @@
+// Package events sends the events of the application to the webhooks of other services: code
+// emits typed events, and each configured target matching the event receives a signed JSON
+// delivery, retried with a backoff and dead-lettered after Config.MaxAttempts failures.
+//
+//	var OrderCreated = events.Topic[Order]{Name: "order.created"}
+//
+//	em, err := events.New(events.Config{Targets: []events.Target{
+//		{Name: "crm", URL: "https://crm.example.com/hooks", Secret: secret, Events: []string{"order.*"}},
+//	}})
+//	em.Schedule(sched, 5*time.Second) // Deliveries run as a task of the scheduler
+//	...
+//	err := OrderCreated.Emit(ctx, em, order)
+//
+// Emitting only stores the deliveries: they're sent by [Emitter.Deliver], run periodically by
+// the scheduler started and stopped with the web server. The deliveries follow the Standard
+// Webhooks specification by default, so that a webhook.Receiver verifies them:
+//
+//	{"id": "...", "type": "order.created", "timestamp": "2026-01-02T15:04:05Z", "data": {...}}
+package events
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ancalabrese/gotth/scheduler"
+	"github.com/ancalabrese/gotth/webhook"
+)
+
+const (
+	// DefaultMaxAttempts is the number of failed attempts dead-lettering a delivery.
+	DefaultMaxAttempts = 8
+	// DefaultTimeout bounds an attempt.
+	DefaultTimeout = 10 * time.Second
+	// DefaultBatchSize is the number of deliveries a run of Deliver takes from the store at once.
+	DefaultBatchSize = 100
+	// DefaultConcurrency is the number of deliveries attempted at once.
+	DefaultConcurrency = 4
+)
+
+// ErrUnknownDelivery is returned by Redeliver for IDs without dead delivery.
+var ErrUnknownDelivery = errors.New("unknown delivery")
+
+// Target is a webhook receiving events.
+type Target struct {
+	// Required: Name identifies the target in the deliveries and the logs.
+	Name string
+	// Required: URL receives the deliveries as POST requests.
+	URL string
+	// Required: Secret signs the deliveries.
+	Secret []byte
+	// Scheme signs the deliveries. Defaults to webhook.StandardWebhooks.
+	Scheme webhook.Scheme
+	// Events are the names of the events sent to the target, or the prefixes ending with "*",
+	// e.g. "order.*". All the events are sent when empty.
+	Events []string
+	// Optional: headers of the deliveries, e.g. an API key.
+	Headers map[string]string
+}
+
+// matches reports whether the event is sent to t.
+func (t Target) matches(event string) bool {
+	if len(t.Events) == 0 {
+		return true
+	}
+	for _, pattern := range t.Events {
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok && strings.HasPrefix(event, prefix) || pattern == event {
+			return true
+		}
+	}
+	return false
+}
+
+// Config configures an [Emitter].
+type Config struct {
+	Targets []Target
+	// Optional: holds the deliveries. Defaults to a [MemoryStore], whose deliveries are lost on
+	// restart.
+	Store Store
+	// Optional: sends the deliveries. Defaults to http.DefaultClient.
+	Client *http.Client
+	// Timeout bounds an attempt. Defaults to [DefaultTimeout].
+	Timeout time.Duration
+	// MaxAttempts is the number of failed attempts dead-lettering a delivery. Defaults to
+	// [DefaultMaxAttempts].
+	MaxAttempts int
+	// Optional: Backoff returns the delay before the next attempt after attempts failures.
+	// Defaults to 1 minute doubling every attempt, up to 6 hours.
+	Backoff func(attempts int) time.Duration
+	// BatchSize is the number of deliveries taken from the store at once. Defaults to
+	// [DefaultBatchSize].
+	BatchSize int
+	// Concurrency is the number of deliveries attempted at once. Defaults to
+	// [DefaultConcurrency].
+	Concurrency int
+	// Optional: logs the failed and dead-lettered deliveries. Defaults to slog.Default().
+	Logger *slog.Logger
+}
+
+// Emitter stores the deliveries of the emitted events and sends them. Instantiate via New.
+type Emitter struct {
+	cfg     Config
+	targets map[string]Target
+	logger  *slog.Logger
+}
+
+// New returns the Emitter of the events to cfg.Targets.
+func New(cfg Config) (*Emitter, error) {
+	targets := make(map[string]Target, len(cfg.Targets))
+	for _, t := range cfg.Targets {
+		if t.Name == "" || len(t.Secret) == 0 {
+			return nil, fmt.Errorf("events: target %q needs a name and a secret", t.Name)
+		}
+		if _, ok := targets[t.Name]; ok {
+			return nil, fmt.Errorf("events: duplicate target %q", t.Name)
+		}
+		if u, err := url.Parse(t.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("events: target %q needs an absolute http(s) URL, not %q", t.Name, t.URL)
+		}
+		if t.Scheme.Sign == nil {
+			t.Scheme = webhook.StandardWebhooks
+		}
+		targets[t.Name] = t
+	}
+	if cfg.Store == nil {
+		cfg.Store = NewMemoryStore()
+	}
+	if cfg.Client == nil {
+		cfg.Client = http.DefaultClient
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultTimeout
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = DefaultMaxAttempts
+	}
+	if cfg.Backoff == nil {
+		cfg.Backoff = func(attempts int) time.Duration {
+			return min(time.Minute<<min(attempts-1, 16), 6*time.Hour)
+		}
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = DefaultBatchSize
+	}
+	if cfg.Concurrency <= 0 {
+		cfg.Concurrency = DefaultConcurrency
+	}
+	logger := cfg.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &Emitter{cfg: cfg, targets: targets, logger: logger}, nil
+}
+
+// Topic is an event name and the type of its data, so that the events of a name always carry
+// the same data:
+//
+//	var OrderCreated = events.Topic[Order]{Name: "order.created"}
+type Topic[T any] struct {
+	Name string
+}
+
+// Emit emits the event of t with data. See [Emitter.Emit].
+func (t Topic[T]) Emit(ctx context.Context, e *Emitter, data T) error {
+	return e.Emit(ctx, t.Name, data)
+}
+
+// envelope is the JSON body of a delivery.
+type envelope struct {
+	ID        string    `json:"id"`
+	Type      string    `json:"type"`
+	Timestamp time.Time `json:"timestamp"`
+	Data      any       `json:"data"`
+}
+
+// Emit stores a delivery of the event with data, encoded with encoding/json, for each target
+// matching it. The deliveries are sent by the next run of [Emitter.Deliver]. Prefer the typed
+// [Topic.Emit].
+func (e *Emitter) Emit(ctx context.Context, event string, data any) error {
+	now := time.Now().UTC().Truncate(time.Second)
+	body, err := json.Marshal(envelope{ID: rand.Text(), Type: event, Timestamp: now, Data: data})
+	if err != nil {
+		return fmt.Errorf("failed to encode event %s. err %w", event, err)
+	}
+	var ds []Delivery
+	for _, t := range e.cfg.Targets {
+		if t.matches(event) {
+			ds = append(ds, Delivery{ID: rand.Text(), Event: event, Target: t.Name, Body: body, CreatedAt: now, NextAttempt: now})
+		}
+	}
+	if len(ds) == 0 {
+		return nil
+	}
+	if err := e.cfg.Store.Add(ctx, ds...); err != nil {
+		return fmt.Errorf("failed to store deliveries of event %s. err %w", event, err)
+	}
+	return nil
+}
+
+// Deliver attempts the due deliveries until none is left or ctx is canceled. Failed deliveries
+// are retried by a later run after Config.Backoff, and dead-lettered after Config.MaxAttempts.
+// It returns the errors of the store only: the failed deliveries are logged. It's a
+// scheduler.Task, see [Emitter.Schedule].
+func (e *Emitter) Deliver(ctx context.Context) error {
+	// The deliveries taken are leased until their attempt surely returned.
+	lease := e.cfg.Timeout + time.Minute
+	for ctx.Err() == nil {
+		due, err := e.cfg.Store.Due(ctx, time.Now(), lease, e.cfg.BatchSize)
+		if err != nil {
+			return fmt.Errorf("failed to get due deliveries. err %w", err)
+		}
+
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		var errs []error
+		sem := make(chan struct{}, e.cfg.Concurrency)
+		for _, d := range due {
+			wg.Add(1)
+			sem <- struct{}{}
+			go func() {
+				defer func() { <-sem; wg.Done() }()
+				if err := e.attempt(ctx, d); err != nil {
+					mu.Lock()
+					errs = append(errs, err)
+					mu.Unlock()
+				}
+			}()
+		}
+		wg.Wait()
+		if err := errors.Join(errs...); err != nil {
+			return err
+		}
+		if len(due) < e.cfg.BatchSize {
+			return nil
+		}
+	}
+	return ctx.Err()
+}
+
+// attempt sends d and records the outcome in the store.
+func (e *Emitter) attempt(ctx context.Context, d Delivery) error {
+	t, ok := e.targets[d.Target]
+	if !ok {
+		d.Dead, d.LastError = true, "unknown target"
+		e.logger.WarnContext(ctx, "webhook delivery dead-lettered", slog.String("id", d.ID), slog.String("target", d.Target), slog.String("error", d.LastError))
+		return e.update(ctx, d)
+	}
+
+	sendErr := e.send(ctx, t, d)
+	if sendErr == nil {
+		if err := e.cfg.Store.Delete(ctx, d.ID); err != nil {
+			return fmt.Errorf("failed to delete delivery %s. err %w", d.ID, err)
+		}
+		return nil
+	}
+	if ctx.Err() != nil {
+		// Stopped, not failed: attempted again after the lease.
+		return nil
+	}
+
+	d.Attempts++
+	d.LastError = sendErr.Error()
+	attrs := []any{slog.String("id", d.ID), slog.String("event", d.Event), slog.String("target", d.Target), slog.Int("attempts", d.Attempts), slog.Any("error", sendErr)}
+	if d.Attempts >= e.cfg.MaxAttempts {
+		d.Dead = true
+		e.logger.ErrorContext(ctx, "webhook delivery dead-lettered", attrs...)
+	} else {
+		d.NextAttempt = time.Now().Add(e.cfg.Backoff(d.Attempts))
+		e.logger.WarnContext(ctx, "webhook delivery failed", attrs...)
+	}
+	return e.update(ctx, d)
+}
+
+func (e *Emitter) update(ctx context.Context, d Delivery) error {
+	if err := e.cfg.Store.Update(ctx, d); err != nil {
+		return fmt.Errorf("failed to update delivery %s. err %w", d.ID, err)
+	}
+	return nil
+}
+
+// send posts d to t, returning an error unless t answers with a 2xx.
+func (e *Emitter) send(ctx context.Context, t Target, d Delivery) error {
+	ctx, cancel := context.WithTimeout(ctx, e.cfg.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.URL, bytes.NewReader(d.Body))
+	if err != nil {
+		return err
+	}
+	for k, v := range t.Headers {
+		req.Header.Set(k, v)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "gotth-events")
+	if t.Scheme.IDHeader != "" {
+		req.Header.Set(t.Scheme.IDHeader, d.ID)
+	}
+	t.Scheme.Sign(req.Header, d.Body, t.Secret, time.Now())
+
+	resp, err := e.cfg.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return errors.New(resp.Status)
+	}
+	return nil
+}
+
+// DeadLetters returns the dead-lettered deliveries, oldest first.
+func (e *Emitter) DeadLetters(ctx context.Context) ([]Delivery, error) {
+	return e.cfg.Store.DeadLetters(ctx)
+}
+
+// Redeliver schedules the dead-lettered delivery id again, with Config.MaxAttempts new
+// attempts, e.g. after its target was fixed.
+func (e *Emitter) Redeliver(ctx context.Context, id string) error {
+	dead, err := e.cfg.Store.DeadLetters(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get dead deliveries. err %w", err)
+	}
+	for _, d := range dead {
+		if d.ID == id {
+			d.Dead, d.Attempts, d.NextAttempt = false, 0, time.Now()
+			return e.update(ctx, d)
+		}
+	}
+	return fmt.Errorf("%w %q", ErrUnknownDelivery, id)
+}
+
+// Schedule adds [Emitter.Deliver] to s as the "webhook deliveries" task, run every interval
+// and when s starts.
+func (e *Emitter) Schedule(s *scheduler.Scheduler, every time.Duration) error {
+	return s.Add("webhook deliveries", scheduler.Every(every), e.Deliver, scheduler.RunOnStart())
+}
@@ -0,0 +1,178 @@
+package events_test
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ancalabrese/gotth/events"
+	"github.com/ancalabrese/gotth/scheduler"
+	"github.com/ancalabrese/gotth/webhook"
+)
+
+type order struct {
+	ID    string `json:"id"`
+	Total int    `json:"total"`
+}
+
+// envelope is the body of the deliveries of orderCreated.
+type envelope struct {
+	ID        string    `json:"id"`
+	Type      string    `json:"type"`
+	Timestamp time.Time `json:"timestamp"`
+	Data      order     `json:"data"`
+}
+
+var (
+	orderCreated = events.Topic[order]{Name: "order.created"}
+	userDeleted  = events.Topic[string]{Name: "user.deleted"}
+	secret       = []byte("secret")
+)
+
+func TestEmitter(t *testing.T) {
+	var mu sync.Mutex
+	var received []webhook.Event[envelope]
+	rc, err := webhook.New(webhook.Config{Secrets: [][]byte{secret}, Scheme: webhook.StandardWebhooks, Logger: slog.New(slog.DiscardHandler)},
+		func(ctx context.Context, e webhook.Event[envelope]) error {
+			mu.Lock()
+			defer mu.Unlock()
+			received = append(received, e)
+			return nil
+		})
+	if err != nil {
+		t.Fatalf("webhook.New() error = %v", err)
+	}
+	crm := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Api-Key") != "key" {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		rc.ServeHTTP(w, r)
+	}))
+	defer crm.Close()
+
+	em, err := events.New(events.Config{
+		Targets: []events.Target{{Name: "crm", URL: crm.URL, Secret: secret, Events: []string{"order.*"}, Headers: map[string]string{"X-Api-Key": "key"}}},
+		Logger:  slog.New(slog.DiscardHandler),
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	ctx := context.Background()
+	if err := orderCreated.Emit(ctx, em, order{ID: "o1", Total: 1200}); err != nil {
+		t.Fatalf("Emit() error = %v", err)
+	}
+	if err := userDeleted.Emit(ctx, em, "u1"); err != nil {
+		t.Fatalf("Emit() error = %v", err)
+	}
+	if err := em.Deliver(ctx); err != nil {
+		t.Fatalf("Deliver() error = %v", err)
+	}
+	if len(received) != 1 {
+		t.Fatalf("received = %+v, want the order.created delivery only", received)
+	}
+	if p := received[0].Payload; p.ID == "" || p.Type != "order.created" || p.Timestamp.IsZero() || p.Data != (order{ID: "o1", Total: 1200}) {
+		t.Errorf("payload = %+v, want the envelope of the order", p)
+	}
+}
+
+func TestEmitter_RetriesAndDeadLetters(t *testing.T) {
+	var mu sync.Mutex
+	var ids []string
+	status := http.StatusServiceUnavailable
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		ids = append(ids, r.Header.Get("webhook-id"))
+		w.WriteHeader(status)
+	}))
+	defer target.Close()
+
+	store := events.NewMemoryStore()
+	em, err := events.New(events.Config{
+		Targets:     []events.Target{{Name: "hooks", URL: target.URL, Secret: secret}},
+		Store:       store,
+		MaxAttempts: 3,
+		Backoff:     func(int) time.Duration { return 0 },
+		Logger:      slog.New(slog.DiscardHandler),
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	ctx := context.Background()
+	orderCreated.Emit(ctx, em, order{ID: "o1"})
+
+	for range 3 {
+		if err := em.Deliver(ctx); err != nil {
+			t.Fatalf("Deliver() error = %v", err)
+		}
+	}
+	if len(ids) != 3 || ids[0] != ids[1] || ids[1] != ids[2] {
+		t.Errorf("attempt IDs = %v, want 3 attempts sharing the delivery ID", ids)
+	}
+	dead, _ := em.DeadLetters(ctx)
+	if len(dead) != 1 || dead[0].Attempts != 3 || dead[0].LastError != "503 Service Unavailable" {
+		t.Fatalf("DeadLetters() = %+v, want the failed delivery", dead)
+	}
+	em.Deliver(ctx)
+	if len(ids) != 3 {
+		t.Errorf("attempts = %d, want dead deliveries not attempted", len(ids))
+	}
+
+	mu.Lock()
+	status = http.StatusNoContent
+	mu.Unlock()
+	if err := em.Redeliver(ctx, dead[0].ID); err != nil {
+		t.Fatalf("Redeliver() error = %v", err)
+	}
+	em.Deliver(ctx)
+	if len(ids) != 4 || store.Len() != 0 {
+		t.Errorf("attempts = %d, Len() = %d, want the redelivery sent and deleted", len(ids), store.Len())
+	}
+	if err := em.Redeliver(ctx, "missing"); !errors.Is(err, events.ErrUnknownDelivery) {
+		t.Errorf("Redeliver() error = %v, want %v", err, events.ErrUnknownDelivery)
+	}
+}
+
+func TestEmitter_Schedule(t *testing.T) {
+	delivered := make(chan struct{}, 1)
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		delivered <- struct{}{}
+	}))
+	defer target.Close()
+
+	em, _ := events.New(events.Config{Targets: []events.Target{{Name: "hooks", URL: target.URL, Secret: secret}}})
+	s := scheduler.New(scheduler.Config{Logger: slog.New(slog.DiscardHandler)})
+	if err := em.Schedule(s, 10*time.Millisecond); err != nil {
+		t.Fatalf("Schedule() error = %v", err)
+	}
+	orderCreated.Emit(context.Background(), em, order{ID: "o1"})
+	s.Start(context.Background())
+	defer s.Stop(context.Background())
+	select {
+	case <-delivered:
+	case <-time.After(time.Second):
+		t.Fatal("the scheduler didn't deliver the event")
+	}
+}
+
+func TestNew_InvalidTargets(t *testing.T) {
+	for name, target := range map[string]events.Target{
+		"No secret":    {Name: "a", URL: "https://example.com"},
+		"Relative URL": {Name: "a", URL: "/hooks", Secret: secret},
+	} {
+		if _, err := events.New(events.Config{Targets: []events.Target{target}}); err == nil {
+			t.Errorf("%s: New() error = nil", name)
+		}
+	}
+	dup := events.Target{Name: "a", URL: "https://example.com", Secret: secret}
+	if _, err := events.New(events.Config{Targets: []events.Target{dup, dup}}); err == nil {
+		t.Error("New() with duplicate targets error = nil")
+	}
+}
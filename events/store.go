@@ -0,0 +1,133 @@
+package events
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Delivery is the delivery of an event to a target, retried until it succeeds or is
+// dead-lettered.
+type Delivery struct {
+	// ID identifies the delivery, sent in the ID header of the signature scheme, e.g.
+	// webhook-id, and shared by its retries so that the targets can deduplicate them.
+	ID string
+	// Event is the name of the event, e.g. "order.created".
+	Event string
+	// Target is the Name of the target.
+	Target string
+	// Body is the JSON body of the delivery.
+	Body []byte
+	// CreatedAt is the time the event was emitted.
+	CreatedAt time.Time
+	// Attempts is the number of failed attempts.
+	Attempts int
+	// NextAttempt is the time of the next attempt.
+	NextAttempt time.Time
+	// LastError describes the last failed attempt, e.g. "503 Service Unavailable".
+	LastError string
+	// Dead reports a delivery that failed Config.MaxAttempts times, or whose target was removed.
+	// It isn't attempted again unless redelivered.
+	Dead bool
+}
+
+// Store holds the pending and the dead-lettered deliveries of an [Emitter].
+type Store interface {
+	// Add stores new deliveries.
+	Add(ctx context.Context, ds ...Delivery) error
+	// Due returns up to limit live deliveries whose NextAttempt is before now, oldest first, and
+	// postpones them by lease, so that concurrent runs don't attempt them. Stores shared by
+	// several instances must do it atomically, e.g. with SELECT ... FOR UPDATE SKIP LOCKED.
+	Due(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]Delivery, error)
+	// Update replaces the delivery with the ID of d, e.g. after a failed attempt.
+	Update(ctx context.Context, d Delivery) error
+	// Delete removes a successful delivery.
+	Delete(ctx context.Context, id string) error
+	// DeadLetters returns the dead deliveries, oldest first.
+	DeadLetters(ctx context.Context) ([]Delivery, error)
+}
+
+// MemoryStore is a [Store] in memory, for a single instance: its deliveries are lost on
+// restart.
+type MemoryStore struct {
+	mu         sync.Mutex
+	deliveries map[string]Delivery
+}
+
+// NewMemoryStore returns an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{deliveries: map[string]Delivery{}}
+}
+
+func (s *MemoryStore) Add(ctx context.Context, ds ...Delivery) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, d := range ds {
+		s.deliveries[d.ID] = d
+	}
+	return nil
+}
+
+func (s *MemoryStore) Due(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]Delivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var due []Delivery
+	for _, d := range s.deliveries {
+		if !d.Dead && !d.NextAttempt.After(now) {
+			due = append(due, d)
+		}
+	}
+	sortDeliveries(due)
+	due = due[:min(limit, len(due))]
+	for _, d := range due {
+		d.NextAttempt = now.Add(lease)
+		s.deliveries[d.ID] = d
+	}
+	return due, nil
+}
+
+func (s *MemoryStore) Update(ctx context.Context, d Delivery) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.deliveries[d.ID]; !ok {
+		return fmt.Errorf("unknown delivery %q", d.ID)
+	}
+	s.deliveries[d.ID] = d
+	return nil
+}
+
+func (s *MemoryStore) Delete(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.deliveries, id)
+	return nil
+}
+
+func (s *MemoryStore) DeadLetters(ctx context.Context) ([]Delivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var dead []Delivery
+	for _, d := range s.deliveries {
+		if d.Dead {
+			dead = append(dead, d)
+		}
+	}
+	sortDeliveries(dead)
+	return dead, nil
+}
+
+// Len returns the number of deliveries held, dead ones included.
+func (s *MemoryStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.deliveries)
+}
+
+func sortDeliveries(ds []Delivery) {
+	slices.SortFunc(ds, func(a, b Delivery) int {
+		return cmp.Or(a.CreatedAt.Compare(b.CreatedAt), cmp.Compare(a.ID, b.ID))
+	})
+}
@@ -0,0 +1,148 @@
+// Package app wires the example pages, fragments and forms onto a gotth.WebServer.
+// Handlers are registered through Register so that tests can exercise the whole framework
+// through [gotth.WebServer.Handler] without starting a listener.
+package app
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/a-h/templ"
+	"github.com/ancalabrese/gotth"
+	"github.com/ancalabrese/gotth/auth"
+	"github.com/ancalabrese/gotth/example/middleware"
+	"github.com/ancalabrese/gotth/example/views"
+	"github.com/ancalabrese/gotth/middlewares"
+	"github.com/ancalabrese/gotth/sessionstore/memory"
+	"github.com/ancalabrese/gotth/views/components/head"
+	"github.com/ancalabrese/gotth/views/components/layout"
+)
+
+const siteName = "Gotth"
+
+// User is the example user stored in the session.
+type User struct {
+	Name string
+}
+
+// String identifies the user in the access log.
+func (u User) String() string { return u.Name }
+
+// demoPassword is accepted for any username.
+const demoPassword = "gotth"
+
+// authConfig is shared by all the session middlewares so that the cookie is issued, read and
+// expired with the same attributes.
+var authConfig = auth.DefaultConfig()
+
+// Register adds all the example routes to ws.
+func Register(ws *gotth.WebServer, store *memory.Store) {
+	// The greeting is translated, so caches must keep one copy per language.
+	ws.ServeContent("/", home, middlewares.Vary(middlewares.VaryAcceptLanguage))
+	ws.ServeContent("GET /contact", contact)
+	ws.ServeContent("GET /login", login)
+
+	ws.Handle("GET /fragments/clock", http.HandlerFunc(clock))
+	ws.Handle("POST /contact", http.HandlerFunc(submitContact))
+	ws.Handle("POST /login", auth.LoginHandler(checkCredentials, store, authConfig,
+		http.RedirectHandler("/account", http.StatusSeeOther), redirectToLogin))
+	ws.Handle("POST /logout", auth.LogoutHandler(store, authConfig,
+		http.RedirectHandler("/", http.StatusSeeOther), redirectToLogin))
+
+	requireSession := middlewares.SessionCheckWithConfig(store, authConfig.Session, true, redirectToLogin)
+	ws.Handle("GET /account", requireSession(http.HandlerFunc(account)))
+}
+
+// pageHead builds the head metadata shared by all the example pages.
+func pageHead(title, description, path string, opts ...head.Option) head.HeadViewModel {
+	defaults := []head.Option{
+		head.WithName(siteName),
+		head.WithHTMX(""),
+		head.WithPageCoreMetadata(title, description, path),
+		head.WithFavicon("/static/gotth.svg", "image/svg+xml"),
+		head.WithStylesheet("/static/style.css", "", "", ""),
+	}
+	return head.NewHeadViewModel(append(defaults, opts...)...)
+}
+
+func home(r *http.Request) (head.HeadViewModel, templ.Component, error) {
+	headVM := pageHead(
+		"Gotth: Get your site online fast | Go + Templ + Tailwind + HTMX",
+		"Get your Go websites online fast with Gotth! Leverages Templ, Tailwind CSS, and HTMX for rapid, SEO-friendly development of modern web applications.",
+		"/",
+		head.WithKeywords([]string{"Gotth", "Go web server", "Templ", "Tailwind CSS", "HTMX", "Go templ", "Fast Go websites", "Rapid web development Go", "Go SEO", "Go web starter kit", "Go Tailwind HTMX", "Full-stack Go"}),
+		head.WithOpenGraph(
+			"", "", // type, locale (use defaults)
+			"/", "Sample Home OG Title", "OG description for sample home.",
+			"https://placehold.co/1200x630/0779e4/ffffff?text=Sample+Home", "1200", "630", "Sample homepage OG image",
+		),
+		head.WithJSONLD(head.JSONLDNode{
+			Context:    "https://schema.org",
+			Type:       "WebSite",
+			Properties: map[string]any{"name": siteName, "url": "/"},
+		}),
+	)
+
+	lang := Language(r)
+	greeting, welcome := T(lang, "greeting"), T(lang, "welcome")
+
+	name := middleware.GetGottherName(r.Context())
+	if name == "" {
+		return headVM, views.Home(greeting, welcome), nil
+	}
+	return headVM, views.HomeWithName(greeting, name, welcome), nil
+}
+
+func contact(r *http.Request) (head.HeadViewModel, templ.Component, error) {
+	return pageHead("Contact | Gotth", "Get in touch with the Gotth team.", "/contact"), views.Contact(), nil
+}
+
+func login(r *http.Request) (head.HeadViewModel, templ.Component, error) {
+	return pageHead("Login | Gotth", "Login to the Gotth example.", "/login"), views.Login(), nil
+}
+
+// clock renders the server time as an HTMX fragment.
+func clock(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	views.Clock(time.Now().Format(time.TimeOnly)).Render(r.Context(), w)
+}
+
+// submitContact validates the contact form and swaps either the form with its errors or the
+// success message.
+func submitContact(w http.ResponseWriter, r *http.Request) {
+	form, err := ParseContactForm(r)
+	if err != nil {
+		http.Error(w, "Bad Request", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if !form.Valid() {
+		// HTMX doesn't swap 4xx responses by default, so the errors are returned with a 200.
+		views.ContactFormFragment(form).Render(r.Context(), w)
+		return
+	}
+	views.ContactSuccess(form.Get("name")).Render(r.Context(), w)
+}
+
+// checkCredentials logs in any user with the demo password. A real application would look up
+// the user and compare the password against its stored hash.
+func checkCredentials(ctx context.Context, username, password string) (any, error) {
+	if password != demoPassword {
+		return nil, auth.ErrInvalidCredentials
+	}
+	return User{Name: username}, nil
+}
+
+func account(w http.ResponseWriter, r *http.Request) {
+	user, _ := middlewares.GetUser(r.Context()).(User)
+	headVM := pageHead("Account | Gotth", "Your Gotth account.", "/account")
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	layout.BasicLayout(headVM, views.Account(user.Name)).Render(r.Context(), w)
+}
+
+func redirectToLogin(w http.ResponseWriter, r *http.Request, err error) {
+	http.Redirect(w, r, "/login", http.StatusSeeOther)
+}
@@ -0,0 +1,206 @@
+package app_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/ancalabrese/gotth"
+	"github.com/ancalabrese/gotth/example/app"
+	"github.com/ancalabrese/gotth/example/middleware"
+	"github.com/ancalabrese/gotth/middlewares"
+	"github.com/ancalabrese/gotth/sessionstore/memory"
+)
+
+func newTestHandler(t *testing.T) http.Handler {
+	t.Helper()
+	ws, err := gotth.New(gotth.WebServerConfig{
+		GlobalMiddlewares: []func(http.Handler) http.Handler{middleware.GottherName},
+	}, nil)
+	if err != nil {
+		t.Fatalf("gotth.New() error = %v", err)
+	}
+	app.Register(ws, memory.New())
+	return ws.Handler()
+}
+
+func serve(h http.Handler, r *http.Request) *httptest.ResponseRecorder {
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, r)
+	return rr
+}
+
+func assertContains(t *testing.T, body string, want ...string) {
+	t.Helper()
+	for _, w := range want {
+		if !strings.Contains(body, w) {
+			t.Errorf("body does not contain %q\nbody: %s", w, body)
+		}
+	}
+}
+
+func TestHomePage(t *testing.T) {
+	h := newTestHandler(t)
+
+	tests := []struct {
+		name           string
+		target         string
+		acceptLanguage string
+		want           []string
+	}{
+		{
+			name:   "Default language and name",
+			target: "/",
+			want: []string{
+				"<!doctype html>",
+				"<title>Gotth: Get your site online fast | Go + Templ + Tailwind + HTMX</title>",
+				`<link rel="canonical" href="/">`,
+				`<script type="application/ld+json">`,
+				`"@type":"WebSite"`,
+				"Hello GOTTHER!",
+			},
+		},
+		{
+			name:   "Name from query",
+			target: "/?name=Gopher",
+			want:   []string{"Hello Gopher!"},
+		},
+		{
+			name:           "Negotiated language",
+			target:         "/",
+			acceptLanguage: "fr-FR, it-IT;q=0.8",
+			want:           []string{"Ciao GOTTHER!", "Benvenuto"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			if tt.acceptLanguage != "" {
+				req.Header.Set("Accept-Language", tt.acceptLanguage)
+			}
+			rr := serve(h, req)
+			if rr.Code != http.StatusOK {
+				t.Fatalf("status = %d, want %d", rr.Code, http.StatusOK)
+			}
+			if vary := rr.Header().Get("Vary"); vary != "Accept-Language" {
+				t.Errorf("Vary = %q, want %q", vary, "Accept-Language")
+			}
+			assertContains(t, rr.Body.String(), tt.want...)
+		})
+	}
+}
+
+func TestClockFragment(t *testing.T) {
+	rr := serve(newTestHandler(t), httptest.NewRequest(http.MethodGet, "/fragments/clock", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rr.Code, http.StatusOK)
+	}
+	body := rr.Body.String()
+	assertContains(t, body, `<span id="clock-time">`)
+	if strings.Contains(body, "<html") {
+		t.Errorf("fragment must not be wrapped in the layout: %s", body)
+	}
+}
+
+func TestContactForm(t *testing.T) {
+	h := newTestHandler(t)
+
+	tests := []struct {
+		name     string
+		form     url.Values
+		want     []string
+		dontWant []string
+	}{
+		{
+			name:     "Invalid submission returns errors and preserves values",
+			form:     url.Values{"name": {""}, "email": {"nope"}, "message": {"short"}},
+			want:     []string{`data-error="name"`, `data-error="email"`, `data-error="message"`, `value="nope"`},
+			dontWant: []string{"Thanks"},
+		},
+		{
+			name:     "Valid submission",
+			form:     url.Values{"name": {"Ada"}, "email": {"ada@example.com"}, "message": {"Hello from the tests!"}},
+			want:     []string{"Thanks Ada"},
+			dontWant: []string{"data-error"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/contact", strings.NewReader(tt.form.Encode()))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			req.Header.Set("HX-Request", "true")
+			rr := serve(h, req)
+			if rr.Code != http.StatusOK {
+				t.Fatalf("status = %d, want %d", rr.Code, http.StatusOK)
+			}
+			assertContains(t, rr.Body.String(), tt.want...)
+			for _, d := range tt.dontWant {
+				if strings.Contains(rr.Body.String(), d) {
+					t.Errorf("body unexpectedly contains %q", d)
+				}
+			}
+		})
+	}
+}
+
+func TestSessionLifecycle(t *testing.T) {
+	h := newTestHandler(t)
+
+	// Anonymous users are sent to the login page.
+	rr := serve(h, httptest.NewRequest(http.MethodGet, "/account", nil))
+	if rr.Code != http.StatusSeeOther || rr.Header().Get("Location") != "/login" {
+		t.Fatalf("anonymous /account: status = %d, location = %q", rr.Code, rr.Header().Get("Location"))
+	}
+
+	// Login issues a session cookie.
+	req := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader("username=Ada&password=wrong"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rr = serve(h, req)
+	if rr.Code != http.StatusSeeOther || rr.Header().Get("Location") != "/login" || len(rr.Result().Cookies()) != 0 {
+		t.Fatalf("login with wrong password: status = %d, location = %q", rr.Code, rr.Header().Get("Location"))
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/login", strings.NewReader("username=Ada&password=gotth"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rr = serve(h, req)
+	if rr.Code != http.StatusSeeOther {
+		t.Fatalf("login: status = %d, want %d", rr.Code, http.StatusSeeOther)
+	}
+	var session *http.Cookie
+	for _, c := range rr.Result().Cookies() {
+		if c.Name == middlewares.SESSION_COOKIE_NAME {
+			session = c
+		}
+	}
+	if session == nil {
+		t.Fatal("login did not set the session cookie")
+	}
+
+	// The session cookie grants access to the account page.
+	req = httptest.NewRequest(http.MethodGet, "/account", nil)
+	req.AddCookie(session)
+	rr = serve(h, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("authenticated /account: status = %d, want %d", rr.Code, http.StatusOK)
+	}
+	assertContains(t, rr.Body.String(), "Logged in as Ada")
+
+	// Logout invalidates the session.
+	req = httptest.NewRequest(http.MethodPost, "/logout", nil)
+	req.AddCookie(session)
+	rr = serve(h, req)
+	if rr.Code != http.StatusSeeOther || rr.Header().Get("Location") != "/" {
+		t.Fatalf("logout: status = %d, location = %q", rr.Code, rr.Header().Get("Location"))
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/account", nil)
+	req.AddCookie(session)
+	rr = serve(h, req)
+	if rr.Code != http.StatusSeeOther {
+		t.Fatalf("/account after logout: status = %d, want %d", rr.Code, http.StatusSeeOther)
+	}
+}
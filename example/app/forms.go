@@ -0,0 +1,27 @@
+package app
+
+import (
+	"net/http"
+	"net/mail"
+	"strings"
+
+	"github.com/ancalabrese/gotth/forms"
+)
+
+// ParseContactForm reads and validates the contact form from r.
+func ParseContactForm(r *http.Request) (*forms.Form, error) {
+	f, err := forms.Parse(r)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, field := range []string{"name", "email", "message"} {
+		f.Values.Set(field, strings.TrimSpace(f.Get(field)))
+	}
+
+	f.Check(f.Get("name") != "", "name", "Name is required")
+	_, err = mail.ParseAddress(f.Get("email"))
+	f.Check(err == nil, "email", "A valid email address is required")
+	f.Check(len(f.Get("message")) >= 10, "message", "Message must be at least 10 characters long")
+	return f, nil
+}
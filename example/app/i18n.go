@@ -0,0 +1,49 @@
+package app
+
+import (
+	"net/http"
+	"strings"
+)
+
+const defaultLanguage = "en"
+
+// translations holds the example's UI strings keyed by language and message ID.
+var translations = map[string]map[string]string{
+	"en": {
+		"greeting": "Hello",
+		"welcome":  "Welcome to the Gotth experience.",
+	},
+	"it": {
+		"greeting": "Ciao",
+		"welcome":  "Benvenuto nell'esperienza Gotth.",
+	},
+	"de": {
+		"greeting": "Hallo",
+		"welcome":  "Willkommen bei Gotth.",
+	},
+}
+
+// Language returns the first supported language listed in the Accept-Language header of r, or
+// the default language. Quality values are ignored: the header order is honoured instead.
+func Language(r *http.Request) string {
+	for _, part := range strings.Split(r.Header.Get("Accept-Language"), ",") {
+		tag, _, _ := strings.Cut(strings.TrimSpace(part), ";")
+		base, _, _ := strings.Cut(strings.ToLower(tag), "-")
+		if _, ok := translations[base]; ok {
+			return base
+		}
+	}
+	return defaultLanguage
+}
+
+// T returns the translation of key for lang, falling back to the default language and then to
+// the key itself.
+func T(lang, key string) string {
+	if msg, ok := translations[lang][key]; ok {
+		return msg
+	}
+	if msg, ok := translations[defaultLanguage][key]; ok {
+		return msg
+	}
+	return key
+}
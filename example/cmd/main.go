@@ -6,23 +6,13 @@ import (
 	"net/http"
 	"time"
 
-	"github.com/a-h/templ"
 	"github.com/ancalabrese/gotth"
+	"github.com/ancalabrese/gotth/example/app"
 	"github.com/ancalabrese/gotth/example/middleware"
-	"github.com/ancalabrese/gotth/example/views"
-	"github.com/ancalabrese/gotth/views/components/head"
+	"github.com/ancalabrese/gotth/middlewares"
+	"github.com/ancalabrese/gotth/sessionstore/memory"
 )
 
-// LoggingMiddleware is a simple example of a global middleware.
-func LoggingMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		start := time.Now()
-		log.Printf("Started %s %s", r.Method, r.RequestURI)
-		next.ServeHTTP(w, r)
-		log.Printf("Completed %s %s in %v", r.Method, r.RequestURI, time.Since(start))
-	})
-}
-
 func main() {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -33,9 +23,17 @@ func main() {
 		http.Dir("./static/dist/"), // Filesystem path relative to where main.go is run
 	)
 
+	// HTMX and its preload extension are loaded from unpkg.com.
+	securityHeaders := middlewares.DefaultSecurityHeadersConfig()
+	securityHeaders.CSP = securityHeaders.CSP.Add("script-src", "https://unpkg.com")
+
 	cfg := gotth.WebServerConfig{
 		StaticAssetsFS: []gotth.StaticAssetFS{appStaticFS},
+		LogRoutes:      true,
 		GlobalMiddlewares: []func(http.Handler) http.Handler{
+			middlewares.RequestID,
+			middlewares.Logging(middlewares.DefaultLoggingConfig()),
+			middlewares.SecurityHeaders(securityHeaders),
 			middleware.GottherName,
 		},
 	}
@@ -54,32 +52,9 @@ func main() {
 		panic(err)
 	}
 
-	webServer.ServeContent("/", func(r *http.Request) (metadata head.HeadViewModel, content templ.Component, err error) {
-		indexHeadVM := head.NewHeadViewModel(
-			head.WithHTMX(""),
-			head.WithPageCoreMetadata(
-				"Gotth: Get your site online fast | Go + Templ + Tailwind + HTMX",
-				"Get your Go websites online fast with Gotth! Leverages Templ, Tailwind CSS, and HTMX for rapid, SEO-friendly development of modern web applications.",
-				"/index"),
-			head.WithKeywords([]string{"Gotth", "Go web server", "Templ", "Tailwind CSS", "HTMX", "Go templ", "Fast Go websites", "Rapid web development Go", "Go SEO", "Go web starter kit", "Go Tailwind HTMX", "Full-stack Go"}),
-			head.WithFavicon("/static/gotth.svg", "image/png"),
-			head.WithKeywords([]string{"gotth", "sample", "homepage", "go", "templ"}),
-			head.WithOpenGraph(
-				"", "", // type, locale (use defaults)
-				"/", "Sample Home OG Title", "OG description for sample home.",
-				"https://placehold.co/1200x630/0779e4/ffffff?text=Sample+Home", "1200", "630", "Sample homepage OG image",
-			),
-			head.WithStylesheet("/static/style.css", "", "", ""),
-		)
+	app.Register(webServer, memory.New())
 
-		name := middleware.GetGottherName(r.Context())
-		if name == "" {
-			content = views.Home()
-		} else {
-			content = views.HomeWithName(name)
-		}
-		return indexHeadVM, content, nil
-	})
-
-	webServer.Start(ctx)
+	if err := webServer.Start(ctx); err != nil {
+		log.Fatal(err)
+	}
 }
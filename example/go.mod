@@ -4,10 +4,20 @@ go 1.24.1
 
 require github.com/ancalabrese/gotth v0.0.0-20250525102643-f20b3cf8622c
 
+require github.com/a-h/templ v0.3.865
+
 require (
-	github.com/a-h/templ v0.3.865
+	github.com/BurntSushi/toml v1.5.0 // indirect
+	github.com/aymerick/douceur v0.2.0 // indirect
+	github.com/coder/websocket v1.8.14 // indirect
+	github.com/gorilla/css v1.0.1 // indirect
+	github.com/microcosm-cc/bluemonday v1.0.27 // indirect
+	github.com/yuin/goldmark v1.7.13 // indirect
+	golang.org/x/image v0.25.0 // indirect
+	golang.org/x/net v0.39.0 // indirect
+	golang.org/x/sys v0.32.0 // indirect
+	golang.org/x/text v0.24.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
 
-replace (
-	github.com/ancalabrese/gotth => ../.
-)
+replace github.com/ancalabrese/gotth => ../.
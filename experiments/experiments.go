@@ -0,0 +1,166 @@
+// Package experiments assigns visitors to A/B test variants for server-rendered experiments:
+//
+//	ws.Handle("/", experiments.Middleware([]experiments.Experiment{{
+//		Name:     "signup-button",
+//		Variants: []experiments.Variant{{Name: "control"}, {Name: "green"}},
+//	}}, experiments.DefaultConfig())(mux))
+//
+// and then, in a ContentProvider or a templ component:
+//
+//	if experiments.Assignment(ctx, "signup-button") == "green" { ... }
+package experiments
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"hash/fnv"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/a-h/templ"
+	"github.com/ancalabrese/gotth/middlewares"
+)
+
+const (
+	DefaultCookieName = "gotth_visitor"
+
+	assignmentsKey contextAssignmentsKeyType = "gotth_experiments_key"
+)
+
+type contextAssignmentsKeyType string
+
+// Experiment is an A/B test with two or more variants.
+type Experiment struct {
+	// Name identifies the experiment in code and analytics.
+	Name     string
+	Variants []Variant
+}
+
+// Variant is an arm of an experiment. Weight sets its share of the visitors relative to the other
+// variants; when all weights are zero, visitors are split equally.
+type Variant struct {
+	Name   string
+	Weight int
+}
+
+// Config configures the [Middleware]. Use [DefaultConfig] as a starting point.
+type Config struct {
+	// Cookie holds the attributes of the cookie persisting the random visitor ID that assignments
+	// are derived from.
+	Cookie middlewares.SessionConfig
+	// Optional: Key returns the key to assign instead of the visitor ID, e.g. the logged in user
+	// ID so that users see the same variant on every device. The visitor ID is used when it
+	// returns "".
+	Key func(r *http.Request) string
+}
+
+// DefaultConfig returns a Config with a visitor cookie that lasts a year.
+func DefaultConfig() Config {
+	cookie := middlewares.DefaultSessionConfig()
+	cookie.Name = DefaultCookieName
+	cookie.TTL = 365 * 24 * time.Hour
+	return Config{Cookie: cookie}
+}
+
+// Middleware returns a new middleware (http.Handler) that assigns the visitor to a variant of
+// every experiment and makes the assignments available via [Assignment] and [Assignments].
+// A visitor always gets the same variant of an experiment as long as the experiment's variants
+// and weights don't change. Since responses now depend on the visitor cookie, "Cookie" is added
+// to the Vary header.
+func Middleware(experiments []Experiment, cfg Config) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := ""
+			if cfg.Key != nil {
+				key = cfg.Key(r)
+			}
+			if key == "" {
+				key = visitorID(w, r, cfg.Cookie)
+			}
+
+			assignments := make(map[string]string, len(experiments))
+			for _, e := range experiments {
+				if v := e.assign(key); v != "" {
+					assignments[e.Name] = v
+				}
+			}
+
+			middlewares.AddVary(w.Header(), "Cookie")
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), assignmentsKey, assignments)))
+		})
+	}
+}
+
+// Assignment returns the variant of experiment assigned to the request, or "" when the
+// experiment isn't running.
+func Assignment(ctx context.Context, experiment string) string {
+	assignments, _ := ctx.Value(assignmentsKey).(map[string]string)
+	return assignments[experiment]
+}
+
+// Assignments returns a copy of all the experiment assignments of the request, by experiment.
+func Assignments(ctx context.Context) map[string]string {
+	assignments, _ := ctx.Value(assignmentsKey).(map[string]string)
+	out := make(map[string]string, len(assignments))
+	for k, v := range assignments {
+		out[k] = v
+	}
+	return out
+}
+
+// Attributes returns a data-experiments attribute listing the assignments as
+// "experiment=variant" pairs separated by ";", sorted by experiment, so analytics scripts can
+// read them from the DOM, e.g. <body { experiments.Attributes(ctx)... }>.
+func Attributes(ctx context.Context) templ.Attributes {
+	assignments := Assignments(ctx)
+	pairs := make([]string, 0, len(assignments))
+	for e, v := range assignments {
+		pairs = append(pairs, e+"="+v)
+	}
+	sort.Strings(pairs)
+	return templ.Attributes{"data-experiments": strings.Join(pairs, ";")}
+}
+
+// assign picks a variant for key, or "" without variants.
+func (e Experiment) assign(key string) string {
+	if len(e.Variants) == 0 {
+		return ""
+	}
+
+	total := 0
+	for _, v := range e.Variants {
+		total += max(v.Weight, 0)
+	}
+	h := fnv.New64a()
+	h.Write([]byte(e.Name))
+	h.Write([]byte{0})
+	h.Write([]byte(key))
+	sum := h.Sum64()
+
+	if total == 0 {
+		return e.Variants[sum%uint64(len(e.Variants))].Name
+	}
+	bucket := int(sum % uint64(total))
+	for _, v := range e.Variants {
+		bucket -= max(v.Weight, 0)
+		if bucket < 0 {
+			return v.Name
+		}
+	}
+	return e.Variants[len(e.Variants)-1].Name
+}
+
+// visitorID returns the visitor ID from the cookie, issuing a new one when missing.
+func visitorID(w http.ResponseWriter, r *http.Request, cfg middlewares.SessionConfig) string {
+	if c, err := r.Cookie(cfg.CookieName()); err == nil && c.Value != "" {
+		return c.Value
+	}
+	b := make([]byte, 16)
+	rand.Read(b)
+	id := base64.RawURLEncoding.EncodeToString(b)
+	middlewares.SetSession(w, id, cfg)
+	return id
+}
@@ -0,0 +1,97 @@
+package experiments_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/ancalabrese/gotth/experiments"
+)
+
+var signup = experiments.Experiment{
+	Name:     "signup-button",
+	Variants: []experiments.Variant{{Name: "control", Weight: 1}, {Name: "green", Weight: 3}},
+}
+
+func serve(t *testing.T, cfg experiments.Config, req *http.Request) (*httptest.ResponseRecorder, context.Context) {
+	t.Helper()
+	var ctx context.Context
+	handler := experiments.Middleware([]experiments.Experiment{signup, {Name: "empty"}}, cfg)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx = r.Context()
+	}))
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	return rr, ctx
+}
+
+func TestMiddleware_PersistsVisitor(t *testing.T) {
+	cfg := experiments.DefaultConfig()
+
+	rr, ctx := serve(t, cfg, httptest.NewRequest(http.MethodGet, "/", nil))
+	cookies := rr.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != experiments.DefaultCookieName {
+		t.Fatalf("expected the visitor cookie, got %v", cookies)
+	}
+	variant := experiments.Assignment(ctx, "signup-button")
+	if variant != "control" && variant != "green" {
+		t.Fatalf("unexpected variant %q", variant)
+	}
+	if experiments.Assignment(ctx, "empty") != "" {
+		t.Error("experiment without variants was assigned")
+	}
+	if rr.Header().Get("Vary") != "Cookie" {
+		t.Errorf("Vary = %q, want Cookie", rr.Header().Get("Vary"))
+	}
+
+	for range 5 {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.AddCookie(cookies[0])
+		rr, ctx := serve(t, cfg, req)
+		if len(rr.Result().Cookies()) != 0 {
+			t.Error("visitor cookie issued again")
+		}
+		if got := experiments.Assignment(ctx, "signup-button"); got != variant {
+			t.Fatalf("returning visitor got %q, first visit got %q", got, variant)
+		}
+	}
+}
+
+func TestMiddleware_Weights(t *testing.T) {
+	cfg := experiments.DefaultConfig()
+	green := 0
+	for i := range 2000 {
+		cfg.Key = func(r *http.Request) string { return "user-" + strconv.Itoa(i) }
+		_, ctx := serve(t, cfg, httptest.NewRequest(http.MethodGet, "/", nil))
+		if experiments.Assignment(ctx, "signup-button") == "green" {
+			green++
+		}
+	}
+	// 3 to 1 weights: about 1500 of 2000.
+	if green < 1350 || green > 1650 {
+		t.Errorf("green assigned to %d of 2000 visitors", green)
+	}
+}
+
+func TestAttributesAndScript(t *testing.T) {
+	cfg := experiments.DefaultConfig()
+	cfg.Key = func(r *http.Request) string { return "user-1" }
+	_, ctx := serve(t, cfg, httptest.NewRequest(http.MethodGet, "/", nil))
+	variant := experiments.Assignment(ctx, "signup-button")
+
+	if got := experiments.Attributes(ctx)["data-experiments"]; got != "signup-button="+variant {
+		t.Errorf("data-experiments = %q", got)
+	}
+
+	var buf bytes.Buffer
+	if err := experiments.Script().Render(ctx, &buf); err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	want := `<script id="gotth-experiments" type="application/json">{"signup-button":"` + variant + `"}`
+	if !strings.HasPrefix(buf.String(), want) {
+		t.Errorf("Script() = %s, want %s", buf.String(), want)
+	}
+}
@@ -0,0 +1,98 @@
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CSV is the CSV [Format]. Its zero value writes comma separated values with a UTF-8 byte order
+// mark, so that Excel detects the encoding.
+//
+// Text cells starting with =, +, -, @, a tab or a carriage return are prefixed with a quote,
+// so that a spreadsheet doesn't evaluate them as formulas (CSV injection). Numbers aren't.
+type CSV struct {
+	// Comma is the field delimiter. Defaults to ','; use ';' for the locales where the comma is
+	// the decimal separator.
+	Comma rune
+	// NoBOM omits the byte order mark.
+	NoBOM bool
+}
+
+func (f CSV) ContentType() string {
+	return "text/csv; charset=utf-8"
+}
+
+func (f CSV) Extension() string {
+	return ".csv"
+}
+
+func (f CSV) NewWriter(w io.Writer) (RowWriter, error) {
+	if !f.NoBOM {
+		if _, err := io.WriteString(w, "\ufeff"); err != nil {
+			return nil, err
+		}
+	}
+	cw := csv.NewWriter(w)
+	if f.Comma != 0 {
+		cw.Comma = f.Comma
+	}
+	return &csvWriter{w: cw}, nil
+}
+
+type csvWriter struct {
+	w      *csv.Writer
+	record []string
+}
+
+func (cw *csvWriter) WriteRow(values []any) error {
+	cw.record = cw.record[:0]
+	for _, v := range values {
+		cw.record = append(cw.record, formatCSV(v))
+	}
+	return cw.w.Write(cw.record)
+}
+
+func (cw *csvWriter) Flush() error {
+	cw.w.Flush()
+	return cw.w.Error()
+}
+
+func (cw *csvWriter) Close() error {
+	return cw.Flush()
+}
+
+// formatCSV formats a cell value.
+func formatCSV(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return escapeFormula(v)
+	case bool:
+		return strconv.FormatBool(v)
+	case int:
+		return strconv.Itoa(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case time.Time:
+		return v.Format(time.RFC3339)
+	case fmt.Stringer:
+		return escapeFormula(v.String())
+	default:
+		return escapeFormula(fmt.Sprint(v))
+	}
+}
+
+// escapeFormula prefixes the text cells a spreadsheet would evaluate with a quote.
+func escapeFormula(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
@@ -0,0 +1,189 @@
+// Package export streams tables of data as downloadable files, e.g. the CSV export of an admin
+// table, from an iterator over the rows: rows are written as they're read, so that exporting a
+// large query doesn't hold it in memory.
+//
+//	func exportUsers(w http.ResponseWriter, r *http.Request) {
+//		rows := db.UsersIter(r.Context()) // iter.Seq2[User, error]
+//		if err := export.Serve(w, r, export.CSV{}, "users", export.FromTable(columns), rows); err != nil {
+//			...
+//		}
+//	}
+//
+// CSV is built in. Other formats, e.g. xlsx through a spreadsheet library, implement [Format].
+package export
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"mime"
+	"net/http"
+
+	"github.com/ancalabrese/gotth/table"
+)
+
+// DefaultFlushRows is the number of rows after which Serve flushes the response.
+const DefaultFlushRows = 500
+
+// ErrIncomplete is wrapped by the errors of Serve once the response started: the client
+// received part of the file.
+var ErrIncomplete = errors.New("export: incomplete response")
+
+// Column is a column of an export of T.
+type Column[T any] struct {
+	Header string
+	// Value returns the value of the cell: a string, a number, a bool, a time.Time, nil or a
+	// value formatted with fmt.Sprint.
+	Value func(T) any
+}
+
+// FromTable returns the columns of the table columns having a Value, with their Label as
+// header, so that an export has the columns of the table it's exported from.
+func FromTable[T any](columns []table.Column[T]) []Column[T] {
+	var out []Column[T]
+	for _, c := range columns {
+		if c.Value != nil {
+			out = append(out, Column[T]{Header: c.Label, Value: c.Value})
+		}
+	}
+	return out
+}
+
+// RowWriter writes the rows of an export.
+type RowWriter interface {
+	// WriteRow writes a row of values, the first one being the headers.
+	WriteRow(values []any) error
+	// Flush writes the buffered rows to the underlying writer.
+	Flush() error
+	// Close completes the file, e.g. the archive of an xlsx, and flushes it.
+	Close() error
+}
+
+// Format is a file format of exports.
+type Format interface {
+	// ContentType is the media type of the files, e.g. "text/csv; charset=utf-8".
+	ContentType() string
+	// Extension is the file name extension, e.g. ".csv".
+	Extension() string
+	// NewWriter returns the RowWriter of a file written to w.
+	NewWriter(w io.Writer) (RowWriter, error)
+}
+
+// Slice returns the iterator over items, e.g. to export rows already in memory.
+func Slice[T any](items []T) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		for _, item := range items {
+			if !yield(item, nil) {
+				return
+			}
+		}
+	}
+}
+
+// Write writes the headers of columns and the rows to w in format f, e.g. to attach the export
+// to an email. It stops at the first error of rows.
+func Write[T any](w io.Writer, f Format, columns []Column[T], rows iter.Seq2[T, error]) error {
+	rw, err := f.NewWriter(w)
+	if err != nil {
+		return fmt.Errorf("failed to create writer. err %w", err)
+	}
+	if err := writeRows(rw, columns, rows, nil, nil); err != nil {
+		return err
+	}
+	return rw.Close()
+}
+
+// Serve streams the export as an attachment named name plus the extension of f, e.g.
+// "users.csv", flushing the response every [DefaultFlushRows] rows.
+//
+// The response starts with the first row, so an error of rows before it is returned with
+// nothing written, to answer with an error page. Later errors wrap [ErrIncomplete]: the
+// client has part of the file, so abort the response with panic(http.ErrAbortHandler) after
+// logging, and the browser reports the download as failed.
+func Serve[T any](w http.ResponseWriter, r *http.Request, f Format, name string, columns []Column[T], rows iter.Seq2[T, error]) error {
+	bw := bufio.NewWriter(w)
+	started := false
+	start := func() {
+		started = true
+		h := w.Header()
+		h.Set("Content-Type", f.ContentType())
+		h.Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": name + f.Extension()}))
+		h.Set("Cache-Control", "no-store")
+		h.Set("X-Content-Type-Options", "nosniff")
+		w.WriteHeader(http.StatusOK)
+	}
+	flush := func() error {
+		if err := bw.Flush(); err != nil {
+			return err
+		}
+		http.NewResponseController(w).Flush()
+		return nil
+	}
+
+	rw, err := f.NewWriter(bw)
+	if err != nil {
+		return fmt.Errorf("failed to create writer. err %w", err)
+	}
+	err = writeRows(rw, columns, rows, start, func() error {
+		if err := rw.Flush(); err != nil {
+			return err
+		}
+		return flush()
+	})
+	if err == nil {
+		if err = rw.Close(); err == nil {
+			err = flush()
+		}
+	}
+	if err != nil && started {
+		return fmt.Errorf("%w: %w", ErrIncomplete, err)
+	}
+	return err
+}
+
+// writeRows writes the headers, with the first row or at the end, and the rows to rw. The
+// optional start is called before the headers, and flush every DefaultFlushRows rows.
+func writeRows[T any](rw RowWriter, columns []Column[T], rows iter.Seq2[T, error], start func(), flush func() error) error {
+	values := make([]any, len(columns))
+	wroteHeaders := false
+	writeHeaders := func() error {
+		wroteHeaders = true
+		if start != nil {
+			start()
+		}
+		for i, c := range columns {
+			values[i] = c.Header
+		}
+		return rw.WriteRow(values)
+	}
+
+	n := 0
+	for item, err := range rows {
+		if err != nil {
+			return fmt.Errorf("failed to read row %d. err %w", n+1, err)
+		}
+		if !wroteHeaders {
+			if err := writeHeaders(); err != nil {
+				return err
+			}
+		}
+		for i, c := range columns {
+			values[i] = c.Value(item)
+		}
+		if err := rw.WriteRow(values); err != nil {
+			return fmt.Errorf("failed to write row %d. err %w", n+1, err)
+		}
+		n++
+		if flush != nil && n%DefaultFlushRows == 0 {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	if !wroteHeaders {
+		return writeHeaders()
+	}
+	return nil
+}
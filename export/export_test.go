@@ -0,0 +1,110 @@
+package export_test
+
+import (
+	"errors"
+	"iter"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ancalabrese/gotth/export"
+	"github.com/ancalabrese/gotth/table"
+)
+
+type user struct {
+	Name    string
+	Age     int
+	Joined  time.Time
+	Balance float64
+}
+
+var users = []user{
+	{Name: "Ada", Age: 36, Joined: time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC), Balance: 12.5},
+	{Name: "=HYPERLINK(\"http://evil\")", Age: 41, Balance: -3},
+}
+
+var columns = []export.Column[user]{
+	{Header: "Name", Value: func(u user) any { return u.Name }},
+	{Header: "Age", Value: func(u user) any { return u.Age }},
+	{Header: "Joined", Value: func(u user) any {
+		if u.Joined.IsZero() {
+			return nil
+		}
+		return u.Joined
+	}},
+	{Header: "Balance", Value: func(u user) any { return u.Balance }},
+}
+
+func TestServe(t *testing.T) {
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/users/export", nil)
+	if err := export.Serve(rr, req, export.CSV{}, "users", columns, export.Slice(users)); err != nil {
+		t.Fatalf("Serve() = %v", err)
+	}
+
+	want := "\ufeffName,Age,Joined,Balance\n" +
+		"Ada,36,2026-01-02T15:04:05Z,12.5\n" +
+		// Formulas are escaped, numbers aren't.
+		"\"'=HYPERLINK(\"\"http://evil\"\")\",41,,-3\n"
+	if rr.Code != http.StatusOK || rr.Body.String() != want {
+		t.Errorf("response = %d %q, want %q", rr.Code, rr.Body, want)
+	}
+	if got := rr.Header().Get("Content-Type"); got != "text/csv; charset=utf-8" {
+		t.Errorf("Content-Type = %q", got)
+	}
+	if got := rr.Header().Get("Content-Disposition"); got != "attachment; filename=users.csv" {
+		t.Errorf("Content-Disposition = %q", got)
+	}
+}
+
+func TestServe_Errors(t *testing.T) {
+	errDB := errors.New("connection reset")
+	failingAt := func(n int) iter.Seq2[user, error] {
+		return func(yield func(user, error) bool) {
+			for i := range n {
+				if !yield(users[i%len(users)], nil) {
+					return
+				}
+			}
+			yield(user{}, errDB)
+		}
+	}
+
+	// Nothing is written before the first row, so the handler can still answer with an error.
+	rr := httptest.NewRecorder()
+	err := export.Serve(rr, httptest.NewRequest(http.MethodGet, "/", nil), export.CSV{}, "users", columns, failingAt(0))
+	if !errors.Is(err, errDB) || errors.Is(err, export.ErrIncomplete) || rr.Body.Len() != 0 || rr.Header().Get("Content-Disposition") != "" {
+		t.Errorf("Serve() failing before the first row = %v, %q, want %v with nothing written", err, rr.Body, errDB)
+	}
+
+	rr = httptest.NewRecorder()
+	err = export.Serve(rr, httptest.NewRequest(http.MethodGet, "/", nil), export.CSV{}, "users", columns, failingAt(export.DefaultFlushRows+1))
+	if !errors.Is(err, errDB) || !errors.Is(err, export.ErrIncomplete) {
+		t.Errorf("Serve() failing after the first row = %v, want %v and %v", err, errDB, export.ErrIncomplete)
+	}
+	if !rr.Flushed || !strings.HasPrefix(rr.Body.String(), "\ufeffName,Age") {
+		t.Errorf("rows before the error weren't flushed, flushed %t", rr.Flushed)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	var sb strings.Builder
+	if err := export.Write(&sb, export.CSV{Comma: ';', NoBOM: true}, columns[:2], export.Slice[user](nil)); err != nil {
+		t.Fatalf("Write() = %v", err)
+	}
+	if want := "Name;Age\n"; sb.String() != want {
+		t.Errorf("export of no rows = %q, want %q", sb.String(), want)
+	}
+}
+
+func TestFromTable(t *testing.T) {
+	got := export.FromTable([]table.Column[user]{
+		{Key: "name", Label: "Name", Value: func(u user) any { return u.Name }},
+		{Key: "actions", Label: "Actions"},
+	})
+	if len(got) != 1 || got[0].Header != "Name" || got[0].Value(users[0]) != "Ada" {
+		t.Errorf("FromTable() = %+v, want the Name column only", got)
+	}
+}
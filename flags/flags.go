@@ -0,0 +1,131 @@
+// Package flags provides feature flags evaluated per request:
+//
+//	ws, _ := gotth.New(gotth.WebServerConfig{GlobalMiddlewares: []func(http.Handler) http.Handler{
+//		flags.Middleware(flags.Chain(flags.Env("GOTTH_FLAG_"), flags.Static{"new-nav": false})),
+//	}}, nil)
+//
+// and then, in a ContentProvider or a templ component:
+//
+//	if flags.Enabled(ctx, "new-nav") { ... }
+package flags
+
+import (
+	"context"
+	"hash/fnv"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const providerKey contextProviderKeyType = "gotth_flags_provider_key"
+
+type contextProviderKeyType string
+
+// Provider evaluates flags. Providers receive the context of the caller of [Enabled], so they can
+// decide per user with middlewares.GetUser(ctx).
+type Provider interface {
+	// Lookup returns whether flag is enabled, and found=false when the provider doesn't know it.
+	Lookup(ctx context.Context, flag string) (enabled, found bool)
+}
+
+// ProviderFunc adapts a function to a Provider.
+type ProviderFunc func(ctx context.Context, flag string) (enabled, found bool)
+
+func (f ProviderFunc) Lookup(ctx context.Context, flag string) (bool, bool) {
+	return f(ctx, flag)
+}
+
+// Middleware returns a new middleware (http.Handler) that makes p available to [Enabled] for the
+// rest of the request.
+func Middleware(p Provider) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(WithProvider(r.Context(), p)))
+		})
+	}
+}
+
+// WithProvider returns a copy of ctx carrying p, e.g. to evaluate flags outside of a request.
+func WithProvider(ctx context.Context, p Provider) context.Context {
+	return context.WithValue(ctx, providerKey, p)
+}
+
+// Enabled reports whether flag is enabled for ctx. Unknown flags, and every flag when
+// [Middleware] isn't in use, are disabled.
+func Enabled(ctx context.Context, flag string) bool {
+	p, ok := ctx.Value(providerKey).(Provider)
+	if !ok {
+		return false
+	}
+	enabled, found := p.Lookup(ctx, flag)
+	return found && enabled
+}
+
+// Static is a Provider backed by a fixed set of flags, e.g. loaded from the config file.
+type Static map[string]bool
+
+func (s Static) Lookup(ctx context.Context, flag string) (bool, bool) {
+	enabled, found := s[flag]
+	return enabled, found
+}
+
+// Env returns a Static provider with the flags set in the environment variables starting with
+// prefix: with prefix "GOTTH_FLAG_", GOTTH_FLAG_NEW_NAV=true enables "new-nav". Values are parsed
+// with strconv.ParseBool; invalid ones are ignored. The environment is read once.
+func Env(prefix string) Static {
+	s := Static{}
+	for _, kv := range os.Environ() {
+		key, value, _ := strings.Cut(kv, "=")
+		name, ok := strings.CutPrefix(key, prefix)
+		if !ok || name == "" {
+			continue
+		}
+		enabled, err := strconv.ParseBool(value)
+		if err != nil {
+			continue
+		}
+		s[strings.ReplaceAll(strings.ToLower(name), "_", "-")] = enabled
+	}
+	return s
+}
+
+// Chain returns a Provider that asks providers in order and returns the first answer found, so
+// that e.g. environment overrides take precedence over defaults.
+func Chain(providers ...Provider) Provider {
+	return ProviderFunc(func(ctx context.Context, flag string) (bool, bool) {
+		for _, p := range providers {
+			if enabled, found := p.Lookup(ctx, flag); found {
+				return enabled, true
+			}
+		}
+		return false, false
+	})
+}
+
+// Rollout returns a Provider that enables each flag for a percentage (0-100) of the keys returned
+// by key, typically the user ID. A key always gets the same answer for a flag, and raising the
+// percentage only adds keys. Flags are disabled when key returns "".
+func Rollout(percentages map[string]int, key func(ctx context.Context) string) Provider {
+	return ProviderFunc(func(ctx context.Context, flag string) (bool, bool) {
+		pct, found := percentages[flag]
+		if !found {
+			return false, false
+		}
+		k := key(ctx)
+		if k == "" {
+			return false, true
+		}
+		return Bucket(flag, k) < pct, true
+	})
+}
+
+// Bucket deterministically maps key to a bucket between 0 and 99 for name (a flag or an
+// experiment), independently from other names.
+func Bucket(name, key string) int {
+	h := fnv.New32a()
+	h.Write([]byte(name))
+	h.Write([]byte{0})
+	h.Write([]byte(key))
+	return int(h.Sum32() % 100)
+}
@@ -0,0 +1,92 @@
+package flags_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/ancalabrese/gotth/flags"
+)
+
+func TestEnabled(t *testing.T) {
+	t.Setenv("TEST_FLAG_NEW_NAV", "true")
+	t.Setenv("TEST_FLAG_DARK_MODE", "0")
+	t.Setenv("TEST_FLAG_BROKEN", "maybe")
+
+	provider := flags.Chain(flags.Env("TEST_FLAG_"), flags.Static{"dark-mode": true, "beta": true, "off": false})
+
+	tests := []struct {
+		flag     string
+		expected bool
+	}{
+		{flag: "new-nav", expected: true},
+		{flag: "dark-mode", expected: false}, // The environment overrides the default
+		{flag: "beta", expected: true},
+		{flag: "off", expected: false},
+		{flag: "broken", expected: false},
+		{flag: "unknown", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.flag, func(t *testing.T) {
+			var got bool
+			handler := flags.Middleware(provider)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = flags.Enabled(r.Context(), tt.flag)
+			}))
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+			if got != tt.expected {
+				t.Errorf("Enabled(%q) = %v, want %v", tt.flag, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestEnabled_WithoutMiddleware(t *testing.T) {
+	if flags.Enabled(context.Background(), "anything") {
+		t.Error("flag enabled without a provider")
+	}
+}
+
+type userKey struct{}
+
+func TestRollout(t *testing.T) {
+	provider := flags.Rollout(map[string]int{"half": 50, "none": 0, "all": 100}, func(ctx context.Context) string {
+		id, _ := ctx.Value(userKey{}).(string)
+		return id
+	})
+
+	count := func(flag string) int {
+		n := 0
+		for i := range 1000 {
+			ctx := flags.WithProvider(context.WithValue(context.Background(), userKey{}, "user-"+strconv.Itoa(i)), provider)
+			if flags.Enabled(ctx, flag) {
+				n++
+			}
+		}
+		return n
+	}
+
+	if n := count("none"); n != 0 {
+		t.Errorf("0%% rollout enabled %d users", n)
+	}
+	if n := count("all"); n != 1000 {
+		t.Errorf("100%% rollout enabled %d users", n)
+	}
+	if n := count("half"); n < 400 || n > 600 {
+		t.Errorf("50%% rollout enabled %d of 1000 users", n)
+	}
+
+	ctx := flags.WithProvider(context.WithValue(context.Background(), userKey{}, "user-1"), provider)
+	first := flags.Enabled(ctx, "half")
+	for range 10 {
+		if flags.Enabled(ctx, "half") != first {
+			t.Fatal("rollout is not deterministic")
+		}
+	}
+	if flags.Enabled(flags.WithProvider(context.Background(), provider), "all") {
+		t.Error("rollout enabled a flag without a key")
+	}
+}
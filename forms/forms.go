@@ -0,0 +1,132 @@
+// Package forms standardizes the invalid-form round trip: parse the submitted values, record
+// validation errors and render the form again with the errors and the values the user typed.
+//
+//	f, err := forms.Parse(r)
+//	if err != nil { ... }
+//	f.Check(strings.TrimSpace(f.Get("name")) != "", "name", "Name is required")
+//	if !f.Valid() {
+//		views.SignupForm(f).Render(r.Context(), w)
+//		return
+//	}
+//
+// where the view uses the components of this package:
+//
+//	@forms.ErrorSummary(f)
+//	@forms.Input(f, "name", "text", nil)
+//	@forms.FieldError(f, "name")
+package forms
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/a-h/templ"
+)
+
+// ValidationError is the validation error of a form field.
+type ValidationError struct {
+	Field   string
+	Message string
+}
+
+// Form holds the values submitted with a form and their validation errors.
+// A nil *Form is an empty form without errors, e.g. to render the form the first time.
+type Form struct {
+	Values url.Values
+	errors []ValidationError
+}
+
+// New returns a Form holding values.
+func New(values url.Values) *Form {
+	if values == nil {
+		values = url.Values{}
+	}
+	return &Form{Values: values}
+}
+
+// Parse returns a Form holding the form values submitted with r.
+func Parse(r *http.Request) (*Form, error) {
+	if err := r.ParseForm(); err != nil {
+		return nil, fmt.Errorf("failed to parse form. err %w", err)
+	}
+	return New(r.PostForm), nil
+}
+
+// Get returns the submitted value of field.
+func (f *Form) Get(field string) string {
+	if f == nil {
+		return ""
+	}
+	return f.Values.Get(field)
+}
+
+// AddError records msg as the error of field. Only the first error of a field is kept.
+func (f *Form) AddError(field, msg string) {
+	if f.Error(field) != "" {
+		return
+	}
+	f.errors = append(f.errors, ValidationError{Field: field, Message: msg})
+}
+
+// Check records msg as the error of field unless ok.
+func (f *Form) Check(ok bool, field, msg string) {
+	if !ok {
+		f.AddError(field, msg)
+	}
+}
+
+// Error returns the error of field, or "" if it's valid.
+func (f *Form) Error(field string) string {
+	if f == nil {
+		return ""
+	}
+	for _, e := range f.errors {
+		if e.Field == field {
+			return e.Message
+		}
+	}
+	return ""
+}
+
+// Errors returns the errors in the order they were recorded.
+func (f *Form) Errors() []ValidationError {
+	if f == nil {
+		return nil
+	}
+	return f.errors
+}
+
+// Valid reports whether the form has no errors.
+func (f *Form) Valid() bool {
+	return len(f.Errors()) == 0
+}
+
+// ErrorID returns the id of the element rendered by [FieldError] for field, which inputs
+// reference with aria-describedby.
+func ErrorID(field string) string {
+	return field + "-error"
+}
+
+// InputAttributes returns the attributes binding an input to field: its name and id, the
+// submitted value and, when invalid, aria-invalid and aria-describedby pointing to its error.
+// attrs are added on top, e.g. a class.
+func InputAttributes(f *Form, field string, attrs templ.Attributes) templ.Attributes {
+	a := templ.Attributes{"id": field, "name": field, "value": f.Get(field)}
+	if f.Error(field) != "" {
+		a["aria-invalid"] = "true"
+		a["aria-describedby"] = ErrorID(field)
+	}
+	for k, v := range attrs {
+		a[k] = v
+	}
+	return a
+}
+
+// textAreaAttributes returns the attributes of InputAttributes without the value, which is the
+// content of a textarea.
+func textAreaAttributes(f *Form, field string, attrs templ.Attributes) templ.Attributes {
+	a := InputAttributes(f, field, attrs)
+	delete(a, "value")
+	return a
+}
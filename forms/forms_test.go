@@ -0,0 +1,106 @@
+package forms_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/a-h/templ"
+	"github.com/ancalabrese/gotth/forms"
+)
+
+func render(t *testing.T, c templ.Component) string {
+	t.Helper()
+	var b strings.Builder
+	if err := c.Render(context.Background(), &b); err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	return b.String()
+}
+
+func TestForm(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(url.Values{"email": {"nope"}, "name": {""}}.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	f, err := forms.Parse(req)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	f.Check(f.Get("email") == "ada@example.com", "email", "Invalid email")
+	f.Check(f.Get("name") != "", "name", "Name is required")
+	f.Check(len(f.Get("email")) > 10, "email", "Email too short")
+
+	if f.Valid() {
+		t.Fatal("Valid() = true, want false")
+	}
+	want := []forms.ValidationError{{Field: "email", Message: "Invalid email"}, {Field: "name", Message: "Name is required"}}
+	if got := f.Errors(); len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("Errors() = %v, want %v", got, want)
+	}
+	if f.Get("email") != "nope" {
+		t.Errorf("Get() = %q, want %q", f.Get("email"), "nope")
+	}
+
+	var empty *forms.Form
+	if !empty.Valid() || empty.Get("email") != "" || empty.Error("email") != "" {
+		t.Error("a nil Form must be empty and valid")
+	}
+}
+
+func TestComponents(t *testing.T) {
+	f := forms.New(url.Values{"email": {`"nope"`}, "bio": {"<b>hi</b>"}})
+	f.AddError("email", "Invalid email")
+
+	tests := []struct {
+		name     string
+		c        templ.Component
+		expected string
+	}{
+		{
+			name:     "Invalid input",
+			c:        forms.Input(f, "email", "email", templ.Attributes{"class": "border"}),
+			expected: `<input type="email" aria-describedby="email-error" aria-invalid="true" class="border" id="email" name="email" value="&#34;nope&#34;">`,
+		},
+		{
+			name:     "Valid input",
+			c:        forms.Input(f, "name", "text", nil),
+			expected: `<input type="text" id="name" name="name" value="">`,
+		},
+		{
+			name:     "Text area",
+			c:        forms.TextArea(f, "bio", nil),
+			expected: `<textarea id="bio" name="bio">&lt;b&gt;hi&lt;/b&gt;</textarea>`,
+		},
+		{
+			name:     "Field error",
+			c:        forms.FieldError(f, "email"),
+			expected: `<p id="email-error" class="text-red-600 text-sm" data-error="email">Invalid email</p>`,
+		},
+		{
+			name:     "No field error",
+			c:        forms.FieldError(f, "bio"),
+			expected: ``,
+		},
+		{
+			name:     "Error summary",
+			c:        forms.ErrorSummary(f),
+			expected: `<div class="text-red-600 text-sm" role="alert" data-error-summary><p>Please correct the following errors:</p><ul><li><a href="#email">Invalid email</a></li></ul></div>`,
+		},
+		{
+			name:     "No error summary",
+			c:        forms.ErrorSummary(nil),
+			expected: ``,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := render(t, tt.c); got != tt.expected {
+				t.Errorf("got %s\nwant %s", got, tt.expected)
+			}
+		})
+	}
+}
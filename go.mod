@@ -2,4 +2,31 @@ module github.com/ancalabrese/gotth
 
 go 1.24.1
 
-require github.com/a-h/templ v0.3.865
+require (
+	github.com/BurntSushi/toml v1.5.0
+	github.com/PuerkitoBio/goquery v1.10.3
+	github.com/a-h/templ v0.3.865
+	github.com/alecthomas/chroma/v2 v2.20.0
+	github.com/alicebob/miniredis/v2 v2.35.0
+	github.com/aymerick/douceur v0.2.0
+	github.com/coder/websocket v1.8.14
+	github.com/golang-jwt/jwt/v5 v5.2.3
+	github.com/mattn/go-sqlite3 v1.14.22
+	github.com/microcosm-cc/bluemonday v1.0.27
+	github.com/redis/go-redis/v9 v9.7.3
+	github.com/yuin/goldmark v1.7.13
+	golang.org/x/image v0.25.0
+	golang.org/x/net v0.39.0
+	golang.org/x/sys v0.32.0
+	golang.org/x/text v0.24.0
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require (
+	github.com/andybalholm/cascadia v1.3.3 // indirect
+	github.com/cespare/xxhash/v2 v2.2.0 // indirect
+	github.com/dgryski/go-rendezvous v0.0.0-20200823014737-9f7001d12a5f // indirect
+	github.com/dlclark/regexp2 v1.11.5 // indirect
+	github.com/gorilla/css v1.0.1 // indirect
+	github.com/yuin/gopher-lua v1.1.1 // indirect
+)
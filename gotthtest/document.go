@@ -0,0 +1,153 @@
+package gotthtest
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// Document is a parsed response. Query it with the embedded goquery.Document, e.g.
+// doc.Find("nav a").Length(), or check it with the Assert methods, which report failures
+// with t.Errorf.
+type Document struct {
+	*goquery.Document
+	Response *http.Response
+	Body     string
+	t        testing.TB
+}
+
+// Parse reads and parses the body of resp, failing the test when it isn't valid HTML.
+func Parse(t testing.TB, resp *http.Response) *Document {
+	t.Helper()
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatalf("failed to read the response body. err %v", err)
+	}
+
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("failed to parse the response body. err %v", err)
+	}
+	return &Document{Document: doc, Response: resp, Body: string(body), t: t}
+}
+
+// Status returns the status code of the response.
+func (d *Document) Status() int {
+	return d.Response.StatusCode
+}
+
+// Title returns the text of the <title>.
+func (d *Document) Title() string {
+	return d.Find("title").First().Text()
+}
+
+// Meta returns the content of the <meta> named name, e.g. "description", or with the property
+// name, e.g. "og:title".
+func (d *Document) Meta(name string) string {
+	sel := d.Find(`meta[name="` + name + `"], meta[property="` + name + `"]`).First()
+	content, _ := sel.Attr("content")
+	return content
+}
+
+// Canonical returns the URL of the canonical link.
+func (d *Document) Canonical() string {
+	href, _ := d.Find(`link[rel="canonical"]`).First().Attr("href")
+	return href
+}
+
+// JSONLD returns the JSON-LD objects of the page. Scripts that aren't valid JSON objects fail
+// the test.
+func (d *Document) JSONLD() []map[string]any {
+	d.t.Helper()
+	var nodes []map[string]any
+	d.Find(`script[type="application/ld+json"]`).Each(func(_ int, s *goquery.Selection) {
+		var node map[string]any
+		if err := json.Unmarshal([]byte(s.Text()), &node); err != nil {
+			d.t.Errorf("invalid JSON-LD %q. err %v", s.Text(), err)
+			return
+		}
+		nodes = append(nodes, node)
+	})
+	return nodes
+}
+
+// AssertStatus checks the status code of the response.
+func (d *Document) AssertStatus(want int) {
+	d.t.Helper()
+	if got := d.Status(); got != want {
+		d.t.Errorf("status = %d, want %d", got, want)
+	}
+}
+
+// AssertTitle checks the <title>.
+func (d *Document) AssertTitle(want string) {
+	d.t.Helper()
+	if got := d.Title(); got != want {
+		d.t.Errorf("title = %q, want %q", got, want)
+	}
+}
+
+// AssertMeta checks the content of the <meta> named name. See [Document.Meta].
+func (d *Document) AssertMeta(name, want string) {
+	d.t.Helper()
+	if got := d.Meta(name); got != want {
+		d.t.Errorf("meta %s = %q, want %q", name, got, want)
+	}
+}
+
+// AssertCanonical checks the URL of the canonical link.
+func (d *Document) AssertCanonical(want string) {
+	d.t.Helper()
+	if got := d.Canonical(); got != want {
+		d.t.Errorf("canonical URL = %q, want %q", got, want)
+	}
+}
+
+// AssertJSONLDType checks that the page has a JSON-LD object of the schema.org type, e.g.
+// "BreadcrumbList".
+func (d *Document) AssertJSONLDType(want string) {
+	d.t.Helper()
+	var types []any
+	for _, node := range d.JSONLD() {
+		if node["@type"] == want {
+			return
+		}
+		types = append(types, node["@type"])
+	}
+	d.t.Errorf("no JSON-LD of type %q, got %v", want, types)
+}
+
+// AssertExists checks that selector matches at least one element.
+func (d *Document) AssertExists(selector string) {
+	d.t.Helper()
+	if d.Find(selector).Length() == 0 {
+		d.t.Errorf("no element matches %q", selector)
+	}
+}
+
+// AssertText checks the trimmed text of the first element matching selector.
+func (d *Document) AssertText(selector, want string) {
+	d.t.Helper()
+	sel := d.Find(selector).First()
+	if sel.Length() == 0 {
+		d.t.Errorf("no element matches %q", selector)
+		return
+	}
+	if got := strings.TrimSpace(sel.Text()); got != want {
+		d.t.Errorf("text of %q = %q, want %q", selector, got, want)
+	}
+}
+
+// AssertHeader checks a header of the response.
+func (d *Document) AssertHeader(key, want string) {
+	d.t.Helper()
+	if got := d.Response.Header.Get(key); got != want {
+		d.t.Errorf("header %s = %q, want %q", key, got, want)
+	}
+}
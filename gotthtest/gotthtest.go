@@ -0,0 +1,81 @@
+// Package gotthtest helps applications test their pages and handlers in-process, through
+// [gotth.WebServer.Handler], without booting real listeners. Responses are parsed into
+// goquery documents with assertions for the head metadata, e.g.:
+//
+//	func TestAbout(t *testing.T) {
+//		doc := gotthtest.RenderPage(t, about, httptest.NewRequest(http.MethodGet, "/about", nil))
+//		doc.AssertStatus(http.StatusOK)
+//		doc.AssertTitle("About | Example")
+//		doc.AssertMeta("description", "Who we are.")
+//		doc.AssertText("h1", "About us")
+//	}
+//
+// Use NewTestServer to test a whole application, with its middlewares and routes:
+//
+//	srv := gotthtest.NewTestServer(t, gotth.WebServerConfig{})
+//	app.Register(srv.WebServer)
+//	srv.Get("/blog/first-post").AssertCanonical("/blog/first-post")
+package gotthtest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/ancalabrese/gotth"
+)
+
+// Server is a WebServer serving requests in-process.
+type Server struct {
+	*gotth.WebServer
+	t testing.TB
+}
+
+// NewTestServer creates a WebServer with cfg, failing the test when it can't be created.
+// Register the routes on the embedded WebServer, then send requests with Do, Get or Post.
+func NewTestServer(t testing.TB, cfg gotth.WebServerConfig) *Server {
+	t.Helper()
+	ws, err := gotth.New(cfg, nil)
+	if err != nil {
+		t.Fatalf("failed to create the WebServer. err %v", err)
+	}
+	return &Server{WebServer: ws, t: t}
+}
+
+// Do serves req and returns the parsed response. It fails the test when a route registration
+// failed (see [gotth.WebServer.Err]), as Start would.
+func (s *Server) Do(req *http.Request) *Document {
+	s.t.Helper()
+	if err := s.Err(); err != nil {
+		s.t.Fatalf("failed to register the routes. err %v", err)
+	}
+	rr := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rr, req)
+	return Parse(s.t, rr.Result())
+}
+
+// Get serves a GET request for target, a path or an absolute URL.
+func (s *Server) Get(target string) *Document {
+	s.t.Helper()
+	return s.Do(httptest.NewRequest(http.MethodGet, target, nil))
+}
+
+// Post serves a POST request of the url-encoded form to target.
+func (s *Server) Post(target string, form url.Values) *Document {
+	s.t.Helper()
+	req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return s.Do(req)
+}
+
+// RenderPage serves req with provider, registered on a new WebServer with the default config,
+// and returns the parsed page. The provider is registered for every path: use NewTestServer and
+// ServeContent with the real pattern when it reads path values.
+func RenderPage(t testing.TB, provider gotth.ContentProviderFunc, req *http.Request) *Document {
+	t.Helper()
+	s := NewTestServer(t, gotth.WebServerConfig{})
+	s.ServeContent("/", provider)
+	return s.Do(req)
+}
@@ -0,0 +1,90 @@
+package gotthtest_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/a-h/templ"
+	"github.com/ancalabrese/gotth"
+	"github.com/ancalabrese/gotth/gotthtest"
+	"github.com/ancalabrese/gotth/views/components/head"
+)
+
+func html(s string) templ.Component {
+	return templ.ComponentFunc(func(ctx context.Context, w io.Writer) error {
+		_, err := io.WriteString(w, s)
+		return err
+	})
+}
+
+func about(r *http.Request) (head.HeadViewModel, templ.Component, error) {
+	return head.NewHeadViewModel(
+		head.WithPageCoreMetadata("About | Example", "Who we are.", "/about"),
+		head.WithJSONLD(head.JSONLDNode{Context: "https://schema.org", Type: "AboutPage"}),
+	), html("<h1> About us </h1>"), nil
+}
+
+// recorder records the failures of the assertions under test.
+type recorder struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recorder) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func TestRenderPage(t *testing.T) {
+	doc := gotthtest.RenderPage(t, about, httptest.NewRequest(http.MethodGet, "/about", nil))
+
+	doc.AssertStatus(http.StatusOK)
+	doc.AssertTitle("About | Example")
+	doc.AssertMeta("description", "Who we are.")
+	doc.AssertMeta("og:title", "About | Example")
+	doc.AssertCanonical("/about")
+	doc.AssertJSONLDType("AboutPage")
+	doc.AssertExists("body h1")
+	doc.AssertText("h1", "About us")
+	doc.AssertHeader("Content-Type", "text/html; charset=utf-8")
+}
+
+func TestDocument_AssertionFailures(t *testing.T) {
+	rec := &recorder{TB: t}
+	doc := gotthtest.RenderPage(rec, about, httptest.NewRequest(http.MethodGet, "/about", nil))
+
+	doc.AssertStatus(http.StatusNotFound)
+	doc.AssertTitle("Home")
+	doc.AssertMeta("description", "")
+	doc.AssertCanonical("/")
+	doc.AssertJSONLDType("BreadcrumbList")
+	doc.AssertExists("footer")
+	doc.AssertText("h2", "")
+	doc.AssertHeader("Cache-Control", "no-store")
+
+	if len(rec.errors) != 8 {
+		t.Errorf("got %d failures, want 8: %q", len(rec.errors), rec.errors)
+	}
+}
+
+func TestServer(t *testing.T) {
+	srv := gotthtest.NewTestServer(t, gotth.WebServerConfig{})
+	srv.ServeContent("GET /posts/{slug}", func(r *http.Request) (head.HeadViewModel, templ.Component, error) {
+		slug := r.PathValue("slug")
+		return head.NewHeadViewModel(head.WithPageCoreMetadata(slug, "", "/posts/"+slug)), html("<p>" + slug + "</p>"), nil
+	})
+	srv.Handle("POST /echo", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "<output>%s</output>", r.FormValue("name"))
+	}))
+
+	doc := srv.Get("/posts/first")
+	doc.AssertTitle("first")
+	doc.AssertCanonical("/posts/first")
+
+	srv.Get("/missing").AssertStatus(http.StatusNotFound)
+	srv.Post("/echo", url.Values{"name": {"Ada"}}).AssertText("output", "Ada")
+}
@@ -0,0 +1,251 @@
+package gotthtest
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/a-h/templ"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// SnapshotDir is the directory of the golden files, relative to the package under test.
+var SnapshotDir = filepath.Join("testdata", "snapshots")
+
+// UpdateEnv rewrites the golden files when set to a non-empty value, like the -update flag.
+const UpdateEnv = "GOTTH_UPDATE_SNAPSHOTS"
+
+func init() {
+	// Test packages often define their own -update flag: reuse it instead of panicking.
+	if flag.Lookup("update") == nil {
+		flag.Bool("update", false, "rewrite the golden files of gotthtest snapshots")
+	}
+}
+
+func updating() bool {
+	if os.Getenv(UpdateEnv) != "" {
+		return true
+	}
+	f := flag.Lookup("update")
+	return f != nil && f.Value.String() == "true"
+}
+
+// SnapshotOption customizes a snapshot.
+type SnapshotOption func(*snapshotOptions)
+
+type snapshotOptions struct {
+	ctx          context.Context
+	replacements []replacement
+}
+
+type replacement struct {
+	re   *regexp.Regexp
+	repl string
+}
+
+// WithContext renders the component with ctx, e.g. carrying the theme, CSRF token or path.
+func WithContext(ctx context.Context) SnapshotOption {
+	return func(o *snapshotOptions) { o.ctx = ctx }
+}
+
+// WithReplace replaces the matches of the regular expression pattern in the normalized HTML
+// with repl, to mask values that change between runs, e.g. nonces, tokens or fingerprints.
+func WithReplace(pattern, repl string) SnapshotOption {
+	re := regexp.MustCompile(pattern)
+	return func(o *snapshotOptions) {
+		o.replacements = append(o.replacements, replacement{re: re, repl: repl})
+	}
+}
+
+// Snapshot renders c and compares its normalized HTML (see [Normalize]) with the golden file
+// testdata/snapshots/<name>.html, failing the test with the first difference. Run the tests
+// with -update (or GOTTH_UPDATE_SNAPSHOTS=1) to write the golden files, then review and commit
+// them:
+//
+//	func TestCard(t *testing.T) {
+//		gotthtest.Snapshot(t, "card", ui.Card(ui.CardViewModel{Title: "Hello"}, body))
+//	}
+func Snapshot(t testing.TB, name string, c templ.Component, opts ...SnapshotOption) {
+	t.Helper()
+	o := snapshotOptions{ctx: context.Background()}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	var buf bytes.Buffer
+	if err := c.Render(o.ctx, &buf); err != nil {
+		t.Fatalf("failed to render %s. err %v", name, err)
+	}
+	SnapshotHTML(t, name, buf.String(), opts...)
+}
+
+// SnapshotHTML compares the normalized s with the golden file of name. See [Snapshot].
+func SnapshotHTML(t testing.TB, name, s string, opts ...SnapshotOption) {
+	t.Helper()
+	var o snapshotOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	got, err := Normalize(s)
+	if err != nil {
+		t.Fatalf("failed to normalize %s. err %v", name, err)
+	}
+	for _, r := range o.replacements {
+		got = r.re.ReplaceAllString(got, r.repl)
+	}
+
+	path := filepath.Join(SnapshotDir, filepath.FromSlash(name)+".html")
+	if updating() {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("failed to create the snapshot directory. err %v", err)
+		}
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Fatalf("failed to write the snapshot. err %v", err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		t.Fatalf("no snapshot %s: run the tests with -update to create it", path)
+	}
+	if err != nil {
+		t.Fatalf("failed to read the snapshot. err %v", err)
+	}
+	if got != string(want) {
+		t.Errorf("%s doesn't match the snapshot %s (run with -update to accept the change):\n%s",
+			name, path, firstDiff(string(want), got))
+	}
+}
+
+// firstDiff describes the first line that differs between want and got, with the line before it.
+func firstDiff(want, got string) string {
+	wantLines, gotLines := strings.Split(want, "\n"), strings.Split(got, "\n")
+	for i := 0; ; i++ {
+		w, g := "<EOF>", "<EOF>"
+		if i < len(wantLines) {
+			w = wantLines[i]
+		}
+		if i < len(gotLines) {
+			g = gotLines[i]
+		}
+		if w == g {
+			continue
+		}
+		var b strings.Builder
+		if i > 0 {
+			fmt.Fprintf(&b, "  %d   %s\n", i, wantLines[i-1])
+		}
+		fmt.Fprintf(&b, "- %d   %s\n+ %d   %s", i+1, w, i+1, g)
+		return b.String()
+	}
+}
+
+// Normalize formats an HTML document or fragment so that snapshots only change when the markup
+// does: one element per line, indented, with sorted attributes and collapsed whitespace (except
+// in pre, textarea, script and style elements).
+func Normalize(s string) (string, error) {
+	var nodes []*html.Node
+	trimmed := strings.ToLower(strings.TrimSpace(s))
+	if strings.HasPrefix(trimmed, "<!doctype") || strings.HasPrefix(trimmed, "<html") {
+		doc, err := html.Parse(strings.NewReader(s))
+		if err != nil {
+			return "", err
+		}
+		for n := doc.FirstChild; n != nil; n = n.NextSibling {
+			nodes = append(nodes, n)
+		}
+	} else {
+		var err error
+		nodes, err = html.ParseFragment(strings.NewReader(s), &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body})
+		if err != nil {
+			return "", err
+		}
+	}
+
+	var b strings.Builder
+	for _, n := range nodes {
+		writeNode(&b, n, 0)
+	}
+	return b.String(), nil
+}
+
+var voidElements = map[string]bool{
+	"area": true, "base": true, "br": true, "col": true, "embed": true, "hr": true, "img": true,
+	"input": true, "link": true, "meta": true, "source": true, "track": true, "wbr": true,
+}
+
+var rawElements = map[string]bool{"pre": true, "textarea": true, "script": true, "style": true}
+
+func writeNode(b *strings.Builder, n *html.Node, depth int) {
+	indent := strings.Repeat("  ", depth)
+	switch n.Type {
+	case html.DoctypeNode:
+		fmt.Fprintf(b, "%s<!DOCTYPE %s>\n", indent, n.Data)
+	case html.CommentNode:
+		fmt.Fprintf(b, "%s<!--%s-->\n", indent, n.Data)
+	case html.TextNode:
+		if text := strings.Join(strings.Fields(n.Data), " "); text != "" {
+			fmt.Fprintf(b, "%s%s\n", indent, html.EscapeString(text))
+		}
+	case html.ElementNode:
+		b.WriteString(indent)
+		writeStartTag(b, n)
+		if voidElements[n.Data] {
+			b.WriteString("\n")
+			return
+		}
+		if rawElements[n.Data] {
+			var raw strings.Builder
+			for c := n.FirstChild; c != nil; c = c.NextSibling {
+				if n.Data == "script" || n.Data == "style" {
+					raw.WriteString(c.Data)
+				} else {
+					html.Render(&raw, c)
+				}
+			}
+			fmt.Fprintf(b, "%s</%s>\n", strings.TrimSpace(raw.String()), n.Data)
+			return
+		}
+		if text, ok := onlyText(n); ok {
+			fmt.Fprintf(b, "%s</%s>\n", html.EscapeString(text), n.Data)
+			return
+		}
+		b.WriteString("\n")
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			writeNode(b, c, depth+1)
+		}
+		fmt.Fprintf(b, "%s</%s>\n", indent, n.Data)
+	}
+}
+
+func writeStartTag(b *strings.Builder, n *html.Node) {
+	attrs := slices.Clone(n.Attr)
+	slices.SortFunc(attrs, func(a, b html.Attribute) int { return strings.Compare(a.Key, b.Key) })
+	b.WriteString("<" + n.Data)
+	for _, a := range attrs {
+		fmt.Fprintf(b, ` %s="%s"`, a.Key, html.EscapeString(a.Val))
+	}
+	b.WriteString(">")
+}
+
+// onlyText returns the collapsed text of n when it has no child elements.
+func onlyText(n *html.Node) (string, bool) {
+	var text []string
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type != html.TextNode {
+			return "", false
+		}
+		text = append(text, strings.Fields(c.Data)...)
+	}
+	return strings.Join(text, " "), true
+}
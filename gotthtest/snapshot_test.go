@@ -0,0 +1,89 @@
+package gotthtest_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ancalabrese/gotth/gotthtest"
+	"github.com/ancalabrese/gotth/views/components/head"
+	"github.com/ancalabrese/gotth/views/components/layout"
+	"github.com/ancalabrese/gotth/views/components/ui"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "Fragment",
+			input:    `<div  id="a" class="x y"><p>Hello,   <b>world</b></p><br></div>`,
+			expected: "<div class=\"x y\" id=\"a\">\n  <p>\n    Hello,\n    <b>world</b>\n  </p>\n  <br>\n</div>\n",
+		},
+		{
+			name:     "Document",
+			input:    "<!DOCTYPE html><html lang=\"en\"><head><title> Home </title></head><body></body></html>",
+			expected: "<!DOCTYPE html>\n<html lang=\"en\">\n  <head>\n    <title>Home</title>\n  </head>\n  <body></body>\n</html>\n",
+		},
+		{
+			name:     "Raw text is kept",
+			input:    "<pre>a\n  b</pre><script>\nlet x = 1 < 2;\n</script>",
+			expected: "<pre>a\n  b</pre>\n<script>let x = 1 < 2;</script>\n",
+		},
+		{
+			name:     "Escaping",
+			input:    `<a href="/?a=1&amp;b=&quot;2&quot;">&lt;tag&gt;</a>`,
+			expected: "<a href=\"/?a=1&amp;b=&#34;2&#34;\">&lt;tag&gt;</a>\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := gotthtest.Normalize(tt.input)
+			if err != nil {
+				t.Fatalf("Normalize() error = %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("Normalize() =\n%s\nwant\n%s", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	vm := head.NewHeadViewModel(
+		head.WithName("Example"),
+		head.WithPageCoreMetadata("Home | Example", "The home page.", "https://example.com/"),
+	)
+	gotthtest.Snapshot(t, "layout", layout.BasicLayout(vm, html("<main><h1>Home</h1></main>")))
+	gotthtest.Snapshot(t, "ui/button", ui.Button(ui.ButtonViewModel{Label: "Save", Type: "submit"}))
+}
+
+func TestSnapshot_Mismatch(t *testing.T) {
+	rec := &recorder{TB: t}
+	gotthtest.Snapshot(rec, "ui/button", ui.Button(ui.ButtonViewModel{Label: "Delete", Type: "submit"}))
+
+	if len(rec.errors) != 1 || !strings.Contains(rec.errors[0], "-update") || !strings.Contains(rec.errors[0], "+ ") {
+		t.Errorf("errors = %q, want the first difference", rec.errors)
+	}
+}
+
+func TestSnapshot_Update(t *testing.T) {
+	dir := gotthtest.SnapshotDir
+	gotthtest.SnapshotDir = t.TempDir()
+	t.Cleanup(func() { gotthtest.SnapshotDir = dir })
+	t.Setenv(gotthtest.UpdateEnv, "1")
+
+	gotthtest.SnapshotHTML(t, "nested/token", `<input name="csrf" value="f00ba4">`, gotthtest.WithReplace(`value="[0-9a-f]+"`, `value="TOKEN"`))
+
+	got, err := os.ReadFile(filepath.Join(gotthtest.SnapshotDir, "nested", "token.html"))
+	if err != nil {
+		t.Fatalf("snapshot not written: %v", err)
+	}
+	if want := "<input name=\"csrf\" value=\"TOKEN\">\n"; string(got) != want {
+		t.Errorf("snapshot = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,192 @@
+// Package htmx provides helpers to read HTMX request headers and set HTMX response headers.
+// See https://htmx.org/reference/#headers.
+package htmx
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// Swap styles for [Reswap] and [LocationOptions.Swap].
+const (
+	SwapInnerHTML   = "innerHTML"
+	SwapOuterHTML   = "outerHTML"
+	SwapBeforeBegin = "beforebegin"
+	SwapAfterBegin  = "afterbegin"
+	SwapBeforeEnd   = "beforeend"
+	SwapAfterEnd    = "afterend"
+	SwapDelete      = "delete"
+	SwapNone        = "none"
+)
+
+// IsRequest reports whether r was sent by HTMX.
+func IsRequest(r *http.Request) bool {
+	return r.Header.Get("HX-Request") == "true"
+}
+
+// IsBoosted reports whether r comes from an element using hx-boost.
+func IsBoosted(r *http.Request) bool {
+	return r.Header.Get("HX-Boosted") == "true"
+}
+
+// IsHistoryRestore reports whether r restores a page missing from the HTMX history cache.
+func IsHistoryRestore(r *http.Request) bool {
+	return r.Header.Get("HX-History-Restore-Request") == "true"
+}
+
+// IsFragment reports whether r is an HTMX request expecting a fragment to swap into the current
+// page, rather than a full page (boosted navigations and history restores).
+func IsFragment(r *http.Request) bool {
+	return IsRequest(r) && !IsBoosted(r) && !IsHistoryRestore(r)
+}
+
+// CurrentURL returns the URL of the browser page that sent r.
+func CurrentURL(r *http.Request) string { return r.Header.Get("HX-Current-URL") }
+
+// Target returns the id of the target element, if it has one.
+func Target(r *http.Request) string { return r.Header.Get("HX-Target") }
+
+// TriggerID returns the id of the element that triggered r, if it has one.
+func TriggerID(r *http.Request) string { return r.Header.Get("HX-Trigger") }
+
+// TriggerName returns the name of the element that triggered r, if it has one.
+func TriggerName(r *http.Request) string { return r.Header.Get("HX-Trigger-Name") }
+
+// Prompt returns the user's answer to hx-prompt.
+func Prompt(r *http.Request) string { return r.Header.Get("HX-Prompt") }
+
+// Redirect makes HTMX do a full page redirect to url.
+func Redirect(w http.ResponseWriter, url string) {
+	w.Header().Set("HX-Redirect", url)
+}
+
+// Refresh makes HTMX do a full page refresh.
+func Refresh(w http.ResponseWriter) {
+	w.Header().Set("HX-Refresh", "true")
+}
+
+// PushURL pushes url into the browser history. Pass "false" to prevent a history update.
+func PushURL(w http.ResponseWriter, url string) {
+	w.Header().Set("HX-Push-Url", url)
+}
+
+// ReplaceURL replaces the current URL in the browser location bar.
+func ReplaceURL(w http.ResponseWriter, url string) {
+	w.Header().Set("HX-Replace-Url", url)
+}
+
+// Retarget swaps the response into the element matching the CSS selector instead of the target.
+func Retarget(w http.ResponseWriter, selector string) {
+	w.Header().Set("HX-Retarget", selector)
+}
+
+// Reswap changes the swap style of the response, e.g. [SwapOuterHTML] or "innerHTML scroll:top".
+func Reswap(w http.ResponseWriter, swap string) {
+	w.Header().Set("HX-Reswap", swap)
+}
+
+// Reselect swaps only the part of the response matching the CSS selector.
+func Reselect(w http.ResponseWriter, selector string) {
+	w.Header().Set("HX-Reselect", selector)
+}
+
+// LocationOptions configures a client side navigation via [Location].
+type LocationOptions struct {
+	Path    string            `json:"path"`
+	Source  string            `json:"source,omitempty"`
+	Event   string            `json:"event,omitempty"`
+	Handler string            `json:"handler,omitempty"`
+	Target  string            `json:"target,omitempty"`
+	Swap    string            `json:"swap,omitempty"`
+	Select  string            `json:"select,omitempty"`
+	Values  map[string]any    `json:"values,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+// Location makes HTMX load opts.Path without a full page reload, as if following an hx-boost
+// link, swapping it into opts.Target (the body by default).
+func Location(w http.ResponseWriter, opts LocationOptions) error {
+	simple := opts.Source == "" && opts.Event == "" && opts.Handler == "" && opts.Target == "" &&
+		opts.Swap == "" && opts.Select == "" && len(opts.Values) == 0 && len(opts.Headers) == 0
+	if simple {
+		w.Header().Set("HX-Location", opts.Path)
+		return nil
+	}
+	b, err := json.Marshal(opts)
+	if err != nil {
+		return err
+	}
+	w.Header().Set("HX-Location", string(b))
+	return nil
+}
+
+// Event is a client side event triggered via [Trigger]. Detail, when set, is JSON encoded and
+// available in the event's detail, e.g. Event{Name: "showMessage", Detail: map[string]string{"level": "info"}}.
+type Event struct {
+	Name   string
+	Detail any
+}
+
+// Trigger triggers events on the client as soon as the response is received. Calling it more than
+// once adds events rather than replacing them.
+func Trigger(w http.ResponseWriter, events ...Event) error {
+	return addEvents(w.Header(), "HX-Trigger", events)
+}
+
+// TriggerAfterSwap is like [Trigger] but triggers the events after the swap.
+func TriggerAfterSwap(w http.ResponseWriter, events ...Event) error {
+	return addEvents(w.Header(), "HX-Trigger-After-Swap", events)
+}
+
+// TriggerAfterSettle is like [Trigger] but triggers the events after the settle step.
+func TriggerAfterSettle(w http.ResponseWriter, events ...Event) error {
+	return addEvents(w.Header(), "HX-Trigger-After-Settle", events)
+}
+
+// addEvents merges events into the trigger header. Events without detail are sent as a comma
+// separated list, which HTMX accepts; otherwise as a JSON object keyed by event name.
+func addEvents(h http.Header, header string, events []Event) error {
+	names := []string{}
+	details := map[string]any{}
+	hasDetail := false
+
+	if existing := h.Get(header); existing != "" {
+		if strings.HasPrefix(strings.TrimSpace(existing), "{") {
+			if err := json.Unmarshal([]byte(existing), &details); err != nil {
+				return err
+			}
+			hasDetail = true
+			for name := range details {
+				names = append(names, name)
+			}
+		} else {
+			for _, name := range strings.Split(existing, ",") {
+				name = strings.TrimSpace(name)
+				names = append(names, name)
+				details[name] = nil
+			}
+		}
+	}
+
+	for _, e := range events {
+		if _, ok := details[e.Name]; !ok {
+			names = append(names, e.Name)
+		}
+		details[e.Name] = e.Detail
+		if e.Detail != nil {
+			hasDetail = true
+		}
+	}
+
+	if !hasDetail {
+		h.Set(header, strings.Join(names, ", "))
+		return nil
+	}
+	b, err := json.Marshal(details)
+	if err != nil {
+		return err
+	}
+	h.Set(header, string(b))
+	return nil
+}
@@ -0,0 +1,102 @@
+package htmx_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ancalabrese/gotth/htmx"
+)
+
+func TestRequestHelpers(t *testing.T) {
+	tests := []struct {
+		name         string
+		headers      map[string]string
+		wantRequest  bool
+		wantFragment bool
+	}{
+		{name: "Plain request"},
+		{name: "HTMX request", headers: map[string]string{"HX-Request": "true"}, wantRequest: true, wantFragment: true},
+		{name: "Boosted", headers: map[string]string{"HX-Request": "true", "HX-Boosted": "true"}, wantRequest: true},
+		{name: "History restore", headers: map[string]string{"HX-Request": "true", "HX-History-Restore-Request": "true"}, wantRequest: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			for k, v := range tt.headers {
+				r.Header.Set(k, v)
+			}
+			if htmx.IsRequest(r) != tt.wantRequest || htmx.IsFragment(r) != tt.wantFragment {
+				t.Errorf("IsRequest = %v, IsFragment = %v", htmx.IsRequest(r), htmx.IsFragment(r))
+			}
+		})
+	}
+}
+
+func TestResponseHelpers(t *testing.T) {
+	tests := []struct {
+		name     string
+		set      func(w http.ResponseWriter) error
+		header   string
+		expected string
+	}{
+		{name: "Redirect", set: func(w http.ResponseWriter) error { htmx.Redirect(w, "/login"); return nil }, header: "HX-Redirect", expected: "/login"},
+		{name: "Refresh", set: func(w http.ResponseWriter) error { htmx.Refresh(w); return nil }, header: "HX-Refresh", expected: "true"},
+		{name: "PushURL", set: func(w http.ResponseWriter) error { htmx.PushURL(w, "/page/2"); return nil }, header: "HX-Push-Url", expected: "/page/2"},
+		{name: "Retarget", set: func(w http.ResponseWriter) error { htmx.Retarget(w, "#errors"); return nil }, header: "HX-Retarget", expected: "#errors"},
+		{name: "Reswap", set: func(w http.ResponseWriter) error { htmx.Reswap(w, htmx.SwapOuterHTML); return nil }, header: "HX-Reswap", expected: "outerHTML"},
+		{
+			name:     "Simple location",
+			set:      func(w http.ResponseWriter) error { return htmx.Location(w, htmx.LocationOptions{Path: "/inbox"}) },
+			header:   "HX-Location",
+			expected: "/inbox",
+		},
+		{
+			name: "Location with options",
+			set: func(w http.ResponseWriter) error {
+				return htmx.Location(w, htmx.LocationOptions{Path: "/inbox", Target: "#main", Swap: htmx.SwapInnerHTML})
+			},
+			header:   "HX-Location",
+			expected: `{"path":"/inbox","target":"#main","swap":"innerHTML"}`,
+		},
+		{
+			name: "Trigger without details",
+			set: func(w http.ResponseWriter) error {
+				htmx.Trigger(w, htmx.Event{Name: "cartUpdated"})
+				return htmx.Trigger(w, htmx.Event{Name: "closeModal"}, htmx.Event{Name: "cartUpdated"})
+			},
+			header:   "HX-Trigger",
+			expected: "cartUpdated, closeModal",
+		},
+		{
+			name: "Trigger with details merges",
+			set: func(w http.ResponseWriter) error {
+				htmx.Trigger(w, htmx.Event{Name: "closeModal"})
+				return htmx.Trigger(w, htmx.Event{Name: "showMessage", Detail: map[string]string{"level": "info", "text": "Saved"}})
+			},
+			header:   "HX-Trigger",
+			expected: `{"closeModal":null,"showMessage":{"level":"info","text":"Saved"}}`,
+		},
+		{
+			name: "Trigger after settle",
+			set: func(w http.ResponseWriter) error {
+				return htmx.TriggerAfterSettle(w, htmx.Event{Name: "count", Detail: 3})
+			},
+			header:   "HX-Trigger-After-Settle",
+			expected: `{"count":3}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			if err := tt.set(rr); err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if got := rr.Header().Get(tt.header); got != tt.expected {
+				t.Errorf("%s = %q, want %q", tt.header, got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package htmx_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/a-h/templ"
+	"github.com/ancalabrese/gotth/htmx"
+)
+
+func TestWithOOB(t *testing.T) {
+	tests := []struct {
+		name      string
+		component templ.Component
+		expected  string
+	}{
+		{
+			name:      "Main only",
+			component: htmx.WithOOB(templ.Raw("<li>Milk</li>")),
+			expected:  "<li>Milk</li>",
+		},
+		{
+			name: "Main and OOB swaps",
+			component: htmx.WithOOB(templ.Raw("<li>Milk</li>"),
+				htmx.OOB("#cart-badge", templ.Raw("3")),
+				htmx.OOBSwap(htmx.SwapBeforeEnd, "#toasts", templ.Raw("<p>Added</p>")),
+			),
+			expected: `<li>Milk</li><div hx-swap-oob="innerHTML:#cart-badge">3</div><div hx-swap-oob="beforeend:#toasts"><p>Added</p></div>`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := tt.component.Render(context.Background(), &buf); err != nil {
+				t.Fatalf("Render() error = %v", err)
+			}
+			if buf.String() != tt.expected {
+				t.Errorf("got %s, want %s", buf.String(), tt.expected)
+			}
+		})
+	}
+}
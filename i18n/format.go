@@ -0,0 +1,216 @@
+package i18n
+
+import (
+	"context"
+	"math"
+	"strings"
+	"time"
+
+	"golang.org/x/text/currency"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"golang.org/x/text/number"
+)
+
+// Formatter formats numbers, prices and dates for a locale. Get the one of the request with
+// [Format] in templ components:
+//
+//	<span>{ i18n.Format(ctx).Currency(p.Price, "EUR") }</span>
+//	<time>{ i18n.Format(ctx).Date(p.Published, i18n.DateLong) }</time>
+type Formatter struct {
+	locale  string
+	tag     language.Tag
+	printer *message.Printer
+	dates   dateFormat
+}
+
+// DefaultLocale is the locale of the contexts without one, e.g. outside of localized routes.
+const DefaultLocale = "en"
+
+// Format returns the Formatter of the locale of ctx, or of [DefaultLocale].
+func Format(ctx context.Context) Formatter {
+	return NewFormatter(FromContext(ctx))
+}
+
+// NewFormatter returns the Formatter of locale, a BCP 47 tag like "de" or "pt-BR". Invalid and
+// empty locales format for [DefaultLocale].
+func NewFormatter(locale string) Formatter {
+	tag, err := language.Parse(locale)
+	if err != nil || locale == "" {
+		locale, tag = DefaultLocale, language.English
+	}
+	return Formatter{locale: locale, tag: tag, printer: message.NewPrinter(tag), dates: lookup(tag, dateFormats, isoDates)}
+}
+
+// Locale returns the locale of f.
+func (f Formatter) Locale() string {
+	return f.locale
+}
+
+// Number formats v with the grouping and decimal separators of the locale and up to 3 fraction
+// digits, e.g. "1.234,5" in German.
+func (f Formatter) Number(v float64) string {
+	return f.printer.Sprint(number.Decimal(v))
+}
+
+// Decimal formats v with exactly digits fraction digits, e.g. "1,234.50" for 2 in English.
+func (f Formatter) Decimal(v float64, digits int) string {
+	return f.printer.Sprint(number.Decimal(v, number.MinFractionDigits(digits), number.MaxFractionDigits(digits)))
+}
+
+// Percent formats the ratio v as a percentage, e.g. "26 %" in German for 0.256.
+func (f Formatter) Percent(v float64) string {
+	return f.printer.Sprint(number.Percent(v))
+}
+
+// Currency formats amount in the currency of the ISO 4217 code, with the fraction digits of the
+// currency and its symbol placed as in the locale, e.g. "€1,234.50" in English and
+// "1.234,50 €" in German. Unknown codes are written after the amount.
+func (f Formatter) Currency(amount float64, code string) string {
+	unit, err := currency.ParseISO(code)
+	if err != nil {
+		return f.Decimal(amount, 2) + " " + code
+	}
+	scale, _ := currency.Standard.Rounding(unit)
+	value := f.Decimal(math.Abs(amount), scale)
+	symbol := f.printer.Sprint(currency.Symbol(unit))
+
+	sign := ""
+	if amount < 0 && value != f.Decimal(0, scale) {
+		sign = "-"
+	}
+	switch lookup(f.tag, currencyPatterns, symbolBefore) {
+	case symbolAfter:
+		return sign + value + "\u00a0" + symbol
+	case symbolBeforeSpaced:
+		return sign + symbol + "\u00a0" + value
+	default:
+		return sign + symbol + value
+	}
+}
+
+// DateStyle is the length of a formatted date.
+type DateStyle int
+
+const (
+	DateShort  DateStyle = iota // Numeric, e.g. "1/2/06" in English and "02.01.06" in German
+	DateMedium                  // Abbreviated month, e.g. "Jan 2, 2006" and "02.01.2006"
+	DateLong                    // Full month, e.g. "January 2, 2006" and "2. Januar 2006"
+)
+
+// Date formats the date of t in the style of the locale. Languages without built-in formats
+// (English, German, French, Spanish, Italian, Portuguese and Dutch are built in) get ISO 8601
+// dates, e.g. "2006-01-02".
+func (f Formatter) Date(t time.Time, style DateStyle) string {
+	layout := f.dates.short
+	switch style {
+	case DateMedium:
+		layout = f.dates.medium
+	case DateLong:
+		layout = f.dates.long
+	}
+	out := t.Format(layout)
+	if f.dates.months != nil {
+		out = strings.ReplaceAll(out, "{month}", f.dates.months[t.Month()-1])
+		out = strings.ReplaceAll(out, "{mon}", f.dates.shortMonths[t.Month()-1])
+	}
+	return out
+}
+
+// Time formats the time of day of t, e.g. "3:04 PM" in English and "15:04" in German.
+func (f Formatter) Time(t time.Time) string {
+	return t.Format(f.dates.time)
+}
+
+// DateTime formats the date of t in style followed by its time of day.
+func (f Formatter) DateTime(t time.Time, style DateStyle) string {
+	return f.Date(t, style) + " " + f.Time(t)
+}
+
+// lookup returns the value of m for the tag, e.g. "pt-PT", else for its language, e.g. "pt",
+// else def.
+func lookup[T any](tag language.Tag, m map[string]T, def T) T {
+	if v, ok := m[tag.String()]; ok {
+		return v
+	}
+	base, _ := tag.Base()
+	if v, ok := m[base.String()]; ok {
+		return v
+	}
+	return def
+}
+
+type currencyPattern int
+
+const (
+	symbolBefore       currencyPattern = iota // €1,234.50
+	symbolBeforeSpaced                        // € 1.234,50
+	symbolAfter                               // 1.234,50 €
+)
+
+// currencyPatterns place the currency symbol of the languages not writing it right before the
+// amount, after the CLDR.
+var currencyPatterns = map[string]currencyPattern{
+	"de": symbolAfter, "de-CH": symbolBeforeSpaced, "fr": symbolAfter, "es": symbolAfter,
+	"it": symbolAfter, "pt": symbolBeforeSpaced, "pt-PT": symbolAfter, "nl": symbolBeforeSpaced,
+	"pl": symbolAfter, "cs": symbolAfter, "sk": symbolAfter, "sv": symbolAfter, "fi": symbolAfter,
+	"da": symbolAfter, "nb": symbolAfter, "ru": symbolAfter, "uk": symbolAfter, "hu": symbolAfter,
+	"ro": symbolAfter, "bg": symbolAfter, "hr": symbolAfter, "sl": symbolAfter, "lt": symbolAfter,
+	"lv": symbolAfter, "et": symbolAfter, "el": symbolAfter,
+}
+
+// dateFormat holds time layouts in which {month} and {mon} stand for the full and abbreviated
+// month names of the language.
+type dateFormat struct {
+	short, medium, long string
+	time                string
+	months, shortMonths []string
+}
+
+var isoDates = dateFormat{short: "2006-01-02", medium: "2006-01-02", long: "2006-01-02", time: "15:04"}
+
+var englishMonths = []string{"January", "February", "March", "April", "May", "June", "July", "August", "September", "October", "November", "December"}
+
+var englishShortMonths = []string{"Jan", "Feb", "Mar", "Apr", "May", "Jun", "Jul", "Aug", "Sep", "Oct", "Nov", "Dec"}
+
+// dateFormats are the CLDR date formats of the built-in languages.
+var dateFormats = map[string]dateFormat{
+	"en": {
+		short: "1/2/06", medium: "{mon} 2, 2006", long: "{month} 2, 2006", time: "3:04 PM",
+		months: englishMonths, shortMonths: englishShortMonths,
+	},
+	"en-GB": {
+		short: "02/01/2006", medium: "2 {mon} 2006", long: "2 {month} 2006", time: "15:04",
+		months: englishMonths, shortMonths: englishShortMonths,
+	},
+	"de": {
+		short: "02.01.06", medium: "02.01.2006", long: "2. {month} 2006", time: "15:04",
+		months:      []string{"Januar", "Februar", "März", "April", "Mai", "Juni", "Juli", "August", "September", "Oktober", "November", "Dezember"},
+		shortMonths: []string{"Jan.", "Feb.", "März", "Apr.", "Mai", "Juni", "Juli", "Aug.", "Sept.", "Okt.", "Nov.", "Dez."},
+	},
+	"fr": {
+		short: "02/01/2006", medium: "2 {mon} 2006", long: "2 {month} 2006", time: "15:04",
+		months:      []string{"janvier", "février", "mars", "avril", "mai", "juin", "juillet", "août", "septembre", "octobre", "novembre", "décembre"},
+		shortMonths: []string{"janv.", "févr.", "mars", "avr.", "mai", "juin", "juil.", "août", "sept.", "oct.", "nov.", "déc."},
+	},
+	"es": {
+		short: "2/1/06", medium: "2 {mon} 2006", long: "2 de {month} de 2006", time: "15:04",
+		months:      []string{"enero", "febrero", "marzo", "abril", "mayo", "junio", "julio", "agosto", "septiembre", "octubre", "noviembre", "diciembre"},
+		shortMonths: []string{"ene", "feb", "mar", "abr", "may", "jun", "jul", "ago", "sept", "oct", "nov", "dic"},
+	},
+	"it": {
+		short: "02/01/06", medium: "2 {mon} 2006", long: "2 {month} 2006", time: "15:04",
+		months:      []string{"gennaio", "febbraio", "marzo", "aprile", "maggio", "giugno", "luglio", "agosto", "settembre", "ottobre", "novembre", "dicembre"},
+		shortMonths: []string{"gen", "feb", "mar", "apr", "mag", "giu", "lug", "ago", "set", "ott", "nov", "dic"},
+	},
+	"pt": {
+		short: "02/01/2006", medium: "2 de {mon} de 2006", long: "2 de {month} de 2006", time: "15:04",
+		months:      []string{"janeiro", "fevereiro", "março", "abril", "maio", "junho", "julho", "agosto", "setembro", "outubro", "novembro", "dezembro"},
+		shortMonths: []string{"jan.", "fev.", "mar.", "abr.", "mai.", "jun.", "jul.", "ago.", "set.", "out.", "nov.", "dez."},
+	},
+	"nl": {
+		short: "02-01-2006", medium: "2 {mon} 2006", long: "2 {month} 2006", time: "15:04",
+		months:      []string{"januari", "februari", "maart", "april", "mei", "juni", "juli", "augustus", "september", "oktober", "november", "december"},
+		shortMonths: []string{"jan", "feb", "mrt", "apr", "mei", "jun", "jul", "aug", "sep", "okt", "nov", "dec"},
+	},
+}
@@ -0,0 +1,103 @@
+package i18n_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ancalabrese/gotth/i18n"
+)
+
+func TestFormatter_Numbers(t *testing.T) {
+	tests := []struct {
+		locale      string
+		wantNumber  string
+		wantDecimal string
+		wantPercent string
+	}{
+		{locale: "en", wantNumber: "1,234,567.891", wantDecimal: "1,234.50", wantPercent: "26%"},
+		{locale: "de", wantNumber: "1.234.567,891", wantDecimal: "1.234,50", wantPercent: "26\u00a0%"},
+		{locale: "fr", wantNumber: "1\u00a0234\u00a0567,891", wantDecimal: "1\u00a0234,50", wantPercent: "26\u00a0%"},
+		{locale: "", wantNumber: "1,234,567.891", wantDecimal: "1,234.50", wantPercent: "26%"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.locale, func(t *testing.T) {
+			f := i18n.NewFormatter(tt.locale)
+			if got := f.Number(1234567.891); got != tt.wantNumber {
+				t.Errorf("Number() = %q, want %q", got, tt.wantNumber)
+			}
+			if got := f.Decimal(1234.5, 2); got != tt.wantDecimal {
+				t.Errorf("Decimal() = %q, want %q", got, tt.wantDecimal)
+			}
+			if got := f.Percent(0.256); got != tt.wantPercent {
+				t.Errorf("Percent() = %q, want %q", got, tt.wantPercent)
+			}
+		})
+	}
+}
+
+func TestFormatter_Currency(t *testing.T) {
+	tests := []struct {
+		locale string
+		amount float64
+		code   string
+		want   string
+	}{
+		{locale: "en", amount: 1234.5, code: "EUR", want: "€1,234.50"},
+		{locale: "en", amount: -9.99, code: "USD", want: "-$9.99"},
+		{locale: "de", amount: 1234.5, code: "EUR", want: "1.234,50\u00a0€"},
+		{locale: "pt-BR", amount: 1234.5, code: "BRL", want: "R$\u00a01.234,50"},
+		{locale: "ja", amount: 1234.5, code: "JPY", want: "￥1,234"},
+		{locale: "en", amount: 10, code: "XYZ1", want: "10.00 XYZ1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.locale+" "+tt.code, func(t *testing.T) {
+			if got := i18n.NewFormatter(tt.locale).Currency(tt.amount, tt.code); got != tt.want {
+				t.Errorf("Currency(%v, %q) = %q, want %q", tt.amount, tt.code, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatter_Dates(t *testing.T) {
+	ts := time.Date(2025, time.March, 7, 15, 4, 0, 0, time.UTC)
+	tests := []struct {
+		locale                      string
+		wantShort, wantMedium, want string
+		wantTime                    string
+	}{
+		{locale: "en", wantShort: "3/7/25", wantMedium: "Mar 7, 2025", want: "March 7, 2025", wantTime: "3:04 PM"},
+		{locale: "en-GB", wantShort: "07/03/2025", wantMedium: "7 Mar 2025", want: "7 March 2025", wantTime: "15:04"},
+		{locale: "de", wantShort: "07.03.25", wantMedium: "07.03.2025", want: "7. März 2025", wantTime: "15:04"},
+		{locale: "es", wantShort: "7/3/25", wantMedium: "7 mar 2025", want: "7 de marzo de 2025", wantTime: "15:04"},
+		{locale: "pt-BR", wantShort: "07/03/2025", wantMedium: "7 de mar. de 2025", want: "7 de março de 2025", wantTime: "15:04"},
+		{locale: "ja", wantShort: "2025-03-07", wantMedium: "2025-03-07", want: "2025-03-07", wantTime: "15:04"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.locale, func(t *testing.T) {
+			f := i18n.NewFormatter(tt.locale)
+			if got := f.Date(ts, i18n.DateShort); got != tt.wantShort {
+				t.Errorf("Date(DateShort) = %q, want %q", got, tt.wantShort)
+			}
+			if got := f.Date(ts, i18n.DateMedium); got != tt.wantMedium {
+				t.Errorf("Date(DateMedium) = %q, want %q", got, tt.wantMedium)
+			}
+			if got := f.Date(ts, i18n.DateLong); got != tt.want {
+				t.Errorf("Date(DateLong) = %q, want %q", got, tt.want)
+			}
+			if got := f.Time(ts); got != tt.wantTime {
+				t.Errorf("Time() = %q, want %q", got, tt.wantTime)
+			}
+		})
+	}
+}
+
+func TestFormat(t *testing.T) {
+	if got := i18n.Format(context.Background()).Locale(); got != i18n.DefaultLocale {
+		t.Errorf("Locale() without locale = %q, want %q", got, i18n.DefaultLocale)
+	}
+	ctx := i18n.WithLocale(context.Background(), "de")
+	if got := i18n.Format(ctx).DateTime(time.Date(2025, time.March, 7, 9, 30, 0, 0, time.UTC), i18n.DateMedium); got != "07.03.2025 09:30" {
+		t.Errorf("DateTime() = %q, want %q", got, "07.03.2025 09:30")
+	}
+}
@@ -0,0 +1,144 @@
+// Package i18n serves the pages of a site under locale prefixes, e.g. /en/about and /de/about:
+// the locale is taken from the path, and the bare path redirects to the locale of the visitor,
+// negotiated from the Accept-Language header.
+//
+//	ws, _ := gotth.New(gotth.WebServerConfig{Locales: &i18n.Config{Locales: []string{"en", "de"}}}, nil)
+//	ws.ServeLocalized("GET /about", about) // Serves /en/about and /de/about, redirects /about
+//
+// Content providers read the locale of the request with [FromContext], and templ components
+// format numbers, prices and dates for it with [Format].
+package i18n
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"golang.org/x/text/language"
+)
+
+// Config configures the [Locales] of a site.
+type Config struct {
+	// Locales are the BCP 47 tags of the supported locales as they appear in the URLs, e.g. "en"
+	// and "pt-br".
+	Locales []string
+	// Optional: Default is the locale of the visitors whose languages aren't supported. Defaults
+	// to the first of Locales.
+	Default string
+}
+
+// ErrNoLocales is returned by [New] without Config.Locales.
+var ErrNoLocales = errors.New("no locales configured")
+
+// Locales are the supported locales of a site.
+type Locales struct {
+	locales []string
+	def     string
+	matcher language.Matcher
+}
+
+// New returns the Locales of cfg.
+func New(cfg Config) (*Locales, error) {
+	if len(cfg.Locales) == 0 {
+		return nil, ErrNoLocales
+	}
+	def := cfg.Default
+	if def == "" {
+		def = cfg.Locales[0]
+	}
+
+	l := &Locales{locales: cfg.Locales, def: def}
+	// The matcher falls back to its first tag: the default.
+	tags := []language.Tag{}
+	for _, locale := range append([]string{def}, cfg.Locales...) {
+		tag, err := language.Parse(locale)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse locale %q. err %w", locale, err)
+		}
+		tags = append(tags, tag)
+	}
+	if !l.Supported(def) {
+		return nil, fmt.Errorf("default locale %q is not one of %v", def, cfg.Locales)
+	}
+	l.matcher = language.NewMatcher(tags)
+	return l, nil
+}
+
+// All returns the supported locales.
+func (l *Locales) All() []string {
+	return append([]string(nil), l.locales...)
+}
+
+// Default returns the default locale.
+func (l *Locales) Default() string {
+	return l.def
+}
+
+// Supported reports whether locale is one of the supported locales.
+func (l *Locales) Supported(locale string) bool {
+	for _, s := range l.locales {
+		if strings.EqualFold(s, locale) {
+			return true
+		}
+	}
+	return false
+}
+
+// Negotiate returns the supported locale best matching the Accept-Language header value, e.g.
+// "de" for "de-AT,de;q=0.9,en;q=0.8", or the default locale.
+func (l *Locales) Negotiate(acceptLanguage string) string {
+	prefs, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil || len(prefs) == 0 {
+		return l.def
+	}
+	_, i, confidence := l.matcher.Match(prefs...)
+	if confidence == language.No || i == 0 {
+		return l.def
+	}
+	return l.locales[i-1]
+}
+
+// Path returns path under the prefix of locale, e.g. "/de/about" for "/about".
+func (l *Locales) Path(locale, path string) string {
+	return "/" + locale + "/" + strings.TrimPrefix(path, "/")
+}
+
+// Split returns the locale prefixing path and the path without it, e.g. "de" and "/about" for
+// "/de/about". ok is false when path has no supported locale prefix.
+func (l *Locales) Split(path string) (locale, rest string, ok bool) {
+	first, rest, _ := strings.Cut(strings.TrimPrefix(path, "/"), "/")
+	for _, s := range l.locales {
+		if strings.EqualFold(s, first) {
+			return s, "/" + rest, true
+		}
+	}
+	return "", path, false
+}
+
+// Middleware returns a new middleware (http.Handler) adding the locale prefixing the request
+// path to the context. The requests without a locale prefix are left as they are.
+func (l *Locales) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if locale, _, ok := l.Split(r.URL.Path); ok {
+			r = r.WithContext(WithLocale(r.Context(), locale))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+type ctxKey string
+
+const localeKey ctxKey = "gotth_locale"
+
+// WithLocale returns a copy of ctx carrying locale.
+func WithLocale(ctx context.Context, locale string) context.Context {
+	return context.WithValue(ctx, localeKey, locale)
+}
+
+// FromContext returns the locale of ctx, or "" when none was set.
+func FromContext(ctx context.Context) string {
+	locale, _ := ctx.Value(localeKey).(string)
+	return locale
+}
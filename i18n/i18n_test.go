@@ -0,0 +1,107 @@
+package i18n_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ancalabrese/gotth/i18n"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     i18n.Config
+		wantErr bool
+	}{
+		{name: "Valid", cfg: i18n.Config{Locales: []string{"en", "de"}}},
+		{name: "Explicit default", cfg: i18n.Config{Locales: []string{"en", "de"}, Default: "de"}},
+		{name: "No locales", cfg: i18n.Config{}, wantErr: true},
+		{name: "Invalid tag", cfg: i18n.Config{Locales: []string{"en", "not a tag"}}, wantErr: true},
+		{name: "Unsupported default", cfg: i18n.Config{Locales: []string{"en", "de"}, Default: "fr"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := i18n.New(tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("New() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+	if _, err := i18n.New(i18n.Config{}); !errors.Is(err, i18n.ErrNoLocales) {
+		t.Errorf("New() error = %v, want %v", err, i18n.ErrNoLocales)
+	}
+}
+
+func TestLocales_Negotiate(t *testing.T) {
+	l, err := i18n.New(i18n.Config{Locales: []string{"en", "de", "pt-br"}})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	tests := []struct {
+		acceptLanguage string
+		want           string
+	}{
+		{acceptLanguage: "", want: "en"},
+		{acceptLanguage: "de", want: "de"},
+		{acceptLanguage: "de-AT,de;q=0.9,en;q=0.8", want: "de"},
+		{acceptLanguage: "fr-FR,fr;q=0.9", want: "en"},
+		{acceptLanguage: "fr;q=0.9,de;q=0.5", want: "de"},
+		{acceptLanguage: "pt-BR", want: "pt-br"},
+		{acceptLanguage: "en-GB;q=0.3,de;q=0.7", want: "de"},
+		{acceptLanguage: "invalid;;q=x", want: "en"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.acceptLanguage, func(t *testing.T) {
+			if got := l.Negotiate(tt.acceptLanguage); got != tt.want {
+				t.Errorf("Negotiate(%q) = %q, want %q", tt.acceptLanguage, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLocales_Split(t *testing.T) {
+	l, _ := i18n.New(i18n.Config{Locales: []string{"en", "de"}})
+	tests := []struct {
+		path       string
+		wantLocale string
+		wantRest   string
+		wantOK     bool
+	}{
+		{path: "/de/about", wantLocale: "de", wantRest: "/about", wantOK: true},
+		{path: "/DE/about", wantLocale: "de", wantRest: "/about", wantOK: true},
+		{path: "/en/", wantLocale: "en", wantRest: "/", wantOK: true},
+		{path: "/en", wantLocale: "en", wantRest: "/", wantOK: true},
+		{path: "/about", wantRest: "/about"},
+		{path: "/design", wantRest: "/design"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			locale, rest, ok := l.Split(tt.path)
+			if locale != tt.wantLocale || rest != tt.wantRest || ok != tt.wantOK {
+				t.Errorf("Split(%q) = %q, %q, %v, want %q, %q, %v", tt.path, locale, rest, ok, tt.wantLocale, tt.wantRest, tt.wantOK)
+			}
+			if ok {
+				if got := l.Path(locale, rest); got != "/"+locale+rest {
+					t.Errorf("Path(%q, %q) = %q", locale, rest, got)
+				}
+			}
+		})
+	}
+}
+
+func TestLocales_Middleware(t *testing.T) {
+	l, _ := i18n.New(i18n.Config{Locales: []string{"en", "de"}})
+	var got string
+	handler := l.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = i18n.FromContext(r.Context())
+	}))
+
+	for path, want := range map[string]string{"/de/contact": "de", "/static/app.css": ""} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+		if got != want {
+			t.Errorf("locale of %s = %q, want %q", path, got, want)
+		}
+	}
+}
@@ -0,0 +1,258 @@
+// Package idempotency makes retried POSTs safe: the response to a request carrying an
+// Idempotency-Key header is saved, and replayed to the retries with the same key instead of
+// running the handler again, so that a double submitted form or a retried API call doesn't
+// create two orders.
+//
+//	ws.Handle("POST /orders", idempotency.Protect(idempotency.Config{})(createOrder))
+//
+// HTMX forms send a new key on every render with [HXHeaders]:
+//
+//	<form hx-post="/orders" hx-headers={ idempotency.HXHeaders() }>
+//
+// A retry while the first request is still processed gets a 409 Conflict, and a key reused for
+// a different request a 422 Unprocessable Content, as in the IETF Idempotency-Key draft.
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"time"
+)
+
+const (
+	// HeaderName is the request header holding the key.
+	HeaderName = "Idempotency-Key"
+	// ReplayedHeader is set to "true" on the replayed responses.
+	ReplayedHeader = "Idempotent-Replayed"
+	// MaxKeyLength is the maximum length of a key.
+	MaxKeyLength = 255
+
+	// DefaultTTL is how long the responses are saved.
+	DefaultTTL = 24 * time.Hour
+	// DefaultLockTTL bounds the time a request holds its key before a retry can run again.
+	DefaultLockTTL = time.Minute
+	// DefaultMaxBodyBytes is the maximum size of a saved response body.
+	DefaultMaxBodyBytes = 1 << 20
+)
+
+// Config configures the [Protect] middleware.
+type Config struct {
+	// Optional: holds the keys and the responses. Defaults to a [MemoryStore], use a shared one
+	// with several instances.
+	Store Store
+	// TTL is how long the responses are saved. Defaults to [DefaultTTL].
+	TTL time.Duration
+	// LockTTL is how long a request in progress holds its key, in case the instance processing
+	// it dies. Defaults to [DefaultLockTTL].
+	LockTTL time.Duration
+	// Methods are the protected methods. Defaults to POST and PATCH.
+	Methods []string
+	// Required answers the requests of Methods without key with a 400 Bad Request, e.g. for an
+	// API. Requests without key are processed normally otherwise.
+	Required bool
+	// Optional: Scope returns the owner of the request, e.g. the user ID, so that a key only
+	// replays the responses of its owner.
+	Scope func(r *http.Request) string
+	// MaxBodyBytes is the maximum size of the saved response bodies: larger responses aren't
+	// saved. The first MaxBodyBytes of the request body identify the request. Defaults to
+	// [DefaultMaxBodyBytes].
+	MaxBodyBytes int64
+	// Optional: logs the store failures. Defaults to slog.Default().
+	Logger *slog.Logger
+}
+
+// Protect returns a new middleware (http.Handler) that saves the responses to the requests of
+// cfg.Methods carrying an Idempotency-Key header, and replays them to the retries with the same
+// key, method and path. Responses with a 5xx status, larger than cfg.MaxBodyBytes or of a
+// panicking handler aren't saved, so that the retry runs the handler again. Set-Cookie
+// headers aren't replayed.
+func Protect(cfg Config) func(http.Handler) http.Handler {
+	if cfg.Store == nil {
+		cfg.Store = NewMemoryStore()
+	}
+	if cfg.TTL <= 0 {
+		cfg.TTL = DefaultTTL
+	}
+	if cfg.LockTTL <= 0 {
+		cfg.LockTTL = DefaultLockTTL
+	}
+	if len(cfg.Methods) == 0 {
+		cfg.Methods = []string{http.MethodPost, http.MethodPatch}
+	}
+	if cfg.MaxBodyBytes <= 0 {
+		cfg.MaxBodyBytes = DefaultMaxBodyBytes
+	}
+	logger := cfg.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(HeaderName)
+			if !slices.Contains(cfg.Methods, r.Method) || key == "" && !cfg.Required {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if key == "" || len(key) > MaxKeyLength {
+				http.Error(w, "Bad Request: missing or invalid "+HeaderName, http.StatusBadRequest)
+				return
+			}
+
+			fp, err := fingerprint(r, cfg.MaxBodyBytes)
+			if err != nil {
+				http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+				return
+			}
+			scope := ""
+			if cfg.Scope != nil {
+				scope = cfg.Scope(r)
+			}
+			sum := sha256.Sum256([]byte(scope + "\x00" + r.Method + " " + r.URL.Path + "\x00" + key))
+			storeKey := hex.EncodeToString(sum[:])
+
+			// Saving and releasing outlive a client hanging up.
+			ctx := context.WithoutCancel(r.Context())
+			first, saved, err := cfg.Store.Claim(ctx, storeKey, cfg.LockTTL)
+			switch {
+			case err != nil:
+				logger.ErrorContext(r.Context(), "failed to claim idempotency key", slog.String("path", r.URL.Path), slog.Any("error", err))
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				return
+			case !first && saved == nil:
+				w.Header().Set("Retry-After", "1")
+				http.Error(w, "Conflict: the request with this "+HeaderName+" is in progress", http.StatusConflict)
+				return
+			case !first && saved.Fingerprint != fp:
+				http.Error(w, "Unprocessable Content: "+HeaderName+" reused for another request", http.StatusUnprocessableEntity)
+				return
+			case !first:
+				replay(w, saved)
+				return
+			}
+
+			rec := &recorder{ResponseWriter: w, status: http.StatusOK, max: cfg.MaxBodyBytes}
+			defer func() {
+				// Also run when the handler panics, so that the key isn't held until LockTTL.
+				if saved != nil {
+					if err := cfg.Store.Save(ctx, storeKey, saved, cfg.TTL); err != nil {
+						logger.ErrorContext(r.Context(), "failed to save idempotent response", slog.String("path", r.URL.Path), slog.Any("error", err))
+					}
+					return
+				}
+				if err := cfg.Store.Release(ctx, storeKey); err != nil {
+					logger.ErrorContext(r.Context(), "failed to release idempotency key", slog.String("path", r.URL.Path), slog.Any("error", err))
+				}
+			}()
+			next.ServeHTTP(rec, r)
+			if !rec.overflow && rec.status < http.StatusInternalServerError {
+				saved = &Response{Status: rec.status, Header: rec.header, Body: rec.body.Bytes(), Fingerprint: fp}
+				if saved.Header == nil {
+					saved.Header = cloneHeader(w.Header())
+				}
+			}
+		})
+	}
+}
+
+// HXHeaders returns the JSON value of an hx-headers attribute sending a new key with the HTMX
+// requests of the element and its children, e.g. a form. A new key is generated on every
+// render, so that each form rendered is submitted once.
+func HXHeaders() string {
+	b, _ := json.Marshal(map[string]string{HeaderName: NewKey()})
+	return string(b)
+}
+
+// NewKey returns a random key.
+func NewKey() string {
+	return rand.Text()
+}
+
+// fingerprint returns the hash of the method, URL and first maxBytes of the body of r, which
+// stays readable by the handler.
+func fingerprint(r *http.Request, maxBytes int64) (string, error) {
+	h := sha256.New()
+	io.WriteString(h, r.Method+" "+r.URL.RequestURI()+"\x00")
+	if r.Body != nil && r.Body != http.NoBody {
+		head, err := io.ReadAll(io.LimitReader(r.Body, maxBytes))
+		if err != nil {
+			return "", err
+		}
+		h.Write(head)
+		r.Body = readCloser{io.MultiReader(bytes.NewReader(head), r.Body), r.Body}
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+func replay(w http.ResponseWriter, resp *Response) {
+	for k, v := range resp.Header {
+		w.Header()[k] = slices.Clone(v)
+	}
+	w.Header().Set(ReplayedHeader, "true")
+	w.WriteHeader(resp.Status)
+	w.Write(resp.Body)
+}
+
+// cloneHeader returns the headers of h to save, without Set-Cookie.
+func cloneHeader(h http.Header) map[string][]string {
+	c := h.Clone()
+	delete(c, "Set-Cookie")
+	return c
+}
+
+// recorder writes the response through while recording it, up to max bytes of body.
+type recorder struct {
+	http.ResponseWriter
+	status   int
+	header   map[string][]string
+	body     bytes.Buffer
+	max      int64
+	overflow bool
+}
+
+func (rr *recorder) WriteHeader(status int) {
+	if rr.header == nil && status >= http.StatusOK {
+		rr.status = status
+		rr.header = cloneHeader(rr.ResponseWriter.Header())
+	}
+	rr.ResponseWriter.WriteHeader(status)
+}
+
+func (rr *recorder) Write(b []byte) (int, error) {
+	if rr.header == nil {
+		rr.WriteHeader(http.StatusOK)
+	}
+	if !rr.overflow {
+		if int64(rr.body.Len()+len(b)) > rr.max {
+			rr.overflow = true
+			rr.body = bytes.Buffer{}
+		} else {
+			rr.body.Write(b)
+		}
+	}
+	return rr.ResponseWriter.Write(b)
+}
+
+func (rr *recorder) Flush() {
+	if rr.header == nil {
+		rr.WriteHeader(http.StatusOK)
+	}
+	http.NewResponseController(rr.ResponseWriter).Flush()
+}
+
+func (rr *recorder) Unwrap() http.ResponseWriter {
+	return rr.ResponseWriter
+}
@@ -0,0 +1,134 @@
+package idempotency_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ancalabrese/gotth/idempotency"
+)
+
+func post(h http.Handler, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(body))
+	if key != "" {
+		req.Header.Set(idempotency.HeaderName, key)
+	}
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestProtect(t *testing.T) {
+	var orders atomic.Int32
+	h := idempotency.Protect(idempotency.Config{Logger: slog.New(slog.DiscardHandler)})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		n := orders.Add(1)
+		http.SetCookie(w, &http.Cookie{Name: "flash", Value: "created"})
+		w.Header().Set("Location", fmt.Sprintf("/orders/%d", n))
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, "order %d: %s", n, body)
+	}))
+
+	first := post(h, "key-1", "item=book")
+	if first.Code != http.StatusCreated || first.Body.String() != "order 1: item=book" {
+		t.Fatalf("first response = %d %q", first.Code, first.Body)
+	}
+
+	retry := post(h, "key-1", "item=book")
+	if orders.Load() != 1 {
+		t.Errorf("orders = %d, want the retry not to run the handler", orders.Load())
+	}
+	if retry.Code != http.StatusCreated || retry.Body.String() != "order 1: item=book" || retry.Header().Get("Location") != "/orders/1" {
+		t.Errorf("retry = %d %q %v, want the first response", retry.Code, retry.Body, retry.Header())
+	}
+	if retry.Header().Get(idempotency.ReplayedHeader) != "true" || retry.Header().Get("Set-Cookie") != "" {
+		t.Errorf("retry headers = %v, want %s and no Set-Cookie", retry.Header(), idempotency.ReplayedHeader)
+	}
+
+	if rr := post(h, "key-1", "item=pen"); rr.Code != http.StatusUnprocessableEntity {
+		t.Errorf("status of a reused key = %d, want %d", rr.Code, http.StatusUnprocessableEntity)
+	}
+	if rr := post(h, "key-2", "item=book"); rr.Body.String() != "order 2: item=book" {
+		t.Errorf("body of another key = %q, want a new order", rr.Body)
+	}
+	post(h, "", "item=book")
+	post(h, "", "item=book")
+	if orders.Load() != 4 {
+		t.Errorf("orders = %d, want the requests without key processed", orders.Load())
+	}
+}
+
+func TestProtect_InProgressAndFailures(t *testing.T) {
+	store := idempotency.NewMemoryStore()
+	release := make(chan struct{})
+	started := make(chan struct{})
+	var calls atomic.Int32
+	h := idempotency.Protect(idempotency.Config{Store: store, Required: true, Logger: slog.New(slog.DiscardHandler)})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch calls.Add(1) {
+		case 1:
+			close(started)
+			<-release
+			w.WriteHeader(http.StatusNoContent)
+		case 2:
+			http.Error(w, "db down", http.StatusServiceUnavailable)
+		case 3:
+			panic("boom")
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+
+	done := make(chan struct{})
+	go func() {
+		post(h, "slow", "")
+		close(done)
+	}()
+	<-started
+	if rr := post(h, "slow", ""); rr.Code != http.StatusConflict || rr.Header().Get("Retry-After") == "" {
+		t.Errorf("status while in progress = %d, want %d with Retry-After", rr.Code, http.StatusConflict)
+	}
+	close(release)
+	<-done
+
+	// Server errors and panics release the key, so that the retry is processed.
+	if rr := post(h, "flaky", ""); rr.Code != http.StatusServiceUnavailable {
+		t.Errorf("status = %d, want %d", rr.Code, http.StatusServiceUnavailable)
+	}
+	func() {
+		defer func() { recover() }()
+		post(h, "flaky", "")
+	}()
+	if rr := post(h, "flaky", ""); rr.Code != http.StatusNoContent || calls.Load() != 4 {
+		t.Errorf("status of the retry = %d after %d calls, want %d", rr.Code, calls.Load(), http.StatusNoContent)
+	}
+	if store.Len() != 2 {
+		t.Errorf("Len() = %d, want the 2 saved responses", store.Len())
+	}
+
+	if rr := post(h, "", ""); rr.Code != http.StatusBadRequest {
+		t.Errorf("status without a required key = %d, want %d", rr.Code, http.StatusBadRequest)
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	s := idempotency.NewMemoryStore()
+	if ok, _, _ := s.Claim(ctx, "a", time.Nanosecond); !ok {
+		t.Error("first Claim() = false")
+	}
+	time.Sleep(time.Millisecond)
+	if ok, _, _ := s.Claim(ctx, "a", time.Hour); !ok {
+		t.Error("Claim() of an expired key = false")
+	}
+	s.Save(ctx, "a", &idempotency.Response{Status: http.StatusOK}, time.Hour)
+	if ok, resp, _ := s.Claim(ctx, "a", time.Hour); ok || resp == nil || resp.Status != http.StatusOK {
+		t.Errorf("Claim() of a saved key = %v, %+v, want the response", ok, resp)
+	}
+}
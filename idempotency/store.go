@@ -0,0 +1,85 @@
+package idempotency
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Response is a response saved by [Protect], replayed to the retries of its request.
+type Response struct {
+	Status int
+	Header map[string][]string
+	Body   []byte
+	// Fingerprint identifies the request, to reject a key reused for another request.
+	Fingerprint string
+}
+
+// Store holds the requests in progress and the saved responses of [Protect], by key.
+type Store interface {
+	// Claim reserves key for ttl while its request is processed. It returns false and the saved
+	// response, nil while the request is in progress, when key is already claimed.
+	Claim(ctx context.Context, key string, ttl time.Duration) (bool, *Response, error)
+	// Save replaces the reservation of key with resp for ttl.
+	Save(ctx context.Context, key string, resp *Response, ttl time.Duration) error
+	// Release forgets key, e.g. after a failed request, so that its retry is processed.
+	Release(ctx context.Context, key string) error
+}
+
+// MemoryStore is a [Store] in memory, for a single instance.
+type MemoryStore struct {
+	mu      sync.Mutex
+	entries map[string]memoryEntry
+	sweepAt int // Size of entries sweeping the expired ones
+	now     func() time.Time
+}
+
+type memoryEntry struct {
+	resp    *Response // nil while in progress
+	expires time.Time
+}
+
+// NewMemoryStore returns an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{entries: map[string]memoryEntry{}, sweepAt: 1024, now: time.Now}
+}
+
+func (s *MemoryStore) Claim(ctx context.Context, key string, ttl time.Duration) (bool, *Response, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	if e, ok := s.entries[key]; ok && now.Before(e.expires) {
+		return false, e.resp, nil
+	}
+	s.entries[key] = memoryEntry{expires: now.Add(ttl)}
+	if len(s.entries) >= s.sweepAt {
+		for k, e := range s.entries {
+			if !now.Before(e.expires) {
+				delete(s.entries, k)
+			}
+		}
+		s.sweepAt = max(1024, 2*len(s.entries))
+	}
+	return true, nil, nil
+}
+
+func (s *MemoryStore) Save(ctx context.Context, key string, resp *Response, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries[key] = memoryEntry{resp: resp, expires: s.now().Add(ttl)}
+	return nil
+}
+
+func (s *MemoryStore) Release(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.entries, key)
+	return nil
+}
+
+// Len returns the number of keys held, including the expired ones not swept yet.
+func (s *MemoryStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.entries)
+}
@@ -0,0 +1,74 @@
+package images
+
+import (
+	"container/list"
+	"sync"
+)
+
+// Cache stores the encoded variants. It must be safe for concurrent use.
+type Cache interface {
+	Get(key string) (*Variant, bool)
+	Set(key string, v *Variant)
+}
+
+// MemoryCache is an in-memory Cache evicting the least recently used variants beyond its size.
+type MemoryCache struct {
+	maxBytes int
+
+	mu      sync.Mutex
+	bytes   int
+	order   *list.List // Of *cacheEntry, the most recently used first
+	entries map[string]*list.Element
+}
+
+type cacheEntry struct {
+	key     string
+	variant *Variant
+}
+
+// NewMemoryCache returns a MemoryCache of up to maxBytes of variants.
+func NewMemoryCache(maxBytes int) *MemoryCache {
+	return &MemoryCache{maxBytes: maxBytes, order: list.New(), entries: map[string]*list.Element{}}
+}
+
+// Get returns the variant of key.
+func (c *MemoryCache) Get(key string) (*Variant, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(e)
+	return e.Value.(*cacheEntry).variant, true
+}
+
+// Set stores the variant of key. Variants larger than the cache aren't stored.
+func (c *MemoryCache) Set(key string, v *Variant) {
+	if len(v.Data) > c.maxBytes {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.entries[key]; ok {
+		c.remove(e)
+	}
+	c.entries[key] = c.order.PushFront(&cacheEntry{key: key, variant: v})
+	c.bytes += len(v.Data)
+	for c.bytes > c.maxBytes {
+		c.remove(c.order.Back())
+	}
+}
+
+// Len returns the number of cached variants.
+func (c *MemoryCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
+
+func (c *MemoryCache) remove(e *list.Element) {
+	entry := c.order.Remove(e).(*cacheEntry)
+	delete(c.entries, entry.key)
+	c.bytes -= len(entry.variant.Data)
+}
@@ -0,0 +1,282 @@
+// Package images serves resized and re-encoded variants of local images, so that pages load
+// images at the size they're displayed:
+//
+//	ix, err := images.New(images.Config{FS: os.DirFS("static/img"), Secret: secret})
+//	...
+//	images.Serve(ws, ix) // GET /img/{w}x{h}/{path...}
+//	<img src={ ix.URL("photos/cat.jpg", 800, 0) }>
+//
+// A variant fits in the w×h box keeping the aspect ratio, 0 leaving a side free, and is never
+// larger than the original. The URLs are signed, so that clients can't make the server resize
+// images to any size: unsigned URLs are only served for Config.Sizes.
+//
+// Variants are encoded in the best format the browser accepts among the encoders: JPEG for
+// opaque images and PNG otherwise, and the encoders of Config.Encoders, e.g. WebP or AVIF ones
+// (the standard library has none). They're cached in memory and by the browsers.
+package images
+
+import (
+	"bytes"
+	"cmp"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"path"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	_ "image/gif" // Decoders of the source images
+	_ "image/jpeg"
+	_ "image/png"
+
+	_ "golang.org/x/image/webp"
+)
+
+const (
+	// DefaultPath is where the variants are served when Config.Path is empty.
+	DefaultPath = "/img"
+	// SignatureParam is the query parameter of the signature of the URLs.
+	SignatureParam = "s"
+
+	DefaultQuality         = 80
+	DefaultMaxSize         = 4096
+	DefaultMaxSourcePixels = 50_000_000
+	DefaultCacheBytes      = 64 << 20
+	DefaultMaxAge          = 30 * 24 * time.Hour
+)
+
+var (
+	// ErrInvalidSize is returned for sizes that aren't "{w}x{h}" or exceed Config.MaxSize.
+	ErrInvalidSize = errors.New("invalid image size")
+	// ErrInvalidSignature is returned for unsigned or wrongly signed URLs of sizes not in
+	// Config.Sizes.
+	ErrInvalidSignature = errors.New("invalid image signature")
+	// ErrTooLarge is returned for source images of more than Config.MaxSourcePixels.
+	ErrTooLarge = errors.New("source image too large")
+)
+
+// Encoder writes img in its format at quality, from 1 to 100, when the format is lossy.
+type Encoder func(w io.Writer, img image.Image, quality int) error
+
+// Size is a width and a height, 0 leaving the side free.
+type Size struct {
+	Width, Height int
+}
+
+func (s Size) String() string {
+	return strconv.Itoa(s.Width) + "x" + strconv.Itoa(s.Height)
+}
+
+// Config configures a [Server].
+type Config struct {
+	// FS holds the source images, e.g. os.DirFS("static/img").
+	FS fs.FS
+	// Optional: path the variants are served under. Defaults to [DefaultPath].
+	Path string
+	// Secret signs the URLs. Without it, only Config.Sizes are served.
+	Secret []byte
+	// Sizes are served without signature, e.g. the sizes of the srcsets of the templates.
+	Sizes []Size
+	// Quality of the lossy encoders. Defaults to [DefaultQuality].
+	Quality int
+	// MaxSize is the largest width and height of a variant. Defaults to [DefaultMaxSize].
+	MaxSize int
+	// MaxSourcePixels is the largest number of pixels of a source image, against decompression
+	// bombs. Defaults to [DefaultMaxSourcePixels].
+	MaxSourcePixels int
+	// Encoders are the encoders of other formats by MIME type, e.g. "image/webp" or
+	// "image/avif", preferred to JPEG and PNG by the browsers accepting them.
+	Encoders map[string]Encoder
+	// Optional: caches the variants. Defaults to a [MemoryCache] of [DefaultCacheBytes].
+	Cache Cache
+	// MaxAge is how long browsers cache the variants. Defaults to [DefaultMaxAge].
+	MaxAge time.Duration
+	// MaxConcurrent is the number of images resized at once. Defaults to the number of CPUs.
+	MaxConcurrent int
+	// Optional: logs the failures to serve an image. Defaults to slog.Default().
+	Logger *slog.Logger
+}
+
+// Server serves the image variants. It's safe for concurrent use.
+type Server struct {
+	cfg     Config
+	formats []string // MIME types of Config.Encoders, the preferred first
+	sem     chan struct{}
+
+	dimensions sync.Map // Size of the source images, by name, modification time and size
+}
+
+// preferred lists the formats of the encoders from the best compression.
+var preferred = []string{"image/avif", "image/webp"}
+
+// New returns the Server of cfg.
+func New(cfg Config) (*Server, error) {
+	if cfg.FS == nil {
+		return nil, errors.New("images: Config.FS is required")
+	}
+	if len(cfg.Secret) == 0 && len(cfg.Sizes) == 0 {
+		return nil, errors.New("images: Config.Secret or Sizes is required")
+	}
+	cfg.Path = "/" + strings.Trim(cmp.Or(cfg.Path, DefaultPath), "/")
+	cfg.Quality = cmp.Or(cfg.Quality, DefaultQuality)
+	cfg.MaxSize = cmp.Or(cfg.MaxSize, DefaultMaxSize)
+	cfg.MaxSourcePixels = cmp.Or(cfg.MaxSourcePixels, DefaultMaxSourcePixels)
+	cfg.MaxAge = cmp.Or(cfg.MaxAge, DefaultMaxAge)
+	cfg.MaxConcurrent = cmp.Or(cfg.MaxConcurrent, runtime.NumCPU())
+	cfg.Logger = cmp.Or(cfg.Logger, slog.Default())
+	if cfg.Cache == nil {
+		cfg.Cache = NewMemoryCache(DefaultCacheBytes)
+	}
+
+	s := &Server{cfg: cfg, sem: make(chan struct{}, cfg.MaxConcurrent)}
+	for mime := range cfg.Encoders {
+		s.formats = append(s.formats, mime)
+	}
+	slices.SortFunc(s.formats, func(a, b string) int {
+		return cmp.Or(cmp.Compare(rank(a), rank(b)), strings.Compare(a, b))
+	})
+	return s, nil
+}
+
+func rank(mime string) int {
+	if i := slices.Index(preferred, mime); i >= 0 {
+		return i
+	}
+	return len(preferred)
+}
+
+// Path returns the path the variants are served under, e.g. "/img".
+func (s *Server) Path() string {
+	return s.cfg.Path
+}
+
+// URL returns the signed URL of the variant of the image at name in Config.FS fitting in
+// width×height, e.g. "/img/800x0/photos/cat.jpg?s=...". Without Config.Secret, the URL isn't
+// signed.
+func (s *Server) URL(name string, width, height int) string {
+	size := Size{Width: width, Height: height}.String()
+	name = strings.TrimPrefix(name, "/")
+	u := s.cfg.Path + "/" + size + "/" + escapePath(name)
+	if len(s.cfg.Secret) > 0 {
+		u += "?" + SignatureParam + "=" + s.sign(size, name)
+	}
+	return u
+}
+
+// escapePath escapes the segments of name, keeping its slashes.
+func escapePath(name string) string {
+	segments := strings.Split(name, "/")
+	for i, seg := range segments {
+		segments[i] = url.PathEscape(seg)
+	}
+	return strings.Join(segments, "/")
+}
+
+func (s *Server) sign(size, name string) string {
+	mac := hmac.New(sha256.New, s.cfg.Secret)
+	mac.Write([]byte("gotth-images\n" + size + "\n" + name))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)[:16])
+}
+
+// ServeHTTP serves the variant of the "size" and "path" path values, e.g. the pattern
+// "GET /img/{size}/{path...}".
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	sizeValue, name := r.PathValue("size"), r.PathValue("path")
+	size, err := s.parseSize(sizeValue)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !s.allowed(size, sizeValue, name, r.URL.Query().Get(SignatureParam)) {
+		http.Error(w, ErrInvalidSignature.Error(), http.StatusForbidden)
+		return
+	}
+	if !fs.ValidPath(name) || path.Clean(name) != name {
+		http.NotFound(w, r)
+		return
+	}
+
+	variant, err := s.variant(name, size, s.negotiate(r))
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		http.NotFound(w, r)
+		return
+	case errors.Is(err, ErrTooLarge), errors.Is(err, image.ErrFormat):
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	case err != nil:
+		s.cfg.Logger.ErrorContext(r.Context(), "failed to serve image", "path", name, "size", sizeValue, "error", err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", variant.ContentType)
+	w.Header().Set("Cache-Control", "public, max-age="+strconv.Itoa(int(s.cfg.MaxAge.Seconds())))
+	w.Header().Set("ETag", variant.ETag)
+	w.Header().Add("Vary", "Accept")
+	http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(variant.Data))
+}
+
+func (s *Server) parseSize(v string) (Size, error) {
+	ws, hs, ok := strings.Cut(v, "x")
+	width, werr := strconv.Atoi(ws)
+	height, herr := strconv.Atoi(hs)
+	if !ok || werr != nil || herr != nil || width < 0 || height < 0 || width+height == 0 ||
+		width > s.cfg.MaxSize || height > s.cfg.MaxSize {
+		return Size{}, fmt.Errorf("%w %q", ErrInvalidSize, v)
+	}
+	return Size{Width: width, Height: height}, nil
+}
+
+// allowed reports whether size is in Config.Sizes or sig signs the URL. The size is checked as
+// written, so that "0800x0" doesn't pass for "800x0".
+func (s *Server) allowed(size Size, sizeValue, name, sig string) bool {
+	if slices.Contains(s.cfg.Sizes, size) {
+		return true
+	}
+	return len(s.cfg.Secret) > 0 && sizeValue == size.String() && hmac.Equal([]byte(sig), []byte(s.sign(sizeValue, name)))
+}
+
+// negotiate returns the preferred format of Config.Encoders accepted by r, or "" for JPEG or
+// PNG.
+func (s *Server) negotiate(r *http.Request) string {
+	accept := r.Header.Get("Accept")
+	for _, mime := range s.formats {
+		if accepts(accept, mime) {
+			return mime
+		}
+	}
+	return ""
+}
+
+// accepts reports whether the Accept header lists mime without q=0. Wildcards don't count: the
+// browsers send "*/*" while they can't display every format.
+func accepts(header, mime string) bool {
+	for _, part := range strings.Split(header, ",") {
+		media, params, _ := strings.Cut(part, ";")
+		if !strings.EqualFold(strings.TrimSpace(media), mime) {
+			continue
+		}
+		for _, p := range strings.Split(params, ";") {
+			if k, v, _ := strings.Cut(strings.TrimSpace(p), "="); k == "q" {
+				if q, err := strconv.ParseFloat(v, 64); err == nil && q == 0 {
+					return false
+				}
+			}
+		}
+		return true
+	}
+	return false
+}
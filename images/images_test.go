@@ -0,0 +1,171 @@
+package images_test
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+
+	"github.com/ancalabrese/gotth/images"
+)
+
+func encodePNG(t *testing.T, w, h int, c color.Color) []byte {
+	t.Helper()
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for x := range w {
+		for y := range h {
+			img.Set(x, y, c)
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func newServer(t *testing.T, cfg images.Config) *images.Server {
+	t.Helper()
+	cfg.FS = fstest.MapFS{
+		"photos/cat.png": {Data: encodePNG(t, 400, 200, color.NRGBA{R: 200, A: 255})},
+		"logo.png":       {Data: encodePNG(t, 100, 100, color.NRGBA{B: 200, A: 128})},
+		"notes.txt":      {Data: []byte("not an image")},
+	}
+	s, err := images.New(cfg)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	return s
+}
+
+func serve(s *images.Server, target, accept string) *httptest.ResponseRecorder {
+	mux := http.NewServeMux()
+	mux.Handle("GET "+s.Path()+"/{size}/{path...}", s)
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	req.Header.Set("Accept", accept)
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestServer(t *testing.T) {
+	s := newServer(t, images.Config{Secret: []byte("secret"), Sizes: []images.Size{{Width: 50}}})
+	tests := []struct {
+		name       string
+		target     string
+		wantStatus int
+		wantType   string
+		wantSize   image.Point
+	}{
+		{name: "Signed width", target: s.URL("photos/cat.png", 100, 0), wantStatus: http.StatusOK, wantType: "image/jpeg", wantSize: image.Pt(100, 50)},
+		{name: "Signed box", target: s.URL("/photos/cat.png", 100, 100), wantStatus: http.StatusOK, wantType: "image/jpeg", wantSize: image.Pt(100, 50)},
+		{name: "Never upscaled", target: s.URL("photos/cat.png", 0, 1000), wantStatus: http.StatusOK, wantType: "image/jpeg", wantSize: image.Pt(400, 200)},
+		{name: "Transparent stays PNG", target: s.URL("logo.png", 10, 10), wantStatus: http.StatusOK, wantType: "image/png", wantSize: image.Pt(10, 10)},
+		{name: "Allowed size unsigned", target: "/img/50x0/photos/cat.png", wantStatus: http.StatusOK, wantType: "image/jpeg", wantSize: image.Pt(50, 25)},
+		{name: "Unsigned", target: "/img/100x0/photos/cat.png", wantStatus: http.StatusForbidden},
+		{name: "Signature of another size", target: "/img/101x0/photos/cat.png?s=" + s.URL("photos/cat.png", 100, 0)[len("/img/100x0/photos/cat.png?s="):], wantStatus: http.StatusForbidden},
+		{name: "Invalid size", target: "/img/axb/photos/cat.png", wantStatus: http.StatusBadRequest},
+		{name: "Too large", target: s.URL("photos/cat.png", 5000, 0), wantStatus: http.StatusBadRequest},
+		{name: "Missing", target: s.URL("missing.png", 10, 0), wantStatus: http.StatusNotFound},
+		{name: "Directory", target: s.URL("photos", 10, 0), wantStatus: http.StatusNotFound},
+		{name: "Not an image", target: s.URL("notes.txt", 10, 0), wantStatus: http.StatusUnprocessableEntity},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(s, tt.target, "image/webp,*/*")
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			if ct := rec.Header().Get("Content-Type"); ct != tt.wantType {
+				t.Errorf("Content-Type = %q, want %q", ct, tt.wantType)
+			}
+			cfg, _, err := image.DecodeConfig(rec.Body)
+			if err != nil || image.Pt(cfg.Width, cfg.Height) != tt.wantSize {
+				t.Errorf("image = %dx%d, %v, want %v", cfg.Width, cfg.Height, err, tt.wantSize)
+			}
+			if rec.Header().Get("ETag") == "" || rec.Header().Get("Cache-Control") != "public, max-age=2592000" {
+				t.Errorf("headers = %v", rec.Header())
+			}
+		})
+	}
+}
+
+func TestServer_Encoders(t *testing.T) {
+	cache := images.NewMemoryCache(1 << 20)
+	fake := func(format string) images.Encoder {
+		return func(w io.Writer, img image.Image, quality int) error {
+			_, err := io.WriteString(w, format)
+			return err
+		}
+	}
+	s := newServer(t, images.Config{
+		Secret:   []byte("secret"),
+		Cache:    cache,
+		Encoders: map[string]images.Encoder{"image/webp": fake("webp"), "image/avif": fake("avif")},
+	})
+	target := s.URL("photos/cat.png", 100, 0)
+	for accept, want := range map[string]string{
+		"image/avif,image/webp,*/*":         "avif",
+		"image/avif;q=0,image/webp,*/*":     "webp",
+		"text/html,*/*;q=0.8":               "",
+		"image/jpeg, image/webp;q=0.5, */*": "webp",
+	} {
+		rec := serve(s, target, accept)
+		if want == "" {
+			if rec.Header().Get("Content-Type") != "image/jpeg" {
+				t.Errorf("Accept %q = %s, want image/jpeg", accept, rec.Header().Get("Content-Type"))
+			}
+			continue
+		}
+		if rec.Body.String() != want || rec.Header().Get("Content-Type") != "image/"+want || rec.Header().Get("Vary") != "Accept" {
+			t.Errorf("Accept %q = %q, headers %v, want %s", accept, rec.Body.String(), rec.Header(), want)
+		}
+	}
+	if cache.Len() != 3 {
+		t.Errorf("cache = %d variants, want 3", cache.Len())
+	}
+
+	rec := serve(s, target, "image/webp")
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	req.Header.Set("Accept", "image/webp")
+	req.Header.Set("If-None-Match", rec.Header().Get("ETag"))
+	mux := http.NewServeMux()
+	mux.Handle("GET /img/{size}/{path...}", s)
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotModified {
+		t.Errorf("If-None-Match status = %d, want %d", rec.Code, http.StatusNotModified)
+	}
+}
+
+func TestServer_MaxSourcePixels(t *testing.T) {
+	s := newServer(t, images.Config{Sizes: []images.Size{{Width: 10}}, MaxSourcePixels: 1000})
+	if rec := serve(s, "/img/10x0/photos/cat.png", ""); rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusUnprocessableEntity)
+	}
+	if _, err := images.New(images.Config{FS: fstest.MapFS{}}); err == nil {
+		t.Error("New() without Secret nor Sizes error = nil")
+	}
+}
+
+func TestMemoryCache(t *testing.T) {
+	c := images.NewMemoryCache(10)
+	c.Set("a", &images.Variant{Data: make([]byte, 4)})
+	c.Set("b", &images.Variant{Data: make([]byte, 4)})
+	c.Get("a")
+	c.Set("c", &images.Variant{Data: make([]byte, 4)}) // Evicts b, the least recently used
+	c.Set("huge", &images.Variant{Data: make([]byte, 11)})
+	for key, want := range map[string]bool{"a": true, "b": false, "c": true, "huge": false} {
+		if _, ok := c.Get(key); ok != want {
+			t.Errorf("Get(%s) = %v, want %v", key, ok, want)
+		}
+	}
+}
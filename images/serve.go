@@ -0,0 +1,12 @@
+package images
+
+import "github.com/ancalabrese/gotth"
+
+// Serve serves the image variants of s on ws at its path, e.g. /img/{w}x{h}/{path...}.
+func Serve(ws *gotth.WebServer, s *Server) {
+	if s == nil {
+		ws.InvalidRoute("", "images.Serve needs an images server")
+		return
+	}
+	ws.Handle("GET "+s.Path()+"/{size}/{path...}", s)
+}
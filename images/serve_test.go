@@ -0,0 +1,40 @@
+package images_test
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/ancalabrese/gotth"
+	"github.com/ancalabrese/gotth/images"
+)
+
+func TestServe(t *testing.T) {
+	var src bytes.Buffer
+	png.Encode(&src, image.NewGray(image.Rect(0, 0, 64, 32)))
+	s, err := images.New(images.Config{FS: fstest.MapFS{"a.png": {Data: src.Bytes()}}, Secret: []byte("secret")})
+	if err != nil {
+		t.Fatalf("images.New() error = %v", err)
+	}
+	ws, err := gotth.New(gotth.WebServerConfig{}, nil)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	images.Serve(ws, s)
+	images.Serve(ws, nil)
+	if err := ws.Err(); err == nil || !strings.Contains(err.Error(), "images.Serve needs an images server") {
+		t.Errorf("Err() = %v, want the nil server", err)
+	}
+
+	rr := httptest.NewRecorder()
+	ws.Handler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, s.URL("a.png", 16, 0), nil))
+	cfg, _, err := image.DecodeConfig(rr.Body)
+	if rr.Code != http.StatusOK || err != nil || cfg.Width != 16 || cfg.Height != 8 {
+		t.Errorf("variant = %d, %dx%d, %v", rr.Code, cfg.Width, cfg.Height, err)
+	}
+}
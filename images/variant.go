@@ -0,0 +1,145 @@
+package images
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"io/fs"
+	"strconv"
+	"strings"
+
+	xdraw "golang.org/x/image/draw"
+)
+
+// Variant is an encoded image variant.
+type Variant struct {
+	ContentType string
+	ETag        string
+	Data        []byte
+}
+
+// variant returns the variant of the image at name fitting in size, encoded as format, or as
+// JPEG or PNG when format is "", from the cache when it's there.
+func (s *Server) variant(name string, size Size, format string) (*Variant, error) {
+	info, err := fs.Stat(s.cfg.FS, name)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return nil, fmt.Errorf("%s is a directory. err %w", name, fs.ErrNotExist)
+	}
+	// The modification time and size of the source invalidate the variants of a changed file.
+	key := fmt.Sprintf("%s\n%s\n%s\n%d\n%d", name, size, format, info.ModTime().UnixNano(), info.Size())
+	if v, ok := s.cfg.Cache.Get(key); ok {
+		return v, nil
+	}
+
+	s.sem <- struct{}{}
+	defer func() { <-s.sem }()
+	v, err := s.render(name, size, format)
+	if err != nil {
+		return nil, err
+	}
+	s.cfg.Cache.Set(key, v)
+	return v, nil
+}
+
+// Dimensions returns the width and height of the image at name in Config.FS, e.g. for the
+// width and height attributes of the img elements.
+func (s *Server) Dimensions(name string) (width, height int, err error) {
+	name = strings.TrimPrefix(name, "/")
+	info, err := fs.Stat(s.cfg.FS, name)
+	if err != nil {
+		return 0, 0, err
+	}
+	key := fmt.Sprintf("%s\n%d\n%d", name, info.ModTime().UnixNano(), info.Size())
+	if size, ok := s.dimensions.Load(key); ok {
+		return size.(Size).Width, size.(Size).Height, nil
+	}
+
+	f, err := s.cfg.FS.Open(name)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer f.Close()
+	imgCfg, _, err := image.DecodeConfig(f)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to decode %s. err %w", name, err)
+	}
+	s.dimensions.Store(key, Size{Width: imgCfg.Width, Height: imgCfg.Height})
+	return imgCfg.Width, imgCfg.Height, nil
+}
+
+func (s *Server) render(name string, size Size, format string) (*Variant, error) {
+	src, err := fs.ReadFile(s.cfg.FS, name)
+	if err != nil {
+		return nil, err
+	}
+	imgCfg, _, err := image.DecodeConfig(bytes.NewReader(src))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s. err %w", name, err)
+	}
+	if imgCfg.Width*imgCfg.Height > s.cfg.MaxSourcePixels {
+		return nil, fmt.Errorf("%w: %s is %dx%d", ErrTooLarge, name, imgCfg.Width, imgCfg.Height)
+	}
+	img, _, err := image.Decode(bytes.NewReader(src))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s. err %w", name, err)
+	}
+	img = resize(img, size)
+
+	var out bytes.Buffer
+	switch {
+	case format != "":
+		err = s.cfg.Encoders[format](&out, img, s.cfg.Quality)
+	case opaque(img):
+		format = "image/jpeg"
+		err = jpeg.Encode(&out, img, &jpeg.Options{Quality: s.cfg.Quality})
+	default:
+		format = "image/png"
+		err = png.Encode(&out, img)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s as %s. err %w", name, format, err)
+	}
+	sum := sha256.Sum256(out.Bytes())
+	return &Variant{
+		ContentType: format,
+		ETag:        strconv.Quote(base64.RawURLEncoding.EncodeToString(sum[:12])),
+		Data:        out.Bytes(),
+	}, nil
+}
+
+// resize scales img down to fit in size, keeping its aspect ratio. Smaller images are kept as
+// they are.
+func resize(img image.Image, size Size) image.Image {
+	b := img.Bounds()
+	scale := 1.0
+	if size.Width > 0 {
+		scale = min(scale, float64(size.Width)/float64(b.Dx()))
+	}
+	if size.Height > 0 {
+		scale = min(scale, float64(size.Height)/float64(b.Dy()))
+	}
+	if scale >= 1 {
+		return img
+	}
+	width := max(1, int(float64(b.Dx())*scale+0.5))
+	height := max(1, int(float64(b.Dy())*scale+0.5))
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	xdraw.CatmullRom.Scale(dst, dst.Bounds(), img, b, draw.Src, nil)
+	return dst
+}
+
+// opaque reports whether img has no transparent pixel, to be encoded as JPEG.
+func opaque(img image.Image) bool {
+	if o, ok := img.(interface{ Opaque() bool }); ok {
+		return o.Opaque()
+	}
+	return false
+}
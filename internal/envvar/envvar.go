@@ -0,0 +1,72 @@
+// Package envvar parses the environment variables configuring the server, so that
+// gotth.ConfigFromEnv and the config package accept the same values.
+package envvar
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Set parses value into dst, a *string, *bool, *int, *time.Duration or *[]string. Lists are
+// comma separated.
+func Set(dst any, value string) error {
+	switch dst := dst.(type) {
+	case *string:
+		*dst = value
+	case *bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		*dst = b
+	case *int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		*dst = n
+	case *time.Duration:
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		*dst = d
+	case *[]string:
+		*dst = List(value)
+	default:
+		return fmt.Errorf("unsupported setting type %T", dst)
+	}
+	return nil
+}
+
+// List splits a comma separated list, dropping the empty items.
+func List(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// Mount is a static asset directory and the URL path it's served under.
+type Mount struct {
+	Path string
+	Dir  string
+}
+
+// Mounts parses a comma separated list of path=dir mounts, e.g. "/static=./static/dist".
+func Mounts(value string) ([]Mount, error) {
+	var mounts []Mount
+	for _, item := range List(value) {
+		path, dir, ok := strings.Cut(item, "=")
+		if !ok || path == "" || dir == "" {
+			return nil, fmt.Errorf("%q is not path=dir", item)
+		}
+		mounts = append(mounts, Mount{Path: path, Dir: dir})
+	}
+	return mounts, nil
+}
@@ -0,0 +1,71 @@
+package gotth
+
+import (
+	"net"
+	"net/http"
+	"strings"
+)
+
+// acmeChallengePath is where ACME clients publish the HTTP-01 challenges.
+const acmeChallengePath = "/.well-known/acme-challenge/"
+
+// useTLS reports whether the server serves HTTPS: with the certificate files, or with the
+// certificates of its TLSConfig (e.g. from autocert).
+func (ws *WebServer) useTLS() bool {
+	if ws.config.TLSCertFile != "" {
+		return true
+	}
+	c := ws.httpServer.TLSConfig
+	return c != nil && (len(c.Certificates) > 0 || c.GetCertificate != nil || c.GetConfigForClient != nil)
+}
+
+// newRedirectServer returns the server of the HTTP listener, redirecting to the HTTPS listener
+// at httpsAddr, with the timeouts of the main server.
+func (ws *WebServer) newRedirectServer(httpsAddr net.Addr) *http.Server {
+	_, port, _ := net.SplitHostPort(httpsAddr.String())
+	var handler http.Handler = httpsRedirect(port)
+	if ws.config.ACMEChallengeDir != "" {
+		mux := http.NewServeMux()
+		mux.Handle(acmeChallengePath, http.StripPrefix(acmeChallengePath, http.FileServer(http.Dir(ws.config.ACMEChallengeDir))))
+		mux.Handle("/", handler)
+		handler = mux
+	}
+	if ws.config.HTTPHandler != nil {
+		handler = ws.config.HTTPHandler(handler)
+	}
+
+	return &http.Server{
+		Addr:              ws.config.HTTPAddr,
+		Handler:           handler,
+		ReadTimeout:       ws.httpServer.ReadTimeout,
+		ReadHeaderTimeout: ws.httpServer.ReadHeaderTimeout,
+		WriteTimeout:      ws.httpServer.WriteTimeout,
+		IdleTimeout:       ws.httpServer.IdleTimeout,
+		MaxHeaderBytes:    ws.httpServer.MaxHeaderBytes,
+		ErrorLog:          ws.httpServer.ErrorLog,
+	}
+}
+
+// httpsRedirect permanently redirects the requests to the same URL over HTTPS, on port unless
+// it's the default one. GET and HEAD requests get a 301, the others a 308 so that the method
+// and body are kept.
+func httpsRedirect(port string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		host = strings.Trim(host, "[]")
+		if port != "" && port != "443" {
+			host = net.JoinHostPort(host, port)
+		} else if strings.Contains(host, ":") {
+			host = "[" + host + "]" // IPv6
+		}
+
+		status := http.StatusMovedPermanently
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			status = http.StatusPermanentRedirect
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), status)
+	})
+}
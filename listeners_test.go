@@ -0,0 +1,155 @@
+package gotth
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWebServer_HTTPRedirectHandler(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "token"), []byte("token.key"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var wrapped bool
+	ws, err := New(WebServerConfig{
+		HTTPAddr:         ":80",
+		ACMEChallengeDir: dir,
+		HTTPHandler: func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				wrapped = true
+				next.ServeHTTP(w, r)
+			})
+		},
+	}, nil)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		httpsPort    int
+		method       string
+		target       string
+		wantStatus   int
+		wantLocation string
+		wantBody     string
+	}{
+		{name: "GET redirected", httpsPort: 443, method: http.MethodGet, target: "http://example.com/a?b=c", wantStatus: http.StatusMovedPermanently, wantLocation: "https://example.com/a?b=c"},
+		{name: "POST keeps method", httpsPort: 443, method: http.MethodPost, target: "http://example.com:80/form", wantStatus: http.StatusPermanentRedirect, wantLocation: "https://example.com/form"},
+		{name: "Non default port", httpsPort: 8443, method: http.MethodGet, target: "http://example.com:8080/", wantStatus: http.StatusMovedPermanently, wantLocation: "https://example.com:8443/"},
+		{name: "IPv6", httpsPort: 443, method: http.MethodGet, target: "http://[::1]:80/", wantStatus: http.StatusMovedPermanently, wantLocation: "https://[::1]/"},
+		{name: "ACME challenge served", httpsPort: 443, method: http.MethodGet, target: "http://example.com/.well-known/acme-challenge/token", wantStatus: http.StatusOK, wantBody: "token.key"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wrapped = false
+			srv := ws.newRedirectServer(&net.TCPAddr{IP: net.IPv4zero, Port: tt.httpsPort})
+			rr := httptest.NewRecorder()
+			srv.Handler.ServeHTTP(rr, httptest.NewRequest(tt.method, tt.target, nil))
+
+			if rr.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rr.Code, tt.wantStatus)
+			}
+			if got := rr.Header().Get("Location"); got != tt.wantLocation {
+				t.Errorf("Location = %q, want %q", got, tt.wantLocation)
+			}
+			if tt.wantBody != "" && rr.Body.String() != tt.wantBody {
+				t.Errorf("body = %q, want %q", rr.Body.String(), tt.wantBody)
+			}
+			if !wrapped {
+				t.Error("HTTPHandler not applied")
+			}
+		})
+	}
+}
+
+func TestWebServer_StartHTTPAndHTTPS(t *testing.T) {
+	// The certificate of a test server, trusted by its client.
+	certs := httptest.NewTLSServer(http.NotFoundHandler())
+	defer certs.Close()
+	client := certs.Client()
+	client.CheckRedirect = func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
+
+	httpsAddr, httpAddr := freeAddr(t), freeAddr(t)
+	ws, err := New(WebServerConfig{HTTPAddr: httpAddr}, &http.Server{
+		Addr:      httpsAddr,
+		TLSConfig: &tls.Config{Certificates: certs.TLS.Certificates},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	ws.Handle("GET /", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { io.WriteString(w, "secure") }))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- ws.Start(ctx) }()
+
+	var resp *http.Response
+	for range 50 {
+		if resp, err = client.Get("https://" + httpsAddr + "/"); err == nil {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatalf("GET https error = %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "secure" {
+		t.Errorf("https body = %q, want secure", body)
+	}
+
+	resp, err = client.Get("http://" + httpAddr + "/page")
+	if err != nil {
+		t.Fatalf("GET http error = %v", err)
+	}
+	resp.Body.Close()
+	_, port, _ := net.SplitHostPort(httpsAddr)
+	if want := "https://127.0.0.1:" + port + "/page"; resp.StatusCode != http.StatusMovedPermanently || resp.Header.Get("Location") != want {
+		t.Errorf("http response = %d %q, want 301 %q", resp.StatusCode, resp.Header.Get("Location"), want)
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Start() error = %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Start() didn't return after cancel")
+	}
+	if _, err := client.Get("http://" + httpAddr + "/"); err == nil {
+		t.Error("http listener still serving after shutdown")
+	}
+}
+
+// freeAddr returns a local address with a free port.
+func freeAddr(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	return ln.Addr().String()
+}
+
+func TestWebServer_HTTPAddrWithoutTLS(t *testing.T) {
+	ws, err := New(WebServerConfig{HTTPAddr: "127.0.0.1:0"}, &http.Server{Addr: "127.0.0.1:0"})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if err := ws.Start(context.Background()); err == nil {
+		t.Error("Start() with HTTPAddr and without TLS succeeded")
+	}
+}
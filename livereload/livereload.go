@@ -0,0 +1,138 @@
+// Package livereload reloads the pages open in the browser when the server restarts or a
+// watched file changes, for an instant edit-refresh loop in development. It's enabled by
+// WebServerConfig.DevMode:
+//
+//	ws, _ := gotth.New(gotth.WebServerConfig{
+//		DevMode:  os.Getenv("GOTTH_DEV") != "",
+//		DevWatch: []string{"static/dist"},
+//	}, nil)
+//
+// Pages keep a Server-Sent Events connection to [DefaultPath]. Each server process has its own
+// ID, so a browser reconnecting after a restart (e.g., by air or templ generate --watch) sees a
+// new ID and reloads.
+package livereload
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/a-h/templ"
+	"github.com/ancalabrese/gotth/devwatch"
+	"github.com/ancalabrese/gotth/sse"
+)
+
+const (
+	// DefaultPath is where the WebServer serves the reload events in DevMode.
+	DefaultPath = "/_gotth/livereload"
+	// RetryDelay is how long browsers wait before reconnecting, e.g. while the server restarts.
+	RetryDelay = 500 * time.Millisecond
+
+	helloEvent  = "hello"
+	reloadEvent = "reload"
+)
+
+// Reloader notifies the connected browsers to reload.
+type Reloader struct {
+	id   string
+	mu   sync.Mutex
+	subs map[chan struct{}]struct{}
+}
+
+// New returns a Reloader with a new process ID.
+func New() *Reloader {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return &Reloader{id: hex.EncodeToString(b), subs: map[chan struct{}]struct{}{}}
+}
+
+// Reload tells every connected browser to reload the page.
+func (rl *Reloader) Reload() {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	for ch := range rl.subs {
+		select {
+		case ch <- struct{}{}:
+		default: // A reload is already pending.
+		}
+	}
+}
+
+// Source returns the sse.Source of the reload events: the ID of the process when the browser
+// connects, then a reload event on every [Reloader.Reload].
+func (rl *Reloader) Source() sse.Source {
+	return func(ctx context.Context, r *http.Request, lastEventID string, send sse.SendFunc) error {
+		ch := make(chan struct{}, 1)
+		rl.mu.Lock()
+		rl.subs[ch] = struct{}{}
+		rl.mu.Unlock()
+		defer func() {
+			rl.mu.Lock()
+			delete(rl.subs, ch)
+			rl.mu.Unlock()
+		}()
+
+		if err := send(sse.Event{Name: helloEvent, Data: rl.id}); err != nil {
+			return err
+		}
+		for {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-ch:
+				if err := send(sse.Event{Name: reloadEvent, Data: rl.id}); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
+
+// Handler returns the handler of the reload events.
+func (rl *Reloader) Handler() http.Handler {
+	return sse.Handler(rl.Source(), sse.WithRetry(RetryDelay))
+}
+
+// Watch calls Reload when a file in dirs is added, removed or modified, until ctx is done.
+// See devwatch.Run to also run generators on change.
+func (rl *Reloader) Watch(ctx context.Context, dirs []string, interval time.Duration) {
+	devwatch.Run(ctx, devwatch.Config{Dirs: dirs, Interval: interval, OnChange: func([]string) { rl.Reload() }})
+}
+
+type livereloadCtxKey string
+
+const pathKey livereloadCtxKey = "livereload-path"
+
+// Middleware enables the reload [Script] in the pages served by next, connecting to path.
+func Middleware(path string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), pathKey, path)))
+		})
+	}
+}
+
+// Script renders the script connecting the page to the reload events. It renders nothing
+// unless [Middleware] is in use, so the layout can always include it.
+func Script() templ.Component {
+	return templ.ComponentFunc(func(ctx context.Context, w io.Writer) error {
+		path, ok := ctx.Value(pathKey).(string)
+		if !ok {
+			return nil
+		}
+		// encoding/json escapes "<" and ">", so the path can't close the script element.
+		p, err := json.Marshal(path)
+		if err != nil {
+			return err
+		}
+		_, err = io.WriteString(w, `<script>(function(){var id,es=new EventSource(`+string(p)+`);`+
+			`es.addEventListener("hello",function(e){if(id&&id!==e.data)location.reload();id=e.data});`+
+			`es.addEventListener("reload",function(){location.reload()})})();</script>`)
+		return err
+	})
+}
@@ -0,0 +1,86 @@
+package livereload_test
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ancalabrese/gotth/livereload"
+)
+
+// readEvent reads the lines of the next event, without the blank separator.
+func readEvent(t *testing.T, r *bufio.Reader) []string {
+	t.Helper()
+	var lines []string
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatalf("failed to read event. err %v", err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+		if line == "" {
+			return lines
+		}
+		lines = append(lines, line)
+	}
+}
+
+func TestReloader_Watch(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "style.css"), []byte("body{}"), 0o644)
+
+	rl := livereload.New()
+	srv := httptest.NewServer(rl.Handler())
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go rl.Watch(ctx, []string{dir}, 10*time.Millisecond)
+
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body := bufio.NewReader(resp.Body)
+
+	if got := readEvent(t, body); len(got) != 1 || got[0] != "retry: 500" {
+		t.Fatalf("first event = %v, want the retry delay", got)
+	}
+	hello := readEvent(t, body)
+	if len(hello) != 2 || hello[0] != "event: hello" {
+		t.Fatalf("hello event = %v", hello)
+	}
+
+	// Wait for the initial snapshot before changing the file.
+	time.Sleep(50 * time.Millisecond)
+	os.WriteFile(filepath.Join(dir, "style.css"), []byte("body{color:red}"), 0o644)
+
+	reload := readEvent(t, body)
+	if len(reload) != 2 || reload[0] != "event: reload" || reload[1] != hello[1] {
+		t.Errorf("reload event = %v, want the reload with the process ID %v", reload, hello[1])
+	}
+}
+
+func TestScript(t *testing.T) {
+	var without, with strings.Builder
+	livereload.Script().Render(context.Background(), &without)
+	if without.Len() != 0 {
+		t.Errorf("script rendered without the middleware: %s", without.String())
+	}
+
+	h := livereload.Middleware("/_reload</script>")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		livereload.Script().Render(r.Context(), &with)
+	}))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if !strings.Contains(with.String(), `new EventSource("/_reload\u003c/script\u003e")`) {
+		t.Errorf("unexpected script: %s", with.String())
+	}
+}
@@ -0,0 +1,95 @@
+package gotth
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/a-h/templ"
+	"github.com/ancalabrese/gotth/i18n"
+	"github.com/ancalabrese/gotth/middlewares"
+	"github.com/ancalabrese/gotth/views/components/head"
+	"golang.org/x/text/language"
+)
+
+// ServeLocalized adds a page served under the prefix of each locale of
+// [WebServerConfig.Locales], like ServeContent: "GET /about" serves /en/about and /de/about,
+// and redirects /about to the locale negotiated from the Accept-Language header. The content
+// provider reads the locale with i18n.FromContext. The head of the page gets the language, the
+// canonical URL of the locale and the hreflang links of the translations, with the bare path as
+// "x-default".
+func (ws *WebServer) ServeLocalized(pattern string, contentProvider ContentProviderFunc, mws ...func(http.Handler) http.Handler) {
+	method, path := "", strings.TrimSpace(pattern)
+	if m, p, ok := strings.Cut(path, " "); ok {
+		method, path = m+" ", strings.TrimSpace(p)
+	}
+	if ws.locales == nil || contentProvider == nil || !strings.HasPrefix(path, "/") {
+		ws.registrationFailed(fmt.Errorf("%w %q registered at %s: ServeLocalized needs WebServerConfig.Locales, a pattern with a path and a ContentProviderFunc",
+			ErrInvalidRoute, pattern, callerSource()))
+		return
+	}
+
+	localized := func(r *http.Request) (head.HeadViewModel, templ.Component, error) {
+		headVM, content, err := contentProvider(r)
+		if err == nil {
+			ws.localizeHead(&headVM, r)
+		}
+		return headVM, content, err
+	}
+	for _, locale := range ws.locales.All() {
+		localePattern := method + ws.locales.Path(locale, path)
+		ws.handle(localePattern, ws.pageHandler(localePattern, localized, mws), RouteKindPage, len(mws))
+	}
+	ws.handle(pattern, http.HandlerFunc(ws.redirectToLocale), RouteKindHandler, 0)
+}
+
+// redirectToLocale redirects the request to its path under the locale of the visitor.
+func (ws *WebServer) redirectToLocale(w http.ResponseWriter, r *http.Request) {
+	locale := ws.locales.Negotiate(r.Header.Get("Accept-Language"))
+	target := url.URL{Path: ws.locales.Path(locale, r.URL.Path), RawQuery: r.URL.RawQuery}
+	middlewares.AddVary(w.Header(), middlewares.VaryAcceptLanguage)
+	http.Redirect(w, r, target.String(), http.StatusFound)
+}
+
+// localizeHead sets the language of vm to the locale of r, moves its canonical URL under the
+// locale prefix and adds the hreflang links of the translations. The canonical URL may name
+// either the bare or a localized path. The links are relative without a canonical URL.
+func (ws *WebServer) localizeHead(vm *head.HeadViewModel, r *http.Request) {
+	locale := i18n.FromContext(r.Context())
+	if locale == "" {
+		return
+	}
+	if vm.Lang == "" {
+		vm.Lang = locale
+	}
+	// og:locale is "en_US" by default: use the likely region of the locale instead.
+	if tag, err := language.Parse(locale); err == nil && vm.OgLocale == "en_US" {
+		base, _ := tag.Base()
+		region, _ := tag.Region()
+		vm.OgLocale = base.String() + "_" + region.String()
+	}
+
+	canonical, err := url.Parse(vm.Metadata.URL)
+	if err != nil || vm.Metadata.URL == "" {
+		canonical = &url.URL{Path: r.URL.Path}
+	}
+	_, bare, _ := ws.locales.Split(canonical.Path)
+	at := func(path string) string {
+		u := *canonical
+		u.Path, u.RawPath = path, ""
+		return u.String()
+	}
+
+	if vm.Metadata.URL != "" {
+		if vm.Metadata.OgURL == vm.Metadata.URL {
+			vm.Metadata.OgURL = at(ws.locales.Path(locale, bare))
+		}
+		vm.Metadata.URL = at(ws.locales.Path(locale, bare))
+	}
+	vm.Alternates = nil
+	for _, l := range ws.locales.All() {
+		vm.Alternates = append(vm.Alternates, head.AlternateLink{Hreflang: l, Href: at(ws.locales.Path(l, bare))})
+	}
+	vm.Alternates = append(vm.Alternates, head.AlternateLink{Hreflang: "x-default", Href: at(bare)})
+}
@@ -0,0 +1,107 @@
+package gotth_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/a-h/templ"
+	"github.com/ancalabrese/gotth"
+	"github.com/ancalabrese/gotth/i18n"
+	"github.com/ancalabrese/gotth/views/components/head"
+)
+
+func TestWebServer_ServeLocalized(t *testing.T) {
+	ws, err := gotth.New(gotth.WebServerConfig{Locales: &i18n.Config{Locales: []string{"en", "de"}}}, nil)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	greetings := map[string]string{"en": "Hello", "de": "Hallo"}
+	ws.ServeLocalized("GET /about", func(r *http.Request) (head.HeadViewModel, templ.Component, error) {
+		return head.NewHeadViewModel(head.WithPageCoreMetadata("About", "", "https://example.com/about")),
+			templ.ComponentFunc(func(ctx context.Context, w io.Writer) error {
+				_, err := io.WriteString(w, "<p>"+greetings[i18n.FromContext(ctx)]+"</p>")
+				return err
+			}), nil
+	})
+	if err := ws.Err(); err != nil {
+		t.Fatalf("Err() = %v", err)
+	}
+
+	tests := []struct {
+		name           string
+		path           string
+		acceptLanguage string
+		wantStatus     int
+		wantLocation   string
+		wantBody       []string
+	}{
+		{
+			name:         "Bare path, default locale",
+			path:         "/about?ref=nav",
+			wantStatus:   http.StatusFound,
+			wantLocation: "/en/about?ref=nav",
+		},
+		{
+			name:           "Bare path, negotiated locale",
+			path:           "/about",
+			acceptLanguage: "de-CH,de;q=0.9,en;q=0.5",
+			wantStatus:     http.StatusFound,
+			wantLocation:   "/de/about",
+		},
+		{
+			name:       "Localized page",
+			path:       "/de/about",
+			wantStatus: http.StatusOK,
+			wantBody: []string{
+				`lang="de"`,
+				"<p>Hallo</p>",
+				`<link rel="canonical" href="https://example.com/de/about"`,
+				`<link rel="alternate" hreflang="en" href="https://example.com/en/about"`,
+				`<link rel="alternate" hreflang="de" href="https://example.com/de/about"`,
+				`<link rel="alternate" hreflang="x-default" href="https://example.com/about"`,
+				`<meta property="og:locale" content="de_DE"`,
+			},
+		},
+		{name: "Unsupported locale", path: "/fr/about", wantStatus: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.acceptLanguage != "" {
+				req.Header.Set("Accept-Language", tt.acceptLanguage)
+			}
+			rr := httptest.NewRecorder()
+			ws.Handler().ServeHTTP(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rr.Code, tt.wantStatus)
+			}
+			if got := rr.Header().Get("Location"); got != tt.wantLocation {
+				t.Errorf("Location = %q, want %q", got, tt.wantLocation)
+			}
+			if tt.wantLocation != "" && !strings.Contains(rr.Header().Get("Vary"), "Accept-Language") {
+				t.Errorf("Vary = %q, want Accept-Language", rr.Header().Get("Vary"))
+			}
+			for _, want := range tt.wantBody {
+				if !strings.Contains(rr.Body.String(), want) {
+					t.Errorf("body is missing %s:\n%s", want, rr.Body.String())
+				}
+			}
+		})
+	}
+}
+
+func TestWebServer_ServeLocalizedWithoutLocales(t *testing.T) {
+	ws, _ := gotth.New(gotth.WebServerConfig{}, nil)
+	ws.ServeLocalized("GET /about", func(r *http.Request) (head.HeadViewModel, templ.Component, error) {
+		return head.NewHeadViewModel(), templ.NopComponent, nil
+	})
+	if err := ws.Err(); !errors.Is(err, gotth.ErrInvalidRoute) {
+		t.Errorf("Err() = %v, want %v", err, gotth.ErrInvalidRoute)
+	}
+}
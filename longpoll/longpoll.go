@@ -0,0 +1,200 @@
+// Package longpoll serves long-poll endpoints, the fallback for the clients and networks where
+// Server-Sent Events and WebSockets aren't available: the request carries the version token of
+// the value the client has, and the response waits until the value changes or a timeout.
+//
+//	scores := longpoll.NewState(Scores{})
+//	ws.Handle("GET /poll/scores", ws.CloseOnShutdown(longpoll.Handler(scores, longpoll.Config{},
+//		longpoll.Fragment(func(s Scores, token string) templ.Component {
+//			return views.Scores(s, token)
+//		}))))
+//
+//	// Anywhere in the application, waking the polls:
+//	scores.Update(func(s Scores) Scores { return s.With(goal) })
+//
+// where views.Scores renders the scores in an element polling again with the new token:
+//
+//	<div hx-get={ "/poll/scores?version=" + token } hx-trigger="load" hx-swap="outerHTML">
+package longpoll
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/a-h/templ"
+	"github.com/ancalabrese/gotth/htmx"
+)
+
+const (
+	// DefaultTimeout is how long a poll waits for a change, shorter than the graceful shutdown
+	// of the web server.
+	DefaultTimeout = 10 * time.Second
+	// DefaultParam is the query parameter of the version token.
+	DefaultParam = "version"
+)
+
+// State is a value watched by long polls. It's safe for concurrent use.
+//
+// Its version tokens are only valid for this State: a token of another State, e.g. before a
+// restart, doesn't match, and its poll gets the current value right away.
+type State[T any] struct {
+	epoch string
+
+	mu      sync.Mutex
+	value   T
+	version uint64
+	changed chan struct{} // Closed and replaced on every change
+}
+
+// NewState returns the State holding initial.
+func NewState[T any](initial T) *State[T] {
+	return &State[T]{epoch: rand.Text()[:8], value: initial, version: 1, changed: make(chan struct{})}
+}
+
+// Get returns the value and its version token.
+func (s *State[T]) Get() (T, string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.value, s.token()
+}
+
+// Set replaces the value, wakes the waiting polls and returns the new token.
+func (s *State[T]) Set(v T) string {
+	return s.Update(func(T) T { return v })
+}
+
+// Update replaces the value with fn of the current one, atomically, wakes the waiting polls and
+// returns the new token.
+func (s *State[T]) Update(fn func(T) T) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.value = fn(s.value)
+	s.version++
+	close(s.changed)
+	s.changed = make(chan struct{})
+	return s.token()
+}
+
+// Wait returns the value and its token once its token differs from token: right away when
+// token is stale, e.g. "" on the first poll, or after the next change. It returns the current
+// value and ctx.Err() when ctx is done first.
+func (s *State[T]) Wait(ctx context.Context, token string) (T, string, error) {
+	s.mu.Lock()
+	value, current, changed := s.value, s.token(), s.changed
+	s.mu.Unlock()
+	if token != current {
+		return value, current, nil
+	}
+	select {
+	case <-changed:
+		value, current := s.Get()
+		return value, current, nil
+	case <-ctx.Done():
+		return value, current, ctx.Err()
+	}
+}
+
+// token returns the version token. s.mu must be locked.
+func (s *State[T]) token() string {
+	return s.epoch + "-" + strconv.FormatUint(s.version, 10)
+}
+
+// WriteFunc writes the response of a poll: the value and its token, which the client sends
+// with its next poll.
+type WriteFunc[T any] func(w http.ResponseWriter, r *http.Request, v T, token string) error
+
+// Config configures a [Handler].
+type Config struct {
+	// Timeout is how long a poll waits for a change. Defaults to [DefaultTimeout].
+	Timeout time.Duration
+	// Param is the query parameter of the version token. Defaults to [DefaultParam].
+	Param string
+	// Optional: logs the failures of the WriteFunc. Defaults to slog.Default().
+	Logger *slog.Logger
+}
+
+// Handler returns the handler of the long polls of s. The token is read from the cfg.Param
+// query parameter, or from If-None-Match. Polls with a stale token get the value right away,
+// the others wait for a change, up to cfg.Timeout. The value is written by write with an ETag
+// of its token.
+//
+// A poll timing out gets a 304 Not Modified, to poll again with the same token, except HTMX
+// requests, which get the value written again: an element polling on load swaps itself with
+// the next poll. So do the polls ended by the shutdown of the server when the handler is
+// wrapped with gotth's WebServer.CloseOnShutdown, which it needs not to hold the shutdown for
+// up to cfg.Timeout.
+func Handler[T any](s *State[T], cfg Config, write WriteFunc[T]) http.Handler {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultTimeout
+	}
+	if cfg.Param == "" {
+		cfg.Param = DefaultParam
+	}
+	logger := cfg.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := r.URL.Query().Get(cfg.Param)
+		if token == "" {
+			token = strings.Trim(strings.TrimPrefix(r.Header.Get("If-None-Match"), "W/"), `"`)
+		}
+
+		// The poll outlives the write timeout of the server.
+		http.NewResponseController(w).SetWriteDeadline(time.Now().Add(cfg.Timeout + 10*time.Second))
+		ctx, cancel := context.WithTimeout(r.Context(), cfg.Timeout)
+		defer cancel()
+		// A poll ended by the client going away is answered like a timeout: the write fails.
+		value, current, err := s.Wait(ctx, token)
+
+		w.Header().Set("Cache-Control", "no-store")
+		w.Header().Set("ETag", `"`+current+`"`)
+		if err != nil && !htmx.IsRequest(r) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		if err := write(w, r, value, current); err != nil {
+			logger.ErrorContext(r.Context(), "failed to write long poll response", slog.String("path", r.URL.Path), slog.Any("error", err))
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		}
+	})
+}
+
+// JSON returns the WriteFunc writing {"version": token, "data": v}, with v encoded by
+// encoding/json.
+func JSON[T any]() WriteFunc[T] {
+	return func(w http.ResponseWriter, r *http.Request, v T, token string) error {
+		body, err := json.Marshal(struct {
+			Version string `json:"version"`
+			Data    T      `json:"data"`
+		}{token, v})
+		if err != nil {
+			return err
+		}
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.Write(body)
+		return nil
+	}
+}
+
+// Fragment returns the WriteFunc rendering the component returned by fn, e.g. an element
+// polling again with token.
+func Fragment[T any](fn func(v T, token string) templ.Component) WriteFunc[T] {
+	return func(w http.ResponseWriter, r *http.Request, v T, token string) error {
+		var buf bytes.Buffer
+		if err := fn(v, token).Render(r.Context(), &buf); err != nil {
+			return err
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(buf.Bytes())
+		return nil
+	}
+}
@@ -0,0 +1,123 @@
+package longpoll_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/a-h/templ"
+	"github.com/ancalabrese/gotth/longpoll"
+)
+
+func TestState(t *testing.T) {
+	s := longpoll.NewState(1)
+	v, token := s.Get()
+	if v != 1 || token == "" {
+		t.Fatalf("Get() = %d, %q", v, token)
+	}
+
+	// A stale token gets the value right away.
+	if v, got, err := s.Wait(context.Background(), "stale"); err != nil || v != 1 || got != token {
+		t.Errorf("Wait(stale) = %d, %q, %v, want the current value", v, got, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, got, err := s.Wait(ctx, token); !errors.Is(err, context.DeadlineExceeded) || got != token {
+		t.Errorf("Wait() without change = %q, %v, want %v", got, err, context.DeadlineExceeded)
+	}
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		s.Update(func(v int) int { return v + 1 })
+	}()
+	v, next, err := s.Wait(context.Background(), token)
+	if err != nil || v != 2 || next == token {
+		t.Errorf("Wait() = %d, %q, %v, want the changed value and a new token", v, next, err)
+	}
+	if _, other := longpoll.NewState(2).Get(); other == next {
+		t.Error("tokens of another State match")
+	}
+}
+
+func TestHandler(t *testing.T) {
+	s := longpoll.NewState("idle")
+	h := longpoll.Handler(s, longpoll.Config{Timeout: 20 * time.Millisecond}, longpoll.JSON[string]())
+	poll := func(token string, header http.Header) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/poll?version="+token, nil)
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := poll("", nil)
+	var body struct {
+		Version string `json:"version"`
+		Data    string `json:"data"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil || rr.Code != http.StatusOK || body.Data != "idle" {
+		t.Fatalf("first poll = %d %q, want the current value", rr.Code, rr.Body)
+	}
+	if rr.Header().Get("ETag") != `"`+body.Version+`"` || rr.Header().Get("Cache-Control") != "no-store" {
+		t.Errorf("headers = %v, want the ETag of the token and no-store", rr.Header())
+	}
+
+	if rr := poll(body.Version, nil); rr.Code != http.StatusNotModified {
+		t.Errorf("status of a poll timing out = %d, want %d", rr.Code, http.StatusNotModified)
+	}
+	if rr := poll("", http.Header{"If-None-Match": {`"` + body.Version + `"`}}); rr.Code != http.StatusNotModified {
+		t.Errorf("status with If-None-Match = %d, want %d", rr.Code, http.StatusNotModified)
+	}
+
+	go func() {
+		time.Sleep(5 * time.Millisecond)
+		s.Set("running")
+	}()
+	h = longpoll.Handler(s, longpoll.Config{Timeout: time.Second}, longpoll.JSON[string]())
+	rr = poll(body.Version, nil)
+	json.Unmarshal(rr.Body.Bytes(), &body)
+	if rr.Code != http.StatusOK || body.Data != "running" {
+		t.Errorf("poll woken by a change = %d %q, want the new value", rr.Code, rr.Body)
+	}
+}
+
+func TestHandler_Shutdown(t *testing.T) {
+	s := longpoll.NewState("idle")
+	_, token := s.Get()
+	h := longpoll.Handler(s, longpoll.Config{Timeout: time.Minute}, longpoll.JSON[string]())
+	// The request context canceled by WebServer.CloseOnShutdown.
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/poll?version="+token, nil).WithContext(ctx))
+	if rr.Code != http.StatusNotModified {
+		t.Errorf("status of a poll ended by the shutdown = %d, want %d", rr.Code, http.StatusNotModified)
+	}
+}
+
+func TestHandler_HTMX(t *testing.T) {
+	s := longpoll.NewState("idle")
+	_, token := s.Get()
+	h := longpoll.Handler(s, longpoll.Config{Timeout: 10 * time.Millisecond}, longpoll.Fragment(func(v, token string) templ.Component {
+		return templ.ComponentFunc(func(ctx context.Context, w io.Writer) error {
+			_, err := io.WriteString(w, `<div hx-get="/poll?version=`+token+`" hx-trigger="load">`+v+`</div>`)
+			return err
+		})
+	}))
+	req := httptest.NewRequest(http.MethodGet, "/poll?version="+token, nil)
+	req.Header.Set("HX-Request", "true")
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+	// HTMX polls timing out get the element again, so that it keeps polling.
+	if want := `<div hx-get="/poll?version=` + token + `" hx-trigger="load">idle</div>`; rr.Code != http.StatusOK || rr.Body.String() != want {
+		t.Errorf("response = %d %q, want %q", rr.Code, rr.Body, want)
+	}
+}
@@ -0,0 +1,254 @@
+// Package mail sends the emails of a site, e.g. the verification, password reset and login
+// links of the auth flows, with bodies rendered by templ components:
+//
+//	sender, err := mail.NewSMTP(mail.SMTPConfig{Addr: "smtp.example.com:587", Username: user, Password: pass})
+//
+//	msg := &mail.Message{From: "Site <noreply@example.com>", To: []string{email}, Subject: "Welcome"}
+//	if err := msg.SetBody(ctx, views.WelcomeEmail(user)); err != nil { ... }
+//	err = sender.Send(ctx, msg)
+//
+// [Render] inlines the rules of the <style> elements of the component into style attributes,
+// since most email clients ignore stylesheets, and generates the plain text alternative. Any
+// provider can deliver the messages by implementing [Sender], e.g. with its HTTP API, and
+// [Outbox] keeps them in memory for the tests and development.
+package mail
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/a-h/templ"
+)
+
+// ErrInvalidMessage is returned when sending a message without sender, recipients or body, or
+// with an invalid address or header.
+var ErrInvalidMessage = errors.New("invalid message")
+
+// Message is an email with an HTML body, its plain text alternative, or both.
+type Message struct {
+	// From is the sender, e.g. "Site <noreply@example.com>".
+	From    string
+	To      []string
+	Cc      []string
+	Bcc     []string // Recipients not listed in the headers
+	ReplyTo string
+	Subject string
+	HTML    string
+	Text    string
+	// Optional: extra headers, e.g. List-Unsubscribe.
+	Headers map[string]string
+}
+
+// SetBody renders c into the HTML body of m, and its plain text alternative. See [Render].
+func (m *Message) SetBody(ctx context.Context, c templ.Component) error {
+	html, text, err := Render(ctx, c)
+	if err != nil {
+		return err
+	}
+	m.HTML, m.Text = html, text
+	return nil
+}
+
+// Recipients returns the addresses of the To, Cc and Bcc recipients, e.g. for the SMTP envelope.
+func (m *Message) Recipients() ([]string, error) {
+	var rcpts []string
+	for _, list := range [][]string{m.To, m.Cc, m.Bcc} {
+		for _, a := range list {
+			addr, err := mail.ParseAddress(a)
+			if err != nil {
+				return nil, fmt.Errorf("%w: recipient %q. err %w", ErrInvalidMessage, a, err)
+			}
+			rcpts = append(rcpts, addr.Address)
+		}
+	}
+	return rcpts, nil
+}
+
+// Validate checks that m has a sender, recipients and a body, and valid addresses and headers.
+func (m *Message) Validate() error {
+	if m.From == "" || len(m.To)+len(m.Cc)+len(m.Bcc) == 0 {
+		return fmt.Errorf("%w: it needs a sender and recipients", ErrInvalidMessage)
+	}
+	if m.HTML == "" && m.Text == "" {
+		return fmt.Errorf("%w: it needs a body", ErrInvalidMessage)
+	}
+	if _, err := mail.ParseAddress(m.From); err != nil {
+		return fmt.Errorf("%w: sender %q. err %w", ErrInvalidMessage, m.From, err)
+	}
+	if m.ReplyTo != "" {
+		if _, err := mail.ParseAddress(m.ReplyTo); err != nil {
+			return fmt.Errorf("%w: reply-to %q. err %w", ErrInvalidMessage, m.ReplyTo, err)
+		}
+	}
+	if _, err := m.Recipients(); err != nil {
+		return err
+	}
+	for k, v := range m.Headers {
+		if strings.ContainsAny(k, "\r\n: ") || strings.ContainsAny(v, "\r\n") {
+			return fmt.Errorf("%w: header %q", ErrInvalidMessage, k)
+		}
+	}
+	if strings.ContainsAny(m.Subject, "\r\n") {
+		return fmt.Errorf("%w: subject with a line break", ErrInvalidMessage)
+	}
+	return nil
+}
+
+// WriteTo writes m to w in the MIME format, e.g. for the DATA command of SMTP: a
+// multipart/alternative message when it has both bodies.
+func (m *Message) WriteTo(w io.Writer) (int64, error) {
+	if err := m.Validate(); err != nil {
+		return 0, err
+	}
+	var buf bytes.Buffer
+	from, _ := mail.ParseAddress(m.From)
+	header := func(k, v string) { fmt.Fprintf(&buf, "%s: %s\r\n", k, v) }
+	header("From", from.String())
+	if len(m.To) > 0 {
+		header("To", addressList(m.To))
+	}
+	if len(m.Cc) > 0 {
+		header("Cc", addressList(m.Cc))
+	}
+	if m.ReplyTo != "" {
+		header("Reply-To", addressList([]string{m.ReplyTo}))
+	}
+	header("Subject", mime.QEncoding.Encode("utf-8", m.Subject))
+	header("Date", time.Now().Format(time.RFC1123Z))
+	header("Message-ID", messageID(from.Address))
+	header("MIME-Version", "1.0")
+	for _, k := range slices.Sorted(maps.Keys(m.Headers)) {
+		header(textproto.CanonicalMIMEHeaderKey(k), mime.QEncoding.Encode("utf-8", m.Headers[k]))
+	}
+
+	switch {
+	case m.HTML == "":
+		writePart(&buf, "text/plain", m.Text)
+	case m.Text == "":
+		writePart(&buf, "text/html", m.HTML)
+	default:
+		mw := multipart.NewWriter(&buf)
+		header("Content-Type", mime.FormatMediaType("multipart/alternative", map[string]string{"boundary": mw.Boundary()}))
+		buf.WriteString("\r\n")
+		for _, part := range []struct{ typ, body string }{{"text/plain", m.Text}, {"text/html", m.HTML}} {
+			pw, err := mw.CreatePart(textproto.MIMEHeader{
+				"Content-Type":              {part.typ + "; charset=utf-8"},
+				"Content-Transfer-Encoding": {"quoted-printable"},
+			})
+			if err != nil {
+				return 0, fmt.Errorf("failed to write the %s part. err %w", part.typ, err)
+			}
+			writeQuotedPrintable(pw, part.body)
+		}
+		mw.Close()
+	}
+	return buf.WriteTo(w)
+}
+
+// writePart writes the headers and body of a single part message.
+func writePart(buf *bytes.Buffer, typ, body string) {
+	fmt.Fprintf(buf, "Content-Type: %s; charset=utf-8\r\nContent-Transfer-Encoding: quoted-printable\r\n\r\n", typ)
+	writeQuotedPrintable(buf, body)
+}
+
+func writeQuotedPrintable(w io.Writer, body string) {
+	qw := quotedprintable.NewWriter(w)
+	qw.Write([]byte(body)) // Line breaks become CRLF
+	qw.Close()
+}
+
+func addressList(list []string) string {
+	formatted := make([]string, 0, len(list))
+	for _, a := range list {
+		addr, _ := mail.ParseAddress(a) // Checked by Validate
+		formatted = append(formatted, addr.String())
+	}
+	return strings.Join(formatted, ", ")
+}
+
+// messageID returns a unique Message-ID in the domain of the sender.
+func messageID(from string) string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	_, domain, _ := strings.Cut(from, "@")
+	return "<" + hex.EncodeToString(b) + "@" + domain + ">"
+}
+
+// Sender delivers messages, e.g. [SMTP] or the HTTP API of an email provider.
+type Sender interface {
+	Send(ctx context.Context, m *Message) error
+}
+
+// SenderFunc is a function implementing [Sender].
+type SenderFunc func(ctx context.Context, m *Message) error
+
+func (f SenderFunc) Send(ctx context.Context, m *Message) error {
+	return f(ctx, m)
+}
+
+// LinkSender returns a function sending the link of the auth flows, e.g. the login link of
+// auth.MagicLink or a password reset link, to the recipient in a copy of msg whose body is
+// rendered by body:
+//
+//	send := mail.LinkSender(sender, mail.Message{From: from, Subject: "Your login link"}, views.LoginEmail)
+//	ml, err := auth.NewMagicLink(secret, callbackURL, lookup, send, store, cfg)
+func LinkSender(s Sender, msg Message, body func(link string) templ.Component) func(ctx context.Context, to, link string) error {
+	return func(ctx context.Context, to, link string) error {
+		m := msg
+		m.To = []string{to}
+		if err := m.SetBody(ctx, body(link)); err != nil {
+			return err
+		}
+		return s.Send(ctx, &m)
+	}
+}
+
+// Outbox is a [Sender] keeping the messages in memory instead of delivering them, for the tests
+// and development.
+type Outbox struct {
+	mu   sync.Mutex
+	msgs []Message
+}
+
+// Send validates m and keeps a copy.
+func (o *Outbox) Send(ctx context.Context, m *Message) error {
+	if err := m.Validate(); err != nil {
+		return err
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.msgs = append(o.msgs, *m)
+	return nil
+}
+
+// Messages returns the messages sent so far, from the oldest.
+func (o *Outbox) Messages() []Message {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return slices.Clone(o.msgs)
+}
+
+// Last returns the last message sent, e.g. to follow the link it contains in a test.
+func (o *Outbox) Last() (Message, bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if len(o.msgs) == 0 {
+		return Message{}, false
+	}
+	return o.msgs[len(o.msgs)-1], true
+}
@@ -0,0 +1,239 @@
+package mail_test
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	netmail "net/mail"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/a-h/templ"
+	"github.com/ancalabrese/gotth/mail"
+)
+
+// welcome is an email body in the Layout, with a button.
+func welcome(link string) templ.Component {
+	body := templ.ComponentFunc(func(ctx context.Context, w io.Writer) error {
+		io.WriteString(w, "<h1>Welcome</h1><p>Confirm   your\n address <b>now</b>.</p>")
+		if err := mail.Button(templ.SafeURL(link), "Confirm").Render(ctx, w); err != nil {
+			return err
+		}
+		_, err := io.WriteString(w, `<ul><li>Docs at <a href="https://example.com/docs">the docs</a></li><li><a href="https://example.com">https://example.com</a></li></ul><p>Thanks,<br>The team</p>`)
+		return err
+	})
+	return templ.ComponentFunc(func(ctx context.Context, w io.Writer) error {
+		return mail.Layout("Welcome", "Confirm your address").Render(templ.WithChildren(ctx, body), w)
+	})
+}
+
+func TestRender(t *testing.T) {
+	html, text, err := mail.Render(context.Background(), welcome("https://example.com/confirm?t=1"))
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	for _, want := range []string{`<h1 style="font-size: 22px; margin: 0 0 16px;">`, "@media (max-width: 600px)", `<a href="https://example.com/confirm?t=1" style="color: #ffffff;`} {
+		if !strings.Contains(html, want) {
+			t.Errorf("html is missing %q:\n%s", want, html)
+		}
+	}
+	if strings.Contains(html, ".button a {") {
+		t.Error("html still has the inlined rules")
+	}
+
+	want := `Welcome
+
+Confirm your address now.
+
+Confirm (https://example.com/confirm?t=1)
+
+- Docs at the docs (https://example.com/docs)
+- https://example.com
+
+Thanks,
+The team
+`
+	if text != want {
+		t.Errorf("text = %q, want %q", text, want)
+	}
+}
+
+func TestMessage_WriteTo(t *testing.T) {
+	m := &mail.Message{
+		From:    "Gotth Site <noreply@example.com>",
+		To:      []string{"Ann Lee <ann@example.com>"},
+		Bcc:     []string{"audit@example.com"},
+		Subject: "Bienvenue à bord",
+		Headers: map[string]string{"List-Unsubscribe": "<https://example.com/unsubscribe>"},
+	}
+	if err := m.SetBody(context.Background(), welcome("https://example.com/confirm")); err != nil {
+		t.Fatal(err)
+	}
+	var b strings.Builder
+	if _, err := m.WriteTo(&b); err != nil {
+		t.Fatalf("WriteTo() error = %v", err)
+	}
+
+	msg, err := netmail.ReadMessage(strings.NewReader(b.String()))
+	if err != nil {
+		t.Fatalf("ReadMessage() error = %v", err)
+	}
+	subject, _ := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if subject != m.Subject || msg.Header.Get("To") != `"Ann Lee" <ann@example.com>` || msg.Header.Get("Bcc") != "" {
+		t.Errorf("headers = %v", msg.Header)
+	}
+	if msg.Header.Get("List-Unsubscribe") == "" || !strings.HasSuffix(msg.Header.Get("Message-Id"), "@example.com>") {
+		t.Errorf("headers = %v", msg.Header)
+	}
+
+	typ, params, _ := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if typ != "multipart/alternative" {
+		t.Fatalf("Content-Type = %q", typ)
+	}
+	r := multipart.NewReader(msg.Body, params["boundary"])
+	var types []string
+	for {
+		p, err := r.NextPart() // Decodes the quoted-printable parts
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(p)
+		types = append(types, p.Header.Get("Content-Type"))
+		if strings.HasPrefix(p.Header.Get("Content-Type"), "text/plain") && !strings.Contains(string(body), "Confirm (https://example.com/confirm)\r\n") {
+			t.Errorf("text part = %q", body)
+		}
+	}
+	if len(types) != 2 || types[0] != "text/plain; charset=utf-8" || types[1] != "text/html; charset=utf-8" {
+		t.Errorf("parts = %v, want the text then the html", types)
+	}
+}
+
+func TestMessage_Validate(t *testing.T) {
+	valid := mail.Message{From: "noreply@example.com", To: []string{"ann@example.com"}, Text: "Hi"}
+	tests := map[string]func(m *mail.Message){
+		"No recipient":          func(m *mail.Message) { m.To = nil },
+		"No body":               func(m *mail.Message) { m.Text = "" },
+		"Invalid sender":        func(m *mail.Message) { m.From = "noreply" },
+		"Invalid recipient":     func(m *mail.Message) { m.Cc = []string{"ann@"} },
+		"Header injection":      func(m *mail.Message) { m.Headers = map[string]string{"X-Tag": "a\r\nBcc: eve@example.com"} },
+		"Multi-line subject":    func(m *mail.Message) { m.Subject = "Hi\nBcc: eve@example.com" },
+		"Invalid header name":   func(m *mail.Message) { m.Headers = map[string]string{"X Tag": "a"} },
+		"Invalid reply address": func(m *mail.Message) { m.ReplyTo = "@example.com" },
+	}
+	if err := valid.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	for name, change := range tests {
+		t.Run(name, func(t *testing.T) {
+			m := valid
+			change(&m)
+			if err := m.Validate(); !errors.Is(err, mail.ErrInvalidMessage) {
+				t.Errorf("Validate() error = %v, want %v", err, mail.ErrInvalidMessage)
+			}
+		})
+	}
+}
+
+func TestLinkSender(t *testing.T) {
+	var outbox mail.Outbox
+	send := mail.LinkSender(&outbox, mail.Message{From: "noreply@example.com", Subject: "Your login link"}, welcome)
+	if err := send(context.Background(), "ann@example.com", "https://example.com/login?token=abc"); err != nil {
+		t.Fatalf("send() error = %v", err)
+	}
+	m, ok := outbox.Last()
+	if !ok || len(outbox.Messages()) != 1 || m.To[0] != "ann@example.com" || !strings.Contains(m.Text, "https://example.com/login?token=abc") {
+		t.Errorf("outbox = %+v", outbox.Messages())
+	}
+	if err := send(context.Background(), "not an address", "https://example.com"); !errors.Is(err, mail.ErrInvalidMessage) {
+		t.Errorf("send() to an invalid address error = %v", err)
+	}
+}
+
+func TestSMTP(t *testing.T) {
+	srv := newSMTPServer(t)
+	sender, err := mail.NewSMTP(mail.SMTPConfig{Addr: srv.addr, Username: "user", Password: "secret"})
+	if err != nil {
+		t.Fatalf("NewSMTP() error = %v", err)
+	}
+	m := &mail.Message{From: "noreply@example.com", To: []string{"ann@example.com"}, Bcc: []string{"audit@example.com"}, Subject: "Hi", Text: "Hello\n.\nBye"}
+	if err := sender.Send(context.Background(), m); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	got := <-srv.received
+	for _, want := range []string{"AUTH PLAIN", "MAIL FROM:<noreply@example.com>", "RCPT TO:<ann@example.com>", "RCPT TO:<audit@example.com>", "Subject: Hi", "Hello\r\n..\r\nBye"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("session is missing %q:\n%s", want, got)
+		}
+	}
+
+	if _, err := mail.NewSMTP(mail.SMTPConfig{Addr: "smtp.example.com"}); err == nil {
+		t.Error("NewSMTP() without a port error = nil")
+	}
+}
+
+// smtpServer is a minimal SMTP server on localhost, without STARTTLS, recording the sessions.
+type smtpServer struct {
+	addr     string
+	received chan string
+}
+
+func newSMTPServer(t *testing.T) *smtpServer {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	s := &smtpServer{addr: ln.Addr().String(), received: make(chan string, 1)}
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.SetDeadline(time.Now().Add(5 * time.Second))
+		var session strings.Builder
+		r := bufio.NewReader(conn)
+		reply := func(line string) { io.WriteString(conn, line+"\r\n") }
+		reply("220 localhost ESMTP")
+		for data := false; ; {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				s.received <- session.String()
+				return
+			}
+			session.WriteString(line)
+			cmd := strings.ToUpper(strings.TrimSpace(line))
+			switch {
+			case data:
+				if line == ".\r\n" {
+					data = false
+					reply("250 OK")
+				}
+			case strings.HasPrefix(cmd, "EHLO"):
+				reply("250-localhost")
+				reply("250 AUTH PLAIN")
+			case strings.HasPrefix(cmd, "AUTH"):
+				reply("235 Authenticated")
+			case cmd == "DATA":
+				data = true
+				reply("354 Go ahead")
+			case cmd == "QUIT":
+				reply("221 Bye")
+				s.received <- session.String()
+				return
+			default:
+				reply("250 OK")
+			}
+		}
+	}()
+	return s
+}
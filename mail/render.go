@@ -0,0 +1,168 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/a-h/templ"
+	"github.com/aymerick/douceur/inliner"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Render renders c into the bodies of an email. In html, the rules of the <style> elements are
+// inlined into the style attributes of the elements they match, the others, e.g. media queries,
+// are kept in a <style> element. text is the plain text alternative: the text of the paragraphs,
+// headings and list items, with the URLs of the links after their text.
+func Render(ctx context.Context, c templ.Component) (htmlBody, text string, err error) {
+	var b strings.Builder
+	if err := c.Render(ctx, &b); err != nil {
+		return "", "", fmt.Errorf("failed to render the email. err %w", err)
+	}
+	htmlBody, err = inliner.Inline(b.String())
+	if err != nil {
+		return "", "", fmt.Errorf("failed to inline the styles of the email. err %w", err)
+	}
+	text, err = PlainText(htmlBody)
+	if err != nil {
+		return "", "", err
+	}
+	return htmlBody, text, nil
+}
+
+// PlainText returns the plain text version of the HTML body of an email, without the elements
+// hidden with an inline "display: none", e.g. the preheader of [Layout].
+func PlainText(body string) (string, error) {
+	doc, err := html.Parse(strings.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("failed to parse the email. err %w", err)
+	}
+	var w textWriter
+	w.node(doc)
+	return strings.TrimSpace(w.b.String()) + "\n", nil
+}
+
+// textWriter writes the text of an HTML tree, collapsing its whitespace as browsers do.
+type textWriter struct {
+	b        strings.Builder
+	newlines int  // Line breaks before the next text
+	space    bool // Space before the next text
+	pre      int  // Depth in <pre>
+}
+
+// blocks are the elements on their own lines, with the number of line breaks around them.
+var blocks = map[atom.Atom]int{
+	atom.P: 2, atom.H1: 2, atom.H2: 2, atom.H3: 2, atom.H4: 2, atom.H5: 2, atom.H6: 2,
+	atom.Ul: 2, atom.Ol: 2, atom.Table: 2, atom.Blockquote: 2, atom.Pre: 2, atom.Hr: 2,
+	atom.Div: 1, atom.Li: 1, atom.Tr: 1, atom.Section: 1, atom.Header: 1, atom.Footer: 1,
+	atom.Article: 1, atom.Main: 1, atom.Dt: 1, atom.Dd: 1,
+}
+
+func (w *textWriter) node(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		w.text(n.Data)
+		return
+	case html.ElementNode:
+	default:
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			w.node(c)
+		}
+		return
+	}
+
+	if style := strings.ReplaceAll(attr(n, "style"), " ", ""); strings.Contains(style, "display:none") {
+		return
+	}
+	switch n.DataAtom {
+	case atom.Head, atom.Script, atom.Style, atom.Template:
+		return
+	case atom.Br:
+		w.lineBreak(1)
+		return
+	case atom.Img:
+		w.text(attr(n, "alt"))
+		return
+	case atom.Td, atom.Th:
+		w.space = true
+	}
+
+	lines := blocks[n.DataAtom]
+	w.lineBreak(lines)
+	switch n.DataAtom {
+	case atom.Li:
+		w.write("- ")
+	case atom.Hr:
+		w.write("---")
+	case atom.Pre:
+		w.pre++
+		defer func() { w.pre-- }()
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		w.node(c)
+	}
+	if n.DataAtom == atom.A {
+		if href := attr(n, "href"); href != "" && !strings.HasPrefix(href, "#") && strings.TrimSpace(textOf(n)) != href {
+			w.text(" (" + strings.TrimPrefix(href, "mailto:") + ")")
+		}
+	}
+	w.lineBreak(lines)
+}
+
+// text writes s, its whitespace collapsed outside of <pre>.
+func (w *textWriter) text(s string) {
+	if w.pre > 0 {
+		w.write(s)
+		return
+	}
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		w.space = w.space || s != ""
+		return
+	}
+	if s[0] == ' ' || s[0] == '\t' || s[0] == '\n' || s[0] == '\r' {
+		w.space = true
+	}
+	w.write(strings.Join(fields, " "))
+	last := s[len(s)-1]
+	w.space = last == ' ' || last == '\t' || last == '\n' || last == '\r'
+}
+
+// write writes s after the pending line breaks or space.
+func (w *textWriter) write(s string) {
+	switch {
+	case w.newlines > 0:
+		w.b.WriteString(strings.Repeat("\n", w.newlines))
+	case w.space && w.b.Len() > 0:
+		w.b.WriteByte(' ')
+	}
+	w.newlines, w.space = 0, false
+	w.b.WriteString(s)
+}
+
+// lineBreak makes the next text start after n line breaks.
+func (w *textWriter) lineBreak(n int) {
+	if w.b.Len() > 0 {
+		w.newlines = max(w.newlines, n)
+	}
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func textOf(n *html.Node) string {
+	var b strings.Builder
+	for c := range n.Descendants() {
+		if c.Type == html.TextNode {
+			b.WriteString(c.Data)
+		}
+	}
+	return b.String()
+}
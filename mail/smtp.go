@@ -0,0 +1,135 @@
+package mail
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"time"
+)
+
+// DefaultSMTPTimeout bounds the delivery of a message by [SMTP] when its context has no deadline.
+const DefaultSMTPTimeout = 30 * time.Second
+
+// SMTPConfig configures an [SMTP] sender.
+type SMTPConfig struct {
+	// Required: host:port of the server, e.g. "smtp.example.com:587".
+	Addr string
+	// Optional: credentials of the PLAIN authentication, only sent over TLS or to localhost.
+	Username string
+	Password string
+	// ImplicitTLS connects with TLS, e.g. to port 465. Otherwise the connection is upgraded with
+	// STARTTLS when the server supports it.
+	ImplicitTLS bool
+	// Optional: TLS configuration, e.g. the RootCAs of a private server.
+	TLSConfig *tls.Config
+	// Timeout of a delivery when the context has no deadline. Defaults to [DefaultSMTPTimeout].
+	Timeout time.Duration
+}
+
+// SMTP is a [Sender] delivering the messages to an SMTP server, one connection per message.
+type SMTP struct {
+	cfg  SMTPConfig
+	host string
+}
+
+// NewSMTP returns an SMTP sender for cfg.
+func NewSMTP(cfg SMTPConfig) (*SMTP, error) {
+	host, _, err := net.SplitHostPort(cfg.Addr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SMTP address %q. err %w", cfg.Addr, err)
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultSMTPTimeout
+	}
+	tlsConfig := &tls.Config{ServerName: host}
+	if cfg.TLSConfig != nil {
+		tlsConfig = cfg.TLSConfig.Clone()
+		if tlsConfig.ServerName == "" {
+			tlsConfig.ServerName = host
+		}
+	}
+	cfg.TLSConfig = tlsConfig
+	return &SMTP{cfg: cfg, host: host}, nil
+}
+
+// Send delivers m, within the deadline of ctx.
+func (s *SMTP) Send(ctx context.Context, m *Message) error {
+	if err := m.Validate(); err != nil {
+		return err
+	}
+	from, _ := mail.ParseAddress(m.From) // Checked by Validate
+	rcpts, _ := m.Recipients()
+
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.cfg.Timeout)
+		defer cancel()
+	}
+	conn, err := s.dial(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to connect to %s. err %w", s.cfg.Addr, err)
+	}
+	deadline, _ := ctx.Deadline()
+	conn.SetDeadline(deadline)
+	stop := context.AfterFunc(ctx, func() { conn.SetDeadline(time.Now()) }) // Cancellation
+	defer stop()
+
+	c, err := smtp.NewClient(conn, s.host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to start the SMTP session. err %w", err)
+	}
+	defer c.Close()
+	if err := s.deliver(c, from.Address, rcpts, m); err != nil {
+		if ctx.Err() != nil {
+			return errors.Join(err, ctx.Err())
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *SMTP) dial(ctx context.Context) (net.Conn, error) {
+	if s.cfg.ImplicitTLS {
+		d := &tls.Dialer{Config: s.cfg.TLSConfig}
+		return d.DialContext(ctx, "tcp", s.cfg.Addr)
+	}
+	var d net.Dialer
+	return d.DialContext(ctx, "tcp", s.cfg.Addr)
+}
+
+func (s *SMTP) deliver(c *smtp.Client, from string, rcpts []string, m *Message) error {
+	if ok, _ := c.Extension("STARTTLS"); ok && !s.cfg.ImplicitTLS {
+		if err := c.StartTLS(s.cfg.TLSConfig); err != nil {
+			return fmt.Errorf("failed to start TLS. err %w", err)
+		}
+	}
+	if s.cfg.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", s.cfg.Username, s.cfg.Password, s.host)); err != nil {
+			return fmt.Errorf("failed to authenticate. err %w", err)
+		}
+	}
+	if err := c.Mail(from); err != nil {
+		return fmt.Errorf("failed to set the sender. err %w", err)
+	}
+	for _, rcpt := range rcpts {
+		if err := c.Rcpt(rcpt); err != nil {
+			return fmt.Errorf("failed to add recipient %s. err %w", rcpt, err)
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return fmt.Errorf("failed to send the message. err %w", err)
+	}
+	if _, err := m.WriteTo(w); err != nil {
+		return fmt.Errorf("failed to send the message. err %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("failed to send the message. err %w", err)
+	}
+	return c.Quit()
+}
@@ -0,0 +1,53 @@
+package middlewares_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ancalabrese/gotth/middlewares"
+)
+
+func TestBasicAuth(t *testing.T) {
+	validate := middlewares.StaticCredentials(map[string]string{"admin": "s3cret", "ops": "hunter2"})
+	handler := middlewares.BasicAuth("Staging", validate)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("welcome"))
+	}))
+
+	tests := []struct {
+		name       string
+		setAuth    bool
+		username   string
+		password   string
+		wantStatus int
+	}{
+		{name: "No credentials", wantStatus: http.StatusUnauthorized},
+		{name: "Valid credentials", setAuth: true, username: "admin", password: "s3cret", wantStatus: http.StatusOK},
+		{name: "Second user", setAuth: true, username: "ops", password: "hunter2", wantStatus: http.StatusOK},
+		{name: "Wrong password", setAuth: true, username: "admin", password: "s3cre", wantStatus: http.StatusUnauthorized},
+		{name: "Password of another user", setAuth: true, username: "admin", password: "hunter2", wantStatus: http.StatusUnauthorized},
+		{name: "Unknown user", setAuth: true, username: "guest", password: "s3cret", wantStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.setAuth {
+				req.SetBasicAuth(tt.username, tt.password)
+			}
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rr.Code, tt.wantStatus)
+			}
+			wantChallenge := ""
+			if tt.wantStatus == http.StatusUnauthorized {
+				wantChallenge = `Basic realm="Staging", charset="UTF-8"`
+			}
+			if got := rr.Header().Get("WWW-Authenticate"); got != wantChallenge {
+				t.Errorf("WWW-Authenticate = %q, want %q", got, wantChallenge)
+			}
+		})
+	}
+}
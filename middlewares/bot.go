@@ -0,0 +1,163 @@
+package middlewares
+
+import (
+	"context"
+	"net/http"
+	"net/netip"
+	"strings"
+	"sync"
+	"time"
+)
+
+const botKey contextBotKeyType = "gotth_bot_key"
+
+type contextBotKeyType string
+
+// Reasons a request is flagged as a bot. See [BotReason].
+const (
+	BotReasonEmptyUserAgent = "empty-user-agent"
+	BotReasonCrawler        = "crawler"
+	BotReasonHoneypot       = "honeypot"
+)
+
+// maxHoneypotEntries bounds the memory used to remember the IPs that hit a honeypot.
+const maxHoneypotEntries = 10000
+
+// DefaultCrawlerPatterns are lowercase User-Agent substrings of common crawlers, HTTP libraries and
+// monitoring tools.
+var DefaultCrawlerPatterns = []string{
+	"bot", "crawler", "spider", "slurp", "crawl", "facebookexternalhit", "embedly", "preview",
+	"curl/", "wget/", "python-requests", "python-urllib", "go-http-client", "java/", "okhttp",
+	"libwww-perl", "httpclient", "headlesschrome", "phantomjs", "lighthouse", "pingdom", "uptime",
+}
+
+// DefaultHoneypots are paths no real visitor of a gotth site requests but vulnerability scanners
+// probe for.
+var DefaultHoneypots = []string{
+	"/wp-login.php", "/wp-admin/", "/xmlrpc.php", "/.env", "/.git/config", "/phpmyadmin/",
+	"/admin.php", "/config.php",
+}
+
+// BotDetectionConfig configures the [BotDetection] middleware. Use [DefaultBotDetectionConfig]
+// as a starting point.
+type BotDetectionConfig struct {
+	// CrawlerPatterns are lowercase substrings that flag a User-Agent as a bot.
+	CrawlerPatterns []string
+	// Honeypots are path prefixes that only bots request. Requests to them get OnHoneypot and
+	// their client IP is flagged for HoneypotTTL.
+	Honeypots   []string
+	HoneypotTTL time.Duration
+	// Optional: response to honeypot requests. Defaults to a 404.
+	OnHoneypot http.Handler
+}
+
+// DefaultBotDetectionConfig returns a BotDetectionConfig with [DefaultCrawlerPatterns],
+// [DefaultHoneypots] and a one hour honeypot TTL.
+func DefaultBotDetectionConfig() BotDetectionConfig {
+	return BotDetectionConfig{
+		CrawlerPatterns: DefaultCrawlerPatterns,
+		Honeypots:       DefaultHoneypots,
+		HoneypotTTL:     time.Hour,
+	}
+}
+
+// BotDetection returns a new middleware (http.Handler) that flags obvious bots: requests without
+// a User-Agent, with a crawler User-Agent, or from an IP that recently requested a honeypot.
+// Flagged requests still reach the next handler; use [IsBot] to skip analytics or apply stricter
+// rate limits. The client IP is the one resolved by [RealIP], when in use.
+func BotDetection(cfg BotDetectionConfig) func(http.Handler) http.Handler {
+	onHoneypot := cfg.OnHoneypot
+	if onHoneypot == nil {
+		onHoneypot = http.NotFoundHandler()
+	}
+	trap := &honeypotIPs{seen: map[netip.Addr]time.Time{}, ttl: cfg.HoneypotTTL, now: time.Now}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ip := ClientIP(r)
+			for _, prefix := range cfg.Honeypots {
+				if strings.HasPrefix(r.URL.Path, prefix) {
+					trap.add(ip)
+					onHoneypot.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), botKey, BotReasonHoneypot)))
+					return
+				}
+			}
+
+			reason := ""
+			ua := strings.ToLower(r.UserAgent())
+			switch {
+			case strings.TrimSpace(ua) == "":
+				reason = BotReasonEmptyUserAgent
+			case trap.contains(ip):
+				reason = BotReasonHoneypot
+			default:
+				for _, p := range cfg.CrawlerPatterns {
+					if strings.Contains(ua, p) {
+						reason = BotReasonCrawler
+						break
+					}
+				}
+			}
+
+			if reason != "" {
+				r = r.WithContext(context.WithValue(r.Context(), botKey, reason))
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// IsBot reports whether [BotDetection] flagged the request as a bot.
+func IsBot(ctx context.Context) bool {
+	return BotReason(ctx) != ""
+}
+
+// BotReason returns why [BotDetection] flagged the request (one of the BotReason constants), or ""
+// for requests that look human.
+func BotReason(ctx context.Context) string {
+	reason, _ := ctx.Value(botKey).(string)
+	return reason
+}
+
+// honeypotIPs remembers the IPs that requested a honeypot until their TTL expires.
+type honeypotIPs struct {
+	mu   sync.Mutex
+	seen map[netip.Addr]time.Time
+	ttl  time.Duration
+	now  func() time.Time
+}
+
+func (h *honeypotIPs) add(ip netip.Addr) {
+	if !ip.IsValid() || h.ttl <= 0 {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	now := h.now()
+	if len(h.seen) >= maxHoneypotEntries {
+		for k, expiry := range h.seen {
+			if !now.Before(expiry) {
+				delete(h.seen, k)
+			}
+		}
+	}
+	if len(h.seen) < maxHoneypotEntries {
+		h.seen[ip] = now.Add(h.ttl)
+	}
+}
+
+func (h *honeypotIPs) contains(ip netip.Addr) bool {
+	if !ip.IsValid() {
+		return false
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	expiry, ok := h.seen[ip]
+	if ok && !h.now().Before(expiry) {
+		delete(h.seen, ip)
+		return false
+	}
+	return ok
+}
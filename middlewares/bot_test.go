@@ -0,0 +1,51 @@
+package middlewares_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ancalabrese/gotth/middlewares"
+)
+
+func TestBotDetection(t *testing.T) {
+	const browser = "Mozilla/5.0 (X11; Linux x86_64; rv:128.0) Gecko/20100101 Firefox/128.0"
+	handler := middlewares.BotDetection(middlewares.DefaultBotDetectionConfig())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Bot", middlewares.BotReason(r.Context()))
+	}))
+
+	// Steps run in order: the honeypot hit flags the IP for the later requests.
+	steps := []struct {
+		name       string
+		path       string
+		userAgent  string
+		remoteAddr string
+		wantStatus int
+		wantReason string
+	}{
+		{name: "Browser", path: "/", userAgent: browser, remoteAddr: "198.51.100.1:1", wantStatus: http.StatusOK},
+		{name: "Empty User-Agent", path: "/", userAgent: "", remoteAddr: "198.51.100.2:1", wantStatus: http.StatusOK, wantReason: middlewares.BotReasonEmptyUserAgent},
+		{name: "Crawler", path: "/", userAgent: "Mozilla/5.0 (compatible; Googlebot/2.1)", remoteAddr: "198.51.100.3:1", wantStatus: http.StatusOK, wantReason: middlewares.BotReasonCrawler},
+		{name: "HTTP library", path: "/", userAgent: "curl/8.5.0", remoteAddr: "198.51.100.3:1", wantStatus: http.StatusOK, wantReason: middlewares.BotReasonCrawler},
+		{name: "Honeypot", path: "/wp-login.php", userAgent: browser, remoteAddr: "198.51.100.4:1", wantStatus: http.StatusNotFound},
+		{name: "Same IP after honeypot", path: "/", userAgent: browser, remoteAddr: "198.51.100.4:2", wantStatus: http.StatusOK, wantReason: middlewares.BotReasonHoneypot},
+		{name: "Other IP after honeypot", path: "/", userAgent: browser, remoteAddr: "198.51.100.5:1", wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range steps {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			req.Header.Set("User-Agent", tt.userAgent)
+			req.RemoteAddr = tt.remoteAddr
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rr.Code, tt.wantStatus)
+			}
+			if got := rr.Header().Get("X-Bot"); got != tt.wantReason {
+				t.Errorf("reason = %q, want %q", got, tt.wantReason)
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package middlewares
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CachePolicy describes a Cache-Control header.
+type CachePolicy struct {
+	// Public allows shared caches (CDNs, proxies) to store the response; Private restricts it to
+	// the browser, e.g. for pages showing the logged in user.
+	Public  bool
+	Private bool
+	// NoStore forbids caching entirely. Other fields are ignored.
+	NoStore bool
+	// NoCache lets caches store the response but forces them to revalidate it before every use.
+	NoCache bool
+	// MaxAge is how long the response is fresh; SMaxAge overrides it for shared caches.
+	MaxAge  time.Duration
+	SMaxAge time.Duration
+	// StaleWhileRevalidate lets caches serve a stale response while they fetch a fresh one.
+	StaleWhileRevalidate time.Duration
+	// StaleIfError lets caches serve a stale response when the server fails.
+	StaleIfError time.Duration
+	// Immutable tells browsers the response never changes, e.g. for fingerprinted assets.
+	Immutable bool
+}
+
+// Common cache policies.
+var (
+	// CacheNoStore disables caching, e.g. for account pages and form responses.
+	CacheNoStore = CachePolicy{NoStore: true}
+	// CacheImmutable caches fingerprinted static assets for a year.
+	CacheImmutable = CachePolicy{Public: true, MaxAge: 365 * 24 * time.Hour, Immutable: true}
+	// CacheRevalidate lets caches store pages but check for changes on every use.
+	CacheRevalidate = CachePolicy{NoCache: true}
+)
+
+// String renders the header value, e.g. "public, max-age=60, stale-while-revalidate=30".
+func (p CachePolicy) String() string {
+	if p.NoStore {
+		return "no-store"
+	}
+
+	var parts []string
+	switch {
+	case p.Private:
+		parts = append(parts, "private")
+	case p.Public:
+		parts = append(parts, "public")
+	}
+	if p.NoCache {
+		parts = append(parts, "no-cache")
+	}
+	seconds := func(name string, d time.Duration) {
+		if d > 0 {
+			parts = append(parts, name+"="+strconv.FormatInt(int64(d.Seconds()), 10))
+		}
+	}
+	seconds("max-age", p.MaxAge)
+	if !p.Private {
+		seconds("s-maxage", p.SMaxAge)
+	}
+	seconds("stale-while-revalidate", p.StaleWhileRevalidate)
+	seconds("stale-if-error", p.StaleIfError)
+	if p.Immutable {
+		parts = append(parts, "immutable")
+	}
+	return strings.Join(parts, ", ")
+}
+
+// CacheControl returns a new middleware (http.Handler) that sets the Cache-Control header from
+// the policy whose pattern matches the request, so that caching is configured in one place:
+//
+//	middlewares.CacheControl(map[string]middlewares.CachePolicy{
+//		"GET /static/":  middlewares.CacheImmutable,
+//		"GET /blog/":    {Public: true, MaxAge: time.Minute, StaleWhileRevalidate: time.Hour},
+//		"/account/":     middlewares.CacheNoStore,
+//	})
+//
+// Patterns use the http.ServeMux syntax and precedence, and like ServeMux it panics on invalid or
+// conflicting patterns. Requests matching no pattern are left untouched. Handlers can still set
+// their own Cache-Control. 5xx responses are sent with "no-store" so that errors aren't cached.
+func CacheControl(policies map[string]CachePolicy) func(http.Handler) http.Handler {
+	matcher := http.NewServeMux()
+	headers := make(map[string]string, len(policies))
+	for pattern, policy := range policies {
+		matcher.Handle(pattern, http.NotFoundHandler())
+		headers[pattern] = policy.String()
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, pattern := matcher.Handler(r)
+			value, ok := headers[pattern]
+			if !ok || value == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Set("Cache-Control", value)
+			next.ServeHTTP(&cacheControlWriter{ResponseWriter: w}, r)
+		})
+	}
+}
+
+// cacheControlWriter replaces the Cache-Control header of server errors with "no-store".
+type cacheControlWriter struct {
+	http.ResponseWriter
+	wroteHeader bool
+}
+
+func (cw *cacheControlWriter) WriteHeader(status int) {
+	if !cw.wroteHeader {
+		cw.wroteHeader = true
+		if status >= http.StatusInternalServerError {
+			cw.Header().Set("Cache-Control", CacheNoStore.String())
+		}
+	}
+	cw.ResponseWriter.WriteHeader(status)
+}
+
+func (cw *cacheControlWriter) Write(b []byte) (int, error) {
+	cw.wroteHeader = true
+	return cw.ResponseWriter.Write(b)
+}
+
+func (cw *cacheControlWriter) Flush() {
+	cw.wroteHeader = true
+	http.NewResponseController(cw.ResponseWriter).Flush()
+}
+
+func (cw *cacheControlWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
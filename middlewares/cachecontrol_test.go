@@ -0,0 +1,82 @@
+package middlewares_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ancalabrese/gotth/middlewares"
+)
+
+func TestCachePolicy_String(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   middlewares.CachePolicy
+		expected string
+	}{
+		{name: "Empty", policy: middlewares.CachePolicy{}, expected: ""},
+		{name: "No store wins", policy: middlewares.CachePolicy{NoStore: true, Public: true, MaxAge: time.Hour}, expected: "no-store"},
+		{name: "Immutable", policy: middlewares.CacheImmutable, expected: "public, max-age=31536000, immutable"},
+		{name: "Revalidate", policy: middlewares.CacheRevalidate, expected: "no-cache"},
+		{
+			name:     "Shared cache with stale directives",
+			policy:   middlewares.CachePolicy{Public: true, MaxAge: time.Minute, SMaxAge: 5 * time.Minute, StaleWhileRevalidate: time.Hour, StaleIfError: 24 * time.Hour},
+			expected: "public, max-age=60, s-maxage=300, stale-while-revalidate=3600, stale-if-error=86400",
+		},
+		{name: "Private drops s-maxage", policy: middlewares.CachePolicy{Private: true, MaxAge: time.Minute, SMaxAge: time.Hour}, expected: "private, max-age=60"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.String(); got != tt.expected {
+				t.Errorf("String() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestCacheControl(t *testing.T) {
+	mw := middlewares.CacheControl(map[string]middlewares.CachePolicy{
+		"GET /static/":      middlewares.CacheImmutable,
+		"GET /blog/":        {Public: true, MaxAge: time.Minute},
+		"GET /blog/drafts/": middlewares.CacheNoStore,
+	})
+
+	tests := []struct {
+		name     string
+		method   string
+		path     string
+		status   int
+		preset   string
+		expected string
+	}{
+		{name: "Static asset", method: http.MethodGet, path: "/static/app.js", expected: "public, max-age=31536000, immutable"},
+		{name: "Most specific pattern wins", method: http.MethodGet, path: "/blog/drafts/1", expected: "no-store"},
+		{name: "Blog post", method: http.MethodGet, path: "/blog/hello", expected: "public, max-age=60"},
+		{name: "Method mismatch", method: http.MethodPost, path: "/blog/hello", expected: ""},
+		{name: "No match", method: http.MethodGet, path: "/about", expected: ""},
+		{name: "Handler override", method: http.MethodGet, path: "/blog/hello", preset: "private", expected: "private"},
+		{name: "Server error isn't cached", method: http.MethodGet, path: "/blog/hello", status: http.StatusInternalServerError, expected: "no-store"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.preset != "" {
+					w.Header().Set("Cache-Control", tt.preset)
+				}
+				if tt.status != 0 {
+					w.WriteHeader(tt.status)
+				}
+				w.Write([]byte("body"))
+			}))
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, httptest.NewRequest(tt.method, tt.path, nil))
+
+			if got := rr.Header().Get("Cache-Control"); got != tt.expected {
+				t.Errorf("Cache-Control = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
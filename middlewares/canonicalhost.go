@@ -0,0 +1,47 @@
+package middlewares
+
+import (
+	"net/http"
+	"strings"
+)
+
+// CanonicalHost returns a new middleware (http.Handler) that permanently redirects requests for
+// any other host (e.g., "www.example.com" or the bare IP) to host, so that search engines index
+// a single copy of the site. host is either a host, e.g. "example.com", keeping the scheme of
+// the request, or a URL forcing the scheme too, e.g. "https://example.com".
+// GET and HEAD requests get a 301, the others a 308 so that the method and body are kept.
+func CanonicalHost(host string) func(http.Handler) http.Handler {
+	scheme, canonical, ok := strings.Cut(host, "://")
+	if !ok {
+		scheme, canonical = "", host
+	}
+	canonical = strings.TrimSuffix(canonical, "/")
+
+	return func(next http.Handler) http.Handler {
+		if canonical == "" {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			reqScheme := "http"
+			if r.TLS != nil {
+				reqScheme = "https"
+			}
+			if strings.EqualFold(r.Host, canonical) && (scheme == "" || scheme == reqScheme) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			target := scheme
+			if target == "" {
+				target = reqScheme
+			}
+			target += "://" + canonical + r.URL.RequestURI()
+
+			status := http.StatusMovedPermanently
+			if r.Method != http.MethodGet && r.Method != http.MethodHead {
+				status = http.StatusPermanentRedirect
+			}
+			http.Redirect(w, r, target, status)
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package middlewares_test
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ancalabrese/gotth/middlewares"
+)
+
+func TestCanonicalHost(t *testing.T) {
+	tests := []struct {
+		name      string
+		canonical string
+		method    string
+		url       string
+		tls       bool
+		status    int
+		location  string
+	}{
+		{name: "Canonical host", canonical: "example.com", url: "http://example.com/a", status: http.StatusOK},
+		{name: "Case-insensitive", canonical: "example.com", url: "http://EXAMPLE.com/a", status: http.StatusOK},
+		{name: "Other host", canonical: "example.com", url: "http://www.example.com/a?b=c", status: http.StatusMovedPermanently, location: "http://example.com/a?b=c"},
+		{name: "Scheme is kept", canonical: "example.com", url: "https://www.example.com/", tls: true, status: http.StatusMovedPermanently, location: "https://example.com/"},
+		{name: "Scheme is forced", canonical: "https://example.com", url: "http://example.com/a", status: http.StatusMovedPermanently, location: "https://example.com/a"},
+		{name: "Forced scheme served", canonical: "https://example.com/", url: "https://example.com/a", tls: true, status: http.StatusOK},
+		{name: "POST keeps its method", canonical: "example.com", method: http.MethodPost, url: "http://www.example.com/form", status: http.StatusPermanentRedirect, location: "http://example.com/form"},
+		{name: "Disabled", canonical: "", url: "http://www.example.com/", status: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := middlewares.CanonicalHost(tt.canonical)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+			method := tt.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			req := httptest.NewRequest(method, tt.url, nil)
+			if tt.tls {
+				req.TLS = &tls.ConnectionState{}
+			} else {
+				req.TLS = nil
+			}
+
+			rr := httptest.NewRecorder()
+			h.ServeHTTP(rr, req)
+
+			if rr.Code != tt.status {
+				t.Errorf("status = %d, want %d", rr.Code, tt.status)
+			}
+			if got := rr.Header().Get("Location"); got != tt.location {
+				t.Errorf("Location = %q, want %q", got, tt.location)
+			}
+		})
+	}
+}
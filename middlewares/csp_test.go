@@ -0,0 +1,13 @@
+package middlewares_test
+
+import (
+	"testing"
+
+	"github.com/ancalabrese/gotth/middlewares"
+)
+
+func TestCSPHash(t *testing.T) {
+	if got, want := middlewares.CSPHash(""), "'sha256-47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU='"; got != want {
+		t.Errorf("CSPHash() = %q, want %q", got, want)
+	}
+}
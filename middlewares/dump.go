@@ -0,0 +1,165 @@
+package middlewares
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"net/textproto"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// DumpConfig configures the [Dump] middleware. Use [DefaultDumpConfig] as a starting point.
+type DumpConfig struct {
+	// Logger receives the dumps. Defaults to slog.Default() when nil.
+	Logger *slog.Logger
+	// Level of the dumps. Nothing is captured when the logger doesn't log it, so the dumps can be
+	// switched on with the log level.
+	Level slog.Level
+	// MaxBodyBytes caps the size of the dumped request and response bodies. Bodies are not dumped
+	// when 0. Only textual bodies (HTML, JSON, forms, ...) are dumped, others are summarized.
+	MaxBodyBytes int
+	// RedactHeaders lists the headers whose values are replaced with "[redacted]".
+	RedactHeaders []string
+	// Optional: Enabled reports whether to dump r, e.g. for the requests with a debug query
+	// parameter. All requests are dumped when nil.
+	Enabled func(r *http.Request) bool
+}
+
+// DefaultDumpConfig returns a DumpConfig dumping up to 4KB of the bodies at debug level, with
+// the credentials redacted.
+func DefaultDumpConfig() DumpConfig {
+	return DumpConfig{
+		Level:         slog.LevelDebug,
+		MaxBodyBytes:  4 << 10,
+		RedactHeaders: []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"},
+	}
+}
+
+// Dump returns a new middleware (http.Handler) that logs the full request and response of next,
+// headers and bodies, to debug a route: wrap a single route with it (e.g., as a ServeContent
+// middleware) or the whole server. Request bodies are read up to MaxBodyBytes and replayed to
+// next, so large uploads aren't buffered.
+// Dumps contain personal data: use them for debugging only and keep RedactHeaders up to date.
+func Dump(cfg DumpConfig) func(http.Handler) http.Handler {
+	redact := map[string]bool{}
+	for _, h := range cfg.RedactHeaders {
+		redact[textproto.CanonicalMIMEHeaderKey(h)] = true
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			logger := cfg.Logger
+			if logger == nil {
+				logger = slog.Default()
+			}
+			if !logger.Enabled(r.Context(), cfg.Level) || (cfg.Enabled != nil && !cfg.Enabled(r)) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			reqBody := peekBody(r, cfg.MaxBodyBytes)
+			reqHeaders := headerAttrs(r.Header, redact)
+			rec := &dumpRecorder{responseRecorder: newResponseRecorder(w), limit: cfg.MaxBodyBytes}
+
+			next.ServeHTTP(rec, r)
+
+			logger.Log(r.Context(), cfg.Level, "request dump",
+				slog.String("request_id", GetRequestID(r.Context())),
+				slog.Group("request",
+					slog.String("method", r.Method),
+					slog.String("url", r.URL.String()),
+					slog.String("proto", r.Proto),
+					slog.String("host", r.Host),
+					slog.Group("headers", reqHeaders...),
+					slog.String("body", bodyString(r.Header, reqBody, r.ContentLength, cfg.MaxBodyBytes)),
+				),
+				slog.Group("response",
+					slog.Int("status", rec.status),
+					slog.Group("headers", headerAttrs(rec.Header(), redact)...),
+					slog.String("body", bodyString(rec.Header(), rec.body.Bytes(), rec.bytes, cfg.MaxBodyBytes)),
+				),
+			)
+		})
+	}
+}
+
+// peekBody reads up to limit+1 bytes of the body of r and puts them back in front of the rest.
+func peekBody(r *http.Request, limit int) []byte {
+	if limit <= 0 || r.Body == nil || r.Body == http.NoBody {
+		return nil
+	}
+	peeked, _ := io.ReadAll(io.LimitReader(r.Body, int64(limit)+1))
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(peeked), r.Body), r.Body}
+	return peeked
+}
+
+// headerAttrs returns the headers as attributes, sorted by name, with the values of the redacted
+// ones replaced.
+func headerAttrs(h http.Header, redact map[string]bool) []any {
+	names := make([]string, 0, len(h))
+	for name := range h {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	attrs := make([]any, 0, len(names))
+	for _, name := range names {
+		value := strings.Join(h[name], ", ")
+		if redact[textproto.CanonicalMIMEHeaderKey(name)] {
+			value = "[redacted]"
+		}
+		attrs = append(attrs, slog.String(name, value))
+	}
+	return attrs
+}
+
+// bodyString returns the dumped body: its text, truncated to limit bytes, or a summary when it
+// isn't textual. total is the full size, or -1 when unknown.
+func bodyString(h http.Header, body []byte, total int64, limit int) string {
+	if len(body) == 0 {
+		return ""
+	}
+	if !isText(h.Get("Content-Type")) {
+		size := "?"
+		if total >= 0 {
+			size = strconv.FormatInt(total, 10)
+		}
+		return "[" + size + " bytes of " + h.Get("Content-Type") + "]"
+	}
+	if len(body) > limit {
+		return string(body[:limit]) + "...[truncated]"
+	}
+	return string(body)
+}
+
+func isText(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return contentType == "" // Sniffed by the server: most likely text
+	}
+	return strings.HasPrefix(mediaType, "text/") ||
+		strings.HasSuffix(mediaType, "json") || strings.HasSuffix(mediaType, "xml") ||
+		mediaType == "application/x-www-form-urlencoded" || mediaType == "application/javascript"
+}
+
+// dumpRecorder records the first limit+1 bytes of the response body, to tell whether it's
+// truncated.
+type dumpRecorder struct {
+	*responseRecorder
+	body  bytes.Buffer
+	limit int
+}
+
+func (d *dumpRecorder) Write(b []byte) (int, error) {
+	if room := d.limit + 1 - d.body.Len(); d.limit > 0 && room > 0 {
+		d.body.Write(b[:min(room, len(b))])
+	}
+	return d.responseRecorder.Write(b)
+}
@@ -0,0 +1,112 @@
+package middlewares_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ancalabrese/gotth/middlewares"
+)
+
+func TestDump(t *testing.T) {
+	var buf bytes.Buffer
+	cfg := middlewares.DefaultDumpConfig()
+	cfg.Logger = slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	cfg.MaxBodyBytes = 16
+
+	h := middlewares.Dump(cfg)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Header().Set("Set-Cookie", "session=secret")
+		w.WriteHeader(http.StatusCreated)
+		io.WriteString(w, "<p>"+string(body)+"</p>")
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/contact?x=1", strings.NewReader("name=Ada&message=Hello+there"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Cookie", "session=secret")
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+
+	if got := rr.Body.String(); got != "<p>name=Ada&message=Hello+there</p>" {
+		t.Errorf("handler didn't get the whole request body: %q", got)
+	}
+
+	var dump struct {
+		Msg     string
+		Request struct {
+			Method, URL, Body string
+			Headers           map[string]string
+		}
+		Response struct {
+			Status  int
+			Body    string
+			Headers map[string]string
+		}
+	}
+	if err := json.Unmarshal(buf.Bytes(), &dump); err != nil {
+		t.Fatalf("invalid dump %q. err %v", buf.String(), err)
+	}
+	if dump.Msg != "request dump" || dump.Request.Method != http.MethodPost || dump.Request.URL != "/contact?x=1" {
+		t.Errorf("dump = %+v", dump)
+	}
+	if dump.Request.Body != "name=Ada&message...[truncated]" {
+		t.Errorf("request body = %q", dump.Request.Body)
+	}
+	if dump.Request.Headers["Cookie"] != "[redacted]" || dump.Response.Headers["Set-Cookie"] != "[redacted]" {
+		t.Errorf("cookies not redacted: %+v %+v", dump.Request.Headers, dump.Response.Headers)
+	}
+	if dump.Response.Status != http.StatusCreated || dump.Response.Body != "<p>name=Ada&mess...[truncated]" {
+		t.Errorf("response = %+v", dump.Response)
+	}
+}
+
+func TestDump_BinaryBody(t *testing.T) {
+	var buf bytes.Buffer
+	cfg := middlewares.DefaultDumpConfig()
+	cfg.Logger = slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	h := middlewares.Dump(cfg)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(make([]byte, 10000))
+	}))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/logo.png", nil))
+
+	if !strings.Contains(buf.String(), `response.body="[10000 bytes of image/png]"`) {
+		t.Errorf("binary body not summarized: %s", buf.String())
+	}
+}
+
+func TestDump_Disabled(t *testing.T) {
+	tests := []struct {
+		name    string
+		level   slog.Level
+		enabled func(r *http.Request) bool
+	}{
+		{name: "Level not logged", level: slog.LevelInfo},
+		{name: "Enabled returns false", level: slog.LevelDebug, enabled: func(r *http.Request) bool { return r.URL.Query().Has("debug") }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			cfg := middlewares.DefaultDumpConfig()
+			cfg.Logger = slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: tt.level}))
+			cfg.Enabled = tt.enabled
+
+			h := middlewares.Dump(cfg)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				io.WriteString(w, "ok")
+			}))
+			rr := httptest.NewRecorder()
+			h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			if rr.Body.String() != "ok" || buf.Len() != 0 {
+				t.Errorf("body = %q, logs = %q, want ok and no dump", rr.Body.String(), buf.String())
+			}
+		})
+	}
+}
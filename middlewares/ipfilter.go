@@ -0,0 +1,60 @@
+package middlewares
+
+import (
+	"errors"
+	"net/http"
+	"net/netip"
+)
+
+// ErrIPDenied is passed to the onDenied callback of [IPFilter].
+var ErrIPDenied = errors.New("client IP not allowed")
+
+// IPFilterConfig holds the networks of an [IPFilter]. Use [ParseCIDRs] to build them.
+type IPFilterConfig struct {
+	// Allow, when not empty, admits only clients in these networks.
+	Allow []netip.Prefix
+	// Deny rejects clients in these networks, even when they're in Allow.
+	Deny []netip.Prefix
+}
+
+// IPFilter returns a new middleware (http.Handler) that admits requests by client IP, e.g. to
+// lock an admin area to office ranges:
+//
+//	office, _ := middlewares.ParseCIDRs("203.0.113.0/24")
+//	ws.Handle("/admin/", middlewares.IPFilter(middlewares.IPFilterConfig{Allow: office}, nil)(admin))
+//
+// The client IP is the one resolved by [RealIP], or the remote address without it; put RealIP
+// first when running behind a proxy. Requests whose IP can't be determined are denied.
+// onDenied is called with [ErrIPDenied]; when nil, a plain 403 is sent.
+func IPFilter(cfg IPFilterConfig, onDenied func(http.ResponseWriter, *http.Request, error)) func(http.Handler) http.Handler {
+	if onDenied == nil {
+		onDenied = func(w http.ResponseWriter, r *http.Request, err error) {
+			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		}
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !cfg.allowed(ClientIP(r)) {
+				onDenied(w, r, ErrIPDenied)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func (cfg IPFilterConfig) allowed(ip netip.Addr) bool {
+	if !ip.IsValid() || containsIP(cfg.Deny, ip) {
+		return false
+	}
+	return len(cfg.Allow) == 0 || containsIP(cfg.Allow, ip)
+}
+
+func containsIP(prefixes []netip.Prefix, ip netip.Addr) bool {
+	for _, p := range prefixes {
+		if p.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,80 @@
+package middlewares_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/ancalabrese/gotth/middlewares"
+)
+
+func TestIPFilter(t *testing.T) {
+	mustParse := func(cidrs ...string) []netip.Prefix {
+		p, err := middlewares.ParseCIDRs(cidrs...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+
+	tests := []struct {
+		name       string
+		cfg        middlewares.IPFilterConfig
+		remoteAddr string
+		allowed    bool
+	}{
+		{name: "Empty config allows everyone", cfg: middlewares.IPFilterConfig{}, remoteAddr: "198.51.100.1:1", allowed: true},
+		{name: "In allow list", cfg: middlewares.IPFilterConfig{Allow: mustParse("203.0.113.0/24")}, remoteAddr: "203.0.113.9:1", allowed: true},
+		{name: "Not in allow list", cfg: middlewares.IPFilterConfig{Allow: mustParse("203.0.113.0/24")}, remoteAddr: "198.51.100.1:1", allowed: false},
+		{name: "Deny wins over allow", cfg: middlewares.IPFilterConfig{Allow: mustParse("203.0.113.0/24"), Deny: mustParse("203.0.113.9")}, remoteAddr: "203.0.113.9:1", allowed: false},
+		{name: "In deny list", cfg: middlewares.IPFilterConfig{Deny: mustParse("2001:db8::/32")}, remoteAddr: "[2001:db8::1]:1", allowed: false},
+		{name: "Unknown IP denied", cfg: middlewares.IPFilterConfig{}, remoteAddr: "garbage", allowed: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var deniedErr error
+			handler := middlewares.IPFilter(tt.cfg, func(w http.ResponseWriter, r *http.Request, err error) {
+				deniedErr = err
+				w.WriteHeader(http.StatusNotFound)
+			})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+			req := httptest.NewRequest(http.MethodGet, "/admin/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			if allowed := rr.Code == http.StatusOK; allowed != tt.allowed {
+				t.Errorf("allowed = %v, want %v", allowed, tt.allowed)
+			}
+			if !tt.allowed && !errors.Is(deniedErr, middlewares.ErrIPDenied) {
+				t.Errorf("onDenied error = %v, want %v", deniedErr, middlewares.ErrIPDenied)
+			}
+		})
+	}
+}
+
+func TestIPFilter_UsesRealIP(t *testing.T) {
+	proxies, _ := middlewares.ParseCIDRs("10.0.0.0/8")
+	office, _ := middlewares.ParseCIDRs("203.0.113.0/24")
+	handler := middlewares.RealIP(middlewares.RealIPConfig{TrustedProxies: proxies})(
+		middlewares.IPFilter(middlewares.IPFilterConfig{Allow: office}, nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "10.0.0.1:1"
+	req.Header.Set("X-Forwarded-For", "203.0.113.5")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Errorf("office client behind proxy: status = %d", rr.Code)
+	}
+
+	req.Header.Set("X-Forwarded-For", "198.51.100.1")
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusForbidden {
+		t.Errorf("outside client behind proxy: status = %d", rr.Code)
+	}
+}
@@ -0,0 +1,110 @@
+package middlewares
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"time"
+)
+
+const requestLogKey contextRequestLogKeyType = "gotth_request_log_key"
+
+type contextRequestLogKeyType string
+
+// requestLog collects data for the access log from handlers further down the chain, which can't
+// change the request seen by the logging middleware.
+type requestLog struct {
+	user any
+}
+
+// LoggingConfig configures the [Logging] middleware. Use [DefaultLoggingConfig] as a starting
+// point.
+type LoggingConfig struct {
+	// Logger receives the access log. Defaults to slog.Default() when nil.
+	Logger *slog.Logger
+	// SampleRate is the fraction, between 0 and 1, of successful requests that are logged. Every
+	// request is logged when it's 0 or less, or 1 and more. Requests answered with a 5xx status
+	// are always logged.
+	SampleRate float64
+	// UserID returns the ID to log for the user set by the session middlewares (see [GetUser]).
+	// When nil, users implementing fmt.Stringer are logged with String() and others are omitted.
+	UserID func(user any) string
+}
+
+// DefaultLoggingConfig returns a LoggingConfig that logs every request to slog.Default().
+func DefaultLoggingConfig() LoggingConfig {
+	return LoggingConfig{SampleRate: 1}
+}
+
+// Logging returns a new middleware (http.Handler) that writes an access log entry per request
+// with method, path, status, bytes written, duration, request ID (see [RequestID]), client IP
+// (see [RealIP]) and user ID.
+// 5xx responses are logged at error level, 4xx at warn level and the rest at info level.
+func Logging(cfg LoggingConfig) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			info := &requestLog{}
+			rec := newResponseRecorder(w)
+
+			next.ServeHTTP(rec, r.WithContext(context.WithValue(r.Context(), requestLogKey, info)))
+
+			if rec.status < http.StatusInternalServerError && cfg.SampleRate > 0 && cfg.SampleRate < 1 && rand.Float64() >= cfg.SampleRate {
+				return
+			}
+
+			level := slog.LevelInfo
+			switch {
+			case rec.status >= http.StatusInternalServerError:
+				level = slog.LevelError
+			case rec.status >= http.StatusBadRequest:
+				level = slog.LevelWarn
+			}
+
+			attrs := []slog.Attr{
+				slog.String("method", r.Method),
+				slog.String("path", r.URL.Path),
+				slog.Int("status", rec.status),
+				slog.Int64("bytes", rec.bytes),
+				slog.Duration("duration", time.Since(start)),
+			}
+			if id := GetRequestID(r.Context()); id != "" {
+				attrs = append(attrs, slog.String("request_id", id))
+			}
+			if ip := GetClientIP(r.Context()); ip.IsValid() {
+				attrs = append(attrs, slog.String("client_ip", ip.String()))
+			}
+			if id := cfg.userID(info.user); id != "" {
+				attrs = append(attrs, slog.String("user_id", id))
+			}
+
+			logger := cfg.Logger
+			if logger == nil {
+				logger = slog.Default()
+			}
+			logger.LogAttrs(r.Context(), level, "request", attrs...)
+		})
+	}
+}
+
+func (cfg LoggingConfig) userID(user any) string {
+	if user == nil {
+		return ""
+	}
+	if cfg.UserID != nil {
+		return cfg.UserID(user)
+	}
+	if s, ok := user.(fmt.Stringer); ok {
+		return s.String()
+	}
+	return ""
+}
+
+// logUser records the authenticated user of the request for the [Logging] middleware.
+func logUser(ctx context.Context, user any) {
+	if info, ok := ctx.Value(requestLogKey).(*requestLog); ok {
+		info.user = user
+	}
+}
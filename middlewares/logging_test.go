@@ -0,0 +1,177 @@
+package middlewares_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ancalabrese/gotth/middlewares"
+	"github.com/ancalabrese/gotth/middlewares/sessiontest"
+)
+
+type stringerUser struct{ ID string }
+
+func (u stringerUser) String() string { return u.ID }
+
+func TestLogging(t *testing.T) {
+	tests := []struct {
+		name       string
+		cfg        func(*middlewares.LoggingConfig)
+		handler    http.HandlerFunc
+		user       any
+		wantLogged bool
+		wantLevel  string
+		wantStatus float64
+		wantBytes  float64
+		wantUser   string
+	}{
+		{
+			name:       "Success is logged at info level",
+			handler:    func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("hello")) },
+			wantLogged: true,
+			wantLevel:  "INFO",
+			wantStatus: 200,
+			wantBytes:  5,
+		},
+		{
+			name:       "Client error is logged at warn level",
+			handler:    func(w http.ResponseWriter, r *http.Request) { http.NotFound(w, r) },
+			wantLogged: true,
+			wantLevel:  "WARN",
+			wantStatus: 404,
+			wantBytes:  19,
+		},
+		{
+			name:       "Stringer user is logged",
+			handler:    func(w http.ResponseWriter, r *http.Request) {},
+			user:       stringerUser{ID: "u-42"},
+			wantLogged: true,
+			wantLevel:  "INFO",
+			wantStatus: 200,
+			wantUser:   "u-42",
+		},
+		{
+			name: "Custom UserID",
+			cfg: func(c *middlewares.LoggingConfig) {
+				c.UserID = func(u any) string { return fmt.Sprint(u.(*mockUser).ID) }
+			},
+			handler:    func(w http.ResponseWriter, r *http.Request) {},
+			user:       &mockUser{ID: "u-7"},
+			wantLogged: true,
+			wantLevel:  "INFO",
+			wantStatus: 200,
+			wantUser:   "u-7",
+		},
+		{
+			name:    "Sampled out",
+			cfg:     func(c *middlewares.LoggingConfig) { c.SampleRate = math.SmallestNonzeroFloat64 },
+			handler: func(w http.ResponseWriter, r *http.Request) {},
+		},
+		{
+			name:       "Zero SampleRate logs every request",
+			cfg:        func(c *middlewares.LoggingConfig) { *c = middlewares.LoggingConfig{Logger: c.Logger} },
+			handler:    func(w http.ResponseWriter, r *http.Request) {},
+			wantLogged: true,
+			wantLevel:  "INFO",
+			wantStatus: 200,
+		},
+		{
+			name:       "Server errors bypass sampling",
+			cfg:        func(c *middlewares.LoggingConfig) { c.SampleRate = math.SmallestNonzeroFloat64 },
+			handler:    func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusBadGateway) },
+			wantLogged: true,
+			wantLevel:  "ERROR",
+			wantStatus: 502,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			cfg := middlewares.DefaultLoggingConfig()
+			cfg.Logger = slog.New(slog.NewJSONHandler(&buf, nil))
+			if tt.cfg != nil {
+				tt.cfg(&cfg)
+			}
+
+			var handler http.Handler = tt.handler
+			if tt.user != nil {
+				store := sessiontest.New().WithAnyUser(tt.user)
+				handler = middlewares.SessionCheck(store, true, func(w http.ResponseWriter, r *http.Request, err error) {
+					t.Fatalf("unexpected session error %v", err)
+				})(handler)
+			}
+			handler = middlewares.RequestID(middlewares.Logging(cfg)(handler))
+
+			req := httptest.NewRequest(http.MethodGet, "/some/path?q=1", nil)
+			req.Header.Set(middlewares.REQUEST_ID_HEADER, "req-1")
+			req.AddCookie(&http.Cookie{Name: middlewares.SESSION_COOKIE_NAME, Value: "session"})
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			if !tt.wantLogged {
+				if buf.Len() != 0 {
+					t.Fatalf("expected no log, got %s", buf.String())
+				}
+				return
+			}
+
+			var entry map[string]any
+			if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+				t.Fatalf("invalid log entry %q: %v", buf.String(), err)
+			}
+			if entry["level"] != tt.wantLevel || entry["status"] != tt.wantStatus || entry["bytes"] != tt.wantBytes {
+				t.Errorf("level/status/bytes = %v/%v/%v, want %v/%v/%v", entry["level"], entry["status"], entry["bytes"], tt.wantLevel, tt.wantStatus, tt.wantBytes)
+			}
+			if entry["method"] != "GET" || entry["path"] != "/some/path" || entry["request_id"] != "req-1" {
+				t.Errorf("unexpected method/path/request_id in %v", entry)
+			}
+			if _, ok := entry["duration"]; !ok {
+				t.Error("missing duration")
+			}
+			if user, _ := entry["user_id"].(string); user != tt.wantUser {
+				t.Errorf("user_id = %q, want %q", user, tt.wantUser)
+			}
+		})
+	}
+}
+
+func TestRequestID(t *testing.T) {
+	tests := []struct {
+		name     string
+		incoming string
+		wantSame bool
+	}{
+		{name: "Generated when missing", incoming: "", wantSame: false},
+		{name: "Incoming ID reused", incoming: "abc-123", wantSame: true},
+		{name: "Invalid incoming ID replaced", incoming: "bad id\n", wantSame: false},
+		{name: "Oversized incoming ID replaced", incoming: strings.Repeat("a", 200), wantSame: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var seen string
+			handler := middlewares.RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				seen = middlewares.GetRequestID(r.Context())
+			}))
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.incoming != "" {
+				req.Header.Set(middlewares.REQUEST_ID_HEADER, tt.incoming)
+			}
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			if seen == "" || rr.Header().Get(middlewares.REQUEST_ID_HEADER) != seen {
+				t.Fatalf("request ID %q, header %q", seen, rr.Header().Get(middlewares.REQUEST_ID_HEADER))
+			}
+			if (seen == tt.incoming) != tt.wantSame {
+				t.Errorf("request ID = %q, incoming %q, wantSame %v", seen, tt.incoming, tt.wantSame)
+			}
+		})
+	}
+}
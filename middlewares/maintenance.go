@@ -0,0 +1,80 @@
+package middlewares
+
+import (
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/a-h/templ"
+)
+
+// MaintenanceConfig configures the [Maintenance] middleware.
+type MaintenanceConfig struct {
+	// Enabled reports whether the site is in maintenance. Use a [MaintenanceSwitch],
+	// [MaintenanceFile] or your own callback (e.g., reading a feature flag).
+	Enabled func(r *http.Request) bool
+	// RetryAfter is sent in the Retry-After header when greater than zero.
+	RetryAfter time.Duration
+	// Allow lists the path prefixes still served during maintenance, e.g. "/static/" or
+	// "/healthz".
+	Allow []string
+	// Optional: Page returns the page rendered with status 503. A plain "Service Unavailable" is
+	// sent when nil and for HTMX fragments.
+	Page func(r *http.Request) templ.Component
+}
+
+// Maintenance returns a new middleware (http.Handler) that answers every request, except those
+// matching cfg.Allow, with a 503 maintenance page while cfg.Enabled reports true.
+func Maintenance(cfg MaintenanceConfig) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if cfg.Enabled == nil || !cfg.Enabled(r) || cfg.allowed(r.URL.Path) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if cfg.RetryAfter > 0 {
+				w.Header().Set("Retry-After", strconv.Itoa(int(cfg.RetryAfter.Seconds())))
+			}
+			w.Header().Set("Cache-Control", "no-store")
+			WriteErrorPage(w, r, http.StatusServiceUnavailable, cfg.Page)
+		})
+	}
+}
+
+func (cfg MaintenanceConfig) allowed(path string) bool {
+	for _, prefix := range cfg.Allow {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// MaintenanceSwitch is a maintenance flag that can be flipped at runtime, e.g. from an admin
+// endpoint. The zero value is off.
+type MaintenanceSwitch struct {
+	on atomic.Bool
+}
+
+// Set turns maintenance mode on or off.
+func (s *MaintenanceSwitch) Set(on bool) {
+	s.on.Store(on)
+}
+
+// Enabled can be used as [MaintenanceConfig.Enabled].
+func (s *MaintenanceSwitch) Enabled(r *http.Request) bool {
+	return s.on.Load()
+}
+
+// MaintenanceFile returns a [MaintenanceConfig.Enabled] callback that reports true while the file
+// at path exists, so that deploy scripts can toggle maintenance with touch and rm.
+func MaintenanceFile(path string) func(r *http.Request) bool {
+	return func(r *http.Request) bool {
+		_, err := os.Stat(path)
+		return err == nil
+	}
+}
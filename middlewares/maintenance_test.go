@@ -0,0 +1,92 @@
+package middlewares_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/a-h/templ"
+	"github.com/ancalabrese/gotth/middlewares"
+)
+
+func TestMaintenance(t *testing.T) {
+	var sw middlewares.MaintenanceSwitch
+	cfg := middlewares.MaintenanceConfig{
+		Enabled:    sw.Enabled,
+		RetryAfter: 10 * time.Minute,
+		Allow:      []string{"/static/", "/healthz"},
+		Page: func(r *http.Request) templ.Component {
+			return templ.ComponentFunc(func(ctx context.Context, w io.Writer) error {
+				_, err := io.WriteString(w, "<h1>Back soon</h1>")
+				return err
+			})
+		},
+	}
+	handler := middlewares.Maintenance(cfg)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+
+	tests := []struct {
+		name           string
+		on             bool
+		path           string
+		headers        map[string]string
+		wantStatus     int
+		wantBody       string
+		wantRetryAfter string
+	}{
+		{name: "Off", on: false, path: "/", wantStatus: http.StatusOK, wantBody: "ok"},
+		{name: "On", on: true, path: "/", wantStatus: http.StatusServiceUnavailable, wantBody: "<h1>Back soon</h1>", wantRetryAfter: "600"},
+		{name: "On, allowed prefix", on: true, path: "/static/style.css", wantStatus: http.StatusOK, wantBody: "ok"},
+		{name: "On, allowed path", on: true, path: "/healthz", wantStatus: http.StatusOK, wantBody: "ok"},
+		{
+			name:           "On, HTMX fragment",
+			on:             true,
+			path:           "/fragments/clock",
+			headers:        map[string]string{"HX-Request": "true"},
+			wantStatus:     http.StatusServiceUnavailable,
+			wantBody:       "Service Unavailable\n",
+			wantRetryAfter: "600",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sw.Set(tt.on)
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != tt.wantStatus || rr.Body.String() != tt.wantBody {
+				t.Errorf("got %d %q, want %d %q", rr.Code, rr.Body.String(), tt.wantStatus, tt.wantBody)
+			}
+			if got := rr.Header().Get("Retry-After"); got != tt.wantRetryAfter {
+				t.Errorf("Retry-After = %q, want %q", got, tt.wantRetryAfter)
+			}
+		})
+	}
+}
+
+func TestMaintenanceFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "maintenance")
+	enabled := middlewares.MaintenanceFile(path)
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+
+	if enabled(req) {
+		t.Error("enabled without the file")
+	}
+	if err := os.WriteFile(path, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if !enabled(req) {
+		t.Error("disabled with the file")
+	}
+}
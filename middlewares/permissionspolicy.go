@@ -0,0 +1,102 @@
+package middlewares
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// PermissionsPolicy builds a Permissions-Policy header value. Features are rendered in the order
+// they were first set. The zero value is an empty policy.
+//
+//	pp := middlewares.DefaultPermissionsPolicy().Set("camera", "self")
+type PermissionsPolicy struct {
+	features []permissionsFeature
+}
+
+type permissionsFeature struct {
+	name      string
+	allowlist []string
+}
+
+// DefaultPermissionsPolicy returns a policy disabling the powerful features most sites don't
+// use: camera, microphone, geolocation, payment, USB and FLoC.
+func DefaultPermissionsPolicy() PermissionsPolicy {
+	return PermissionsPolicy{}.
+		Set("camera").
+		Set("microphone").
+		Set("geolocation").
+		Set("payment").
+		Set("usb").
+		Set("interest-cohort")
+}
+
+// Set replaces the allowlist of feature and returns the updated policy. Allowlist entries are
+// "self", "*" or origins such as "https://meet.example.com"; an empty allowlist disables the
+// feature. An existing feature keeps its position.
+func (p PermissionsPolicy) Set(feature string, allowlist ...string) PermissionsPolicy {
+	feature = strings.ToLower(strings.TrimSpace(feature))
+	out := PermissionsPolicy{features: make([]permissionsFeature, 0, len(p.features)+1)}
+	found := false
+	for _, f := range p.features {
+		if f.name == feature {
+			f = permissionsFeature{name: feature, allowlist: allowlist}
+			found = true
+		}
+		out.features = append(out.features, permissionsFeature{name: f.name, allowlist: append([]string(nil), f.allowlist...)})
+	}
+	if !found {
+		out.features = append(out.features, permissionsFeature{name: feature, allowlist: append([]string(nil), allowlist...)})
+	}
+	return out
+}
+
+// String renders the header value, e.g. `camera=(self "https://meet.example.com"), usb=()`.
+func (p PermissionsPolicy) String() string {
+	parts := make([]string, 0, len(p.features))
+	for _, f := range p.features {
+		items := make([]string, 0, len(f.allowlist))
+		for _, a := range f.allowlist {
+			switch a {
+			case "self", "*", "src":
+				items = append(items, a)
+			default:
+				items = append(items, strconv.Quote(a))
+			}
+		}
+		if len(items) == 1 && items[0] == "*" {
+			parts = append(parts, f.name+"=*")
+			continue
+		}
+		parts = append(parts, f.name+"=("+strings.Join(items, " ")+")")
+	}
+	return strings.Join(parts, ", ")
+}
+
+// SecurityHeadersOverride holds the security headers a route sends instead of the global
+// [SecurityHeadersConfig]. Empty fields keep the global value.
+type SecurityHeadersOverride struct {
+	PermissionsPolicy string
+	ReferrerPolicy    string
+}
+
+// OverrideSecurityHeaders returns a new middleware (http.Handler) that replaces the security
+// headers set by [SecurityHeaders] for a single route. Pass it to ServeContent or wrap the
+// handler, e.g. to allow the camera on a video call page only:
+//
+//	ws.ServeContent("GET /video-call", videoCall, middlewares.OverrideSecurityHeaders(middlewares.SecurityHeadersOverride{
+//		PermissionsPolicy: middlewares.DefaultPermissionsPolicy().Set("camera", "self").Set("microphone", "self").String(),
+//	}))
+func OverrideSecurityHeaders(o SecurityHeadersOverride) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if o.PermissionsPolicy != "" {
+				w.Header().Set("Permissions-Policy", o.PermissionsPolicy)
+			}
+			if o.ReferrerPolicy != "" {
+				w.Header().Set("Referrer-Policy", o.ReferrerPolicy)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package middlewares_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ancalabrese/gotth/middlewares"
+)
+
+func TestPermissionsPolicy(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   middlewares.PermissionsPolicy
+		expected string
+	}{
+		{name: "Empty", policy: middlewares.PermissionsPolicy{}, expected: ""},
+		{
+			name:     "Default",
+			policy:   middlewares.DefaultPermissionsPolicy(),
+			expected: "camera=(), microphone=(), geolocation=(), payment=(), usb=(), interest-cohort=()",
+		},
+		{
+			name:     "Set keeps position and quotes origins",
+			policy:   middlewares.DefaultPermissionsPolicy().Set("camera", "self", "https://meet.example.com").Set("fullscreen", "*"),
+			expected: `camera=(self "https://meet.example.com"), microphone=(), geolocation=(), payment=(), usb=(), interest-cohort=(), fullscreen=*`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.String(); got != tt.expected {
+				t.Errorf("String() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestOverrideSecurityHeaders(t *testing.T) {
+	global := middlewares.SecurityHeaders(middlewares.DefaultSecurityHeadersConfig())
+	mux := http.NewServeMux()
+	mux.Handle("GET /video-call", middlewares.OverrideSecurityHeaders(middlewares.SecurityHeadersOverride{
+		PermissionsPolicy: middlewares.DefaultPermissionsPolicy().Set("camera", "self").String(),
+		ReferrerPolicy:    "no-referrer",
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})))
+	mux.Handle("GET /", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	handler := global(mux)
+
+	tests := []struct {
+		path            string
+		wantPermissions string
+		wantReferrer    string
+	}{
+		{
+			path:            "/video-call",
+			wantPermissions: "camera=(self), microphone=(), geolocation=(), payment=(), usb=(), interest-cohort=()",
+			wantReferrer:    "no-referrer",
+		},
+		{
+			path:            "/",
+			wantPermissions: middlewares.DefaultPermissionsPolicy().String(),
+			wantReferrer:    "strict-origin-when-cross-origin",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if got := rr.Header().Get("Permissions-Policy"); got != tt.wantPermissions {
+				t.Errorf("Permissions-Policy = %q, want %q", got, tt.wantPermissions)
+			}
+			if got := rr.Header().Get("Referrer-Policy"); got != tt.wantReferrer {
+				t.Errorf("Referrer-Policy = %q, want %q", got, tt.wantReferrer)
+			}
+		})
+	}
+}
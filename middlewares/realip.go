@@ -0,0 +1,152 @@
+package middlewares
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+const clientIPKey contextClientIPKeyType = "gotth_client_ip_key"
+
+type contextClientIPKeyType string
+
+// RealIPConfig configures the [RealIP] middleware.
+type RealIPConfig struct {
+	// TrustedProxies lists the networks of the proxies and load balancers in front of the server.
+	// Forwarding headers are only honoured on connections from these networks, because anyone
+	// else can set them to any value. Use [ParseTrustedProxies] to build it from CIDR strings.
+	TrustedProxies []netip.Prefix
+}
+
+// ParseTrustedProxies parses CIDRs (e.g., "10.0.0.0/8") and single IPs into prefixes for
+// [RealIPConfig.TrustedProxies].
+func ParseTrustedProxies(cidrs ...string) ([]netip.Prefix, error) {
+	return ParseCIDRs(cidrs...)
+}
+
+// ParseCIDRs parses CIDRs (e.g., "10.0.0.0/8") and single IPs, which match only themselves,
+// into prefixes.
+func ParseCIDRs(cidrs ...string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		cidr = strings.TrimSpace(cidr)
+		if !strings.Contains(cidr, "/") {
+			addr, err := netip.ParseAddr(cidr)
+			if err != nil {
+				return nil, fmt.Errorf("invalid CIDR %q. err %w", cidr, err)
+			}
+			prefixes = append(prefixes, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR %q. err %w", cidr, err)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
+}
+
+// RealIP returns a new middleware (http.Handler) that resolves the IP of the client and makes it
+// available via [GetClientIP].
+// When the connection comes from a trusted proxy, the first header found among Forwarded,
+// X-Forwarded-For and X-Real-IP is walked from the nearest hop back, skipping trusted proxies;
+// the first untrusted address is the client. Otherwise the connection's remote address is used.
+func RealIP(cfg RealIPConfig) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ip := cfg.clientIP(r)
+			if !ip.IsValid() {
+				next.ServeHTTP(w, r)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), clientIPKey, ip)))
+		})
+	}
+}
+
+// GetClientIP returns the client IP resolved by [RealIP]. Without the middleware, it returns the
+// zero netip.Addr; see [ClientIP] for a fallback to the remote address.
+func GetClientIP(ctx context.Context) netip.Addr {
+	ip, _ := ctx.Value(clientIPKey).(netip.Addr)
+	return ip
+}
+
+// ClientIP returns the client IP resolved by [RealIP] or, without the middleware, the remote
+// address of the connection.
+func ClientIP(r *http.Request) netip.Addr {
+	if ip := GetClientIP(r.Context()); ip.IsValid() {
+		return ip
+	}
+	return parseHostPort(r.RemoteAddr)
+}
+
+func (cfg RealIPConfig) clientIP(r *http.Request) netip.Addr {
+	remote := parseHostPort(r.RemoteAddr)
+	if !remote.IsValid() || !cfg.trusted(remote) {
+		return remote
+	}
+
+	var hops []string
+	switch {
+	case r.Header.Get("Forwarded") != "":
+		hops = forwardedFor(r.Header.Values("Forwarded"))
+	case r.Header.Get("X-Forwarded-For") != "":
+		for _, v := range r.Header.Values("X-Forwarded-For") {
+			hops = append(hops, strings.Split(v, ",")...)
+		}
+	case r.Header.Get("X-Real-IP") != "":
+		hops = []string{r.Header.Get("X-Real-IP")}
+	}
+
+	client := remote
+	for i := len(hops) - 1; i >= 0; i-- {
+		ip := parseHostPort(strings.TrimSpace(hops[i]))
+		if !ip.IsValid() {
+			// Obfuscated or garbled hop: nothing before it can be trusted.
+			break
+		}
+		client = ip
+		if !cfg.trusted(ip) {
+			break
+		}
+	}
+	return client
+}
+
+func (cfg RealIPConfig) trusted(ip netip.Addr) bool {
+	return containsIP(cfg.TrustedProxies, ip)
+}
+
+// forwardedFor extracts the for= parameters of RFC 7239 Forwarded headers, in order.
+func forwardedFor(values []string) []string {
+	var hops []string
+	for _, v := range values {
+		for _, element := range strings.Split(v, ",") {
+			for _, pair := range strings.Split(element, ";") {
+				key, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+				if ok && strings.EqualFold(key, "for") {
+					hops = append(hops, strings.Trim(value, `"`))
+				}
+			}
+		}
+	}
+	return hops
+}
+
+// parseHostPort parses "ip", "ip:port", "[ipv6]" and "[ipv6]:port". IPv4-mapped IPv6 addresses are
+// unmapped.
+func parseHostPort(s string) netip.Addr {
+	if host, _, err := net.SplitHostPort(s); err == nil {
+		s = host
+	}
+	s = strings.TrimSuffix(strings.TrimPrefix(s, "["), "]")
+	ip, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Addr{}
+	}
+	return ip.Unmap()
+}
@@ -0,0 +1,108 @@
+package middlewares_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ancalabrese/gotth/middlewares"
+)
+
+func TestRealIP(t *testing.T) {
+	trusted, err := middlewares.ParseTrustedProxies("10.0.0.0/8", "192.168.1.1", "2001:db8::/32")
+	if err != nil {
+		t.Fatalf("ParseTrustedProxies() error = %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		headers    map[string]string
+		expected   string
+	}{
+		{
+			name:       "Direct connection",
+			remoteAddr: "203.0.113.7:1234",
+			expected:   "203.0.113.7",
+		},
+		{
+			name:       "Untrusted peer can't spoof X-Forwarded-For",
+			remoteAddr: "203.0.113.7:1234",
+			headers:    map[string]string{"X-Forwarded-For": "1.2.3.4"},
+			expected:   "203.0.113.7",
+		},
+		{
+			name:       "Trusted proxy X-Forwarded-For",
+			remoteAddr: "10.0.0.2:1234",
+			headers:    map[string]string{"X-Forwarded-For": "1.2.3.4, 198.51.100.9, 10.1.1.1"},
+			expected:   "198.51.100.9",
+		},
+		{
+			name:       "Only trusted hops",
+			remoteAddr: "10.0.0.2:1234",
+			headers:    map[string]string{"X-Forwarded-For": "10.3.3.3"},
+			expected:   "10.3.3.3",
+		},
+		{
+			name:       "Forwarded header takes precedence",
+			remoteAddr: "192.168.1.1:1234",
+			headers: map[string]string{
+				"Forwarded":       `for="[2001:db8:cafe::17]:4711";proto=https, for=198.51.100.1;by=10.0.0.1`,
+				"X-Forwarded-For": "1.2.3.4",
+			},
+			expected: "198.51.100.1",
+		},
+		{
+			name:       "Forwarded header with trusted IPv6 hop",
+			remoteAddr: "[2001:db8::1]:443",
+			headers:    map[string]string{"Forwarded": `for=203.0.113.60, for="[2001:db8::5]"`},
+			expected:   "203.0.113.60",
+		},
+		{
+			name:       "Garbled hop stops the walk",
+			remoteAddr: "10.0.0.2:1234",
+			headers:    map[string]string{"X-Forwarded-For": "1.2.3.4, unknown, 10.9.9.9"},
+			expected:   "10.9.9.9",
+		},
+		{
+			name:       "X-Real-IP",
+			remoteAddr: "10.0.0.2:1234",
+			headers:    map[string]string{"X-Real-IP": "198.51.100.4"},
+			expected:   "198.51.100.4",
+		},
+		{
+			name:       "IPv4-mapped remote address",
+			remoteAddr: "[::ffff:10.0.0.2]:1234",
+			headers:    map[string]string{"X-Real-IP": "198.51.100.4"},
+			expected:   "198.51.100.4",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			handler := middlewares.RealIP(middlewares.RealIPConfig{TrustedProxies: trusted})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = middlewares.GetClientIP(r.Context()).String()
+				if middlewares.ClientIP(r).String() != got {
+					t.Errorf("ClientIP() = %v, GetClientIP() = %v", middlewares.ClientIP(r), got)
+				}
+			}))
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			if got != tt.expected {
+				t.Errorf("client IP = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseTrustedProxies_Invalid(t *testing.T) {
+	if _, err := middlewares.ParseTrustedProxies("10.0.0.0/8", "not-an-ip"); err == nil {
+		t.Error("expected an error for an invalid proxy")
+	}
+}
@@ -0,0 +1,93 @@
+package middlewares
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/a-h/templ"
+	"github.com/ancalabrese/gotth/htmx"
+)
+
+// RecoverConfig configures the [Recover] middleware.
+type RecoverConfig struct {
+	// Logger receives the panic and its stack trace. Defaults to slog.Default() when nil.
+	Logger *slog.Logger
+	// Optional: ErrorPage returns the page rendered, with status 500, after a panic.
+	// A plain "Internal Server Error" is sent when nil, when it returns nil or fails to render,
+	// and for HTMX fragment requests, which would otherwise swap a whole page into the target.
+	ErrorPage func(r *http.Request) templ.Component
+	// Optional: DebugPage returns the page rendered instead of ErrorPage, with the panic value
+	// and the stack trace of the panicking goroutine, e.g. deverror.Page in development.
+	// Never set it in production.
+	DebugPage func(r *http.Request, p any, stack []byte) templ.Component
+}
+
+// Recover returns a new middleware (http.Handler) that recovers from panics in the next handlers,
+// logs the panic with its stack trace and answers with a 500 error page, so that a single bad
+// handler doesn't end the connection with a blank response.
+// When the handler already started the response, the connection is aborted instead so that the
+// client doesn't take a truncated page for a complete one.
+func Recover(cfg RecoverConfig) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rec := newResponseRecorder(w)
+			defer func() {
+				p := recover()
+				if p == nil {
+					return
+				}
+				stack := debug.Stack()
+				if pe, ok := p.(*PanicError); ok {
+					p, stack = pe.Value, pe.Stack
+				}
+				if p == http.ErrAbortHandler {
+					panic(p)
+				}
+
+				logger := cfg.Logger
+				if logger == nil {
+					logger = slog.Default()
+				}
+				logger.ErrorContext(r.Context(), "panic serving request",
+					slog.String("method", r.Method),
+					slog.String("path", r.URL.Path),
+					slog.String("request_id", GetRequestID(r.Context())),
+					slog.String("panic", fmt.Sprint(p)),
+					slog.String("stack", string(stack)),
+				)
+
+				if rec.wroteHeader {
+					panic(http.ErrAbortHandler)
+				}
+				page := cfg.ErrorPage
+				if cfg.DebugPage != nil {
+					page = func(r *http.Request) templ.Component { return cfg.DebugPage(r, p, stack) }
+				}
+				WriteErrorPage(w, r, http.StatusInternalServerError, page)
+			}()
+
+			next.ServeHTTP(rec, r)
+		})
+	}
+}
+
+// WriteErrorPage answers with status and the page returned by page, or a plain error when page is
+// nil, returns nil or fails to render, and for HTMX fragment requests. It's the error response of
+// Recover, Timeout and Maintenance, for handlers failing in the same way.
+func WriteErrorPage(w http.ResponseWriter, r *http.Request, status int, page func(r *http.Request) templ.Component) {
+	if page != nil && !htmx.IsFragment(r) {
+		if c := page(r); c != nil {
+			var buf bytes.Buffer
+			if err := c.Render(r.Context(), &buf); err == nil {
+				w.Header().Set("Content-Type", "text/html; charset=utf-8")
+				w.WriteHeader(status)
+				w.Write(buf.Bytes())
+				return
+			}
+		}
+	}
+	http.Error(w, http.StatusText(status), status)
+}
@@ -0,0 +1,155 @@
+package middlewares_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/a-h/templ"
+	"github.com/ancalabrese/gotth/middlewares"
+)
+
+func TestRecover(t *testing.T) {
+	errorPage := func(r *http.Request) templ.Component {
+		return templ.ComponentFunc(func(ctx context.Context, w io.Writer) error {
+			_, err := io.WriteString(w, "<h1>Something went wrong</h1>")
+			return err
+		})
+	}
+
+	tests := []struct {
+		name            string
+		handler         http.HandlerFunc
+		errorPage       func(r *http.Request) templ.Component
+		headers         map[string]string
+		wantStatus      int
+		wantBody        string
+		wantContentType string
+		wantLogged      bool
+	}{
+		{
+			name:       "No panic",
+			handler:    func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("ok")) },
+			errorPage:  errorPage,
+			wantStatus: http.StatusOK,
+			wantBody:   "ok",
+		},
+		{
+			name:            "Panic renders the error page",
+			handler:         func(w http.ResponseWriter, r *http.Request) { panic("boom") },
+			errorPage:       errorPage,
+			wantStatus:      http.StatusInternalServerError,
+			wantBody:        "<h1>Something went wrong</h1>",
+			wantContentType: "text/html; charset=utf-8",
+			wantLogged:      true,
+		},
+		{
+			name:       "Panic without error page sends a plain 500",
+			handler:    func(w http.ResponseWriter, r *http.Request) { panic("boom") },
+			wantStatus: http.StatusInternalServerError,
+			wantBody:   "Internal Server Error\n",
+			wantLogged: true,
+		},
+		{
+			name:       "HTMX fragment gets a plain 500",
+			handler:    func(w http.ResponseWriter, r *http.Request) { panic("boom") },
+			errorPage:  errorPage,
+			headers:    map[string]string{"HX-Request": "true"},
+			wantStatus: http.StatusInternalServerError,
+			wantBody:   "Internal Server Error\n",
+			wantLogged: true,
+		},
+		{
+			name:       "Boosted HTMX request gets the error page",
+			handler:    func(w http.ResponseWriter, r *http.Request) { panic("boom") },
+			errorPage:  errorPage,
+			headers:    map[string]string{"HX-Request": "true", "HX-Boosted": "true"},
+			wantStatus: http.StatusInternalServerError,
+			wantBody:   "<h1>Something went wrong</h1>",
+			wantLogged: true,
+		},
+		{
+			name:    "Failing error page falls back to a plain 500",
+			handler: func(w http.ResponseWriter, r *http.Request) { panic("boom") },
+			errorPage: func(r *http.Request) templ.Component {
+				return templ.ComponentFunc(func(ctx context.Context, w io.Writer) error {
+					io.WriteString(w, "partial")
+					return io.ErrUnexpectedEOF
+				})
+			},
+			wantStatus: http.StatusInternalServerError,
+			wantBody:   "Internal Server Error\n",
+			wantLogged: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logs bytes.Buffer
+			cfg := middlewares.RecoverConfig{Logger: slog.New(slog.NewTextHandler(&logs, nil)), ErrorPage: tt.errorPage}
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			rr := httptest.NewRecorder()
+			middlewares.Recover(cfg)(tt.handler).ServeHTTP(rr, req)
+
+			if rr.Code != tt.wantStatus || rr.Body.String() != tt.wantBody {
+				t.Errorf("got %d %q, want %d %q", rr.Code, rr.Body.String(), tt.wantStatus, tt.wantBody)
+			}
+			if tt.wantContentType != "" && rr.Header().Get("Content-Type") != tt.wantContentType {
+				t.Errorf("Content-Type = %q, want %q", rr.Header().Get("Content-Type"), tt.wantContentType)
+			}
+			logged := strings.Contains(logs.String(), "panic=boom") && strings.Contains(logs.String(), "stack=")
+			if logged != tt.wantLogged {
+				t.Errorf("logged = %v, want %v: %s", logged, tt.wantLogged, logs.String())
+			}
+		})
+	}
+}
+
+func TestRecover_AbortsStartedResponses(t *testing.T) {
+	handler := middlewares.Recover(middlewares.RecoverConfig{Logger: slog.New(slog.NewTextHandler(io.Discard, nil))})(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("half a page"))
+			panic("boom")
+		}))
+
+	defer func() {
+		if p := recover(); p != http.ErrAbortHandler {
+			t.Errorf("recovered %v, want http.ErrAbortHandler", p)
+		}
+	}()
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+}
+
+func TestRecover_DebugPage(t *testing.T) {
+	var gotPanic any
+	var gotStack []byte
+	h := middlewares.Recover(middlewares.RecoverConfig{
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+		ErrorPage: func(r *http.Request) templ.Component {
+			t.Error("ErrorPage rendered instead of DebugPage")
+			return nil
+		},
+		DebugPage: func(r *http.Request, p any, stack []byte) templ.Component {
+			gotPanic, gotStack = p, stack
+			return templ.Raw("<h1>debug</h1>")
+		},
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { panic("boom") }))
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if rr.Code != http.StatusInternalServerError || rr.Body.String() != "<h1>debug</h1>" {
+		t.Errorf("response = %d %q, want 500 with the debug page", rr.Code, rr.Body.String())
+	}
+	if gotPanic != "boom" || !bytes.Contains(gotStack, []byte("recover_test.go")) {
+		t.Errorf("DebugPage got panic %v and stack:\n%s", gotPanic, gotStack)
+	}
+}
@@ -0,0 +1,57 @@
+package middlewares
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+)
+
+const (
+	// REQUEST_ID_HEADER carries the request ID in requests and responses.
+	REQUEST_ID_HEADER = "X-Request-ID"
+
+	requestIDKey contextRequestIDKeyType = "gotth_request_id_key"
+
+	maxRequestIDLength = 128
+)
+
+type contextRequestIDKeyType string
+
+// RequestID is a middleware that assigns every request an ID, available via [GetRequestID] and
+// echoed in the [REQUEST_ID_HEADER] response header. An incoming [REQUEST_ID_HEADER] set by a
+// proxy is reused when it's printable ASCII and at most 128 bytes long.
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(REQUEST_ID_HEADER)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+		w.Header().Set(REQUEST_ID_HEADER, id)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestIDKey, id)))
+	})
+}
+
+// GetRequestID returns the ID assigned by [RequestID], or "" when the middleware isn't in use.
+func GetRequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
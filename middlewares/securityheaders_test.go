@@ -0,0 +1,118 @@
+package middlewares_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ancalabrese/gotth/middlewares"
+)
+
+func TestCSP(t *testing.T) {
+	tests := []struct {
+		name     string
+		csp      middlewares.CSP
+		expected string
+	}{
+		{
+			name:     "Empty policy",
+			csp:      middlewares.CSP{},
+			expected: "",
+		},
+		{
+			name: "Add keeps order and skips duplicates",
+			csp: middlewares.CSP{}.
+				Add("default-src", middlewares.CSPSelf).
+				Add("script-src", middlewares.CSPSelf, "https://unpkg.com").
+				Add("default-src", middlewares.CSPSelf, "https://cdn.example.com").
+				Add("upgrade-insecure-requests"),
+			expected: "default-src 'self' https://cdn.example.com; script-src 'self' https://unpkg.com; upgrade-insecure-requests",
+		},
+		{
+			name:     "Set and Remove",
+			csp:      middlewares.DefaultCSP().Set("img-src", "*").Remove("font-src").Remove("connect-src").Remove("base-uri").Remove("form-action").Remove("object-src").Remove("style-src"),
+			expected: "default-src 'self'; script-src 'self'; img-src *; frame-ancestors 'none'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.csp.String(); got != tt.expected {
+				t.Errorf("String() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestCSP_BuilderDoesNotShareState(t *testing.T) {
+	base := middlewares.DefaultCSP()
+	before := base.String()
+	_ = base.Add("script-src", "https://unpkg.com")
+	if base.String() != before {
+		t.Errorf("Add modified the original policy: %q", base.String())
+	}
+}
+
+func TestSecurityHeaders(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      middlewares.SecurityHeadersConfig
+		handler  http.HandlerFunc
+		expected map[string]string
+	}{
+		{
+			name: "Defaults",
+			cfg:  middlewares.DefaultSecurityHeadersConfig(),
+			expected: map[string]string{
+				"Strict-Transport-Security":           "max-age=31536000; includeSubDomains",
+				"X-Content-Type-Options":              "nosniff",
+				"X-Frame-Options":                     "DENY",
+				"Referrer-Policy":                     "strict-origin-when-cross-origin",
+				"Permissions-Policy":                  "camera=(), microphone=(), geolocation=(), payment=(), usb=(), interest-cohort=()",
+				"Content-Security-Policy":             middlewares.DefaultCSP().String(),
+				"Content-Security-Policy-Report-Only": "",
+			},
+		},
+		{
+			name: "Report only CSP with preload",
+			cfg: middlewares.SecurityHeadersConfig{
+				HSTSMaxAge:    time.Hour,
+				HSTSPreload:   true,
+				CSP:           middlewares.CSP{}.Add("default-src", middlewares.CSPSelf),
+				CSPReportOnly: true,
+			},
+			expected: map[string]string{
+				"Strict-Transport-Security":           "max-age=3600; preload",
+				"Content-Security-Policy":             "",
+				"Content-Security-Policy-Report-Only": "default-src 'self'",
+				"X-Frame-Options":                     "",
+			},
+		},
+		{
+			name: "Handler overrides a header",
+			cfg:  middlewares.DefaultSecurityHeadersConfig(),
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("X-Frame-Options", "SAMEORIGIN")
+			},
+			expected: map[string]string{"X-Frame-Options": "SAMEORIGIN"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := tt.handler
+			if handler == nil {
+				handler = func(w http.ResponseWriter, r *http.Request) {}
+			}
+			rr := httptest.NewRecorder()
+			middlewares.SecurityHeaders(tt.cfg)(handler).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			for name, want := range tt.expected {
+				if got := rr.Header().Get(name); got != want {
+					t.Errorf("%s = %q, want %q", name, got, want)
+				}
+			}
+		})
+	}
+}
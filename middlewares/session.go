@@ -11,15 +11,147 @@ import (
 const (
 	SESSION_COOKIE_NAME                    = "session_id"
 	UserKey             contextUserKeyType = "gotth_user_key"
+
+	hostCookiePrefix = "__Host-"
 )
 
 type contextUserKeyType string
 
+// SessionConfig holds the attributes of the session cookie.
+// Use [DefaultSessionConfig] as a starting point.
+type SessionConfig struct {
+	// Cookie name. Defaults to [SESSION_COOKIE_NAME] when empty.
+	Name string
+	// Cookie path. Defaults to "/" when empty.
+	Path string
+	// Optional: cookie domain. Leave empty to scope the cookie to the current host.
+	Domain string
+	// Secure restricts the cookie to HTTPS connections.
+	Secure bool
+	// SameSite policy. Defaults to [http.SameSiteLaxMode] when unset.
+	SameSite http.SameSite
+	// TTL sets the cookie Max-Age. A zero TTL issues a browser-session cookie.
+	TTL time.Duration
+	// HostPrefix adds the "__Host-" prefix to the cookie name, locking the cookie to the current
+	// host. Browsers only accept such cookies when Secure, with Path "/" and no Domain, so those
+	// attributes are enforced and Path and Domain are ignored.
+	HostPrefix bool
+	// SlidingExpiration extends the session by TTL on every authenticated request, via
+	// [SessionStore.RefreshSession], and re-issues the cookie with the new Max-Age, so that active
+	// users are not logged out mid-session. Ignored when TTL is zero.
+	SlidingExpiration bool
+}
+
+// DefaultSessionConfig returns a SessionConfig with secure defaults: an HTTPS only, SameSite=Lax
+// cookie named [SESSION_COOKIE_NAME] scoped to "/" that lasts 24 hours.
+func DefaultSessionConfig() SessionConfig {
+	return SessionConfig{
+		Name:     SESSION_COOKIE_NAME,
+		Path:     "/",
+		Secure:   true,
+		SameSite: http.SameSiteLaxMode,
+		TTL:      24 * time.Hour,
+	}
+}
+
+// CookieName returns the name of the session cookie, including the "__Host-" prefix if enabled.
+func (cfg SessionConfig) CookieName() string {
+	name := cfg.Name
+	if name == "" {
+		name = SESSION_COOKIE_NAME
+	}
+	if cfg.HostPrefix {
+		name = hostCookiePrefix + name
+	}
+	return name
+}
+
+// cookie returns the session cookie for value with the attributes of cfg. The cookie is always
+// HttpOnly so that scripts can't read the session ID.
+func (cfg SessionConfig) cookie(value string) *http.Cookie {
+	c := &http.Cookie{
+		Name:     cfg.CookieName(),
+		Value:    value,
+		Path:     cfg.Path,
+		Domain:   cfg.Domain,
+		Secure:   cfg.Secure,
+		HttpOnly: true,
+		SameSite: cfg.SameSite,
+	}
+	if cfg.HostPrefix {
+		c.Secure = true
+		c.Path = "/"
+		c.Domain = ""
+	}
+	if c.Path == "" {
+		c.Path = "/"
+	}
+	if c.SameSite == 0 {
+		c.SameSite = http.SameSiteLaxMode
+	}
+	if cfg.TTL > 0 {
+		c.MaxAge = int(cfg.TTL.Seconds())
+		c.Expires = time.Now().Add(cfg.TTL)
+	}
+	return c
+}
+
+// expiredCookie returns a cookie that makes browsers delete the cookie described by cfg.
+func (cfg SessionConfig) expiredCookie(value string) *http.Cookie {
+	c := cfg.cookie(value)
+	c.Expires = time.Now().Add(-2 * time.Hour)
+	c.MaxAge = -1
+	return c
+}
+
+// SetSession writes the session cookie for sessionID to w using the attributes in cfg.
+// Call it once the user has been authenticated and the session has been created in the
+// [SessionStore].
+func SetSession(w http.ResponseWriter, sessionID string, cfg SessionConfig) {
+	http.SetCookie(w, cfg.cookie(sessionID))
+}
+
+// ClearSession expires the cookie described by cfg. The expired cookie carries the same Path and
+// Domain as the one [SetSession] issues, so browsers replace it.
+func ClearSession(w http.ResponseWriter, cfg SessionConfig) {
+	http.SetCookie(w, cfg.expiredCookie(""))
+}
+
+// SessionCreatorFunc authenticates the request (e.g., by checking the submitted credentials),
+// creates a new session and returns its ID.
+type SessionCreatorFunc func(r *http.Request) (sessionID string, err error)
+
+// IssueSession returns a new middleware (http.Handler) that completes a login: it calls create
+// and, on success, sets the session cookie with [SetSession] before calling the next handler
+// (e.g., a redirect to the user's home page).
+// It calls onError when create fails or returns an empty session ID.
+func IssueSession(create SessionCreatorFunc, cfg SessionConfig, onError func(http.ResponseWriter, *http.Request, error)) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			sessionID, err := create(r)
+			if err != nil {
+				onError(w, r, fmt.Errorf("failed to create session. err %w", err))
+				return
+			}
+			if sessionID == "" {
+				onError(w, r, errors.New("session creator returned an empty session ID"))
+				return
+			}
+
+			SetSession(w, sessionID, cfg)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
 type SessionStore interface {
 	// ExchangeSessionIDForUser returns the user object that corresponds to the sessionID
 	ExchangeSessionIDForUser(ctx context.Context, sessionID string) (any, error)
 	// InvalidateSession invalidates the session ID when no longer valid i.e. logout
 	InvalidateSession(ctx context.Context, user any, sessionID string) error
+	// RefreshSession extends the validity of the session ID by ttl from now.
+	// Called on activity when [SessionConfig.SlidingExpiration] is enabled.
+	RefreshSession(ctx context.Context, sessionID string, ttl time.Duration) error
 }
 
 // SessionCheck returns a new middleware (http.Handler) that checks whether the request has a
@@ -28,10 +160,29 @@ type SessionStore interface {
 // OnError is called when:
 //   - [isSessionIDRequired] and the request is missing the cookie or has an invalid cookie [onFail]
 //   - [isSessionIDRequired] and [ExchangeSessionIDForUser] fails
+//
+// It reads the [SESSION_COOKIE_NAME] cookie. Use [SessionCheckWithConfig] for custom cookies.
 func SessionCheck(ss SessionStore, isSessionIDRequired bool, onError func(http.ResponseWriter, *http.Request, error)) func(http.Handler) http.Handler {
+	return SessionCheckWithConfig(ss, SessionConfig{}, isSessionIDRequired, onError)
+}
+
+// SessionCheckWithConfig is like [SessionCheck] but reads the session cookie described by cfg.
+// With [SessionConfig.SlidingExpiration] it also refreshes the session of authenticated requests.
+// A failed refresh doesn't fail the request: the session stays valid until its current expiry.
+// The user set in the context by a preceding middleware, e.g. auth.RememberMe, is kept without
+// exchanging the session ID again.
+func SessionCheckWithConfig(ss SessionStore, cfg SessionConfig, isSessionIDRequired bool, onError func(http.ResponseWriter, *http.Request, error)) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			sessionCookie, err := r.Cookie(SESSION_COOKIE_NAME)
+			if GetUser(r.Context()) != nil {
+				if sessionCookie, err := r.Cookie(cfg.CookieName()); err == nil {
+					refreshSession(w, r, ss, cfg, sessionCookie.Value)
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			sessionCookie, err := r.Cookie(cfg.CookieName())
 			if err != nil {
 				if !isSessionIDRequired {
 					next.ServeHTTP(w, r)
@@ -61,21 +212,40 @@ func SessionCheck(ss SessionStore, isSessionIDRequired bool, onError func(http.R
 				return
 			}
 
-			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), UserKey, user)))
+			refreshSession(w, r, ss, cfg, sessionCookie.Value)
+			next.ServeHTTP(w, r.WithContext(ContextWithUser(r.Context(), user)))
 		})
 	}
 }
 
+// refreshSession extends the session with [SessionConfig.SlidingExpiration].
+func refreshSession(w http.ResponseWriter, r *http.Request, ss SessionStore, cfg SessionConfig, sessionID string) {
+	if cfg.SlidingExpiration && cfg.TTL > 0 {
+		if err := ss.RefreshSession(r.Context(), sessionID, cfg.TTL); err == nil {
+			SetSession(w, sessionID, cfg)
+		}
+	}
+}
+
 // InvalidateSession invalidates the sessionID of the current request and calls the next handler in
 // the chain.
 // It call onError when:
 // - A session cookie cannot be found
 // - The user object in the request context corresponding to the sessionID is null
 // - SessionStore fails to invalidate the sessionID
+//
+// It expires the [SESSION_COOKIE_NAME] cookie. Use [InvalidateSessionWithConfig] for custom cookies.
 func InvalidateSession(ss SessionStore, onError func(http.ResponseWriter, *http.Request, error)) func(http.Handler) http.Handler {
+	return InvalidateSessionWithConfig(ss, SessionConfig{}, onError)
+}
+
+// InvalidateSessionWithConfig is like [InvalidateSession] but reads and expires the session
+// cookie described by cfg. The expired cookie must carry the same Path and Domain as the one
+// that was issued, or browsers will keep the original.
+func InvalidateSessionWithConfig(ss SessionStore, cfg SessionConfig, onError func(http.ResponseWriter, *http.Request, error)) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			sessionCookie, err := r.Cookie(SESSION_COOKIE_NAME)
+			sessionCookie, err := r.Cookie(cfg.CookieName())
 			if err != nil {
 				onError(w, r, err)
 				return
@@ -93,21 +263,20 @@ func InvalidateSession(ss SessionStore, onError func(http.ResponseWriter, *http.
 				return
 			}
 
-			http.SetCookie(w, &http.Cookie{
-				Name:       SESSION_COOKIE_NAME,
-				Value:      sessionCookie.Value,
-				Path:       "/",
-				Expires:    time.Now().Add(-2 * time.Hour),
-				RawExpires: "",
-				HttpOnly:   true,
-				SameSite:   http.SameSiteLaxMode,
-			})
-
+			http.SetCookie(w, cfg.expiredCookie(sessionCookie.Value))
 			next.ServeHTTP(w, r)
 		})
 	}
 }
 
+// ContextWithUser returns a copy of ctx carrying user, as set by the session middlewares, and
+// records it for the [Logging] middleware. Middlewares authenticating requests another way use
+// it, e.g. auth.RememberMe.
+func ContextWithUser(ctx context.Context, user any) context.Context {
+	logUser(ctx, user)
+	return context.WithValue(ctx, UserKey, user)
+}
+
 func GetUser(ctx context.Context) any {
 	return ctx.Value(UserKey)
 }
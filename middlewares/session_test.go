@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/ancalabrese/gotth/middlewares"
+	"github.com/ancalabrese/gotth/middlewares/sessiontest"
 )
 
 // mockUser is struct for testing user objects in context.
@@ -18,36 +19,6 @@ type mockUser struct {
 	Name string
 }
 
-// mockSessionStore implements middlewares.SessionStore for testing.
-type mockSessionStore struct {
-	UserForSessionID        any
-	ErrorForSessionID       error
-	ExchangeCalled          bool
-	SessionIDPassed         string
-	InvalidateError         error
-	InvalidateCalled        bool
-	InvalidateUserPassed    any
-	InvalidateIDPassed      string
-	InvalidateContextPassed context.Context
-}
-
-func (m *mockSessionStore) ExchangeSessionIDForUser(ctx context.Context, sessionID string) (any, error) {
-	m.ExchangeCalled = true
-	m.SessionIDPassed = sessionID
-	if m.ErrorForSessionID != nil {
-		return nil, m.ErrorForSessionID
-	}
-	return m.UserForSessionID, nil
-}
-
-func (m *mockSessionStore) InvalidateSession(ctx context.Context, user any, sessionID string) error {
-	m.InvalidateCalled = true
-	m.InvalidateContextPassed = ctx
-	m.InvalidateUserPassed = user
-	m.InvalidateIDPassed = sessionID
-	return m.InvalidateError
-}
-
 func TestSessionCheck(t *testing.T) {
 	sampleUser := mockUser{ID: "user123", Name: "Test User"}
 	errSessionExchangeFailed := errors.New("session exchange failed from mock")
@@ -65,7 +36,7 @@ func TestSessionCheck(t *testing.T) {
 	}{
 		{
 			name:                "Required: Cookie present, session valid",
-			sessionStore:        &mockSessionStore{UserForSessionID: sampleUser},
+			sessionStore:        sessiontest.New().WithAnyUser(sampleUser),
 			isSessionIDRequired: true,
 			configureRequest: func(r *http.Request) {
 				r.AddCookie(&http.Cookie{Name: middlewares.SESSION_COOKIE_NAME, Value: "valid-session-token"})
@@ -80,7 +51,7 @@ func TestSessionCheck(t *testing.T) {
 		// This means r.Cookie() found the cookie AND sessionCookie.Valid() returned nil.
 		{
 			name:                "Required: Cookie present, Expires in past, MaxAge=0 (assuming Valid() passes in this env)",
-			sessionStore:        &mockSessionStore{ErrorForSessionID: errSessionExchangeFailed}, // Let Exchange fail to stop it there
+			sessionStore:        &sessiontest.Store{ExchangeErr: errSessionExchangeFailed}, // Let Exchange fail to stop it there
 			isSessionIDRequired: true,
 			configureRequest: func(r *http.Request) {
 				r.AddCookie(&http.Cookie{
@@ -100,7 +71,7 @@ func TestSessionCheck(t *testing.T) {
 		},
 		{
 			name:                   "Required: Cookie missing",
-			sessionStore:           &mockSessionStore{},
+			sessionStore:           sessiontest.New(),
 			isSessionIDRequired:    true,
 			configureRequest:       func(r *http.Request) { /* No cookie */ },
 			expectedOnErrorCalled:  true,
@@ -111,7 +82,7 @@ func TestSessionCheck(t *testing.T) {
 		},
 		{
 			name:                "Required: Cookie present, ExchangeSessionIDForUser fails",
-			sessionStore:        &mockSessionStore{ErrorForSessionID: errSessionExchangeFailed},
+			sessionStore:        &sessiontest.Store{ExchangeErr: errSessionExchangeFailed},
 			isSessionIDRequired: true,
 			configureRequest: func(r *http.Request) {
 				r.AddCookie(&http.Cookie{Name: middlewares.SESSION_COOKIE_NAME, Value: "token-for-failed-exchange"})
@@ -125,7 +96,7 @@ func TestSessionCheck(t *testing.T) {
 		// --- Cases where session is NOT required ---
 		{
 			name:                "Not Required: Cookie present, session valid",
-			sessionStore:        &mockSessionStore{UserForSessionID: sampleUser},
+			sessionStore:        sessiontest.New().WithAnyUser(sampleUser),
 			isSessionIDRequired: false,
 			configureRequest: func(r *http.Request) {
 				r.AddCookie(&http.Cookie{Name: middlewares.SESSION_COOKIE_NAME, Value: "optional-valid-token"})
@@ -137,7 +108,7 @@ func TestSessionCheck(t *testing.T) {
 		},
 		{
 			name:                "Not Required: Cookie present, ExchangeSessionIDForUser fails",
-			sessionStore:        &mockSessionStore{ErrorForSessionID: errSessionExchangeFailed},
+			sessionStore:        &sessiontest.Store{ExchangeErr: errSessionExchangeFailed},
 			isSessionIDRequired: false,
 			configureRequest: func(r *http.Request) {
 				r.AddCookie(&http.Cookie{Name: middlewares.SESSION_COOKIE_NAME, Value: "optional-token-for-failed-exchange"})
@@ -152,7 +123,7 @@ func TestSessionCheck(t *testing.T) {
 		// So, even if not required, the path to ExchangeSessionIDForUser was taken.
 		{
 			name:                "Not Required: Cookie present, Expires in past, MaxAge=0 (assuming Valid() passes, Exchange fails)",
-			sessionStore:        &mockSessionStore{ErrorForSessionID: errSessionExchangeFailed}, // Let exchange fail
+			sessionStore:        &sessiontest.Store{ExchangeErr: errSessionExchangeFailed}, // Let exchange fail
 			isSessionIDRequired: false,
 			configureRequest: func(r *http.Request) {
 				r.AddCookie(&http.Cookie{
@@ -169,7 +140,7 @@ func TestSessionCheck(t *testing.T) {
 		},
 		{
 			name:                  "Not Required: Cookie missing",
-			sessionStore:          &mockSessionStore{},
+			sessionStore:          sessiontest.New(),
 			isSessionIDRequired:   false,
 			configureRequest:      func(r *http.Request) { /* No cookie */ },
 			expectedOnErrorCalled: false,
@@ -236,9 +207,9 @@ func TestSessionCheck(t *testing.T) {
 				}
 			}
 
-			if mockSS, ok := tt.sessionStore.(*mockSessionStore); ok {
-				if mockSS.ExchangeCalled != tt.expectExchangeCalled {
-					t.Errorf("[%s] SessionStore.ExchangeSessionIDForUser called: got %v, want %v", tt.name, mockSS.ExchangeCalled, tt.expectExchangeCalled)
+			if mockSS, ok := tt.sessionStore.(*sessiontest.Store); ok {
+				if mockSS.Called(sessiontest.Exchange) != tt.expectExchangeCalled {
+					t.Errorf("[%s] SessionStore.ExchangeSessionIDForUser called: got %v, want %v", tt.name, mockSS.Called(sessiontest.Exchange), tt.expectExchangeCalled)
 				}
 			}
 		})
@@ -267,7 +238,7 @@ func TestInvalidateSession(t *testing.T) {
 	}{
 		{
 			name:         "Success: Valid session, user in context, store invalidates successfully",
-			sessionStore: &mockSessionStore{}, // No error on Invalidate
+			sessionStore: sessiontest.New(), // No error on Invalidate
 			requestSetup: func(r *http.Request) {
 				r.AddCookie(&http.Cookie{Name: middlewares.SESSION_COOKIE_NAME, Value: "session_to_invalidate"})
 				ctxWithUser := context.WithValue(r.Context(), middlewares.UserKey, sampleUser)
@@ -280,7 +251,7 @@ func TestInvalidateSession(t *testing.T) {
 		},
 		{
 			name:                   "Error: Cookie missing",
-			sessionStore:           &mockSessionStore{}, // Should not be called
+			sessionStore:           sessiontest.New(), // Should not be called
 			requestSetup:           func(r *http.Request) { /* No cookie */ },
 			expectedOnErrorCalled:  true,
 			expectedErrorSubstring: http.ErrNoCookie.Error(), // Exact error
@@ -288,7 +259,7 @@ func TestInvalidateSession(t *testing.T) {
 		},
 		{
 			name:         "Error: User nil in context",
-			sessionStore: &mockSessionStore{}, // Should not be called
+			sessionStore: sessiontest.New(), // Should not be called
 			requestSetup: func(r *http.Request) {
 				r.AddCookie(&http.Cookie{Name: middlewares.SESSION_COOKIE_NAME, Value: "session_with_no_user_ctx"})
 				// User is NOT set in context
@@ -299,7 +270,7 @@ func TestInvalidateSession(t *testing.T) {
 		},
 		{
 			name:         "Error: SessionStore.InvalidateSession fails",
-			sessionStore: &mockSessionStore{InvalidateError: errSessionStoreInvalidate},
+			sessionStore: &sessiontest.Store{InvalidateErr: errSessionStoreInvalidate},
 			requestSetup: func(r *http.Request) {
 				r.AddCookie(&http.Cookie{Name: middlewares.SESSION_COOKIE_NAME, Value: "session_store_fail"})
 				ctxWithUser := context.WithValue(r.Context(), middlewares.UserKey, sampleUser)
@@ -384,22 +355,23 @@ func TestInvalidateSession(t *testing.T) {
 				}
 			}
 
-			if mockSS, ok := tt.sessionStore.(*mockSessionStore); ok {
+			if mockSS, ok := tt.sessionStore.(*sessiontest.Store); ok {
 				shouldCallInvalidate := false
 				if tt.name == "Success: Valid session, user in context, store invalidates successfully" ||
 					tt.name == "Error: SessionStore.InvalidateSession fails" {
 					shouldCallInvalidate = true
 				}
 
-				if mockSS.InvalidateCalled != shouldCallInvalidate {
-					t.Errorf("[%s] SessionStore.InvalidateSession called: got %v, want %v", tt.name, mockSS.InvalidateCalled, shouldCallInvalidate)
+				invalidateCall, invalidateCalled := mockSS.LastCall(sessiontest.Invalidate)
+				if invalidateCalled != shouldCallInvalidate {
+					t.Errorf("[%s] SessionStore.InvalidateSession called: got %v, want %v", tt.name, invalidateCalled, shouldCallInvalidate)
 				}
 				if shouldCallInvalidate {
 					if tt.name == "Success: Valid session, user in context, store invalidates successfully" ||
 						tt.name == "Error: SessionStore.InvalidateSession fails" {
-						if _, ok := mockSS.InvalidateUserPassed.(mockUser); !ok {
-							if mockSS.InvalidateUserPassed != sampleUser && tt.name != "Error: User nil in context" {
-								t.Errorf("[%s] InvalidateSession passed user: got %v, want %v", tt.name, mockSS.InvalidateUserPassed, sampleUser)
+						if _, ok := invalidateCall.User.(mockUser); !ok {
+							if invalidateCall.User != sampleUser && tt.name != "Error: User nil in context" {
+								t.Errorf("[%s] InvalidateSession passed user: got %v, want %v", tt.name, invalidateCall.User, sampleUser)
 							}
 						}
 
@@ -407,11 +379,11 @@ func TestInvalidateSession(t *testing.T) {
 						tt.requestSetup(tempReq)
 						originalCookie, _ := tempReq.Cookie(middlewares.SESSION_COOKIE_NAME)
 						if originalCookie != nil {
-							if mockSS.InvalidateIDPassed != originalCookie.Value {
-								t.Errorf("[%s] InvalidateSession passed sessionID: got %q, want %q", tt.name, mockSS.InvalidateIDPassed, originalCookie.Value)
+							if invalidateCall.SessionID != originalCookie.Value {
+								t.Errorf("[%s] InvalidateSession passed sessionID: got %q, want %q", tt.name, invalidateCall.SessionID, originalCookie.Value)
 							}
-						} else if mockSS.InvalidateIDPassed != "" {
-							t.Errorf("[%s] InvalidateSession passed sessionID %q, but no original cookie was expected in setup.", tt.name, mockSS.InvalidateIDPassed)
+						} else if invalidateCall.SessionID != "" {
+							t.Errorf("[%s] InvalidateSession passed sessionID %q, but no original cookie was expected in setup.", tt.name, invalidateCall.SessionID)
 						}
 					}
 				}
@@ -419,3 +391,323 @@ func TestInvalidateSession(t *testing.T) {
 		})
 	}
 }
+
+func TestSetSession(t *testing.T) {
+	tests := []struct {
+		name           string
+		cfg            middlewares.SessionConfig
+		expectedName   string
+		expectedPath   string
+		expectedDomain string
+		expectedSecure bool
+		expectedSame   http.SameSite
+		expectedMaxAge int
+	}{
+		{
+			name:           "Default config",
+			cfg:            middlewares.DefaultSessionConfig(),
+			expectedName:   middlewares.SESSION_COOKIE_NAME,
+			expectedPath:   "/",
+			expectedSecure: true,
+			expectedSame:   http.SameSiteLaxMode,
+			expectedMaxAge: int((24 * time.Hour).Seconds()),
+		},
+		{
+			name:           "Zero config falls back to defaults and a browser-session cookie",
+			cfg:            middlewares.SessionConfig{},
+			expectedName:   middlewares.SESSION_COOKIE_NAME,
+			expectedPath:   "/",
+			expectedSame:   http.SameSiteLaxMode,
+			expectedMaxAge: 0,
+		},
+		{
+			name: "Custom config",
+			cfg: middlewares.SessionConfig{
+				Name:     "sid",
+				Path:     "/app",
+				Domain:   "example.com",
+				Secure:   true,
+				SameSite: http.SameSiteStrictMode,
+				TTL:      time.Hour,
+			},
+			expectedName:   "sid",
+			expectedPath:   "/app",
+			expectedDomain: "example.com",
+			expectedSecure: true,
+			expectedSame:   http.SameSiteStrictMode,
+			expectedMaxAge: 3600,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			middlewares.SetSession(rr, "new-session-id", tt.cfg)
+
+			cookies := rr.Result().Cookies()
+			if len(cookies) != 1 {
+				t.Fatalf("expected 1 cookie, got %d", len(cookies))
+			}
+			c := cookies[0]
+			if c.Name != tt.expectedName {
+				t.Errorf("Name: got %q, want %q", c.Name, tt.expectedName)
+			}
+			if c.Value != "new-session-id" {
+				t.Errorf("Value: got %q, want %q", c.Value, "new-session-id")
+			}
+			if c.Path != tt.expectedPath {
+				t.Errorf("Path: got %q, want %q", c.Path, tt.expectedPath)
+			}
+			if c.Domain != tt.expectedDomain {
+				t.Errorf("Domain: got %q, want %q", c.Domain, tt.expectedDomain)
+			}
+			if c.Secure != tt.expectedSecure {
+				t.Errorf("Secure: got %v, want %v", c.Secure, tt.expectedSecure)
+			}
+			if !c.HttpOnly {
+				t.Errorf("expected cookie to be HttpOnly")
+			}
+			if c.SameSite != tt.expectedSame {
+				t.Errorf("SameSite: got %v, want %v", c.SameSite, tt.expectedSame)
+			}
+			if c.MaxAge != tt.expectedMaxAge {
+				t.Errorf("MaxAge: got %d, want %d", c.MaxAge, tt.expectedMaxAge)
+			}
+		})
+	}
+}
+
+func TestIssueSession(t *testing.T) {
+	errBadCredentials := errors.New("bad credentials")
+
+	tests := []struct {
+		name                  string
+		create                middlewares.SessionCreatorFunc
+		expectedOnErrorCalled bool
+		expectedNextCalled    bool
+		expectedCookieValue   string
+	}{
+		{
+			name:                "Success",
+			create:              func(r *http.Request) (string, error) { return "issued-id", nil },
+			expectedNextCalled:  true,
+			expectedCookieValue: "issued-id",
+		},
+		{
+			name:                  "Creator fails",
+			create:                func(r *http.Request) (string, error) { return "", errBadCredentials },
+			expectedOnErrorCalled: true,
+		},
+		{
+			name:                  "Creator returns empty session ID",
+			create:                func(r *http.Request) (string, error) { return "", nil },
+			expectedOnErrorCalled: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			onErrorCalled, nextCalled := false, false
+			onError := func(w http.ResponseWriter, r *http.Request, err error) { onErrorCalled = true }
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { nextCalled = true })
+
+			rr := httptest.NewRecorder()
+			middlewares.IssueSession(tt.create, middlewares.DefaultSessionConfig(), onError)(next).
+				ServeHTTP(rr, httptest.NewRequest("POST", "/login", nil))
+
+			if onErrorCalled != tt.expectedOnErrorCalled {
+				t.Errorf("onError called: got %v, want %v", onErrorCalled, tt.expectedOnErrorCalled)
+			}
+			if nextCalled != tt.expectedNextCalled {
+				t.Errorf("next called: got %v, want %v", nextCalled, tt.expectedNextCalled)
+			}
+
+			cookies := rr.Result().Cookies()
+			if tt.expectedCookieValue == "" {
+				if len(cookies) != 0 {
+					t.Errorf("expected no cookies, got %v", cookies)
+				}
+				return
+			}
+			if len(cookies) != 1 || cookies[0].Value != tt.expectedCookieValue {
+				t.Errorf("expected session cookie %q, got %v", tt.expectedCookieValue, cookies)
+			}
+		})
+	}
+}
+
+func TestSessionConfig_CookieName(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      middlewares.SessionConfig
+		expected string
+	}{
+		{name: "Default", cfg: middlewares.SessionConfig{}, expected: middlewares.SESSION_COOKIE_NAME},
+		{name: "Custom", cfg: middlewares.SessionConfig{Name: "sid"}, expected: "sid"},
+		{name: "Host prefix", cfg: middlewares.SessionConfig{Name: "sid", HostPrefix: true}, expected: "__Host-sid"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.cfg.CookieName(); got != tt.expected {
+				t.Errorf("CookieName() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSetSession_HostPrefixEnforcesAttributes(t *testing.T) {
+	rr := httptest.NewRecorder()
+	middlewares.SetSession(rr, "id", middlewares.SessionConfig{
+		Name:       "sid",
+		Path:       "/app",
+		Domain:     "example.com",
+		HostPrefix: true,
+	})
+
+	c := rr.Result().Cookies()[0]
+	if c.Name != "__Host-sid" || !c.Secure || c.Path != "/" || c.Domain != "" {
+		t.Errorf("unexpected __Host- cookie attributes: name=%q secure=%v path=%q domain=%q", c.Name, c.Secure, c.Path, c.Domain)
+	}
+}
+
+func TestSessionCheckWithConfig(t *testing.T) {
+	cfg := middlewares.SessionConfig{Name: "sid", HostPrefix: true}
+	sampleUser := mockUser{ID: "user456", Name: "Config User"}
+
+	tests := []struct {
+		name               string
+		cookieName         string
+		expectedNextCalled bool
+		expectedUser       any
+	}{
+		{name: "Configured cookie is read", cookieName: "__Host-sid", expectedNextCalled: true, expectedUser: sampleUser},
+		{name: "Default cookie is ignored", cookieName: middlewares.SESSION_COOKIE_NAME, expectedNextCalled: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var userInContext any
+			nextCalled := false
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				nextCalled = true
+				userInContext = middlewares.GetUser(r.Context())
+			})
+
+			req := httptest.NewRequest("GET", "/", nil)
+			req.AddCookie(&http.Cookie{Name: tt.cookieName, Value: "token"})
+			ss := sessiontest.New().WithAnyUser(sampleUser)
+			middlewares.SessionCheckWithConfig(ss, cfg, true, func(http.ResponseWriter, *http.Request, error) {})(next).
+				ServeHTTP(httptest.NewRecorder(), req)
+
+			if nextCalled != tt.expectedNextCalled {
+				t.Errorf("next called: got %v, want %v", nextCalled, tt.expectedNextCalled)
+			}
+			if userInContext != tt.expectedUser {
+				t.Errorf("user in context: got %v, want %v", userInContext, tt.expectedUser)
+			}
+		})
+	}
+}
+
+func TestInvalidateSessionWithConfig(t *testing.T) {
+	cfg := middlewares.SessionConfig{Name: "sid", Path: "/app", Domain: "example.com", Secure: true, SameSite: http.SameSiteStrictMode}
+
+	req := httptest.NewRequest("POST", "/app/logout", nil)
+	req.AddCookie(&http.Cookie{Name: "sid", Value: "session_to_invalidate"})
+	req = req.WithContext(context.WithValue(req.Context(), middlewares.UserKey, mockUser{ID: "u"}))
+	rr := httptest.NewRecorder()
+
+	ss := sessiontest.New()
+	middlewares.InvalidateSessionWithConfig(ss, cfg, func(w http.ResponseWriter, r *http.Request, err error) {
+		t.Fatalf("unexpected onError call: %v", err)
+	})(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})).ServeHTTP(rr, req)
+
+	if invalidateCall, _ := ss.LastCall(sessiontest.Invalidate); invalidateCall.SessionID != "session_to_invalidate" {
+		t.Errorf("InvalidateSession passed sessionID: got %q", invalidateCall.SessionID)
+	}
+
+	cookies := rr.Result().Cookies()
+	if len(cookies) != 1 {
+		t.Fatalf("expected 1 cookie, got %d", len(cookies))
+	}
+	c := cookies[0]
+	if c.Name != "sid" || c.Path != "/app" || c.Domain != "example.com" || !c.Secure || c.SameSite != http.SameSiteStrictMode {
+		t.Errorf("expired cookie doesn't match the configured attributes: %+v", c)
+	}
+	if c.MaxAge >= 0 || !c.Expires.Before(time.Now()) {
+		t.Errorf("expected cookie to be expired, got MaxAge=%d Expires=%v", c.MaxAge, c.Expires)
+	}
+}
+
+func TestSessionCheckWithConfig_SlidingExpiration(t *testing.T) {
+	sampleUser := mockUser{ID: "user321", Name: "Active User"}
+	errRefreshFailed := errors.New("refresh failed")
+
+	tests := []struct {
+		name                string
+		cfg                 middlewares.SessionConfig
+		refreshError        error
+		expectRefreshCalled bool
+		expectCookie        bool
+	}{
+		{
+			name:                "Enabled: session refreshed and cookie re-issued",
+			cfg:                 middlewares.SessionConfig{TTL: time.Hour, SlidingExpiration: true},
+			expectRefreshCalled: true,
+			expectCookie:        true,
+		},
+		{
+			name:                "Enabled: refresh fails, request still served without new cookie",
+			cfg:                 middlewares.SessionConfig{TTL: time.Hour, SlidingExpiration: true},
+			refreshError:        errRefreshFailed,
+			expectRefreshCalled: true,
+			expectCookie:        false,
+		},
+		{
+			name:                "Enabled without TTL: nothing to extend",
+			cfg:                 middlewares.SessionConfig{SlidingExpiration: true},
+			expectRefreshCalled: false,
+			expectCookie:        false,
+		},
+		{
+			name:                "Disabled",
+			cfg:                 middlewares.SessionConfig{TTL: time.Hour},
+			expectRefreshCalled: false,
+			expectCookie:        false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ss := &sessiontest.Store{User: sampleUser, RefreshErr: tt.refreshError}
+			nextCalled := false
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { nextCalled = true })
+
+			req := httptest.NewRequest("GET", "/", nil)
+			req.AddCookie(&http.Cookie{Name: middlewares.SESSION_COOKIE_NAME, Value: "active-token"})
+			rr := httptest.NewRecorder()
+			middlewares.SessionCheckWithConfig(ss, tt.cfg, true, func(w http.ResponseWriter, r *http.Request, err error) {
+				t.Fatalf("unexpected onError call: %v", err)
+			})(next).ServeHTTP(rr, req)
+
+			if !nextCalled {
+				t.Errorf("expected next handler to be called")
+			}
+			if ss.Called(sessiontest.Refresh) != tt.expectRefreshCalled {
+				t.Errorf("RefreshSession called: got %v, want %v", ss.Called(sessiontest.Refresh), tt.expectRefreshCalled)
+			}
+			if refreshCall, _ := ss.LastCall(sessiontest.Refresh); tt.expectRefreshCalled && (refreshCall.SessionID != "active-token" || refreshCall.TTL != tt.cfg.TTL) {
+				t.Errorf("RefreshSession args: got (%q, %v), want (%q, %v)", refreshCall.SessionID, refreshCall.TTL, "active-token", tt.cfg.TTL)
+			}
+
+			cookies := rr.Result().Cookies()
+			if tt.expectCookie {
+				if len(cookies) != 1 || cookies[0].Value != "active-token" || cookies[0].MaxAge != int(tt.cfg.TTL.Seconds()) {
+					t.Errorf("expected re-issued session cookie, got %v", cookies)
+				}
+			} else if len(cookies) != 0 {
+				t.Errorf("expected no cookies, got %v", cookies)
+			}
+		})
+	}
+}
@@ -0,0 +1,193 @@
+// Package sessiontest provides a fake [middlewares.SessionStore] to test the authentication
+// flows of an application without a real session storage: script the users and the errors of
+// the store, run the requests through the session middlewares, then assert on the recorded
+// calls.
+//
+//	store := sessiontest.New().WithUser("token", user)
+//	handler := middlewares.SessionCheck(store, true, onError)(next)
+//	...
+//	if !store.Called(sessiontest.Exchange) {
+//		t.Error("the session wasn't checked")
+//	}
+package sessiontest
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/ancalabrese/gotth/middlewares"
+)
+
+// The methods of the store, as recorded in [Call.Method].
+const (
+	Exchange   = "ExchangeSessionIDForUser"
+	Invalidate = "InvalidateSession"
+	Refresh    = "RefreshSession"
+)
+
+// ErrUnknownSession is returned by ExchangeSessionIDForUser for the session IDs without a user.
+var ErrUnknownSession = errors.New("unknown session ID")
+
+var _ middlewares.SessionStore = (*Store)(nil)
+
+// Call is a call to the store.
+type Call struct {
+	// Method is one of [Exchange], [Invalidate] or [Refresh].
+	Method    string
+	SessionID string
+	// User is the user passed to InvalidateSession.
+	User any
+	// TTL is the ttl passed to RefreshSession.
+	TTL time.Duration
+	Ctx context.Context
+}
+
+// Store is a fake [middlewares.SessionStore], safe for concurrent use. Set its fields before
+// the first request, or use the With methods.
+type Store struct {
+	// Users maps the session IDs to their users.
+	Users map[string]any
+	// User is returned for the session IDs missing from Users, e.g. to accept any session.
+	// ExchangeSessionIDForUser returns [ErrUnknownSession] for them when nil.
+	User any
+	// ExchangeErr, InvalidateErr and RefreshErr are returned by the corresponding methods when
+	// set, after recording the call.
+	ExchangeErr   error
+	InvalidateErr error
+	RefreshErr    error
+
+	mu          sync.Mutex
+	calls       []Call
+	invalidated map[string]bool
+}
+
+// New returns an empty Store: every session ID is unknown.
+func New() *Store {
+	return &Store{}
+}
+
+// WithUser maps sessionID to user and returns s.
+func (s *Store) WithUser(sessionID string, user any) *Store {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.Users == nil {
+		s.Users = map[string]any{}
+	}
+	s.Users[sessionID] = user
+	delete(s.invalidated, sessionID)
+	return s
+}
+
+// WithAnyUser makes every session ID valid for user and returns s.
+func (s *Store) WithAnyUser(user any) *Store {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.User = user
+	return s
+}
+
+// WithErrors sets the errors returned by ExchangeSessionIDForUser, InvalidateSession and
+// RefreshSession and returns s. Pass nil for the methods that should succeed.
+func (s *Store) WithErrors(exchange, invalidate, refresh error) *Store {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ExchangeErr, s.InvalidateErr, s.RefreshErr = exchange, invalidate, refresh
+	return s
+}
+
+// ExchangeSessionIDForUser returns the user of sessionID, or ExchangeErr when set.
+// Invalidated sessions are unknown.
+func (s *Store) ExchangeSessionIDForUser(ctx context.Context, sessionID string) (any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls = append(s.calls, Call{Method: Exchange, SessionID: sessionID, Ctx: ctx})
+	if s.ExchangeErr != nil {
+		return nil, s.ExchangeErr
+	}
+	if s.invalidated[sessionID] {
+		return nil, ErrUnknownSession
+	}
+	if user, ok := s.Users[sessionID]; ok {
+		return user, nil
+	}
+	if s.User != nil {
+		return s.User, nil
+	}
+	return nil, ErrUnknownSession
+}
+
+// InvalidateSession invalidates sessionID, or returns InvalidateErr when set.
+func (s *Store) InvalidateSession(ctx context.Context, user any, sessionID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls = append(s.calls, Call{Method: Invalidate, SessionID: sessionID, User: user, Ctx: ctx})
+	if s.InvalidateErr != nil {
+		return s.InvalidateErr
+	}
+	if s.invalidated == nil {
+		s.invalidated = map[string]bool{}
+	}
+	s.invalidated[sessionID] = true
+	return nil
+}
+
+// RefreshSession records the call and returns RefreshErr.
+func (s *Store) RefreshSession(ctx context.Context, sessionID string, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls = append(s.calls, Call{Method: Refresh, SessionID: sessionID, TTL: ttl, Ctx: ctx})
+	return s.RefreshErr
+}
+
+// Calls returns the calls to the store, the oldest first.
+func (s *Store) Calls() []Call {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.calls)
+}
+
+// CallsTo returns the calls to method, the oldest first.
+func (s *Store) CallsTo(method string) []Call {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var calls []Call
+	for _, c := range s.calls {
+		if c.Method == method {
+			calls = append(calls, c)
+		}
+	}
+	return calls
+}
+
+// Called reports whether method was called.
+func (s *Store) Called(method string) bool {
+	return len(s.CallsTo(method)) > 0
+}
+
+// LastCall returns the last call to method, and whether there was one.
+func (s *Store) LastCall(method string) (Call, bool) {
+	calls := s.CallsTo(method)
+	if len(calls) == 0 {
+		return Call{}, false
+	}
+	return calls[len(calls)-1], true
+}
+
+// Invalidated reports whether sessionID was invalidated.
+func (s *Store) Invalidated(sessionID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.invalidated[sessionID]
+}
+
+// Reset forgets the recorded calls and the invalidated sessions, keeping the users and the
+// errors.
+func (s *Store) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls = nil
+	s.invalidated = nil
+}
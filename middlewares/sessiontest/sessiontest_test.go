@@ -0,0 +1,95 @@
+package sessiontest_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ancalabrese/gotth/middlewares"
+	"github.com/ancalabrese/gotth/middlewares/sessiontest"
+)
+
+func TestStore_AuthFlow(t *testing.T) {
+	store := sessiontest.New().WithUser("token", "alice")
+	cfg := middlewares.SessionConfig{TTL: time.Hour, SlidingExpiration: true}
+
+	var onErr error
+	onError := func(w http.ResponseWriter, r *http.Request, err error) { onErr = err }
+	var user any
+	protected := middlewares.SessionCheckWithConfig(store, cfg, true, onError)(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { user = middlewares.GetUser(r.Context()) }))
+	logout := middlewares.SessionCheckWithConfig(store, cfg, true, onError)(
+		middlewares.InvalidateSessionWithConfig(store, cfg, onError)(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})))
+
+	request := func(h http.Handler) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.AddCookie(&http.Cookie{Name: cfg.CookieName(), Value: "token"})
+		h.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	request(protected)
+	if user != "alice" || onErr != nil {
+		t.Fatalf("user = %v, err = %v, want alice", user, onErr)
+	}
+	if call, ok := store.LastCall(sessiontest.Refresh); !ok || call.SessionID != "token" || call.TTL != time.Hour {
+		t.Errorf("LastCall(Refresh) = %+v, %v", call, ok)
+	}
+
+	request(logout)
+	if call, ok := store.LastCall(sessiontest.Invalidate); !ok || call.User != "alice" || !store.Invalidated("token") {
+		t.Errorf("LastCall(Invalidate) = %+v, %v, Invalidated = %v", call, ok, store.Invalidated("token"))
+	}
+
+	request(protected)
+	if !errors.Is(onErr, sessiontest.ErrUnknownSession) {
+		t.Errorf("err after logout = %v, want ErrUnknownSession", onErr)
+	}
+
+	want := []string{sessiontest.Exchange, sessiontest.Refresh, sessiontest.Exchange, sessiontest.Refresh, sessiontest.Invalidate, sessiontest.Exchange}
+	calls := store.Calls()
+	if len(calls) != len(want) {
+		t.Fatalf("Calls() = %+v, want %v", calls, want)
+	}
+	for i, c := range calls {
+		if c.Method != want[i] {
+			t.Errorf("Calls()[%d].Method = %q, want %q", i, c.Method, want[i])
+		}
+	}
+
+	store.Reset()
+	if len(store.Calls()) != 0 || store.Invalidated("token") {
+		t.Error("Reset() kept the calls or the invalidated sessions")
+	}
+}
+
+func TestStore_Scripting(t *testing.T) {
+	errDown := errors.New("store down")
+
+	tests := []struct {
+		name     string
+		store    *sessiontest.Store
+		id       string
+		wantUser any
+		wantErr  error
+	}{
+		{name: "Unknown session", store: sessiontest.New(), id: "x", wantErr: sessiontest.ErrUnknownSession},
+		{name: "Mapped session", store: sessiontest.New().WithUser("a", 1).WithUser("b", 2), id: "b", wantUser: 2},
+		{name: "Any session", store: sessiontest.New().WithUser("a", 1).WithAnyUser(3), id: "x", wantUser: 3},
+		{name: "Mapped session wins over any", store: sessiontest.New().WithUser("a", 1).WithAnyUser(3), id: "a", wantUser: 1},
+		{name: "Scripted error", store: sessiontest.New().WithAnyUser(3).WithErrors(errDown, nil, nil), id: "x", wantErr: errDown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user, err := tt.store.ExchangeSessionIDForUser(t.Context(), tt.id)
+			if user != tt.wantUser || !errors.Is(err, tt.wantErr) {
+				t.Errorf("ExchangeSessionIDForUser(%q) = %v, %v, want %v, %v", tt.id, user, err, tt.wantUser, tt.wantErr)
+			}
+			if !tt.store.Called(sessiontest.Exchange) {
+				t.Error("Called(Exchange) = false")
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package middlewares
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/a-h/templ"
+)
+
+// Timeout returns a new middleware (http.Handler) that gives the next handler timeout to build
+// its response. The deadline is set on r.Context(), so database calls and other context aware
+// work are cancelled too. When it expires, the client gets a 504 with the page returned by
+// errorPage, or a plain "Gateway Timeout" when nil and for HTMX fragments.
+//
+// The response is buffered until the handler returns, so don't use it for streaming responses
+// (e.g., server-sent events). A panic in the handler is re-raised as a [*PanicError] carrying
+// the stack of the handler, so that [Recover] reports where it happened.
+func Timeout(timeout time.Duration, errorPage func(r *http.Request) templ.Component) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, cancel := context.WithTimeout(r.Context(), timeout)
+			defer cancel()
+			r = r.WithContext(ctx)
+
+			tw := &timeoutWriter{header: make(http.Header), status: http.StatusOK}
+			done := make(chan struct{})
+			panicChan := make(chan any, 1)
+			go func() {
+				defer func() {
+					if p := recover(); p != nil {
+						if p != http.ErrAbortHandler {
+							p = &PanicError{Value: p, Stack: debug.Stack()}
+						}
+						panicChan <- p
+					}
+				}()
+				next.ServeHTTP(tw, r)
+				close(done)
+			}()
+
+			select {
+			case p := <-panicChan:
+				panic(p)
+			case <-done:
+				tw.mu.Lock()
+				defer tw.mu.Unlock()
+				dst := w.Header()
+				for k, v := range tw.header {
+					dst[k] = v
+				}
+				w.WriteHeader(tw.status)
+				w.Write(tw.buf.Bytes())
+			case <-ctx.Done():
+				tw.mu.Lock()
+				defer tw.mu.Unlock()
+				tw.timedOut = true
+				// Rendering with the expired context would fail: templ components check it first.
+				WriteErrorPage(w, r.WithContext(context.WithoutCancel(ctx)), http.StatusGatewayTimeout, errorPage)
+			}
+		})
+	}
+}
+
+// PanicError is the panic value re-raised by [Timeout] when its handler panics in another
+// goroutine. Stack is the stack trace of that goroutine.
+type PanicError struct {
+	Value any
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprint(e.Value)
+}
+
+// timeoutWriter buffers the response of a handler run by [Timeout]. Writes after the deadline
+// fail with http.ErrHandlerTimeout.
+type timeoutWriter struct {
+	mu          sync.Mutex
+	header      http.Header
+	buf         bytes.Buffer
+	status      int
+	wroteHeader bool
+	timedOut    bool
+}
+
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.header
+}
+
+func (tw *timeoutWriter) WriteHeader(status int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut || tw.wroteHeader {
+		return
+	}
+	tw.status = status
+	tw.wroteHeader = true
+}
+
+func (tw *timeoutWriter) Write(b []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	tw.wroteHeader = true
+	return tw.buf.Write(b)
+}
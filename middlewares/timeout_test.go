@@ -0,0 +1,136 @@
+package middlewares_test
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/a-h/templ"
+	"github.com/ancalabrese/gotth/middlewares"
+	"github.com/ancalabrese/gotth/views/components/ui"
+)
+
+func TestTimeout(t *testing.T) {
+	timeoutPage := func(r *http.Request) templ.Component {
+		return templ.ComponentFunc(func(ctx context.Context, w io.Writer) error {
+			_, err := io.WriteString(w, "<h1>Too slow</h1>")
+			return err
+		})
+	}
+	slow := func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+		w.Write([]byte("late"))
+	}
+
+	tests := []struct {
+		name       string
+		handler    http.HandlerFunc
+		page       func(r *http.Request) templ.Component
+		headers    map[string]string
+		wantStatus int
+		wantBody   string
+		wantHeader string
+	}{
+		{
+			name: "Fast handler response is copied",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				if _, ok := r.Context().Deadline(); !ok {
+					t.Error("missing context deadline")
+				}
+				w.Header().Set("X-Test", "yes")
+				w.WriteHeader(http.StatusCreated)
+				w.Write([]byte("done"))
+			},
+			wantStatus: http.StatusCreated,
+			wantBody:   "done",
+			wantHeader: "yes",
+		},
+		{
+			name:       "Slow handler gets the timeout page",
+			handler:    slow,
+			page:       timeoutPage,
+			wantStatus: http.StatusGatewayTimeout,
+			wantBody:   "<h1>Too slow</h1>",
+		},
+		{
+			name:       "Slow handler without page",
+			handler:    slow,
+			wantStatus: http.StatusGatewayTimeout,
+			wantBody:   "Gateway Timeout\n",
+		},
+		{
+			name:       "Slow HTMX fragment gets a plain 504",
+			handler:    slow,
+			page:       timeoutPage,
+			headers:    map[string]string{"HX-Request": "true"},
+			wantStatus: http.StatusGatewayTimeout,
+			wantBody:   "Gateway Timeout\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			rr := httptest.NewRecorder()
+			middlewares.Timeout(20*time.Millisecond, tt.page)(tt.handler).ServeHTTP(rr, req)
+
+			if rr.Code != tt.wantStatus || rr.Body.String() != tt.wantBody {
+				t.Errorf("got %d %q, want %d %q", rr.Code, rr.Body.String(), tt.wantStatus, tt.wantBody)
+			}
+			if rr.Header().Get("X-Test") != tt.wantHeader {
+				t.Errorf("X-Test = %q, want %q", rr.Header().Get("X-Test"), tt.wantHeader)
+			}
+		})
+	}
+}
+
+func TestTimeout_GeneratedPage(t *testing.T) {
+	// Generated components fail to render with a done context, unlike templ.ComponentFunc.
+	page := func(r *http.Request) templ.Component {
+		return ui.Button(ui.ButtonViewModel{Label: "Try again", Href: "/"})
+	}
+	rr := httptest.NewRecorder()
+	middlewares.Timeout(20*time.Millisecond, page)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	})).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if rr.Code != http.StatusGatewayTimeout || !strings.Contains(rr.Body.String(), "Try again") {
+		t.Errorf("got %d %q, want %d with the timeout page", rr.Code, rr.Body.String(), http.StatusGatewayTimeout)
+	}
+}
+
+func TestTimeout_PanicReachesRecover(t *testing.T) {
+	var (
+		panicked any
+		stack    string
+	)
+	handler := middlewares.Recover(middlewares.RecoverConfig{
+		Logger: slog.New(slog.DiscardHandler),
+		DebugPage: func(r *http.Request, p any, s []byte) templ.Component {
+			panicked, stack = p, string(s)
+			return nil
+		},
+	})(middlewares.Timeout(time.Second, nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})))
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rr.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want %d", rr.Code, http.StatusInternalServerError)
+	}
+	if panicked != "boom" {
+		t.Errorf("panic value = %v, want boom", panicked)
+	}
+	if !strings.Contains(stack, "TestTimeout_PanicReachesRecover.func") {
+		t.Errorf("stack doesn't include the panicking handler:\n%s", stack)
+	}
+}
@@ -0,0 +1,52 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/textproto"
+	"strings"
+)
+
+// Request headers that responses commonly vary on.
+const (
+	VaryHTMX           = "HX-Request"
+	VaryHTMXBoosted    = "HX-Boosted"
+	VaryAcceptLanguage = "Accept-Language"
+	VaryAcceptEncoding = "Accept-Encoding"
+	VaryAccept         = "Accept"
+)
+
+// AddVary adds fields to the Vary header in h, skipping the ones already listed, so that shared
+// caches and CDNs store a separate response per value of those request headers. Call it from
+// any handler whose response depends on a request header, e.g. an HTMX fragment served on the
+// same URL as a full page.
+func AddVary(h http.Header, fields ...string) {
+	present := map[string]bool{}
+	for _, v := range h.Values("Vary") {
+		for _, f := range strings.Split(v, ",") {
+			present[textproto.CanonicalMIMEHeaderKey(strings.TrimSpace(f))] = true
+		}
+	}
+	if present["*"] {
+		return
+	}
+
+	for _, f := range fields {
+		key := textproto.CanonicalMIMEHeaderKey(strings.TrimSpace(f))
+		if key == "" || present[key] {
+			continue
+		}
+		present[key] = true
+		h.Add("Vary", f)
+	}
+}
+
+// Vary returns a new middleware (http.Handler) that adds fields to the Vary header of every
+// response, e.g. Vary(VaryAcceptLanguage) for translated pages.
+func Vary(fields ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			AddVary(w.Header(), fields...)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
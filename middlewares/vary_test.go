@@ -0,0 +1,62 @@
+package middlewares_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/ancalabrese/gotth/middlewares"
+)
+
+func TestAddVary(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing []string
+		fields   []string
+		expected []string
+	}{
+		{
+			name:     "Empty header",
+			fields:   []string{middlewares.VaryHTMX, middlewares.VaryAcceptLanguage},
+			expected: []string{"HX-Request", "Accept-Language"},
+		},
+		{
+			name:     "Duplicates are skipped case-insensitively",
+			existing: []string{"accept-encoding, Hx-Request"},
+			fields:   []string{middlewares.VaryHTMX, middlewares.VaryAcceptEncoding, middlewares.VaryAcceptLanguage},
+			expected: []string{"accept-encoding, Hx-Request", "Accept-Language"},
+		},
+		{
+			name:     "Wildcard already varies on everything",
+			existing: []string{"*"},
+			fields:   []string{middlewares.VaryHTMX},
+			expected: []string{"*"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := http.Header{}
+			for _, v := range tt.existing {
+				h.Add("Vary", v)
+			}
+			middlewares.AddVary(h, tt.fields...)
+			if got := h.Values("Vary"); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Vary = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestVary(t *testing.T) {
+	handler := middlewares.Vary(middlewares.VaryAcceptLanguage)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		middlewares.AddVary(w.Header(), middlewares.VaryHTMX, middlewares.VaryAcceptLanguage)
+	}))
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if got := rr.Header().Values("Vary"); !reflect.DeepEqual(got, []string{"Accept-Language", "HX-Request"}) {
+		t.Errorf("Vary = %q", got)
+	}
+}
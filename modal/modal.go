@@ -0,0 +1,61 @@
+// Package modal implements the usual HTMX modal conventions. The layout renders an empty
+// [Target] once; links load the modal into it with hx-get ([OpenAttributes]); the handler
+// renders a [Modal] or [Drawer]; and the modal is closed by the user (close button, Escape or
+// a click on the backdrop) or by the server with [Close] or [CloseOOB]:
+//
+//	<a href="/items/new" { modal.OpenAttributes("/items/new")... }>New item</a>
+//
+//	func newItemForm(w http.ResponseWriter, r *http.Request) {
+//		modal.Modal("New item", views.ItemForm()).Render(r.Context(), w)
+//	}
+//
+//	func createItem(w http.ResponseWriter, r *http.Request) {
+//		...
+//		modal.Close(w)
+//		views.ItemRow(item).Render(r.Context(), w)
+//	}
+//
+// Closing from the browser uses Alpine.js (see head.WithAlpine).
+package modal
+
+import (
+	"net/http"
+
+	"github.com/a-h/templ"
+	"github.com/ancalabrese/gotth/htmx"
+)
+
+const (
+	// TargetID is the id of the element the modals are loaded into.
+	TargetID = "modal"
+	// CloseEvent is the event closing the open modal.
+	CloseEvent = "gotth-modal-close"
+)
+
+// OpenAttributes returns the attributes of an element loading the modal returned by url into
+// the [Target].
+func OpenAttributes(url string) templ.Attributes {
+	return templ.Attributes{
+		"hx-get":    url,
+		"hx-target": "#" + TargetID,
+		"hx-swap":   htmx.SwapInnerHTML,
+	}
+}
+
+// Close closes the open modal once the response is received, by triggering [CloseEvent].
+// It must be called before the response is written.
+func Close(w http.ResponseWriter) error {
+	return htmx.Trigger(w, htmx.Event{Name: CloseEvent})
+}
+
+// alpineAttributes returns the Alpine attributes of the modal backdrop, closing it on Escape, on
+// a click outside of the panel and on [CloseEvent].
+func alpineAttributes() templ.Attributes {
+	return templ.Attributes{
+		"x-data":                         "{ open: true }",
+		"x-show":                         "open",
+		"x-on:keydown.escape.window":     "open = false",
+		"x-on:" + CloseEvent + ".window": "open = false",
+		"x-on:click.self":                "open = false",
+	}
+}
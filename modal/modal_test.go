@@ -0,0 +1,82 @@
+package modal_test
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/a-h/templ"
+	"github.com/ancalabrese/gotth/modal"
+)
+
+func render(t *testing.T, c templ.Component) string {
+	t.Helper()
+	var b strings.Builder
+	if err := c.Render(context.Background(), &b); err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	return b.String()
+}
+
+func TestModal(t *testing.T) {
+	tests := []struct {
+		name string
+		c    templ.Component
+		want []string
+	}{
+		{
+			name: "Modal",
+			c:    modal.Modal("New <item>", templ.Raw("<form></form>")),
+			want: []string{
+				`x-data="{ open: true }"`,
+				`x-on:gotth-modal-close.window="open = false"`,
+				`x-on:keydown.escape.window="open = false"`,
+				`<div role="dialog" aria-modal="true" aria-labelledby="modal-title"`,
+				`<h2 id="modal-title" class="text-lg font-semibold">New &lt;item&gt;</h2>`,
+				`<form></form>`,
+				"items-center",
+			},
+		},
+		{
+			name: "Drawer",
+			c:    modal.Drawer("Filters", templ.Raw("<p>x</p>")),
+			want: []string{`role="dialog"`, "justify-end", "<p>x</p>"},
+		},
+		{
+			name: "Target",
+			c:    modal.Target(),
+			want: []string{`<div id="modal"></div>`},
+		},
+		{
+			name: "Close out-of-band",
+			c:    modal.CloseOOB(),
+			want: []string{`<div id="modal" hx-swap-oob="innerHTML"></div>`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := render(t, tt.c)
+			for _, w := range tt.want {
+				if !strings.Contains(got, w) {
+					t.Errorf("does not contain %s\n%s", w, got)
+				}
+			}
+		})
+	}
+}
+
+func TestOpenAndClose(t *testing.T) {
+	a := modal.OpenAttributes("/items/new")
+	if a["hx-get"] != "/items/new" || a["hx-target"] != "#modal" || a["hx-swap"] != "innerHTML" {
+		t.Errorf("OpenAttributes() = %v", a)
+	}
+
+	rr := httptest.NewRecorder()
+	if err := modal.Close(rr); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if got := rr.Header().Get("HX-Trigger"); got != modal.CloseEvent {
+		t.Errorf("HX-Trigger = %q, want %q", got, modal.CloseEvent)
+	}
+}
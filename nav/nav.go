@@ -0,0 +1,87 @@
+// Package nav marks the navigation links of the current page as active. The WebServer adds the
+// request path to the context of every request, so components only need the href:
+//
+//	<a href="/blog" class={ nav.Class(ctx, "/blog", nav.Prefix, "font-bold", "text-slate-500") }
+//		{ nav.AriaCurrent(ctx, "/blog", nav.Prefix)... }>Blog</a>
+//
+// With other routers, wrap the handler with [Middleware].
+package nav
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/a-h/templ"
+)
+
+// Match is how a link href is compared with the current path.
+type Match int
+
+const (
+	// Prefix marks the link active on its path and any path below it: "/blog" matches
+	// "/blog" and "/blog/first-post" but not "/blogroll". The root "/" only matches itself.
+	Prefix Match = iota
+	// Exact marks the link active on its path only.
+	Exact
+)
+
+type navCtxKey string
+
+const pathKey navCtxKey = "nav-path"
+
+// Middleware adds the path of the request to its context.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(ContextWithPath(r.Context(), r.URL.Path)))
+	})
+}
+
+// ContextWithPath returns a copy of ctx with path as the current path, e.g. to render a
+// component outside of a request.
+func ContextWithPath(ctx context.Context, path string) context.Context {
+	return context.WithValue(ctx, pathKey, path)
+}
+
+// Path returns the current path, or an empty string when [Middleware] is not in use.
+func Path(ctx context.Context) string {
+	path, _ := ctx.Value(pathKey).(string)
+	return path
+}
+
+// IsActive reports whether the link to href is the current page. The query and fragment of href
+// are ignored.
+func IsActive(ctx context.Context, href string, match Match) bool {
+	current := Path(ctx)
+	if current == "" {
+		return false
+	}
+	u, err := url.Parse(href)
+	if err != nil || u.Path == "" {
+		return false
+	}
+	target := u.Path
+	if match == Exact || target == "/" {
+		return strings.TrimSuffix(current, "/") == strings.TrimSuffix(target, "/")
+	}
+	target = strings.TrimSuffix(target, "/")
+	return current == target || strings.HasPrefix(current, target+"/")
+}
+
+// Class returns active when the link to href is the current page and inactive otherwise.
+func Class(ctx context.Context, href string, match Match, active, inactive string) string {
+	if IsActive(ctx, href, match) {
+		return active
+	}
+	return inactive
+}
+
+// AriaCurrent returns the aria-current="page" attribute when the link to href is the current
+// page, so that screen readers announce it.
+func AriaCurrent(ctx context.Context, href string, match Match) templ.Attributes {
+	if IsActive(ctx, href, match) {
+		return templ.Attributes{"aria-current": "page"}
+	}
+	return templ.Attributes{}
+}
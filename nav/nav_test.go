@@ -0,0 +1,58 @@
+package nav_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ancalabrese/gotth/nav"
+)
+
+func TestIsActive(t *testing.T) {
+	tests := []struct {
+		name     string
+		current  string
+		href     string
+		match    nav.Match
+		expected bool
+	}{
+		{name: "Exact match", current: "/blog", href: "/blog", match: nav.Exact, expected: true},
+		{name: "Exact ignores trailing slash", current: "/blog/", href: "/blog", match: nav.Exact, expected: true},
+		{name: "Exact doesn't match children", current: "/blog/post", href: "/blog", match: nav.Exact, expected: false},
+		{name: "Prefix matches children", current: "/blog/post", href: "/blog", match: nav.Prefix, expected: true},
+		{name: "Prefix matches whole segments", current: "/blogroll", href: "/blog", match: nav.Prefix, expected: false},
+		{name: "Prefix with trailing slash", current: "/docs/intro", href: "/docs/", match: nav.Prefix, expected: true},
+		{name: "Root only matches itself", current: "/blog", href: "/", match: nav.Prefix, expected: false},
+		{name: "Root", current: "/", href: "/", match: nav.Prefix, expected: true},
+		{name: "Query and fragment ignored", current: "/search", href: "/search?q=go#results", match: nav.Exact, expected: true},
+		{name: "Absolute URL", current: "/about", href: "https://example.com/about", match: nav.Exact, expected: true},
+		{name: "No current path", current: "", href: "/", match: nav.Prefix, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.current != "" {
+				ctx = nav.ContextWithPath(ctx, tt.current)
+			}
+			if got := nav.IsActive(ctx, tt.href, tt.match); got != tt.expected {
+				t.Errorf("IsActive(%q, %q) = %v, want %v", tt.current, tt.href, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	var class string
+	var aria bool
+	h := nav.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		class = nav.Class(r.Context(), "/blog", nav.Prefix, "active", "inactive")
+		_, aria = nav.AriaCurrent(r.Context(), "/blog", nav.Prefix)["aria-current"]
+	}))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/blog/first-post", nil))
+
+	if class != "active" || !aria {
+		t.Errorf("class = %q, aria-current = %v, want active link", class, aria)
+	}
+}
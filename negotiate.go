@@ -0,0 +1,121 @@
+package gotth
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ancalabrese/gotth/htmx"
+	"github.com/ancalabrese/gotth/middlewares"
+	"github.com/ancalabrese/gotth/routes"
+)
+
+// JSONProviderFunc returns the data of a page for the JSON clients, encoded with encoding/json.
+// Like content providers, it returns an error wrapping routes.ErrNotFound for a 404.
+type JSONProviderFunc func(r *http.Request) (any, error)
+
+// ServeNegotiated adds a page served like ServeContent to browsers, and as the JSON of
+// jsonProvider to the clients preferring application/json in their Accept header, e.g. an API
+// client or fetch(). Both responses vary on Accept, so caches keep them apart. HTMX requests
+// always get HTML. The optional middlewares wrap both.
+//
+//	ws.ServeNegotiated("GET /posts/{slug}", postPage, postJSON)
+func (ws *WebServer) ServeNegotiated(path string, contentProvider ContentProviderFunc, jsonProvider JSONProviderFunc, mws ...func(http.Handler) http.Handler) {
+	if path == "" || contentProvider == nil || jsonProvider == nil {
+		ws.registrationFailed(fmt.Errorf("%w %q registered at %s: ServeNegotiated needs a pattern, a ContentProviderFunc and a JSONProviderFunc",
+			ErrInvalidRoute, path, callerSource()))
+		return
+	}
+
+	page := ws.pageHandler(path, contentProvider, nil)
+	var handler http.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		middlewares.AddVary(w.Header(), middlewares.VaryAccept)
+		if htmx.IsRequest(r) || !prefersJSON(r.Header.Get("Accept")) {
+			page.ServeHTTP(w, r)
+			return
+		}
+		ws.serveJSON(w, r, path, jsonProvider)
+	})
+	for i := len(mws) - 1; i >= 0; i-- {
+		handler = mws[i](handler)
+	}
+	ws.handle(path, handler, RouteKindPage, len(mws))
+}
+
+// serveJSON answers r with the data of jsonProvider, or a JSON error.
+func (ws *WebServer) serveJSON(w http.ResponseWriter, r *http.Request, path string, jsonProvider JSONProviderFunc) {
+	data, err := jsonProvider(r)
+	var body bytes.Buffer
+	if err == nil {
+		err = json.NewEncoder(&body).Encode(data)
+	}
+	status := http.StatusOK
+	switch {
+	case errors.Is(err, routes.ErrNotFound):
+		ws.logger.DebugContext(r.Context(), "content not found", ws.requestAttrs(r, path, err)...)
+		status = http.StatusNotFound
+	case err != nil:
+		ws.logger.ErrorContext(r.Context(), "JSON provider failed", ws.requestAttrs(r, path, err)...)
+		status = http.StatusInternalServerError
+	}
+	if status != http.StatusOK {
+		body.Reset()
+		json.NewEncoder(&body).Encode(map[string]string{"error": http.StatusText(status)})
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Content-Length", strconv.Itoa(body.Len()))
+	w.WriteHeader(status)
+	w.Write(body.Bytes())
+}
+
+// prefersJSON reports whether the Accept header ranks application/json above text/html: by
+// quality, then by the most specific media range matching each. Empty and "*/*" headers get
+// HTML.
+func prefersJSON(accept string) bool {
+	j, h := acceptQuality(accept, "application", "json"), acceptQuality(accept, "text", "html")
+	return j.q > 0 && (j.q > h.q || j.q == h.q && j.specificity > h.specificity)
+}
+
+type quality struct {
+	q           float64
+	specificity int // 0 for */*, 1 for type/*, 2 for type/subtype
+}
+
+// acceptQuality returns the quality of typ/subtype in the Accept header, given by its most
+// specific matching range.
+func acceptQuality(accept, typ, subtype string) quality {
+	best := quality{specificity: -1}
+	for r := range strings.SplitSeq(accept, ",") {
+		mediaRange, params, _ := strings.Cut(r, ";")
+		t, s, _ := strings.Cut(strings.ToLower(strings.TrimSpace(mediaRange)), "/")
+		specificity := 0
+		switch {
+		case t == typ && s == subtype:
+			specificity = 2
+		case t == typ && s == "*":
+			specificity = 1
+		case t != "*" || s != "*":
+			continue
+		}
+		if specificity < best.specificity {
+			continue
+		}
+		q := 1.0
+		for p := range strings.SplitSeq(params, ";") {
+			if k, v, ok := strings.Cut(strings.TrimSpace(p), "="); ok && strings.EqualFold(k, "q") {
+				if f, err := strconv.ParseFloat(v, 64); err == nil {
+					q = f
+				}
+			}
+		}
+		best = quality{q: q, specificity: specificity}
+	}
+	if best.specificity < 0 {
+		return quality{}
+	}
+	return best
+}
@@ -0,0 +1,91 @@
+package gotth_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/a-h/templ"
+	"github.com/ancalabrese/gotth"
+	"github.com/ancalabrese/gotth/content"
+	"github.com/ancalabrese/gotth/views/components/head"
+)
+
+func TestWebServer_ServeNegotiated(t *testing.T) {
+	type post struct {
+		Slug  string `json:"slug"`
+		Title string `json:"title"`
+	}
+	posts := map[string]post{"hello": {Slug: "hello", Title: "Hello"}}
+	find := func(r *http.Request) (post, error) {
+		p, ok := posts[r.PathValue("slug")]
+		if !ok {
+			return post{}, fmt.Errorf("post %q: %w", r.PathValue("slug"), content.ErrNotFound)
+		}
+		return p, nil
+	}
+
+	ws, err := gotth.New(gotth.WebServerConfig{}, nil)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	ws.ServeNegotiated("GET /posts/{slug}", func(r *http.Request) (head.HeadViewModel, templ.Component, error) {
+		p, err := find(r)
+		return head.NewHeadViewModel(head.WithPageCoreMetadata(p.Title, "", "")), templ.ComponentFunc(func(ctx context.Context, w io.Writer) error {
+			_, err := io.WriteString(w, "<h1>"+p.Title+"</h1>")
+			return err
+		}), err
+	}, func(r *http.Request) (any, error) {
+		if r.PathValue("slug") == "broken" {
+			return nil, errors.New("db down")
+		}
+		return find(r)
+	})
+	ws.ServeNegotiated("GET /nil", nil, nil)
+	if err := ws.Err(); err == nil || !strings.Contains(err.Error(), "ServeNegotiated needs") {
+		t.Errorf("Err() = %v, want the missing providers", err)
+	}
+
+	tests := []struct {
+		name       string
+		path       string
+		accept     string
+		htmx       bool
+		wantStatus int
+		wantType   string
+		wantBody   string
+	}{
+		{name: "Browser", path: "/posts/hello", accept: "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", wantStatus: http.StatusOK, wantType: "text/html", wantBody: "<h1>Hello</h1>"},
+		{name: "No Accept", path: "/posts/hello", wantStatus: http.StatusOK, wantType: "text/html", wantBody: "<h1>Hello</h1>"},
+		{name: "JSON client", path: "/posts/hello", accept: "application/json", wantStatus: http.StatusOK, wantType: "application/json", wantBody: `{"slug":"hello","title":"Hello"}`},
+		{name: "JSON over wildcard", path: "/posts/hello", accept: "application/json, text/plain, */*", wantStatus: http.StatusOK, wantType: "application/json", wantBody: `"title":"Hello"`},
+		{name: "HTML preferred", path: "/posts/hello", accept: "application/json;q=0.5, text/html", wantStatus: http.StatusOK, wantType: "text/html", wantBody: "<h1>Hello</h1>"},
+		{name: "HTMX", path: "/posts/hello", accept: "application/json", htmx: true, wantStatus: http.StatusOK, wantType: "text/html", wantBody: "<h1>Hello</h1>"},
+		{name: "JSON not found", path: "/posts/missing", accept: "application/json", wantStatus: http.StatusNotFound, wantType: "application/json", wantBody: `{"error":"Not Found"}`},
+		{name: "JSON error", path: "/posts/broken", accept: "application/json", wantStatus: http.StatusInternalServerError, wantType: "application/json", wantBody: `{"error":"Internal Server Error"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			if tt.htmx {
+				req.Header.Set("HX-Request", "true")
+			}
+			rr := httptest.NewRecorder()
+			ws.Handler().ServeHTTP(rr, req)
+			if rr.Code != tt.wantStatus || !strings.HasPrefix(rr.Header().Get("Content-Type"), tt.wantType) || !strings.Contains(rr.Body.String(), tt.wantBody) {
+				t.Errorf("response = %d %q %q, want %d %q %q", rr.Code, rr.Header().Get("Content-Type"), rr.Body.String(), tt.wantStatus, tt.wantType, tt.wantBody)
+			}
+			if !strings.Contains(strings.Join(rr.Header().Values("Vary"), ","), "Accept") {
+				t.Errorf("Vary = %q, want Accept", rr.Header().Values("Vary"))
+			}
+		})
+	}
+}
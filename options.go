@@ -0,0 +1,318 @@
+package gotth
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ancalabrese/gotth/admin"
+	"github.com/ancalabrese/gotth/i18n"
+	"github.com/ancalabrese/gotth/internal/envvar"
+	"github.com/ancalabrese/gotth/scheduler"
+)
+
+// Option configures the WebServer created by [NewWithOptions].
+type Option func(*options)
+
+// options holds the configuration and the http.Server built by the Options, and the first
+// error an Option ran into.
+type options struct {
+	config WebServerConfig
+	server *http.Server
+	err    error
+}
+
+// NewWithOptions creates a new WebServer configured with opts, starting from the default
+// http.Server (listening on :8080, with read, write and idle timeouts). E.g.:
+//
+//	ws, err := gotth.NewWithOptions(
+//		gotth.WithAddr(":3000"),
+//		gotth.WithStatic("/static", http.Dir("./static/dist")),
+//		gotth.WithMiddlewares(middlewares.RequestID),
+//		gotth.ConfigFromEnv(), // Last, so that the environment overrides the defaults above
+//	)
+func NewWithOptions(opts ...Option) (*WebServer, error) {
+	o := &options{server: defaultServer()}
+	for _, opt := range opts {
+		opt(o)
+		if o.err != nil {
+			return nil, o.err
+		}
+	}
+	return New(o.config, o.server)
+}
+
+// WithConfig replaces the WebServerConfig built so far with cfg.
+func WithConfig(cfg WebServerConfig) Option {
+	return func(o *options) {
+		o.config = cfg
+	}
+}
+
+// WithAddr sets the TCP address the server listens on, e.g. ":8080".
+func WithAddr(addr string) Option {
+	return func(o *options) {
+		o.server.Addr = addr
+	}
+}
+
+// WithReadTimeout sets the maximum duration for reading an entire request, including the body.
+func WithReadTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.server.ReadTimeout = d
+	}
+}
+
+// WithReadHeaderTimeout sets the maximum duration for reading the request headers.
+func WithReadHeaderTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.server.ReadHeaderTimeout = d
+	}
+}
+
+// WithWriteTimeout sets the maximum duration before timing out writes of the response.
+func WithWriteTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.server.WriteTimeout = d
+	}
+}
+
+// WithIdleTimeout sets the maximum amount of time to wait for the next request on keep-alive
+// connections.
+func WithIdleTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.server.IdleTimeout = d
+	}
+}
+
+// WithMaxHeaderBytes sets the maximum size of the request headers.
+func WithMaxHeaderBytes(n int) Option {
+	return func(o *options) {
+		o.server.MaxHeaderBytes = n
+	}
+}
+
+// WithTLS serves HTTPS with the certificate and private key in certFile and keyFile.
+func WithTLS(certFile, keyFile string) Option {
+	return func(o *options) {
+		o.config.TLSCertFile = certFile
+		o.config.TLSKeyFile = keyFile
+	}
+}
+
+// WithHTTPRedirect also listens for plain HTTP on addr, redirecting to HTTPS and serving the
+// ACME challenges of acmeChallengeDir when set. See [WebServerConfig.HTTPAddr].
+func WithHTTPRedirect(addr, acmeChallengeDir string) Option {
+	return func(o *options) {
+		o.config.HTTPAddr = addr
+		o.config.ACMEChallengeDir = acmeChallengeDir
+	}
+}
+
+// WithStatic serves the files of fs under urlPath, e.g. "/static". See [NewStaticAssetFS].
+func WithStatic(urlPath string, fs http.FileSystem) Option {
+	return func(o *options) {
+		o.config.StaticAssetsFS = append(o.config.StaticAssetsFS, NewStaticAssetFS(urlPath, fs))
+	}
+}
+
+// WithMiddlewares appends mws to the global middlewares.
+func WithMiddlewares(mws ...func(http.Handler) http.Handler) Option {
+	return func(o *options) {
+		o.config.GlobalMiddlewares = append(o.config.GlobalMiddlewares, mws...)
+	}
+}
+
+// WithLogger sets the logger of the server. See [WebServerConfig.Logger].
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *options) {
+		o.config.Logger = logger
+	}
+}
+
+// WithErrorPage sets the page rendered when a handler panics. See [WebServerConfig.ErrorPage].
+func WithErrorPage(page ContentProviderFunc) Option {
+	return func(o *options) {
+		o.config.ErrorPage = page
+	}
+}
+
+// WithDevMode enables DevMode, watching dirs for changes. See [WebServerConfig.DevMode].
+func WithDevMode(dirs ...string) Option {
+	return func(o *options) {
+		o.config.DevMode = true
+		o.config.DevWatch = append(o.config.DevWatch, dirs...)
+	}
+}
+
+// WithLogRoutes prints the route table when the server starts.
+func WithLogRoutes() Option {
+	return func(o *options) {
+		o.config.LogRoutes = true
+	}
+}
+
+// WithPprof mounts the pprof endpoints protected by guard. See [WebServerConfig.EnablePprof].
+func WithPprof(guard func(http.Handler) http.Handler) Option {
+	return func(o *options) {
+		o.config.EnablePprof = true
+		o.config.PprofGuard = guard
+	}
+}
+
+// WithAdmin serves the admin dashboard of cfg. See [WebServerConfig.Admin].
+func WithAdmin(cfg admin.Config) Option {
+	return func(o *options) {
+		o.config.Admin = &cfg
+	}
+}
+
+// WithScheduler runs the tasks of s with the server. See [WebServerConfig.Scheduler].
+func WithScheduler(s *scheduler.Scheduler) Option {
+	return func(o *options) {
+		o.config.Scheduler = s
+	}
+}
+
+// WithLocales serves the pages of ServeLocalized under the prefixes of locales, the first being
+// the default. See [WebServerConfig.Locales].
+func WithLocales(locales ...string) Option {
+	return func(o *options) {
+		o.config.Locales = &i18n.Config{Locales: locales}
+	}
+}
+
+// WithBuildInfo serves the version of the binary at path. See [WebServerConfig.BuildInfoPath].
+func WithBuildInfo(path string) Option {
+	return func(o *options) {
+		o.config.BuildInfoPath = path
+	}
+}
+
+// WithReusePort binds the address with SO_REUSEPORT. See [WebServerConfig.ReusePort].
+func WithReusePort() Option {
+	return func(o *options) {
+		o.config.ReusePort = true
+	}
+}
+
+// WithRestartOnSIGHUP restarts the server without dropping connections on SIGHUP. See
+// [WebServerConfig.RestartOnSIGHUP].
+func WithRestartOnSIGHUP() Option {
+	return func(o *options) {
+		o.config.RestartOnSIGHUP = true
+	}
+}
+
+// Environment variables read by [ConfigFromEnv].
+const (
+	EnvAddr              = "GOTTH_ADDR"                // e.g. ":8080"
+	EnvPort              = "PORT"                      // Listen on ":$PORT" when GOTTH_ADDR is unset
+	EnvReadTimeout       = "GOTTH_READ_TIMEOUT"        // e.g. "10s"
+	EnvReadHeaderTimeout = "GOTTH_READ_HEADER_TIMEOUT" // e.g. "5s"
+	EnvWriteTimeout      = "GOTTH_WRITE_TIMEOUT"       // e.g. "10s"
+	EnvIdleTimeout       = "GOTTH_IDLE_TIMEOUT"        // e.g. "2m"
+	EnvMaxHeaderBytes    = "GOTTH_MAX_HEADER_BYTES"    // e.g. "1048576"
+	EnvStatic            = "GOTTH_STATIC"              // e.g. "/static=./static/dist,/img=./images"
+	EnvTLSCertFile       = "GOTTH_TLS_CERT_FILE"       // e.g. "/etc/gotth/cert.pem"
+	EnvTLSKeyFile        = "GOTTH_TLS_KEY_FILE"        // e.g. "/etc/gotth/key.pem"
+	EnvHTTPAddr          = "GOTTH_HTTP_ADDR"           // e.g. ":80", redirected to HTTPS
+	EnvACMEChallengeDir  = "GOTTH_ACME_CHALLENGE_DIR"  // e.g. "/var/www/acme"
+	EnvDev               = "GOTTH_DEV"                 // e.g. "true"
+	EnvDevWatch          = "GOTTH_DEV_WATCH"           // e.g. ".,./static"
+	EnvLogRoutes         = "GOTTH_LOG_ROUTES"          // e.g. "true"
+	EnvReusePort         = "GOTTH_REUSE_PORT"          // e.g. "true"
+	EnvRestartOnSIGHUP   = "GOTTH_RESTART_ON_SIGHUP"   // e.g. "true"
+	EnvLogLevel          = "GOTTH_LOG_LEVEL"           // debug, info, warn or error
+	EnvLogFormat         = "GOTTH_LOG_FORMAT"          // text or json
+)
+
+// ConfigFromEnv configures the server from the environment variables above. Unset variables
+// leave the configuration unchanged, and an invalid value makes [NewWithOptions] fail.
+// When GOTTH_LOG_LEVEL or GOTTH_LOG_FORMAT is set the server logs to stderr.
+func ConfigFromEnv() Option {
+	return func(o *options) {
+		o.err = configFromEnv(o)
+	}
+}
+
+func configFromEnv(o *options) error {
+	if addr := os.Getenv(EnvAddr); addr != "" {
+		o.server.Addr = addr
+	} else if port := os.Getenv(EnvPort); port != "" {
+		o.server.Addr = ":" + port
+	}
+
+	// The variables are checked in a fixed order so that the first invalid one is reported.
+	for _, setting := range []struct {
+		name string
+		dst  any
+	}{
+		{EnvReadTimeout, &o.server.ReadTimeout},
+		{EnvReadHeaderTimeout, &o.server.ReadHeaderTimeout},
+		{EnvWriteTimeout, &o.server.WriteTimeout},
+		{EnvIdleTimeout, &o.server.IdleTimeout},
+		{EnvMaxHeaderBytes, &o.server.MaxHeaderBytes},
+		{EnvTLSCertFile, &o.config.TLSCertFile},
+		{EnvTLSKeyFile, &o.config.TLSKeyFile},
+		{EnvHTTPAddr, &o.config.HTTPAddr},
+		{EnvACMEChallengeDir, &o.config.ACMEChallengeDir},
+		{EnvDev, &o.config.DevMode},
+		{EnvLogRoutes, &o.config.LogRoutes},
+		{EnvReusePort, &o.config.ReusePort},
+		{EnvRestartOnSIGHUP, &o.config.RestartOnSIGHUP},
+	} {
+		if v := os.Getenv(setting.name); v != "" {
+			if err := envvar.Set(setting.dst, v); err != nil {
+				return fmt.Errorf("failed to parse %s. err %w", setting.name, err)
+			}
+		}
+	}
+
+	if v := os.Getenv(EnvStatic); v != "" {
+		mounts, err := envvar.Mounts(v)
+		if err != nil {
+			return fmt.Errorf("failed to parse %s. err %w", EnvStatic, err)
+		}
+		for _, m := range mounts {
+			o.config.StaticAssetsFS = append(o.config.StaticAssetsFS, NewStaticAssetFS(m.Path, http.Dir(m.Dir)))
+		}
+	}
+	o.config.DevWatch = append(o.config.DevWatch, envvar.List(os.Getenv(EnvDevWatch))...)
+
+	level, format := os.Getenv(EnvLogLevel), os.Getenv(EnvLogFormat)
+	if level != "" || format != "" {
+		logger, err := NewLogger(os.Stderr, level, format)
+		if err != nil {
+			return fmt.Errorf("failed to parse %s or %s. err %w", EnvLogLevel, EnvLogFormat, err)
+		}
+		o.config.Logger = logger
+	}
+	return nil
+}
+
+// NewLogger returns a logger writing to w at level (debug, info, warn or error, info when empty)
+// in format (text or json, text when empty).
+func NewLogger(w io.Writer, level, format string) (*slog.Logger, error) {
+	handlerOpts := &slog.HandlerOptions{}
+	if level != "" {
+		var l slog.Level
+		if err := l.UnmarshalText([]byte(level)); err != nil {
+			return nil, fmt.Errorf("failed to parse the log level. err %w", err)
+		}
+		handlerOpts.Level = l
+	}
+
+	switch strings.ToLower(format) {
+	case "", "text":
+		return slog.New(slog.NewTextHandler(w, handlerOpts)), nil
+	case "json":
+		return slog.New(slog.NewJSONHandler(w, handlerOpts)), nil
+	default:
+		return nil, fmt.Errorf("unknown log format %q", format)
+	}
+}
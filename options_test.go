@@ -0,0 +1,111 @@
+package gotth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewWithOptions(t *testing.T) {
+	ws, err := NewWithOptions(
+		WithAddr(":3000"),
+		WithReadTimeout(time.Second),
+		WithWriteTimeout(2*time.Second),
+		WithStatic("/assets", http.Dir("./example/static")),
+		WithLogRoutes(),
+	)
+	if err != nil {
+		t.Fatalf("NewWithOptions() error = %v", err)
+	}
+
+	s := ws.httpServer
+	if s.Addr != ":3000" || s.ReadTimeout != time.Second || s.WriteTimeout != 2*time.Second {
+		t.Errorf("server = %q %v %v, want :3000 1s 2s", s.Addr, s.ReadTimeout, s.WriteTimeout)
+	}
+	if s.IdleTimeout != defaultServer().IdleTimeout {
+		t.Errorf("IdleTimeout = %v, want the default", s.IdleTimeout)
+	}
+	if !ws.config.LogRoutes {
+		t.Error("LogRoutes not set")
+	}
+
+	rr := httptest.NewRecorder()
+	ws.Handler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/assets/tailwind.css", nil))
+	if rr.Code != http.StatusOK {
+		t.Errorf("static asset status = %d, want %d", rr.Code, http.StatusOK)
+	}
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	t.Setenv(EnvPort, "9000")
+	t.Setenv(EnvReadHeaderTimeout, "3s")
+	t.Setenv(EnvMaxHeaderBytes, "4096")
+	t.Setenv(EnvStatic, "/static=./example/static, /img=./example/static")
+	t.Setenv(EnvDev, "true")
+	t.Setenv(EnvDevWatch, "./example/static")
+	t.Setenv(EnvLogLevel, "debug")
+
+	ws, err := NewWithOptions(WithAddr(":3000"), ConfigFromEnv())
+	if err != nil {
+		t.Fatalf("NewWithOptions() error = %v", err)
+	}
+
+	s := ws.httpServer
+	if s.Addr != ":9000" || s.ReadHeaderTimeout != 3*time.Second || s.MaxHeaderBytes != 4096 {
+		t.Errorf("server = %q %v %d, want :9000 3s 4096", s.Addr, s.ReadHeaderTimeout, s.MaxHeaderBytes)
+	}
+	if got := len(ws.config.StaticAssetsFS); got != 2 {
+		t.Errorf("len(StaticAssetsFS) = %d, want 2", got)
+	}
+	if !ws.config.DevMode || len(ws.config.DevWatch) != 1 {
+		t.Errorf("DevMode = %v, DevWatch = %v", ws.config.DevMode, ws.config.DevWatch)
+	}
+	if ws.config.Logger == nil {
+		t.Error("Logger not set from GOTTH_LOG_LEVEL")
+	}
+
+	t.Setenv(EnvAddr, "127.0.0.1:8081")
+	ws, err = NewWithOptions(ConfigFromEnv())
+	if err != nil {
+		t.Fatalf("NewWithOptions() error = %v", err)
+	}
+	if ws.httpServer.Addr != "127.0.0.1:8081" {
+		t.Errorf("Addr = %q, want GOTTH_ADDR to win over PORT", ws.httpServer.Addr)
+	}
+}
+
+func TestConfigFromEnv_Invalid(t *testing.T) {
+	tests := []struct {
+		name, key, value string
+	}{
+		{name: "duration", key: EnvWriteTimeout, value: "10"},
+		{name: "int", key: EnvMaxHeaderBytes, value: "1MB"},
+		{name: "bool", key: EnvDev, value: "sure"},
+		{name: "static", key: EnvStatic, value: "./static"},
+		{name: "log level", key: EnvLogLevel, value: "loud"},
+		{name: "log format", key: EnvLogFormat, value: "xml"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(tt.key, tt.value)
+			if _, err := NewWithOptions(ConfigFromEnv()); err == nil {
+				t.Errorf("NewWithOptions() with %s=%q: expected an error", tt.key, tt.value)
+			}
+		})
+	}
+}
+
+func TestConfigFromEnv_FirstInvalid(t *testing.T) {
+	t.Setenv(EnvReadTimeout, "soon")
+	t.Setenv(EnvIdleTimeout, "later")
+	t.Setenv(EnvDev, "sure")
+	t.Setenv(EnvReusePort, "maybe")
+	for range 10 {
+		_, err := NewWithOptions(ConfigFromEnv())
+		if err == nil || !strings.Contains(err.Error(), EnvReadTimeout) {
+			t.Fatalf("NewWithOptions() error = %v, want the %s error", err, EnvReadTimeout)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+// Package pdf renders HTML documents to PDF with a pluggable headless browser, for the
+// invoices and reports built from the same templ components as the pages (see
+// gotth.WebServer.ServePrintable). Size and margins come from the CSS of the page, e.g.
+// "@page { size: A4; margin: 2cm }", and Tailwind's print: variant styles the printed version.
+//
+// The default Renderer runs a Chrome or Chromium executable in headless mode. To use a browser
+// driven by chromedp, or a rendering service like Gotenberg, adapt it with RendererFunc:
+//
+//	renderer := pdf.RendererFunc(func(ctx context.Context, doc pdf.Document) ([]byte, error) {
+//		var out []byte
+//		err := chromedp.Run(ctx, chromedp.Navigate("about:blank"), setContent(doc.HTML),
+//			chromedp.ActionFunc(func(ctx context.Context) (err error) {
+//				out, _, err = page.PrintToPDF().WithPrintBackground(true).Do(ctx)
+//				return err
+//			}))
+//		return out, err
+//	})
+package pdf
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultChromeBinary is the Chrome executable looked up in the PATH.
+const DefaultChromeBinary = "chromium"
+
+// Document is an HTML document to render.
+type Document struct {
+	HTML []byte
+	// BaseURL resolves the relative URLs of the document, e.g. of its stylesheets and images,
+	// usually the URL of the page. Optional.
+	BaseURL string
+}
+
+// Renderer renders a document to PDF and returns the content of the PDF.
+type Renderer interface {
+	Render(ctx context.Context, doc Document) ([]byte, error)
+}
+
+// RendererFunc adapts a function to a Renderer.
+type RendererFunc func(ctx context.Context, doc Document) ([]byte, error)
+
+// Render calls f.
+func (f RendererFunc) Render(ctx context.Context, doc Document) ([]byte, error) {
+	return f(ctx, doc)
+}
+
+// Chrome returns a Renderer running the Chrome or Chromium executable at binary (looked up in
+// the PATH when it has no path separator) with --headless --print-to-pdf, one process per
+// document. args are added to the command line, e.g. "--no-sandbox" to run as root in a
+// container.
+//
+// The document is loaded from a temporary file with a <base> of its BaseURL, so its
+// stylesheets are fetched from the server.
+func Chrome(binary string, args ...string) Renderer {
+	return RendererFunc(func(ctx context.Context, doc Document) ([]byte, error) {
+		dir, err := os.MkdirTemp("", "gotth-pdf-")
+		if err != nil {
+			return nil, fmt.Errorf("failed to create temporary directory. err %w", err)
+		}
+		defer os.RemoveAll(dir)
+
+		in, out := filepath.Join(dir, "document.html"), filepath.Join(dir, "document.pdf")
+		document := doc.HTML
+		if doc.BaseURL != "" {
+			document = withBase(document, doc.BaseURL)
+		}
+		if err := os.WriteFile(in, document, 0o600); err != nil {
+			return nil, fmt.Errorf("failed to write document. err %w", err)
+		}
+
+		cmdArgs := append([]string{
+			"--headless",
+			"--disable-gpu",
+			"--no-first-run",
+			"--no-pdf-header-footer",
+			"--user-data-dir=" + filepath.Join(dir, "profile"),
+			"--print-to-pdf=" + out,
+		}, args...)
+		var stderr bytes.Buffer
+		cmd := exec.CommandContext(ctx, binary, append(cmdArgs, "file://"+filepath.ToSlash(in))...)
+		cmd.Stderr = &stderr
+		if err := cmd.Run(); err != nil {
+			return nil, fmt.Errorf("failed to run %s. err %w: %s", binary, err, strings.TrimSpace(stderr.String()))
+		}
+		pdf, err := os.ReadFile(out)
+		if err != nil {
+			return nil, fmt.Errorf("%s printed no PDF. err %w: %s", binary, err, strings.TrimSpace(stderr.String()))
+		}
+		return pdf, nil
+	})
+}
+
+// withBase adds a <base href> of baseURL at the start of the head of document, or of the
+// document when it has no head.
+func withBase(document []byte, baseURL string) []byte {
+	base := []byte(`<base href="` + html.EscapeString(baseURL) + `">`)
+	// ASCII only, so that the indexes match document.
+	lower := make([]byte, len(document))
+	for i, c := range document {
+		if 'A' <= c && c <= 'Z' {
+			c += 'a' - 'A'
+		}
+		lower[i] = c
+	}
+	i := 0
+	for h := 0; ; {
+		n := bytes.Index(lower[h:], []byte("<head"))
+		if n < 0 {
+			break
+		}
+		h += n + len("<head")
+		// Not <header>.
+		if h < len(document) && strings.IndexByte("> \t\r\n", document[h]) >= 0 {
+			if end := bytes.IndexByte(document[h:], '>'); end >= 0 {
+				i = h + end + 1
+			}
+			break
+		}
+	}
+	out := make([]byte, 0, len(document)+len(base))
+	out = append(out, document[:i]...)
+	out = append(out, base...)
+	return append(out, document[i:]...)
+}
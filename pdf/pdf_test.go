@@ -0,0 +1,66 @@
+package pdf_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/ancalabrese/gotth/pdf"
+)
+
+// fakeChrome writes a script printing the document it's given, and its arguments, as the PDF.
+func fakeChrome(t *testing.T) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("needs a shell")
+	}
+	script := `#!/bin/sh
+out=""
+for arg in "$@"; do
+	case "$arg" in
+	--print-to-pdf=*) out="${arg#--print-to-pdf=}" ;;
+	file://*) in="${arg#file://}" ;;
+	esac
+done
+[ "$FAIL" = "1" ] && { echo "cannot open display" >&2; exit 1; }
+{ echo "$@"; cat "$in"; } > "$out"
+`
+	path := filepath.Join(t.TempDir(), "chrome")
+	if err := os.WriteFile(path, []byte(script), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestChrome(t *testing.T) {
+	r := pdf.Chrome(fakeChrome(t), "--no-sandbox")
+	got, err := r.Render(context.Background(), pdf.Document{
+		HTML:    []byte(`<!doctype html><html><HEAD lang="en"><title>Invoice</title></head><body><header>Acme</header></body></html>`),
+		BaseURL: "https://example.com/invoices/42?a=1&b=2",
+	})
+	if err != nil {
+		t.Fatalf("Render() = %v", err)
+	}
+	args, document, _ := strings.Cut(string(got), "\n")
+	for _, want := range []string{"--headless", "--no-pdf-header-footer", "--no-sandbox"} {
+		if !strings.Contains(args, want) {
+			t.Errorf("arguments %q, want %s", args, want)
+		}
+	}
+	if want := `<html><HEAD lang="en"><base href="https://example.com/invoices/42?a=1&amp;b=2"><title>`; !strings.Contains(document, want) {
+		t.Errorf("document = %q, want the base at the start of the head", document)
+	}
+
+	got, err = r.Render(context.Background(), pdf.Document{HTML: []byte(`<header>Acme</header>`), BaseURL: "/"})
+	if err != nil || !strings.HasSuffix(string(got), "\n"+`<base href="/"><header>Acme</header>`) {
+		t.Errorf("Render() without head = %q, %v, want the base first", got, err)
+	}
+
+	t.Setenv("FAIL", "1")
+	if _, err := r.Render(context.Background(), pdf.Document{HTML: []byte("<p>")}); err == nil || !strings.Contains(err.Error(), "cannot open display") {
+		t.Errorf("Render() of a failing browser = %v, want its stderr", err)
+	}
+}
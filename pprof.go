@@ -0,0 +1,35 @@
+package gotth
+
+import (
+	"net/http"
+	"net/http/pprof"
+	"time"
+)
+
+// enablePprof mounts the net/http/pprof handlers under /debug/pprof/, behind guard when set.
+func (ws *WebServer) enablePprof(guard func(http.Handler) http.Handler) {
+	if guard == nil {
+		guard = func(next http.Handler) http.Handler { return next }
+		ws.logger.Warn("pprof is enabled without a guard: anyone can profile this server")
+	}
+
+	handlers := map[string]http.HandlerFunc{
+		"/debug/pprof/":        pprof.Index,
+		"/debug/pprof/cmdline": pprof.Cmdline,
+		"/debug/pprof/profile": withoutWriteDeadline(pprof.Profile),
+		"/debug/pprof/symbol":  pprof.Symbol,
+		"/debug/pprof/trace":   withoutWriteDeadline(pprof.Trace),
+	}
+	for path, h := range handlers {
+		ws.handle(path, guard(h), RouteKindBuiltin, 0)
+	}
+}
+
+// withoutWriteDeadline lifts the server WriteTimeout for handlers that stream for a requested
+// duration (e.g., a 30 seconds CPU profile), which would otherwise be cut short.
+func withoutWriteDeadline(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		http.NewResponseController(w).SetWriteDeadline(time.Time{})
+		h(w, r)
+	}
+}
@@ -0,0 +1,228 @@
+// Package preview lets editors review unpublished content on production: the drafts stay hidden
+// from everyone but the requests carrying a signed, expiring preview token.
+//
+//	pv, err := preview.New(secret, preview.DefaultConfig())
+//	...
+//	ws, err := gotth.New(cfg, nil, gotth.WithMiddlewares(pv.Middleware))
+//	link, err := pv.Link("https://example.com/blog/upcoming") // Share it with the editors
+//
+// Opening a link stores the token in a cookie and redirects to the page without it, so the
+// whole site is previewed until the token expires or [Preview.ExitHandler] is visited. Content
+// providers show their drafts when [Enabled] reports a preview, e.g. the blog package. The
+// responses of a preview are never cached nor indexed.
+package preview
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultCookieName = "gotth_preview"
+	DefaultParam      = "preview"
+	DefaultTTL        = 24 * time.Hour
+
+	enabledKey contextEnabledKeyType = "gotth_preview_key"
+)
+
+type contextEnabledKeyType string
+
+var (
+	// ErrInvalidToken is returned when a preview token is malformed or its signature is wrong.
+	ErrInvalidToken = errors.New("invalid preview token")
+	// ErrExpiredToken is returned when a preview token has expired.
+	ErrExpiredToken = errors.New("expired preview token")
+)
+
+// Config configures the previews. Use [DefaultConfig] as a starting point.
+type Config struct {
+	// Name of the cookie holding the token.
+	CookieName string
+	// Name of the query parameter holding the token in the links.
+	Param string
+	// TTL is how long a token is valid. Defaults to [DefaultTTL].
+	TTL time.Duration
+	// Secure restricts the cookie to HTTPS connections.
+	Secure bool
+}
+
+// DefaultConfig returns the default Config: HTTPS only cookies and tokens valid for a day.
+func DefaultConfig() Config {
+	return Config{
+		CookieName: DefaultCookieName,
+		Param:      DefaultParam,
+		TTL:        DefaultTTL,
+		Secure:     true,
+	}
+}
+
+// Preview issues and verifies the preview tokens. Instantiate via New.
+//
+// Tokens are stateless and can be shared until they expire: anyone holding a link sees the
+// drafts, so keep the TTL as short as the review needs.
+type Preview struct {
+	secret []byte
+	cfg    Config
+	now    func() time.Time
+}
+
+// New creates a Preview. secret signs the tokens and must be kept private.
+func New(secret []byte, cfg Config) (*Preview, error) {
+	if len(secret) == 0 {
+		return nil, errors.New("preview secret is empty")
+	}
+	if cfg.CookieName == "" {
+		cfg.CookieName = DefaultCookieName
+	}
+	if cfg.Param == "" {
+		cfg.Param = DefaultParam
+	}
+	if cfg.TTL <= 0 {
+		cfg.TTL = DefaultTTL
+	}
+	return &Preview{secret: secret, cfg: cfg, now: time.Now}, nil
+}
+
+// Token returns a new token, valid for Config.TTL.
+func (p *Preview) Token() string {
+	payload := base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(p.now().Add(p.cfg.TTL).Unix(), 10)))
+	return payload + "." + p.sign(payload)
+}
+
+// Link returns u with a new token in its query, e.g. the URL of a draft to share with the
+// editors.
+func (p *Preview) Link(u string) (string, error) {
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return "", err
+	}
+	q := parsed.Query()
+	q.Set(p.cfg.Param, p.Token())
+	parsed.RawQuery = q.Encode()
+	return parsed.String(), nil
+}
+
+// Verify checks token and returns when it expires.
+func (p *Preview) Verify(token string) (time.Time, error) {
+	payload, sig, ok := strings.Cut(token, ".")
+	if !ok || !hmac.Equal([]byte(sig), []byte(p.sign(payload))) {
+		return time.Time{}, ErrInvalidToken
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return time.Time{}, ErrInvalidToken
+	}
+	exp, err := strconv.ParseInt(string(raw), 10, 64)
+	if err != nil {
+		return time.Time{}, ErrInvalidToken
+	}
+	expiresAt := time.Unix(exp, 0)
+	if !p.now().Before(expiresAt) {
+		return time.Time{}, ErrExpiredToken
+	}
+	return expiresAt, nil
+}
+
+// Middleware enables the preview of the requests with a valid token, see [Enabled].
+//
+// A token in the query is moved to the cookie: GET requests are redirected to their URL without
+// it, so that it doesn't stay in the address bar nor leak in the Referer header. Invalid or
+// expired cookies are deleted. The responses of a preview get "Cache-Control: private,
+// no-store" and "X-Robots-Tag: noindex".
+func (p *Preview) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if token := r.URL.Query().Get(p.cfg.Param); token != "" {
+			if expiresAt, err := p.Verify(token); err == nil {
+				p.setCookie(w, token, expiresAt)
+				if r.Method == http.MethodGet || r.Method == http.MethodHead {
+					u := *r.URL
+					q := u.Query()
+					q.Del(p.cfg.Param)
+					u.RawQuery = q.Encode()
+					w.Header().Set("Cache-Control", "no-store")
+					w.Header().Set("Referrer-Policy", "no-referrer")
+					http.Redirect(w, r, u.RequestURI(), http.StatusSeeOther)
+					return
+				}
+				p.serve(w, r, next)
+				return
+			}
+		}
+
+		if c, err := r.Cookie(p.cfg.CookieName); err == nil {
+			if _, err := p.Verify(c.Value); err == nil {
+				p.serve(w, r, next)
+				return
+			}
+			p.deleteCookie(w)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (p *Preview) serve(w http.ResponseWriter, r *http.Request, next http.Handler) {
+	w.Header().Set("Cache-Control", "private, no-store")
+	w.Header().Set("X-Robots-Tag", "noindex")
+	next.ServeHTTP(w, r.WithContext(WithEnabled(r.Context())))
+}
+
+// ExitHandler returns a handler ending the preview: it deletes the cookie and redirects to
+// redirectTo, e.g. "/".
+func (p *Preview) ExitHandler(redirectTo string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p.deleteCookie(w)
+		w.Header().Set("Cache-Control", "no-store")
+		http.Redirect(w, r, redirectTo, http.StatusSeeOther)
+	})
+}
+
+func (p *Preview) setCookie(w http.ResponseWriter, token string, expiresAt time.Time) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     p.cfg.CookieName,
+		Value:    token,
+		Path:     "/",
+		Expires:  expiresAt,
+		HttpOnly: true,
+		Secure:   p.cfg.Secure,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+func (p *Preview) deleteCookie(w http.ResponseWriter) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     p.cfg.CookieName,
+		Value:    "",
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   p.cfg.Secure,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+func (p *Preview) sign(payload string) string {
+	mac := hmac.New(sha256.New, p.secret)
+	mac.Write([]byte("gotth-preview\n"))
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// Enabled reports whether the request of ctx is a preview, and should see the drafts.
+func Enabled(ctx context.Context) bool {
+	enabled, _ := ctx.Value(enabledKey).(bool)
+	return enabled
+}
+
+// WithEnabled returns a copy of ctx in preview, e.g. for the requests of the logged in editors
+// or in tests.
+func WithEnabled(ctx context.Context) context.Context {
+	return context.WithValue(ctx, enabledKey, true)
+}
@@ -0,0 +1,98 @@
+package preview
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestPreview_Verify(t *testing.T) {
+	p, err := New([]byte("secret"), DefaultConfig())
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	now := time.Now()
+	p.now = func() time.Time { return now }
+	other, _ := New([]byte("other"), DefaultConfig())
+
+	token := p.Token()
+	if exp, err := p.Verify(token); err != nil || exp.Unix() != now.Add(DefaultTTL).Unix() {
+		t.Errorf("Verify() = %v, %v", exp, err)
+	}
+	for _, bad := range []string{"", "nope", token + "x", "MTIz." + token[len("MTIz."):]} {
+		if _, err := p.Verify(bad); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("Verify(%q) error = %v, want %v", bad, err, ErrInvalidToken)
+		}
+	}
+	if _, err := other.Verify(token); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Verify() with another secret error = %v, want %v", err, ErrInvalidToken)
+	}
+
+	p.now = func() time.Time { return now.Add(DefaultTTL) }
+	if _, err := p.Verify(token); !errors.Is(err, ErrExpiredToken) {
+		t.Errorf("Verify() after the TTL error = %v, want %v", err, ErrExpiredToken)
+	}
+
+	if _, err := New(nil, DefaultConfig()); err == nil {
+		t.Error("New() with an empty secret error = nil")
+	}
+}
+
+func TestPreview_Middleware(t *testing.T) {
+	p, _ := New([]byte("secret"), DefaultConfig())
+	h := p.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if Enabled(r.Context()) {
+			w.Write([]byte("preview"))
+		}
+	}))
+	serve := func(r *http.Request) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, r)
+		return rec
+	}
+
+	link, err := p.Link("https://example.com/blog/upcoming?ref=mail")
+	if err != nil {
+		t.Fatalf("Link() error = %v", err)
+	}
+	u, _ := url.Parse(link)
+
+	// The link moves the token to the cookie.
+	rec := serve(httptest.NewRequest(http.MethodGet, u.RequestURI(), nil))
+	if rec.Code != http.StatusSeeOther || rec.Header().Get("Location") != "/blog/upcoming?ref=mail" {
+		t.Fatalf("link = %d to %q, want a redirect without the token", rec.Code, rec.Header().Get("Location"))
+	}
+	cookies := rec.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != DefaultCookieName || !cookies[0].HttpOnly || !cookies[0].Secure {
+		t.Fatalf("cookies = %+v", cookies)
+	}
+
+	// The cookie enables the preview.
+	req := httptest.NewRequest(http.MethodGet, "/blog/upcoming", nil)
+	req.AddCookie(cookies[0])
+	rec = serve(req)
+	if rec.Body.String() != "preview" || rec.Header().Get("Cache-Control") != "private, no-store" || rec.Header().Get("X-Robots-Tag") != "noindex" {
+		t.Errorf("with the cookie = %q, headers %v", rec.Body.String(), rec.Header())
+	}
+
+	// Other requests aren't previews, and invalid cookies are deleted.
+	if rec := serve(httptest.NewRequest(http.MethodGet, "/blog/upcoming", nil)); rec.Body.Len() != 0 || rec.Header().Get("Cache-Control") != "" {
+		t.Errorf("without a token = %q, headers %v", rec.Body.String(), rec.Header())
+	}
+	req = httptest.NewRequest(http.MethodGet, "/blog/upcoming?preview=forged", nil)
+	req.AddCookie(&http.Cookie{Name: DefaultCookieName, Value: "forged.token"})
+	rec = serve(req)
+	if rec.Body.Len() != 0 || len(rec.Result().Cookies()) != 1 || rec.Result().Cookies()[0].MaxAge >= 0 {
+		t.Errorf("with a forged token = %q, cookies %+v", rec.Body.String(), rec.Result().Cookies())
+	}
+
+	// ExitHandler ends the preview.
+	rec = httptest.NewRecorder()
+	p.ExitHandler("/").ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/preview/exit", nil))
+	if c := rec.Result().Cookies(); rec.Header().Get("Location") != "/" || len(c) != 1 || c[0].MaxAge >= 0 {
+		t.Errorf("exit = %q, cookies %+v", rec.Header().Get("Location"), c)
+	}
+}
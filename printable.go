@@ -0,0 +1,105 @@
+package gotth
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/ancalabrese/gotth/pdf"
+	"github.com/ancalabrese/gotth/routes"
+	"github.com/ancalabrese/gotth/views/components/layout"
+)
+
+// ServePrintable adds a page served like ServeContent, and its PDF rendered by renderer at the
+// same path plus ".pdf", e.g. for invoices and reports:
+//
+//	ws.ServePrintable("GET /invoices/{id}", invoice, pdf.Chrome(pdf.DefaultChromeBinary))
+//
+// serves /invoices/42 and /invoices/42.pdf. The PDF is the full page rendered with the base
+// layout, so its stylesheets apply: style the printed version with CSS @page rules and print:
+// variants. The renderer fetches them from the URL of the request without its cookies, so the
+// static assets must be public. PDFs are displayed inline and named after the last path
+// segment, e.g. "42.pdf".
+//
+// When the pattern ends with a wildcard, the same route serves both, with the ".pdf" suffix
+// removed from the wildcard value: {id} is "42" for /invoices/42.pdf. Otherwise the PDF gets its
+// own route. Patterns ending with a slash or {$} can't have a PDF. The optional middlewares
+// wrap both, the first being the outermost, e.g. to require a login.
+func (ws *WebServer) ServePrintable(pattern string, contentProvider ContentProviderFunc, renderer pdf.Renderer, mws ...func(http.Handler) http.Handler) {
+	method, p := "", pattern
+	if m, rest, ok := strings.Cut(pattern, " "); ok {
+		method, p = m+" ", strings.TrimSpace(rest)
+	}
+	if contentProvider == nil || renderer == nil || !strings.Contains(p, "/") || strings.HasSuffix(p, "/") || strings.HasSuffix(p, "{$}") {
+		ws.registrationFailed(fmt.Errorf("%w %q registered at %s: ServePrintable needs a pattern not ending with a slash, a ContentProviderFunc and a Renderer",
+			ErrInvalidRoute, pattern, callerSource()))
+		return
+	}
+
+	page := ws.pageHandler(pattern, contentProvider, nil)
+	printable := ws.pdfHandler(pattern, contentProvider, renderer)
+	wrap := func(handler http.Handler) http.Handler {
+		for i := len(mws) - 1; i >= 0; i-- {
+			handler = mws[i](handler)
+		}
+		return handler
+	}
+
+	if !strings.HasSuffix(p, "}") {
+		if ws.handle(pattern, wrap(page), RouteKindPage, len(mws)) == nil {
+			ws.handle(method+p+".pdf", wrap(printable), RouteKindPage, len(mws))
+		}
+		return
+	}
+	wildcard := strings.TrimSuffix(p[strings.LastIndex(p, "{")+1:len(p)-1], "...")
+	ws.handle(pattern, wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if v, ok := strings.CutSuffix(r.PathValue(wildcard), ".pdf"); ok {
+			r.SetPathValue(wildcard, v)
+			printable.ServeHTTP(w, r)
+			return
+		}
+		page.ServeHTTP(w, r)
+	})), RouteKindPage, len(mws))
+}
+
+// pdfHandler returns the handler of the PDF of the page of contentProvider registered at
+// pattern.
+func (ws *WebServer) pdfHandler(pattern string, contentProvider ContentProviderFunc, renderer pdf.Renderer) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headVM, pageContent, err := contentProvider(r)
+		if errors.Is(err, routes.ErrNotFound) {
+			ws.logger.DebugContext(r.Context(), "content not found", ws.requestAttrs(r, pattern, err)...)
+			http.NotFound(w, r)
+			return
+		}
+		if err != nil {
+			ws.logger.ErrorContext(r.Context(), "content provider failed", ws.requestAttrs(r, pattern, err)...)
+			ws.serveError(w, r, err)
+			return
+		}
+
+		var html bytes.Buffer
+		if err := layout.BasicLayout(headVM, pageContent).Render(r.Context(), &html); err != nil {
+			ws.logger.ErrorContext(r.Context(), "failed to render page", ws.requestAttrs(r, pattern, err)...)
+			ws.serveError(w, r, err)
+			return
+		}
+		scheme := "http"
+		if r.TLS != nil {
+			scheme = "https"
+		}
+		baseURL := scheme + "://" + r.Host + strings.TrimSuffix(r.URL.EscapedPath(), ".pdf")
+		doc, err := renderer.Render(r.Context(), pdf.Document{HTML: html.Bytes(), BaseURL: baseURL})
+		if err != nil {
+			ws.logger.ErrorContext(r.Context(), "failed to render PDF", ws.requestAttrs(r, pattern, err)...)
+			ws.serveError(w, r, err)
+			return
+		}
+
+		ServeDownload(w, r, bytes.NewReader(doc), path.Base(r.URL.Path), int64(len(doc)), time.Time{}, DownloadInline())
+	})
+}
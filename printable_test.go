@@ -0,0 +1,97 @@
+package gotth_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/a-h/templ"
+	"github.com/ancalabrese/gotth"
+	"github.com/ancalabrese/gotth/content"
+	"github.com/ancalabrese/gotth/pdf"
+	"github.com/ancalabrese/gotth/views/components/head"
+)
+
+func TestWebServer_ServePrintable(t *testing.T) {
+	invoice := func(r *http.Request) (head.HeadViewModel, templ.Component, error) {
+		id := r.PathValue("id")
+		if id == "" {
+			id = "report"
+		}
+		if id == "404" {
+			return head.HeadViewModel{}, nil, fmt.Errorf("invoice %s: %w", id, content.ErrNotFound)
+		}
+		return head.NewHeadViewModel(head.WithPageCoreMetadata("Invoice "+id, "", "")), templ.ComponentFunc(func(ctx context.Context, w io.Writer) error {
+			_, err := io.WriteString(w, "<h1>Invoice "+id+"</h1>")
+			return err
+		}), nil
+	}
+	var baseURL string
+	renderer := pdf.RendererFunc(func(ctx context.Context, doc pdf.Document) ([]byte, error) {
+		baseURL = doc.BaseURL
+		return append([]byte("%PDF-1.7\n"), doc.HTML...), nil
+	})
+
+	ws, err := gotth.New(gotth.WebServerConfig{}, nil)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	ws.ServePrintable("GET /invoices/{id}", invoice, renderer)
+	ws.ServePrintable("GET /report", invoice, renderer)
+	if err := ws.Err(); err != nil {
+		t.Fatalf("Err() = %v", err)
+	}
+	ws.ServePrintable("GET /docs/", invoice, renderer)
+	if err := ws.Err(); err == nil || !strings.Contains(err.Error(), "ServePrintable needs") {
+		t.Errorf("Err() = %v, want the pattern ending with a slash", err)
+	}
+
+	tests := []struct {
+		path        string
+		status      int
+		contentType string
+		body        string
+	}{
+		{path: "/invoices/42", status: http.StatusOK, contentType: "text/html; charset=utf-8", body: "<h1>Invoice 42</h1>"},
+		{path: "/invoices/42.pdf", status: http.StatusOK, contentType: "application/pdf", body: "<h1>Invoice 42</h1>"},
+		{path: "/invoices/404.pdf", status: http.StatusNotFound},
+		{path: "/report", status: http.StatusOK, contentType: "text/html; charset=utf-8", body: "<h1>Invoice report</h1>"},
+		{path: "/report.pdf", status: http.StatusOK, contentType: "application/pdf", body: "<h1>Invoice report</h1>"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			ws.Handler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if rr.Code != tt.status {
+				t.Fatalf("status = %d, want %d", rr.Code, tt.status)
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+			if got := rr.Header().Get("Content-Type"); got != tt.contentType {
+				t.Errorf("Content-Type = %q, want %q", got, tt.contentType)
+			}
+			if !strings.Contains(rr.Body.String(), tt.body) {
+				t.Errorf("body = %q, want %q", rr.Body, tt.body)
+			}
+			if tt.contentType != "application/pdf" {
+				return
+			}
+			// The full page, with its head and stylesheets.
+			if !strings.HasPrefix(rr.Body.String(), "%PDF-1.7\n<!doctype html>") {
+				t.Errorf("PDF of %q, want the full page", rr.Body)
+			}
+			name := tt.path[strings.LastIndex(tt.path, "/")+1:]
+			if got := rr.Header().Get("Content-Disposition"); got != "inline; filename="+name {
+				t.Errorf("Content-Disposition = %q", got)
+			}
+			if want := "http://example.com" + strings.TrimSuffix(tt.path, ".pdf"); baseURL != want {
+				t.Errorf("BaseURL = %q, want %q", baseURL, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,196 @@
+// Package problem answers the errors of JSON endpoints with the problem details of RFC 9457
+// (formerly RFC 7807): an application/problem+json object with the status, a title and a
+// detail safe to show to the client, and extension members, e.g. the invalid fields.
+//
+//	ws.Handle("POST /api/posts", problem.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+//		q, err := gotth.BindQuery[postQuery](r)
+//		if err != nil {
+//			return err // 400 with the invalid parameters in "errors"
+//		}
+//		if !allowed(r) {
+//			return problem.New(http.StatusForbidden, "You can't publish posts.")
+//		}
+//		...
+//	}))
+//
+// Errors other than HTTPError answer with a 500 without detail, so that internal messages
+// don't leak to the clients.
+package problem
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"maps"
+	"net/http"
+	"strconv"
+
+	"github.com/ancalabrese/gotth/forms"
+	"github.com/ancalabrese/gotth/middlewares"
+	"github.com/ancalabrese/gotth/routes"
+)
+
+// ContentType is the media type of the problem details.
+const ContentType = "application/problem+json"
+
+// HTTPError is an error answered with its problem details.
+type HTTPError struct {
+	// Status is the HTTP status of the response, 500 when 0.
+	Status int
+	// Optional: Type is a URI identifying the kind of problem, e.g.
+	// "https://example.com/problems/out-of-credit". It's "about:blank" when empty, and Title
+	// is then the status text.
+	Type string
+	// Title summarizes the kind of problem. Defaults to the status text, e.g. "Not Found".
+	Title string
+	// Detail explains this occurrence of the problem to the client, e.g. "Your balance is 30,
+	// but that costs 50."
+	Detail string
+	// Optional: Errors are the invalid fields of the request, in the "errors" member.
+	Errors []forms.ValidationError
+	// Optional: Extensions are additional members, e.g. {"balance": 30}.
+	Extensions map[string]any
+	// Err is the cause of the problem, for errors.Is and the logs, never sent to the client.
+	Err error
+}
+
+// New returns the HTTPError of status with detail.
+func New(status int, detail string) *HTTPError {
+	return &HTTPError{Status: status, Detail: detail}
+}
+
+// Wrap returns the HTTPError of status with detail caused by err.
+func Wrap(status int, detail string, err error) *HTTPError {
+	return &HTTPError{Status: status, Detail: detail, Err: err}
+}
+
+// Validation returns the 422 HTTPError of the invalid fields of a request, e.g. the Errors of a
+// forms.Form.
+func Validation(errs ...forms.ValidationError) *HTTPError {
+	return &HTTPError{Status: http.StatusUnprocessableEntity, Detail: "The request has invalid fields.", Errors: errs}
+}
+
+func (e *HTTPError) Error() string {
+	msg := strconv.Itoa(e.status()) + " " + e.title()
+	if e.Detail != "" {
+		msg += ": " + e.Detail
+	}
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+func (e *HTTPError) Unwrap() error {
+	return e.Err
+}
+
+func (e *HTTPError) status() int {
+	if e.Status == 0 {
+		return http.StatusInternalServerError
+	}
+	return e.Status
+}
+
+func (e *HTTPError) title() string {
+	if e.Title != "" {
+		return e.Title
+	}
+	return http.StatusText(e.status())
+}
+
+// fieldErrors is implemented by the errors listing the invalid fields of a request, e.g.
+// *gotth.QueryError.
+type fieldErrors interface {
+	error
+	FieldErrors() []forms.ValidationError
+}
+
+// FromError returns the HTTPError of err: err itself when it wraps an HTTPError, a 400 with the
+// invalid fields of errors with a FieldErrors method, e.g. *gotth.QueryError, a 404 for
+// routes.ErrNotFound (content.ErrNotFound), or a 500 without detail.
+func FromError(err error) *HTTPError {
+	var he *HTTPError
+	if errors.As(err, &he) {
+		return he
+	}
+	var fe fieldErrors
+	if errors.As(err, &fe) {
+		return &HTTPError{Status: http.StatusBadRequest, Detail: "The request has invalid parameters.", Errors: fe.FieldErrors(), Err: err}
+	}
+	if errors.Is(err, routes.ErrNotFound) {
+		return &HTTPError{Status: http.StatusNotFound, Err: err}
+	}
+	return &HTTPError{Status: http.StatusInternalServerError, Err: err}
+}
+
+// Write answers with the problem details of err, see [FromError]. The instance member is the
+// request path, and the request_id member the ID of middlewares.RequestID.
+func Write(w http.ResponseWriter, r *http.Request, err error) {
+	he := FromError(err)
+	body, encErr := json.Marshal(he.members(r))
+	if encErr != nil {
+		// Extensions that can't be encoded.
+		he = &HTTPError{Status: he.status()}
+		body, _ = json.Marshal(he.members(r))
+	}
+	w.Header().Set("Content-Type", ContentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)+1))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(he.status())
+	w.Write(append(body, '\n'))
+}
+
+// members returns the members of the problem details of e for r.
+func (e *HTTPError) members(r *http.Request) map[string]any {
+	m := make(map[string]any, len(e.Extensions)+7)
+	maps.Copy(m, e.Extensions)
+	if e.Type != "" {
+		m["type"] = e.Type
+	}
+	m["title"] = e.title()
+	m["status"] = e.status()
+	if e.Detail != "" {
+		m["detail"] = e.Detail
+	}
+	m["instance"] = r.URL.Path
+	if id := middlewares.GetRequestID(r.Context()); id != "" {
+		m["request_id"] = id
+	}
+	if len(e.Errors) > 0 {
+		type fieldError struct {
+			Field  string `json:"field"`
+			Detail string `json:"detail"`
+		}
+		errs := make([]fieldError, len(e.Errors))
+		for i, v := range e.Errors {
+			errs[i] = fieldError{Field: v.Field, Detail: v.Message}
+		}
+		m["errors"] = errs
+	}
+	return m
+}
+
+// HandlerFunc is a JSON endpoint returning its error instead of writing it. It's an
+// http.Handler answering the errors with [Write], logging the server errors with
+// slog.Default():
+//
+//	ws.Handle("GET /api/posts/{slug}", problem.HandlerFunc(getPost))
+type HandlerFunc func(w http.ResponseWriter, r *http.Request) error
+
+func (fn HandlerFunc) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	err := fn(w, r)
+	if err == nil {
+		return
+	}
+	if status := FromError(err).status(); status >= http.StatusInternalServerError {
+		slog.Default().ErrorContext(r.Context(), "API handler failed",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+			slog.String("request_id", middlewares.GetRequestID(r.Context())),
+			slog.Int("status", status),
+			slog.Any("error", err),
+		)
+	}
+	Write(w, r, err)
+}
@@ -0,0 +1,107 @@
+package problem_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/ancalabrese/gotth"
+	"github.com/ancalabrese/gotth/content"
+	"github.com/ancalabrese/gotth/forms"
+	"github.com/ancalabrese/gotth/problem"
+)
+
+func TestWrite(t *testing.T) {
+	slog.SetDefault(slog.New(slog.DiscardHandler))
+	tests := []struct {
+		name   string
+		err    error
+		status int
+		want   map[string]any
+	}{
+		{
+			name:   "HTTPError",
+			err:    fmt.Errorf("charge: %w", &problem.HTTPError{Status: http.StatusForbidden, Type: "https://example.com/problems/out-of-credit", Title: "Out of credit", Detail: "Your balance is 30.", Extensions: map[string]any{"balance": 30, "status": 0}}),
+			status: http.StatusForbidden,
+			want:   map[string]any{"type": "https://example.com/problems/out-of-credit", "title": "Out of credit", "status": 403.0, "detail": "Your balance is 30.", "balance": 30.0, "instance": "/api/posts"},
+		},
+		{
+			name:   "Validation",
+			err:    problem.Validation(forms.ValidationError{Field: "title", Message: "is required"}),
+			status: http.StatusUnprocessableEntity,
+			want: map[string]any{"title": "Unprocessable Entity", "status": 422.0, "detail": "The request has invalid fields.", "instance": "/api/posts",
+				"errors": []any{map[string]any{"field": "title", "detail": "is required"}}},
+		},
+		{
+			name:   "Not found",
+			err:    fmt.Errorf("post %q: %w", "missing", content.ErrNotFound),
+			status: http.StatusNotFound,
+			want:   map[string]any{"title": "Not Found", "status": 404.0, "instance": "/api/posts"},
+		},
+		{
+			name:   "Internal error",
+			err:    errors.New("db: connection refused"),
+			status: http.StatusInternalServerError,
+			want:   map[string]any{"title": "Internal Server Error", "status": 500.0, "instance": "/api/posts"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			problem.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+				return tt.err
+			}).ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/api/posts", nil))
+
+			if rr.Code != tt.status || rr.Header().Get("Content-Type") != problem.ContentType {
+				t.Errorf("status = %d, Content-Type = %q, want %d and %q", rr.Code, rr.Header().Get("Content-Type"), tt.status, problem.ContentType)
+			}
+			var got map[string]any
+			if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+				t.Fatalf("body %q isn't JSON. err %v", rr.Body, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("body = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWrite_QueryError(t *testing.T) {
+	type listQuery struct {
+		gotth.Pagination
+	}
+	r := httptest.NewRequest(http.MethodGet, "/api/posts?page=0", nil)
+	_, err := gotth.BindQuery[listQuery](r)
+
+	rr := httptest.NewRecorder()
+	problem.Write(rr, r, err)
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want %d", rr.Code, http.StatusBadRequest)
+	}
+	var got struct {
+		Errors []struct{ Field, Detail string }
+	}
+	json.Unmarshal(rr.Body.Bytes(), &got)
+	if len(got.Errors) != 1 || got.Errors[0].Field != "page" || got.Errors[0].Detail != "must be at least 1" {
+		t.Errorf("errors = %+v, want the invalid page", got.Errors)
+	}
+}
+
+func TestHTTPError(t *testing.T) {
+	cause := errors.New("quota exceeded")
+	err := problem.Wrap(http.StatusTooManyRequests, "Try again in a minute.", cause)
+	if !errors.Is(err, cause) {
+		t.Error("errors.Is(Wrap(cause), cause) = false")
+	}
+	if want := "429 Too Many Requests: Try again in a minute.: quota exceeded"; err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err.Error(), want)
+	}
+	if got := problem.FromError(problem.New(0, "")); got.Status != 0 || got.Error() != "500 Internal Server Error" {
+		t.Errorf("FromError() = %v, want a 500", got)
+	}
+}
@@ -0,0 +1,120 @@
+package gotth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/ancalabrese/gotth/middlewares"
+)
+
+// DefaultProxyTimeout bounds the proxied requests without ProxyConfig.Timeout.
+const DefaultProxyTimeout = 30 * time.Second
+
+// ProxyConfig configures a route of [WebServer.Proxy].
+type ProxyConfig struct {
+	// StripPrefix removes the prefix from the forwarded path: /api/users reaches the target as
+	// /users instead of /api/users.
+	StripPrefix bool
+	// PreserveHost forwards the Host header of the request instead of the host of the target.
+	PreserveHost bool
+	// Optional: headers set on the forwarded requests, e.g. an internal API key. Empty values
+	// remove the header, e.g. "Cookie".
+	RequestHeaders map[string]string
+	// Optional: headers set on the proxied responses. Empty values remove the header, e.g.
+	// "Server".
+	ResponseHeaders map[string]string
+	// Timeout bounds a proxied request, response body included. Defaults to
+	// [DefaultProxyTimeout]; it also lifts the write timeout of the server for the route.
+	Timeout time.Duration
+	// Optional: transport of the forwarded requests. Defaults to a copy of
+	// http.DefaultTransport.
+	Transport http.RoundTripper
+}
+
+// Proxy forwards the requests under prefix, e.g. "/api", to target with an
+// httputil.ReverseProxy, so that gotth can front an internal service during a migration. The
+// forwarded requests get the X-Forwarded-For, X-Forwarded-Host and X-Forwarded-Proto headers,
+// and failures of the target answer with a 502 Bad Gateway, or 504 Gateway Timeout after
+// cfg.Timeout. The optional middlewares wrap the route, the first being the outermost, e.g. to
+// require a login:
+//
+//	api, _ := url.Parse("http://localhost:9000")
+//	ws.Proxy("/api", api, gotth.ProxyConfig{StripPrefix: true}, auth.Require(sessions))
+func (ws *WebServer) Proxy(prefix string, target *url.URL, cfg ProxyConfig, mws ...func(http.Handler) http.Handler) {
+	if !strings.HasPrefix(prefix, "/") || target == nil || target.Scheme == "" || target.Host == "" {
+		ws.registrationFailed(fmt.Errorf("%w %q registered at %s: Proxy needs a path prefix and an absolute target URL",
+			ErrInvalidRoute, prefix, callerSource()))
+		return
+	}
+	prefix = strings.TrimSuffix(prefix, "/")
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultProxyTimeout
+	}
+	if cfg.Transport == nil {
+		cfg.Transport = http.DefaultTransport.(*http.Transport).Clone()
+	}
+
+	rp := &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			if cfg.StripPrefix {
+				pr.Out.URL.Path = "/" + strings.TrimPrefix(strings.TrimPrefix(pr.In.URL.Path, prefix), "/")
+				pr.Out.URL.RawPath = ""
+			}
+			pr.SetURL(target)
+			pr.SetXForwarded()
+			if cfg.PreserveHost {
+				pr.Out.Host = pr.In.Host
+			}
+			setHeaders(pr.Out.Header, cfg.RequestHeaders)
+		},
+		Transport: cfg.Transport,
+		ModifyResponse: func(resp *http.Response) error {
+			setHeaders(resp.Header, cfg.ResponseHeaders)
+			return nil
+		},
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			if errors.Is(r.Context().Err(), context.Canceled) {
+				return // The client went away
+			}
+			status := http.StatusBadGateway
+			var netErr net.Error
+			if errors.Is(err, context.DeadlineExceeded) || errors.As(err, &netErr) && netErr.Timeout() {
+				status = http.StatusGatewayTimeout
+			}
+			ws.logger.ErrorContext(r.Context(), "proxy failed", ws.requestAttrs(r, prefix+"/", err)...)
+			middlewares.WriteErrorPage(w, r, status, nil)
+		},
+	}
+
+	var handler http.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), cfg.Timeout)
+		defer cancel()
+		http.NewResponseController(w).SetWriteDeadline(time.Now().Add(cfg.Timeout))
+		rp.ServeHTTP(w, r.WithContext(ctx))
+	})
+	for i := len(mws) - 1; i >= 0; i-- {
+		handler = mws[i](handler)
+	}
+	if ws.handle(prefix+"/", handler, RouteKindProxy, len(mws)) == nil && prefix != "" {
+		// The prefix itself, which http.ServeMux would redirect to prefix + "/".
+		ws.handle(prefix, handler, RouteKindProxy, len(mws))
+	}
+}
+
+// setHeaders sets the headers of values in h, removing the ones with an empty value.
+func setHeaders(h http.Header, values map[string]string) {
+	for k, v := range values {
+		if v == "" {
+			h.Del(k)
+			continue
+		}
+		h.Set(k, v)
+	}
+}
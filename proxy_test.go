@@ -0,0 +1,121 @@
+package gotth_test
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/ancalabrese/gotth"
+)
+
+func TestWebServer_Proxy(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(time.Second):
+			}
+			return
+		}
+		w.Header().Set("Server", "legacy/1.0")
+		w.Header().Set("X-Path", r.URL.Path+"?"+r.URL.RawQuery)
+		w.Header().Set("X-Key", r.Header.Get("X-Internal-Key"))
+		w.Header().Set("X-Cookie", r.Header.Get("Cookie"))
+		w.Header().Set("X-Forwarded", r.Header.Get("X-Forwarded-Host"))
+		io.WriteString(w, "legacy")
+	}))
+	defer upstream.Close()
+	target, _ := url.Parse(upstream.URL)
+
+	ws, err := gotth.New(gotth.WebServerConfig{}, nil)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	requireKey := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") == "" {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+	ws.Proxy("/api/", target, gotth.ProxyConfig{
+		StripPrefix:     true,
+		RequestHeaders:  map[string]string{"X-Internal-Key": "secret", "Cookie": ""},
+		ResponseHeaders: map[string]string{"Server": "", "X-Proxied": "true"},
+		Timeout:         50 * time.Millisecond,
+	}, requireKey)
+	ws.Proxy("/legacy", target, gotth.ProxyConfig{})
+	if err := ws.Err(); err != nil {
+		t.Fatalf("Err() = %v", err)
+	}
+
+	serve := func(path string, auth bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, nil)
+		req.Host = "example.com"
+		req.Header.Set("Cookie", "session=1")
+		if auth {
+			req.Header.Set("Authorization", "Bearer token")
+		}
+		rr := httptest.NewRecorder()
+		ws.Handler().ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := serve("/api/users?page=2", true)
+	if rr.Code != http.StatusOK || rr.Body.String() != "legacy" {
+		t.Fatalf("status = %d, body = %q", rr.Code, rr.Body)
+	}
+	for header, want := range map[string]string{
+		"X-Path":      "/users?page=2",
+		"X-Key":       "secret",
+		"X-Cookie":    "",
+		"X-Forwarded": "example.com",
+		"Server":      "",
+		"X-Proxied":   "true",
+	} {
+		if got := rr.Header().Get(header); got != want {
+			t.Errorf("%s = %q, want %q", header, got, want)
+		}
+	}
+	if rr := serve("/api", true); rr.Header().Get("X-Path") != "/?" {
+		t.Errorf("X-Path of the prefix = %q, want %q", rr.Header().Get("X-Path"), "/?")
+	}
+	if rr := serve("/legacy/users", false); rr.Header().Get("X-Path") != "/legacy/users?" || rr.Header().Get("X-Cookie") != "session=1" {
+		t.Errorf("X-Path = %q, X-Cookie = %q, want the unchanged request", rr.Header().Get("X-Path"), rr.Header().Get("X-Cookie"))
+	}
+
+	if rr := serve("/api/users", false); rr.Code != http.StatusUnauthorized {
+		t.Errorf("status without authorization = %d, want %d", rr.Code, http.StatusUnauthorized)
+	}
+	if rr := serve("/api/slow", true); rr.Code != http.StatusGatewayTimeout {
+		t.Errorf("status of a slow target = %d, want %d", rr.Code, http.StatusGatewayTimeout)
+	}
+}
+
+func TestWebServer_Proxy_Errors(t *testing.T) {
+	down := httptest.NewServer(http.NotFoundHandler())
+	target, _ := url.Parse(down.URL)
+	down.Close()
+
+	ws, err := gotth.New(gotth.WebServerConfig{}, nil)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	ws.Proxy("/api", target, gotth.ProxyConfig{})
+	rr := httptest.NewRecorder()
+	ws.Handler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/users", nil))
+	if rr.Code != http.StatusBadGateway {
+		t.Errorf("status of an unreachable target = %d, want %d", rr.Code, http.StatusBadGateway)
+	}
+
+	ws.Proxy("/relative", &url.URL{Path: "/users"}, gotth.ProxyConfig{})
+	if err := ws.Err(); !errors.Is(err, gotth.ErrInvalidRoute) {
+		t.Errorf("Err() = %v, want %v", err, gotth.ErrInvalidRoute)
+	}
+}
@@ -0,0 +1,107 @@
+package gotth
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ListenFDEnv and HTTPListenFDEnv name the environment variables passing inherited listening
+// sockets to a new process: the numbers of their file descriptors, for the server and for the
+// plain HTTP listener of [WebServerConfig.HTTPAddr]. The server serves them instead of binding
+// its addresses. Systemd socket activation (LISTEN_FDS and LISTEN_PID) is supported too, with
+// the sockets in the same order.
+const (
+	ListenFDEnv     = "GOTTH_LISTEN_FD"
+	HTTPListenFDEnv = "GOTTH_HTTP_LISTEN_FD"
+)
+
+// listen returns the listener for addr: the one inherited through env or the systemd socket at
+// sdIndex when there is one, or a new one bound to addr.
+func (ws *WebServer) listen(addr, env string, sdIndex int) (net.Listener, error) {
+	ln, err := inheritedListener(env, sdIndex)
+	if ln != nil || err != nil {
+		return ln, err
+	}
+
+	var lc net.ListenConfig
+	if ws.config.ReusePort {
+		lc.Control = reusePort
+	}
+	return lc.Listen(context.Background(), "tcp", addr)
+}
+
+// inheritedListener returns the listener passed by the parent process, or nil when there is
+// none. env is cleared, so that it doesn't leak to the children of this process.
+func inheritedListener(env string, sdIndex int) (net.Listener, error) {
+	fd := -1
+	if v := os.Getenv(env); v != "" {
+		os.Unsetenv(env)
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("failed to parse %s: %q is not a file descriptor", env, v)
+		}
+		fd = n
+	} else if os.Getenv("LISTEN_PID") == strconv.Itoa(os.Getpid()) {
+		if n, err := strconv.Atoi(os.Getenv("LISTEN_FDS")); err == nil && sdIndex < n {
+			fd = 3 + sdIndex // SD_LISTEN_FDS_START
+		}
+	}
+	if fd < 0 {
+		return nil, nil
+	}
+
+	f := os.NewFile(uintptr(fd), "inherited listener")
+	if f == nil {
+		return nil, fmt.Errorf("failed to inherit listener. err invalid file descriptor %d", fd)
+	}
+	defer f.Close()
+	ln, err := net.FileListener(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to inherit listener fd %d. err %w", fd, err)
+	}
+	return ln, nil
+}
+
+// startSuccessor starts a new process of the running executable, with the same arguments and
+// environment, handing it the listeners, each through the environment variable at the same
+// index of envs. The listening sockets stay open across the handover, so the connections
+// arriving meanwhile wait in their backlog instead of being refused.
+func startSuccessor(lns []net.Listener, envs []string) (*os.Process, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("failed to find the executable. err %w", err)
+	}
+	cmd := exec.Command(exe, os.Args[1:]...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	for _, kv := range os.Environ() {
+		name, _, _ := strings.Cut(kv, "=")
+		if !slices.Contains(envs, name) && name != "LISTEN_PID" && name != "LISTEN_FDS" {
+			cmd.Env = append(cmd.Env, kv)
+		}
+	}
+
+	for i, ln := range lns {
+		filer, ok := ln.(interface{ File() (*os.File, error) })
+		if !ok {
+			return nil, fmt.Errorf("failed to hand over listener. err %T has no file descriptor", ln)
+		}
+		f, err := filer.File()
+		if err != nil {
+			return nil, fmt.Errorf("failed to hand over listener. err %w", err)
+		}
+		defer f.Close()
+		// ExtraFiles start at file descriptor 3, after stdin, stdout and stderr.
+		cmd.Env = append(cmd.Env, envs[i]+"="+strconv.Itoa(3+i))
+		cmd.ExtraFiles = append(cmd.ExtraFiles, f)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start the new process. err %w", err)
+	}
+	return cmd.Process, nil
+}
@@ -0,0 +1,75 @@
+package gotth
+
+import (
+	"net"
+	"os"
+	"runtime"
+	"strconv"
+	"testing"
+)
+
+func TestWebServer_ReusePort(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("SO_REUSEPORT is not supported on windows")
+	}
+
+	old, err := NewWithOptions(WithReusePort())
+	if err != nil {
+		t.Fatalf("NewWithOptions() error = %v", err)
+	}
+	ln, err := old.listen("127.0.0.1:0", ListenFDEnv, 0)
+	if err != nil {
+		t.Fatalf("listen() error = %v", err)
+	}
+	defer ln.Close()
+	addr := ln.Addr().String()
+
+	next, _ := NewWithOptions(WithReusePort())
+	ln2, err := next.listen(addr, ListenFDEnv, 0)
+	if err != nil {
+		t.Fatalf("listen() with ReusePort on a bound address error = %v", err)
+	}
+	ln2.Close()
+
+	without, _ := NewWithOptions()
+	if ln3, err := without.listen(addr, ListenFDEnv, 0); err == nil {
+		ln3.Close()
+		t.Error("listen() without ReusePort on a bound address succeeded")
+	}
+}
+
+func TestWebServer_InheritedListener(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("listeners can't be inherited on windows")
+	}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	defer ln.Close()
+	f, err := ln.(*net.TCPListener).File()
+	if err != nil {
+		t.Fatalf("File() error = %v", err)
+	}
+	t.Setenv(ListenFDEnv, strconv.Itoa(int(f.Fd())))
+
+	ws, _ := NewWithOptions()
+	inherited, err := ws.listen("127.0.0.1:1", ListenFDEnv, 0)
+	f.Close() // Already closed by listen, which owns the descriptor
+	if err != nil {
+		t.Fatalf("listen() error = %v", err)
+	}
+	defer inherited.Close()
+	if inherited.Addr().String() != ln.Addr().String() {
+		t.Errorf("listen() address = %s, want the inherited %s", inherited.Addr(), ln.Addr())
+	}
+	if _, ok := os.LookupEnv(ListenFDEnv); ok {
+		t.Errorf("%s not cleared", ListenFDEnv)
+	}
+
+	t.Setenv(ListenFDEnv, "not-a-fd")
+	if _, err := ws.listen("127.0.0.1:1", ListenFDEnv, 0); err == nil {
+		t.Errorf("listen() with an invalid %s succeeded", ListenFDEnv)
+	}
+}
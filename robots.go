@@ -0,0 +1,76 @@
+package gotth
+
+import (
+	"fmt"
+	"strings"
+)
+
+// RobotsGroup is a group of robots.txt rules applying to one or more user agents.
+type RobotsGroup struct {
+	// UserAgents the rules apply to. Defaults to "*" when empty.
+	UserAgents []string
+	Allow      []string
+	Disallow   []string
+	// Optional: seconds between requests. Not supported by every crawler.
+	CrawlDelay int
+}
+
+// RobotsConfig describes the crawler policy served at /robots.txt.
+type RobotsConfig struct {
+	Groups []RobotsGroup
+	// Absolute URLs of the sitemaps to advertise.
+	Sitemaps []string
+	// DisallowAll ignores Groups and blocks every crawler from the whole site.
+	// Set it for staging and preview environments so they never get indexed.
+	DisallowAll bool
+}
+
+// String renders the robots.txt content.
+func (c RobotsConfig) String() string {
+	var b strings.Builder
+
+	groups := c.Groups
+	if c.DisallowAll {
+		groups = []RobotsGroup{{Disallow: []string{"/"}}}
+	}
+	if len(groups) == 0 {
+		// An empty Disallow allows everything.
+		groups = []RobotsGroup{{Disallow: []string{""}}}
+	}
+
+	for i, g := range groups {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		userAgents := g.UserAgents
+		if len(userAgents) == 0 {
+			userAgents = []string{"*"}
+		}
+		for _, ua := range userAgents {
+			fmt.Fprintf(&b, "User-agent: %s\n", ua)
+		}
+		for _, p := range g.Allow {
+			fmt.Fprintf(&b, "Allow: %s\n", p)
+		}
+		for _, p := range g.Disallow {
+			fmt.Fprintf(&b, "Disallow: %s\n", p)
+		}
+		if g.CrawlDelay > 0 {
+			fmt.Fprintf(&b, "Crawl-delay: %d\n", g.CrawlDelay)
+		}
+	}
+
+	if len(c.Sitemaps) > 0 {
+		b.WriteString("\n")
+		for _, s := range c.Sitemaps {
+			fmt.Fprintf(&b, "Sitemap: %s\n", s)
+		}
+	}
+
+	return b.String()
+}
+
+// EnableRobots serves the robots.txt generated from cfg at /robots.txt.
+func (ws *WebServer) EnableRobots(cfg RobotsConfig) {
+	ws.handle("GET /robots.txt", textHandler(cfg.String()), RouteKindBuiltin, 0)
+}
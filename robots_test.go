@@ -0,0 +1,74 @@
+package gotth_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ancalabrese/gotth"
+)
+
+func TestRobotsConfig_String(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      gotth.RobotsConfig
+		expected string
+	}{
+		{
+			name:     "Empty config allows everything",
+			cfg:      gotth.RobotsConfig{},
+			expected: "User-agent: *\nDisallow: \n",
+		},
+		{
+			name: "Groups and sitemaps",
+			cfg: gotth.RobotsConfig{
+				Groups: []gotth.RobotsGroup{
+					{Disallow: []string{"/admin", "/account"}, Allow: []string{"/admin/public"}},
+					{UserAgents: []string{"BadBot", "WorseBot"}, Disallow: []string{"/"}, CrawlDelay: 10},
+				},
+				Sitemaps: []string{"https://example.com/sitemap.xml"},
+			},
+			expected: "User-agent: *\nAllow: /admin/public\nDisallow: /admin\nDisallow: /account\n\n" +
+				"User-agent: BadBot\nUser-agent: WorseBot\nDisallow: /\nCrawl-delay: 10\n\n" +
+				"Sitemap: https://example.com/sitemap.xml\n",
+		},
+		{
+			name: "DisallowAll overrides groups",
+			cfg: gotth.RobotsConfig{
+				Groups:      []gotth.RobotsGroup{{Allow: []string{"/"}}},
+				Sitemaps:    []string{"https://staging.example.com/sitemap.xml"},
+				DisallowAll: true,
+			},
+			expected: "User-agent: *\nDisallow: /\n\nSitemap: https://staging.example.com/sitemap.xml\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.cfg.String(); got != tt.expected {
+				t.Errorf("String() =\n%q\nwant\n%q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestEnableRobots(t *testing.T) {
+	ws, err := gotth.New(gotth.WebServerConfig{}, nil)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	ws.EnableRobots(gotth.RobotsConfig{DisallowAll: true})
+
+	rr := httptest.NewRecorder()
+	ws.Handler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/robots.txt", nil))
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rr.Code, http.StatusOK)
+	}
+	if ct := rr.Header().Get("Content-Type"); ct != "text/plain; charset=utf-8" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	if got, want := rr.Body.String(), "User-agent: *\nDisallow: /\n"; got != want {
+		t.Errorf("body = %q, want %q", got, want)
+	}
+}
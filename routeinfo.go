@@ -0,0 +1,222 @@
+package gotth
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"runtime"
+	"slices"
+	"strings"
+	"text/tabwriter"
+)
+
+// Kinds of registered routes.
+const (
+	RouteKindPage    = "page"    // Registered with ServeContent.
+	RouteKindHandler = "handler" // Registered with Handle.
+	RouteKindStatic  = "static"  // Static assets of WebServerConfig.StaticAssetsFS.
+	RouteKindBuiltin = "builtin" // Served by gotth, e.g. robots.txt or pprof.
+	RouteKindProxy   = "proxy"   // Forwarded to another server with Proxy.
+)
+
+// RouteInfo describes a route registered on the WebServer.
+type RouteInfo struct {
+	// Method is the HTTP method of the pattern, empty when the route matches any method.
+	Method string
+	// Path is the pattern without the method, e.g. "/blog/{slug}".
+	Path string
+	// Pattern is the ServeMux pattern, e.g. "GET /blog/{slug}".
+	Pattern string
+	// Name is set with SetRouteMeta.
+	Name string
+	// Kind is one of the RouteKind constants.
+	Kind string
+	// Middlewares is the number of middlewares wrapping this route only, without the global
+	// ones.
+	Middlewares int
+	// Source is the file:line of the registration in the application, e.g. the ServeContent
+	// call, or of the gotth.New call for the routes of the WebServerConfig.
+	Source string
+}
+
+// RouteConflictError reports a pattern that conflicts with a registered route: both match
+// some requests and neither is more specific, e.g. the same pattern registered twice.
+type RouteConflictError struct {
+	// Pattern and Source of the rejected registration.
+	Pattern string
+	Source  string
+	// Existing is the registered route it conflicts with.
+	Existing RouteInfo
+	// Detail is the explanation of http.ServeMux, e.g. an example path matched by both.
+	Detail string
+}
+
+func (e *RouteConflictError) Error() string {
+	msg := fmt.Sprintf("route %q registered at %s conflicts with %q registered at %s",
+		e.Pattern, e.Source, e.Existing.Pattern, e.Existing.Source)
+	if e.Detail != "" {
+		msg += ": " + e.Detail
+	}
+	return msg
+}
+
+// Routes returns the registered routes sorted by path and method.
+func (ws *WebServer) Routes() []RouteInfo {
+	list := make([]RouteInfo, len(ws.routeInfos))
+	copy(list, ws.routeInfos)
+	for i := range list {
+		if meta, ok := ws.routes.Get(list[i].Pattern); ok {
+			list[i].Name = meta.Name
+		}
+	}
+	slices.SortStableFunc(list, func(a, b RouteInfo) int {
+		if c := strings.Compare(a.Path, b.Path); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Method, b.Method)
+	})
+	return list
+}
+
+// PrintRoutes writes the route table to w. It's logged at startup when
+// WebServerConfig.LogRoutes is set.
+func (ws *WebServer) PrintRoutes(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "METHOD\tPATH\tNAME\tKIND\tMIDDLEWARES")
+	for _, r := range ws.Routes() {
+		method := r.Method
+		if method == "" {
+			method = "*"
+		}
+		name := r.Name
+		if name == "" {
+			name = "-"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%d\n", method, r.Path, name, r.Kind, r.Middlewares)
+	}
+	return tw.Flush()
+}
+
+// ErrInvalidRoute is wrapped by the registration errors of malformed routes, e.g. with an
+// empty pattern or a nil handler.
+var ErrInvalidRoute = errors.New("invalid route")
+
+// Err returns the errors of the invalid or conflicting registrations joined, or nil. Start
+// returns them instead of serving an incomplete site, and New those of the routes of the
+// WebServerConfig.
+func (ws *WebServer) Err() error {
+	return errors.Join(ws.registrationErrs...)
+}
+
+// InvalidRoute reports that the routes of pattern, if any, can't be registered because of
+// reason, e.g. a nil argument: Start fails and Err returns the error. It's for the Serve
+// functions of the feature packages, e.g. blog.Serve.
+func (ws *WebServer) InvalidRoute(pattern, reason string) {
+	if pattern == "" {
+		ws.registrationFailed(fmt.Errorf("%w registered at %s: %s", ErrInvalidRoute, callerSource(), reason))
+		return
+	}
+	ws.registrationFailed(fmt.Errorf("%w %q registered at %s: %s", ErrInvalidRoute, pattern, callerSource(), reason))
+}
+
+// registrationFailed records and logs err, failing Start.
+func (ws *WebServer) registrationFailed(err error) error {
+	ws.logger.Error("route registration failed", slog.Any("error", err))
+	ws.registrationErrs = append(ws.registrationErrs, err)
+	return err
+}
+
+// handle registers handler on the mux and records the route. Invalid and conflicting routes
+// aren't registered: the error is returned and reported by Err.
+func (ws *WebServer) handle(pattern string, handler http.Handler, kind string, middlewares int) error {
+	source := callerSource()
+	if err := register(ws.mux, pattern, handler); err != nil {
+		return ws.registrationFailed(ws.conflictError(pattern, source, err))
+	}
+
+	method, path := "", strings.TrimSpace(pattern)
+	if m, p, ok := strings.Cut(path, " "); ok {
+		method, path = m, strings.TrimSpace(p)
+	}
+	ws.logger.Debug("route registered", slog.String("pattern", pattern), slog.String("kind", kind))
+	ws.routeInfos = append(ws.routeInfos, RouteInfo{
+		Method:      method,
+		Path:        path,
+		Pattern:     pattern,
+		Kind:        kind,
+		Middlewares: middlewares,
+		Source:      source,
+	})
+	return nil
+}
+
+// conflictError explains why pattern couldn't be registered: it returns a RouteConflictError
+// naming the conflicting route. http.ServeMux names the gotth internals as the registration
+// sites of both patterns, so the registered routes are probed on a scratch mux to find it.
+// This only runs on failure: successful registrations are checked by the server mux itself.
+func (ws *WebServer) conflictError(pattern, source string, err error) error {
+	if perr := register(http.NewServeMux(), pattern, http.NotFoundHandler()); perr != nil {
+		return fmt.Errorf("%w %q registered at %s: %w", ErrInvalidRoute, pattern, source, perr)
+	}
+	for _, existing := range ws.routeInfos {
+		if perr := probe(existing.Pattern, pattern); perr != nil {
+			detail := perr.Error()
+			if _, d, ok := strings.Cut(detail, ":\n"); ok {
+				detail = strings.ReplaceAll(strings.TrimSpace(d), "\n", " ")
+			}
+			return &RouteConflictError{Pattern: pattern, Source: source, Existing: existing, Detail: detail}
+		}
+	}
+	return fmt.Errorf("%w %q registered at %s: %w", ErrInvalidRoute, pattern, source, err)
+}
+
+// probe registers patterns on a new mux, returning the panic of the last one as an error.
+func probe(patterns ...string) error {
+	mux := http.NewServeMux()
+	for _, p := range patterns {
+		if err := register(mux, p, http.NotFoundHandler()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// register adds pattern to mux, returning its panic as an error. ServeMux checks the pattern
+// before changing its routes, so mux stays usable after a failure.
+func register(mux *http.ServeMux, pattern string, handler http.Handler) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+	mux.Handle(pattern, handler)
+	return nil
+}
+
+const (
+	// rootPackage prefixes the names of the functions of this package, but not of its
+	// subpackages.
+	rootPackage = "github.com/ancalabrese/gotth."
+	// subpackages prefixes the names of the functions of the subpackages.
+	subpackages = "github.com/ancalabrese/gotth/"
+)
+
+// callerSource returns the file:line of the first caller outside this package and the Serve
+// functions of the subpackages, e.g. blog.Serve, which register routes on behalf of their caller.
+func callerSource() string {
+	pcs := make([]uintptr, 16)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(2, pcs)])
+	for {
+		frame, more := frames.Next()
+		internal := strings.HasPrefix(frame.Function, rootPackage) ||
+			strings.HasPrefix(frame.Function, subpackages) && strings.HasSuffix(frame.Function, ".Serve")
+		if !internal || strings.HasSuffix(frame.File, "_test.go") {
+			return fmt.Sprintf("%s:%d", frame.File, frame.Line)
+		}
+		if !more {
+			return "unknown"
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package gotth_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/a-h/templ"
+	"github.com/ancalabrese/gotth"
+	"github.com/ancalabrese/gotth/middlewares"
+	"github.com/ancalabrese/gotth/routes"
+	"github.com/ancalabrese/gotth/views/components/head"
+)
+
+func TestWebServer_Routes(t *testing.T) {
+	ws, err := gotth.New(gotth.WebServerConfig{
+		StaticAssetsFS: []gotth.StaticAssetFS{gotth.NewStaticAssetFS("static", http.Dir("."))},
+		HumansTxt:      &gotth.HumansTxt{},
+	}, nil)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	page := func(r *http.Request) (head.HeadViewModel, templ.Component, error) {
+		return head.NewHeadViewModel(), templ.NopComponent, nil
+	}
+	ws.ServeContent("GET /blog/{slug}", page, middlewares.Vary(middlewares.VaryAcceptLanguage))
+	ws.SetRouteMeta("GET /blog/{slug}", routes.Meta{Name: "blog.post"})
+	ws.Handle("POST /contact", http.NotFoundHandler())
+	ws.Handle("/", http.NotFoundHandler())
+
+	expected := []gotth.RouteInfo{
+		{Method: "", Path: "/", Pattern: "/", Kind: gotth.RouteKindHandler},
+		{Method: "GET", Path: "/blog/{slug}", Pattern: "GET /blog/{slug}", Name: "blog.post", Kind: gotth.RouteKindPage, Middlewares: 1},
+		{Method: "POST", Path: "/contact", Pattern: "POST /contact", Kind: gotth.RouteKindHandler},
+		{Method: "GET", Path: "/humans.txt", Pattern: "GET /humans.txt", Kind: gotth.RouteKindBuiltin},
+		{Method: "", Path: "/static/", Pattern: "/static/", Kind: gotth.RouteKindStatic},
+	}
+	got := ws.Routes()
+	for i := range got {
+		if !strings.Contains(got[i].Source, "routeinfo_test.go:") {
+			t.Errorf("Source of %s = %q, want the registration site in this file", got[i].Pattern, got[i].Source)
+		}
+		got[i].Source = ""
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Routes() = %+v\nwant %+v", got, expected)
+	}
+
+	var b strings.Builder
+	if err := ws.PrintRoutes(&b); err != nil {
+		t.Fatalf("PrintRoutes() error = %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+	if len(lines) != 6 || strings.Join(strings.Fields(lines[2]), " ") != "GET /blog/{slug} blog.post page 1" || !strings.HasPrefix(lines[1], "*") {
+		t.Errorf("unexpected route table:\n%s", b.String())
+	}
+}
+
+func TestWebServer_RouteConflict(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing string
+		pattern  string
+	}{
+		{name: "Duplicate", existing: "GET /about", pattern: "GET /about"},
+		{name: "Same wildcards", existing: "GET /blog/{slug}", pattern: "GET /blog/{id}"},
+		{name: "Overlapping", existing: "GET /posts/{id}", pattern: "/posts/latest"},
+		{name: "Static mount", existing: "/static/", pattern: "/static/"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ws, err := gotth.New(gotth.WebServerConfig{}, nil)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			ws.Handle(tt.existing, http.NotFoundHandler())
+			ws.Handle(tt.pattern, http.NotFoundHandler())
+
+			var conflict *gotth.RouteConflictError
+			if !errors.As(ws.Err(), &conflict) {
+				t.Fatalf("Err() = %v, want a *RouteConflictError", ws.Err())
+			}
+			if conflict.Pattern != tt.pattern || conflict.Existing.Pattern != tt.existing {
+				t.Errorf("conflict between %q and %q, want %q and %q", conflict.Pattern, conflict.Existing.Pattern, tt.pattern, tt.existing)
+			}
+			if msg := conflict.Error(); strings.Count(msg, "routeinfo_test.go:") != 2 {
+				t.Errorf("error doesn't list both registration sites: %s", msg)
+			}
+			if got := len(ws.Routes()); got != 1 {
+				t.Errorf("len(Routes()) = %d, want the conflicting route not registered", got)
+			}
+		})
+	}
+}
+
+func TestWebServer_NoRouteConflict(t *testing.T) {
+	ws, err := gotth.New(gotth.WebServerConfig{}, nil)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	// More specific patterns take precedence: these don't conflict.
+	for _, pattern := range []string{"/", "GET /posts/{id}", "GET /posts/latest", "POST /posts/{id}", "/static/", "GET /static/logo.svg"} {
+		ws.Handle(pattern, http.NotFoundHandler())
+	}
+	if got := len(ws.Routes()); got != 6 {
+		t.Errorf("len(Routes()) = %d, want 6", got)
+	}
+	if err := ws.Err(); err != nil {
+		t.Errorf("Err() = %v", err)
+	}
+}
+
+func TestWebServer_InvalidRoutes(t *testing.T) {
+	ws, err := gotth.New(gotth.WebServerConfig{}, nil)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	ws.ServeContent("", func(r *http.Request) (head.HeadViewModel, templ.Component, error) {
+		return head.NewHeadViewModel(), templ.NopComponent, nil
+	})
+	ws.ServeContent("GET /about", nil)
+	ws.Handle("GET /contact", nil)
+	ws.Handle("GET /{bad", http.NotFoundHandler())
+
+	err = ws.Err()
+	if !errors.Is(err, gotth.ErrInvalidRoute) {
+		t.Fatalf("Err() = %v, want ErrInvalidRoute", err)
+	}
+	for _, want := range []string{`""`, `"GET /about"`, `"GET /contact"`, `"GET /{bad"`} {
+		if !strings.Contains(err.Error(), want+" registered at ") || !strings.Contains(err.Error(), "routeinfo_test.go:") {
+			t.Errorf("Err() doesn't report %s with its registration site: %v", want, err)
+		}
+	}
+	if len(ws.Routes()) != 0 {
+		t.Errorf("Routes() = %+v, want none", ws.Routes())
+	}
+
+	if err := ws.Start(context.Background()); !errors.Is(err, gotth.ErrInvalidRoute) {
+		t.Errorf("Start() = %v, want the registration errors", err)
+	}
+}
+
+func TestWebServer_SetRouteMetaUnregistered(t *testing.T) {
+	ws, err := gotth.New(gotth.WebServerConfig{}, nil)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	ws.Handle("GET /about", http.NotFoundHandler())
+	ws.SetRouteMeta("GET /about", routes.Meta{Name: "about"})
+	ws.SetRouteMeta("GET /missing", routes.Meta{Name: "missing"})
+	ws.SetRouteMeta("GET /{bad", routes.Meta{Name: "bad"})
+
+	err = ws.Err()
+	if !errors.Is(err, gotth.ErrInvalidRoute) {
+		t.Fatalf("Err() = %v, want ErrInvalidRoute", err)
+	}
+	for _, want := range []string{`"GET /missing"`, `"GET /{bad"`} {
+		if !strings.Contains(err.Error(), want+" registered at ") || !strings.Contains(err.Error(), "routeinfo_test.go:") {
+			t.Errorf("Err() doesn't report %s with its registration site: %v", want, err)
+		}
+	}
+	if strings.Contains(err.Error(), `"GET /about"`) {
+		t.Errorf("Err() reports the registered route: %v", err)
+	}
+}
+
+func TestNew_InvalidConfigRoutes(t *testing.T) {
+	_, err := gotth.New(gotth.WebServerConfig{SecurityTxt: &gotth.SecurityTxt{}}, nil)
+	if err == nil || !strings.Contains(err.Error(), "security.txt") {
+		t.Errorf("New() error = %v, want the invalid security.txt", err)
+	}
+}
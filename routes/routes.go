@@ -0,0 +1,188 @@
+// Package routes holds metadata about the registered routes, such as their names and titles,
+// used to build breadcrumbs:
+//
+//	ws.ServeContent("GET /blog", blog)
+//	ws.SetRouteMeta("GET /blog", routes.Meta{Name: "blog", Title: "Blog"})
+//	ws.ServeContent("GET /blog/{slug}", post)
+//	ws.SetRouteMeta("GET /blog/{slug}", routes.Meta{Name: "blog.post", TitleFunc: postTitle})
+//
+// The WebServer adds its Registry to the context of every request (see [FromContext]).
+package routes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// ErrNotFound is wrapped by the errors of page providers for missing pages, e.g. an unknown
+// slug. gotth.WebServer answers them with 404 Not Found. content.ErrNotFound is the same error.
+var ErrNotFound = errors.New("content not found")
+
+// Meta describes a route.
+type Meta struct {
+	// Name identifies the route, e.g. "blog.post".
+	Name string
+	// Title is the human readable title of the route, e.g. for breadcrumbs.
+	Title string
+	// Optional: TitleFunc returns the title of the route for r, e.g. from its path values.
+	// It takes precedence over Title. For breadcrumbs, r is a GET request for the parent page
+	// with the context of the current request.
+	TitleFunc func(r *http.Request) string
+}
+
+// Breadcrumb is a page of a breadcrumb trail.
+type Breadcrumb struct {
+	Title string
+	URL   string
+}
+
+// Registry maps route patterns, as registered on a ServeMux, to their Meta. It's safe for
+// concurrent use.
+type Registry struct {
+	mu   sync.RWMutex
+	mux  *http.ServeMux
+	meta map[string]Meta
+}
+
+// NewRegistry returns an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{mux: http.NewServeMux(), meta: map[string]Meta{}}
+}
+
+type routesCtxKey string
+
+const (
+	registryKey routesCtxKey = "routes-registry"
+	titleKey    routesCtxKey = "routes-title"
+)
+
+// Set sets the Meta of pattern, replacing any previous one. Patterns follow the http.ServeMux
+// syntax and are matched the same way. It returns an error, and sets nothing, when pattern is
+// invalid or conflicts with a pattern set before.
+func (reg *Registry) Set(pattern string, meta Meta) error {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	if _, ok := reg.meta[pattern]; !ok {
+		if err := reg.register(pattern); err != nil {
+			return err
+		}
+	}
+	reg.meta[pattern] = meta
+	return nil
+}
+
+// register adds pattern to the mux, returning its panic on an invalid or conflicting pattern
+// as an error.
+func (reg *Registry) register(pattern string) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+	reg.mux.Handle(pattern, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reg.mu.RLock()
+		meta := reg.meta[pattern]
+		reg.mu.RUnlock()
+
+		title := meta.Title
+		if meta.TitleFunc != nil {
+			title = meta.TitleFunc(r)
+		}
+		*r.Context().Value(titleKey).(*string) = title
+	}))
+	return nil
+}
+
+// Get returns the Meta of pattern.
+func (reg *Registry) Get(pattern string) (Meta, bool) {
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+	meta, ok := reg.meta[pattern]
+	return meta, ok
+}
+
+// Title returns the title of the route matching r, and whether a route matched. A subtree
+// pattern (e.g., "/docs/") only matches its own path, so that pages without metadata don't
+// inherit the title of a parent.
+func (reg *Registry) Title(r *http.Request) (string, bool) {
+	_, pattern := reg.mux.Handler(r)
+	if pattern == "" || !matchesPath(pattern, r.URL.Path) {
+		return "", false
+	}
+	var title string
+	reg.mux.ServeHTTP(discard{}, r.WithContext(context.WithValue(r.Context(), titleKey, &title)))
+	return title, true
+}
+
+// Trail returns the breadcrumbs of path: the titled routes matching path and each of its parent
+// paths, from the root down to path. Paths without a matching route or title are skipped.
+func (reg *Registry) Trail(ctx context.Context, path string) []Breadcrumb {
+	var trail []Breadcrumb
+	for _, p := range parents(path) {
+		// A subtree pattern, e.g. "/docs/", redirects its path without the trailing slash.
+		for _, candidate := range []string{p, strings.TrimSuffix(p, "/") + "/"} {
+			r, err := http.NewRequestWithContext(ctx, http.MethodGet, candidate, nil)
+			if err != nil {
+				break
+			}
+			if title, ok := reg.Title(r); ok && title != "" {
+				trail = append(trail, Breadcrumb{Title: title, URL: candidate})
+				break
+			}
+		}
+	}
+	return trail
+}
+
+// parents returns "/" and every parent path of path, ending with path itself.
+func parents(path string) []string {
+	list := []string{"/"}
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	current := ""
+	for _, s := range segments {
+		if s == "" {
+			continue
+		}
+		current += "/" + s
+		list = append(list, current)
+	}
+	return list
+}
+
+// matchesPath reports whether the ServeMux pattern matched path itself rather than as one of
+// the paths of its subtree.
+func matchesPath(pattern, path string) bool {
+	// Strip the method and host.
+	if _, p, ok := strings.Cut(pattern, " "); ok {
+		pattern = strings.TrimSpace(p)
+	}
+	if i := strings.Index(pattern, "/"); i > 0 {
+		pattern = pattern[i:]
+	}
+	if !strings.HasSuffix(pattern, "/") {
+		return true
+	}
+	return pattern == path || strings.TrimSuffix(pattern, "/") == strings.TrimSuffix(path, "/")
+}
+
+// ContextWithRegistry returns a copy of ctx carrying reg.
+func ContextWithRegistry(ctx context.Context, reg *Registry) context.Context {
+	return context.WithValue(ctx, registryKey, reg)
+}
+
+// FromContext returns the Registry of the WebServer serving the request, or nil.
+func FromContext(ctx context.Context) *Registry {
+	reg, _ := ctx.Value(registryKey).(*Registry)
+	return reg
+}
+
+// discard is a ResponseWriter ignoring everything, to run the route handlers for their title.
+type discard struct{}
+
+func (discard) Header() http.Header         { return http.Header{} }
+func (discard) Write(b []byte) (int, error) { return len(b), nil }
+func (discard) WriteHeader(int)             {}
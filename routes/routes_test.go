@@ -0,0 +1,72 @@
+package routes_test
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/ancalabrese/gotth/routes"
+)
+
+func TestRegistry_Trail(t *testing.T) {
+	reg := routes.NewRegistry()
+	reg.Set("/{$}", routes.Meta{Name: "home", Title: "Home"})
+	reg.Set("GET /blog", routes.Meta{Name: "blog", Title: "Blog"})
+	reg.Set("GET /blog/{slug}", routes.Meta{Name: "blog.post", TitleFunc: func(r *http.Request) string { return "Post " + r.PathValue("slug") }})
+	reg.Set("/docs/", routes.Meta{Name: "docs", Title: "Docs"})
+	reg.Set("GET /untitled", routes.Meta{Name: "untitled"})
+
+	tests := []struct {
+		name     string
+		path     string
+		expected []routes.Breadcrumb
+	}{
+		{name: "Root", path: "/", expected: []routes.Breadcrumb{{Title: "Home", URL: "/"}}},
+		{
+			name:     "Nested with path value",
+			path:     "/blog/first",
+			expected: []routes.Breadcrumb{{Title: "Home", URL: "/"}, {Title: "Blog", URL: "/blog"}, {Title: "Post first", URL: "/blog/first"}},
+		},
+		{name: "Unknown parent skipped", path: "/blog/first/comments", expected: []routes.Breadcrumb{{Title: "Home", URL: "/"}, {Title: "Blog", URL: "/blog"}, {Title: "Post first", URL: "/blog/first"}}},
+		{name: "Subtree pattern matches its own path only", path: "/docs/intro", expected: []routes.Breadcrumb{{Title: "Home", URL: "/"}, {Title: "Docs", URL: "/docs/"}}},
+		{name: "Route without title", path: "/untitled", expected: []routes.Breadcrumb{{Title: "Home", URL: "/"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := reg.Trail(context.Background(), tt.path); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Trail(%q) = %v, want %v", tt.path, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRegistry_Set(t *testing.T) {
+	reg := routes.NewRegistry()
+	reg.Set("GET /about", routes.Meta{Name: "about", Title: "About"})
+	reg.Set("GET /about", routes.Meta{Name: "about", Title: "About us"})
+
+	if meta, ok := reg.Get("GET /about"); !ok || meta.Title != "About us" {
+		t.Errorf("Get() = %v, %v, want the replaced meta", meta, ok)
+	}
+	r, _ := http.NewRequest(http.MethodGet, "/about", nil)
+	if title, ok := reg.Title(r); !ok || title != "About us" {
+		t.Errorf("Title() = %q, %v, want %q", title, ok, "About us")
+	}
+	if _, ok := reg.Get("GET /missing"); ok {
+		t.Error("Get() found a missing pattern")
+	}
+
+	for _, pattern := range []string{"GET about", "GET /{a"} {
+		if err := reg.Set(pattern, routes.Meta{Name: "bad"}); err == nil {
+			t.Errorf("Set(%q) expected an error", pattern)
+		}
+	}
+	if err := reg.Set("GET /{b}/x", routes.Meta{Name: "x"}); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if err := reg.Set("GET /x/{c}", routes.Meta{Name: "conflict"}); err == nil {
+		t.Error("Set() of a conflicting pattern expected an error")
+	}
+}
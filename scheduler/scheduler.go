@@ -0,0 +1,265 @@
+// Package scheduler runs the periodic tasks of a site, e.g. regenerating the sitemap, warming up
+// a cache or reloading the content, on intervals or cron expressions:
+//
+//	s := scheduler.New(scheduler.Config{})
+//	s.Add("sitemap", scheduler.Every(time.Hour), regenerateSitemap, scheduler.RunOnStart())
+//	s.Add("digest", scheduler.MustCron("0 8 * * mon-fri", nil), sendDigest, scheduler.WithTimeout(time.Minute))
+//	ws, err := gotth.New(gotth.WebServerConfig{Scheduler: s}, nil)
+//
+// gotth.WebServer starts the tasks with the server and stops them during the graceful shutdown,
+// waiting for the running ones. A task doesn't overlap itself: a run late because the previous
+// one was still running is skipped. [Scheduler.Stats] reports the runs and failures of the
+// tasks, shown by the admin dashboard.
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Task is a periodic task. Its context is canceled when the scheduler stops or the task times
+// out.
+type Task func(ctx context.Context) error
+
+// TaskOption configures a task.
+type TaskOption func(*taskOptions)
+
+type taskOptions struct {
+	timeout    time.Duration
+	runOnStart bool
+}
+
+// WithTimeout cancels the context of the runs of the task after d.
+func WithTimeout(d time.Duration) TaskOption {
+	return func(o *taskOptions) {
+		o.timeout = d
+	}
+}
+
+// RunOnStart runs the task when the scheduler starts, then on its schedule, e.g. to warm up a
+// cache.
+func RunOnStart() TaskOption {
+	return func(o *taskOptions) {
+		o.runOnStart = true
+	}
+}
+
+// Config configures a [Scheduler].
+type Config struct {
+	// Optional: logs the runs (at debug level) and failures of the tasks. Defaults to
+	// slog.Default().
+	Logger *slog.Logger
+}
+
+// TaskStats are the runs of a task.
+type TaskStats struct {
+	Name     string
+	Schedule string
+	Runs     uint64
+	Failures uint64
+	Skipped  uint64 // Runs missed while the previous one was running
+	Running  bool
+	LastRun  time.Time
+	// LastDuration and LastError are the duration and error of the last run.
+	LastDuration time.Duration
+	LastError    string
+	// Next is the time of the next run, zero when the scheduler isn't running.
+	Next time.Time
+}
+
+// Scheduler runs tasks on their schedules. It's safe for concurrent use.
+type Scheduler struct {
+	logger *slog.Logger
+
+	mu      sync.Mutex
+	tasks   []*task
+	ctx     context.Context // Of the running scheduler, nil otherwise
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+	stopped bool
+}
+
+type task struct {
+	name     string
+	schedule Schedule
+	fn       Task
+	opts     taskOptions
+	stats    TaskStats // Guarded by Scheduler.mu
+}
+
+// New returns a Scheduler without tasks.
+func New(cfg Config) *Scheduler {
+	logger := cfg.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &Scheduler{logger: logger}
+}
+
+// Add schedules fn as the task name. Tasks added to a running scheduler start right away. Names
+// must be unique.
+func (s *Scheduler) Add(name string, schedule Schedule, fn Task, opts ...TaskOption) error {
+	if name == "" || schedule == nil || fn == nil {
+		return errors.New("scheduler: a task needs a name, a schedule and a function")
+	}
+	t := &task{name: name, schedule: schedule, fn: fn, stats: TaskStats{Name: name, Schedule: schedule.String()}}
+	for _, opt := range opts {
+		opt(&t.opts)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if slices.ContainsFunc(s.tasks, func(other *task) bool { return other.name == name }) {
+		return fmt.Errorf("scheduler: task %q already added", name)
+	}
+	s.tasks = append(s.tasks, t)
+	if s.ctx != nil {
+		s.start(t)
+	}
+	return nil
+}
+
+// Start runs the tasks on their schedules until ctx is canceled or [Scheduler.Stop] is called.
+// It returns right away. A stopped scheduler doesn't start again.
+func (s *Scheduler) Start(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch {
+	case s.stopped:
+		return errors.New("scheduler: stopped")
+	case s.ctx != nil:
+		return errors.New("scheduler: already started")
+	}
+	s.ctx, s.cancel = context.WithCancel(ctx)
+	for _, t := range s.tasks {
+		s.start(t)
+	}
+	return nil
+}
+
+// start runs the goroutine of t. s.mu must be locked.
+func (s *Scheduler) start(t *task) {
+	s.wg.Add(1)
+	go s.loop(s.ctx, t)
+}
+
+// Stop cancels the tasks and waits for the running ones to return, until ctx is done.
+func (s *Scheduler) Stop(ctx context.Context) error {
+	s.mu.Lock()
+	s.stopped = true
+	if s.cancel != nil {
+		s.cancel()
+	}
+	s.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("scheduler: tasks still running at shutdown. err %w", ctx.Err())
+	}
+}
+
+// loop runs t on its schedule until ctx is canceled.
+func (s *Scheduler) loop(ctx context.Context, t *task) {
+	defer s.wg.Done()
+	if t.opts.runOnStart {
+		s.run(ctx, t)
+	}
+	next := t.schedule.Next(time.Now())
+	for !next.IsZero() {
+		s.mu.Lock()
+		t.stats.Next = next
+		s.mu.Unlock()
+
+		timer := time.NewTimer(next.Sub(time.Now()))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			s.mu.Lock()
+			t.stats.Next = time.Time{}
+			s.mu.Unlock()
+			return
+		case <-timer.C:
+		}
+		s.run(ctx, t)
+
+		// Skip the runs missed while this one ran.
+		now := time.Now()
+		for next = t.schedule.Next(next); !next.IsZero() && next.Before(now); next = t.schedule.Next(next) {
+			s.mu.Lock()
+			t.stats.Skipped++
+			s.mu.Unlock()
+		}
+		if _, ok := t.schedule.(every); ok {
+			next = t.schedule.Next(now) // Intervals count from the end of the run
+		}
+	}
+}
+
+// run runs t once, recording its stats.
+func (s *Scheduler) run(ctx context.Context, t *task) {
+	start := time.Now()
+	s.mu.Lock()
+	t.stats.Running = true
+	s.mu.Unlock()
+
+	runCtx := ctx
+	if t.opts.timeout > 0 {
+		var cancel context.CancelFunc
+		runCtx, cancel = context.WithTimeout(ctx, t.opts.timeout)
+		defer cancel()
+	}
+	err := call(runCtx, t.fn)
+	duration := time.Now().Sub(start)
+
+	s.mu.Lock()
+	t.stats.Running = false
+	t.stats.Runs++
+	t.stats.LastRun = start
+	t.stats.LastDuration = duration
+	t.stats.LastError = ""
+	if err != nil {
+		t.stats.Failures++
+		t.stats.LastError = err.Error()
+	}
+	s.mu.Unlock()
+
+	if err != nil {
+		s.logger.ErrorContext(ctx, "scheduled task failed", slog.String("task", t.name), slog.Duration("duration", duration), slog.Any("error", err))
+		return
+	}
+	s.logger.DebugContext(ctx, "scheduled task done", slog.String("task", t.name), slog.Duration("duration", duration))
+}
+
+func call(ctx context.Context, fn Task) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("task panicked: %v", p)
+		}
+	}()
+	return fn(ctx)
+}
+
+// Stats returns the stats of the tasks, sorted by name.
+func (s *Scheduler) Stats() []TaskStats {
+	s.mu.Lock()
+	stats := make([]TaskStats, 0, len(s.tasks))
+	for _, t := range s.tasks {
+		stats = append(stats, t.stats)
+	}
+	s.mu.Unlock()
+	slices.SortFunc(stats, func(a, b TaskStats) int { return strings.Compare(a.Name, b.Name) })
+	return stats
+}
@@ -0,0 +1,181 @@
+package scheduler_test
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ancalabrese/gotth/scheduler"
+)
+
+func TestCron(t *testing.T) {
+	from := time.Date(2025, 3, 14, 10, 30, 20, 0, time.UTC) // A Friday
+	tests := []struct {
+		expr string
+		want []time.Time
+	}{
+		{
+			expr: "*/20 * * * *",
+			want: []time.Time{time.Date(2025, 3, 14, 10, 40, 0, 0, time.UTC), time.Date(2025, 3, 14, 11, 0, 0, 0, time.UTC)},
+		},
+		{
+			expr: "0 8 * * mon-fri",
+			want: []time.Time{time.Date(2025, 3, 17, 8, 0, 0, 0, time.UTC), time.Date(2025, 3, 18, 8, 0, 0, 0, time.UTC)},
+		},
+		{
+			expr: "30 9-17/4 1,15 * *",
+			want: []time.Time{time.Date(2025, 3, 15, 9, 30, 0, 0, time.UTC), time.Date(2025, 3, 15, 13, 30, 0, 0, time.UTC)},
+		},
+		{
+			expr: "0 0 13 * 7", // The 13th or a Sunday
+			want: []time.Time{time.Date(2025, 3, 16, 0, 0, 0, 0, time.UTC), time.Date(2025, 3, 23, 0, 0, 0, 0, time.UTC)},
+		},
+		{
+			expr: "@monthly",
+			want: []time.Time{time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, 5, 1, 0, 0, 0, 0, time.UTC)},
+		},
+		{
+			expr: "0 12 29 feb *",
+			want: []time.Time{time.Date(2028, 2, 29, 12, 0, 0, 0, time.UTC)},
+		},
+		{
+			expr: "@every 1h30m0s",
+			want: []time.Time{from.Add(90 * time.Minute), from.Add(180 * time.Minute)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			s, err := scheduler.Cron(tt.expr, time.UTC)
+			if err != nil {
+				t.Fatalf("Cron() error = %v", err)
+			}
+			next := from
+			for _, want := range tt.want {
+				if next = s.Next(next); !next.Equal(want) {
+					t.Fatalf("Next() = %v, want %v", next, want)
+				}
+			}
+			if s.String() != tt.expr {
+				t.Errorf("String() = %q", s.String())
+			}
+		})
+	}
+
+	if next := scheduler.MustCron("0 0 30 2 *", time.UTC).Next(from); !next.IsZero() {
+		t.Errorf("Next() of Feb 30 = %v, want the zero time", next)
+	}
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* * 0 * *", "5-1 * * * *", "*/0 * * * *", "* * * foo *", "@every -1s"} {
+		if _, err := scheduler.Cron(expr, nil); err == nil {
+			t.Errorf("Cron(%q) error = nil", expr)
+		}
+	}
+}
+
+func TestCron_Location(t *testing.T) {
+	rome, err := time.LoadLocation("Europe/Rome")
+	if err != nil {
+		t.Skip("no tz database")
+	}
+	s := scheduler.MustCron("0 8 * * *", rome)
+	if next := s.Next(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)); !next.Equal(time.Date(2025, 1, 1, 7, 0, 0, 0, time.UTC)) {
+		t.Errorf("Next() = %v, want 8am in Rome", next.UTC())
+	}
+}
+
+func TestScheduler(t *testing.T) {
+	s := scheduler.New(scheduler.Config{Logger: slog.New(slog.DiscardHandler)})
+	var ok, failing atomic.Int32
+	if err := s.Add("ok", scheduler.Every(5*time.Millisecond), func(ctx context.Context) error {
+		ok.Add(1)
+		return nil
+	}, scheduler.RunOnStart()); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if err := s.Add("ok", scheduler.Every(time.Hour), func(ctx context.Context) error { return nil }); err == nil {
+		t.Error("Add() of a duplicate name error = nil")
+	}
+	if err := s.Start(context.Background()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	// Tasks added to the running scheduler start too.
+	s.Add("failing", scheduler.Every(5*time.Millisecond), func(ctx context.Context) error {
+		if failing.Add(1) == 1 {
+			panic("boom")
+		}
+		return errors.New("down")
+	})
+
+	deadline := time.Now().Add(5 * time.Second)
+	for ok.Load() < 3 || failing.Load() < 2 {
+		if time.Now().After(deadline) {
+			t.Fatalf("runs = %d, %d", ok.Load(), failing.Load())
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if err := s.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+
+	stats := s.Stats()
+	if len(stats) != 2 || stats[0].Name != "failing" || stats[1].Name != "ok" {
+		t.Fatalf("Stats() = %+v", stats)
+	}
+	if f := stats[0]; f.Failures != f.Runs || f.LastError != "down" || !f.Next.IsZero() {
+		t.Errorf("stats of the failing task = %+v", f)
+	}
+	if o := stats[1]; o.Failures != 0 || o.Runs < 3 || o.Schedule != "@every 5ms" || o.LastRun.IsZero() {
+		t.Errorf("stats of the ok task = %+v", o)
+	}
+	if err := s.Start(context.Background()); err == nil {
+		t.Error("Start() of a stopped scheduler error = nil")
+	}
+}
+
+func TestScheduler_StopTimeout(t *testing.T) {
+	s := scheduler.New(scheduler.Config{Logger: slog.New(slog.DiscardHandler)})
+	release := make(chan struct{})
+	running := make(chan struct{})
+	s.Add("stuck", scheduler.Every(time.Hour), func(ctx context.Context) error {
+		close(running)
+		<-release // Ignores its context
+		return nil
+	}, scheduler.RunOnStart())
+	s.Start(context.Background())
+	<-running
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := s.Stop(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Stop() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if stats := s.Stats(); !stats[0].Running {
+		t.Errorf("Stats() = %+v, want the task running", stats)
+	}
+	close(release)
+	if err := s.Stop(context.Background()); err != nil {
+		t.Errorf("Stop() error = %v once the task returned", err)
+	}
+}
+
+func TestScheduler_Timeout(t *testing.T) {
+	s := scheduler.New(scheduler.Config{Logger: slog.New(slog.DiscardHandler)})
+	done := make(chan error, 1)
+	s.Add("slow", scheduler.Every(time.Hour), func(ctx context.Context) error {
+		<-ctx.Done()
+		done <- ctx.Err()
+		return ctx.Err()
+	}, scheduler.RunOnStart(), scheduler.WithTimeout(5*time.Millisecond))
+	s.Start(context.Background())
+	defer s.Stop(context.Background())
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("ctx.Err() = %v, want %v", err, context.DeadlineExceeded)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("task not timed out")
+	}
+}
@@ -0,0 +1,52 @@
+package gotth
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/ancalabrese/gotth/scheduler"
+)
+
+func TestWebServer_Scheduler(t *testing.T) {
+	s := scheduler.New(scheduler.Config{Logger: slog.New(slog.DiscardHandler)})
+	started, stopped := make(chan struct{}), make(chan struct{})
+	s.Add("sitemap", scheduler.Every(time.Hour), func(ctx context.Context) error {
+		close(started)
+		<-ctx.Done() // Runs until the shutdown
+		close(stopped)
+		return nil
+	}, scheduler.RunOnStart())
+
+	ws, err := NewWithOptions(WithAddr(freeAddr(t)), WithLogger(slog.New(slog.DiscardHandler)), WithScheduler(s))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- ws.Start(ctx) }()
+
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("task not started with the server")
+	}
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Start() error = %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Start() didn't return after cancel")
+	}
+	select {
+	case <-stopped:
+	default:
+		t.Error("Start() returned before the task stopped")
+	}
+	if stats := s.Stats(); len(stats) != 1 || stats[0].Runs != 1 {
+		t.Errorf("Stats() = %+v, want one run", stats)
+	}
+}
@@ -0,0 +1,91 @@
+// Package scroll standardizes infinite lists: the server returns a page of items followed by a
+// sentinel element that loads the next page with hx-get when it's scrolled into view, replacing
+// itself with the new items and the next sentinel.
+//
+//	func messages(w http.ResponseWriter, r *http.Request) {
+//		var after int64 // Keyset pagination on the message ID
+//		if err := scroll.DecodeCursor(scroll.CursorParam(r), &after); err != nil { ... }
+//		msgs, _ := db.Messages(r.Context(), after, pageSize+1) // One more to know if there's a next page
+//		page, _ := scroll.NewPage(msgs, pageSize, func(m Message) any { return m.ID })
+//		views.Messages(page.Items, scroll.NextURL(r, page.Next)).Render(r.Context(), w)
+//	}
+//
+// where the view renders the items followed by @scroll.Sentinel(nextURL).
+package scroll
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// DefaultCursorParam is the query parameter holding the cursor.
+const DefaultCursorParam = "cursor"
+
+// ErrInvalidCursor is returned when a cursor can't be decoded.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Page is a page of items.
+type Page[T any] struct {
+	Items []T
+	// Next is the cursor of the next page, or "" on the last page.
+	Next string
+}
+
+// NewPage returns the first limit items as a page. Fetch limit+1 items: the extra one tells
+// there's a next page, whose cursor is the value returned by cursor for the last item of the
+// page (e.g., its ID or sort key).
+func NewPage[T any](items []T, limit int, cursor func(T) any) (Page[T], error) {
+	if len(items) <= limit {
+		return Page[T]{Items: items}, nil
+	}
+	items = items[:limit]
+	next, err := EncodeCursor(cursor(items[len(items)-1]))
+	if err != nil {
+		return Page[T]{}, err
+	}
+	return Page[T]{Items: items, Next: next}, nil
+}
+
+// EncodeCursor encodes v into an opaque cursor. Cursors aren't signed: validate the decoded
+// values like any other user input.
+func EncodeCursor(v any) (string, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode cursor. err %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// DecodeCursor decodes cursor into v. An empty cursor, for the first page, leaves v unchanged.
+func DecodeCursor(cursor string, v any) error {
+	if cursor == "" {
+		return nil
+	}
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return ErrInvalidCursor
+	}
+	if err := json.Unmarshal(b, v); err != nil {
+		return ErrInvalidCursor
+	}
+	return nil
+}
+
+// CursorParam returns the cursor of r, from the [DefaultCursorParam] query parameter.
+func CursorParam(r *http.Request) string {
+	return r.URL.Query().Get(DefaultCursorParam)
+}
+
+// NextURL returns the URL of the page after r with the cursor next, keeping the other query
+// parameters (e.g., filters). It returns "" when next is "", on the last page.
+func NextURL(r *http.Request, next string) string {
+	if next == "" {
+		return ""
+	}
+	q := r.URL.Query()
+	q.Set(DefaultCursorParam, next)
+	return r.URL.Path + "?" + q.Encode()
+}
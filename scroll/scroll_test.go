@@ -0,0 +1,107 @@
+package scroll_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/a-h/templ"
+	"github.com/ancalabrese/gotth/scroll"
+)
+
+type message struct {
+	ID   int64
+	Text string
+}
+
+func TestPagination(t *testing.T) {
+	all := []message{{1, "a"}, {2, "b"}, {3, "c"}, {4, "d"}, {5, "e"}}
+	// fetch emulates a keyset query returning up to n messages after the cursor.
+	fetch := func(cursor string, n int) []message {
+		var after int64
+		if err := scroll.DecodeCursor(cursor, &after); err != nil {
+			t.Fatalf("DecodeCursor() error = %v", err)
+		}
+		var out []message
+		for _, m := range all {
+			if m.ID > after && len(out) < n {
+				out = append(out, m)
+			}
+		}
+		return out
+	}
+
+	var got []string
+	cursor, pages := "", 0
+	for {
+		page, err := scroll.NewPage(fetch(cursor, 3), 2, func(m message) any { return m.ID })
+		if err != nil {
+			t.Fatalf("NewPage() error = %v", err)
+		}
+		for _, m := range page.Items {
+			got = append(got, m.Text)
+		}
+		pages++
+		if page.Next == "" {
+			break
+		}
+		cursor = page.Next
+	}
+	if strings.Join(got, "") != "abcde" || pages != 3 {
+		t.Errorf("got %v in %d pages, want abcde in 3 pages", got, pages)
+	}
+
+	var v int64
+	if err := scroll.DecodeCursor("not base64!", &v); !errors.Is(err, scroll.ErrInvalidCursor) {
+		t.Errorf("DecodeCursor() error = %v, want %v", err, scroll.ErrInvalidCursor)
+	}
+}
+
+func TestNextURL(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/messages?q=hi&cursor=old", nil)
+	if got := scroll.NextURL(r, "new"); got != "/messages?cursor=new&q=hi" {
+		t.Errorf("NextURL() = %q", got)
+	}
+	if got := scroll.NextURL(r, ""); got != "" {
+		t.Errorf("NextURL() on the last page = %q, want empty", got)
+	}
+}
+
+func TestItems(t *testing.T) {
+	items := []templ.Component{templ.Raw("<li>a</li>"), templ.Raw("<li>b</li>")}
+	tests := []struct {
+		name     string
+		c        templ.Component
+		expected string
+	}{
+		{
+			name:     "With next page",
+			c:        scroll.Items(items, "/messages?cursor=Mg"),
+			expected: `<li>a</li><li>b</li><div hx-get="/messages?cursor=Mg" hx-trigger="revealed" hx-swap="outerHTML" aria-busy="true" data-scroll-sentinel><span class="htmx-indicator">Loading…</span></div>`,
+		},
+		{
+			name:     "Last page",
+			c:        scroll.Items(items, ""),
+			expected: `<li>a</li><li>b</li>`,
+		},
+		{
+			name:     "Table row",
+			c:        scroll.SentinelRow("/rows?cursor=Mg", 3),
+			expected: `<tr hx-get="/rows?cursor=Mg" hx-trigger="revealed" hx-swap="outerHTML" aria-busy="true" data-scroll-sentinel><td colspan="3"><span class="htmx-indicator">Loading…</span></td></tr>`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b strings.Builder
+			if err := tt.c.Render(context.Background(), &b); err != nil {
+				t.Fatal(err)
+			}
+			if b.String() != tt.expected {
+				t.Errorf("got %s\nwant %s", b.String(), tt.expected)
+			}
+		})
+	}
+}
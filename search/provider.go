@@ -0,0 +1,28 @@
+package search
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/a-h/templ"
+	"github.com/ancalabrese/gotth/htmx"
+	"github.com/ancalabrese/gotth/views/components/head"
+)
+
+// Provider is the content provider of the search page, searching the QueryParam query
+// parameter. The requests of the live search, targeting the results, get only the results.
+func (ix *Index) Provider(r *http.Request) (head.HeadViewModel, templ.Component, error) {
+	q := strings.TrimSpace(r.URL.Query().Get(QueryParam))
+	results := ix.Search(q)
+
+	title := "Search"
+	if q != "" {
+		title = "Search: " + q
+	}
+	opts := append(slices.Clone(ix.cfg.Head), head.WithPageCoreMetadata(title, "", ""))
+	if htmx.Target(r) == ResultsID {
+		return head.NewHeadViewModel(opts...), Results(q, results), nil
+	}
+	return head.NewHeadViewModel(opts...), Page(ix.cfg.Path, q, results), nil
+}
@@ -0,0 +1,328 @@
+// Package search is a lightweight in-memory full-text index of the content of a site, with a
+// search page and HTMX live search:
+//
+//	ix := search.New(search.Config{})
+//	ix.AddSource("blog", b.SearchSource())
+//	ix.AddSource("guides", search.CollectionSource(guides, func(e *content.Entry[Guide]) search.Document {
+//		return search.Document{URL: "/guides/" + e.ID, Title: e.Data.Title, Text: e.Content.HTML}
+//	}))
+//	search.Serve(ws, ix) // GET /search?q=...
+//
+// Documents are matched on all the words of the query, the last one as a prefix while it's being
+// typed, ignoring case and accents. Matches in titles weigh more. Rebuild the index when the
+// content changes: [Index.Rebuild] runs the sources again, [Index.Replace] swaps the documents
+// of a source, e.g. from a hook of the content.
+//
+// The search page is styled with Tailwind: add this package to the sources of your stylesheet.
+package search
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/ancalabrese/gotth/content"
+	"github.com/ancalabrese/gotth/views/components/head"
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+const (
+	// DefaultPath is where the search page is served when Config.Path is empty.
+	DefaultPath = "/search"
+	// QueryParam is the query parameter of the search terms.
+	QueryParam = "q"
+	// ResultsID is the id of the element of the results, swapped by the live search.
+	ResultsID = "search-results"
+
+	titleWeight = 5 // A word of the title counts as many words of the text
+	maxTerms    = 10
+	maxQuery    = 200 // Bytes of the query searched
+)
+
+// Document is a searchable page.
+type Document struct {
+	// URL of the page. It identifies the document in its source.
+	URL   string
+	Title string
+	// Text is the content of the page, plain or HTML.
+	Text string
+}
+
+// Fragment is a part of a highlighted text, Match when it's a word of the query.
+type Fragment struct {
+	Text  string
+	Match bool
+}
+
+// Result is a document matching a query.
+type Result struct {
+	Document
+	// Source is the name of the source of the document.
+	Source string
+	Score  float64
+	// HighlightedTitle is the title, with the words of the query highlighted.
+	HighlightedTitle []Fragment
+	// Snippet is the excerpt of the text around the first match, highlighted.
+	Snippet []Fragment
+}
+
+// Source returns the documents of a part of the site, e.g. the posts of a blog.
+type Source func() ([]Document, error)
+
+// Config configures an [Index] and its search page.
+type Config struct {
+	// Path the search page is served at. Defaults to [DefaultPath].
+	Path string
+	// Limit is the maximum number of results. Defaults to 20.
+	Limit int
+	// Optional: head options of the search page, e.g. the site name and stylesheets.
+	Head []head.Option
+}
+
+// Index is an in-memory full-text index. It's safe for concurrent use.
+type Index struct {
+	cfg Config
+
+	mu       sync.RWMutex
+	sources  map[string]Source
+	docs     map[string][]*indexed // By source
+	postings map[string][]posting  // By term
+	terms    []string              // Sorted, for the prefix matches
+}
+
+type indexed struct {
+	Document
+	source string
+	text   string // Plain text
+}
+
+type posting struct {
+	doc    *indexed
+	weight float64
+}
+
+// New returns an empty Index.
+func New(cfg Config) *Index {
+	cfg.Path = "/" + strings.Trim(cmp.Or(cfg.Path, DefaultPath), "/")
+	if cfg.Limit <= 0 {
+		cfg.Limit = 20
+	}
+	return &Index{cfg: cfg, sources: map[string]Source{}, docs: map[string][]*indexed{}, postings: map[string][]posting{}}
+}
+
+// Path returns the path the search page is served at.
+func (ix *Index) Path() string {
+	return ix.cfg.Path
+}
+
+// AddSource indexes the documents of src as the source name, replacing the source of the same
+// name, and runs it again on [Index.Rebuild].
+func (ix *Index) AddSource(name string, src Source) error {
+	ix.mu.Lock()
+	ix.sources[name] = src
+	ix.mu.Unlock()
+	return ix.rebuildSource(name, src)
+}
+
+// Rebuild runs the sources again and indexes their documents. The sources that fail keep their
+// previous documents, and their errors are returned together.
+func (ix *Index) Rebuild() error {
+	ix.mu.RLock()
+	sources := maps.Clone(ix.sources)
+	ix.mu.RUnlock()
+
+	var errs []error
+	for name, src := range sources {
+		if err := ix.rebuildSource(name, src); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (ix *Index) rebuildSource(name string, src Source) error {
+	docs, err := src()
+	if err != nil {
+		return fmt.Errorf("failed to index %s. err %w", name, err)
+	}
+	ix.Replace(name, docs)
+	return nil
+}
+
+// Replace swaps the documents of the source name for docs, e.g. from a hook called when the
+// content changes.
+func (ix *Index) Replace(name string, docs []Document) {
+	indexedDocs := make([]*indexed, 0, len(docs))
+	for _, d := range docs {
+		indexedDocs = append(indexedDocs, &indexed{Document: d, source: name, text: plainText(d.Text)})
+	}
+
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	if len(indexedDocs) == 0 {
+		delete(ix.docs, name)
+	} else {
+		ix.docs[name] = indexedDocs
+	}
+	ix.reindex()
+}
+
+// Len returns the number of indexed documents.
+func (ix *Index) Len() int {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	return ix.count()
+}
+
+// count is Len with ix.mu locked.
+func (ix *Index) count() int {
+	n := 0
+	for _, docs := range ix.docs {
+		n += len(docs)
+	}
+	return n
+}
+
+// reindex rebuilds the postings from the documents. ix.mu must be locked.
+func (ix *Index) reindex() {
+	ix.postings = map[string][]posting{}
+	for _, docs := range ix.docs {
+		for _, d := range docs {
+			counts := map[string]float64{}
+			for _, t := range terms(d.Title) {
+				counts[t] += titleWeight
+			}
+			for _, t := range terms(d.text) {
+				counts[t]++
+			}
+			for t, n := range counts {
+				// Dampen the repetitions, so that long documents don't win by their length.
+				ix.postings[t] = append(ix.postings[t], posting{doc: d, weight: 1 + math.Log(n)})
+			}
+		}
+	}
+	ix.terms = ix.terms[:0]
+	for t := range ix.postings {
+		ix.terms = append(ix.terms, t)
+	}
+	slices.Sort(ix.terms)
+}
+
+// Search returns the documents matching all the words of query, the best first, up to
+// Config.Limit.
+func (ix *Index) Search(query string) []Result {
+	if len(query) > maxQuery {
+		query = query[:maxQuery]
+	}
+	words := terms(query)
+	if len(words) == 0 {
+		return nil
+	}
+	words = words[:min(len(words), maxTerms)]
+
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	n := float64(ix.count())
+	var scores map[*indexed]float64
+	for i, w := range words {
+		matched := map[*indexed]float64{}
+		for _, t := range ix.matching(w, i == len(words)-1) {
+			postings := ix.postings[t]
+			idf := math.Log(1 + n/float64(len(postings)))
+			for _, p := range postings {
+				matched[p.doc] += p.weight * idf
+			}
+		}
+		if scores == nil {
+			scores = matched
+			continue
+		}
+		for d, s := range scores {
+			if m, ok := matched[d]; ok {
+				scores[d] = s + m
+			} else {
+				delete(scores, d)
+			}
+		}
+	}
+
+	matches := make([]*indexed, 0, len(scores))
+	for d := range scores {
+		matches = append(matches, d)
+	}
+	slices.SortFunc(matches, func(a, b *indexed) int {
+		return cmp.Or(cmp.Compare(scores[b], scores[a]), strings.Compare(a.Title, b.Title), strings.Compare(a.URL, b.URL))
+	})
+	matches = matches[:min(len(matches), ix.cfg.Limit)]
+	results := make([]Result, 0, len(matches))
+	for _, d := range matches {
+		results = append(results, Result{
+			Document:         d.Document,
+			Source:           d.source,
+			Score:            scores[d],
+			HighlightedTitle: highlight(d.Title, words, false),
+			Snippet:          highlight(d.text, words, true),
+		})
+	}
+	return results
+}
+
+// matching returns the indexed terms equal to w, or starting with it when prefix is set.
+func (ix *Index) matching(w string, prefix bool) []string {
+	if !prefix {
+		if _, ok := ix.postings[w]; ok {
+			return []string{w}
+		}
+		return nil
+	}
+	var matched []string
+	for i := sort.SearchStrings(ix.terms, w); i < len(ix.terms) && strings.HasPrefix(ix.terms[i], w); i++ {
+		matched = append(matched, ix.terms[i])
+	}
+	return matched
+}
+
+// CollectionSource returns the Source of the entries of c, turned into documents by doc.
+func CollectionSource[T any](c *content.Collection[T], doc func(*content.Entry[T]) Document) Source {
+	return func() ([]Document, error) {
+		entries := c.All()
+		docs := make([]Document, 0, len(entries))
+		for _, e := range entries {
+			docs = append(docs, doc(e))
+		}
+		return docs, nil
+	}
+}
+
+// fold lowercases s and removes its accents.
+var fold = transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), runes.Map(unicode.ToLower), norm.NFC)
+
+func foldWord(w string) string {
+	folded, _, err := transform.String(fold, w)
+	if err != nil {
+		return strings.ToLower(w)
+	}
+	return folded
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsNumber(r)
+}
+
+// terms returns the folded words of s.
+func terms(s string) []string {
+	words := strings.FieldsFunc(s, func(r rune) bool { return !isWordRune(r) })
+	for i, w := range words {
+		words[i] = foldWord(w)
+	}
+	return words
+}
@@ -0,0 +1,180 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/ancalabrese/gotth/content"
+)
+
+func newIndex(t *testing.T) *Index {
+	t.Helper()
+	ix := New(Config{})
+	err := ix.AddSource("docs", func() ([]Document, error) {
+		return []Document{
+			{URL: "/install", Title: "Installing gotth", Text: "<p>Run <code>go get</code> to install the module.</p><script>var hidden = 1</script>"},
+			{URL: "/templates", Title: "Templates", Text: "Templates are compiled by templ. Install the templ CLI first."},
+			{URL: "/cafe", Title: "Café", Text: "A page with accents: crème brûlée."},
+		}, nil
+	})
+	if err != nil {
+		t.Fatalf("AddSource() error = %v", err)
+	}
+	return ix
+}
+
+func urls(results []Result) string {
+	var out []string
+	for _, r := range results {
+		out = append(out, r.URL)
+	}
+	return strings.Join(out, ",")
+}
+
+func TestIndex_Search(t *testing.T) {
+	ix := newIndex(t)
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{query: "install", want: "/install,/templates"}, // Title matches first
+		{query: "templ cli", want: "/templates"},        // All the words
+		{query: "tem", want: "/templates"},              // Last word as a prefix
+		{query: "tem install", want: ""},                // Only the last word
+		{query: "CAFE creme", want: "/cafe"},            // Case and accents
+		{query: "hidden", want: ""},                     // Scripts aren't indexed
+		{query: "code", want: ""},                       // Nor tags
+		{query: "  ", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			if got := urls(ix.Search(tt.query)); got != tt.want {
+				t.Errorf("Search(%q) = %s, want %s", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIndex_Highlight(t *testing.T) {
+	ix := New(Config{})
+	ix.Replace("docs", []Document{{
+		URL:   "/long",
+		Title: "Long page",
+		Text:  strings.Repeat("filler ", 20) + "the needle is here " + strings.Repeat("more ", 40),
+	}})
+	results := ix.Search("needl")
+	if len(results) != 1 {
+		t.Fatalf("Search() = %d results, want 1", len(results))
+	}
+	r := results[0]
+	if want := []Fragment{{Text: "Long page"}}; !reflect.DeepEqual(r.HighlightedTitle, want) {
+		t.Errorf("HighlightedTitle = %+v, want %+v", r.HighlightedTitle, want)
+	}
+	want := []Fragment{
+		{Text: "… " + strings.Repeat("filler ", 7) + "the "},
+		{Text: "needle", Match: true},
+		{Text: " is here" + strings.Repeat(" more", 19) + " …"},
+	}
+	if !reflect.DeepEqual(r.Snippet, want) {
+		t.Errorf("Snippet = %+v, want %+v", r.Snippet, want)
+	}
+}
+
+func TestIndex_Rebuild(t *testing.T) {
+	ix := newIndex(t)
+	version := 1
+	failing := false
+	err := ix.AddSource("news", func() ([]Document, error) {
+		if failing {
+			return nil, errors.New("unavailable")
+		}
+		return []Document{{URL: "/news", Title: "News", Text: "version " + strings.Repeat("v", version)}}, nil
+	})
+	if err != nil || ix.Len() != 4 {
+		t.Fatalf("AddSource() error = %v, %d documents", err, ix.Len())
+	}
+
+	version = 2
+	if err := ix.Rebuild(); err != nil {
+		t.Fatalf("Rebuild() error = %v", err)
+	}
+	if got := urls(ix.Search("vv")); got != "/news" {
+		t.Errorf("Search(vv) after Rebuild = %s", got)
+	}
+
+	failing = true
+	if err := ix.Rebuild(); err == nil || !strings.Contains(err.Error(), "news") {
+		t.Errorf("Rebuild() error = %v, want the failing source", err)
+	}
+	if ix.Len() != 4 {
+		t.Errorf("Len() = %d after a failed rebuild, want 4", ix.Len())
+	}
+
+	ix.Replace("docs", nil)
+	if ix.Len() != 1 || urls(ix.Search("install")) != "" {
+		t.Errorf("Len() = %d after Replace, want 1", ix.Len())
+	}
+}
+
+func TestCollectionSource(t *testing.T) {
+	type page struct {
+		Title string `yaml:"title"`
+	}
+	c, err := content.NewCollection(content.CollectionConfig[page]{FS: fstest.MapFS{
+		"guide.md": {Data: []byte("---\ntitle: Guide\n---\nHow to **deploy**.\n")},
+	}})
+	if err != nil {
+		t.Fatalf("NewCollection() error = %v", err)
+	}
+	ix := New(Config{})
+	ix.AddSource("guides", CollectionSource(c, func(e *content.Entry[page]) Document {
+		return Document{URL: "/guides/" + e.ID, Title: e.Data.Title, Text: e.Content.HTML}
+	}))
+	if got := urls(ix.Search("deploy")); got != "/guides/guide" {
+		t.Errorf("Search(deploy) = %s", got)
+	}
+}
+
+func TestIndex_Provider(t *testing.T) {
+	ix := newIndex(t)
+
+	r := httptest.NewRequest("GET", "/search?q=templ", nil)
+	vm, page, err := ix.Provider(r)
+	if err != nil {
+		t.Fatalf("Provider() error = %v", err)
+	}
+	html := render(t, page)
+	if vm.Metadata.Title != "Search: templ" || !strings.Contains(html, `<form method="get" action="/search"`) || !strings.Contains(html, `<mark>Templates</mark> are compiled by <mark>templ</mark>. Install`) {
+		t.Errorf("page = %q, %s", vm.Metadata.Title, html)
+	}
+
+	r.Header.Set("HX-Request", "true")
+	r.Header.Set("HX-Target", ResultsID)
+	_, results, _ := ix.Provider(r)
+	html = render(t, results)
+	if strings.Contains(html, "<form") || !strings.HasPrefix(html, `<div id="search-results"`) {
+		t.Errorf("live search results = %s", html)
+	}
+
+	_, results, _ = ix.Provider(httptest.NewRequest("GET", "/search?q=nothing", nil))
+	if html := render(t, results); !strings.Contains(html, "No results for “nothing”") {
+		t.Errorf("no results = %s", html)
+	}
+}
+
+func render(t *testing.T, c interface {
+	Render(context.Context, io.Writer) error
+}) string {
+	t.Helper()
+	var out strings.Builder
+	if err := c.Render(context.Background(), &out); err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	return out.String()
+}
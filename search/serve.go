@@ -0,0 +1,17 @@
+package search
+
+import (
+	"github.com/ancalabrese/gotth"
+	"github.com/ancalabrese/gotth/routes"
+)
+
+// Serve serves the search page of ix on ws at its path, e.g. /search, named "search" for the
+// breadcrumbs. Its form searches as the query is typed, swapping the results with HTMX.
+func Serve(ws *gotth.WebServer, ix *Index) {
+	if ix == nil {
+		ws.InvalidRoute("", "search.Serve needs an index")
+		return
+	}
+	ws.ServeContent("GET "+ix.Path(), ix.Provider)
+	ws.SetRouteMeta("GET "+ix.Path(), routes.Meta{Name: "search", Title: "Search"})
+}
@@ -0,0 +1,55 @@
+package search_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/ancalabrese/gotth"
+	"github.com/ancalabrese/gotth/blog"
+	"github.com/ancalabrese/gotth/search"
+)
+
+func TestServe(t *testing.T) {
+	b, err := blog.New(blog.Config{
+		FS: fstest.MapFS{
+			"hello.md": {Data: []byte("---\ntitle: Hello\ndate: 2025-06-01\n---\nServing pages with **htmx**.\n")},
+			"draft.md": {Data: []byte("---\ntitle: Secret htmx plans\ndate: 2025-06-02\ndraft: true\n---\n")},
+		},
+		Drafts: true,
+	})
+	if err != nil {
+		t.Fatalf("blog.New() error = %v", err)
+	}
+	ix := search.New(search.Config{})
+	if err := ix.AddSource("blog", b.SearchSource()); err != nil {
+		t.Fatalf("AddSource() error = %v", err)
+	}
+	ws, err := gotth.New(gotth.WebServerConfig{}, nil)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	search.Serve(ws, ix)
+	search.Serve(ws, nil)
+	if err := ws.Err(); err == nil || !strings.Contains(err.Error(), "search.Serve needs an index") {
+		t.Errorf("Err() = %v, want the nil index", err)
+	}
+
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/search?q=htm", nil)
+	req.Header.Set("HX-Request", "true")
+	req.Header.Set("HX-Target", search.ResultsID)
+	ws.Handler().ServeHTTP(rr, req)
+	body := rr.Body.String()
+	if rr.Code != http.StatusOK || !strings.Contains(body, `href="/blog/hello"`) || strings.Contains(body, "Secret") || strings.Contains(body, "<form") {
+		t.Errorf("live search = %d:\n%s", rr.Code, body)
+	}
+
+	rr = httptest.NewRecorder()
+	ws.Handler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/search?q=htmx", nil))
+	if body := rr.Body.String(); !strings.Contains(body, "<html") || !strings.Contains(body, `value="htmx"`) {
+		t.Errorf("search page = %d:\n%s", rr.Code, body)
+	}
+}
@@ -0,0 +1,138 @@
+package search
+
+import (
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+const (
+	snippetWords = 30 // Words of a snippet
+	snippetLead  = 8  // Words of the snippet before the first match
+)
+
+// plainText returns the text of s without its HTML tags, scripts and styles.
+func plainText(s string) string {
+	if !strings.Contains(s, "<") {
+		return s
+	}
+	var text strings.Builder
+	skip := 0 // Depth in scripts and styles
+	z := html.NewTokenizer(strings.NewReader(s))
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return strings.Join(strings.Fields(text.String()), " ")
+		case html.StartTagToken:
+			if a := z.Token().DataAtom; a == atom.Script || a == atom.Style {
+				skip++
+			}
+			text.WriteByte(' ')
+		case html.EndTagToken:
+			if a := z.Token().DataAtom; (a == atom.Script || a == atom.Style) && skip > 0 {
+				skip--
+			}
+			text.WriteByte(' ')
+		case html.SelfClosingTagToken:
+			text.WriteByte(' ')
+		case html.TextToken:
+			if skip == 0 {
+				text.Write(z.Text())
+			}
+		}
+	}
+}
+
+// token is a word of a text, or what separates two words.
+type token struct {
+	text  string
+	word  bool
+	match bool
+}
+
+// tokenize splits s into words and separators, marking the words matching the query words,
+// the last one as a prefix.
+func tokenize(s string, words []string) []token {
+	var tokens []token
+	for len(s) > 0 {
+		first, _ := utf8.DecodeRuneInString(s)
+		word := isWordRune(first)
+		end := strings.IndexFunc(s, func(r rune) bool { return isWordRune(r) != word })
+		if end < 0 {
+			end = len(s)
+		}
+		t := token{text: s[:end], word: word}
+		if word {
+			folded := foldWord(t.text)
+			for i, w := range words {
+				if folded == w || (i == len(words)-1 && strings.HasPrefix(folded, w)) {
+					t.match = true
+					break
+				}
+			}
+		}
+		tokens = append(tokens, t)
+		s = s[end:]
+	}
+	return tokens
+}
+
+// highlight returns s as fragments, the words of the query being matches. When snippet is set,
+// only the words around the first match are kept.
+func highlight(s string, words []string, snippet bool) []Fragment {
+	tokens := tokenize(s, words)
+	if snippet {
+		tokens = window(tokens)
+	}
+	var fragments []Fragment
+	for _, t := range tokens {
+		if n := len(fragments); n > 0 && fragments[n-1].Match == t.match && !t.match {
+			fragments[n-1].Text += t.text
+			continue
+		}
+		fragments = append(fragments, Fragment{Text: t.text, Match: t.match})
+	}
+	return fragments
+}
+
+// window returns the snippetWords words of tokens from snippetLead words before the first
+// match, with ellipses where the text is cut.
+func window(tokens []token) []token {
+	first, words := -1, 0
+	var wordIndex []int // Index in tokens of each word
+	for i, t := range tokens {
+		if !t.word {
+			continue
+		}
+		if t.match && first < 0 {
+			first = words
+		}
+		wordIndex = append(wordIndex, i)
+		words++
+	}
+	if words == 0 {
+		return nil
+	}
+	start := max(0, first-snippetLead)
+	end := min(words, start+snippetWords)
+	start = max(0, min(start, end-snippetWords))
+
+	from, to := 0, len(tokens)
+	if start > 0 {
+		from = wordIndex[start]
+	}
+	if end < words {
+		to = wordIndex[end-1] + 1
+	}
+	out := slices.Clone(tokens[from:to])
+	if start > 0 {
+		out = append([]token{{text: "… "}}, out...)
+	}
+	if end < words {
+		out = append(out, token{text: " …"})
+	}
+	return out
+}
@@ -2,15 +2,31 @@ package gotth
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"runtime/debug"
+	"slices"
 	"strings"
 	"syscall"
 	"time"
 
 	"github.com/a-h/templ"
+	"github.com/ancalabrese/gotth/admin"
+	"github.com/ancalabrese/gotth/deverror"
+	"github.com/ancalabrese/gotth/devwatch"
+	"github.com/ancalabrese/gotth/htmx"
+	"github.com/ancalabrese/gotth/i18n"
+	"github.com/ancalabrese/gotth/livereload"
+	"github.com/ancalabrese/gotth/middlewares"
+	"github.com/ancalabrese/gotth/nav"
+	"github.com/ancalabrese/gotth/routes"
+	"github.com/ancalabrese/gotth/scheduler"
 	"github.com/ancalabrese/gotth/views/components/head"
 	"github.com/ancalabrese/gotth/views/components/layout"
 )
@@ -39,6 +55,68 @@ type WebServerConfig struct {
 	StaticAssetsFS []StaticAssetFS
 	// Middlewares globally applied
 	GlobalMiddlewares []func(http.Handler) http.Handler
+	// Optional: the locales of the pages of [WebServer.ServeLocalized]. The locale prefixing the
+	// request path is added to the context of every request (see the i18n package).
+	Locales *i18n.Config
+	// Optional: served at /.well-known/security.txt when set
+	SecurityTxt *SecurityTxt
+	// Optional: served at /humans.txt when set
+	HumansTxt *HumansTxt
+	// Optional: path serving the version of the binary as JSON, e.g. "/version". See
+	// [WebServer.EnableBuildInfo].
+	BuildInfoPath string
+	// EnablePprof mounts net/http/pprof under /debug/pprof/ to diagnose slow renders in
+	// production. Always set PprofGuard, e.g. to middlewares.BasicAuth or middlewares.IPFilter.
+	EnablePprof bool
+	// Optional: middleware protecting the pprof endpoints.
+	PprofGuard func(http.Handler) http.Handler
+	// Optional: serves the admin dashboard (routes, recent errors, request metrics, cache hit
+	// rates and active sessions) behind its Guard. See the admin package.
+	Admin *admin.Config
+	// Optional: periodic tasks started with the server and stopped during its graceful shutdown,
+	// waiting for the running ones. The admin dashboard lists their runs. See the scheduler
+	// package.
+	Scheduler *scheduler.Scheduler
+	// Optional: Logger receives the server logs: registrations (at debug level), startup,
+	// shutdown and errors. Defaults to slog.Default() when nil.
+	Logger *slog.Logger
+	// Optional: FragmentLayout wraps the content of pages requested by HTMX (HX-Request) instead
+	// of the full layout, e.g. to add an out-of-band title. Only the content is rendered when nil.
+	FragmentLayout func(headVM head.HeadViewModel, content templ.Component) templ.Component
+	// Optional: page rendered with status 500 when a handler panics. A plain error is sent when
+	// nil. See [middlewares.Recover].
+	ErrorPage ContentProviderFunc
+	// DevMode reloads the pages open in the browser when the server restarts or a file in
+	// DevWatch changes (see the livereload package), and answers content provider errors and
+	// panics with developer error pages (see the deverror package). Never enable it in production.
+	DevMode bool
+	// Optional: serve HTTPS with the certificate and private key in these PEM files.
+	TLSCertFile string
+	TLSKeyFile  string
+	// LogRoutes prints the route table (see [WebServer.PrintRoutes]) when the server starts.
+	LogRoutes bool
+	// Optional: directories watched in DevMode, e.g. "." for the sources of DevTasks or the
+	// output of Tailwind.
+	DevWatch []string
+	// Optional: generators run in DevMode when a matching file of DevWatch changes, e.g.
+	// devwatch.TemplTask and devwatch.TailwindTask. Browsers reload once they succeed.
+	DevTasks []devwatch.Task
+	// ReusePort binds the address with SO_REUSEPORT (Linux and BSDs), so that the new process of
+	// a deploy can bind the same port while this one drains its connections after SIGTERM.
+	ReusePort bool
+	// Optional: with TLS, also listen for plain HTTP on this address, e.g. ":80", redirecting
+	// the requests to HTTPS. Both listeners start and stop together.
+	HTTPAddr string
+	// Optional: directory served under /.well-known/acme-challenge/ by the HTTP listener, for
+	// the HTTP-01 challenges of ACME clients like certbot ("--webroot").
+	ACMEChallengeDir string
+	// Optional: wraps the handler of the HTTP listener, e.g. autocert.Manager.HTTPHandler to
+	// answer its challenges. Set http.Server.TLSConfig to its TLSConfig() instead of TLSCertFile.
+	HTTPHandler func(http.Handler) http.Handler
+	// RestartOnSIGHUP hands the listening socket to a new process of the same executable on
+	// SIGHUP, then drains and stops this server, for deploys without dropped connections: replace
+	// the executable, then send SIGHUP. The new process serves the socket in [ListenFDEnv].
+	RestartOnSIGHUP bool
 }
 
 // WebServer handles HTTP requests and serves configured web pages
@@ -46,6 +124,19 @@ type WebServer struct {
 	config     WebServerConfig
 	httpServer *http.Server
 	mux        *http.ServeMux // Using standard library ServeMux for simplicity
+	logger     *slog.Logger
+	routes     *routes.Registry
+	routeInfos []RouteInfo
+	// Errors of the invalid or conflicting registrations, returned by Start.
+	registrationErrs []error
+	reloader         *livereload.Reloader // Only in DevMode
+	devLogs          *deverror.LogBuffer  // Only in DevMode
+	redirectServer   *http.Server         // Only with HTTPAddr, once started
+	admin            *admin.Dashboard     // Only with Admin
+	locales          *i18n.Locales        // Only with Locales
+	// Done when the server starts shutting down, ending the streams of CloseOnShutdown.
+	streams     context.Context
+	stopStreams context.CancelFunc
 }
 
 // New creates a new WebServer.
@@ -54,7 +145,30 @@ func New(cfg WebServerConfig, s *http.Server) (*WebServer, error) {
 		s = defaultServer()
 	}
 
-	mux := http.NewServeMux()
+	ws := &WebServer{
+		httpServer: s,
+		config:     cfg,
+		mux:        http.NewServeMux(),
+		logger:     cfg.Logger,
+		routes:     routes.NewRegistry(),
+	}
+	ws.streams, ws.stopStreams = context.WithCancel(context.Background())
+	if ws.logger == nil {
+		ws.logger = slog.Default()
+	}
+	if cfg.DevMode {
+		ws.devLogs = deverror.NewLogBuffer(ws.logger.Handler(), devLogLines)
+		ws.logger = slog.New(ws.devLogs)
+	}
+
+	if cfg.Locales != nil {
+		locales, err := i18n.New(*cfg.Locales)
+		if err != nil {
+			ws.registrationFailed(fmt.Errorf("invalid WebServerConfig.Locales. err %w", err))
+		}
+		ws.locales = locales
+	}
+
 	// Setup global static file serving if configured
 	for _, fsConfig := range cfg.StaticAssetsFS {
 		if fsConfig.assetFS != nil && fsConfig.urlPath != "" {
@@ -70,42 +184,102 @@ func New(cfg WebServerConfig, s *http.Server) (*WebServer, error) {
 				servePath += "/"
 			}
 
-			mux.Handle(servePath, http.StripPrefix(strings.TrimSuffix(urlPath, "/"), http.FileServer(fsConfig.assetFS)))
-			fmt.Printf("Serving static assets in %s from URL path '%s'\n", fsConfig.assetFS, servePath)
+			ws.handle(servePath, http.StripPrefix(strings.TrimSuffix(urlPath, "/"), http.FileServer(fsConfig.assetFS)), RouteKindStatic, 0)
 		}
 	}
 
-	return &WebServer{
-		httpServer: s,
-		config:     cfg,
-		mux:        mux,
-	}, nil
+	if cfg.SecurityTxt != nil {
+		ws.EnableSecurityTxt(*cfg.SecurityTxt)
+	}
+	if cfg.HumansTxt != nil {
+		ws.EnableHumansTxt(*cfg.HumansTxt)
+	}
+	if cfg.BuildInfoPath != "" {
+		ws.EnableBuildInfo(cfg.BuildInfoPath)
+	}
+	if cfg.EnablePprof {
+		ws.enablePprof(cfg.PprofGuard)
+	}
+	if cfg.Admin != nil {
+		ws.enableAdmin(*cfg.Admin)
+	}
+	if cfg.DevMode {
+		ws.reloader = livereload.New()
+		ws.handle("GET "+livereload.DefaultPath, ws.reloader.Handler(), RouteKindBuiltin, 0)
+	}
+	if err := ws.Err(); err != nil {
+		return nil, err
+	}
+
+	return ws, nil
 }
 
-// ServeContent adds a page to be served.
-func (ws *WebServer) ServeContent(path string, contentProvider ContentProviderFunc) {
+// ServeContent adds a page to be served. HTMX requests for the page get only its content (see
+// [WebServerConfig.FragmentLayout]), so the same route serves both the full page and the
+// fragment swapped in by hx-get. Boosted navigations get the body content with the title and
+// metadata updated out-of-band (see [layout.BoostedLayout]), history restores get the full page.
+// Providers returning an error wrapping routes.ErrNotFound (or content.ErrNotFound, the same
+// error), e.g. for an unknown slug, get a 404 Not Found, other errors a 500. The optional
+// middlewares wrap this page only, the first being the outermost, e.g. to give a slow page a
+// deadline:
+//
+//	ws.ServeContent("GET /report", report, middlewares.Timeout(2*time.Second, nil))
+func (ws *WebServer) ServeContent(path string, contentProvider ContentProviderFunc, mws ...func(http.Handler) http.Handler) {
 	if path == "" || contentProvider == nil {
-		fmt.Printf("Skipping registration of page with empty path or no ContentProvider\n")
+		ws.registrationFailed(fmt.Errorf("%w %q registered at %s: ServeContent needs a pattern and a ContentProviderFunc",
+			ErrInvalidRoute, path, callerSource()))
 		return
 	}
 
-	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	ws.handle(path, ws.pageHandler(path, contentProvider, mws), RouteKindPage, len(mws))
+}
+
+// pageHandler returns the handler of the page of contentProvider registered at path, wrapped
+// with mws.
+func (ws *WebServer) pageHandler(path string, contentProvider ContentProviderFunc, mws []func(http.Handler) http.Handler) http.Handler {
+	var handler http.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		headVM, pageContent, err := contentProvider(r)
+		if errors.Is(err, routes.ErrNotFound) {
+			ws.logger.DebugContext(r.Context(), "content not found", ws.requestAttrs(r, path, err)...)
+			http.NotFound(w, r)
+			return
+		}
 		if err != nil {
-			// TODO: Handle the error appropriately (e.g., log it, show a generic error page)
-			// allow the ContentProviderFunc to also suggest an HTTP status code
-			fmt.Fprintf(os.Stderr, "Error in ContentProvider for %s: %v\n", path, err)
+			ws.logger.ErrorContext(r.Context(), "content provider failed", ws.requestAttrs(r, path, err)...)
+			ws.serveError(w, r, err)
 			return
 		}
 
-		// Create the full page component by wrapping the page's content with the base layout
-		fullPageContent := layout.BasicLayout(headVM, pageContent)
+		if len(headVM.Breadcrumbs) == 0 {
+			if trail := ws.routes.Trail(r.Context(), r.URL.Path); len(trail) > 1 {
+				headVM.Breadcrumbs = trail
+			}
+		}
+
+		// HTMX swaps the response into the current page, which already has the layout and head.
+		// Responses differ by HX-Request and HX-Boosted, so caches must keep every version.
+		middlewares.AddVary(w.Header(), middlewares.VaryHTMX, middlewares.VaryHTMXBoosted)
+		var fullPageContent templ.Component
+		switch {
+		case htmx.IsFragment(r):
+			fullPageContent = pageContent
+			if ws.config.FragmentLayout != nil {
+				fullPageContent = ws.config.FragmentLayout(headVM, pageContent)
+			}
+		case htmx.IsBoosted(r) && !htmx.IsHistoryRestore(r):
+			// Boosted navigations only swap the body, so skip the document and update the head
+			// out-of-band.
+			fullPageContent = layout.BoostedLayout(headVM, pageContent)
+		default:
+			// Create the full page component by wrapping the page's content with the base layout
+			fullPageContent = layout.BasicLayout(headVM, pageContent)
+		}
 
 		// Set content type and render
 		w.Header().Set("Content-Type", "text/html; charset=utf-8")
 		err = fullPageContent.Render(r.Context(), w) // Pass request context
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error rendering page %s: %v\n", path, err)
+			ws.logger.ErrorContext(r.Context(), "failed to render page", ws.requestAttrs(r, path, err)...)
 			// On rendering error return HTTP error. Any other error should be an error message
 			// in the rendered page. TODO: better error handling
 			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
@@ -113,42 +287,283 @@ func (ws *WebServer) ServeContent(path string, contentProvider ContentProviderFu
 		}
 	})
 
-	fmt.Printf("Registering page at path: %s\n", path)
-	ws.mux.Handle(path, handler)
+	for i := len(mws) - 1; i >= 0; i-- {
+		handler = mws[i](handler)
+	}
+	return handler
 }
 
-// Start initializes and runs the HTTP server.
-// Cancelling the context will stop the server
-func (ws *WebServer) Start(ctx context.Context) error {
-	var finalHandler http.Handler = ws.mux
+// Handle registers a plain http.Handler for the given pattern. Use it for endpoints that don't
+// render a full page, e.g. HTMX fragments, form submissions or redirects.
+// Invalid and conflicting registrations, here and in ServeContent, make Start fail: check them
+// with Err.
+func (ws *WebServer) Handle(pattern string, handler http.Handler) {
+	if pattern == "" || handler == nil {
+		ws.registrationFailed(fmt.Errorf("%w %q registered at %s: Handle needs a pattern and a handler",
+			ErrInvalidRoute, pattern, callerSource()))
+		return
+	}
+
+	ws.handle(pattern, handler, RouteKindHandler, 0)
+}
+
+// SetRouteMeta sets the metadata of the route registered with pattern, e.g. its title for the
+// breadcrumbs. Pages served with ServeContent get the BreadcrumbList JSON-LD of their trail.
+//
+//	ws.ServeContent("GET /blog/{slug}", post)
+//	ws.SetRouteMeta("GET /blog/{slug}", routes.Meta{Name: "blog.post", TitleFunc: postTitle})
+//
+// Like invalid routes, metadata for a pattern that isn't registered is reported by Err.
+func (ws *WebServer) SetRouteMeta(pattern string, meta routes.Meta) {
+	if !slices.ContainsFunc(ws.routeInfos, func(ri RouteInfo) bool { return ri.Pattern == pattern }) {
+		ws.registrationFailed(fmt.Errorf("%w %q registered at %s: SetRouteMeta needs a registered route",
+			ErrInvalidRoute, pattern, callerSource()))
+		return
+	}
+	if err := ws.routes.Set(pattern, meta); err != nil {
+		ws.registrationFailed(fmt.Errorf("%w %q registered at %s: %w", ErrInvalidRoute, pattern, callerSource(), err))
+	}
+}
+
+// Handler returns the root http.Handler of the WebServer with the global middlewares applied.
+// Panics in the registered handlers are recovered and answered with the configured ErrorPage,
+// within the global middlewares so that they still log the request. It's what Start serves and
+// can be used directly with net/http/httptest.
+// The request path and the route metadata are added to the context for the active links of
+// [nav] and the breadcrumbs.
+func (ws *WebServer) Handler() http.Handler {
+	var finalHandler http.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := r.WithContext(routes.ContextWithRegistry(r.Context(), ws.routes))
+		if ws.admin != nil {
+			defer func() { admin.SetRoute(req.Context(), req.Pattern) }()
+		}
+		ws.mux.ServeHTTP(w, req)
+	})
+	recoverCfg := middlewares.RecoverConfig{ErrorPage: ws.errorPage, Logger: ws.logger}
+	if ws.devLogs != nil {
+		recoverCfg.DebugPage = func(r *http.Request, p any, stack []byte) templ.Component {
+			return deverror.Page(deverror.ForPanic(r, p, stack, ws.devLogs.Lines()))
+		}
+	}
+	finalHandler = middlewares.Recover(recoverCfg)(nav.Middleware(finalHandler))
+	if ws.locales != nil {
+		finalHandler = ws.locales.Middleware(finalHandler)
+	}
+	if ws.reloader != nil {
+		finalHandler = livereload.Middleware(livereload.DefaultPath)(finalHandler)
+	}
 	// Apply in reverse
 	for i := len(ws.config.GlobalMiddlewares) - 1; i >= 0; i-- {
 		finalHandler = ws.config.GlobalMiddlewares[i](finalHandler)
 	}
-	ws.httpServer.Handler = finalHandler
+	if ws.admin != nil {
+		finalHandler = ws.admin.Middleware(finalHandler)
+	}
+	return finalHandler
+}
+
+// serveError answers a failed page with a 500: the developer error page in DevMode, otherwise
+// the ErrorPage or a plain error.
+func (ws *WebServer) serveError(w http.ResponseWriter, r *http.Request, err error) {
+	page := ws.errorPage
+	if ws.devLogs != nil {
+		stack := debug.Stack()
+		page = func(r *http.Request) templ.Component {
+			return deverror.Page(deverror.ForError(r, err, stack, ws.devLogs.Lines()))
+		}
+	}
+	middlewares.WriteErrorPage(w, r, http.StatusInternalServerError, page)
+}
+
+// errorPage renders the configured ErrorPage within the base layout, or returns nil.
+func (ws *WebServer) errorPage(r *http.Request) templ.Component {
+	if ws.config.ErrorPage == nil {
+		return nil
+	}
+	headVM, content, err := ws.config.ErrorPage(r)
+	if err != nil {
+		ws.logger.ErrorContext(r.Context(), "error page content provider failed", ws.requestAttrs(r, "", err)...)
+		return nil
+	}
+	return layout.BasicLayout(headVM, content)
+}
 
-	fmt.Printf("WebServer starting on %s\n", ws.httpServer.Addr)
+// Start initializes and runs the HTTP server.
+// Cancelling the context will stop the server. Start fails without listening when a route
+// registration failed, see [WebServer.Err].
+func (ws *WebServer) Start(ctx context.Context) error {
+	if err := ws.Err(); err != nil {
+		return fmt.Errorf("failed to register the routes. err %w", err)
+	}
+	ws.httpServer.Handler = ws.Handler()
+	if ws.reloader != nil && len(ws.config.DevWatch) > 0 {
+		go devwatch.Run(ctx, devwatch.Config{
+			Dirs:     ws.config.DevWatch,
+			Tasks:    ws.config.DevTasks,
+			OnChange: ws.reloadOnChange,
+		})
+	}
 
-	errChan := make(chan error, 1)
+	if ws.config.HTTPAddr != "" && !ws.useTLS() {
+		return errors.New("HTTPAddr redirects to HTTPS, but TLS isn't configured")
+	}
+	addr := ws.httpServer.Addr
+	if addr == "" {
+		addr = ":http"
+		if ws.useTLS() {
+			addr = ":https"
+		}
+	}
+	ws.logger.Info("web server starting", slog.String("addr", addr), slog.Bool("tls", ws.useTLS()))
+	if ws.config.LogRoutes {
+		ws.PrintRoutes(os.Stdout)
+	}
+
+	ln, err := ws.listen(addr, ListenFDEnv, 0)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s. err %w", addr, err)
+	}
+	lns, envs := []net.Listener{ln}, []string{ListenFDEnv}
+	if ws.config.HTTPAddr != "" {
+		httpLn, err := ws.listen(ws.config.HTTPAddr, HTTPListenFDEnv, 1)
+		if err != nil {
+			ln.Close()
+			return fmt.Errorf("failed to listen on %s. err %w", ws.config.HTTPAddr, err)
+		}
+		ws.redirectServer = ws.newRedirectServer(ln.Addr())
+		lns, envs = append(lns, httpLn), append(envs, HTTPListenFDEnv)
+		ws.logger.Info("http listener starting", slog.String("addr", ws.config.HTTPAddr))
+	}
+	if ws.config.Scheduler != nil {
+		if err := ws.config.Scheduler.Start(ctx); err != nil {
+			for _, l := range lns {
+				l.Close()
+			}
+			return fmt.Errorf("failed to start the scheduler. err %w", err)
+		}
+	}
+
+	errChan := make(chan error, len(lns))
 	go func() {
-		if err := ws.httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			errChan <- fmt.Errorf("ListenAndServe failed: %w", err)
+		var err error
+		if ws.useTLS() {
+			err = ws.httpServer.ServeTLS(ln, ws.config.TLSCertFile, ws.config.TLSKeyFile)
+		} else {
+			err = ws.httpServer.Serve(ln)
 		}
-		close(errChan)
+		if err != nil && err != http.ErrServerClosed {
+			err = fmt.Errorf("ListenAndServe failed: %w", err)
+		}
+		errChan <- err
 	}()
+	if ws.redirectServer != nil {
+		go func() {
+			err := ws.redirectServer.Serve(lns[1])
+			if err != nil && err != http.ErrServerClosed {
+				err = fmt.Errorf("http listener failed: %w", err)
+			}
+			errChan <- err
+		}()
+	}
 
-	// Wait for an error or a shutdown signal
-	select {
-	case err := <-errChan:
+	var hup chan os.Signal
+	if ws.config.RestartOnSIGHUP {
+		hup = make(chan os.Signal, 1)
+		signal.Notify(hup, syscall.SIGHUP)
+		defer signal.Stop(hup)
+	}
+	shutdown := ws.gracefulShutdownContext(ctx).Done()
+
+	// Wait for an error, a shutdown signal or a restart. The listeners stop together.
+	for {
+		select {
+		case err := <-errChan:
+			if err != nil && err != http.ErrServerClosed {
+				return errors.Join(err, ws.shutdown())
+			}
+		case <-hup:
+			p, err := startSuccessor(lns, envs)
+			if err != nil {
+				ws.logger.Error("web server restart failed", slog.Any("error", err))
+				continue
+			}
+			ws.logger.Info("web server restarting", slog.Int("pid", p.Pid))
+			p.Release()
+		case <-shutdown:
+		}
+		return ws.shutdown()
+	}
+}
+
+// shutdown ends the streams of CloseOnShutdown, stops the server and the HTTP listener once the
+// requests in flight are served, then the scheduled tasks once the running ones return, all
+// within 15 seconds.
+func (ws *WebServer) shutdown() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	ws.logger.Info("web server shutting down")
+	// Shutdown waits for the requests in flight, which long-lived streams never finish.
+	ws.stopStreams()
+	servers := []*http.Server{ws.httpServer}
+	if ws.redirectServer != nil {
+		servers = append(servers, ws.redirectServer)
+	}
+	var errs []error
+	for _, s := range servers {
+		if err := s.Shutdown(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("server shutdown failed: %w", err))
+		}
+	}
+	// The tasks are stopped even when a server didn't drain in time.
+	if ws.config.Scheduler != nil {
+		if err := ws.config.Scheduler.Stop(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("scheduler shutdown failed: %w", err))
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
 		return err
-	case <-ws.gracefulShutdownContext(ctx).Done():
-		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	}
+	ws.logger.Info("web server gracefully stopped")
+	return nil
+}
+
+// CloseOnShutdown cancels the request context of handler when the server starts shutting down,
+// for the long-lived responses the graceful shutdown would otherwise wait for: Server-Sent
+// Events, WebSockets and long polls. ServeSSE and websocket.Serve use it.
+//
+//	ws.Handle("GET /poll/scores", ws.CloseOnShutdown(longpoll.Handler(scores, longpoll.Config{}, write)))
+func (ws *WebServer) CloseOnShutdown(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithCancel(r.Context())
 		defer cancel()
-		if err := ws.httpServer.Shutdown(ctx); err != nil {
-			return fmt.Errorf("server shutdown failed: %w", err)
+		stop := context.AfterFunc(ws.streams, cancel)
+		defer stop()
+		handler.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// requestAttrs returns the log attributes of an error serving r with the route pattern.
+func (ws *WebServer) requestAttrs(r *http.Request, pattern string, err error) []any {
+	attrs := []any{
+		slog.String("method", r.Method),
+		slog.String("path", r.URL.Path),
+		slog.String("request_id", middlewares.GetRequestID(r.Context())),
+		slog.Any("error", err),
+	}
+	if pattern != "" {
+		attrs = append(attrs, slog.String("pattern", pattern))
+	}
+	return attrs
+}
+
+// reloadOnChange reloads the browsers in DevMode, unless only Go code changed: the Go and templ
+// sources need a rebuild, and the restarted server reloads the browsers.
+func (ws *WebServer) reloadOnChange(changed []string) {
+	for _, f := range changed {
+		if ext := filepath.Ext(f); ext != ".go" && ext != ".templ" {
+			ws.reloader.Reload()
+			return
 		}
-		fmt.Println("WebServer gracefully stopped")
-		return nil
 	}
 }
 
@@ -165,6 +580,9 @@ func (ws *WebServer) gracefulShutdownContext(ctx context.Context) context.Contex
 	return cancellableCtx
 }
 
+// devLogLines is the number of log lines shown by the developer error pages.
+const devLogLines = 50
+
 func defaultServer() *http.Server {
 	return &http.Server{
 		Addr:              ":8080",
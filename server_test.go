@@ -0,0 +1,442 @@
+package gotth_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/a-h/templ"
+	"github.com/ancalabrese/gotth"
+	"github.com/ancalabrese/gotth/admin"
+	"github.com/ancalabrese/gotth/middlewares"
+	"github.com/ancalabrese/gotth/nav"
+	"github.com/ancalabrese/gotth/routes"
+	"github.com/ancalabrese/gotth/views/components/breadcrumbs"
+	"github.com/ancalabrese/gotth/views/components/head"
+)
+
+func TestWebServer_ErrorPageOnPanic(t *testing.T) {
+	ws, err := gotth.New(gotth.WebServerConfig{
+		ErrorPage: func(r *http.Request) (head.HeadViewModel, templ.Component, error) {
+			return head.NewHeadViewModel(head.WithPageCoreMetadata("Error", "", "")),
+				templ.ComponentFunc(func(ctx context.Context, w io.Writer) error {
+					_, err := io.WriteString(w, "<p>Sorry!</p>")
+					return err
+				}), nil
+		},
+	}, nil)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	ws.Handle("GET /panic", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { panic("boom") }))
+
+	rr := httptest.NewRecorder()
+	ws.Handler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/panic", nil))
+
+	if rr.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want %d", rr.Code, http.StatusInternalServerError)
+	}
+	body := rr.Body.String()
+	if !strings.Contains(body, "<title>Error</title>") || !strings.Contains(body, "<p>Sorry!</p>") {
+		t.Errorf("error page not rendered in the layout: %s", body)
+	}
+}
+
+func TestWebServer_ServeContentMiddlewares(t *testing.T) {
+	ws, err := gotth.New(gotth.WebServerConfig{}, nil)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	var order []string
+	mw := func(name string) func(http.Handler) http.Handler {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				order = append(order, name)
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+	ws.ServeContent("GET /page", func(r *http.Request) (head.HeadViewModel, templ.Component, error) {
+		order = append(order, "provider")
+		return head.NewHeadViewModel(), templ.NopComponent, nil
+	}, mw("first"), mw("second"))
+
+	rr := httptest.NewRecorder()
+	ws.Handler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/page", nil))
+
+	if rr.Code != http.StatusOK || strings.Join(order, ",") != "first,second,provider" {
+		t.Errorf("status = %d, order = %v", rr.Code, order)
+	}
+}
+
+func TestWebServer_Pprof(t *testing.T) {
+	tests := []struct {
+		name       string
+		cfg        gotth.WebServerConfig
+		setAuth    bool
+		wantStatus int
+	}{
+		{name: "Disabled", cfg: gotth.WebServerConfig{}, wantStatus: http.StatusNotFound},
+		{
+			name:       "Guarded, no credentials",
+			cfg:        gotth.WebServerConfig{EnablePprof: true, PprofGuard: middlewares.BasicAuth("pprof", middlewares.StaticCredentials(map[string]string{"ops": "pw"}))},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "Guarded, valid credentials",
+			cfg:        gotth.WebServerConfig{EnablePprof: true, PprofGuard: middlewares.BasicAuth("pprof", middlewares.StaticCredentials(map[string]string{"ops": "pw"}))},
+			setAuth:    true,
+			wantStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ws, err := gotth.New(tt.cfg, nil)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			req := httptest.NewRequest(http.MethodGet, "/debug/pprof/", nil)
+			if tt.setAuth {
+				req.SetBasicAuth("ops", "pw")
+			}
+			rr := httptest.NewRecorder()
+			ws.Handler().ServeHTTP(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rr.Code, tt.wantStatus)
+			}
+			if tt.wantStatus == http.StatusOK && !strings.Contains(rr.Body.String(), "goroutine") {
+				t.Errorf("unexpected pprof index: %s", rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestWebServer_Admin(t *testing.T) {
+	if _, err := gotth.New(gotth.WebServerConfig{Admin: &admin.Config{}}, nil); !errors.Is(err, admin.ErrNoGuard) {
+		t.Fatalf("New() without guard error = %v, want %v", err, admin.ErrNoGuard)
+	}
+
+	guard := middlewares.BasicAuth("admin", middlewares.StaticCredentials(map[string]string{"ops": "pw"}))
+	ws, err := gotth.New(gotth.WebServerConfig{Admin: &admin.Config{Guard: guard}}, nil)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	ws.Handle("GET /posts/{id}", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	handler := ws.Handler()
+	for _, path := range []string{"/posts/1", "/posts/2", "/missing"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, admin.DefaultPath, nil))
+	if rr.Code != http.StatusUnauthorized {
+		t.Errorf("status without credentials = %d, want %d", rr.Code, http.StatusUnauthorized)
+	}
+
+	var stats *admin.RouteStats
+	for _, s := range ws.Admin().Overview().Routes {
+		if s.Route == "GET /posts/{id}" {
+			stats = &s
+		}
+	}
+	if stats == nil || stats.Count != 2 {
+		t.Errorf("stats of GET /posts/{id} = %+v, want 2 requests", stats)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, admin.DefaultPath, nil)
+	req.SetBasicAuth("ops", "pw")
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), "GET /posts/{id}") {
+		t.Errorf("dashboard status = %d, want %d listing the routes: %s", rr.Code, http.StatusOK, rr.Body.String())
+	}
+}
+
+func TestWebServer_ServeContentHTMXFragments(t *testing.T) {
+	provider := func(r *http.Request) (head.HeadViewModel, templ.Component, error) {
+		return head.NewHeadViewModel(head.WithPageCoreMetadata("Inbox", "Your messages", "/inbox")),
+			templ.ComponentFunc(func(ctx context.Context, w io.Writer) error {
+				_, err := io.WriteString(w, "<ul id=\"messages\"></ul>")
+				return err
+			}), nil
+	}
+	titleLayout := func(headVM head.HeadViewModel, content templ.Component) templ.Component {
+		return templ.ComponentFunc(func(ctx context.Context, w io.Writer) error {
+			io.WriteString(w, "<title>"+headVM.Metadata.Title+"</title>")
+			return content.Render(ctx, w)
+		})
+	}
+
+	tests := []struct {
+		name     string
+		cfg      gotth.WebServerConfig
+		headers  map[string]string
+		expected string
+		contains []string
+		fullPage bool
+	}{
+		{name: "Full page", headers: nil, fullPage: true},
+		{name: "HTMX fragment", headers: map[string]string{"HX-Request": "true"}, expected: "<ul id=\"messages\"></ul>"},
+		{
+			name:    "Boosted navigation",
+			headers: map[string]string{"HX-Request": "true", "HX-Boosted": "true"},
+			contains: []string{
+				"<title>Inbox</title>",
+				`<meta name="description" content="Your messages" hx-swap-oob="outerHTML:meta[name=&#39;description&#39;]">`,
+				`<link rel="canonical" href="/inbox" hx-swap-oob="outerHTML:link[rel=&#39;canonical&#39;]">`,
+				`<meta property="og:title" content="Inbox" hx-swap-oob="outerHTML:meta[property=&#39;og:title&#39;]">`,
+				"<ul id=\"messages\"></ul>",
+			},
+		},
+		{name: "History restore", headers: map[string]string{"HX-Request": "true", "HX-History-Restore-Request": "true"}, fullPage: true},
+		{
+			name:     "Custom fragment layout",
+			cfg:      gotth.WebServerConfig{FragmentLayout: titleLayout},
+			headers:  map[string]string{"HX-Request": "true"},
+			expected: "<title>Inbox</title><ul id=\"messages\"></ul>",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ws, err := gotth.New(tt.cfg, nil)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			ws.ServeContent("GET /inbox", provider)
+
+			req := httptest.NewRequest(http.MethodGet, "/inbox", nil)
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			rr := httptest.NewRecorder()
+			ws.Handler().ServeHTTP(rr, req)
+
+			body := rr.Body.String()
+			switch {
+			case len(tt.contains) > 0:
+				if strings.Contains(body, "<!doctype html>") {
+					t.Errorf("expected the body content only, got %s", body)
+				}
+				for _, c := range tt.contains {
+					if !strings.Contains(body, c) {
+						t.Errorf("body does not contain %s\nbody: %s", c, body)
+					}
+				}
+			case tt.fullPage:
+				if !strings.Contains(body, "<!doctype html>") || !strings.Contains(body, "<ul id=\"messages\"></ul>") {
+					t.Errorf("expected the full page, got %s", body)
+				}
+			case body != tt.expected:
+				t.Errorf("body = %q, want %q", body, tt.expected)
+			}
+			if vary := strings.Join(rr.Header().Values("Vary"), ", "); vary != "HX-Request, HX-Boosted" {
+				t.Errorf("Vary = %q, want %q", vary, "HX-Request, HX-Boosted")
+			}
+		})
+	}
+}
+
+func TestWebServer_NavPath(t *testing.T) {
+	ws, err := gotth.New(gotth.WebServerConfig{}, nil)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	ws.ServeContent("GET /blog/{slug}", func(r *http.Request) (head.HeadViewModel, templ.Component, error) {
+		return head.NewHeadViewModel(), templ.ComponentFunc(func(ctx context.Context, w io.Writer) error {
+			_, err := io.WriteString(w, nav.Class(ctx, "/blog", nav.Prefix, "active", "inactive"))
+			return err
+		}), nil
+	})
+
+	rr := httptest.NewRecorder()
+	ws.Handler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/blog/first-post", nil))
+
+	if !strings.Contains(rr.Body.String(), "active") || strings.Contains(rr.Body.String(), "inactive") {
+		t.Errorf("blog link not active: %s", rr.Body.String())
+	}
+}
+
+func TestWebServer_Breadcrumbs(t *testing.T) {
+	ws, err := gotth.New(gotth.WebServerConfig{}, nil)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	page := func(title string) gotth.ContentProviderFunc {
+		return func(r *http.Request) (head.HeadViewModel, templ.Component, error) {
+			return head.NewHeadViewModel(head.WithPageCoreMetadata(title, "", "https://example.com"+r.URL.Path)), breadcrumbs.Breadcrumbs(), nil
+		}
+	}
+	ws.ServeContent("GET /{$}", page("Home"))
+	ws.SetRouteMeta("GET /{$}", routes.Meta{Name: "home", Title: "Home"})
+	ws.ServeContent("GET /blog/{slug}", page("Post"))
+	ws.SetRouteMeta("GET /blog/{slug}", routes.Meta{Name: "blog.post", TitleFunc: func(r *http.Request) string { return "Post " + r.PathValue("slug") }})
+
+	tests := []struct {
+		name     string
+		target   string
+		want     []string
+		dontWant []string
+	}{
+		{
+			name:   "Nested page",
+			target: "/blog/first",
+			want: []string{
+				`"@type":"BreadcrumbList"`,
+				`{"@type":"ListItem","item":"https://example.com/","name":"Home","position":1}`,
+				`{"@type":"ListItem","item":"https://example.com/blog/first","name":"Post first","position":2}`,
+				`<nav aria-label="Breadcrumb"`,
+				`<a href="/" class="hover:underline">Home</a>`,
+				`<span aria-current="page" class="font-medium">Post first</span>`,
+			},
+		},
+		{name: "Single page trail", target: "/", dontWant: []string{"BreadcrumbList", "Breadcrumb"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			ws.Handler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, tt.target, nil))
+			body := rr.Body.String()
+			for _, w := range tt.want {
+				if !strings.Contains(body, w) {
+					t.Errorf("body does not contain %s\nbody: %s", w, body)
+				}
+			}
+			for _, d := range tt.dontWant {
+				if strings.Contains(body, d) {
+					t.Errorf("body unexpectedly contains %s", d)
+				}
+			}
+		})
+	}
+}
+
+func TestWebServer_DevMode(t *testing.T) {
+	for _, devMode := range []bool{false, true} {
+		ws, err := gotth.New(gotth.WebServerConfig{DevMode: devMode}, nil)
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+		ws.ServeContent("GET /{$}", func(r *http.Request) (head.HeadViewModel, templ.Component, error) {
+			return head.NewHeadViewModel(), templ.NopComponent, nil
+		})
+
+		rr := httptest.NewRecorder()
+		ws.Handler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+		if got := strings.Contains(rr.Body.String(), `new EventSource("/_gotth/livereload")`); got != devMode {
+			t.Errorf("DevMode = %v: reload script rendered = %v", devMode, got)
+		}
+
+		rr = httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodHead, "/_gotth/livereload", nil)
+		ctx, cancel := context.WithCancel(req.Context())
+		cancel() // End the stream right away.
+		ws.Handler().ServeHTTP(rr, req.WithContext(ctx))
+		if got := rr.Header().Get("Content-Type") == "text/event-stream"; got != devMode {
+			t.Errorf("DevMode = %v: reload endpoint served = %v", devMode, got)
+		}
+	}
+}
+
+func TestWebServer_ErrorPages(t *testing.T) {
+	failing := func(r *http.Request) (head.HeadViewModel, templ.Component, error) {
+		return head.HeadViewModel{}, nil, fmt.Errorf("failed to load post. err %w", fs.ErrNotExist)
+	}
+	tests := []struct {
+		name     string
+		devMode  bool
+		path     string
+		contains []string
+		excludes []string
+	}{
+		{name: "Provider error in production", path: "/post", contains: []string{"Internal Server Error"}, excludes: []string{"fs.ErrNotExist", "server_test.go"}},
+		{name: "Panic in production", path: "/panic", contains: []string{"Internal Server Error"}, excludes: []string{"boom"}},
+		{
+			name:     "Provider error in dev mode",
+			devMode:  true,
+			path:     "/post",
+			contains: []string{"<h1>failed to load post. err file does not exist</h1>", "*fmt.wrapError", "<h2>Stack trace</h2>", "ERROR content provider failed method=GET path=/post"},
+		},
+		{name: "Panic in dev mode", devMode: true, path: "/panic", contains: []string{"<h1>boom</h1>", "server_test.go", "<th>URL</th><td>/panic</td>"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+			ws, err := gotth.New(gotth.WebServerConfig{DevMode: tt.devMode, Logger: logger}, nil)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			ws.ServeContent("GET /post", failing)
+			ws.Handle("GET /panic", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { panic("boom") }))
+
+			rr := httptest.NewRecorder()
+			ws.Handler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if rr.Code != http.StatusInternalServerError {
+				t.Errorf("status = %d, want %d", rr.Code, http.StatusInternalServerError)
+			}
+			body := rr.Body.String()
+			for _, want := range tt.contains {
+				if !strings.Contains(body, want) {
+					t.Errorf("body doesn't contain %q:\n%s", want, body)
+				}
+			}
+			for _, unwanted := range tt.excludes {
+				if strings.Contains(body, unwanted) {
+					t.Errorf("body contains %q:\n%s", unwanted, body)
+				}
+			}
+		})
+	}
+}
+
+func TestWebServer_Logger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	ws, err := gotth.New(gotth.WebServerConfig{Logger: logger}, nil)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	ws.ServeContent("GET /broken", func(r *http.Request) (head.HeadViewModel, templ.Component, error) {
+		return head.HeadViewModel{}, nil, errors.New("no content")
+	})
+	ws.Handle("", nil)
+
+	ws.Handler().ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/broken", nil))
+
+	logs := buf.String()
+	for _, want := range []string{
+		`level=DEBUG msg="route registered" pattern="GET /broken" kind=page`,
+		`level=ERROR msg="route registration failed" error="invalid route \"\" registered at `,
+		`level=ERROR msg="content provider failed" method=GET path=/broken request_id="" error="no content" pattern="GET /broken"`,
+	} {
+		if !strings.Contains(logs, want) {
+			t.Errorf("logs missing %q:\n%s", want, logs)
+		}
+	}
+}
+
+func TestWebServer_BuildInfo(t *testing.T) {
+	ws, err := gotth.New(gotth.WebServerConfig{BuildInfoPath: "/version"}, nil)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	rr := httptest.NewRecorder()
+	ws.Handler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/version", nil))
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"go_version"`) {
+		t.Errorf("GET /version = %d %s, want the build info", rr.Code, rr.Body.String())
+	}
+}
@@ -0,0 +1,156 @@
+// Package cookie provides a stateless middlewares.SessionStore that keeps the session user in
+// the session cookie itself, encrypted and authenticated with AES-GCM. No server-side storage
+// is needed, which suits simple sites.
+//
+// The "session ID" returned by CreateSession is the encrypted token: write it with
+// middlewares.SetSession as usual. Being stateless, sessions can't be revoked before they expire
+// and can't be extended in place: InvalidateSession only lets the middleware expire the cookie
+// and RefreshSession returns an error wrapping errors.ErrUnsupported. Issue a new token with
+// CreateSession to extend a session.
+package cookie
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ancalabrese/gotth/sessionstore"
+)
+
+const (
+	DefaultTTL = 24 * time.Hour
+	// MaxTokenSize keeps the cookie within the 4096 bytes browsers are guaranteed to store,
+	// leaving room for the cookie name and attributes.
+	MaxTokenSize = 3800
+)
+
+var (
+	// ErrInvalidToken is returned when a token can't be decrypted with any of the keys.
+	ErrInvalidToken = errors.New("invalid session token")
+	// ErrTokenTooLarge is returned when the encrypted user doesn't fit in a cookie.
+	ErrTokenTooLarge = errors.New("session token too large for a cookie")
+)
+
+type payload[T any] struct {
+	User      T     `json:"u"`
+	ExpiresAt int64 `json:"e"`
+}
+
+// Store is a stateless encrypted-cookie session store for users of type T.
+// Instantiate via New and functional options.
+type Store[T any] struct {
+	aeads []cipher.AEAD
+	ttl   time.Duration
+	now   func() time.Time
+}
+
+// Option defines a function that configures the Store.
+type Option func(*options)
+
+type options struct {
+	ttl time.Duration
+}
+
+// WithTTL sets how long a token is valid after it's created.
+func WithTTL(ttl time.Duration) Option {
+	return func(o *options) {
+		if ttl > 0 {
+			o.ttl = ttl
+		}
+	}
+}
+
+// New creates a new Store. keys must be 16, 24 or 32 bytes long (AES-128, AES-192 or AES-256).
+// New tokens are encrypted with the first key, while all the keys are tried for decryption:
+// to rotate keys, prepend the new key and drop the old one once the tokens it encrypted have
+// expired.
+func New[T any](keys [][]byte, opts ...Option) (*Store[T], error) {
+	if len(keys) == 0 {
+		return nil, errors.New("at least one key is required")
+	}
+
+	o := options{ttl: DefaultTTL}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	s := &Store[T]{ttl: o.ttl, now: time.Now}
+	for i, key := range keys {
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, fmt.Errorf("invalid key at index %d. err %w", i, err)
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, fmt.Errorf("invalid key at index %d. err %w", i, err)
+		}
+		s.aeads = append(s.aeads, aead)
+	}
+	return s, nil
+}
+
+// CreateSession encrypts user into a new token to be used as the session cookie value.
+func (s *Store[T]) CreateSession(ctx context.Context, user T) (string, error) {
+	plaintext, err := json.Marshal(payload[T]{User: user, ExpiresAt: s.now().Add(s.ttl).Unix()})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal session user. err %w", err)
+	}
+
+	aead := s.aeads[0]
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	token := base64.RawURLEncoding.EncodeToString(aead.Seal(nonce, nonce, plaintext, nil))
+	if len(token) > MaxTokenSize {
+		return "", ErrTokenTooLarge
+	}
+	return token, nil
+}
+
+// ExchangeSessionIDForUser decrypts the token and returns the user of type T it contains.
+func (s *Store[T]) ExchangeSessionIDForUser(ctx context.Context, sessionID string) (any, error) {
+	data, err := base64.RawURLEncoding.DecodeString(sessionID)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	for _, aead := range s.aeads {
+		if len(data) < aead.NonceSize() {
+			continue
+		}
+		nonce, ciphertext := data[:aead.NonceSize()], data[aead.NonceSize():]
+		plaintext, err := aead.Open(nil, nonce, ciphertext, nil)
+		if err != nil {
+			continue
+		}
+
+		var p payload[T]
+		if err := json.Unmarshal(plaintext, &p); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal session user. err %w", err)
+		}
+		if s.now().Unix() >= p.ExpiresAt {
+			return nil, sessionstore.ErrSessionExpired
+		}
+		return p.User, nil
+	}
+	return nil, ErrInvalidToken
+}
+
+// InvalidateSession is a no-op: the cookie is expired by the middleware, but a copy of the token
+// stays valid until it expires.
+func (s *Store[T]) InvalidateSession(ctx context.Context, user any, sessionID string) error {
+	return nil
+}
+
+// RefreshSession always fails: the expiry is sealed in the token. Create a new token instead.
+func (s *Store[T]) RefreshSession(ctx context.Context, sessionID string, ttl time.Duration) error {
+	return fmt.Errorf("stateless cookie sessions can't be refreshed in place: %w", errors.ErrUnsupported)
+}
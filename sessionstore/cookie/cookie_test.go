@@ -0,0 +1,132 @@
+package cookie
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ancalabrese/gotth/middlewares"
+	"github.com/ancalabrese/gotth/sessionstore"
+)
+
+var _ middlewares.SessionStore = (*Store[user])(nil)
+
+type user struct {
+	ID    string
+	Roles []string
+}
+
+var (
+	oldKey = bytes.Repeat([]byte{1}, 32)
+	newKey = bytes.Repeat([]byte{2}, 32)
+)
+
+func TestStore_RoundTrip(t *testing.T) {
+	ctx := context.Background()
+	s, err := New[user]([][]byte{newKey})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	alice := user{ID: "alice", Roles: []string{"admin"}}
+
+	token, err := s.CreateSession(ctx, alice)
+	if err != nil {
+		t.Fatalf("CreateSession() error = %v", err)
+	}
+	if strings.Contains(token, "alice") {
+		t.Errorf("token leaks the user in clear text: %s", token)
+	}
+
+	got, err := s.ExchangeSessionIDForUser(ctx, token)
+	if err != nil {
+		t.Fatalf("ExchangeSessionIDForUser() error = %v", err)
+	}
+	if u, ok := got.(user); !ok || u.ID != alice.ID || len(u.Roles) != 1 {
+		t.Errorf("ExchangeSessionIDForUser() = %#v, want %#v", got, alice)
+	}
+}
+
+func TestStore_KeyRotation(t *testing.T) {
+	ctx := context.Background()
+	before, _ := New[user]([][]byte{oldKey})
+	after, _ := New[user]([][]byte{newKey, oldKey})
+	retired, _ := New[user]([][]byte{newKey})
+
+	oldToken, _ := before.CreateSession(ctx, user{ID: "bob"})
+	if _, err := after.ExchangeSessionIDForUser(ctx, oldToken); err != nil {
+		t.Errorf("token encrypted with the previous key should still be valid, got %v", err)
+	}
+	if _, err := retired.ExchangeSessionIDForUser(ctx, oldToken); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("token encrypted with a retired key: error = %v, want %v", err, ErrInvalidToken)
+	}
+
+	newToken, _ := after.CreateSession(ctx, user{ID: "bob"})
+	if _, err := retired.ExchangeSessionIDForUser(ctx, newToken); err != nil {
+		t.Errorf("new tokens should be encrypted with the first key, got %v", err)
+	}
+}
+
+func TestStore_InvalidTokens(t *testing.T) {
+	ctx := context.Background()
+	s, _ := New[user]([][]byte{newKey})
+	token, _ := s.CreateSession(ctx, user{ID: "carol"})
+
+	tampered := []byte(token)
+	tampered[len(tampered)/2] ^= 1
+
+	other, _ := New[user]([][]byte{oldKey})
+	otherToken, _ := other.CreateSession(ctx, user{ID: "carol"})
+
+	for name, tok := range map[string]string{
+		"Tampered":    string(tampered),
+		"Not base64":  "!!!",
+		"Too short":   "AAAA",
+		"Empty":       "",
+		"Other store": otherToken,
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := s.ExchangeSessionIDForUser(ctx, tok); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestStore_Expiry(t *testing.T) {
+	ctx := context.Background()
+	s, _ := New[user]([][]byte{newKey}, WithTTL(time.Hour))
+	now := time.Now()
+	s.now = func() time.Time { return now }
+
+	token, _ := s.CreateSession(ctx, user{ID: "dave"})
+
+	now = now.Add(59 * time.Minute)
+	if _, err := s.ExchangeSessionIDForUser(ctx, token); err != nil {
+		t.Errorf("ExchangeSessionIDForUser() before expiry error = %v", err)
+	}
+	now = now.Add(time.Minute)
+	if _, err := s.ExchangeSessionIDForUser(ctx, token); !errors.Is(err, sessionstore.ErrSessionExpired) {
+		t.Errorf("ExchangeSessionIDForUser() after expiry error = %v, want %v", err, sessionstore.ErrSessionExpired)
+	}
+	if err := s.RefreshSession(ctx, token, time.Hour); !errors.Is(err, errors.ErrUnsupported) {
+		t.Errorf("RefreshSession() error = %v, want %v", err, errors.ErrUnsupported)
+	}
+}
+
+func TestStore_Limits(t *testing.T) {
+	if _, err := New[user](nil); err == nil {
+		t.Error("New() without keys should fail")
+	}
+	if _, err := New[user]([][]byte{[]byte("short")}); err == nil {
+		t.Error("New() with an invalid key size should fail")
+	}
+
+	s, _ := New[user]([][]byte{newKey})
+	big := user{ID: strings.Repeat("x", MaxTokenSize)}
+	if _, err := s.CreateSession(context.Background(), big); !errors.Is(err, ErrTokenTooLarge) {
+		t.Errorf("CreateSession() error = %v, want %v", err, ErrTokenTooLarge)
+	}
+}
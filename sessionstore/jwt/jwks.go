@@ -0,0 +1,176 @@
+package jwt
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+
+	gojwt "github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	DefaultJWKSRefreshInterval = time.Hour
+	// minJWKSRefetchInterval rate limits the fetches triggered by unknown key IDs.
+	minJWKSRefetchInterval = time.Minute
+)
+
+// JWKSKeySet caches the RSA keys published at a JWKS endpoint. The keys are fetched by one
+// request at a time, outside of the lock: the other requests keep verifying with the cached
+// keys, and only the ones needing an unknown key wait for the fetch.
+type JWKSKeySet struct {
+	url             string
+	client          *http.Client
+	refreshInterval time.Duration
+	now             func() time.Time
+
+	mu          sync.Mutex
+	keys        map[string]*rsa.PublicKey
+	fetchedAt   time.Time     // Of the last successful fetch
+	attemptedAt time.Time     // Of the last fetch, failed or not
+	lastErr     error         // Of the last fetch
+	fetching    chan struct{} // Closed once the fetch in flight ends, nil without one
+}
+
+// JWKSOption defines a function that configures the JWKSKeySet.
+type JWKSOption func(*JWKSKeySet)
+
+// WithHTTPClient sets the client used to fetch the keys.
+func WithHTTPClient(client *http.Client) JWKSOption {
+	return func(ks *JWKSKeySet) { ks.client = client }
+}
+
+// WithRefreshInterval sets how often the keys are re-fetched.
+func WithRefreshInterval(interval time.Duration) JWKSOption {
+	return func(ks *JWKSKeySet) {
+		if interval > 0 {
+			ks.refreshInterval = interval
+		}
+	}
+}
+
+// NewJWKSKeySet creates a key set for the JWKS published at url.
+func NewJWKSKeySet(url string, opts ...JWKSOption) *JWKSKeySet {
+	ks := &JWKSKeySet{
+		url:             url,
+		client:          &http.Client{Timeout: 10 * time.Second},
+		refreshInterval: DefaultJWKSRefreshInterval,
+		now:             time.Now,
+	}
+	for _, opt := range opts {
+		opt(ks)
+	}
+	return ks
+}
+
+// Keyfunc returns the key matching the "kid" header of token.
+func (ks *JWKSKeySet) Keyfunc(token *gojwt.Token) (any, error) {
+	return ks.keyfunc(context.Background(), token)
+}
+
+// keyfunc returns the key matching the "kid" header of token, waiting for the fetch of the keys
+// until ctx is done when the key is unknown.
+func (ks *JWKSKeySet) keyfunc(ctx context.Context, token *gojwt.Token) (any, error) {
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		return nil, errors.New("token has no kid header")
+	}
+
+	waited := false
+	for {
+		ks.mu.Lock()
+		key, known := ks.keys[kid]
+		now := ks.now()
+		stale := !known || now.Sub(ks.fetchedAt) >= ks.refreshInterval
+		// Failed fetches are rate limited too, so that a down endpoint isn't hit by every request.
+		if stale && ks.fetching == nil && (ks.attemptedAt.IsZero() || now.Sub(ks.attemptedAt) >= minJWKSRefetchInterval) {
+			ks.fetching = make(chan struct{})
+			ks.attemptedAt = now
+			go ks.refresh(context.WithoutCancel(ctx), ks.fetching)
+		}
+		fetching, noKeys, lastErr := ks.fetching, ks.keys == nil, ks.lastErr
+		ks.mu.Unlock()
+
+		switch {
+		case known:
+			return key, nil
+		case fetching != nil && !waited:
+			select {
+			case <-fetching:
+				waited = true
+				continue
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		case noKeys && lastErr != nil:
+			return nil, lastErr
+		}
+		return nil, fmt.Errorf("unknown key ID %q", kid)
+	}
+}
+
+// refresh fetches the keys and closes done.
+func (ks *JWKSKeySet) refresh(ctx context.Context, done chan struct{}) {
+	keys, err := ks.fetch(ctx)
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	if err == nil {
+		ks.keys = keys
+		ks.fetchedAt = ks.now()
+	}
+	ks.lastErr = err
+	ks.fetching = nil
+	close(done)
+}
+
+// fetch downloads and parses the keys.
+func (ks *JWKSKeySet) fetch(ctx context.Context) (map[string]*rsa.PublicKey, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ks.url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create JWKS request. err %w", err)
+	}
+	resp, err := ks.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch JWKS. err %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch JWKS: unexpected status %d", resp.StatusCode)
+	}
+
+	var set struct {
+		Keys []struct {
+			Kid string `json:"kid"`
+			Kty string `json:"kty"`
+			Use string `json:"use"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return nil, fmt.Errorf("failed to decode JWKS. err %w", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Kty != "RSA" || (k.Use != "" && k.Use != "sig") {
+			continue
+		}
+		n, errN := base64.RawURLEncoding.DecodeString(k.N)
+		e, errE := base64.RawURLEncoding.DecodeString(k.E)
+		if errN != nil || errE != nil {
+			continue
+		}
+		keys[k.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+	return keys, nil
+}
@@ -0,0 +1,128 @@
+// Package jwt provides a middlewares.SessionStore that verifies a JWT carried by the session
+// cookie, for apps fronted by an external identity provider. Tokens can be verified with a
+// shared secret (HS256), an RSA public key (RS256) or the keys published at a JWKS endpoint.
+//
+// The identity provider owns the token lifecycle: InvalidateSession is a no-op (the middleware
+// still expires the cookie) and RefreshSession returns an error wrapping errors.ErrUnsupported.
+package jwt
+
+import (
+	"context"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"time"
+
+	gojwt "github.com/golang-jwt/jwt/v5"
+)
+
+// ClaimsMapper maps the claims of a verified token to the user object returned by
+// middlewares.GetUser.
+type ClaimsMapper func(claims gojwt.MapClaims) (any, error)
+
+// Store verifies JWT session tokens. Instantiate via NewHS256, NewRS256 or NewJWKS.
+type Store struct {
+	keyfunc keyfunc
+	parser  *gojwt.Parser
+	mapUser ClaimsMapper
+}
+
+// keyfunc is a gojwt.Keyfunc given the context of the request.
+type keyfunc func(ctx context.Context, token *gojwt.Token) (any, error)
+
+// Option defines a function that configures the Store.
+type Option func(*options)
+
+type options struct {
+	parserOpts []gojwt.ParserOption
+	mapUser    ClaimsMapper
+	jwks       []JWKSOption
+}
+
+// WithIssuer requires the "iss" claim to match issuer.
+func WithIssuer(issuer string) Option {
+	return func(o *options) { o.parserOpts = append(o.parserOpts, gojwt.WithIssuer(issuer)) }
+}
+
+// WithAudience requires the "aud" claim to contain audience.
+func WithAudience(audience string) Option {
+	return func(o *options) { o.parserOpts = append(o.parserOpts, gojwt.WithAudience(audience)) }
+}
+
+// WithLeeway allows for clock skew when validating the time based claims.
+func WithLeeway(leeway time.Duration) Option {
+	return func(o *options) { o.parserOpts = append(o.parserOpts, gojwt.WithLeeway(leeway)) }
+}
+
+// WithClaimsMapper sets how claims are turned into the user object.
+// By default the gojwt.MapClaims are returned as is.
+func WithClaimsMapper(mapper ClaimsMapper) Option {
+	return func(o *options) { o.mapUser = mapper }
+}
+
+// WithJWKSOptions configures the JWKS key set used by NewJWKS.
+func WithJWKSOptions(opts ...JWKSOption) Option {
+	return func(o *options) { o.jwks = append(o.jwks, opts...) }
+}
+
+func newStore(method string, keyfunc keyfunc, opts []Option) *Store {
+	o := options{
+		mapUser: func(claims gojwt.MapClaims) (any, error) { return claims, nil },
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	parserOpts := append([]gojwt.ParserOption{
+		gojwt.WithValidMethods([]string{method}),
+		gojwt.WithExpirationRequired(),
+	}, o.parserOpts...)
+
+	return &Store{
+		keyfunc: keyfunc,
+		parser:  gojwt.NewParser(parserOpts...),
+		mapUser: o.mapUser,
+	}
+}
+
+// NewHS256 creates a Store verifying HS256 tokens signed with secret.
+func NewHS256(secret []byte, opts ...Option) *Store {
+	return newStore(gojwt.SigningMethodHS256.Alg(), func(context.Context, *gojwt.Token) (any, error) { return secret, nil }, opts)
+}
+
+// NewRS256 creates a Store verifying RS256 tokens signed by the private key of publicKey.
+func NewRS256(publicKey *rsa.PublicKey, opts ...Option) *Store {
+	return newStore(gojwt.SigningMethodRS256.Alg(), func(context.Context, *gojwt.Token) (any, error) { return publicKey, nil }, opts)
+}
+
+// NewJWKS creates a Store verifying RS256 tokens with the keys published at jwksURL. The key is
+// selected by the "kid" header of the token. Keys are fetched lazily and refreshed periodically
+// or when a token references an unknown key, at most once a minute, failed fetches included.
+func NewJWKS(jwksURL string, opts ...Option) *Store {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	keys := NewJWKSKeySet(jwksURL, o.jwks...)
+	return newStore(gojwt.SigningMethodRS256.Alg(), keys.keyfunc, opts)
+}
+
+// ExchangeSessionIDForUser verifies the token and maps its claims to the user.
+func (s *Store) ExchangeSessionIDForUser(ctx context.Context, sessionID string) (any, error) {
+	claims := gojwt.MapClaims{}
+	kf := func(token *gojwt.Token) (any, error) { return s.keyfunc(ctx, token) }
+	if _, err := s.parser.ParseWithClaims(sessionID, claims, kf); err != nil {
+		return nil, fmt.Errorf("invalid session token. err %w", err)
+	}
+	return s.mapUser(claims)
+}
+
+// InvalidateSession is a no-op: tokens stay valid until they expire.
+func (s *Store) InvalidateSession(ctx context.Context, user any, sessionID string) error {
+	return nil
+}
+
+// RefreshSession always fails: tokens are refreshed by the identity provider.
+func (s *Store) RefreshSession(ctx context.Context, sessionID string, ttl time.Duration) error {
+	return fmt.Errorf("JWT sessions are refreshed by the identity provider: %w", errors.ErrUnsupported)
+}
@@ -0,0 +1,204 @@
+package jwt_test
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ancalabrese/gotth/middlewares"
+	"github.com/ancalabrese/gotth/sessionstore/jwt"
+	gojwt "github.com/golang-jwt/jwt/v5"
+)
+
+var _ middlewares.SessionStore = (*jwt.Store)(nil)
+
+type user struct {
+	ID    string
+	Email string
+}
+
+func mapUser(claims gojwt.MapClaims) (any, error) {
+	sub, _ := claims.GetSubject()
+	email, _ := claims["email"].(string)
+	return user{ID: sub, Email: email}, nil
+}
+
+func sign(t *testing.T, method gojwt.SigningMethod, key any, kid string, claims gojwt.MapClaims) string {
+	t.Helper()
+	token := gojwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	s, err := token.SignedString(key)
+	if err != nil {
+		t.Fatalf("failed to sign token: %v", err)
+	}
+	return s
+}
+
+func validClaims() gojwt.MapClaims {
+	return gojwt.MapClaims{
+		"sub":   "user-1",
+		"email": "user@example.com",
+		"iss":   "https://idp.example.com",
+		"aud":   "gotth",
+		"exp":   time.Now().Add(time.Hour).Unix(),
+	}
+}
+
+func TestHS256(t *testing.T) {
+	secret := []byte("top-secret")
+	s := jwt.NewHS256(secret,
+		jwt.WithIssuer("https://idp.example.com"),
+		jwt.WithAudience("gotth"),
+		jwt.WithClaimsMapper(mapUser),
+	)
+
+	expired := validClaims()
+	expired["exp"] = time.Now().Add(-time.Hour).Unix()
+	wrongIssuer := validClaims()
+	wrongIssuer["iss"] = "https://evil.example.com"
+	noExpiry := validClaims()
+	delete(noExpiry, "exp")
+
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+
+	tests := []struct {
+		name    string
+		token   string
+		want    any
+		wantErr bool
+	}{
+		{name: "Valid", token: sign(t, gojwt.SigningMethodHS256, secret, "", validClaims()), want: user{ID: "user-1", Email: "user@example.com"}},
+		{name: "Wrong secret", token: sign(t, gojwt.SigningMethodHS256, []byte("other"), "", validClaims()), wantErr: true},
+		{name: "Expired", token: sign(t, gojwt.SigningMethodHS256, secret, "", expired), wantErr: true},
+		{name: "Missing expiry", token: sign(t, gojwt.SigningMethodHS256, secret, "", noExpiry), wantErr: true},
+		{name: "Wrong issuer", token: sign(t, gojwt.SigningMethodHS256, secret, "", wrongIssuer), wantErr: true},
+		{name: "Unexpected algorithm", token: sign(t, gojwt.SigningMethodRS256, rsaKey, "", validClaims()), wantErr: true},
+		{name: "Garbage", token: "not-a-jwt", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.ExchangeSessionIDForUser(context.Background(), tt.token)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ExchangeSessionIDForUser() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("ExchangeSessionIDForUser() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+
+	if err := s.RefreshSession(context.Background(), "", time.Hour); !errors.Is(err, errors.ErrUnsupported) {
+		t.Errorf("RefreshSession() error = %v, want %v", err, errors.ErrUnsupported)
+	}
+}
+
+func TestRS256(t *testing.T) {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	other, _ := rsa.GenerateKey(rand.Reader, 2048)
+	s := jwt.NewRS256(&key.PublicKey)
+
+	got, err := s.ExchangeSessionIDForUser(context.Background(), sign(t, gojwt.SigningMethodRS256, key, "", validClaims()))
+	if err != nil {
+		t.Fatalf("ExchangeSessionIDForUser() error = %v", err)
+	}
+	if claims, ok := got.(gojwt.MapClaims); !ok || claims["sub"] != "user-1" {
+		t.Errorf("expected the raw claims by default, got %#v", got)
+	}
+
+	if _, err := s.ExchangeSessionIDForUser(context.Background(), sign(t, gojwt.SigningMethodRS256, other, "", validClaims())); err == nil {
+		t.Error("expected an error for a token signed with another key")
+	}
+}
+
+func jwk(kid string, pub *rsa.PublicKey) map[string]string {
+	return map[string]string{
+		"kid": kid,
+		"kty": "RSA",
+		"use": "sig",
+		"n":   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+		"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+	}
+}
+
+func TestJWKS(t *testing.T) {
+	key1, _ := rsa.GenerateKey(rand.Reader, 2048)
+	key2, _ := rsa.GenerateKey(rand.Reader, 2048)
+
+	var fetches atomic.Int32
+	published := []map[string]string{jwk("key-1", &key1.PublicKey)}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		json.NewEncoder(w).Encode(map[string]any{"keys": published})
+	}))
+	defer srv.Close()
+
+	s := jwt.NewJWKS(srv.URL, jwt.WithClaimsMapper(mapUser))
+	ctx := context.Background()
+
+	for range 3 {
+		if _, err := s.ExchangeSessionIDForUser(ctx, sign(t, gojwt.SigningMethodRS256, key1, "key-1", validClaims())); err != nil {
+			t.Fatalf("ExchangeSessionIDForUser() error = %v", err)
+		}
+	}
+	if n := fetches.Load(); n != 1 {
+		t.Errorf("expected the keys to be cached, fetched %d times", n)
+	}
+
+	// Unknown key IDs don't trigger a fetch storm.
+	published = append(published, jwk("key-2", &key2.PublicKey))
+	if _, err := s.ExchangeSessionIDForUser(ctx, sign(t, gojwt.SigningMethodRS256, key2, "key-2", validClaims())); err == nil {
+		t.Error("expected key-2 to be unknown until the rate limited refetch")
+	}
+	if n := fetches.Load(); n != 1 {
+		t.Errorf("unknown kid refetched too early, fetched %d times", n)
+	}
+
+	if _, err := s.ExchangeSessionIDForUser(ctx, sign(t, gojwt.SigningMethodRS256, key1, "", validClaims())); err == nil {
+		t.Error("expected an error for a token without kid")
+	}
+}
+
+func TestJWKS_Unavailable(t *testing.T) {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	var fetches atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		time.Sleep(20 * time.Millisecond)
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	s := jwt.NewJWKS(srv.URL, jwt.WithClaimsMapper(mapUser))
+	token := sign(t, gojwt.SigningMethodRS256, key, "key-1", validClaims())
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := s.ExchangeSessionIDForUser(context.Background(), token); err == nil {
+				t.Error("ExchangeSessionIDForUser() succeeded without keys")
+			}
+		}()
+	}
+	wg.Wait()
+	// Failed fetches are rate limited too.
+	if _, err := s.ExchangeSessionIDForUser(context.Background(), token); err == nil || !strings.Contains(err.Error(), "unexpected status 503") {
+		t.Errorf("ExchangeSessionIDForUser() error = %v, want the error of the fetch", err)
+	}
+	if n := fetches.Load(); n != 1 {
+		t.Errorf("fetched %d times, want 1", n)
+	}
+}
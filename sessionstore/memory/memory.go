@@ -0,0 +1,197 @@
+// Package memory provides an in-memory, thread-safe middlewares.SessionStore.
+//
+// Sessions are lost when the process exits and aren't shared between instances, which makes
+// the store a good fit for tests, development and small single-instance apps.
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ancalabrese/gotth/sessionstore"
+)
+
+const (
+	DefaultTTL        = 24 * time.Hour
+	DefaultGCInterval = 10 * time.Minute
+)
+
+type session struct {
+	user      any
+	expiresAt time.Time
+}
+
+// Store is an in-memory session store. Instantiate via New and functional options.
+type Store struct {
+	ttl        time.Duration
+	maxEntries int
+	gcInterval time.Duration
+	now        func() time.Time
+
+	mu       sync.RWMutex
+	sessions map[string]session
+
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// Option defines a function that configures the Store.
+type Option func(*Store)
+
+// WithTTL sets how long a session is valid after it's created or refreshed.
+func WithTTL(ttl time.Duration) Option {
+	return func(s *Store) {
+		if ttl > 0 {
+			s.ttl = ttl
+		}
+	}
+}
+
+// WithMaxEntries caps the number of stored sessions. When the cap is reached the session
+// closest to expiring is evicted to make room for a new one. Zero means no limit.
+func WithMaxEntries(n int) Option {
+	return func(s *Store) { s.maxEntries = n }
+}
+
+// WithGCInterval sets how often expired sessions are removed. Zero disables the periodic
+// collection: expired sessions are then only removed when accessed or evicted.
+func WithGCInterval(interval time.Duration) Option {
+	return func(s *Store) { s.gcInterval = interval }
+}
+
+// New creates a new Store and starts the periodic collection of expired sessions.
+// Call Close to stop it.
+func New(opts ...Option) *Store {
+	s := &Store{
+		ttl:        DefaultTTL,
+		gcInterval: DefaultGCInterval,
+		now:        time.Now,
+		sessions:   make(map[string]session),
+		stop:       make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	if s.gcInterval > 0 {
+		go s.gcLoop()
+	}
+	return s
+}
+
+// CreateSession stores user in a new session and returns its ID.
+func (s *Store) CreateSession(ctx context.Context, user any) (string, error) {
+	id, err := sessionstore.NewID()
+	if err != nil {
+		return "", err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.maxEntries > 0 && len(s.sessions) >= s.maxEntries {
+		s.collect()
+		if len(s.sessions) >= s.maxEntries {
+			s.evictOne()
+		}
+	}
+	s.sessions[id] = session{user: user, expiresAt: s.now().Add(s.ttl)}
+	return id, nil
+}
+
+// ExchangeSessionIDForUser returns the user stored in the session.
+func (s *Store) ExchangeSessionIDForUser(ctx context.Context, sessionID string) (any, error) {
+	s.mu.RLock()
+	sess, ok := s.sessions[sessionID]
+	s.mu.RUnlock()
+	if !ok {
+		return nil, sessionstore.ErrSessionNotFound
+	}
+	if !s.now().Before(sess.expiresAt) {
+		s.mu.Lock()
+		delete(s.sessions, sessionID)
+		s.mu.Unlock()
+		return nil, sessionstore.ErrSessionExpired
+	}
+	return sess.user, nil
+}
+
+// InvalidateSession deletes the session.
+func (s *Store) InvalidateSession(ctx context.Context, user any, sessionID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.sessions[sessionID]; !ok {
+		return sessionstore.ErrSessionNotFound
+	}
+	delete(s.sessions, sessionID)
+	return nil
+}
+
+// RefreshSession extends the session validity by ttl from now.
+func (s *Store) RefreshSession(ctx context.Context, sessionID string, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sess, ok := s.sessions[sessionID]
+	if !ok {
+		return sessionstore.ErrSessionNotFound
+	}
+	now := s.now()
+	if !now.Before(sess.expiresAt) {
+		delete(s.sessions, sessionID)
+		return sessionstore.ErrSessionExpired
+	}
+	sess.expiresAt = now.Add(ttl)
+	s.sessions[sessionID] = sess
+	return nil
+}
+
+// Len returns the number of stored sessions, including expired ones not collected yet.
+func (s *Store) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.sessions)
+}
+
+// Close stops the periodic collection of expired sessions.
+func (s *Store) Close() {
+	s.stopOnce.Do(func() { close(s.stop) })
+}
+
+func (s *Store) gcLoop() {
+	ticker := time.NewTicker(s.gcInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.mu.Lock()
+			s.collect()
+			s.mu.Unlock()
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+// collect removes the expired sessions. s.mu must be held.
+func (s *Store) collect() {
+	now := s.now()
+	for id, sess := range s.sessions {
+		if !now.Before(sess.expiresAt) {
+			delete(s.sessions, id)
+		}
+	}
+}
+
+// evictOne removes the session closest to expiring. s.mu must be held.
+func (s *Store) evictOne() {
+	var (
+		victim    string
+		victimExp time.Time
+	)
+	for id, sess := range s.sessions {
+		if victim == "" || sess.expiresAt.Before(victimExp) {
+			victim, victimExp = id, sess.expiresAt
+		}
+	}
+	delete(s.sessions, victim)
+}
@@ -0,0 +1,142 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ancalabrese/gotth/middlewares"
+	"github.com/ancalabrese/gotth/sessionstore"
+)
+
+var _ middlewares.SessionStore = (*Store)(nil)
+
+// fakeClock is a manually advanced clock.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func newTestStore(t *testing.T, opts ...Option) (*Store, *fakeClock) {
+	t.Helper()
+	clock := &fakeClock{now: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+	s := New(append([]Option{WithGCInterval(0)}, opts...)...)
+	s.now = clock.Now
+	t.Cleanup(s.Close)
+	return s, clock
+}
+
+func TestStore_Lifecycle(t *testing.T) {
+	ctx := context.Background()
+	s, _ := newTestStore(t)
+
+	id, err := s.CreateSession(ctx, "alice")
+	if err != nil {
+		t.Fatalf("CreateSession() error = %v", err)
+	}
+
+	user, err := s.ExchangeSessionIDForUser(ctx, id)
+	if err != nil || user != "alice" {
+		t.Fatalf("ExchangeSessionIDForUser() = %v, %v; want alice, nil", user, err)
+	}
+
+	if err := s.InvalidateSession(ctx, user, id); err != nil {
+		t.Fatalf("InvalidateSession() error = %v", err)
+	}
+	if _, err := s.ExchangeSessionIDForUser(ctx, id); !errors.Is(err, sessionstore.ErrSessionNotFound) {
+		t.Errorf("ExchangeSessionIDForUser() after invalidation error = %v, want %v", err, sessionstore.ErrSessionNotFound)
+	}
+	if err := s.InvalidateSession(ctx, user, id); !errors.Is(err, sessionstore.ErrSessionNotFound) {
+		t.Errorf("second InvalidateSession() error = %v, want %v", err, sessionstore.ErrSessionNotFound)
+	}
+}
+
+func TestStore_TTLAndRefresh(t *testing.T) {
+	ctx := context.Background()
+	s, clock := newTestStore(t, WithTTL(time.Hour))
+
+	id, _ := s.CreateSession(ctx, "bob")
+
+	clock.Advance(50 * time.Minute)
+	if err := s.RefreshSession(ctx, id, time.Hour); err != nil {
+		t.Fatalf("RefreshSession() error = %v", err)
+	}
+
+	// Past the original expiry but within the refreshed one.
+	clock.Advance(50 * time.Minute)
+	if _, err := s.ExchangeSessionIDForUser(ctx, id); err != nil {
+		t.Fatalf("ExchangeSessionIDForUser() after refresh error = %v", err)
+	}
+
+	clock.Advance(11 * time.Minute)
+	if _, err := s.ExchangeSessionIDForUser(ctx, id); !errors.Is(err, sessionstore.ErrSessionExpired) {
+		t.Errorf("ExchangeSessionIDForUser() after expiry error = %v, want %v", err, sessionstore.ErrSessionExpired)
+	}
+	if s.Len() != 0 {
+		t.Errorf("expired session was not removed on access, Len() = %d", s.Len())
+	}
+	if err := s.RefreshSession(ctx, id, time.Hour); !errors.Is(err, sessionstore.ErrSessionNotFound) {
+		t.Errorf("RefreshSession() of removed session error = %v, want %v", err, sessionstore.ErrSessionNotFound)
+	}
+}
+
+func TestStore_MaxEntries(t *testing.T) {
+	ctx := context.Background()
+	s, clock := newTestStore(t, WithMaxEntries(2), WithTTL(time.Hour))
+
+	first, _ := s.CreateSession(ctx, "first")
+	clock.Advance(time.Minute)
+	second, _ := s.CreateSession(ctx, "second")
+	clock.Advance(time.Minute)
+	third, _ := s.CreateSession(ctx, "third")
+
+	if s.Len() != 2 {
+		t.Fatalf("Len() = %d, want 2", s.Len())
+	}
+	if _, err := s.ExchangeSessionIDForUser(ctx, first); err == nil {
+		t.Errorf("expected the session closest to expiry to be evicted")
+	}
+	for _, id := range []string{second, third} {
+		if _, err := s.ExchangeSessionIDForUser(ctx, id); err != nil {
+			t.Errorf("ExchangeSessionIDForUser(%q) error = %v", id, err)
+		}
+	}
+
+	// Expired sessions are collected before evicting live ones.
+	clock.Advance(59 * time.Minute) // second has expired
+	fourth, _ := s.CreateSession(ctx, "fourth")
+	for _, id := range []string{third, fourth} {
+		if _, err := s.ExchangeSessionIDForUser(ctx, id); err != nil {
+			t.Errorf("ExchangeSessionIDForUser(%q) error = %v", id, err)
+		}
+	}
+}
+
+func TestStore_PeriodicGC(t *testing.T) {
+	s := New(WithTTL(time.Millisecond), WithGCInterval(5*time.Millisecond))
+	defer s.Close()
+
+	s.CreateSession(context.Background(), "carol")
+
+	deadline := time.Now().Add(time.Second)
+	for s.Len() != 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("expired session was not collected")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
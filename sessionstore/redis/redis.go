@@ -0,0 +1,128 @@
+// Package redis provides a middlewares.SessionStore backed by Redis, so that sessions can be
+// shared by multiple instances of a gotth app.
+//
+// Users are stored as JSON and decoded into the type parameter of the Store, which is what
+// middlewares.GetUser returns.
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ancalabrese/gotth/sessionstore"
+	goredis "github.com/redis/go-redis/v9"
+)
+
+const (
+	DefaultTTL       = 24 * time.Hour
+	DefaultKeyPrefix = "gotth:session:"
+)
+
+// Store is a Redis session store for users of type T.
+// Instantiate via New and functional options.
+type Store[T any] struct {
+	client    goredis.UniversalClient
+	ttl       time.Duration
+	keyPrefix string
+}
+
+// Option defines a function that configures the Store.
+type Option func(*options)
+
+type options struct {
+	ttl       time.Duration
+	keyPrefix string
+}
+
+// WithTTL sets how long a session is valid after it's created or refreshed.
+func WithTTL(ttl time.Duration) Option {
+	return func(o *options) {
+		if ttl > 0 {
+			o.ttl = ttl
+		}
+	}
+}
+
+// WithKeyPrefix sets the prefix of the Redis keys holding the sessions.
+func WithKeyPrefix(prefix string) Option {
+	return func(o *options) { o.keyPrefix = prefix }
+}
+
+// New creates a new Store using client, which can be a single node, sentinel or cluster client.
+func New[T any](client goredis.UniversalClient, opts ...Option) *Store[T] {
+	o := options{ttl: DefaultTTL, keyPrefix: DefaultKeyPrefix}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &Store[T]{client: client, ttl: o.ttl, keyPrefix: o.keyPrefix}
+}
+
+// CreateSession stores user in a new session and returns its ID.
+func (s *Store[T]) CreateSession(ctx context.Context, user T) (string, error) {
+	payload, err := json.Marshal(user)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal session user. err %w", err)
+	}
+
+	id, err := sessionstore.NewID()
+	if err != nil {
+		return "", err
+	}
+
+	ok, err := s.client.SetNX(ctx, s.key(id), payload, s.ttl).Result()
+	if err != nil {
+		return "", fmt.Errorf("failed to store session. err %w", err)
+	}
+	if !ok {
+		return "", errors.New("session ID collision")
+	}
+	return id, nil
+}
+
+// ExchangeSessionIDForUser returns the user of type T stored in the session.
+func (s *Store[T]) ExchangeSessionIDForUser(ctx context.Context, sessionID string) (any, error) {
+	payload, err := s.client.Get(ctx, s.key(sessionID)).Bytes()
+	if errors.Is(err, goredis.Nil) {
+		return nil, sessionstore.ErrSessionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read session. err %w", err)
+	}
+
+	var user T
+	if err := json.Unmarshal(payload, &user); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal session user. err %w", err)
+	}
+	return user, nil
+}
+
+// InvalidateSession deletes the session.
+func (s *Store[T]) InvalidateSession(ctx context.Context, user any, sessionID string) error {
+	n, err := s.client.Del(ctx, s.key(sessionID)).Result()
+	if err != nil {
+		return fmt.Errorf("failed to delete session. err %w", err)
+	}
+	if n == 0 {
+		return sessionstore.ErrSessionNotFound
+	}
+	return nil
+}
+
+// RefreshSession extends the session validity by ttl from now.
+func (s *Store[T]) RefreshSession(ctx context.Context, sessionID string, ttl time.Duration) error {
+	ok, err := s.client.Expire(ctx, s.key(sessionID), ttl).Result()
+	if err != nil {
+		return fmt.Errorf("failed to refresh session. err %w", err)
+	}
+	if !ok {
+		return sessionstore.ErrSessionNotFound
+	}
+	return nil
+}
+
+func (s *Store[T]) key(sessionID string) string {
+	return s.keyPrefix + sessionID
+}
@@ -0,0 +1,104 @@
+package redis_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/ancalabrese/gotth/middlewares"
+	"github.com/ancalabrese/gotth/sessionstore"
+	"github.com/ancalabrese/gotth/sessionstore/redis"
+	goredis "github.com/redis/go-redis/v9"
+)
+
+type user struct {
+	ID    string `json:"id"`
+	Email string `json:"email"`
+}
+
+var _ middlewares.SessionStore = (*redis.Store[user])(nil)
+
+func newTestStore(t *testing.T, opts ...redis.Option) (*redis.Store[user], *miniredis.Miniredis) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	client := goredis.NewClient(&goredis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return redis.New[user](client, opts...), mr
+}
+
+func TestStore_Lifecycle(t *testing.T) {
+	ctx := context.Background()
+	s, mr := newTestStore(t, redis.WithKeyPrefix("test:"))
+	alice := user{ID: "1", Email: "alice@example.com"}
+
+	id, err := s.CreateSession(ctx, alice)
+	if err != nil {
+		t.Fatalf("CreateSession() error = %v", err)
+	}
+
+	stored, err := mr.Get("test:" + id)
+	if err != nil {
+		t.Fatalf("session not stored under the configured prefix: %v", err)
+	}
+	if stored != `{"id":"1","email":"alice@example.com"}` {
+		t.Errorf("stored payload = %s", stored)
+	}
+
+	got, err := s.ExchangeSessionIDForUser(ctx, id)
+	if err != nil {
+		t.Fatalf("ExchangeSessionIDForUser() error = %v", err)
+	}
+	if got != alice {
+		t.Errorf("ExchangeSessionIDForUser() = %#v, want %#v", got, alice)
+	}
+
+	if err := s.InvalidateSession(ctx, got, id); err != nil {
+		t.Fatalf("InvalidateSession() error = %v", err)
+	}
+	if _, err := s.ExchangeSessionIDForUser(ctx, id); !errors.Is(err, sessionstore.ErrSessionNotFound) {
+		t.Errorf("ExchangeSessionIDForUser() after invalidation error = %v, want %v", err, sessionstore.ErrSessionNotFound)
+	}
+	if err := s.InvalidateSession(ctx, got, id); !errors.Is(err, sessionstore.ErrSessionNotFound) {
+		t.Errorf("second InvalidateSession() error = %v, want %v", err, sessionstore.ErrSessionNotFound)
+	}
+}
+
+func TestStore_TTLAndRefresh(t *testing.T) {
+	ctx := context.Background()
+	s, mr := newTestStore(t, redis.WithTTL(time.Hour))
+
+	id, err := s.CreateSession(ctx, user{ID: "2"})
+	if err != nil {
+		t.Fatalf("CreateSession() error = %v", err)
+	}
+	if ttl := mr.TTL(redis.DefaultKeyPrefix + id); ttl != time.Hour {
+		t.Errorf("TTL = %v, want %v", ttl, time.Hour)
+	}
+
+	mr.FastForward(50 * time.Minute)
+	if err := s.RefreshSession(ctx, id, 2*time.Hour); err != nil {
+		t.Fatalf("RefreshSession() error = %v", err)
+	}
+	if ttl := mr.TTL(redis.DefaultKeyPrefix + id); ttl != 2*time.Hour {
+		t.Errorf("TTL after refresh = %v, want %v", ttl, 2*time.Hour)
+	}
+
+	mr.FastForward(2 * time.Hour)
+	if _, err := s.ExchangeSessionIDForUser(ctx, id); !errors.Is(err, sessionstore.ErrSessionNotFound) {
+		t.Errorf("ExchangeSessionIDForUser() after expiry error = %v, want %v", err, sessionstore.ErrSessionNotFound)
+	}
+	if err := s.RefreshSession(ctx, id, time.Hour); !errors.Is(err, sessionstore.ErrSessionNotFound) {
+		t.Errorf("RefreshSession() of expired session error = %v, want %v", err, sessionstore.ErrSessionNotFound)
+	}
+}
+
+func TestStore_CorruptedPayload(t *testing.T) {
+	s, mr := newTestStore(t)
+	mr.Set(redis.DefaultKeyPrefix+"corrupted", "not json")
+
+	if _, err := s.ExchangeSessionIDForUser(context.Background(), "corrupted"); err == nil {
+		t.Error("expected an error for a corrupted payload")
+	}
+}
@@ -0,0 +1,28 @@
+// Package sessionstore contains the building blocks shared by the middlewares.SessionStore
+// implementations in its subpackages.
+package sessionstore
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+)
+
+// idBytes is the amount of random bytes in a session ID (256 bits).
+const idBytes = 32
+
+var (
+	// ErrSessionNotFound is returned when a session ID doesn't match any session.
+	ErrSessionNotFound = errors.New("session not found")
+	// ErrSessionExpired is returned when a session exists but is no longer valid.
+	ErrSessionExpired = errors.New("session expired")
+)
+
+// NewID returns a new random, URL and cookie safe session ID.
+func NewID() (string, error) {
+	b := make([]byte, idBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
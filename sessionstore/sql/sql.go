@@ -0,0 +1,251 @@
+// Package sql provides a middlewares.SessionStore backed by a database/sql database, for apps
+// that want to keep sessions in Postgres, MySQL or SQLite without extra infrastructure.
+//
+// Create the sessions table with Store.CreateTable or with the statement returned by
+// CreateTableSQL in your own migrations.
+package sql
+
+import (
+	"context"
+	dbsql "database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ancalabrese/gotth/sessionstore"
+)
+
+const (
+	DefaultTTL       = 24 * time.Hour
+	DefaultTableName = "gotth_sessions"
+)
+
+// Dialect describes the SQL differences between the supported databases.
+type Dialect struct {
+	Name string
+	// placeholder returns the bind parameter for the n-th (1-based) argument.
+	placeholder func(n int) string
+	// column types
+	idType, dataType, timeType string
+}
+
+var (
+	Postgres = Dialect{
+		Name:        "postgres",
+		placeholder: func(n int) string { return "$" + strconv.Itoa(n) },
+		idType:      "VARCHAR(64)",
+		dataType:    "BYTEA",
+		timeType:    "TIMESTAMPTZ",
+	}
+	MySQL = Dialect{
+		Name:        "mysql",
+		placeholder: func(int) string { return "?" },
+		idType:      "VARCHAR(64)",
+		dataType:    "BLOB",
+		timeType:    "DATETIME(6)",
+	}
+	SQLite = Dialect{
+		Name:        "sqlite",
+		placeholder: func(int) string { return "?" },
+		idType:      "TEXT",
+		dataType:    "BLOB",
+		timeType:    "TIMESTAMP",
+	}
+)
+
+// Codec serializes the session users to and from the database.
+type Codec interface {
+	Encode(user any) ([]byte, error)
+	Decode(data []byte) (any, error)
+}
+
+type jsonCodec[T any] struct{}
+
+func (jsonCodec[T]) Encode(user any) ([]byte, error) {
+	return json.Marshal(user)
+}
+
+func (jsonCodec[T]) Decode(data []byte) (any, error) {
+	var user T
+	err := json.Unmarshal(data, &user)
+	return user, err
+}
+
+// JSONCodec returns a Codec that stores users as JSON and decodes them into values of type T.
+func JSONCodec[T any]() Codec {
+	return jsonCodec[T]{}
+}
+
+var tableNameRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// CreateTableSQL returns the statement creating the sessions table for dialect.
+func CreateTableSQL(dialect Dialect, table string) string {
+	return fmt.Sprintf(
+		"CREATE TABLE IF NOT EXISTS %s (id %s PRIMARY KEY, data %s NOT NULL, expires_at %s NOT NULL)",
+		table, dialect.idType, dialect.dataType, dialect.timeType,
+	)
+}
+
+// Store is a SQL session store. Instantiate via New and functional options.
+type Store struct {
+	db      *dbsql.DB
+	dialect Dialect
+	table   string
+	ttl     time.Duration
+	codec   Codec
+	now     func() time.Time
+}
+
+// Option defines a function that configures the Store.
+type Option func(*Store)
+
+// WithTTL sets how long a session is valid after it's created or refreshed.
+func WithTTL(ttl time.Duration) Option {
+	return func(s *Store) {
+		if ttl > 0 {
+			s.ttl = ttl
+		}
+	}
+}
+
+// WithTableName sets the name of the sessions table.
+func WithTableName(table string) Option {
+	return func(s *Store) { s.table = table }
+}
+
+// WithCodec sets how users are serialized. Defaults to JSONCodec[map[string]any].
+func WithCodec(codec Codec) Option {
+	return func(s *Store) { s.codec = codec }
+}
+
+// New creates a new Store on db.
+func New(db *dbsql.DB, dialect Dialect, opts ...Option) (*Store, error) {
+	s := &Store{
+		db:      db,
+		dialect: dialect,
+		table:   DefaultTableName,
+		ttl:     DefaultTTL,
+		codec:   JSONCodec[map[string]any](),
+		now:     time.Now,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	if db == nil {
+		return nil, errors.New("db is nil")
+	}
+	if dialect.placeholder == nil {
+		return nil, errors.New("unknown SQL dialect")
+	}
+	// The table name is interpolated in the queries, so only allow plain identifiers.
+	if !tableNameRegexp.MatchString(s.table) {
+		return nil, fmt.Errorf("invalid table name %q", s.table)
+	}
+	return s, nil
+}
+
+// CreateTable creates the sessions table if it doesn't exist.
+func (s *Store) CreateTable(ctx context.Context) error {
+	_, err := s.db.ExecContext(ctx, CreateTableSQL(s.dialect, s.table))
+	return err
+}
+
+// CreateSession stores user in a new session and returns its ID.
+func (s *Store) CreateSession(ctx context.Context, user any) (string, error) {
+	data, err := s.codec.Encode(user)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode session user. err %w", err)
+	}
+
+	id, err := sessionstore.NewID()
+	if err != nil {
+		return "", err
+	}
+
+	_, err = s.db.ExecContext(ctx, s.query("INSERT INTO %s (id, data, expires_at) VALUES (?, ?, ?)"),
+		id, data, s.now().UTC().Add(s.ttl))
+	if err != nil {
+		return "", fmt.Errorf("failed to store session. err %w", err)
+	}
+	return id, nil
+}
+
+// ExchangeSessionIDForUser returns the user stored in the session.
+func (s *Store) ExchangeSessionIDForUser(ctx context.Context, sessionID string) (any, error) {
+	var data []byte
+	err := s.db.QueryRowContext(ctx, s.query("SELECT data FROM %s WHERE id = ? AND expires_at > ?"),
+		sessionID, s.now().UTC()).Scan(&data)
+	if errors.Is(err, dbsql.ErrNoRows) {
+		return nil, sessionstore.ErrSessionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read session. err %w", err)
+	}
+
+	user, err := s.codec.Decode(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode session user. err %w", err)
+	}
+	return user, nil
+}
+
+// InvalidateSession deletes the session.
+func (s *Store) InvalidateSession(ctx context.Context, user any, sessionID string) error {
+	return s.execOne(ctx, "failed to delete session",
+		s.query("DELETE FROM %s WHERE id = ?"), sessionID)
+}
+
+// RefreshSession extends the session validity by ttl from now.
+func (s *Store) RefreshSession(ctx context.Context, sessionID string, ttl time.Duration) error {
+	now := s.now().UTC()
+	return s.execOne(ctx, "failed to refresh session",
+		s.query("UPDATE %s SET expires_at = ? WHERE id = ? AND expires_at > ?"), now.Add(ttl), sessionID, now)
+}
+
+// DeleteExpired removes the expired sessions and returns how many were deleted.
+// Run it periodically to keep the table small.
+func (s *Store) DeleteExpired(ctx context.Context) (int64, error) {
+	res, err := s.db.ExecContext(ctx, s.query("DELETE FROM %s WHERE expires_at <= ?"), s.now().UTC())
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired sessions. err %w", err)
+	}
+	return res.RowsAffected()
+}
+
+// execOne runs a statement that must affect exactly one session.
+func (s *Store) execOne(ctx context.Context, errMsg, query string, args ...any) error {
+	res, err := s.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("%s. err %w", errMsg, err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s. err %w", errMsg, err)
+	}
+	if n == 0 {
+		return sessionstore.ErrSessionNotFound
+	}
+	return nil
+}
+
+// query fills in the table name and rewrites the "?" bind parameters for the dialect.
+func (s *Store) query(q string) string {
+	q = fmt.Sprintf(q, s.table)
+
+	var b strings.Builder
+	n := 0
+	for _, r := range q {
+		if r == '?' {
+			n++
+			b.WriteString(s.dialect.placeholder(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,147 @@
+package sql
+
+import (
+	"context"
+	dbsql "database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ancalabrese/gotth/middlewares"
+	"github.com/ancalabrese/gotth/sessionstore"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+var _ middlewares.SessionStore = (*Store)(nil)
+
+type user struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+func newTestStore(t *testing.T, opts ...Option) (*Store, *time.Time) {
+	t.Helper()
+	db, err := dbsql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("failed to open db: %v", err)
+	}
+	db.SetMaxOpenConns(1) // every connection to :memory: is a new database
+	t.Cleanup(func() { db.Close() })
+
+	s, err := New(db, SQLite, append([]Option{WithCodec(JSONCodec[user]())}, opts...)...)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+
+	if err := s.CreateTable(context.Background()); err != nil {
+		t.Fatalf("CreateTable() error = %v", err)
+	}
+	return s, &now
+}
+
+func TestStore_Lifecycle(t *testing.T) {
+	ctx := context.Background()
+	s, _ := newTestStore(t)
+	alice := user{ID: 1, Name: "Alice"}
+
+	id, err := s.CreateSession(ctx, alice)
+	if err != nil {
+		t.Fatalf("CreateSession() error = %v", err)
+	}
+
+	got, err := s.ExchangeSessionIDForUser(ctx, id)
+	if err != nil {
+		t.Fatalf("ExchangeSessionIDForUser() error = %v", err)
+	}
+	if got != alice {
+		t.Errorf("ExchangeSessionIDForUser() = %#v, want %#v", got, alice)
+	}
+
+	if err := s.InvalidateSession(ctx, got, id); err != nil {
+		t.Fatalf("InvalidateSession() error = %v", err)
+	}
+	if _, err := s.ExchangeSessionIDForUser(ctx, id); !errors.Is(err, sessionstore.ErrSessionNotFound) {
+		t.Errorf("ExchangeSessionIDForUser() after invalidation error = %v, want %v", err, sessionstore.ErrSessionNotFound)
+	}
+	if err := s.InvalidateSession(ctx, got, id); !errors.Is(err, sessionstore.ErrSessionNotFound) {
+		t.Errorf("second InvalidateSession() error = %v, want %v", err, sessionstore.ErrSessionNotFound)
+	}
+}
+
+func TestStore_TTLRefreshAndCleanup(t *testing.T) {
+	ctx := context.Background()
+	s, now := newTestStore(t, WithTTL(time.Hour))
+
+	id, _ := s.CreateSession(ctx, user{ID: 2})
+	stale, _ := s.CreateSession(ctx, user{ID: 3})
+
+	*now = now.Add(50 * time.Minute)
+	if err := s.RefreshSession(ctx, id, time.Hour); err != nil {
+		t.Fatalf("RefreshSession() error = %v", err)
+	}
+
+	*now = now.Add(30 * time.Minute)
+	if _, err := s.ExchangeSessionIDForUser(ctx, id); err != nil {
+		t.Errorf("ExchangeSessionIDForUser() of refreshed session error = %v", err)
+	}
+	if _, err := s.ExchangeSessionIDForUser(ctx, stale); !errors.Is(err, sessionstore.ErrSessionNotFound) {
+		t.Errorf("ExchangeSessionIDForUser() of expired session error = %v, want %v", err, sessionstore.ErrSessionNotFound)
+	}
+	if err := s.RefreshSession(ctx, stale, time.Hour); !errors.Is(err, sessionstore.ErrSessionNotFound) {
+		t.Errorf("RefreshSession() of expired session error = %v, want %v", err, sessionstore.ErrSessionNotFound)
+	}
+
+	n, err := s.DeleteExpired(ctx)
+	if err != nil {
+		t.Fatalf("DeleteExpired() error = %v", err)
+	}
+	if n != 1 {
+		t.Errorf("DeleteExpired() = %d, want 1", n)
+	}
+}
+
+func TestNew_Validation(t *testing.T) {
+	db := &dbsql.DB{}
+	tests := []struct {
+		name    string
+		db      *dbsql.DB
+		dialect Dialect
+		opts    []Option
+		wantErr bool
+	}{
+		{name: "Valid", db: db, dialect: Postgres},
+		{name: "Nil db", db: nil, dialect: Postgres, wantErr: true},
+		{name: "Unknown dialect", db: db, dialect: Dialect{}, wantErr: true},
+		{name: "Injected table name", db: db, dialect: MySQL, opts: []Option{WithTableName("sessions; DROP TABLE users")}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := New(tt.db, tt.dialect, tt.opts...); (err != nil) != tt.wantErr {
+				t.Errorf("New() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestStore_QueryPlaceholders(t *testing.T) {
+	tests := []struct {
+		dialect  Dialect
+		expected string
+	}{
+		{dialect: Postgres, expected: "UPDATE sessions SET expires_at = $1 WHERE id = $2 AND expires_at > $3"},
+		{dialect: MySQL, expected: "UPDATE sessions SET expires_at = ? WHERE id = ? AND expires_at > ?"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.dialect.Name, func(t *testing.T) {
+			s, err := New(&dbsql.DB{}, tt.dialect, WithTableName("sessions"))
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			if got := s.query("UPDATE %s SET expires_at = ? WHERE id = ? AND expires_at > ?"); got != tt.expected {
+				t.Errorf("query() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,159 @@
+// Package signedurl mints URLs signed with an HMAC and an optional expiry, and verifies them,
+// to hand out links that work without a session but can't be forged or altered: downloads,
+// unsubscribe links of emails, image resizing.
+//
+//	s, err := signedurl.New(secret, signedurl.DefaultConfig())
+//	...
+//	link, err := s.Sign("/downloads/report.pdf?user=42", time.Now().Add(time.Hour))
+//	ws.Handle("GET /downloads/{file}", s.Middleware(downloads))
+//
+// The signature covers the path and the query of the URL, but not its scheme and host, so that
+// relative and absolute links verify the same. Links are stateless: they can't be revoked
+// before they expire, keep the expiry as short as the use allows.
+package signedurl
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const (
+	DefaultSignatureParam = "signature"
+	DefaultExpiresParam   = "expires"
+)
+
+var (
+	// ErrInvalidSignature is returned for unsigned URLs, or URLs altered after signing.
+	ErrInvalidSignature = errors.New("invalid URL signature")
+	// ErrExpired is returned for signed URLs past their expiry.
+	ErrExpired = errors.New("expired URL")
+)
+
+// Config configures a [Signer]. Use [DefaultConfig] as a starting point.
+type Config struct {
+	// Name of the query parameter holding the signature.
+	SignatureParam string
+	// Name of the query parameter holding the expiry, in Unix seconds.
+	ExpiresParam string
+	// Optional: secrets of the URLs signed before a rotation, still verified until they expire.
+	PreviousSecrets [][]byte
+	// Optional: OnError answers the rejected requests of Middleware, with an error wrapping
+	// ErrInvalidSignature or ErrExpired. A plain 403 Forbidden is sent when nil.
+	OnError func(w http.ResponseWriter, r *http.Request, err error)
+}
+
+// DefaultConfig returns the default Config.
+func DefaultConfig() Config {
+	return Config{
+		SignatureParam: DefaultSignatureParam,
+		ExpiresParam:   DefaultExpiresParam,
+	}
+}
+
+// Signer signs and verifies URLs. Instantiate via New.
+type Signer struct {
+	secrets [][]byte
+	cfg     Config
+	now     func() time.Time
+}
+
+// New creates a Signer. secret signs the URLs and must be kept private.
+func New(secret []byte, cfg Config) (*Signer, error) {
+	if len(secret) == 0 {
+		return nil, errors.New("signed URL secret is empty")
+	}
+	if cfg.SignatureParam == "" {
+		cfg.SignatureParam = DefaultSignatureParam
+	}
+	if cfg.ExpiresParam == "" {
+		cfg.ExpiresParam = DefaultExpiresParam
+	}
+	if cfg.OnError == nil {
+		cfg.OnError = func(w http.ResponseWriter, r *http.Request, err error) {
+			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		}
+	}
+	return &Signer{secrets: append([][]byte{secret}, cfg.PreviousSecrets...), cfg: cfg, now: time.Now}, nil
+}
+
+// Sign returns u, relative or absolute, with its signature valid until expires in its query. A
+// zero expires signs a URL that never expires, e.g. an unsubscribe link.
+func (s *Signer) Sign(u string, expires time.Time) (string, error) {
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse URL. err %w", err)
+	}
+	q := parsed.Query()
+	q.Del(s.cfg.SignatureParam)
+	q.Del(s.cfg.ExpiresParam)
+	if !expires.IsZero() {
+		q.Set(s.cfg.ExpiresParam, strconv.FormatInt(expires.Unix(), 10))
+	}
+	q.Set(s.cfg.SignatureParam, s.sign(s.secrets[0], parsed.EscapedPath(), q))
+	parsed.RawQuery = q.Encode()
+	return parsed.String(), nil
+}
+
+// Verify checks the signature and the expiry of u.
+func (s *Signer) Verify(u *url.URL) error {
+	q := u.Query()
+	sig := q.Get(s.cfg.SignatureParam)
+	if sig == "" {
+		return fmt.Errorf("%w: no %s parameter", ErrInvalidSignature, s.cfg.SignatureParam)
+	}
+	valid := false
+	for _, secret := range s.secrets {
+		if hmac.Equal([]byte(sig), []byte(s.sign(secret, u.EscapedPath(), q))) {
+			valid = true
+			break
+		}
+	}
+	if !valid {
+		return ErrInvalidSignature
+	}
+	if exp := q.Get(s.cfg.ExpiresParam); exp != "" {
+		unix, err := strconv.ParseInt(exp, 10, 64)
+		if err != nil {
+			return fmt.Errorf("%w: malformed %s parameter", ErrInvalidSignature, s.cfg.ExpiresParam)
+		}
+		if expiresAt := time.Unix(unix, 0); !s.now().Before(expiresAt) {
+			return fmt.Errorf("%w: at %s", ErrExpired, expiresAt.Format(time.RFC3339))
+		}
+	}
+	return nil
+}
+
+// Middleware serves the requests whose URL is signed, and answers the others with
+// Config.OnError. Wrap the handler before any http.StripPrefix, which changes the path that was
+// signed. The responses of signed URLs get "Referrer-Policy: no-referrer", so that the links
+// don't leak to the sites linked from the page.
+func (s *Signer) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := s.Verify(r.URL); err != nil {
+			s.cfg.OnError(w, r, err)
+			return
+		}
+		w.Header().Set("Referrer-Policy", "no-referrer")
+		next.ServeHTTP(w, r)
+	})
+}
+
+// sign returns the signature of the path and the query q, without the signature parameter.
+func (s *Signer) sign(secret []byte, path string, q url.Values) string {
+	unsigned := url.Values{}
+	for k, v := range q {
+		if k != s.cfg.SignatureParam {
+			unsigned[k] = v
+		}
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte("gotth-signedurl\n" + path + "\n" + unsigned.Encode()))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
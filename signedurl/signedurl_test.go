@@ -0,0 +1,97 @@
+package signedurl_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ancalabrese/gotth/signedurl"
+)
+
+func mustParse(t *testing.T, u string) *url.URL {
+	t.Helper()
+	parsed, err := url.Parse(u)
+	if err != nil {
+		t.Fatalf("url.Parse(%q) error = %v", u, err)
+	}
+	return parsed
+}
+
+func TestSigner(t *testing.T) {
+	s, err := signedurl.New([]byte("secret"), signedurl.DefaultConfig())
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	link, err := s.Sign("https://example.com/downloads/report.pdf?user=42", time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatalf("Sign() error = %v", err)
+	}
+	if !strings.HasPrefix(link, "https://example.com/downloads/report.pdf?") {
+		t.Errorf("Sign() = %q, want the URL with its signature", link)
+	}
+	if err := s.Verify(mustParse(t, link)); err != nil {
+		t.Errorf("Verify() error = %v", err)
+	}
+	// Relative and absolute links verify the same.
+	if err := s.Verify(mustParse(t, strings.TrimPrefix(link, "https://example.com"))); err != nil {
+		t.Errorf("Verify() of the relative link error = %v", err)
+	}
+
+	tampered := map[string]string{
+		"Other query": strings.Replace(link, "user=42", "user=43", 1),
+		"Other path":  strings.Replace(link, "report.pdf", "secret.pdf", 1),
+		"Added param": link + "&admin=true",
+		"Unsigned":    "https://example.com/downloads/report.pdf?user=42",
+	}
+	for name, u := range tampered {
+		if err := s.Verify(mustParse(t, u)); !errors.Is(err, signedurl.ErrInvalidSignature) {
+			t.Errorf("%s: Verify() error = %v, want %v", name, err, signedurl.ErrInvalidSignature)
+		}
+	}
+
+	expired, _ := s.Sign("/downloads/report.pdf", time.Now().Add(-time.Second))
+	if err := s.Verify(mustParse(t, expired)); !errors.Is(err, signedurl.ErrExpired) {
+		t.Errorf("Verify() of an expired link error = %v, want %v", err, signedurl.ErrExpired)
+	}
+	permanent, _ := s.Sign("/unsubscribe?list=news&user=42", time.Time{})
+	if err := s.Verify(mustParse(t, permanent)); err != nil || strings.Contains(permanent, signedurl.DefaultExpiresParam) {
+		t.Errorf("Verify(%q) error = %v, want a valid link without expiry", permanent, err)
+	}
+
+	other, _ := signedurl.New([]byte("other"), signedurl.DefaultConfig())
+	if err := other.Verify(mustParse(t, link)); !errors.Is(err, signedurl.ErrInvalidSignature) {
+		t.Errorf("Verify() with another secret error = %v, want %v", err, signedurl.ErrInvalidSignature)
+	}
+	rotated, _ := signedurl.New([]byte("new"), signedurl.Config{PreviousSecrets: [][]byte{[]byte("secret")}})
+	if err := rotated.Verify(mustParse(t, link)); err != nil {
+		t.Errorf("Verify() with the previous secret error = %v", err)
+	}
+	if _, err := signedurl.New(nil, signedurl.DefaultConfig()); err == nil {
+		t.Error("New() with an empty secret error = nil")
+	}
+}
+
+func TestSigner_Middleware(t *testing.T) {
+	s, _ := signedurl.New([]byte("secret"), signedurl.DefaultConfig())
+	h := s.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("report"))
+	}))
+	serve := func(u string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, u, nil))
+		return rr
+	}
+
+	link, _ := s.Sign("/downloads/report.pdf", time.Now().Add(time.Minute))
+	if rr := serve(link); rr.Code != http.StatusOK || rr.Header().Get("Referrer-Policy") != "no-referrer" {
+		t.Errorf("status = %d, Referrer-Policy = %q, want %d and no-referrer", rr.Code, rr.Header().Get("Referrer-Policy"), http.StatusOK)
+	}
+	if rr := serve("/downloads/report.pdf"); rr.Code != http.StatusForbidden {
+		t.Errorf("status of an unsigned URL = %d, want %d", rr.Code, http.StatusForbidden)
+	}
+}
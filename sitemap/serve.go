@@ -0,0 +1,15 @@
+package sitemap
+
+import "github.com/ancalabrese/gotth"
+
+// Serve serves the sitemap index of sm on ws at its path, e.g. /sitemap.xml, and its sitemap
+// files under its shard path, e.g. /sitemaps/blog-1.xml. Generate them with sm.Generate, e.g.
+// as a task of the scheduler run on start.
+func Serve(ws *gotth.WebServer, sm *Sitemap) {
+	if sm == nil {
+		ws.InvalidRoute("", "sitemap.Serve needs a sitemap")
+		return
+	}
+	ws.Handle("GET "+sm.Path(), sm.Handler())
+	ws.Handle("GET "+sm.ShardPath()+"{file}", sm.Handler())
+}
@@ -0,0 +1,45 @@
+package sitemap_test
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ancalabrese/gotth"
+	"github.com/ancalabrese/gotth/sitemap"
+)
+
+func TestServe(t *testing.T) {
+	sm, err := sitemap.New(sitemap.Config{BaseURL: "https://example.com", Dir: t.TempDir(), Logger: slog.New(slog.DiscardHandler)})
+	if err != nil {
+		t.Fatalf("sitemap.New() error = %v", err)
+	}
+	sm.Add("pages", sitemap.Paths("/", "/about"))
+	if err := sm.Generate(context.Background()); err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	ws, err := gotth.New(gotth.WebServerConfig{}, nil)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	sitemap.Serve(ws, sm)
+	sitemap.Serve(ws, nil)
+	// The error names the caller of Serve, not Serve itself.
+	if err := ws.Err(); err == nil || !strings.Contains(err.Error(), "sitemap.Serve needs a sitemap") || !strings.Contains(err.Error(), "serve_test.go:") {
+		t.Errorf("Err() = %v, want the nil sitemap at its registration site", err)
+	}
+
+	for path, want := range map[string]string{
+		"/sitemap.xml":          "<loc>https://example.com/sitemaps/pages-1.xml</loc>",
+		"/sitemaps/pages-1.xml": "<loc>https://example.com/about</loc>",
+	} {
+		rr := httptest.NewRecorder()
+		ws.Handler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+		if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), want) {
+			t.Errorf("GET %s = %d %s, want %s", path, rr.Code, rr.Body, want)
+		}
+	}
+}
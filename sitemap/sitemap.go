@@ -0,0 +1,473 @@
+// Package sitemap generates the sitemaps of large sites: the URLs of its sources, e.g. a content
+// collection or a database query, are streamed to sitemap files of up to [MaxURLs] URLs each,
+// listed by a sitemap index, so that memory stays flat with hundreds of thousands of URLs.
+//
+//	sm, err := sitemap.New(sitemap.Config{BaseURL: "https://example.com", Dir: "var/sitemaps"})
+//	...
+//	sm.Add("pages", sitemap.Paths("/", "/about", "/pricing"))
+//	sm.Add("blog", b.SitemapSource())
+//	sm.Add("products", func(ctx context.Context) iter.Seq2[sitemap.URL, error] {
+//		return db.ProductURLs(ctx) // Rows read one at a time
+//	})
+//	s.Add("sitemap", scheduler.Every(time.Hour), sm.Generate, scheduler.RunOnStart())
+//	sitemap.Serve(ws, sm) // GET /sitemap.xml and /sitemaps/{file}
+//
+// and advertise sm.IndexURL() in robots.txt (see gotth.RobotsConfig.Sitemaps).
+package sitemap
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"iter"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ancalabrese/gotth/content"
+)
+
+const (
+	// MaxURLs is the maximum number of URLs of a sitemap file, set by the sitemaps protocol.
+	MaxURLs = 50000
+	// MaxFileSize is the maximum size of a sitemap file, uncompressed, set by the sitemaps
+	// protocol.
+	MaxFileSize = 50 << 20
+
+	// DefaultPath is the path of the sitemap index.
+	DefaultPath = "/sitemap.xml"
+	// DefaultShardPath is the path the sitemap files are served under.
+	DefaultShardPath = "/sitemaps/"
+)
+
+// URL is an entry of a sitemap.
+type URL struct {
+	// Loc is the absolute URL of the page, or its path, e.g. "/blog/hello", joined to
+	// Config.BaseURL. URLs without Loc are skipped, e.g. drafts.
+	Loc string
+	// Optional: time of the last change of the page.
+	LastMod time.Time
+	// Optional: "always", "hourly", "daily", "weekly", "monthly", "yearly" or "never".
+	ChangeFreq string
+	// Optional: priority relative to the other pages of the site, from 0.0 to 1.0. Left out
+	// when 0: the crawlers default to 0.5.
+	Priority float64
+}
+
+// Source returns the URLs of a part of the site, e.g. the posts of a blog. It's run by every
+// Generate: read the rows of a database one at a time rather than loading them all. The first
+// error fails the generation.
+type Source func(ctx context.Context) iter.Seq2[URL, error]
+
+// Paths returns the Source of the pages at paths, e.g. the static pages of the site.
+func Paths(paths ...string) Source {
+	return func(ctx context.Context) iter.Seq2[URL, error] {
+		return func(yield func(URL, error) bool) {
+			for _, p := range paths {
+				if !yield(URL{Loc: p}, nil) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// CollectionSource returns the Source of the entries of c, turned into URLs by entryURL.
+func CollectionSource[T any](c *content.Collection[T], entryURL func(*content.Entry[T]) URL) Source {
+	return func(ctx context.Context) iter.Seq2[URL, error] {
+		return func(yield func(URL, error) bool) {
+			for _, e := range c.All() {
+				if !yield(entryURL(e), nil) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Config configures a [Sitemap].
+type Config struct {
+	// BaseURL is the absolute URL of the site, e.g. "https://example.com", joined to the paths of
+	// the URLs and of the sitemap files.
+	BaseURL string
+	// Dir is the directory the files are written to and served from. Use a directory of the
+	// sitemaps only: the files of the sources removed since the previous Generate are deleted.
+	Dir string
+	// Path is the path of the sitemap index. Defaults to [DefaultPath].
+	Path string
+	// ShardPath is the path the sitemap files are served under, e.g. /sitemaps/blog-1.xml.
+	// Defaults to [DefaultShardPath].
+	ShardPath string
+	// MaxURLs is the maximum number of URLs of a sitemap file. Defaults to and can't exceed
+	// [MaxURLs].
+	MaxURLs int
+	// Optional: logs the generations. Defaults to slog.Default().
+	Logger *slog.Logger
+}
+
+// Sitemap generates the sitemap index and files of its sources. It's safe for concurrent use.
+type Sitemap struct {
+	cfg    Config
+	logger *slog.Logger
+
+	genMu sync.Mutex // Serializes Generate
+
+	mu      sync.RWMutex
+	sources []namedSource
+	ready   bool // Generated at least once
+}
+
+type namedSource struct {
+	name string
+	src  Source
+}
+
+var (
+	validName = regexp.MustCompile(`^[a-z0-9_]+(-[a-z0-9_]+)*$`)
+	shardName = regexp.MustCompile(`^[a-z0-9_-]+-[0-9]+\.xml$`)
+)
+
+// New returns the Sitemap of cfg, without sources.
+func New(cfg Config) (*Sitemap, error) {
+	u, err := url.Parse(cfg.BaseURL)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("sitemap: Config.BaseURL must be an absolute URL, got %q", cfg.BaseURL)
+	}
+	if cfg.Dir == "" {
+		return nil, errors.New("sitemap: Config.Dir is required")
+	}
+	cfg.BaseURL = strings.TrimSuffix(cfg.BaseURL, "/")
+	cfg.Path = "/" + strings.TrimPrefix(cmp.Or(cfg.Path, DefaultPath), "/")
+	cfg.ShardPath = "/" + strings.Trim(cmp.Or(cfg.ShardPath, DefaultShardPath), "/") + "/"
+	if cfg.MaxURLs <= 0 || cfg.MaxURLs > MaxURLs {
+		cfg.MaxURLs = MaxURLs
+	}
+	logger := cfg.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &Sitemap{cfg: cfg, logger: logger}, nil
+}
+
+// Add adds the source name, e.g. "blog", replacing the source of the same name. Its files are
+// named after it, e.g. blog-1.xml: names are lowercase letters, digits, underscores and
+// hyphens. The next Generate includes it.
+func (sm *Sitemap) Add(name string, src Source) error {
+	if !validName.MatchString(name) || src == nil {
+		return fmt.Errorf("sitemap: invalid source %q", name)
+	}
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	for i, s := range sm.sources {
+		if s.name == name {
+			sm.sources[i].src = src
+			return nil
+		}
+	}
+	sm.sources = append(sm.sources, namedSource{name, src})
+	return nil
+}
+
+// Path returns the path of the sitemap index, e.g. "/sitemap.xml".
+func (sm *Sitemap) Path() string {
+	return sm.cfg.Path
+}
+
+// ShardPath returns the path the sitemap files are served under, e.g. "/sitemaps/".
+func (sm *Sitemap) ShardPath() string {
+	return sm.cfg.ShardPath
+}
+
+// IndexURL returns the absolute URL of the sitemap index, for robots.txt.
+func (sm *Sitemap) IndexURL() string {
+	return sm.cfg.BaseURL + sm.cfg.Path
+}
+
+// shard is a written sitemap file.
+type shard struct {
+	file    string
+	lastMod time.Time
+}
+
+// Generate writes the sitemap files of the sources, then the index listing them, each file
+// replacing the previous one atomically. The files of the previous generation that aren't
+// written again are removed. On error, the previous index is kept.
+//
+// Its signature is the one of a scheduler task.
+func (sm *Sitemap) Generate(ctx context.Context) error {
+	sm.genMu.Lock()
+	defer sm.genMu.Unlock()
+	if err := os.MkdirAll(sm.cfg.Dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create sitemap directory. err %w", err)
+	}
+
+	sm.mu.RLock()
+	sources := append([]namedSource(nil), sm.sources...)
+	sm.mu.RUnlock()
+
+	start := time.Now()
+	var shards []shard
+	urls := 0
+	for _, s := range sources {
+		written, n, err := sm.writeSource(ctx, s)
+		if err != nil {
+			return fmt.Errorf("failed to generate sitemap of %s. err %w", s.name, err)
+		}
+		shards = append(shards, written...)
+		urls += n
+	}
+	if err := sm.writeIndex(shards); err != nil {
+		return fmt.Errorf("failed to write sitemap index. err %w", err)
+	}
+	sm.removeStale(shards)
+
+	sm.mu.Lock()
+	sm.ready = true
+	sm.mu.Unlock()
+	sm.logger.InfoContext(ctx, "sitemap generated", slog.Int("urls", urls), slog.Int("files", len(shards)), slog.Duration("duration", time.Since(start)))
+	return nil
+}
+
+const (
+	urlsetHeader = xml.Header + `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">` + "\n"
+	urlsetFooter = "</urlset>\n"
+)
+
+// writeSource writes the sitemap files of s and returns them with the number of URLs.
+func (sm *Sitemap) writeSource(ctx context.Context, s namedSource) ([]shard, int, error) {
+	var (
+		shards []shard
+		f      *atomicFile
+		size   int // Of the current file
+		count  int // URLs of the current file
+		total  int
+		entry  bytes.Buffer
+	)
+	defer func() {
+		if f != nil {
+			f.abort()
+		}
+	}()
+	closeShard := func() error {
+		if _, err := f.WriteString(urlsetFooter); err != nil {
+			return err
+		}
+		err := f.commit()
+		f = nil
+		return err
+	}
+
+	for u, err := range s.src(ctx) {
+		if err != nil {
+			return nil, 0, err
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, 0, err
+		}
+		if u.Loc == "" {
+			continue
+		}
+		entry.Reset()
+		sm.writeURL(&entry, u)
+
+		if f != nil && (count == sm.cfg.MaxURLs || size+entry.Len()+len(urlsetFooter) > MaxFileSize) {
+			if err := closeShard(); err != nil {
+				return nil, 0, err
+			}
+		}
+		if f == nil {
+			name := s.name + "-" + strconv.Itoa(len(shards)+1) + ".xml"
+			var err error
+			if f, err = createAtomic(filepath.Join(sm.cfg.Dir, name)); err != nil {
+				return nil, 0, err
+			}
+			if _, err := f.WriteString(urlsetHeader); err != nil {
+				return nil, 0, err
+			}
+			shards = append(shards, shard{file: name})
+			size, count = len(urlsetHeader), 0
+		}
+		if _, err := f.Write(entry.Bytes()); err != nil {
+			return nil, 0, err
+		}
+		size += entry.Len()
+		count++
+		total++
+		if last := &shards[len(shards)-1]; u.LastMod.After(last.lastMod) {
+			last.lastMod = u.LastMod
+		}
+	}
+	if f != nil {
+		if err := closeShard(); err != nil {
+			return nil, 0, err
+		}
+	}
+	return shards, total, nil
+}
+
+// writeURL writes the <url> element of u to buf.
+func (sm *Sitemap) writeURL(buf *bytes.Buffer, u URL) {
+	loc := u.Loc
+	if strings.HasPrefix(loc, "/") {
+		loc = sm.cfg.BaseURL + loc
+	}
+	buf.WriteString("<url><loc>")
+	xml.EscapeText(buf, []byte(loc))
+	buf.WriteString("</loc>")
+	if !u.LastMod.IsZero() {
+		buf.WriteString("<lastmod>" + u.LastMod.UTC().Format(time.RFC3339) + "</lastmod>")
+	}
+	if u.ChangeFreq != "" {
+		buf.WriteString("<changefreq>")
+		xml.EscapeText(buf, []byte(u.ChangeFreq))
+		buf.WriteString("</changefreq>")
+	}
+	if u.Priority > 0 {
+		buf.WriteString("<priority>" + strconv.FormatFloat(min(u.Priority, 1), 'f', 1, 64) + "</priority>")
+	}
+	buf.WriteString("</url>\n")
+}
+
+// writeIndex writes the sitemap index listing shards.
+func (sm *Sitemap) writeIndex(shards []shard) error {
+	f, err := createAtomic(filepath.Join(sm.cfg.Dir, path.Base(sm.cfg.Path)))
+	if err != nil {
+		return err
+	}
+	defer f.abort()
+	f.WriteString(xml.Header + `<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">` + "\n")
+	for _, s := range shards {
+		f.WriteString("<sitemap><loc>")
+		xml.EscapeText(f, []byte(sm.cfg.BaseURL+sm.cfg.ShardPath+s.file))
+		f.WriteString("</loc>")
+		if !s.lastMod.IsZero() {
+			f.WriteString("<lastmod>" + s.lastMod.UTC().Format(time.RFC3339) + "</lastmod>")
+		}
+		f.WriteString("</sitemap>\n")
+	}
+	if _, err := f.WriteString("</sitemapindex>\n"); err != nil {
+		return err
+	}
+	return f.commit()
+}
+
+// removeStale removes the sitemap files of Dir that aren't in shards.
+func (sm *Sitemap) removeStale(shards []shard) {
+	keep := map[string]bool{}
+	for _, s := range shards {
+		keep[s.file] = true
+	}
+	entries, err := os.ReadDir(sm.cfg.Dir)
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		if !e.IsDir() && shardName.MatchString(e.Name()) && !keep[e.Name()] {
+			if err := os.Remove(filepath.Join(sm.cfg.Dir, e.Name())); err != nil {
+				sm.logger.Warn("failed to remove stale sitemap", slog.String("file", e.Name()), slog.Any("error", err))
+			}
+		}
+	}
+}
+
+// Handler serves the sitemap index at Path and the sitemap files under ShardPath from Dir.
+// Requests before the first Generate get a 503 Service Unavailable, so that the crawlers retry.
+func (sm *Sitemap) Handler() http.Handler {
+	index := path.Base(sm.cfg.Path)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var name string
+		switch {
+		case r.URL.Path == sm.cfg.Path:
+			name = index
+		case strings.HasPrefix(r.URL.Path, sm.cfg.ShardPath):
+			name = strings.TrimPrefix(r.URL.Path, sm.cfg.ShardPath)
+			if !shardName.MatchString(name) {
+				http.NotFound(w, r)
+				return
+			}
+		default:
+			http.NotFound(w, r)
+			return
+		}
+
+		sm.mu.RLock()
+		ready := sm.ready
+		sm.mu.RUnlock()
+		if !ready {
+			w.Header().Set("Retry-After", "60")
+			http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+			return
+		}
+		f, err := os.Open(filepath.Join(sm.cfg.Dir, name))
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		defer f.Close()
+		info, err := f.Stat()
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+		http.ServeContent(w, r, name, info.ModTime(), f)
+	})
+}
+
+// atomicFile is a buffered temporary file renamed to its name by commit.
+type atomicFile struct {
+	*bufio.Writer
+	f    *os.File
+	name string
+}
+
+func createAtomic(name string) (*atomicFile, error) {
+	f, err := os.CreateTemp(filepath.Dir(name), ".tmp-"+filepath.Base(name)+"-*")
+	if err != nil {
+		return nil, err
+	}
+	return &atomicFile{Writer: bufio.NewWriterSize(f, 64<<10), f: f, name: name}, nil
+}
+
+// commit writes the file and renames it to its name.
+func (a *atomicFile) commit() error {
+	if err := a.Flush(); err != nil {
+		a.abort()
+		return err
+	}
+	if err := a.f.Chmod(0o644); err != nil {
+		a.abort()
+		return err
+	}
+	if err := a.f.Close(); err != nil {
+		os.Remove(a.f.Name())
+		return err
+	}
+	if err := os.Rename(a.f.Name(), a.name); err != nil {
+		os.Remove(a.f.Name())
+		return err
+	}
+	a.f = nil
+	return nil
+}
+
+// abort removes the file unless it's committed.
+func (a *atomicFile) abort() {
+	if a.f != nil {
+		a.f.Close()
+		os.Remove(a.f.Name())
+		a.f = nil
+	}
+}
@@ -0,0 +1,156 @@
+package sitemap_test
+
+import (
+	"context"
+	"errors"
+	"iter"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ancalabrese/gotth/sitemap"
+)
+
+// products returns the Source of n product pages, failing with err after them when not nil.
+func products(n *int, err *error) sitemap.Source {
+	return func(ctx context.Context) iter.Seq2[sitemap.URL, error] {
+		return func(yield func(sitemap.URL, error) bool) {
+			for i := range *n {
+				u := sitemap.URL{Loc: "/products/" + strconv.Itoa(i+1), LastMod: time.Date(2026, 1, i+1, 0, 0, 0, 0, time.UTC)}
+				if !yield(u, nil) {
+					return
+				}
+			}
+			if *err != nil {
+				yield(sitemap.URL{}, *err)
+			}
+		}
+	}
+}
+
+func newSitemap(t *testing.T) (*sitemap.Sitemap, string) {
+	t.Helper()
+	dir := t.TempDir()
+	sm, err := sitemap.New(sitemap.Config{BaseURL: "https://example.com/", Dir: dir, MaxURLs: 2, Logger: slog.New(slog.DiscardHandler)})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	return sm, dir
+}
+
+func readFile(t *testing.T, name string) string {
+	t.Helper()
+	b, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestSitemap_Generate(t *testing.T) {
+	sm, dir := newSitemap(t)
+	n, failure := 5, error(nil)
+	sm.Add("pages", sitemap.Paths("/", "/search?q=a&b", "https://example.com/about"))
+	sm.Add("products", products(&n, &failure))
+	if err := sm.Generate(context.Background()); err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*"))
+	for i := range files {
+		files[i] = filepath.Base(files[i])
+	}
+	if want := []string{"pages-1.xml", "pages-2.xml", "products-1.xml", "products-2.xml", "products-3.xml", "sitemap.xml"}; !slices.Equal(files, want) {
+		t.Fatalf("files = %v, want %v", files, want)
+	}
+
+	index := readFile(t, filepath.Join(dir, "sitemap.xml"))
+	for _, want := range []string{
+		`<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">`,
+		"<sitemap><loc>https://example.com/sitemaps/pages-1.xml</loc></sitemap>",
+		// The last modification of the URLs of the file.
+		"<sitemap><loc>https://example.com/sitemaps/products-3.xml</loc><lastmod>2026-01-05T00:00:00Z</lastmod></sitemap>",
+	} {
+		if !strings.Contains(index, want) {
+			t.Errorf("index = %s, want %s", index, want)
+		}
+	}
+	want := `<url><loc>https://example.com/</loc></url>` + "\n" + `<url><loc>https://example.com/search?q=a&amp;b</loc></url>`
+	if pages := readFile(t, filepath.Join(dir, "pages-1.xml")); !strings.Contains(pages, want) || !strings.HasSuffix(pages, "</urlset>\n") {
+		t.Errorf("pages-1.xml = %s, want %s", pages, want)
+	}
+
+	// Fewer products: the files of the previous generation are removed.
+	n = 1
+	if err := sm.Generate(context.Background()); err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "products-2.xml")); !os.IsNotExist(err) {
+		t.Errorf("products-2.xml wasn't removed, err %v", err)
+	}
+
+	// A failing source keeps the previous index.
+	failure = errors.New("db down")
+	before := readFile(t, filepath.Join(dir, "sitemap.xml"))
+	if err := sm.Generate(context.Background()); !errors.Is(err, failure) {
+		t.Errorf("Generate() error = %v, want %v", err, failure)
+	}
+	if readFile(t, filepath.Join(dir, "sitemap.xml")) != before {
+		t.Error("the index changed after a failed generation")
+	}
+	if tmp, _ := filepath.Glob(filepath.Join(dir, ".tmp-*")); len(tmp) > 0 {
+		t.Errorf("temporary files left: %v", tmp)
+	}
+}
+
+func TestSitemap_Handler(t *testing.T) {
+	sm, _ := newSitemap(t)
+	sm.Add("pages", sitemap.Paths("/"))
+	get := func(path string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		sm.Handler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+		return rr
+	}
+
+	if rr := get("/sitemap.xml"); rr.Code != http.StatusServiceUnavailable {
+		t.Errorf("status before Generate = %d, want %d", rr.Code, http.StatusServiceUnavailable)
+	}
+	if err := sm.Generate(context.Background()); err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	tests := []struct {
+		path   string
+		status int
+	}{
+		{"/sitemap.xml", http.StatusOK},
+		{"/sitemaps/pages-1.xml", http.StatusOK},
+		{"/sitemaps/pages-2.xml", http.StatusNotFound},
+		{"/sitemaps/..%2fsitemap.xml", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		rr := get(tt.path)
+		if rr.Code != tt.status {
+			t.Errorf("GET %s = %d, want %d", tt.path, rr.Code, tt.status)
+		}
+		if tt.status == http.StatusOK && rr.Header().Get("Content-Type") != "application/xml; charset=utf-8" {
+			t.Errorf("Content-Type of %s = %q", tt.path, rr.Header().Get("Content-Type"))
+		}
+	}
+}
+
+func TestNew_Errors(t *testing.T) {
+	if _, err := sitemap.New(sitemap.Config{BaseURL: "/", Dir: t.TempDir()}); err == nil {
+		t.Error("New() with a relative BaseURL succeeded")
+	}
+	sm, _ := newSitemap(t)
+	if err := sm.Add("Blog/Posts", sitemap.Paths("/")); err == nil {
+		t.Error("Add() with an invalid name succeeded")
+	}
+}
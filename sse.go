@@ -0,0 +1,19 @@
+package gotth
+
+import (
+	"net/http"
+
+	"github.com/ancalabrese/gotth/sse"
+)
+
+// ServeSSE streams the Server-Sent Events produced by source at path, with heartbeats and
+// Last-Event-ID based reconnection. The streams end when the server starts shutting down (see
+// [WebServer.CloseOnShutdown]). See the sse package for streaming templ fragments to the htmx
+// SSE extension.
+func (ws *WebServer) ServeSSE(path string, source sse.Source, opts ...sse.Option) {
+	var handler http.Handler
+	if source != nil {
+		handler = ws.CloseOnShutdown(sse.Handler(source, opts...))
+	}
+	ws.Handle(path, handler)
+}
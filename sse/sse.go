@@ -0,0 +1,225 @@
+// Package sse serves Server-Sent Events, including rendered templ fragments for the htmx SSE
+// extension (https://htmx.org/extensions/sse/):
+//
+//	ws.ServeSSE("GET /events/clock", func(ctx context.Context, r *http.Request, lastEventID string, send sse.SendFunc) error {
+//		for {
+//			select {
+//			case <-ctx.Done():
+//				return nil
+//			case now := <-ticker.C:
+//				e, err := sse.Fragment(ctx, "clock", views.Clock(now))
+//				if err != nil {
+//					return err
+//				}
+//				if err := send(e); err != nil {
+//					return err
+//				}
+//			}
+//		}
+//	})
+//
+// and in the page: <div hx-ext="sse" sse-connect="/events/clock" sse-swap="clock"></div>.
+package sse
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/a-h/templ"
+)
+
+const DefaultHeartbeat = 15 * time.Second
+
+// ErrStreamingUnsupported is returned when the ResponseWriter can't flush, e.g. behind a
+// buffering middleware such as middlewares.Timeout.
+var ErrStreamingUnsupported = errors.New("streaming unsupported by the response writer")
+
+// Event is a server-sent event.
+type Event struct {
+	// ID is sent back by the browser in Last-Event-ID when it reconnects, so the source can resume.
+	ID string
+	// Name is the event type, "message" when empty. The htmx SSE extension swaps events by name
+	// (sse-swap="name").
+	Name string
+	// Data is the payload. Multi-line data is split over several data fields.
+	Data string
+	// Retry, when set, tells the browser how long to wait before reconnecting.
+	Retry time.Duration
+}
+
+// Fragment renders c into an event named name, for the htmx SSE extension to swap.
+func Fragment(ctx context.Context, name string, c templ.Component) (Event, error) {
+	var buf bytes.Buffer
+	if err := c.Render(ctx, &buf); err != nil {
+		return Event{}, fmt.Errorf("failed to render fragment. err %w", err)
+	}
+	return Event{Name: name, Data: buf.String()}, nil
+}
+
+// SendFunc sends an event to the client. It returns an error once the client is gone.
+type SendFunc func(Event) error
+
+// Source produces the events of a connection. It's called once per connection with the
+// Last-Event-ID sent by a reconnecting browser ("" on the first connection) and should send
+// events until ctx is done, which happens when the client disconnects, or when the server shuts
+// down if the handler is wrapped with gotth's WebServer.CloseOnShutdown, as ServeSSE does.
+// Returning ends the stream; the browser then reconnects after the retry delay.
+type Source func(ctx context.Context, r *http.Request, lastEventID string, send SendFunc) error
+
+// Option configures the Handler.
+type Option func(*options)
+
+type options struct {
+	heartbeat time.Duration
+	retry     time.Duration
+	onError   func(*http.Request, error)
+}
+
+// WithHeartbeat sets how often a comment is sent on idle connections, so proxies and load
+// balancers don't close them. Defaults to [DefaultHeartbeat]; zero disables heartbeats.
+func WithHeartbeat(d time.Duration) Option {
+	return func(o *options) { o.heartbeat = d }
+}
+
+// WithRetry sets the reconnection delay sent to the browser when the connection opens.
+func WithRetry(d time.Duration) Option {
+	return func(o *options) { o.retry = d }
+}
+
+// WithErrorHandler sets a callback for the errors returned by the Source.
+func WithErrorHandler(onError func(*http.Request, error)) Option {
+	return func(o *options) { o.onError = onError }
+}
+
+// Handler returns an http.Handler that streams the events of source to each client.
+// The server WriteTimeout is lifted for the connection, which would otherwise cut long-lived
+// streams short.
+func Handler(source Source, opts ...Option) http.Handler {
+	o := options{heartbeat: DefaultHeartbeat}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rc := http.NewResponseController(w)
+		rc.SetWriteDeadline(time.Time{})
+
+		h := w.Header()
+		h.Set("Content-Type", "text/event-stream")
+		h.Set("Cache-Control", "no-cache")
+		h.Set("X-Accel-Buffering", "no") // Disable response buffering in nginx
+		w.WriteHeader(http.StatusOK)
+		if err := rc.Flush(); err != nil {
+			if o.onError != nil {
+				o.onError(r, fmt.Errorf("%w. err %w", ErrStreamingUnsupported, err))
+			}
+			return
+		}
+
+		s := &stream{w: w, rc: rc}
+		if o.retry > 0 {
+			if err := s.send(Event{Retry: o.retry}); err != nil {
+				return
+			}
+		}
+
+		// The heartbeat must be done writing before the handler returns, so wait for it after
+		// cancelling the context.
+		var wg sync.WaitGroup
+		defer wg.Wait()
+		ctx, cancel := context.WithCancel(r.Context())
+		defer cancel()
+
+		if o.heartbeat > 0 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				ticker := time.NewTicker(o.heartbeat)
+				defer ticker.Stop()
+				for {
+					select {
+					case <-ctx.Done():
+						return
+					case <-ticker.C:
+						if err := s.comment("heartbeat"); err != nil {
+							cancel()
+							return
+						}
+					}
+				}
+			}()
+		}
+
+		send := func(e Event) error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if err := s.send(e); err != nil {
+				cancel()
+				return err
+			}
+			return nil
+		}
+		if err := source(ctx, r, r.Header.Get("Last-Event-ID"), send); err != nil && ctx.Err() == nil && o.onError != nil {
+			o.onError(r, err)
+		}
+	})
+}
+
+// stream serializes the writes of the source and the heartbeat.
+type stream struct {
+	mu sync.Mutex
+	w  io.Writer
+	rc *http.ResponseController
+}
+
+func (s *stream) send(e Event) error {
+	var b strings.Builder
+	if e.ID != "" {
+		b.WriteString("id: " + stripNewlines(e.ID) + "\n")
+	}
+	if e.Name != "" {
+		b.WriteString("event: " + stripNewlines(e.Name) + "\n")
+	}
+	if e.Retry > 0 {
+		b.WriteString("retry: " + strconv.FormatInt(e.Retry.Milliseconds(), 10) + "\n")
+	}
+	if e.Data != "" || e.Retry == 0 {
+		for _, line := range strings.Split(normalizeNewlines(e.Data), "\n") {
+			b.WriteString("data: " + line + "\n")
+		}
+	}
+	b.WriteString("\n")
+	return s.write(b.String())
+}
+
+func (s *stream) comment(text string) error {
+	return s.write(": " + text + "\n\n")
+}
+
+func (s *stream) write(data string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := io.WriteString(s.w, data); err != nil {
+		return err
+	}
+	return s.rc.Flush()
+}
+
+func stripNewlines(s string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(s)
+}
+
+// normalizeNewlines turns the \r\n and \r line endings of s into \n, since clients end a field
+// on any of them.
+func normalizeNewlines(s string) string {
+	return strings.NewReplacer("\r\n", "\n", "\r", "\n").Replace(s)
+}
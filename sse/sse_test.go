@@ -0,0 +1,157 @@
+package sse_test
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/a-h/templ"
+	"github.com/ancalabrese/gotth/sse"
+)
+
+// readEvent reads the lines of the next event or comment, without the blank separator.
+func readEvent(t *testing.T, r *bufio.Reader) []string {
+	t.Helper()
+	var lines []string
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatalf("failed to read event. err %v", err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+		if line == "" {
+			return lines
+		}
+		lines = append(lines, line)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	fragment := templ.ComponentFunc(func(ctx context.Context, w io.Writer) error {
+		_, err := io.WriteString(w, "<p>\nhello\n</p>")
+		return err
+	})
+
+	var lastEventID string
+	source := func(ctx context.Context, r *http.Request, last string, send sse.SendFunc) error {
+		lastEventID = last
+		e, err := sse.Fragment(ctx, "greeting", fragment)
+		if err != nil {
+			return err
+		}
+		e.ID = "2"
+		if err := send(e); err != nil {
+			return err
+		}
+		// A lone \r ends a line too: it mustn't let the data start a new field.
+		if err := send(sse.Event{Data: "a\r\nb\revent: injected"}); err != nil {
+			return err
+		}
+		return send(sse.Event{Data: "bye"})
+	}
+
+	srv := httptest.NewServer(sse.Handler(source, sse.WithRetry(3*time.Second), sse.WithHeartbeat(0)))
+	defer srv.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	req.Header.Set("Last-Event-ID", "1")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Content-Type = %q, want %q", ct, "text/event-stream")
+	}
+	if cc := resp.Header.Get("Cache-Control"); cc != "no-cache" {
+		t.Errorf("Cache-Control = %q, want %q", cc, "no-cache")
+	}
+
+	body := bufio.NewReader(resp.Body)
+	tests := [][]string{
+		{"retry: 3000"},
+		{"id: 2", "event: greeting", "data: <p>", "data: hello", "data: </p>"},
+		{"data: a", "data: b", "data: event: injected"},
+		{"data: bye"},
+	}
+	for i, want := range tests {
+		if got := readEvent(t, body); strings.Join(got, "|") != strings.Join(want, "|") {
+			t.Errorf("event %d = %q, want %q", i, got, want)
+		}
+	}
+	if lastEventID != "1" {
+		t.Errorf("lastEventID = %q, want %q", lastEventID, "1")
+	}
+}
+
+func TestHandler_Heartbeat(t *testing.T) {
+	source := func(ctx context.Context, r *http.Request, last string, send sse.SendFunc) error {
+		<-ctx.Done()
+		return nil
+	}
+	srv := httptest.NewServer(sse.Handler(source, sse.WithHeartbeat(10*time.Millisecond)))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if got := readEvent(t, bufio.NewReader(resp.Body)); len(got) != 1 || got[0] != ": heartbeat" {
+		t.Errorf("heartbeat = %q, want %q", got, ": heartbeat")
+	}
+}
+
+func TestHandler_Disconnect(t *testing.T) {
+	done := make(chan error, 1)
+	source := func(ctx context.Context, r *http.Request, last string, send sse.SendFunc) error {
+		for {
+			if err := send(sse.Event{Data: "tick"}); err != nil {
+				done <- err
+				return err
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+	srv := httptest.NewServer(sse.Handler(source))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	readEvent(t, bufio.NewReader(resp.Body))
+	resp.Body.Close()
+
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Error("send() error = nil after the client disconnected")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("source still running after the client disconnected")
+	}
+}
+
+func TestHandler_SourceError(t *testing.T) {
+	wantErr := errors.New("boom")
+	errs := make(chan error, 1)
+	source := func(ctx context.Context, r *http.Request, last string, send sse.SendFunc) error {
+		return wantErr
+	}
+	h := sse.Handler(source, sse.WithErrorHandler(func(r *http.Request, err error) { errs <- err }))
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+	if err := <-errs; !errors.Is(err, wantErr) {
+		t.Errorf("onError() err = %v, want %v", err, wantErr)
+	}
+}
@@ -0,0 +1,54 @@
+package gotth
+
+import (
+	"bufio"
+	"context"
+	"log/slog"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/ancalabrese/gotth/sse"
+)
+
+func TestWebServer_ShutdownEndsStreams(t *testing.T) {
+	addr := freeAddr(t)
+	ws, err := NewWithOptions(WithAddr(addr), WithLogger(slog.New(slog.DiscardHandler)))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	ws.ServeSSE("GET /events", func(ctx context.Context, r *http.Request, lastEventID string, send sse.SendFunc) error {
+		send(sse.Event{Data: "hello"})
+		<-ctx.Done()
+		return nil
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- ws.Start(ctx) }()
+
+	var resp *http.Response
+	for range 50 {
+		if resp, err = http.Get("http://" + addr + "/events"); err == nil {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatalf("GET /events error = %v", err)
+	}
+	defer resp.Body.Close()
+	if _, err := bufio.NewReader(resp.Body).ReadString('\n'); err != nil {
+		t.Fatalf("stream error = %v", err)
+	}
+
+	// The connected client doesn't hold the shutdown until its deadline.
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Start() error = %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Start() didn't return with a stream open")
+	}
+}
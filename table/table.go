@@ -0,0 +1,214 @@
+// Package table builds HTMX-driven tables: sorting by a column and filtering re-request the
+// table with hx-get, and the sort and filters are encoded in the query string so that the URL
+// can be shared and the table state survives a reload.
+//
+//	var columns = []table.Column[User]{
+//		{Key: "name", Label: "Name", Sortable: true, Filterable: true,
+//			Cell: func(u User) templ.Component { return table.Text(u.Name) },
+//			Compare: func(a, b User) int { return strings.Compare(a.Name, b.Name) },
+//			Match: func(u User, q string) bool { return strings.Contains(u.Name, q) }},
+//		{Key: "email", Label: "Email", Cell: func(u User) templ.Component { return table.Text(u.Email) }},
+//	}
+//
+//	func users(r *http.Request) (head.HeadViewModel, templ.Component, error) {
+//		state := table.ParseState(r, columns)
+//		rows := table.Apply(allUsers, columns, state) // Or sort and filter in the database query
+//		return headVM, table.Table(table.New("users", "/users", columns, rows, state)), nil
+//	}
+package table
+
+import (
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/a-h/templ"
+)
+
+const (
+	// SortParam is the query parameter holding the sort column, prefixed with "-" when
+	// descending.
+	SortParam = "sort"
+	// FilterPrefix prefixes the query parameters holding the column filters.
+	FilterPrefix = "filter."
+)
+
+// Column defines a column of a table of T.
+type Column[T any] struct {
+	// Key identifies the column in the query string.
+	Key   string
+	Label string
+	// Sortable and Filterable enable the sort link and the filter input of the column.
+	Sortable   bool
+	Filterable bool
+	// Cell renders the cell of the column for an item.
+	Cell func(T) templ.Component
+	// Optional: Compare and Match sort and filter the items in memory with [Apply].
+	Compare func(a, b T) int
+	Match   func(item T, filter string) bool
+	// Optional: Value returns the raw value of the cell, e.g. a number or a time.Time, for the
+	// exports of the table (see the export package).
+	Value func(T) any
+}
+
+// State is the sort and filters of a table.
+type State struct {
+	// Sort is the key of the sort column, or "" when unsorted.
+	Sort string
+	Desc bool
+	// Filters maps column keys to their filter.
+	Filters map[string]string
+}
+
+// ParseState reads the state of the table from the query string of r. Only the sortable and
+// filterable columns are accepted, so Sort is safe to map to a database column.
+func ParseState[T any](r *http.Request, columns []Column[T]) State {
+	q := r.URL.Query()
+	state := State{Filters: map[string]string{}}
+
+	sort := q.Get(SortParam)
+	desc := strings.HasPrefix(sort, "-")
+	sort = strings.TrimPrefix(sort, "-")
+	for _, c := range columns {
+		if c.Sortable && c.Key == sort {
+			state.Sort, state.Desc = sort, desc
+		}
+		if v := strings.TrimSpace(q.Get(FilterPrefix + c.Key)); c.Filterable && v != "" {
+			state.Filters[c.Key] = v
+		}
+	}
+	return state
+}
+
+// Query encodes the state in query parameters.
+func (s State) Query() url.Values {
+	q := url.Values{}
+	if s.Sort != "" {
+		q.Set(SortParam, s.sortValue())
+	}
+	for k, v := range s.Filters {
+		q.Set(FilterPrefix+k, v)
+	}
+	return q
+}
+
+// SortedBy returns the state sorted by the column key: ascending, or reversed if the table is
+// already sorted by it.
+func (s State) SortedBy(key string) State {
+	sorted := State{Sort: key, Filters: s.Filters}
+	if s.Sort == key {
+		sorted.Desc = !s.Desc
+	}
+	return sorted
+}
+
+func (s State) sortValue() string {
+	if s.Desc {
+		return "-" + s.Sort
+	}
+	return s.Sort
+}
+
+// Apply returns the items matching the filters, sorted, using the Compare and Match functions
+// of the columns.
+func Apply[T any](items []T, columns []Column[T], state State) []T {
+	out := make([]T, 0, len(items))
+	for _, item := range items {
+		if matches(item, columns, state.Filters) {
+			out = append(out, item)
+		}
+	}
+
+	for _, c := range columns {
+		if c.Key != state.Sort || c.Compare == nil {
+			continue
+		}
+		slices.SortStableFunc(out, func(a, b T) int {
+			if state.Desc {
+				return c.Compare(b, a)
+			}
+			return c.Compare(a, b)
+		})
+	}
+	return out
+}
+
+func matches[T any](item T, columns []Column[T], filters map[string]string) bool {
+	for _, c := range columns {
+		if f, ok := filters[c.Key]; ok && c.Match != nil && !c.Match(item, f) {
+			return false
+		}
+	}
+	return true
+}
+
+// Heading is the header of a column.
+type Heading struct {
+	Key        string
+	Label      string
+	Sortable   bool
+	Filterable bool
+}
+
+// View is a table ready to be rendered by [Table].
+type View struct {
+	// ID of the table container, targeted by the sort and filter requests.
+	ID string
+	// URL the table is requested from, usually the page it's on.
+	URL      string
+	Headings []Heading
+	Rows     [][]templ.Component
+	State    State
+	// Empty is shown when there are no rows.
+	Empty string
+}
+
+// New returns the View of items.
+func New[T any](id, url string, columns []Column[T], items []T, state State) View {
+	v := View{ID: id, URL: url, State: state, Empty: "No results"}
+	for _, c := range columns {
+		v.Headings = append(v.Headings, Heading{Key: c.Key, Label: c.Label, Sortable: c.Sortable, Filterable: c.Filterable})
+	}
+	for _, item := range items {
+		row := make([]templ.Component, len(columns))
+		for i, c := range columns {
+			row[i] = c.Cell(item)
+		}
+		v.Rows = append(v.Rows, row)
+	}
+	return v
+}
+
+// sortURL returns the URL sorting the table by key.
+func (v View) sortURL(key string) string {
+	return v.URL + "?" + v.State.SortedBy(key).Query().Encode()
+}
+
+// ariaSort returns the aria-sort value of the column key.
+func (v View) ariaSort(key string) string {
+	switch {
+	case v.State.Sort != key:
+		return "none"
+	case v.State.Desc:
+		return "descending"
+	default:
+		return "ascending"
+	}
+}
+
+func (v View) filterable() bool {
+	return slices.ContainsFunc(v.Headings, func(h Heading) bool { return h.Filterable })
+}
+
+// sortIndicator returns the arrow shown next to the sort column.
+func (v View) sortIndicator(key string) string {
+	switch {
+	case v.State.Sort != key:
+		return ""
+	case v.State.Desc:
+		return " ▼"
+	default:
+		return " ▲"
+	}
+}
@@ -0,0 +1,120 @@
+package table_test
+
+import (
+	"cmp"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/a-h/templ"
+	"github.com/ancalabrese/gotth/table"
+)
+
+type user struct {
+	Name string
+	Age  int
+}
+
+var columns = []table.Column[user]{
+	{
+		Key: "name", Label: "Name", Sortable: true, Filterable: true,
+		Cell:    func(u user) templ.Component { return table.Text(u.Name) },
+		Compare: func(a, b user) int { return strings.Compare(a.Name, b.Name) },
+		Match:   func(u user, q string) bool { return strings.Contains(strings.ToLower(u.Name), strings.ToLower(q)) },
+	},
+	{
+		Key: "age", Label: "Age", Sortable: true,
+		Cell:    func(u user) templ.Component { return table.Text(strings.Repeat("*", u.Age)) },
+		Compare: func(a, b user) int { return cmp.Compare(a.Age, b.Age) },
+	},
+	{Key: "secret", Label: "Secret", Cell: func(u user) templ.Component { return table.Text("") }},
+}
+
+var users = []user{{"Ada", 3}, {"Grace", 1}, {"Alan", 2}}
+
+func TestParseState(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		expected table.State
+	}{
+		{name: "Empty", query: "", expected: table.State{Filters: map[string]string{}}},
+		{name: "Descending with filter", query: "sort=-age&filter.name=a", expected: table.State{Sort: "age", Desc: true, Filters: map[string]string{"name": "a"}}},
+		{name: "Unknown and unsortable columns", query: "sort=secret&filter.age=1&filter.password=x", expected: table.State{Filters: map[string]string{}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := table.ParseState(httptest.NewRequest(http.MethodGet, "/users?"+tt.query, nil), columns)
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("ParseState() = %+v, want %+v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestState_SortedBy(t *testing.T) {
+	s := table.State{Filters: map[string]string{"name": "a"}}
+	s = s.SortedBy("name")
+	if got := s.Query().Encode(); got != "filter.name=a&sort=name" {
+		t.Errorf("first sort = %q", got)
+	}
+	if got := s.SortedBy("name").Query().Encode(); got != "filter.name=a&sort=-name" {
+		t.Errorf("second sort = %q", got)
+	}
+	if got := s.SortedBy("age").Query().Encode(); got != "filter.name=a&sort=age" {
+		t.Errorf("other column = %q", got)
+	}
+}
+
+func TestApply(t *testing.T) {
+	names := func(us []user) []string {
+		var n []string
+		for _, u := range us {
+			n = append(n, u.Name)
+		}
+		return n
+	}
+
+	got := table.Apply(users, columns, table.State{Sort: "age", Desc: true, Filters: map[string]string{"name": "a"}})
+	if !reflect.DeepEqual(names(got), []string{"Ada", "Alan", "Grace"}) {
+		t.Errorf("Apply() = %v", names(got))
+	}
+	got = table.Apply(users, columns, table.State{Sort: "name", Filters: map[string]string{"name": "al"}})
+	if !reflect.DeepEqual(names(got), []string{"Alan"}) {
+		t.Errorf("Apply() = %v", names(got))
+	}
+}
+
+func TestTable(t *testing.T) {
+	state := table.State{Sort: "name", Filters: map[string]string{"name": "a"}}
+	v := table.New("users", "/users", columns, table.Apply(users, columns, state), state)
+
+	var b strings.Builder
+	if err := table.Table(v).Render(context.Background(), &b); err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	body := b.String()
+	for _, want := range []string{
+		`<div id="users">`,
+		`<th scope="col" aria-sort="ascending" class="text-left p-2"><a href="/users?filter.name=a&amp;sort=-name" hx-get="/users?filter.name=a&amp;sort=-name" hx-target="#users" hx-select="#users" hx-swap="outerHTML" hx-push-url="true">Name ▲</a></th>`,
+		`aria-sort="none"`,
+		`<th scope="col" class="text-left p-2">Secret</th>`,
+		`name="filter.name" value="a"`,
+		`<td class="p-2">Ada</td>`,
+		`<input type="hidden" name="sort" value="name">`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("table does not contain %s\n%s", want, body)
+		}
+	}
+
+	v = table.New("users", "/users", columns, nil, state)
+	b.Reset()
+	table.Table(v).Render(context.Background(), &b)
+	if !strings.Contains(b.String(), `<td colspan="3" class="p-2 text-center">No results</td>`) {
+		t.Errorf("empty table: %s", b.String())
+	}
+}
@@ -0,0 +1,26 @@
+package taxonomy
+
+import (
+	"net/http"
+
+	"github.com/ancalabrese/gotth"
+	"github.com/ancalabrese/gotth/routes"
+)
+
+// Serve serves the pages of t on ws: the index of its terms at its path, e.g. /tags, and the
+// page of each term at /tags/{term}. They're named after the taxonomy, e.g. "tags" and
+// "tags.term", for the breadcrumbs.
+func Serve(ws *gotth.WebServer, t Pages) {
+	if t == nil {
+		ws.InvalidRoute("", "taxonomy.Serve needs a taxonomy")
+		return
+	}
+	path := t.Path()
+	ws.ServeContent("GET "+path, t.IndexProvider)
+	ws.ServeContent("GET "+path+"/{term}", t.TermProvider)
+
+	ws.SetRouteMeta("GET "+path, routes.Meta{Name: t.Name(), Title: t.Title()})
+	ws.SetRouteMeta("GET "+path+"/{term}", routes.Meta{Name: t.Name() + ".term", TitleFunc: func(r *http.Request) string {
+		return t.TermTitle(Slug(r.PathValue("term")))
+	}})
+}
@@ -0,0 +1,74 @@
+package taxonomy_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/ancalabrese/gotth"
+	"github.com/ancalabrese/gotth/content"
+	"github.com/ancalabrese/gotth/taxonomy"
+)
+
+func TestServe(t *testing.T) {
+	type page struct {
+		Title      string   `yaml:"title"`
+		Categories []string `yaml:"categories"`
+	}
+	pages, err := content.NewCollection(content.CollectionConfig[page]{FS: fstest.MapFS{
+		"a.md": {Data: []byte("---\ntitle: A\ncategories: [Guides]\n---\n")},
+		"b.md": {Data: []byte("---\ntitle: B\ncategories: [Guides, News]\n---\n")},
+	}})
+	if err != nil {
+		t.Fatalf("NewCollection() error = %v", err)
+	}
+	categories, err := taxonomy.New(taxonomy.Config[page]{
+		Collection: pages,
+		Name:       "categories",
+		Terms:      func(e *content.Entry[page]) []string { return e.Data.Categories },
+		Item: func(e *content.Entry[page]) taxonomy.Item {
+			return taxonomy.Item{Title: e.Data.Title, URL: "/" + e.ID}
+		},
+	})
+	if err != nil {
+		t.Fatalf("taxonomy.New() error = %v", err)
+	}
+	ws, err := gotth.New(gotth.WebServerConfig{}, nil)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	taxonomy.Serve(ws, categories)
+	if err := ws.Err(); err != nil {
+		t.Fatalf("Err() = %v", err)
+	}
+
+	tests := []struct {
+		path        string
+		wantStatus  int
+		wantContain string
+		wantRobots  bool
+	}{
+		{path: "/categories", wantStatus: http.StatusOK, wantContain: `href="/categories/guides"`},
+		{path: "/categories/guides", wantStatus: http.StatusOK, wantContain: `href="/b"`},
+		{path: "/categories/news", wantStatus: http.StatusOK, wantContain: `href="/b"`, wantRobots: true},
+		{path: "/categories/events", wantStatus: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			ws.Handler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rr.Code, tt.wantStatus)
+			}
+			body := rr.Body.String()
+			if !strings.Contains(body, tt.wantContain) {
+				t.Errorf("body is missing %s:\n%s", tt.wantContain, body)
+			}
+			if got := strings.Contains(body, `<meta name="robots" content="noindex, follow">`); got != tt.wantRobots {
+				t.Errorf("robots meta = %v, want %v", got, tt.wantRobots)
+			}
+		})
+	}
+}
@@ -0,0 +1,305 @@
+// Package taxonomy generates the listing pages of the terms of a content collection, e.g. its
+// tags or categories: an index of the terms and a paginated page per term, with canonical URLs
+// and the thin pages kept out of the search engines.
+//
+//	tags, err := taxonomy.New(taxonomy.Config[Guide]{
+//		Collection: guides,
+//		Name:       "tags",
+//		BaseURL:    "https://example.com",
+//		Terms:      func(e *content.Entry[Guide]) []string { return e.Data.Tags },
+//		Item: func(e *content.Entry[Guide]) taxonomy.Item {
+//			return taxonomy.Item{Title: e.Data.Title, URL: "/guides/" + e.ID}
+//		},
+//	})
+//	...
+//	taxonomy.Serve(ws, tags) // /tags and /tags/{term}
+//
+// The terms are read from the collection on every request, so the pages follow the reloads of
+// the collection.
+package taxonomy
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/a-h/templ"
+	"github.com/ancalabrese/gotth/content"
+	"github.com/ancalabrese/gotth/views/components/head"
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Item is an entry listed on the page of a term.
+type Item struct {
+	Title       string
+	URL         string
+	Description string
+	Date        time.Time // Optional
+}
+
+// Config configures a [Taxonomy] of the entries of a collection with the front matter T.
+type Config[T any] struct {
+	Collection *content.Collection[T]
+	// Name of the taxonomy, e.g. "tags" or "categories". It names the routes and, unless Path is
+	// set, is their path.
+	Name string
+	// Optional: path of the index of the terms, e.g. "/topics". Defaults to "/" + Name.
+	Path string
+	// Optional: title of the index. Defaults to Name, capitalized.
+	Title string
+	// BaseURL of the site, e.g. "https://example.com", for the canonical URLs.
+	BaseURL string
+	// Terms returns the terms of an entry.
+	Terms func(*content.Entry[T]) []string
+	// Item returns how an entry is listed.
+	Item func(*content.Entry[T]) Item
+	// PageSize is the number of entries per page of a term. Defaults to 10.
+	PageSize int
+	// MinEntries is the number of entries under which the page of a term is thin, and marked
+	// noindex. Defaults to 2.
+	MinEntries int
+	// Optional: head options of every page, e.g. the site name and stylesheets.
+	Head []head.Option
+	// Optional: IndexView and TermView replace the default views, rendered in the layout of the
+	// WebServer.
+	IndexView func(IndexPage) templ.Component
+	TermView  func(TermPage) templ.Component
+}
+
+// Term is a term of a taxonomy.
+type Term struct {
+	// Name is the term as written in the first entry using it.
+	Name string
+	// Slug is the term in the URL, lowercase without accents, e.g. "web-dev" for "Web Dev".
+	// The terms are told apart by their slug.
+	Slug  string
+	Count int
+	URL   string
+}
+
+// IndexPage is the index of the terms.
+type IndexPage struct {
+	Title string
+	Terms []Term
+}
+
+// TermPage is a page of the entries of a term.
+type TermPage struct {
+	Title string
+	Term  Term
+	Items []Item
+	// URL is the path of the page, e.g. "/tags/go?page=2".
+	URL string
+	// Page is the number of the page, from 1, of Pages.
+	Page  int
+	Pages int
+	// PrevURL and NextURL link the previous and next pages, "" on the first and last ones.
+	PrevURL string
+	NextURL string
+	// Thin is set when the term has less than Config.MinEntries entries.
+	Thin bool
+}
+
+// Taxonomy serves the pages of the terms of a collection. It implements [Pages].
+type Taxonomy[T any] struct {
+	cfg Config[T]
+}
+
+// Pages are the pages of a taxonomy, served by [Serve].
+type Pages interface {
+	Name() string
+	Title() string
+	Path() string
+	// IndexProvider and TermProvider are the content providers of the index and of the page of
+	// the "term" path value.
+	IndexProvider(r *http.Request) (head.HeadViewModel, templ.Component, error)
+	TermProvider(r *http.Request) (head.HeadViewModel, templ.Component, error)
+	// TermTitle returns the name of the term of slug, for the breadcrumbs.
+	TermTitle(slug string) string
+}
+
+// New returns the Taxonomy of cfg.
+func New[T any](cfg Config[T]) (*Taxonomy[T], error) {
+	if cfg.Collection == nil || cfg.Name == "" || cfg.Terms == nil || cfg.Item == nil {
+		return nil, errors.New("taxonomy: Config.Collection, Name, Terms and Item are required")
+	}
+	cfg.Path = "/" + strings.Trim(cmp.Or(cfg.Path, cfg.Name), "/")
+	cfg.Title = cmp.Or(cfg.Title, strings.ToUpper(cfg.Name[:1])+cfg.Name[1:])
+	cfg.BaseURL = strings.TrimSuffix(cfg.BaseURL, "/")
+	if cfg.PageSize <= 0 {
+		cfg.PageSize = 10
+	}
+	if cfg.MinEntries <= 0 {
+		cfg.MinEntries = 2
+	}
+	return &Taxonomy[T]{cfg: cfg}, nil
+}
+
+// Name returns the name of the taxonomy.
+func (t *Taxonomy[T]) Name() string {
+	return t.cfg.Name
+}
+
+// Title returns the title of the index.
+func (t *Taxonomy[T]) Title() string {
+	return t.cfg.Title
+}
+
+// Path returns the path of the index, e.g. "/tags".
+func (t *Taxonomy[T]) Path() string {
+	return t.cfg.Path
+}
+
+// Terms returns the terms of the entries, sorted by slug.
+func (t *Taxonomy[T]) Terms() []Term {
+	terms, _ := t.group()
+	return terms
+}
+
+// TermTitle returns the name of the term of slug, or slug when there's none.
+func (t *Taxonomy[T]) TermTitle(slug string) string {
+	for _, term := range t.Terms() {
+		if term.Slug == slug {
+			return term.Name
+		}
+	}
+	return slug
+}
+
+// group returns the terms, sorted by slug, and the entries of each slug, in the order of the
+// collection.
+func (t *Taxonomy[T]) group() ([]Term, map[string][]*content.Entry[T]) {
+	bySlug := map[string]*Term{}
+	entries := map[string][]*content.Entry[T]{}
+	for _, e := range t.cfg.Collection.All() {
+		seen := map[string]bool{} // An entry listing a term twice counts once
+		for _, name := range t.cfg.Terms(e) {
+			slug := Slug(name)
+			if slug == "" || seen[slug] {
+				continue
+			}
+			seen[slug] = true
+			if bySlug[slug] == nil {
+				bySlug[slug] = &Term{Name: name, Slug: slug, URL: t.termURL(slug, 1)}
+			}
+			bySlug[slug].Count++
+			entries[slug] = append(entries[slug], e)
+		}
+	}
+	terms := make([]Term, 0, len(bySlug))
+	for _, term := range bySlug {
+		terms = append(terms, *term)
+	}
+	slices.SortFunc(terms, func(a, b Term) int { return strings.Compare(a.Slug, b.Slug) })
+	return terms, entries
+}
+
+// Page returns the page of the entries of the term of slug. ok is false for unknown terms and
+// pages out of range.
+func (t *Taxonomy[T]) Page(slug string, page int) (tp TermPage, ok bool) {
+	terms, entries := t.group()
+	i, found := slices.BinarySearchFunc(terms, slug, func(term Term, slug string) int { return strings.Compare(term.Slug, slug) })
+	if !found {
+		return TermPage{}, false
+	}
+	term, termEntries := terms[i], entries[slug]
+	pages := (len(termEntries) + t.cfg.PageSize - 1) / t.cfg.PageSize
+	if page < 1 || page > pages {
+		return TermPage{}, false
+	}
+
+	tp = TermPage{
+		Title: t.cfg.Title + ": " + term.Name,
+		Term:  term,
+		URL:   t.termURL(slug, page),
+		Page:  page,
+		Pages: pages,
+		Thin:  term.Count < t.cfg.MinEntries,
+	}
+	for _, e := range termEntries[(page-1)*t.cfg.PageSize : min(page*t.cfg.PageSize, len(termEntries))] {
+		tp.Items = append(tp.Items, t.cfg.Item(e))
+	}
+	if page > 1 {
+		tp.PrevURL = t.termURL(slug, page-1)
+	}
+	if page < pages {
+		tp.NextURL = t.termURL(slug, page+1)
+	}
+	return tp, true
+}
+
+func (t *Taxonomy[T]) termURL(slug string, page int) string {
+	u := t.cfg.Path + "/" + url.PathEscape(slug)
+	if page > 1 {
+		u += "?page=" + strconv.Itoa(page)
+	}
+	return u
+}
+
+// IndexProvider is the content provider of the index of the terms.
+func (t *Taxonomy[T]) IndexProvider(r *http.Request) (head.HeadViewModel, templ.Component, error) {
+	ip := IndexPage{Title: t.cfg.Title, Terms: t.Terms()}
+	opts := append(slices.Clone(t.cfg.Head), head.WithPageCoreMetadata(ip.Title, "", t.cfg.BaseURL+t.cfg.Path))
+
+	view := t.cfg.IndexView
+	if view == nil {
+		view = IndexView
+	}
+	return head.NewHeadViewModel(opts...), view(ip), nil
+}
+
+// TermProvider is the content provider of the page of the term of the "term" path value. The
+// page number is in the "page" query parameter. The term is matched by its slug, so /tags/Go
+// serves the page of "go", with the canonical URL of the slug. Thin pages are marked noindex.
+func (t *Taxonomy[T]) TermProvider(r *http.Request) (head.HeadViewModel, templ.Component, error) {
+	page := 1
+	if v := r.URL.Query().Get("page"); v != "" {
+		var err error
+		if page, err = strconv.Atoi(v); err != nil {
+			return head.HeadViewModel{}, nil, fmt.Errorf("invalid page %q. err %w", v, content.ErrNotFound)
+		}
+	}
+	slug := Slug(r.PathValue("term"))
+	tp, ok := t.Page(slug, page)
+	if !ok {
+		return head.HeadViewModel{}, nil, fmt.Errorf("no page %d of %s %q. err %w", page, t.cfg.Name, slug, content.ErrNotFound)
+	}
+
+	title := tp.Title
+	if page > 1 {
+		title += " (page " + strconv.Itoa(page) + ")"
+	}
+	opts := append(slices.Clone(t.cfg.Head), head.WithPageCoreMetadata(title, "", t.cfg.BaseURL+tp.URL))
+	if tp.Thin {
+		opts = append(opts, head.WithRobots("noindex, follow"))
+	}
+
+	view := t.cfg.TermView
+	if view == nil {
+		view = TermView
+	}
+	return head.NewHeadViewModel(opts...), view(tp), nil
+}
+
+// fold lowercases a term and removes its accents.
+var fold = transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), runes.Map(unicode.ToLower), norm.NFC)
+
+// Slug returns the slug of a term: lowercase without accents, its words joined by dashes, e.g.
+// "creme-brulee" for "Crème Brûlée".
+func Slug(term string) string {
+	folded, _, err := transform.String(fold, term)
+	if err != nil {
+		folded = strings.ToLower(term)
+	}
+	words := strings.FieldsFunc(folded, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsNumber(r) })
+	return strings.Join(words, "-")
+}
@@ -0,0 +1,131 @@
+package taxonomy_test
+
+import (
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/ancalabrese/gotth/content"
+	"github.com/ancalabrese/gotth/taxonomy"
+)
+
+type guide struct {
+	Title string   `yaml:"title"`
+	Tags  []string `yaml:"tags"`
+}
+
+func newTags(t *testing.T, pageSize int) *taxonomy.Taxonomy[guide] {
+	t.Helper()
+	guides, err := content.NewCollection(content.CollectionConfig[guide]{FS: fstest.MapFS{
+		"a.md": {Data: []byte("---\ntitle: A\ntags: [Go, Web Dev, go]\n---\n")},
+		"b.md": {Data: []byte("---\ntitle: B\ntags: [go]\n---\n")},
+		"c.md": {Data: []byte("---\ntitle: C\ntags: [Go, Crème Brûlée]\n---\n")},
+	}})
+	if err != nil {
+		t.Fatalf("NewCollection() error = %v", err)
+	}
+	tags, err := taxonomy.New(taxonomy.Config[guide]{
+		Collection: guides,
+		Name:       "tags",
+		BaseURL:    "https://example.com/",
+		PageSize:   pageSize,
+		Terms:      func(e *content.Entry[guide]) []string { return e.Data.Tags },
+		Item: func(e *content.Entry[guide]) taxonomy.Item {
+			return taxonomy.Item{Title: e.Data.Title, URL: "/guides/" + e.ID}
+		},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	return tags
+}
+
+func TestTaxonomy_Terms(t *testing.T) {
+	var got []string
+	for _, term := range newTags(t, 10).Terms() {
+		got = append(got, term.Name+"="+term.Slug+":"+term.URL)
+		if term.Slug == "go" && term.Count != 3 {
+			t.Errorf("go count = %d, want 3", term.Count)
+		}
+	}
+	want := "Crème Brûlée=creme-brulee:/tags/creme-brulee,Go=go:/tags/go,Web Dev=web-dev:/tags/web-dev"
+	if strings.Join(got, ",") != want {
+		t.Errorf("Terms() = %s, want %s", strings.Join(got, ","), want)
+	}
+
+	if _, err := taxonomy.New(taxonomy.Config[guide]{Name: "tags"}); err == nil {
+		t.Error("New() without a collection error = nil")
+	}
+}
+
+func TestTaxonomy_Page(t *testing.T) {
+	tags := newTags(t, 2)
+	tests := []struct {
+		name     string
+		slug     string
+		page     int
+		wantOK   bool
+		wantURL  string
+		wantPrev string
+		wantNext string
+		wantThin bool
+		wantN    int
+	}{
+		{name: "First page", slug: "go", page: 1, wantOK: true, wantURL: "/tags/go", wantNext: "/tags/go?page=2", wantN: 2},
+		{name: "Last page", slug: "go", page: 2, wantOK: true, wantURL: "/tags/go?page=2", wantPrev: "/tags/go", wantN: 1},
+		{name: "Thin term", slug: "web-dev", page: 1, wantOK: true, wantURL: "/tags/web-dev", wantThin: true, wantN: 1},
+		{name: "Out of range", slug: "go", page: 3},
+		{name: "Unknown term", slug: "rust", page: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tp, ok := tags.Page(tt.slug, tt.page)
+			if ok != tt.wantOK {
+				t.Fatalf("Page() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if tp.URL != tt.wantURL || tp.PrevURL != tt.wantPrev || tp.NextURL != tt.wantNext || tp.Thin != tt.wantThin || len(tp.Items) != tt.wantN {
+				t.Errorf("Page() = %+v", tp)
+			}
+		})
+	}
+}
+
+func TestTaxonomy_TermProvider(t *testing.T) {
+	tags := newTags(t, 10)
+	tests := []struct {
+		term          string
+		query         string
+		wantCanonical string
+		wantRobots    string
+		wantErr       error
+	}{
+		{term: "Go", wantCanonical: "https://example.com/tags/go"},
+		{term: "web-dev", wantCanonical: "https://example.com/tags/web-dev", wantRobots: "noindex, follow"},
+		{term: "go", query: "?page=2", wantErr: content.ErrNotFound},
+		{term: "go", query: "?page=x", wantErr: content.ErrNotFound},
+		{term: "rust", wantErr: content.ErrNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.term+tt.query, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/tags/"+tt.term+tt.query, nil)
+			r.SetPathValue("term", tt.term)
+			vm, _, err := tags.TermProvider(r)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("TermProvider() error = %v, want %v", err, tt.wantErr)
+			}
+			if vm.Metadata.URL != tt.wantCanonical || vm.Robots != tt.wantRobots {
+				t.Errorf("head = canonical %q, robots %q", vm.Metadata.URL, vm.Robots)
+			}
+		})
+	}
+}
+
+func TestSlug(t *testing.T) {
+	for term, want := range map[string]string{"Web Dev": "web-dev", "  C++ / Go ": "c-go", "Crème Brûlée": "creme-brulee", "日本語": "日本語", "!!": ""} {
+		if got := taxonomy.Slug(term); got != want {
+			t.Errorf("Slug(%q) = %q, want %q", term, got, want)
+		}
+	}
+}
@@ -0,0 +1,160 @@
+// Package theme adds a light/dark mode persisted in a cookie. The middleware reads the cookie so
+// that the layout renders the right class on the <html> element, and a small script in the head
+// resolves the "system" mode before the first paint, avoiding the flash of the wrong theme:
+//
+//	ws, _ := gotth.New(gotth.WebServerConfig{
+//		GlobalMiddlewares: []func(http.Handler) http.Handler{theme.Middleware},
+//	}, nil)
+//	ws.Handle("POST /theme", theme.Handler())
+//
+// and render @theme.Toggle("/theme") anywhere in the page. Tailwind must apply its dark variant
+// on the class rather than the media query:
+//
+//	@custom-variant dark (&:where(.dark, .dark *));
+//
+// With a Content-Security-Policy, allow the script with its hash:
+// csp.Add("script-src", theme.ScriptHash).
+package theme
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/a-h/templ"
+	"github.com/ancalabrese/gotth/htmx"
+	"github.com/ancalabrese/gotth/middlewares"
+)
+
+const (
+	// CookieName is the name of the cookie holding the Mode.
+	CookieName = "theme"
+	// CookieMaxAge is how long the choice is remembered.
+	CookieMaxAge = 365 * 24 * time.Hour
+	// FormField is the form field read by [Handler].
+	FormField = "theme"
+	// DarkClass is the class set on the <html> element in dark mode.
+	DarkClass = "dark"
+)
+
+// Mode is the color theme chosen by the user.
+type Mode string
+
+const (
+	Light Mode = "light"
+	Dark  Mode = "dark"
+	// System follows the color scheme of the operating system.
+	System Mode = "system"
+)
+
+// ParseMode returns the Mode named s, or System when s is not a valid mode.
+func ParseMode(s string) Mode {
+	switch m := Mode(s); m {
+	case Light, Dark:
+		return m
+	default:
+		return System
+	}
+}
+
+type themeCtxKey string
+
+const modeKey themeCtxKey = "theme-mode"
+
+// Middleware reads the Mode from the theme cookie and adds it to the request context.
+// Responses vary on the cookie, so that shared caches don't serve a page in the wrong theme.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mode := System
+		if c, err := r.Cookie(CookieName); err == nil {
+			mode = ParseMode(c.Value)
+		}
+		middlewares.AddVary(w.Header(), "Cookie")
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), modeKey, mode)))
+	})
+}
+
+// Current returns the Mode of the request, or System when [Middleware] is not in use.
+func Current(ctx context.Context) Mode {
+	if m, ok := ctx.Value(modeKey).(Mode); ok {
+		return m
+	}
+	return System
+}
+
+// enabled reports whether [Middleware] is in use.
+func enabled(ctx context.Context) bool {
+	_, ok := ctx.Value(modeKey).(Mode)
+	return ok
+}
+
+// Set stores mode in the theme cookie. The cookie is readable from JavaScript, since the script
+// updates it when the theme is toggled in the browser.
+func Set(w http.ResponseWriter, mode Mode) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     CookieName,
+		Value:    string(mode),
+		Path:     "/",
+		MaxAge:   int(CookieMaxAge.Seconds()),
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// Handler returns a handler that stores the mode submitted in [FormField], for the toggle to
+// work without JavaScript. It refreshes the page for HTMX requests and otherwise redirects back
+// to the referring page of the same site.
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Set(w, ParseMode(r.PostFormValue(FormField)))
+		if htmx.IsRequest(r) {
+			htmx.Refresh(w)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		http.Redirect(w, r, backURL(r), http.StatusSeeOther)
+	})
+}
+
+// backURL returns the path of the referring page when it belongs to the same host, or "/".
+func backURL(r *http.Request) string {
+	u, err := url.Parse(r.Referer())
+	if err != nil || u.Host != r.Host || u.Path == "" || u.Path[0] != '/' {
+		return "/"
+	}
+	return (&url.URL{Path: u.Path, RawQuery: u.RawQuery}).String()
+}
+
+// script applies the mode of the cookie to the <html> element, following the operating system
+// in system mode, and toggles it when an element with the data-theme-toggle attribute is
+// clicked.
+const script = `(function(){var d=document.documentElement,m=(document.cookie.match(/(?:^|; )theme=(light|dark|system)/)||[])[1]||"system",q=matchMedia("(prefers-color-scheme: dark)");` +
+	`function apply(){d.classList.toggle("dark",m==="dark"||(m==="system"&&q.matches));d.dataset.theme=m}` +
+	`apply();q.addEventListener("change",apply);` +
+	`document.addEventListener("click",function(e){var t=e.target.closest&&e.target.closest("[data-theme-toggle]");if(!t)return;e.preventDefault();` +
+	`m=d.classList.contains("dark")?"light":"dark";document.cookie="theme="+m+"; path=/; max-age=31536000; samesite=lax";apply()})})();`
+
+// ScriptHash allows [Script] under a Content-Security-Policy without 'unsafe-inline'.
+var ScriptHash = middlewares.CSPHash(script)
+
+// Script renders the inline script applying the theme. It renders nothing when [Middleware] is
+// not in use. The layout renders it at the top of the head, before any stylesheet.
+func Script() templ.Component {
+	return templ.ComponentFunc(func(ctx context.Context, w io.Writer) error {
+		if !enabled(ctx) {
+			return nil
+		}
+		_, err := io.WriteString(w, "<script>"+script+"</script>")
+		return err
+	})
+}
+
+// next returns the mode the toggle switches to, for the form submitted without JavaScript.
+// In system mode the server doesn't know the current color scheme and assumes light.
+func next(ctx context.Context) Mode {
+	if Current(ctx) == Dark {
+		return Light
+	}
+	return Dark
+}
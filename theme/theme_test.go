@@ -0,0 +1,140 @@
+package theme_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/a-h/templ"
+	"github.com/ancalabrese/gotth/theme"
+	"github.com/ancalabrese/gotth/views/components/head"
+	"github.com/ancalabrese/gotth/views/components/layout"
+)
+
+// renderPage serves the basic layout behind theme.Middleware, with the optional theme cookie.
+func renderPage(t *testing.T, cookie string) *httptest.ResponseRecorder {
+	t.Helper()
+	h := theme.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page := layout.BasicLayout(head.NewHeadViewModel(), theme.Toggle("/theme"))
+		if err := page.Render(r.Context(), w); err != nil {
+			t.Fatalf("Render() error = %v", err)
+		}
+	}))
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if cookie != "" {
+		req.AddCookie(&http.Cookie{Name: theme.CookieName, Value: cookie})
+	}
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestMiddleware(t *testing.T) {
+	tests := []struct {
+		name     string
+		cookie   string
+		want     []string
+		dontWant []string
+	}{
+		{
+			name:     "No cookie",
+			want:     []string{`<html class="h-full bg-white scroll-smooth"`, "<script>", `value="dark" data-theme-toggle`},
+			dontWant: []string{" dark\""},
+		},
+		{
+			name:   "Dark",
+			cookie: "dark",
+			want:   []string{`<html class="h-full bg-white scroll-smooth dark"`, `value="light" data-theme-toggle`},
+		},
+		{
+			name:     "Invalid cookie",
+			cookie:   "purple",
+			want:     []string{`value="dark"`},
+			dontWant: []string{"scroll-smooth dark"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := renderPage(t, tt.cookie)
+			body := rr.Body.String()
+			for _, w := range tt.want {
+				if !strings.Contains(body, w) {
+					t.Errorf("body does not contain %q\nbody: %s", w, body)
+				}
+			}
+			for _, d := range tt.dontWant {
+				if strings.Contains(body, d) {
+					t.Errorf("body unexpectedly contains %q", d)
+				}
+			}
+			if vary := rr.Header().Get("Vary"); vary != "Cookie" {
+				t.Errorf("Vary = %q, want %q", vary, "Cookie")
+			}
+			// The script must run before the stylesheets.
+			if strings.Index(body, "<script>") > strings.Index(body, "<meta name=\"viewport\"") {
+				t.Errorf("theme script is not at the top of the head: %s", body)
+			}
+		})
+	}
+}
+
+func TestScript_WithoutMiddleware(t *testing.T) {
+	var b strings.Builder
+	layout.BasicLayout(head.NewHeadViewModel(), templ.NopComponent).Render(context.Background(), &b)
+	if strings.Contains(b.String(), "data-theme-toggle") || strings.Contains(b.String(), "<script>(function") {
+		t.Errorf("theme script rendered without the middleware: %s", b.String())
+	}
+	if theme.Current(context.Background()) != theme.System {
+		t.Errorf("Current() = %q, want %q", theme.Current(context.Background()), theme.System)
+	}
+	if !strings.HasPrefix(theme.ScriptHash, "'sha256-") {
+		t.Errorf("ScriptHash = %q", theme.ScriptHash)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	tests := []struct {
+		name         string
+		value        string
+		referer      string
+		htmx         bool
+		wantStatus   int
+		wantLocation string
+		wantCookie   string
+	}{
+		{name: "Redirect back", value: "dark", referer: "http://example.com/blog?page=2", wantStatus: http.StatusSeeOther, wantLocation: "/blog?page=2", wantCookie: "dark"},
+		{name: "Foreign referer", value: "light", referer: "https://evil.example/phish", wantStatus: http.StatusSeeOther, wantLocation: "/", wantCookie: "light"},
+		{name: "Invalid mode", value: "purple", wantStatus: http.StatusSeeOther, wantLocation: "/", wantCookie: "system"},
+		{name: "HTMX refresh", value: "dark", htmx: true, wantStatus: http.StatusNoContent, wantCookie: "dark"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "http://example.com/theme", strings.NewReader(url.Values{theme.FormField: {tt.value}}.Encode()))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			if tt.referer != "" {
+				req.Header.Set("Referer", tt.referer)
+			}
+			if tt.htmx {
+				req.Header.Set("HX-Request", "true")
+			}
+			rr := httptest.NewRecorder()
+			theme.Handler().ServeHTTP(rr, req)
+
+			if rr.Code != tt.wantStatus || rr.Header().Get("Location") != tt.wantLocation {
+				t.Errorf("status = %d, location = %q, want %d, %q", rr.Code, rr.Header().Get("Location"), tt.wantStatus, tt.wantLocation)
+			}
+			if tt.htmx && rr.Header().Get("HX-Refresh") != "true" {
+				t.Errorf("HX-Refresh = %q, want true", rr.Header().Get("HX-Refresh"))
+			}
+			cookies := rr.Result().Cookies()
+			if len(cookies) != 1 || cookies[0].Name != theme.CookieName || cookies[0].Value != tt.wantCookie || cookies[0].HttpOnly {
+				t.Errorf("cookies = %v, want %s=%s", cookies, theme.CookieName, tt.wantCookie)
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+// Package toast shows notifications from Go code. Handlers queue toasts on the response as an
+// HX-Trigger event, and the [Container] component, rendered once in the layout, displays them:
+//
+//	func save(w http.ResponseWriter, r *http.Request) {
+//		if err := store.Save(r.Context(), item); err != nil {
+//			toast.Error(w, "Couldn't save the item")
+//			return
+//		}
+//		toast.Success(w, "Saved!")
+//		views.Item(item).Render(r.Context(), w)
+//	}
+//
+// The container uses Alpine.js (see head.WithAlpine).
+package toast
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/ancalabrese/gotth/htmx"
+)
+
+// EventName is the name of the HTMX event carrying the toasts.
+const EventName = "gotth-toast"
+
+// Level is the severity of a toast.
+type Level string
+
+const (
+	LevelInfo    Level = "info"
+	LevelSuccess Level = "success"
+	LevelWarning Level = "warning"
+	LevelError   Level = "error"
+)
+
+// Toast is a notification.
+type Toast struct {
+	Level   Level  `json:"level"`
+	Title   string `json:"title,omitempty"`
+	Message string `json:"message"`
+}
+
+// detail is the detail of the toast event.
+type detail struct {
+	Toasts []Toast `json:"toasts"`
+}
+
+// Add queues toasts on the response, after the ones already queued. It must be called before
+// the response is written.
+func Add(w http.ResponseWriter, toasts ...Toast) error {
+	var d detail
+	if existing := strings.TrimSpace(w.Header().Get("HX-Trigger")); strings.HasPrefix(existing, "{") {
+		var events map[string]json.RawMessage
+		if err := json.Unmarshal([]byte(existing), &events); err != nil {
+			return fmt.Errorf("failed to parse HX-Trigger. err %w", err)
+		}
+		if raw, ok := events[EventName]; ok {
+			if err := json.Unmarshal(raw, &d); err != nil {
+				return fmt.Errorf("failed to parse queued toasts. err %w", err)
+			}
+		}
+	}
+	d.Toasts = append(d.Toasts, toasts...)
+	return htmx.Trigger(w, htmx.Event{Name: EventName, Detail: d})
+}
+
+// Info queues an informational toast.
+func Info(w http.ResponseWriter, message string) error {
+	return Add(w, Toast{Level: LevelInfo, Message: message})
+}
+
+// Success queues a success toast.
+func Success(w http.ResponseWriter, message string) error {
+	return Add(w, Toast{Level: LevelSuccess, Message: message})
+}
+
+// Warning queues a warning toast.
+func Warning(w http.ResponseWriter, message string) error {
+	return Add(w, Toast{Level: LevelWarning, Message: message})
+}
+
+// Error queues an error toast.
+func Error(w http.ResponseWriter, message string) error {
+	return Add(w, Toast{Level: LevelError, Message: message})
+}
+
+// alpineData returns the Alpine state of the container, dismissing toasts after dismissAfter
+// milliseconds, or never if zero.
+func alpineData(dismissAfter int64) string {
+	return fmt.Sprintf(`{
+	toasts: [],
+	next: 0,
+	add(toasts) {
+		for (const t of toasts || []) {
+			const id = this.next++;
+			this.toasts.push({ ...t, id });
+			if (%[1]d > 0) setTimeout(() => this.remove(id), %[1]d);
+		}
+	},
+	remove(id) { this.toasts = this.toasts.filter(t => t.id !== id); }
+}`, dismissAfter)
+}
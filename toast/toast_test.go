@@ -0,0 +1,42 @@
+package toast_test
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ancalabrese/gotth/htmx"
+	"github.com/ancalabrese/gotth/toast"
+)
+
+func TestAdd(t *testing.T) {
+	rr := httptest.NewRecorder()
+	if err := htmx.Trigger(rr, htmx.Event{Name: "itemSaved", Detail: 42}); err != nil {
+		t.Fatal(err)
+	}
+	if err := toast.Success(rr, "Saved!"); err != nil {
+		t.Fatalf("Success() error = %v", err)
+	}
+	if err := toast.Add(rr, toast.Toast{Level: toast.LevelWarning, Title: "Quota", Message: "90% used"}); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+
+	expected := `{"gotth-toast":{"toasts":[{"level":"success","message":"Saved!"},{"level":"warning","title":"Quota","message":"90% used"}]},"itemSaved":42}`
+	if got := rr.Header().Get("HX-Trigger"); got != expected {
+		t.Errorf("HX-Trigger = %s, want %s", got, expected)
+	}
+}
+
+func TestContainer(t *testing.T) {
+	var b strings.Builder
+	if err := toast.Container(5*time.Second).Render(context.Background(), &b); err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	for _, want := range []string{`id="gotth-toasts"`, `@gotth-toast.window="add($event.detail.toasts)"`, `setTimeout(() =&gt; this.remove(id), 5000)`, `x-for="t in toasts"`} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("container does not contain %s\n%s", want, b.String())
+		}
+	}
+}
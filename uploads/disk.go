@@ -0,0 +1,79 @@
+package uploads
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// ErrInvalidKey is returned by DiskStorage for keys escaping its directory.
+var ErrInvalidKey = errors.New("invalid storage key")
+
+// DiskStorage stores files in a directory. Files are written to a temporary file first and
+// moved in place once complete, so that readers never see partial uploads.
+type DiskStorage struct {
+	dir string
+}
+
+// NewDiskStorage returns a DiskStorage writing to dir, which is created if missing.
+func NewDiskStorage(dir string) (*DiskStorage, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create upload directory. err %w", err)
+	}
+	return &DiskStorage{dir: dir}, nil
+}
+
+// Save writes r to the file named key.
+func (d *DiskStorage) Save(ctx context.Context, key string, r io.Reader, contentType string) error {
+	path, err := d.path(key)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(d.dir, ".upload-*")
+	if err != nil {
+		return fmt.Errorf("failed to create file. err %w", err)
+	}
+	defer os.Remove(tmp.Name()) // No-op once renamed
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write file. err %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to store file. err %w", err)
+	}
+	return nil
+}
+
+// Open opens the file named key.
+func (d *DiskStorage) Open(key string) (*os.File, error) {
+	path, err := d.path(key)
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(path)
+}
+
+// Delete removes the file named key.
+func (d *DiskStorage) Delete(key string) error {
+	path, err := d.path(key)
+	if err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
+
+// path returns the path of key, which must be a plain file name.
+func (d *DiskStorage) path(key string) (string, error) {
+	if key == "" || key != filepath.Base(key) || key[0] == '.' {
+		return "", ErrInvalidKey
+	}
+	return filepath.Join(d.dir, key), nil
+}
@@ -0,0 +1,344 @@
+// Package uploads receives files from multipart forms and streams them to a pluggable Storage,
+// enforcing size limits and an allowlist of content types sniffed from the file content
+// (the type declared by the browser is ignored):
+//
+//	store, _ := uploads.NewDiskStorage("./data/uploads")
+//	cfg := uploads.DefaultConfig()
+//	cfg.AllowedTypes = []string{"image/png", "image/jpeg"}
+//
+//	func upload(w http.ResponseWriter, r *http.Request) {
+//		res, err := uploads.Receive(w, r, store, cfg)
+//		if err != nil { ... }
+//		views.Uploaded(res.Files).Render(r.Context(), w)
+//	}
+//
+// Files are streamed to the storage part by part, without buffering them in memory or on disk,
+// so the upload progress reported by the browser (see [ProgressAttributes]) is the real one.
+// That requires the body to reach Receive unread: don't call r.FormValue or r.ParseMultipartForm
+// before it, and place it behind middlewares that leave multipart bodies alone, e.g.
+// csrf.Protect. When the form was already parsed, the files are read from r.MultipartForm, where
+// net/http buffered them in memory or in temporary files.
+package uploads
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/a-h/templ"
+)
+
+const (
+	DefaultField    = "file"
+	DefaultMaxSize  = 10 << 20
+	DefaultMaxFiles = 1
+
+	// maxValuesSize caps the total size of the non-file fields of the form.
+	maxValuesSize = 1 << 20
+	// sniffLen is the number of bytes used by http.DetectContentType.
+	sniffLen = 512
+)
+
+var (
+	// ErrNoFile is returned when the request carries no file.
+	ErrNoFile = errors.New("no file uploaded")
+	// ErrTooLarge is returned when a file exceeds Config.MaxSize.
+	ErrTooLarge = errors.New("file too large")
+	// ErrTooManyFiles is returned when the request carries more than Config.MaxFiles files.
+	ErrTooManyFiles = errors.New("too many files")
+	// ErrTypeNotAllowed is returned when the content of a file isn't in Config.AllowedTypes.
+	ErrTypeNotAllowed = errors.New("file type not allowed")
+)
+
+// Storage stores uploaded files. Save must consume r until EOF or an error and must not keep
+// the file when reading r fails, e.g. because the file exceeds the size limit.
+type Storage interface {
+	Save(ctx context.Context, key string, r io.Reader, contentType string) error
+}
+
+// StorageFunc adapts a function to a Storage, e.g. to upload to an S3-compatible bucket:
+//
+//	uploads.StorageFunc(func(ctx context.Context, key string, r io.Reader, contentType string) error {
+//		_, err := client.PutObject(ctx, "uploads", key, r, -1, minio.PutObjectOptions{ContentType: contentType})
+//		return err
+//	})
+type StorageFunc func(ctx context.Context, key string, r io.Reader, contentType string) error
+
+// Save calls f.
+func (f StorageFunc) Save(ctx context.Context, key string, r io.Reader, contentType string) error {
+	return f(ctx, key, r, contentType)
+}
+
+// Config configures Receive. Use [DefaultConfig] as a starting point.
+type Config struct {
+	// Field is the name of the file input. Other file inputs are rejected.
+	Field string
+	// MaxSize is the maximum size in bytes of each file.
+	MaxSize int64
+	// MaxFiles is the maximum number of files per request.
+	MaxFiles int
+	// AllowedTypes lists the accepted content types, as sniffed by http.DetectContentType
+	// (e.g., "image/png", or "image/*" for any image). Any type is accepted when empty.
+	AllowedTypes []string
+	// Key returns the storage key of a file. Defaults to a random name keeping the extension of
+	// the sniffed content type, so that user supplied names never reach the storage.
+	Key func(r *http.Request, filename, contentType string) string
+}
+
+// DefaultConfig returns the default Config: a single file of up to 10MB named "file", of any
+// type.
+func DefaultConfig() Config {
+	return Config{
+		Field:    DefaultField,
+		MaxSize:  DefaultMaxSize,
+		MaxFiles: DefaultMaxFiles,
+		Key:      RandomKey,
+	}
+}
+
+// File describes a stored file.
+type File struct {
+	Key string
+	// Filename is the name of the file on the user's device. Don't trust it.
+	Filename    string
+	ContentType string
+	Size        int64
+}
+
+// Result is the outcome of Receive.
+type Result struct {
+	Files []File
+	// Values holds the other fields of the form.
+	Values url.Values
+}
+
+// Receive streams the files of the multipart form of r to store. It fails on the first
+// invalid file, with ErrNoFile, ErrTooLarge, ErrTooManyFiles or ErrTypeNotAllowed; the files
+// stored before it are returned with the error so that they can be removed.
+// w is used to cap the size of the request body. Zero values in cfg fall back to the defaults.
+func Receive(w http.ResponseWriter, r *http.Request, store Storage, cfg Config) (Result, error) {
+	res := Result{Values: url.Values{}}
+	if cfg.Field == "" {
+		cfg.Field = DefaultField
+	}
+	if cfg.MaxSize <= 0 {
+		cfg.MaxSize = DefaultMaxSize
+	}
+	if cfg.MaxFiles <= 0 {
+		cfg.MaxFiles = DefaultMaxFiles
+	}
+	if cfg.Key == nil {
+		cfg.Key = RandomKey
+	}
+	if r.MultipartForm != nil {
+		return receiveParsed(r, store, cfg)
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, cfg.MaxSize*int64(cfg.MaxFiles)+maxValuesSize)
+	mr, err := r.MultipartReader()
+	if err != nil {
+		return res, fmt.Errorf("failed to read multipart form. err %w", err)
+	}
+
+	valuesSize := int64(0)
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return res, fmt.Errorf("failed to read multipart form. err %w", err)
+		}
+
+		if part.FileName() == "" {
+			// A regular form field.
+			n, _ := io.ReadAll(io.LimitReader(part, maxValuesSize-valuesSize+1))
+			valuesSize += int64(len(n))
+			if valuesSize > maxValuesSize {
+				return res, fmt.Errorf("form fields too large")
+			}
+			res.Values.Add(part.FormName(), string(n))
+			continue
+		}
+
+		if part.FormName() != cfg.Field {
+			return res, fmt.Errorf("unexpected file field %q", part.FormName())
+		}
+		if len(res.Files) == cfg.MaxFiles {
+			return res, ErrTooManyFiles
+		}
+		f, err := save(r, part, part.FileName(), store, cfg)
+		if err != nil {
+			return res, err
+		}
+		res.Files = append(res.Files, f)
+	}
+
+	if len(res.Files) == 0 {
+		return res, ErrNoFile
+	}
+	return res, nil
+}
+
+// receiveParsed is Receive for a form already parsed by r.ParseMultipartForm.
+func receiveParsed(r *http.Request, store Storage, cfg Config) (Result, error) {
+	res := Result{Values: url.Values(r.MultipartForm.Value)}
+	for field := range r.MultipartForm.File {
+		if field != cfg.Field {
+			return res, fmt.Errorf("unexpected file field %q", field)
+		}
+	}
+
+	for _, fh := range r.MultipartForm.File[cfg.Field] {
+		if len(res.Files) == cfg.MaxFiles {
+			return res, ErrTooManyFiles
+		}
+		if fh.Size > cfg.MaxSize {
+			return res, ErrTooLarge
+		}
+		part, err := fh.Open()
+		if err != nil {
+			return res, fmt.Errorf("failed to open file. err %w", err)
+		}
+		f, err := save(r, part, fh.Filename, store, cfg)
+		part.Close()
+		if err != nil {
+			return res, err
+		}
+		res.Files = append(res.Files, f)
+	}
+
+	if len(res.Files) == 0 {
+		return res, ErrNoFile
+	}
+	return res, nil
+}
+
+func save(r *http.Request, part io.Reader, filename string, store Storage, cfg Config) (File, error) {
+	limited := &limitReader{r: part, n: cfg.MaxSize}
+	br := bufio.NewReaderSize(limited, sniffLen)
+	head, err := br.Peek(sniffLen)
+	if err != nil && err != io.EOF {
+		if errors.Is(err, ErrTooLarge) {
+			return File{}, ErrTooLarge
+		}
+		return File{}, fmt.Errorf("failed to read file. err %w", err)
+	}
+	if len(head) == 0 {
+		return File{}, ErrNoFile
+	}
+
+	contentType := http.DetectContentType(head)
+	if !allowed(cfg.AllowedTypes, contentType) {
+		return File{}, fmt.Errorf("%w: %s", ErrTypeNotAllowed, contentType)
+	}
+
+	f := File{Key: cfg.Key(r, filename, contentType), Filename: filename, ContentType: contentType}
+	counter := &countReader{r: br}
+	if err := store.Save(r.Context(), f.Key, counter, contentType); err != nil {
+		if errors.Is(err, ErrTooLarge) {
+			return File{}, ErrTooLarge
+		}
+		return File{}, fmt.Errorf("failed to store file. err %w", err)
+	}
+	f.Size = counter.n
+	return f, nil
+}
+
+// allowed reports whether contentType matches one of the allowed types.
+func allowed(types []string, contentType string) bool {
+	if len(types) == 0 {
+		return true
+	}
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	for _, t := range types {
+		if prefix, ok := strings.CutSuffix(t, "/*"); ok {
+			if strings.HasPrefix(mediaType, prefix+"/") {
+				return true
+			}
+		} else if mediaType == t {
+			return true
+		}
+	}
+	return false
+}
+
+// extensions are the preferred extensions of common types, for which mime.ExtensionsByType
+// returns several.
+var extensions = map[string]string{
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+	"image/gif":       ".gif",
+	"image/webp":      ".webp",
+	"application/pdf": ".pdf",
+	"text/plain":      ".txt",
+	"video/mp4":       ".mp4",
+}
+
+// RandomKey returns a random file name with the extension of contentType. The user supplied
+// filename isn't used.
+func RandomKey(r *http.Request, filename, contentType string) string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	key := hex.EncodeToString(b)
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if ext, ok := extensions[mediaType]; ok {
+		return key + ext
+	}
+	if exts, _ := mime.ExtensionsByType(mediaType); len(exts) > 0 {
+		return key + exts[0]
+	}
+	return key
+}
+
+// limitReader reads up to n bytes, then fails with ErrTooLarge if more remain.
+type limitReader struct {
+	r io.Reader
+	n int64
+}
+
+func (l *limitReader) Read(p []byte) (int, error) {
+	if l.n < 0 {
+		return 0, ErrTooLarge
+	}
+	// Read one byte past the limit to tell a file of exactly n bytes from a larger one.
+	if int64(len(p)) > l.n+1 {
+		p = p[:l.n+1]
+	}
+	n, err := l.r.Read(p)
+	l.n -= int64(n)
+	if l.n < 0 {
+		return 0, ErrTooLarge
+	}
+	return n, err
+}
+
+type countReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// ProgressAttributes returns the attributes of an HTMX upload form sending the files as
+// multipart and reporting the upload progress to the [Progress] bar with id progressID.
+// hx-on handlers are evaluated by HTMX, which requires 'unsafe-eval' in the CSP script-src.
+func ProgressAttributes(progressID string) templ.Attributes {
+	return templ.Attributes{
+		"hx-encoding":         "multipart/form-data",
+		"hx-on::xhr:progress": fmt.Sprintf("htmx.find('#%s').value = event.detail.loaded / event.detail.total * 100", progressID),
+	}
+}
@@ -0,0 +1,183 @@
+package uploads_test
+
+import (
+	"bytes"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ancalabrese/gotth/uploads"
+)
+
+var pngHeader = []byte("\x89PNG\x0D\x0A\x1A\x0A")
+
+type part struct {
+	field, filename string
+	content         []byte
+}
+
+func multipartRequest(t *testing.T, parts ...part) *http.Request {
+	t.Helper()
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	for _, p := range parts {
+		var err error
+		if p.filename == "" {
+			err = mw.WriteField(p.field, string(p.content))
+		} else {
+			var w interface{ Write([]byte) (int, error) }
+			w, err = mw.CreateFormFile(p.field, p.filename)
+			if err == nil {
+				_, err = w.Write(p.content)
+			}
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	mw.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/upload", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	return req
+}
+
+func TestReceive(t *testing.T) {
+	png := append(pngHeader, bytes.Repeat([]byte{0}, 100)...)
+
+	tests := []struct {
+		name      string
+		parts     []part
+		configure func(*uploads.Config)
+		wantErr   error
+		wantFiles int
+	}{
+		{
+			name:      "Valid image",
+			parts:     []part{{field: "title", content: []byte("Holiday")}, {field: "file", filename: "photo.png", content: png}},
+			configure: func(c *uploads.Config) { c.AllowedTypes = []string{"image/*"} },
+			wantFiles: 1,
+		},
+		{
+			name:      "Exactly the maximum size",
+			parts:     []part{{field: "file", filename: "photo.png", content: png}},
+			configure: func(c *uploads.Config) { c.MaxSize = int64(len(png)) },
+			wantFiles: 1,
+		},
+		{
+			name:      "Too large",
+			parts:     []part{{field: "file", filename: "photo.png", content: png}},
+			configure: func(c *uploads.Config) { c.MaxSize = int64(len(png)) - 1 },
+			wantErr:   uploads.ErrTooLarge,
+		},
+		{
+			name:      "Type not allowed despite the extension",
+			parts:     []part{{field: "file", filename: "photo.png", content: []byte("<html><script>alert(1)</script>")}},
+			configure: func(c *uploads.Config) { c.AllowedTypes = []string{"image/png"} },
+			wantErr:   uploads.ErrTypeNotAllowed,
+		},
+		{
+			// The files stored before the error are returned so that they can be removed.
+			name:      "Too many files",
+			parts:     []part{{field: "file", filename: "photo.png", content: png}, {field: "file", filename: "b.png", content: png}},
+			wantErr:   uploads.ErrTooManyFiles,
+			wantFiles: 1,
+		},
+		{
+			name:      "Zero config uses the defaults",
+			parts:     []part{{field: "file", filename: "photo.png", content: png}},
+			configure: func(c *uploads.Config) { *c = uploads.Config{} },
+			wantFiles: 1,
+		},
+		{
+			name:    "No file",
+			parts:   []part{{field: "title", content: []byte("Holiday")}},
+			wantErr: uploads.ErrNoFile,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			store, err := uploads.NewDiskStorage(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			cfg := uploads.DefaultConfig()
+			if tt.configure != nil {
+				tt.configure(&cfg)
+			}
+
+			res, err := uploads.Receive(httptest.NewRecorder(), multipartRequest(t, tt.parts...), store, cfg)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Receive() error = %v, want %v", err, tt.wantErr)
+			}
+			if len(res.Files) != tt.wantFiles {
+				t.Fatalf("len(Files) = %d, want %d", len(res.Files), tt.wantFiles)
+			}
+
+			entries, _ := os.ReadDir(dir)
+			if len(entries) != tt.wantFiles {
+				t.Errorf("stored %d files, want %d (partial uploads must be removed)", len(entries), tt.wantFiles)
+			}
+			if tt.wantFiles == 0 {
+				return
+			}
+
+			f := res.Files[0]
+			if f.ContentType != "image/png" || f.Size != int64(len(png)) || f.Filename != "photo.png" || !strings.HasSuffix(f.Key, ".png") {
+				t.Errorf("File = %+v", f)
+			}
+			stored, err := os.ReadFile(filepath.Join(dir, f.Key))
+			if err != nil || !bytes.Equal(stored, png) {
+				t.Errorf("stored content differs, err %v", err)
+			}
+			if tt.parts[0].field == "title" && res.Values.Get("title") != "Holiday" {
+				t.Errorf("Values[title] = %q, want %q", res.Values.Get("title"), "Holiday")
+			}
+		})
+	}
+}
+
+func TestReceive_ParsedForm(t *testing.T) {
+	png := append(pngHeader, bytes.Repeat([]byte{0}, 100)...)
+	store, err := uploads.NewDiskStorage(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := uploads.DefaultConfig()
+
+	// A middleware already parsed the body: the files are read from r.MultipartForm.
+	req := multipartRequest(t, part{field: "title", content: []byte("Holiday")}, part{field: "file", filename: "photo.png", content: png})
+	if err := req.ParseMultipartForm(1 << 20); err != nil {
+		t.Fatal(err)
+	}
+	res, err := uploads.Receive(httptest.NewRecorder(), req, store, cfg)
+	if err != nil || len(res.Files) != 1 || res.Files[0].ContentType != "image/png" || res.Values.Get("title") != "Holiday" {
+		t.Fatalf("Receive() = %+v, %v", res, err)
+	}
+
+	cfg.MaxSize = int64(len(png)) - 1
+	req = multipartRequest(t, part{field: "file", filename: "photo.png", content: png})
+	req.ParseMultipartForm(1 << 20)
+	if _, err := uploads.Receive(httptest.NewRecorder(), req, store, cfg); !errors.Is(err, uploads.ErrTooLarge) {
+		t.Errorf("Receive() error = %v, want %v", err, uploads.ErrTooLarge)
+	}
+}
+
+func TestDiskStorage_InvalidKey(t *testing.T) {
+	store, err := uploads.NewDiskStorage(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"", "../escape", "sub/file", ".hidden"} {
+		if err := store.Save(t.Context(), key, strings.NewReader("x"), "text/plain"); !errors.Is(err, uploads.ErrInvalidKey) {
+			t.Errorf("Save(%q) error = %v, want %v", key, err, uploads.ErrInvalidKey)
+		}
+	}
+}
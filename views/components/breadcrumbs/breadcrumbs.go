@@ -0,0 +1,25 @@
+// Package breadcrumbs renders the breadcrumb trail of the current page, built from the route
+// titles set with WebServer.SetRouteMeta:
+//
+//	@breadcrumbs.Breadcrumbs()
+//
+// renders Home › Blog › First post on /blog/first-post. ServeContent adds the matching
+// BreadcrumbList JSON-LD to the head.
+package breadcrumbs
+
+import (
+	"context"
+
+	"github.com/ancalabrese/gotth/nav"
+	"github.com/ancalabrese/gotth/routes"
+)
+
+// Trail returns the breadcrumb trail of the current page, or nil outside of a WebServer.
+func Trail(ctx context.Context) []routes.Breadcrumb {
+	reg := routes.FromContext(ctx)
+	path := nav.Path(ctx)
+	if reg == nil || path == "" {
+		return nil
+	}
+	return reg.Trail(ctx, path)
+}
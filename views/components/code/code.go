@@ -0,0 +1,214 @@
+// Package code renders syntax highlighted code blocks on the server with chroma, so that docs
+// and technical blogs don't need a client-side highlighter:
+//
+//	@code.Block(src, "go")
+//
+// A [Highlighter] is also a content.Highlighter, highlighting the fenced code blocks of
+// Markdown:
+//
+//	md := content.NewMarkdown(content.WithHighlighter(code.New(code.WithTheme("dracula"))))
+//
+// The tokens are colored by CSS classes: serve the stylesheet of the themes, e.g.
+// ws.Handle("GET /static/code.css", code.Default.StylesheetHandler()), or render
+// @code.Default.Styles() in the head. The dark theme applies under the "dark" class of the theme
+// package. The copy buttons of the blocks need @code.CopyScript() once in the page; with a
+// Content-Security-Policy, allow it with csp.Add("script-src", code.CopyScriptHash).
+package code
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/a-h/templ"
+	"github.com/alecthomas/chroma/v2"
+	chromahtml "github.com/alecthomas/chroma/v2/formatters/html"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/alecthomas/chroma/v2/styles"
+	"github.com/ancalabrese/gotth/middlewares"
+	"github.com/ancalabrese/gotth/theme"
+)
+
+const (
+	// DefaultTheme is the chroma style of the code blocks.
+	DefaultTheme = "github"
+	// DefaultDarkTheme is the chroma style of the code blocks in dark mode.
+	DefaultDarkTheme = "github-dark"
+)
+
+// Highlighter highlights code with chroma. It's safe for concurrent use.
+type Highlighter struct {
+	opts      options
+	formatter *chromahtml.Formatter
+	css       func() string
+}
+
+type options struct {
+	theme       string
+	darkTheme   string
+	lineNumbers bool
+	copyButton  bool
+	tabWidth    int
+}
+
+// Option configures a [Highlighter].
+type Option func(*options)
+
+// WithTheme sets the chroma style of the code blocks, e.g. "monokai". Unknown styles fall back
+// to the chroma default. Defaults to [DefaultTheme].
+func WithTheme(name string) Option {
+	return func(o *options) {
+		o.theme = name
+	}
+}
+
+// WithDarkTheme sets the chroma style of the code blocks in dark mode, "" to keep the light one.
+// Defaults to [DefaultDarkTheme].
+func WithDarkTheme(name string) Option {
+	return func(o *options) {
+		o.darkTheme = name
+	}
+}
+
+// WithLineNumbers numbers the lines of the code blocks.
+func WithLineNumbers() Option {
+	return func(o *options) {
+		o.lineNumbers = true
+	}
+}
+
+// WithoutCopyButton leaves the copy button out of the code blocks.
+func WithoutCopyButton() Option {
+	return func(o *options) {
+		o.copyButton = false
+	}
+}
+
+// WithTabWidth sets the number of spaces of a tab. Defaults to 4.
+func WithTabWidth(n int) Option {
+	return func(o *options) {
+		o.tabWidth = n
+	}
+}
+
+// New returns a Highlighter with the options.
+func New(opts ...Option) *Highlighter {
+	o := options{theme: DefaultTheme, darkTheme: DefaultDarkTheme, copyButton: true, tabWidth: 4}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	h := &Highlighter{
+		opts: o,
+		formatter: chromahtml.New(
+			chromahtml.WithClasses(true),
+			chromahtml.WithCSSComments(false),
+			chromahtml.WithLineNumbers(o.lineNumbers),
+			chromahtml.TabWidth(o.tabWidth),
+		),
+	}
+	h.css = sync.OnceValue(h.stylesheet)
+	return h
+}
+
+// Default is the Highlighter of [Block], with the default options.
+var Default = New()
+
+// Block renders code highlighted as lang with the [Default] Highlighter.
+func Block(code, lang string) templ.Component {
+	return Default.Block(code, lang)
+}
+
+// Block renders code highlighted as lang, e.g. "go" or "html". Code of an unknown or empty lang
+// is rendered as plain text.
+func (h *Highlighter) Block(code, lang string) templ.Component {
+	return templ.ComponentFunc(func(ctx context.Context, w io.Writer) error {
+		var out bytes.Buffer
+		if err := h.format(&out, code, lang); err != nil {
+			return err
+		}
+		return block(out.String(), lang, h.opts.copyButton).Render(ctx, w)
+	})
+}
+
+// Highlight writes the code block of code, implementing content.Highlighter.
+func (h *Highlighter) Highlight(w io.Writer, code, lang string) error {
+	return h.Block(code, lang).Render(context.Background(), w)
+}
+
+func (h *Highlighter) format(w io.Writer, code, lang string) error {
+	lexer := lexers.Get(lang)
+	if lexer == nil || lang == "" {
+		lexer = lexers.Fallback
+	}
+	tokens, err := chroma.Coalesce(lexer).Tokenise(nil, code)
+	if err != nil {
+		return err
+	}
+	return h.formatter.Format(w, styles.Get(h.opts.theme), tokens)
+}
+
+// CSS returns the stylesheet of the code blocks: the classes of the theme, of the dark theme
+// under the "dark" class, and the layout of the copy button.
+func (h *Highlighter) CSS() string {
+	return h.css()
+}
+
+func (h *Highlighter) stylesheet() string {
+	var css strings.Builder
+	css.WriteString(blockCSS)
+	h.writeThemeCSS(&css, h.opts.theme, "")
+	if h.opts.darkTheme != "" {
+		h.writeThemeCSS(&css, h.opts.darkTheme, "."+theme.DarkClass+" ")
+	}
+	return css.String()
+}
+
+// writeThemeCSS writes the classes of the chroma style name, their selectors prefixed with
+// scope. The rule of the standalone ".bg" class is left out, the blocks being ".chroma".
+func (h *Highlighter) writeThemeCSS(css *strings.Builder, name, scope string) {
+	var rules strings.Builder
+	h.formatter.WriteCSS(&rules, styles.Get(name))
+	for rule := range strings.Lines(rules.String()) {
+		if !strings.HasPrefix(rule, ".bg ") {
+			css.WriteString(scope + rule)
+		}
+	}
+}
+
+// Styles renders the stylesheet of the code blocks in a <style> element.
+func (h *Highlighter) Styles() templ.Component {
+	return templ.Raw("<style>" + h.CSS() + "</style>")
+}
+
+// StylesheetHandler serves the stylesheet of the code blocks, to link it from the head.
+func (h *Highlighter) StylesheetHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/css; charset=utf-8")
+		w.Header().Set("Cache-Control", "public, max-age=86400")
+		io.WriteString(w, h.CSS())
+	})
+}
+
+// blockCSS lays out the code blocks and their copy button.
+const blockCSS = `.code-block { position: relative; }
+.code-block .chroma { overflow-x: auto; padding: 1rem; border-radius: .5rem; font-size: .875rem; line-height: 1.5; }
+.code-copy { position: absolute; top: .5rem; right: .5rem; padding: .125rem .5rem; border: 1px solid currentColor; border-radius: .25rem; font-size: .75rem; background: transparent; color: inherit; opacity: .6; cursor: pointer; }
+.code-copy:hover, .code-copy:focus-visible { opacity: 1; }
+`
+
+// copyScript copies the code of a block when its copy button is clicked, without the line
+// numbers, and confirms it on the button for a moment.
+const copyScript = `(function(){document.addEventListener("click",function(e){var b=e.target.closest&&e.target.closest("[data-code-copy]");if(!b||!navigator.clipboard)return;` +
+	`var c=b.closest("[data-code-block]").querySelector("code").cloneNode(true);c.querySelectorAll(".ln").forEach(function(n){n.remove()});` +
+	`navigator.clipboard.writeText(c.textContent).then(function(){b.textContent="Copied";setTimeout(function(){b.textContent="Copy"},2000)})})})();`
+
+// CopyScriptHash is the script-src hash of [CopyScript].
+var CopyScriptHash = middlewares.CSPHash(copyScript)
+
+// CopyScript renders the inline script of the copy buttons.
+func CopyScript() templ.Component {
+	return templ.Raw("<script>" + copyScript + "</script>")
+}
@@ -0,0 +1,99 @@
+package code_test
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ancalabrese/gotth/content"
+	"github.com/ancalabrese/gotth/views/components/code"
+)
+
+func TestHighlighter_Block(t *testing.T) {
+	tests := []struct {
+		name    string
+		h       *code.Highlighter
+		code    string
+		lang    string
+		want    []string
+		notWant []string
+	}{
+		{
+			name: "Go",
+			h:    code.Default,
+			code: "package main\n",
+			lang: "go",
+			want: []string{`data-lang="go"`, `<pre class="chroma">`, `<span class="kn">package</span>`, `data-code-copy`},
+		},
+		{
+			name:    "Unknown language is escaped",
+			h:       code.New(),
+			code:    "<script>alert(1)</script>",
+			lang:    "nope",
+			want:    []string{"&lt;script&gt;"},
+			notWant: []string{"<script>"},
+		},
+		{
+			name: "Line numbers",
+			h:    code.New(code.WithLineNumbers()),
+			code: "a\nb\n",
+			want: []string{`<span class="ln">1</span>`, `<span class="ln">2</span>`},
+		},
+		{
+			name:    "Without copy button",
+			h:       code.New(code.WithoutCopyButton()),
+			code:    "a",
+			notWant: []string{"data-code-copy"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out strings.Builder
+			if err := tt.h.Block(tt.code, tt.lang).Render(context.Background(), &out); err != nil {
+				t.Fatalf("Render() error = %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(out.String(), want) {
+					t.Errorf("output misses %q:\n%s", want, out.String())
+				}
+			}
+			for _, notWant := range tt.notWant {
+				if strings.Contains(out.String(), notWant) {
+					t.Errorf("output has %q:\n%s", notWant, out.String())
+				}
+			}
+		})
+	}
+}
+
+func TestHighlighter_CSS(t *testing.T) {
+	css := code.New(code.WithTheme("monokai"), code.WithDarkTheme("dracula")).CSS()
+	for _, want := range []string{".code-copy {", "\n.chroma {", "\n.chroma .k {", "\n.dark .chroma .k {"} {
+		if !strings.Contains(css, want) {
+			t.Errorf("CSS misses %q:\n%s", want, css)
+		}
+	}
+	if strings.Contains(css, "\n.bg ") {
+		t.Errorf("CSS has the .bg class:\n%s", css)
+	}
+	if strings.Contains(code.New(code.WithDarkTheme("")).CSS(), ".dark") {
+		t.Error("CSS has a dark theme without one")
+	}
+
+	rec := httptest.NewRecorder()
+	code.Default.StylesheetHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/code.css", nil))
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/css") || rec.Body.String() != code.Default.CSS() {
+		t.Errorf("stylesheet = %q, %d bytes", ct, rec.Body.Len())
+	}
+}
+
+func TestHighlighter_Markdown(t *testing.T) {
+	doc, err := content.NewMarkdown(content.WithHighlighter(code.Default)).Render([]byte("```go\nfunc f() {}\n```\n"))
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if !strings.Contains(doc.HTML, `<div class="code-block" data-code-block data-lang="go">`) || !strings.Contains(doc.HTML, `<span class="kd">func</span>`) {
+		t.Errorf("HTML = %s", doc.HTML)
+	}
+}
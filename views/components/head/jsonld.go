@@ -2,6 +2,9 @@ package head
 
 import (
 	"encoding/json"
+	"net/url"
+
+	"github.com/ancalabrese/gotth/routes"
 )
 
 // JSONLDNode represents a generic JSON-LD object node.
@@ -87,3 +90,40 @@ func (n JSONLDNode) MarshalJSON() ([]byte, error) {
 
 	return json.Marshal(out)
 }
+
+// BreadcrumbListJSONLD returns the schema.org BreadcrumbList of trail. Relative URLs are
+// resolved against base when it is an absolute URL, as search engines expect absolute ones.
+func BreadcrumbListJSONLD(trail []routes.Breadcrumb, base string) JSONLDNode {
+	baseURL, err := url.Parse(base)
+	if err != nil || !baseURL.IsAbs() {
+		baseURL = nil
+	}
+
+	items := make([]map[string]any, 0, len(trail))
+	for i, b := range trail {
+		item := b.URL
+		if u, err := url.Parse(b.URL); err == nil && baseURL != nil {
+			item = baseURL.ResolveReference(u).String()
+		}
+		items = append(items, map[string]any{
+			"@type":    "ListItem",
+			"position": i + 1,
+			"name":     b.Title,
+			"item":     item,
+		})
+	}
+	return JSONLDNode{
+		Context:    "https://schema.org",
+		Type:       "BreadcrumbList",
+		Properties: map[string]any{"itemListElement": items},
+	}
+}
+
+// breadcrumbJSONLD marshals the BreadcrumbList of vm.
+func breadcrumbJSONLD(vm HeadViewModel) string {
+	data, err := json.Marshal(BreadcrumbListJSONLD(vm.Breadcrumbs, vm.Metadata.URL))
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
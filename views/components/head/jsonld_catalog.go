@@ -0,0 +1,358 @@
+package head
+
+import (
+	"cmp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The catalog below fills the schema.org nodes of the common rich results: fill in the struct
+// of the result and pass it to its JSONLD function, e.g.
+//
+//	head.WithJSONLD(head.RecipeJSONLD(head.Recipe{Name: "Pancakes", ...}))
+//
+// Empty fields are left out of the node. Add the properties the catalog misses to the
+// Properties of the returned node.
+
+// PostalAddress is a schema.org PostalAddress.
+type PostalAddress struct {
+	StreetAddress string
+	Locality      string // City
+	Region        string // State or province
+	PostalCode    string
+	Country       string // ISO 3166-1 alpha-2 code, e.g. "IT"
+}
+
+// GeoCoordinates are the schema.org GeoCoordinates of a place.
+type GeoCoordinates struct {
+	Latitude  float64
+	Longitude float64
+}
+
+// OpeningHours are the opening hours of a LocalBusiness on Days, e.g. {Days: []string{"Monday",
+// "Tuesday"}, Opens: "09:00", Closes: "18:00"}.
+type OpeningHours struct {
+	Days   []string
+	Opens  string
+	Closes string
+}
+
+// AggregateRating is the average rating of an item, from 1 to 5 unless Best is set.
+type AggregateRating struct {
+	Value float64
+	Count int
+	Best  float64 // Optional: 5 when 0
+}
+
+// Organization is the schema.org Organization publishing a JobPosting or a SoftwareApplication.
+type Organization struct {
+	Name string
+	URL  string
+	Logo string
+}
+
+// LocalBusiness is a physical business, for the local business rich results.
+type LocalBusiness struct {
+	// Type is the most specific schema.org type of the business, e.g. "Restaurant" or "Dentist".
+	// Defaults to "LocalBusiness".
+	Type         string
+	Name         string
+	Description  string
+	URL          string
+	Images       []string
+	Telephone    string
+	PriceRange   string // e.g. "$$"
+	Address      PostalAddress
+	Geo          *GeoCoordinates
+	OpeningHours []OpeningHours
+	Rating       *AggregateRating
+	// SameAs lists the profiles of the business on other sites, e.g. social networks.
+	SameAs []string
+}
+
+// LocalBusinessJSONLD returns the schema.org node of b.
+func LocalBusinessJSONLD(b LocalBusiness) JSONLDNode {
+	props := jsonLDProps{}
+	props.set("name", b.Name)
+	props.set("description", b.Description)
+	props.set("url", b.URL)
+	props.set("image", b.Images)
+	props.set("telephone", b.Telephone)
+	props.set("priceRange", b.PriceRange)
+	props.set("address", b.Address.node())
+	if b.Geo != nil {
+		props.set("geo", map[string]any{"@type": "GeoCoordinates", "latitude": b.Geo.Latitude, "longitude": b.Geo.Longitude})
+	}
+	specs := make([]map[string]any, 0, len(b.OpeningHours))
+	for _, h := range b.OpeningHours {
+		specs = append(specs, map[string]any{"@type": "OpeningHoursSpecification", "dayOfWeek": h.Days, "opens": h.Opens, "closes": h.Closes})
+	}
+	props.set("openingHoursSpecification", specs)
+	props.set("aggregateRating", b.Rating.node())
+	props.set("sameAs", b.SameAs)
+
+	return JSONLDNode{Context: "https://schema.org", Type: cmp.Or(b.Type, "LocalBusiness"), Properties: props}
+}
+
+// Recipe is a recipe, for the recipe rich results.
+type Recipe struct {
+	Name          string
+	Description   string
+	Images        []string
+	Author        string
+	DatePublished time.Time
+	PrepTime      time.Duration
+	CookTime      time.Duration
+	// TotalTime defaults to PrepTime + CookTime.
+	TotalTime time.Duration
+	Yield     string // e.g. "4 servings"
+	Category  string // e.g. "Dessert"
+	Cuisine   string // e.g. "Italian"
+	Keywords  []string
+	Calories  string // Per serving, e.g. "270 calories"
+	// Ingredients are the ingredients with their quantity, e.g. "2 cups of flour".
+	Ingredients []string
+	// Instructions are the steps, in order.
+	Instructions []string
+	Rating       *AggregateRating
+	VideoURL     string
+}
+
+// RecipeJSONLD returns the schema.org node of r.
+func RecipeJSONLD(r Recipe) JSONLDNode {
+	props := jsonLDProps{}
+	props.set("name", r.Name)
+	props.set("description", r.Description)
+	props.set("image", r.Images)
+	if r.Author != "" {
+		props.set("author", map[string]any{"@type": "Person", "name": r.Author})
+	}
+	props.set("datePublished", isoDate(r.DatePublished))
+	props.set("prepTime", isoDuration(r.PrepTime))
+	props.set("cookTime", isoDuration(r.CookTime))
+	if r.TotalTime == 0 {
+		r.TotalTime = r.PrepTime + r.CookTime
+	}
+	props.set("totalTime", isoDuration(r.TotalTime))
+	props.set("recipeYield", r.Yield)
+	props.set("recipeCategory", r.Category)
+	props.set("recipeCuisine", r.Cuisine)
+	props.set("keywords", strings.Join(r.Keywords, ", "))
+	if r.Calories != "" {
+		props.set("nutrition", map[string]any{"@type": "NutritionInformation", "calories": r.Calories})
+	}
+	props.set("recipeIngredient", r.Ingredients)
+	steps := make([]map[string]any, 0, len(r.Instructions))
+	for _, step := range r.Instructions {
+		steps = append(steps, map[string]any{"@type": "HowToStep", "text": step})
+	}
+	props.set("recipeInstructions", steps)
+	props.set("aggregateRating", r.Rating.node())
+	if r.VideoURL != "" {
+		props.set("video", map[string]any{"@type": "VideoObject", "name": r.Name, "contentUrl": r.VideoURL})
+	}
+
+	return JSONLDNode{Context: "https://schema.org", Type: "Recipe", Properties: props}
+}
+
+// Salary is the base salary of a JobPosting, from Min to Max, or exactly Min when Max is 0.
+type Salary struct {
+	Currency string // ISO 4217 code, e.g. "EUR"
+	Min      float64
+	Max      float64
+	// Unit is one of "HOUR", "DAY", "WEEK", "MONTH" or "YEAR".
+	Unit string
+}
+
+// JobPosting is a job offer, for the job posting rich results.
+type JobPosting struct {
+	Title string
+	// Description is the full description of the job, in HTML.
+	Description  string
+	DatePosted   time.Time
+	ValidThrough time.Time
+	// EmploymentType lists the types of the job: "FULL_TIME", "PART_TIME", "CONTRACTOR",
+	// "TEMPORARY", "INTERN", "VOLUNTEER", "PER_DIEM" or "OTHER".
+	EmploymentType     []string
+	HiringOrganization Organization
+	// Location is where the job is, left empty for fully remote jobs.
+	Location PostalAddress
+	// Remote marks a remote job, open to the applicants of RemoteCountries.
+	Remote          bool
+	RemoteCountries []string
+	Salary          *Salary
+	// Identifier is the ID of the job for the hiring organization.
+	Identifier string
+}
+
+// JobPostingJSONLD returns the schema.org node of j.
+func JobPostingJSONLD(j JobPosting) JSONLDNode {
+	props := jsonLDProps{}
+	props.set("title", j.Title)
+	props.set("description", j.Description)
+	props.set("datePosted", isoDate(j.DatePosted))
+	if !j.ValidThrough.IsZero() {
+		props.set("validThrough", j.ValidThrough.Format(time.RFC3339))
+	}
+	if len(j.EmploymentType) == 1 {
+		props.set("employmentType", j.EmploymentType[0])
+	} else {
+		props.set("employmentType", j.EmploymentType)
+	}
+	props.set("hiringOrganization", j.HiringOrganization.node())
+	if address := j.Location.node(); address != nil {
+		props.set("jobLocation", map[string]any{"@type": "Place", "address": address})
+	}
+	if j.Remote {
+		props.set("jobLocationType", "TELECOMMUTE")
+		countries := make([]map[string]any, 0, len(j.RemoteCountries))
+		for _, c := range j.RemoteCountries {
+			countries = append(countries, map[string]any{"@type": "Country", "name": c})
+		}
+		props.set("applicantLocationRequirements", countries)
+	}
+	if s := j.Salary; s != nil {
+		value := map[string]any{"@type": "QuantitativeValue", "unitText": s.Unit}
+		if s.Max > s.Min {
+			value["minValue"], value["maxValue"] = s.Min, s.Max
+		} else {
+			value["value"] = s.Min
+		}
+		props.set("baseSalary", map[string]any{"@type": "MonetaryAmount", "currency": s.Currency, "value": value})
+	}
+	if j.Identifier != "" {
+		props.set("identifier", map[string]any{"@type": "PropertyValue", "name": j.HiringOrganization.Name, "value": j.Identifier})
+	}
+
+	return JSONLDNode{Context: "https://schema.org", Type: "JobPosting", Properties: props}
+}
+
+// SoftwareApplication is an application, for the software app rich results.
+type SoftwareApplication struct {
+	// Type is "SoftwareApplication", "MobileApplication", "WebApplication" or "VideoGame".
+	// Defaults to "SoftwareApplication".
+	Type        string
+	Name        string
+	Description string
+	URL         string
+	DownloadURL string
+	Version     string
+	Screenshots []string
+	// OperatingSystem lists the supported systems, e.g. "Windows 10, macOS".
+	OperatingSystem string
+	// Category is the schema.org category, e.g. "GameApplication" or "BusinessApplication".
+	Category  string
+	Publisher Organization
+	// Price of the application, "0" for free ones. Search engines require an offer.
+	Price    string
+	Currency string // ISO 4217 code, e.g. "EUR"
+	Rating   *AggregateRating
+}
+
+// SoftwareApplicationJSONLD returns the schema.org node of a.
+func SoftwareApplicationJSONLD(a SoftwareApplication) JSONLDNode {
+	props := jsonLDProps{}
+	props.set("name", a.Name)
+	props.set("description", a.Description)
+	props.set("url", a.URL)
+	props.set("downloadUrl", a.DownloadURL)
+	props.set("softwareVersion", a.Version)
+	props.set("screenshot", a.Screenshots)
+	props.set("operatingSystem", a.OperatingSystem)
+	props.set("applicationCategory", a.Category)
+	props.set("publisher", a.Publisher.node())
+	if a.Price != "" {
+		props.set("offers", map[string]any{"@type": "Offer", "price": a.Price, "priceCurrency": a.Currency})
+	}
+	props.set("aggregateRating", a.Rating.node())
+
+	return JSONLDNode{Context: "https://schema.org", Type: cmp.Or(a.Type, "SoftwareApplication"), Properties: props}
+}
+
+// jsonLDProps are the properties of a node, leaving out the empty values.
+type jsonLDProps map[string]any
+
+func (p jsonLDProps) set(key string, value any) {
+	switch v := value.(type) {
+	case string:
+		if v == "" {
+			return
+		}
+	case []string:
+		if len(v) == 0 {
+			return
+		}
+	case []map[string]any:
+		if len(v) == 0 {
+			return
+		}
+	case map[string]any:
+		if v == nil {
+			return
+		}
+	}
+	p[key] = value
+}
+
+func (a PostalAddress) node() map[string]any {
+	if a == (PostalAddress{}) {
+		return nil
+	}
+	props := jsonLDProps{"@type": "PostalAddress"}
+	props.set("streetAddress", a.StreetAddress)
+	props.set("addressLocality", a.Locality)
+	props.set("addressRegion", a.Region)
+	props.set("postalCode", a.PostalCode)
+	props.set("addressCountry", a.Country)
+	return props
+}
+
+func (r *AggregateRating) node() map[string]any {
+	if r == nil {
+		return nil
+	}
+	return map[string]any{
+		"@type":       "AggregateRating",
+		"ratingValue": r.Value,
+		"ratingCount": r.Count,
+		"bestRating":  cmp.Or(r.Best, 5),
+	}
+}
+
+func (o Organization) node() map[string]any {
+	if o == (Organization{}) {
+		return nil
+	}
+	props := jsonLDProps{"@type": "Organization"}
+	props.set("name", o.Name)
+	props.set("url", o.URL)
+	props.set("logo", o.Logo)
+	return props
+}
+
+// isoDate formats t as an ISO 8601 date, or "" when zero.
+func isoDate(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.DateOnly)
+}
+
+// isoDuration formats d as an ISO 8601 duration, e.g. "PT1H30M", or "" when not positive.
+func isoDuration(d time.Duration) string {
+	if d <= 0 {
+		return ""
+	}
+	out := "PT"
+	if h := int(d / time.Hour); h > 0 {
+		out += strconv.Itoa(h) + "H"
+	}
+	if m := int(d % time.Hour / time.Minute); m > 0 {
+		out += strconv.Itoa(m) + "M"
+	}
+	if s := int(d % time.Minute / time.Second); s > 0 || out == "PT" {
+		out += strconv.Itoa(s) + "S"
+	}
+	return out
+}
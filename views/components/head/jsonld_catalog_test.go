@@ -0,0 +1,159 @@
+package head
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLocalBusinessJSONLD(t *testing.T) {
+	node := LocalBusinessJSONLD(LocalBusiness{
+		Type:         "Restaurant",
+		Name:         "Trattoria",
+		Telephone:    "+39 06 1234567",
+		Address:      PostalAddress{StreetAddress: "Via Roma 1", Locality: "Roma", Country: "IT"},
+		Geo:          &GeoCoordinates{Latitude: 41.9, Longitude: 12.5},
+		OpeningHours: []OpeningHours{{Days: []string{"Monday", "Tuesday"}, Opens: "12:00", Closes: "23:00"}},
+		Rating:       &AggregateRating{Value: 4.5, Count: 120},
+	})
+	assertJSONEqual(t, node, `{
+		"@context": "https://schema.org",
+		"@type": "Restaurant",
+		"name": "Trattoria",
+		"telephone": "+39 06 1234567",
+		"address": {"@type": "PostalAddress", "streetAddress": "Via Roma 1", "addressLocality": "Roma", "addressCountry": "IT"},
+		"geo": {"@type": "GeoCoordinates", "latitude": 41.9, "longitude": 12.5},
+		"openingHoursSpecification": [{"@type": "OpeningHoursSpecification", "dayOfWeek": ["Monday", "Tuesday"], "opens": "12:00", "closes": "23:00"}],
+		"aggregateRating": {"@type": "AggregateRating", "ratingValue": 4.5, "ratingCount": 120, "bestRating": 5}
+	}`)
+
+	assertJSONEqual(t, LocalBusinessJSONLD(LocalBusiness{Name: "Shop"}), `{
+		"@context": "https://schema.org",
+		"@type": "LocalBusiness",
+		"name": "Shop"
+	}`)
+}
+
+func TestRecipeJSONLD(t *testing.T) {
+	node := RecipeJSONLD(Recipe{
+		Name:          "Pancakes",
+		Author:        "Ada",
+		DatePublished: time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC),
+		PrepTime:      10 * time.Minute,
+		CookTime:      time.Hour + 5*time.Minute,
+		Yield:         "4 servings",
+		Keywords:      []string{"breakfast", "sweet"},
+		Calories:      "270 calories",
+		Ingredients:   []string{"2 cups of flour", "2 eggs"},
+		Instructions:  []string{"Mix.", "Cook."},
+	})
+	assertJSONEqual(t, node, `{
+		"@context": "https://schema.org",
+		"@type": "Recipe",
+		"name": "Pancakes",
+		"author": {"@type": "Person", "name": "Ada"},
+		"datePublished": "2025-03-01",
+		"prepTime": "PT10M",
+		"cookTime": "PT1H5M",
+		"totalTime": "PT1H15M",
+		"recipeYield": "4 servings",
+		"keywords": "breakfast, sweet",
+		"nutrition": {"@type": "NutritionInformation", "calories": "270 calories"},
+		"recipeIngredient": ["2 cups of flour", "2 eggs"],
+		"recipeInstructions": [{"@type": "HowToStep", "text": "Mix."}, {"@type": "HowToStep", "text": "Cook."}]
+	}`)
+}
+
+func TestJobPostingJSONLD(t *testing.T) {
+	tests := []struct {
+		name     string
+		job      JobPosting
+		expected string
+	}{
+		{
+			name: "On site with salary range",
+			job: JobPosting{
+				Title:              "Go developer",
+				DatePosted:         time.Date(2025, 5, 2, 0, 0, 0, 0, time.UTC),
+				ValidThrough:       time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC),
+				EmploymentType:     []string{"FULL_TIME"},
+				HiringOrganization: Organization{Name: "Gotth", URL: "https://gotth.dev"},
+				Location:           PostalAddress{Locality: "Milano", Country: "IT"},
+				Salary:             &Salary{Currency: "EUR", Min: 50000, Max: 70000, Unit: "YEAR"},
+				Identifier:         "GO-1",
+			},
+			expected: `{
+				"@context": "https://schema.org",
+				"@type": "JobPosting",
+				"title": "Go developer",
+				"datePosted": "2025-05-02",
+				"validThrough": "2025-06-01T00:00:00Z",
+				"employmentType": "FULL_TIME",
+				"hiringOrganization": {"@type": "Organization", "name": "Gotth", "url": "https://gotth.dev"},
+				"jobLocation": {"@type": "Place", "address": {"@type": "PostalAddress", "addressLocality": "Milano", "addressCountry": "IT"}},
+				"baseSalary": {"@type": "MonetaryAmount", "currency": "EUR", "value": {"@type": "QuantitativeValue", "unitText": "YEAR", "minValue": 50000, "maxValue": 70000}},
+				"identifier": {"@type": "PropertyValue", "name": "Gotth", "value": "GO-1"}
+			}`,
+		},
+		{
+			name: "Remote with fixed salary",
+			job: JobPosting{
+				Title:           "Designer",
+				EmploymentType:  []string{"PART_TIME", "CONTRACTOR"},
+				Remote:          true,
+				RemoteCountries: []string{"IT", "DE"},
+				Salary:          &Salary{Currency: "EUR", Min: 40, Unit: "HOUR"},
+			},
+			expected: `{
+				"@context": "https://schema.org",
+				"@type": "JobPosting",
+				"title": "Designer",
+				"employmentType": ["PART_TIME", "CONTRACTOR"],
+				"jobLocationType": "TELECOMMUTE",
+				"applicantLocationRequirements": [{"@type": "Country", "name": "IT"}, {"@type": "Country", "name": "DE"}],
+				"baseSalary": {"@type": "MonetaryAmount", "currency": "EUR", "value": {"@type": "QuantitativeValue", "unitText": "HOUR", "value": 40}}
+			}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertJSONEqual(t, JobPostingJSONLD(tt.job), tt.expected)
+		})
+	}
+}
+
+func TestSoftwareApplicationJSONLD(t *testing.T) {
+	node := SoftwareApplicationJSONLD(SoftwareApplication{
+		Type:            "WebApplication",
+		Name:            "Gotth Notes",
+		OperatingSystem: "All",
+		Category:        "BusinessApplication",
+		Price:           "0",
+		Currency:        "EUR",
+		Rating:          &AggregateRating{Value: 9, Count: 40, Best: 10},
+	})
+	assertJSONEqual(t, node, `{
+		"@context": "https://schema.org",
+		"@type": "WebApplication",
+		"name": "Gotth Notes",
+		"operatingSystem": "All",
+		"applicationCategory": "BusinessApplication",
+		"offers": {"@type": "Offer", "price": "0", "priceCurrency": "EUR"},
+		"aggregateRating": {"@type": "AggregateRating", "ratingValue": 9, "ratingCount": 40, "bestRating": 10}
+	}`)
+}
+
+func TestIsoDuration(t *testing.T) {
+	tests := map[time.Duration]string{
+		0:                             "",
+		90 * time.Second:              "PT1M30S",
+		2 * time.Hour:                 "PT2H",
+		26*time.Hour + 15*time.Minute: "PT26H15M",
+		500 * time.Millisecond:        "PT0S",
+	}
+	for d, want := range tests {
+		if got := isoDuration(d); got != want {
+			t.Errorf("isoDuration(%v) = %q, want %q", d, got, want)
+		}
+	}
+}
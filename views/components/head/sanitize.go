@@ -0,0 +1,133 @@
+package head
+
+import (
+	"log/slog"
+	"net/url"
+	"strings"
+	"unicode"
+)
+
+// Sanitize cleans up the view model fields before rendering:
+//   - control characters are stripped and surrounding whitespace trimmed from text fields
+//   - URL fields (canonical, alternates, images, icons) that don't parse or use a scheme other than
+//     http/https are cleared
+//   - empty and duplicate (case-insensitive) keywords are removed
+//
+// Invalid values are logged with the default slog logger. NewHeadViewModel calls it after
+// applying the options. Call it again if the view model is modified afterwards.
+func (vm *HeadViewModel) Sanitize() {
+	for _, f := range []*string{
+		&vm.Name,
+		&vm.Metadata.Title, &vm.Metadata.Description, &vm.Metadata.Author, &vm.Metadata.ViewPort,
+		&vm.Metadata.OgTitle, &vm.Metadata.OgDescription, &vm.Metadata.OgImageAlt,
+		&vm.Metadata.OgImageWidth, &vm.Metadata.OgImageHeight,
+		&vm.Metadata.TwitterTitle, &vm.Metadata.TwitterDescription, &vm.Metadata.TwitterImageAlt,
+		&vm.FaviconType, &vm.MsTileColor, &vm.OgType, &vm.OgLocale, &vm.TwitterCardType,
+		&vm.TwitterSiteHandle, &vm.TwitterCreatorHandle, &vm.ThemeColor, &vm.AppleStatusBarColor,
+		&vm.ColorScheme, &vm.Lang, &vm.Robots,
+	} {
+		*f = sanitizeText(*f)
+	}
+
+	for name, f := range map[string]*string{
+		"canonical URL":     &vm.Metadata.URL,
+		"schema image URL":  &vm.Metadata.SchemaImageURL,
+		"OpenGraph URL":     &vm.Metadata.OgURL,
+		"OpenGraph image":   &vm.Metadata.OgImage,
+		"Twitter image":     &vm.Metadata.TwitterImage,
+		"favicon":           &vm.FaviconPath,
+		"Apple touch icon":  &vm.AppleTouchIconPath,
+		"MS browser config": &vm.MsBrowserConfigPath,
+		"MS start URL":      &vm.MsStartURL,
+	} {
+		u, ok := sanitizeURL(*f)
+		if !ok {
+			slog.Warn("dropping invalid head metadata", slog.String("field", name), slog.String("value", *f))
+		}
+		*f = u
+	}
+
+	alternates := vm.Alternates[:0]
+	for _, a := range vm.Alternates {
+		a.Hreflang = sanitizeText(a.Hreflang)
+		href, ok := sanitizeURL(a.Href)
+		if !ok || href == "" || a.Hreflang == "" {
+			slog.Warn("dropping invalid alternate link in head metadata", slog.String("hreflang", a.Hreflang), slog.String("href", a.Href))
+			continue
+		}
+		a.Href = href
+		alternates = append(alternates, a)
+	}
+	vm.Alternates = alternates
+
+	vm.Metadata.Keywords = dedupeKeywords(vm.Metadata.Keywords)
+
+	if vm.CustomMetaTags != nil {
+		tags := make(map[string]string, len(vm.CustomMetaTags))
+		for k, v := range vm.CustomMetaTags {
+			if k = sanitizeText(k); k != "" {
+				tags[k] = sanitizeText(v)
+			}
+		}
+		vm.CustomMetaTags = tags
+	}
+}
+
+// sanitizeText removes control characters and trims surrounding whitespace.
+func sanitizeText(s string) string {
+	return strings.TrimSpace(strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, s))
+}
+
+// sanitizeURL cleans s and reports whether it's a valid absolute http(s) URL or a relative
+// reference. Invalid URLs are returned empty.
+func sanitizeURL(s string) (string, bool) {
+	s = sanitizeText(s)
+	if s == "" {
+		return "", true
+	}
+
+	u, err := url.Parse(s)
+	if err != nil {
+		return "", false
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "":
+		// Relative references (e.g., "/static/img.png") are fine, scheme-relative ones need a host.
+		if strings.HasPrefix(s, "//") && u.Host == "" {
+			return "", false
+		}
+	case "http", "https":
+		if u.Host == "" {
+			return "", false
+		}
+	default:
+		return "", false
+	}
+	return s, true
+}
+
+// dedupeKeywords returns the non-empty keywords with case-insensitive duplicates removed,
+// preserving the order of first occurrence.
+func dedupeKeywords(keywords []string) []string {
+	if len(keywords) == 0 {
+		return keywords
+	}
+
+	seen := make(map[string]bool, len(keywords))
+	out := make([]string, 0, len(keywords))
+	for _, k := range keywords {
+		k = sanitizeText(k)
+		key := strings.ToLower(k)
+		if k == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		out = append(out, k)
+	}
+	return out
+}
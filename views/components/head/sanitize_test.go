@@ -0,0 +1,72 @@
+package head
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSanitize_Text(t *testing.T) {
+	vm := NewHeadViewModel(
+		WithName(" My\x00App "),
+		WithPageCoreMetadata("Title\r\nInjected", "Desc\x1b[31m", "/page"),
+		WithCustomMetaTag("robots\t", "noindex\x07"),
+	)
+
+	if vm.Name != "MyApp" {
+		t.Errorf("Name = %q, want %q", vm.Name, "MyApp")
+	}
+	if vm.Metadata.Title != "TitleInjected" {
+		t.Errorf("Title = %q, want %q", vm.Metadata.Title, "TitleInjected")
+	}
+	if vm.Metadata.Description != "Desc[31m" {
+		t.Errorf("Description = %q, want %q", vm.Metadata.Description, "Desc[31m")
+	}
+	if !reflect.DeepEqual(vm.CustomMetaTags, map[string]string{"robots": "noindex"}) {
+		t.Errorf("CustomMetaTags = %v", vm.CustomMetaTags)
+	}
+}
+
+func TestSanitize_URLs(t *testing.T) {
+	tests := []struct {
+		name     string
+		url      string
+		expected string
+	}{
+		{name: "Relative path", url: "/static/og.png", expected: "/static/og.png"},
+		{name: "Absolute https", url: "https://example.com/og.png", expected: "https://example.com/og.png"},
+		{name: "Scheme relative", url: "//cdn.example.com/og.png", expected: "//cdn.example.com/og.png"},
+		{name: "Surrounding whitespace", url: "  https://example.com/og.png\n", expected: "https://example.com/og.png"},
+		{name: "Javascript scheme", url: "javascript:alert(1)", expected: ""},
+		{name: "Data scheme", url: "data:image/png;base64,AAAA", expected: ""},
+		{name: "Missing host", url: "https:///og.png", expected: ""},
+		{name: "Unparsable", url: "http://[::1", expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vm := NewHeadViewModel(
+				WithPageCoreMetadata("t", "d", tt.url),
+				WithOpenGraph("", "", "", "", "", tt.url, "", "", ""),
+			)
+			if vm.Metadata.URL != tt.expected {
+				t.Errorf("URL = %q, want %q", vm.Metadata.URL, tt.expected)
+			}
+			if vm.Metadata.OgImage != tt.expected {
+				t.Errorf("OgImage = %q, want %q", vm.Metadata.OgImage, tt.expected)
+			}
+			if vm.Metadata.TwitterImage != tt.expected {
+				t.Errorf("TwitterImage = %q, want %q", vm.Metadata.TwitterImage, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSanitize_Keywords(t *testing.T) {
+	vm := NewHeadViewModel(
+		WithKeywords([]string{"Go", "templ", " go ", "", "HTMX", "Templ", "htmx\x00"}),
+	)
+	expected := []string{"Go", "templ", "HTMX"}
+	if !reflect.DeepEqual(vm.Metadata.Keywords, expected) {
+		t.Errorf("Keywords = %v, want %v", vm.Metadata.Keywords, expected)
+	}
+}
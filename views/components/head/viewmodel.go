@@ -4,6 +4,8 @@ package head
 import (
 	"encoding/json"
 	"log"
+
+	"github.com/ancalabrese/gotth/routes"
 )
 
 // HeadViewModel is the primary model for the Head templ component.
@@ -15,6 +17,11 @@ type HeadViewModel struct {
 	// Core Page Metadata (SEO and page identity)
 	Metadata PageMetadata
 
+	// Localization
+	Lang       string          // Language of the page, for the html lang attribute (defaults to "en")
+	Alternates []AlternateLink // Translations of the page, rendered as hreflang links
+	Robots     string          // Directives of the robots meta tag, e.g. "noindex, follow"
+
 	// Favicons and Touch Icons
 	FaviconPath        string // Path to the main favicon (e.g., /static/favicon.ico or /static/image.png)
 	FaviconType        string // MIME type of the favicon (e.g., image/x-icon, image/png)
@@ -51,7 +58,8 @@ type HeadViewModel struct {
 	MeasuramentID      string // e.g., Google Analytics Measurement ID
 
 	// Structured Data
-	PreparedJSONLD string // Pre-marshaled JSON-LD string
+	PreparedJSONLD string              // Pre-marshaled JSON-LD string
+	Breadcrumbs    []routes.Breadcrumb // Rendered as a BreadcrumbList JSON-LD
 
 	// Miscellaneous
 	CustomMetaTags map[string]string // For any other arbitrary meta tags
@@ -86,6 +94,12 @@ type PageMetadata struct {
 	TwitterImageAlt    string // Alt text for Twitter image
 }
 
+// AlternateLink is a translation of the page.
+type AlternateLink struct {
+	Hreflang string // BCP 47 language tag (e.g., "de" or "pt-BR"), or "x-default"
+	Href     string // URL of the translation, absolute when the canonical URL is
+}
+
 // FontLink defines a font to be loaded.
 type FontLink struct {
 	Href        string // Full URL to the font CSS or font file
@@ -159,6 +173,8 @@ func NewHeadViewModel(opts ...Option) HeadViewModel {
 		vm.Metadata.TwitterImageAlt = "Image for " + vm.Metadata.TwitterTitle
 	}
 
+	vm.Sanitize()
+
 	return vm
 }
 
@@ -376,6 +392,32 @@ func WithJSONLD(jsonLDNode JSONLDNode) Option {
 	}
 }
 
+// WithBreadcrumbs sets the breadcrumb trail of the page, emitted as a BreadcrumbList JSON-LD.
+// Relative URLs are resolved against the canonical URL. ServeContent sets the trail from the
+// route metadata when none is given.
+func WithBreadcrumbs(trail ...routes.Breadcrumb) Option {
+	return func(vm *HeadViewModel) { vm.Breadcrumbs = trail }
+}
+
+// WithLang sets the language of the page, e.g. "de".
+func WithLang(lang string) Option {
+	return func(vm *HeadViewModel) { vm.Lang = lang }
+}
+
+// WithAlternate adds a translation of the page, e.g. WithAlternate("de", "https://example.com/de/about").
+// Use "x-default" for the page shown to the visitors of the other languages.
+func WithAlternate(hreflang, href string) Option {
+	return func(vm *HeadViewModel) {
+		vm.Alternates = append(vm.Alternates, AlternateLink{Hreflang: hreflang, Href: href})
+	}
+}
+
+// WithRobots sets the directives of the robots meta tag, e.g. "noindex, follow" to keep a thin
+// page out of the search engines while following its links.
+func WithRobots(directives string) Option {
+	return func(vm *HeadViewModel) { vm.Robots = directives }
+}
+
 // WithFont adds a font link to the list of fonts.
 func WithFont(href string, crossOrigin bool) Option {
 	return func(vm *HeadViewModel) {
@@ -0,0 +1,189 @@
+// Package picture renders responsive images: a <picture> whose srcset lists the variants of an
+// image by width, so that browsers download the one fitting the layout, and whose width and
+// height attributes reserve the space of the image, without layout shift while it loads.
+//
+//	src := picture.Endpoint(ix) // The variants of the images package
+//	@picture.Picture(src, picture.Props{Src: "photos/cat.jpg", Alt: "A cat", Sizes: "(min-width: 768px) 50vw, 100vw"})
+//
+// The variants can also come from a [Manifest], e.g. written by the build step resizing the
+// images.
+package picture
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/ancalabrese/gotth/images"
+)
+
+// DefaultSizes is the sizes attribute of the images without Props.Sizes: the width of the
+// viewport.
+const DefaultSizes = "100vw"
+
+// DefaultWidths are the widths of the variants of [Endpoint] without Props.Widths.
+var DefaultWidths = []int{320, 640, 960, 1280, 1920}
+
+// Candidate is a variant of an image.
+type Candidate struct {
+	URL   string `json:"url"`
+	Width int    `json:"width"`
+	// Type is the MIME type of the variant, e.g. "image/webp", or "" when the server negotiates
+	// it. AVIF and WebP variants are offered in <source> elements, to the browsers supporting
+	// them.
+	Type string `json:"type,omitempty"`
+}
+
+// Image is an image and its variants.
+type Image struct {
+	// Width and Height of the original image, for the aspect ratio.
+	Width      int         `json:"width"`
+	Height     int         `json:"height"`
+	Candidates []Candidate `json:"candidates"`
+}
+
+// Source returns the variants of the images.
+type Source interface {
+	// Image returns the image at name and its variants, of widths when the source can resize
+	// it.
+	Image(name string, widths []int) (Image, error)
+}
+
+// Props configures a [Picture].
+type Props struct {
+	// Src is the name of the image in the Source, e.g. "photos/cat.jpg".
+	Src string
+	Alt string
+	// Optional: widths of the variants. Defaults to [DefaultWidths].
+	Widths []int
+	// Optional: sizes attribute, the width of the image in the layout, e.g.
+	// "(min-width: 768px) 50vw, 100vw". Defaults to [DefaultSizes].
+	Sizes string
+	// Optional: classes of the img element.
+	Class string
+	// Priority loads the image eagerly with a high priority, e.g. for the hero image, instead
+	// of lazily.
+	Priority bool
+}
+
+func (p Props) widths() []int {
+	if len(p.Widths) == 0 {
+		return DefaultWidths
+	}
+	return p.Widths
+}
+
+// Endpoint returns the Source of the variants served by s, e.g. at /img/{w}x{h}/{path...}, in
+// the format negotiated with the browser.
+func Endpoint(s *images.Server) Source {
+	return endpoint{s: s}
+}
+
+type endpoint struct {
+	s *images.Server
+}
+
+func (e endpoint) Image(name string, widths []int) (Image, error) {
+	width, height, err := e.s.Dimensions(name)
+	if err != nil {
+		return Image{}, err
+	}
+	img := Image{Width: width, Height: height}
+	for _, w := range widths {
+		if w < width {
+			img.Candidates = append(img.Candidates, Candidate{URL: e.s.URL(name, w, 0), Width: w})
+		}
+	}
+	// Variants are never upscaled: the largest is the original width.
+	img.Candidates = append(img.Candidates, Candidate{URL: e.s.URL(name, width, 0), Width: width})
+	return img, nil
+}
+
+// Manifest lists the images and their variants by name, e.g. as generated at build time:
+//
+//	{"photos/cat.jpg": {"width": 1600, "height": 900, "candidates": [
+//		{"url": "/static/cat-800.webp", "width": 800, "type": "image/webp"},
+//		{"url": "/static/cat-800.jpg", "width": 800}
+//	]}}
+type Manifest map[string]Image
+
+// LoadManifest reads the JSON Manifest at name in fsys.
+func LoadManifest(fsys fs.FS, name string) (Manifest, error) {
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image manifest. err %w", err)
+	}
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("failed to parse image manifest %s. err %w", name, err)
+	}
+	return m, nil
+}
+
+// Image returns the image at name, with all its variants of the manifest.
+func (m Manifest) Image(name string, _ []int) (Image, error) {
+	img, ok := m[strings.TrimPrefix(name, "/")]
+	if !ok {
+		return Image{}, fmt.Errorf("image %q not in the manifest. err %w", name, fs.ErrNotExist)
+	}
+	return img, nil
+}
+
+// modernTypes are the formats offered in <source> elements, the best first.
+var modernTypes = []string{"image/avif", "image/webp"}
+
+// sourceSet is the srcset of the variants of a type.
+type sourceSet struct {
+	Type   string
+	SrcSet string
+}
+
+// sets returns the srcsets of the modern types of img, and the fallback of the img element
+// with its src, the largest variant.
+func sets(img Image) (sources []sourceSet, fallback sourceSet, src string) {
+	byType := map[string][]Candidate{}
+	for _, c := range img.Candidates {
+		t := c.Type
+		if !slices.Contains(modernTypes, t) {
+			t = ""
+		}
+		byType[t] = append(byType[t], c)
+	}
+	for _, t := range modernTypes {
+		if len(byType[t]) > 0 {
+			sources = append(sources, sourceSet{Type: t, SrcSet: srcset(byType[t])})
+		}
+	}
+
+	fallbackCandidates := byType[""]
+	if len(fallbackCandidates) == 0 && len(sources) > 0 {
+		// Only modern variants: the img element falls back to the last, most supported one.
+		fallbackCandidates = byType[sources[len(sources)-1].Type]
+		sources = sources[:len(sources)-1]
+	}
+	fallback = sourceSet{SrcSet: srcset(fallbackCandidates)}
+	if len(fallbackCandidates) > 0 {
+		src = slices.MaxFunc(fallbackCandidates, func(a, b Candidate) int { return a.Width - b.Width }).URL
+	}
+	return sources, fallback, src
+}
+
+// srcset returns the srcset attribute of candidates, by increasing width.
+func srcset(candidates []Candidate) string {
+	sorted := slices.SortedFunc(slices.Values(candidates), func(a, b Candidate) int { return a.Width - b.Width })
+	parts := make([]string, 0, len(sorted))
+	for _, c := range sorted {
+		parts = append(parts, c.URL+" "+strconv.Itoa(c.Width)+"w")
+	}
+	return strings.Join(parts, ", ")
+}
+
+func dimension(n int) string {
+	if n <= 0 {
+		return ""
+	}
+	return strconv.Itoa(n)
+}
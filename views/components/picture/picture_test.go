@@ -0,0 +1,82 @@
+package picture_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/png"
+	"io/fs"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/ancalabrese/gotth/images"
+	"github.com/ancalabrese/gotth/views/components/picture"
+)
+
+func render(t *testing.T, src picture.Source, p picture.Props) (string, error) {
+	t.Helper()
+	var out strings.Builder
+	err := picture.Picture(src, p).Render(context.Background(), &out)
+	return out.String(), err
+}
+
+func TestPicture_Endpoint(t *testing.T) {
+	var photo bytes.Buffer
+	png.Encode(&photo, image.NewGray(image.Rect(0, 0, 1000, 500)))
+	ix, err := images.New(images.Config{FS: fstest.MapFS{"cat.png": {Data: photo.Bytes()}}, Secret: []byte("secret")})
+	if err != nil {
+		t.Fatalf("images.New() error = %v", err)
+	}
+
+	out, err := render(t, picture.Endpoint(ix), picture.Props{Src: "cat.png", Alt: "A cat", Sizes: "50vw"})
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	srcset := ix.URL("cat.png", 320, 0) + " 320w, " + ix.URL("cat.png", 640, 0) + " 640w, " +
+		ix.URL("cat.png", 960, 0) + " 960w, " + ix.URL("cat.png", 1000, 0) + " 1000w"
+	for _, want := range []string{
+		`srcset="` + strings.ReplaceAll(srcset, "&", "&amp;") + `"`,
+		`src="` + ix.URL("cat.png", 1000, 0) + `"`,
+		`sizes="50vw"`, `alt="A cat"`, `width="1000"`, `height="500"`, `loading="lazy"`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output misses %s:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "<source") {
+		t.Errorf("output has sources for a negotiated format:\n%s", out)
+	}
+
+	if _, err := render(t, picture.Endpoint(ix), picture.Props{Src: "missing.png"}); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Render() of a missing image error = %v, want %v", err, fs.ErrNotExist)
+	}
+}
+
+func TestPicture_Manifest(t *testing.T) {
+	m, err := picture.LoadManifest(fstest.MapFS{"images.json": {Data: []byte(`{"hero.jpg": {"width": 1600, "height": 900, "candidates": [
+		{"url": "/static/hero-1600.jpg", "width": 1600},
+		{"url": "/static/hero-800.jpg", "width": 800},
+		{"url": "/static/hero-800.webp", "width": 800, "type": "image/webp"},
+		{"url": "/static/hero-800.avif", "width": 800, "type": "image/avif"}
+	]}}`)}}, "images.json")
+	if err != nil {
+		t.Fatalf("LoadManifest() error = %v", err)
+	}
+
+	out, err := render(t, m, picture.Props{Src: "/hero.jpg", Priority: true, Class: "w-full"})
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	want := `<picture><source type="image/avif" srcset="/static/hero-800.avif 800w" sizes="100vw"> ` +
+		`<source type="image/webp" srcset="/static/hero-800.webp 800w" sizes="100vw"> ` +
+		`<img src="/static/hero-1600.jpg" srcset="/static/hero-800.jpg 800w, /static/hero-1600.jpg 1600w" sizes="100vw" alt="" width="1600" height="900" class="w-full" fetchpriority="high"></picture>`
+	if out != want {
+		t.Errorf("output =\n%s\nwant\n%s", out, want)
+	}
+
+	if _, err := picture.LoadManifest(fstest.MapFS{"images.json": {Data: []byte("{")}}, "images.json"); err == nil {
+		t.Error("LoadManifest() of invalid JSON error = nil")
+	}
+}
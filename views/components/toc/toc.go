@@ -0,0 +1,34 @@
+// Package toc renders the table of contents of a page, with links to the anchors of its
+// headings:
+//
+//	doc, _ := content.RenderMarkdown(src)
+//	@toc.TOC("On this page", doc.TOC(2, 3))
+//
+// or, for HTML not rendered from Markdown, content.TOC(content.HeadingsFromHTML(page), 2, 3).
+// With @toc.Script() in the page, the link of the section being read gets the data-active and
+// aria-current attributes while scrolling, also after HTMX swaps. With a Content-Security-Policy,
+// allow the script with csp.Add("script-src", toc.ScriptHash).
+//
+// The list is styled with Tailwind: add this package to the sources of your stylesheet.
+package toc
+
+import (
+	"github.com/a-h/templ"
+	"github.com/ancalabrese/gotth/middlewares"
+)
+
+// script marks the link of the section being read, the last one whose heading is above the top
+// quarter of the viewport, in every [data-toc] element. The elements are looked up on every
+// update, so the TOCs swapped in by HTMX need no setup.
+const script = `(function(){function update(){document.querySelectorAll("[data-toc]").forEach(function(nav){` +
+	`var links=nav.querySelectorAll("a[href^='#']"),active=null;links.forEach(function(a){var h=document.getElementById(decodeURIComponent(a.hash.slice(1)));if(h&&h.getBoundingClientRect().top<=innerHeight/4)active=a});` +
+	`links.forEach(function(a){a.toggleAttribute("data-active",a===active);if(a===active)a.setAttribute("aria-current","location");else a.removeAttribute("aria-current")})})}` +
+	`addEventListener("scroll",update,{passive:true});document.addEventListener("DOMContentLoaded",update);document.addEventListener("htmx:afterSettle",update)})();`
+
+// ScriptHash is the [middlewares.CSPHash] of [Script].
+var ScriptHash = middlewares.CSPHash(script)
+
+// Script renders the inline script highlighting the section being read.
+func Script() templ.Component {
+	return templ.Raw("<script>" + script + "</script>")
+}
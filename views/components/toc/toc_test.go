@@ -0,0 +1,38 @@
+package toc_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/ancalabrese/gotth/content"
+	"github.com/ancalabrese/gotth/views/components/toc"
+)
+
+func TestTOC(t *testing.T) {
+	entries := []content.TOCEntry{
+		{Heading: content.Heading{Level: 2, ID: "install", Text: "Install"}, Children: []content.TOCEntry{
+			{Heading: content.Heading{Level: 3, ID: "linux", Text: "Linux & co"}},
+		}},
+		{Heading: content.Heading{Level: 2, ID: "usage", Text: "Usage"}},
+	}
+	var out strings.Builder
+	if err := toc.TOC("On this page", entries).Render(context.Background(), &out); err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	html := out.String()
+	for _, want := range []string{`aria-label="On this page"`, "data-toc", `href="#install"`, `href="#linux"`, "Linux &amp; co", `href="#usage"`} {
+		if !strings.Contains(html, want) {
+			t.Errorf("TOC misses %q:\n%s", want, html)
+		}
+	}
+	if strings.Index(html, `href="#linux"`) > strings.Index(html, `href="#usage"`) || strings.Count(html, "<ol") != 2 {
+		t.Errorf("TOC isn't nested:\n%s", html)
+	}
+
+	out.Reset()
+	toc.TOC("On this page", nil).Render(context.Background(), &out)
+	if out.Len() != 0 {
+		t.Errorf("TOC without entries = %q", out.String())
+	}
+}
@@ -0,0 +1,209 @@
+// Package ui is a small set of Tailwind components (button, navbar, footer, hero and card)
+// driven by view models, to start new gotth projects with usable building blocks:
+//
+//	@ui.Navbar(ui.NavbarViewModel{Brand: "Gotth", Links: []ui.Link{{Label: "Blog", Href: "/blog"}}})
+//	@ui.Hero(ui.HeroViewModel{
+//		Title:   "Get your site online fast",
+//		Actions: []ui.ButtonViewModel{{Label: "Start", Href: "/docs"}},
+//	})
+//
+// The colors, borders and corner radius come from the [Theme] of the request, set with the
+// [WithTheme] middleware, and every view model has a Class field whose utilities override the
+// component defaults (see classes.Join).
+//
+// Tailwind only generates the classes it finds in the sources, so add this package to them,
+// e.g. with `@source "<path to the gotth module>/views/components/ui";` in your stylesheet.
+package ui
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/a-h/templ"
+	"github.com/ancalabrese/gotth/classes"
+	"github.com/ancalabrese/gotth/nav"
+)
+
+// Theme holds the Tailwind classes shared by the components.
+type Theme struct {
+	Primary   string // Primary actions, e.g. the default button.
+	Secondary string // Secondary actions.
+	Danger    string // Destructive actions.
+	Ghost     string // Actions without a background, e.g. navbar links.
+	Active    string // Navbar link of the current page.
+	Surface   string // Background and text of navbars, footers and cards.
+	Muted     string // Secondary text.
+	Border    string // Border color of surfaces.
+	Rounded   string // Corner radius of buttons and cards.
+	Focus     string // Focus ring of interactive elements.
+}
+
+// DefaultTheme returns the default Theme: sky blue actions on white (or slate, in dark mode)
+// surfaces.
+func DefaultTheme() Theme {
+	return Theme{
+		Primary:   "bg-sky-700 text-white hover:bg-sky-800",
+		Secondary: "bg-slate-200 text-slate-900 hover:bg-slate-300 dark:bg-slate-700 dark:text-slate-100 dark:hover:bg-slate-600",
+		Danger:    "bg-red-700 text-white hover:bg-red-800",
+		Ghost:     "text-slate-700 hover:bg-slate-100 dark:text-slate-200 dark:hover:bg-slate-800",
+		Active:    "bg-slate-100 text-sky-700 dark:bg-slate-800 dark:text-sky-400",
+		Surface:   "bg-white text-slate-900 dark:bg-slate-900 dark:text-slate-100",
+		Muted:     "text-slate-500 dark:text-slate-400",
+		Border:    "border-slate-200 dark:border-slate-700",
+		Rounded:   "rounded-lg",
+		Focus:     "focus-visible:outline-none focus-visible:ring-2 focus-visible:ring-sky-500 focus-visible:ring-offset-2",
+	}
+}
+
+type themeCtxKey string
+
+const themeKey themeCtxKey = "ui-theme"
+
+// WithTheme returns a middleware that sets the Theme of the components rendered for the request.
+func WithTheme(t Theme) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(ContextWithTheme(r.Context(), t)))
+		})
+	}
+}
+
+// ContextWithTheme returns a copy of ctx carrying t.
+func ContextWithTheme(ctx context.Context, t Theme) context.Context {
+	return context.WithValue(ctx, themeKey, t)
+}
+
+// CurrentTheme returns the Theme set by [WithTheme], or [DefaultTheme].
+func CurrentTheme(ctx context.Context) Theme {
+	if t, ok := ctx.Value(themeKey).(Theme); ok {
+		return t
+	}
+	return DefaultTheme()
+}
+
+// Link is a navigation link of the navbar and footer.
+type Link struct {
+	Label string
+	Href  string
+	// Match selects how the navbar compares Href with the current page to mark the link active
+	// (see nav.IsActive). Defaults to nav.Prefix.
+	Match nav.Match
+}
+
+// ButtonVariant selects the Theme classes of a button.
+type ButtonVariant string
+
+const (
+	ButtonPrimary   ButtonVariant = "primary"
+	ButtonSecondary ButtonVariant = "secondary"
+	ButtonDanger    ButtonVariant = "danger"
+	ButtonGhost     ButtonVariant = "ghost"
+)
+
+// ButtonSize selects the padding and font size of a button.
+type ButtonSize string
+
+const (
+	ButtonSmall  ButtonSize = "sm"
+	ButtonMedium ButtonSize = "md"
+	ButtonLarge  ButtonSize = "lg"
+)
+
+// ButtonViewModel is the view model of [Button]. The zero value of Variant and Size is a
+// medium primary button.
+type ButtonViewModel struct {
+	Label   string
+	Variant ButtonVariant
+	Size    ButtonSize
+	// Href renders the button as a link.
+	Href string
+	// Type of the button element (defaults to "button"). Ignored for links.
+	Type     string
+	Disabled bool
+	// Attributes are added to the element, e.g. hx-post or hx-target.
+	Attributes templ.Attributes
+	// Class overrides the default classes.
+	Class string
+}
+
+func (vm ButtonViewModel) class(t Theme) string {
+	variant := t.Primary
+	switch vm.Variant {
+	case ButtonSecondary:
+		variant = t.Secondary
+	case ButtonDanger:
+		variant = t.Danger
+	case ButtonGhost:
+		variant = t.Ghost
+	}
+	size := "px-4 py-2 text-sm"
+	switch vm.Size {
+	case ButtonSmall:
+		size = "px-3 py-1.5 text-xs"
+	case ButtonLarge:
+		size = "px-6 py-3 text-base"
+	}
+	return classes.Merge(
+		"inline-flex items-center justify-center gap-2 font-semibold transition-colors "+size+" "+t.Rounded+" "+variant+" "+t.Focus+" "+vm.Class,
+		map[string]bool{"opacity-50 pointer-events-none": vm.Disabled},
+	)
+}
+
+func (vm ButtonViewModel) buttonType() string {
+	if vm.Type == "" {
+		return "button"
+	}
+	return vm.Type
+}
+
+// NavbarViewModel is the view model of [Navbar].
+type NavbarViewModel struct {
+	Brand string
+	// BrandHref is the link of the brand (defaults to "/").
+	BrandHref string
+	// LogoSrc is an optional logo shown before the brand.
+	LogoSrc string
+	Links   []Link
+	// Actions are shown after the links, e.g. a login button.
+	Actions []ButtonViewModel
+	Class   string
+}
+
+func (vm NavbarViewModel) brandHref() string {
+	if vm.BrandHref == "" {
+		return "/"
+	}
+	return vm.BrandHref
+}
+
+// FooterViewModel is the view model of [Footer].
+type FooterViewModel struct {
+	// Text is shown next to the links, e.g. a copyright notice.
+	Text  string
+	Links []Link
+	Class string
+}
+
+// HeroViewModel is the view model of [Hero].
+type HeroViewModel struct {
+	Title    string
+	Subtitle string
+	ImageSrc string
+	ImageAlt string
+	Actions  []ButtonViewModel
+	Class    string
+}
+
+// CardViewModel is the view model of [Card].
+type CardViewModel struct {
+	Title    string
+	ImageSrc string
+	ImageAlt string
+	// Href makes the title a link.
+	Href  string
+	Class string
+}
+
+func (l Link) class(ctx context.Context, t Theme) string {
+	return classes.Merge("px-3 py-2 text-sm font-medium "+t.Rounded+" "+t.Ghost+" "+t.Focus, map[string]bool{t.Active: nav.IsActive(ctx, l.Href, l.Match)})
+}
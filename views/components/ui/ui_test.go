@@ -0,0 +1,127 @@
+package ui_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/a-h/templ"
+	"github.com/ancalabrese/gotth/nav"
+	"github.com/ancalabrese/gotth/views/components/ui"
+)
+
+func render(t *testing.T, ctx context.Context, c templ.Component) string {
+	t.Helper()
+	var b strings.Builder
+	if err := c.Render(ctx, &b); err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	return b.String()
+}
+
+func TestComponents(t *testing.T) {
+	tests := []struct {
+		name     string
+		c        templ.Component
+		want     []string
+		dontWant []string
+	}{
+		{
+			name: "Primary button",
+			c:    ui.Button(ui.ButtonViewModel{Label: "Save", Type: "submit", Attributes: templ.Attributes{"hx-post": "/save"}}),
+			want: []string{`<button type="submit"`, "bg-sky-700", "px-4 py-2 text-sm", `hx-post="/save"`, ">Save</button>"},
+		},
+		{
+			name:     "Danger link button with class override",
+			c:        ui.Button(ui.ButtonViewModel{Label: "Delete", Href: "/delete", Variant: ui.ButtonDanger, Size: ui.ButtonLarge, Class: "px-8"}),
+			want:     []string{`<a href="/delete"`, "bg-red-700", `px-8"`, "py-3"},
+			dontWant: []string{"bg-sky-700", "px-6"},
+		},
+		{
+			name: "Disabled button",
+			c:    ui.Button(ui.ButtonViewModel{Label: "Wait", Disabled: true}),
+			want: []string{`type="button"`, "opacity-50", " disabled"},
+		},
+		{
+			name: "Navbar",
+			c: ui.Navbar(ui.NavbarViewModel{
+				Brand:   "Gotth",
+				LogoSrc: "/static/gotth.svg",
+				Links:   []ui.Link{{Label: "Blog", Href: "/blog"}},
+				Actions: []ui.ButtonViewModel{{Label: "Login", Href: "/login"}},
+			}),
+			want: []string{`<a href="/" class="flex items-center gap-2 text-lg font-bold"><img src="/static/gotth.svg"`, `href="/blog"`, ">Blog</a>", `href="/login"`, "<details"},
+		},
+		{
+			name:     "Navbar without links",
+			c:        ui.Navbar(ui.NavbarViewModel{Brand: "Gotth", BrandHref: "/home"}),
+			want:     []string{`<a href="/home"`},
+			dontWant: []string{"<details"},
+		},
+		{
+			name: "Footer",
+			c:    ui.Footer(ui.FooterViewModel{Text: "© Gotth", Links: []ui.Link{{Label: "Privacy", Href: "/privacy"}}}),
+			want: []string{"<footer", "© Gotth", `href="/privacy"`},
+		},
+		{
+			name:     "Hero",
+			c:        ui.Hero(ui.HeroViewModel{Title: "Fast <sites>", Subtitle: "Go + HTMX", Actions: []ui.ButtonViewModel{{Label: "Start", Href: "/docs"}}}),
+			want:     []string{"<h1", "Fast &lt;sites&gt;", "Go + HTMX", `href="/docs"`},
+			dontWant: []string{"<img"},
+		},
+		{
+			name:     "Card",
+			c:        ui.Card(ui.CardViewModel{Title: "Post", Href: "/posts/1", ImageSrc: "/img.png", ImageAlt: "Cover", Class: "rounded-none"}, templ.Raw("<p>Body</p>")),
+			want:     []string{"<article", "rounded-none", `<img src="/img.png" alt="Cover"`, `<a href="/posts/1" class="hover:underline">Post</a>`, "<p>Body</p>"},
+			dontWant: []string{"rounded-lg"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := render(t, context.Background(), tt.c)
+			for _, w := range tt.want {
+				if !strings.Contains(got, w) {
+					t.Errorf("output does not contain %q\noutput: %s", w, got)
+				}
+			}
+			for _, d := range tt.dontWant {
+				if strings.Contains(got, d) {
+					t.Errorf("output unexpectedly contains %q\noutput: %s", d, got)
+				}
+			}
+		})
+	}
+}
+
+func TestWithTheme(t *testing.T) {
+	theme := ui.DefaultTheme()
+	theme.Primary = "bg-emerald-700 text-white"
+
+	var got string
+	h := ui.WithTheme(theme)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = render(t, r.Context(), ui.Button(ui.ButtonViewModel{Label: "Go"}))
+	}))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if !strings.Contains(got, "bg-emerald-700") || strings.Contains(got, "bg-sky-700") {
+		t.Errorf("theme not applied: %s", got)
+	}
+}
+
+func TestNavbar_ActiveLink(t *testing.T) {
+	ctx := nav.ContextWithPath(context.Background(), "/blog/first-post")
+	got := render(t, ctx, ui.Navbar(ui.NavbarViewModel{
+		Brand: "Gotth",
+		Links: []ui.Link{{Label: "Home", Href: "/"}, {Label: "Blog", Href: "/blog"}, {Label: "Posts", Href: "/blog", Match: nav.Exact}},
+	}))
+
+	if n := strings.Count(got, `aria-current="page"`); n != 2 { // Desktop and mobile menus.
+		t.Errorf("aria-current count = %d, want 2\noutput: %s", n, got)
+	}
+	if !strings.Contains(got, `text-sky-700 dark:bg-slate-800 dark:text-sky-400" aria-current="page">Blog</a>`) {
+		t.Errorf("Blog link not active: %s", got)
+	}
+}
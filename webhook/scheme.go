@@ -0,0 +1,148 @@
+package webhook
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Signature is the signature of a delivery, read from its headers by a [Scheme].
+type Signature struct {
+	// Optional: ID and Type of the delivery, see [Event].
+	ID   string
+	Type string
+	// Timestamp is the signed time of the delivery, zero when the scheme doesn't sign one.
+	Timestamp time.Time
+	// Payload is the signed content, e.g. the body or the timestamp and the body.
+	Payload []byte
+	// MACs are the signatures of Payload sent with the delivery, one per secret of the service.
+	MACs [][]byte
+}
+
+// Scheme describes how a service signs its deliveries.
+type Scheme struct {
+	Name string
+	// IDHeader is the header of the delivery ID, empty when the scheme has none.
+	IDHeader string
+	// Hash of the HMAC of the signatures.
+	Hash func() hash.Hash
+	// Parse reads the signature of a delivery. It returns an error wrapping
+	// [ErrMissingSignature] or [ErrInvalidSignature] when the headers are missing or malformed.
+	Parse func(h http.Header, body []byte) (Signature, error)
+	// Sign sets the signature headers of a delivery of body signed with secret at now, e.g. to
+	// test a Receiver or to send deliveries (see the events package). The ID header already set
+	// is kept, so that the retries of a delivery share its ID.
+	Sign func(h http.Header, body, secret []byte, now time.Time)
+}
+
+// GitHub signs the body in X-Hub-Signature-256 ("sha256=<hex>"), with the delivery ID in
+// X-GitHub-Delivery and the event type in X-GitHub-Event. Its deliveries have no timestamp.
+var GitHub = Scheme{
+	Name:     "github",
+	IDHeader: "X-GitHub-Delivery",
+	Hash:     sha256.New,
+	Parse: func(h http.Header, body []byte) (Signature, error) {
+		value := h.Get("X-Hub-Signature-256")
+		if value == "" {
+			return Signature{}, fmt.Errorf("%w: no X-Hub-Signature-256", ErrMissingSignature)
+		}
+		encoded, ok := strings.CutPrefix(value, "sha256=")
+		mac, err := hex.DecodeString(encoded)
+		if !ok || err != nil {
+			return Signature{}, fmt.Errorf("%w: malformed X-Hub-Signature-256", ErrInvalidSignature)
+		}
+		return Signature{ID: h.Get("X-GitHub-Delivery"), Type: h.Get("X-GitHub-Event"), Payload: body, MACs: [][]byte{mac}}, nil
+	},
+	Sign: func(h http.Header, body, secret []byte, now time.Time) {
+		if h.Get("X-GitHub-Delivery") == "" {
+			h.Set("X-GitHub-Delivery", rand.Text())
+		}
+		h.Set("X-Hub-Signature-256", "sha256="+hex.EncodeToString(computeMAC(sha256.New, secret, body)))
+	},
+}
+
+// Stripe signs "<timestamp>.<body>" in Stripe-Signature ("t=<unix>,v1=<hex>"). Its deliveries
+// have no ID header: the event ID and type are in the body.
+var Stripe = Scheme{
+	Name: "stripe",
+	Hash: sha256.New,
+	Parse: func(h http.Header, body []byte) (Signature, error) {
+		value := h.Get("Stripe-Signature")
+		if value == "" {
+			return Signature{}, fmt.Errorf("%w: no Stripe-Signature", ErrMissingSignature)
+		}
+		var sig Signature
+		var ts string
+		for item := range strings.SplitSeq(value, ",") {
+			k, v, _ := strings.Cut(strings.TrimSpace(item), "=")
+			switch k {
+			case "t":
+				ts = v
+			case "v1":
+				if mac, err := hex.DecodeString(v); err == nil {
+					sig.MACs = append(sig.MACs, mac)
+				}
+			}
+		}
+		unix, err := strconv.ParseInt(ts, 10, 64)
+		if err != nil || len(sig.MACs) == 0 {
+			return Signature{}, fmt.Errorf("%w: malformed Stripe-Signature", ErrInvalidSignature)
+		}
+		sig.Timestamp = time.Unix(unix, 0)
+		sig.Payload = append([]byte(ts+"."), body...)
+		return sig, nil
+	},
+	Sign: func(h http.Header, body, secret []byte, now time.Time) {
+		ts := strconv.FormatInt(now.Unix(), 10)
+		mac := computeMAC(sha256.New, secret, append([]byte(ts+"."), body...))
+		h.Set("Stripe-Signature", "t="+ts+",v1="+hex.EncodeToString(mac))
+	},
+}
+
+// StandardWebhooks signs "<id>.<timestamp>.<body>" in webhook-signature ("v1,<base64>"), with
+// the webhook-id and webhook-timestamp headers, as specified by standardwebhooks.com and used
+// by Svix, Resend or Clerk. Their secrets are the base64 after the "whsec_" prefix: decode it.
+var StandardWebhooks = Scheme{
+	Name:     "standard",
+	IDHeader: "webhook-id",
+	Hash:     sha256.New,
+	Parse: func(h http.Header, body []byte) (Signature, error) {
+		id, ts, value := h.Get("webhook-id"), h.Get("webhook-timestamp"), h.Get("webhook-signature")
+		if id == "" || ts == "" || value == "" {
+			return Signature{}, fmt.Errorf("%w: no webhook-id, webhook-timestamp or webhook-signature", ErrMissingSignature)
+		}
+		unix, err := strconv.ParseInt(ts, 10, 64)
+		if err != nil {
+			return Signature{}, fmt.Errorf("%w: malformed webhook-timestamp", ErrInvalidSignature)
+		}
+		sig := Signature{ID: id, Timestamp: time.Unix(unix, 0), Payload: append([]byte(id+"."+ts+"."), body...)}
+		for item := range strings.FieldsSeq(value) {
+			if encoded, ok := strings.CutPrefix(item, "v1,"); ok {
+				if mac, err := base64.StdEncoding.DecodeString(encoded); err == nil {
+					sig.MACs = append(sig.MACs, mac)
+				}
+			}
+		}
+		if len(sig.MACs) == 0 {
+			return Signature{}, fmt.Errorf("%w: malformed webhook-signature", ErrInvalidSignature)
+		}
+		return sig, nil
+	},
+	Sign: func(h http.Header, body, secret []byte, now time.Time) {
+		id, ts := h.Get("webhook-id"), strconv.FormatInt(now.Unix(), 10)
+		if id == "" {
+			id = rand.Text()
+		}
+		mac := computeMAC(sha256.New, secret, append([]byte(id+"."+ts+"."), body...))
+		h.Set("webhook-id", id)
+		h.Set("webhook-timestamp", ts)
+		h.Set("webhook-signature", "v1,"+base64.StdEncoding.EncodeToString(mac))
+	},
+}
@@ -0,0 +1,14 @@
+package webhook
+
+import "github.com/ancalabrese/gotth"
+
+// Serve receives the deliveries of rc on ws at path, e.g. "/webhooks/github". Exempt the path
+// from csrf.Protect with csrf.Config.ExemptPaths: the deliveries are authenticated by their
+// signature.
+func Serve(ws *gotth.WebServer, path string, rc *Receiver) {
+	if path == "" || rc == nil {
+		ws.InvalidRoute(path, "webhook.Serve needs a path and a receiver")
+		return
+	}
+	ws.Handle("POST "+path, rc)
+}
@@ -0,0 +1,44 @@
+package webhook_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ancalabrese/gotth"
+	"github.com/ancalabrese/gotth/webhook"
+)
+
+func TestServe(t *testing.T) {
+	secret := []byte("secret")
+	var got string
+	rc, err := webhook.New(webhook.Config{Secrets: [][]byte{secret}, Scheme: webhook.GitHub},
+		func(ctx context.Context, e webhook.Event[struct{ Ref string }]) error {
+			got = e.Payload.Ref
+			return nil
+		})
+	if err != nil {
+		t.Fatalf("webhook.New() error = %v", err)
+	}
+	ws, err := gotth.New(gotth.WebServerConfig{}, nil)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	webhook.Serve(ws, "/webhooks/github", rc)
+	webhook.Serve(ws, "/webhooks/stripe", nil)
+	if err := ws.Err(); err == nil || !strings.Contains(err.Error(), "webhook.Serve needs a path and a receiver") {
+		t.Errorf("Err() = %v, want the nil receiver", err)
+	}
+
+	body := `{"ref":"refs/heads/main"}`
+	req := httptest.NewRequest(http.MethodPost, "/webhooks/github", strings.NewReader(body))
+	webhook.GitHub.Sign(req.Header, []byte(body), secret, time.Now())
+	rr := httptest.NewRecorder()
+	ws.Handler().ServeHTTP(rr, req)
+	if rr.Code != http.StatusNoContent || got != "refs/heads/main" {
+		t.Errorf("delivery = %d, ref %q", rr.Code, got)
+	}
+}
@@ -0,0 +1,61 @@
+package webhook
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Store remembers the deliveries processed by a [Receiver], to reject their replays.
+type Store interface {
+	// Claim records key for ttl. It returns false when key is already recorded.
+	Claim(ctx context.Context, key string, ttl time.Duration) (bool, error)
+	// Release forgets key, e.g. after a failed delivery, so that its retry is processed.
+	Release(ctx context.Context, key string) error
+}
+
+// MemoryStore is a [Store] in memory, for a single instance.
+type MemoryStore struct {
+	mu      sync.Mutex
+	keys    map[string]time.Time // Expiry of the keys
+	sweepAt int                  // Size of keys sweeping the expired ones
+	now     func() time.Time
+}
+
+// NewMemoryStore returns an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{keys: map[string]time.Time{}, sweepAt: 1024, now: time.Now}
+}
+
+func (s *MemoryStore) Claim(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	if exp, ok := s.keys[key]; ok && now.Before(exp) {
+		return false, nil
+	}
+	s.keys[key] = now.Add(ttl)
+	if len(s.keys) >= s.sweepAt {
+		for k, exp := range s.keys {
+			if !now.Before(exp) {
+				delete(s.keys, k)
+			}
+		}
+		s.sweepAt = max(1024, 2*len(s.keys))
+	}
+	return true, nil
+}
+
+func (s *MemoryStore) Release(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.keys, key)
+	return nil
+}
+
+// Len returns the number of deliveries remembered, including the expired ones not swept yet.
+func (s *MemoryStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.keys)
+}
@@ -0,0 +1,217 @@
+// Package webhook receives the webhooks of third-party services, e.g. Stripe or GitHub, safely
+// by default: the signature of every delivery is verified against the raw body, stale and
+// replayed deliveries are rejected, and the payload is decoded into a typed event.
+//
+//	rc, err := webhook.New(webhook.Config{Secrets: [][]byte{secret}, Scheme: webhook.GitHub},
+//		func(ctx context.Context, e webhook.Event[PushEvent]) error {
+//			return rebuild(ctx, e.Payload.Ref)
+//		})
+//	webhook.Serve(ws, "/webhooks/github", rc)
+//
+// Failed handlers get a 500 so that the service retries the delivery. Deliveries aren't
+// protected by csrf.Protect: exempt their paths with csrf.Config.ExemptPaths.
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+const (
+	// DefaultTolerance is the maximum age of the signed timestamp of a delivery.
+	DefaultTolerance = 5 * time.Minute
+	// DefaultMaxBodyBytes is the maximum size of a delivery.
+	DefaultMaxBodyBytes = 1 << 20
+	// DefaultDedupeTTL is how long delivery IDs are remembered to reject replays.
+	DefaultDedupeTTL = 24 * time.Hour
+)
+
+var (
+	// ErrMissingSignature is returned for deliveries without the headers of their Scheme.
+	ErrMissingSignature = errors.New("webhook: missing signature")
+	// ErrInvalidSignature is returned for deliveries whose signature doesn't match any secret.
+	ErrInvalidSignature = errors.New("webhook: invalid signature")
+	// ErrExpired is returned for deliveries signed longer than Config.Tolerance ago.
+	ErrExpired = errors.New("webhook: signature expired")
+	// ErrInvalidPayload is returned for deliveries whose body doesn't decode into the payload.
+	ErrInvalidPayload = errors.New("webhook: invalid payload")
+)
+
+// Event is a verified delivery and its decoded payload.
+type Event[T any] struct {
+	// ID identifies the delivery, empty when the scheme has none.
+	ID string
+	// Type is the event type of the headers, e.g. X-GitHub-Event, empty when the scheme has none.
+	Type string
+	// Timestamp is the signed time of the delivery, zero when the scheme has none.
+	Timestamp time.Time
+	Payload   T
+	// Body is the raw body the signature was computed on.
+	Body   []byte
+	Header http.Header
+}
+
+// HandlerFunc processes a verified event. Returning an error answers with a 500 and forgets the
+// delivery, so that the service can retry it.
+type HandlerFunc[T any] func(ctx context.Context, e Event[T]) error
+
+// Config configures a [Receiver].
+type Config struct {
+	// Required: signing secrets, the current one first, then the previous ones while rotating.
+	Secrets [][]byte
+	// Required: how the deliveries are signed, e.g. [GitHub] or [Stripe].
+	Scheme Scheme
+	// Tolerance is the maximum age of the signed timestamps. Defaults to [DefaultTolerance].
+	Tolerance time.Duration
+	// MaxBodyBytes is the maximum size of a delivery. Defaults to [DefaultMaxBodyBytes].
+	MaxBodyBytes int64
+	// Optional: remembers the deliveries to reject replays. Defaults to a [MemoryStore], use a
+	// shared one with several instances.
+	Store Store
+	// DedupeTTL is how long deliveries are remembered. Defaults to [DefaultDedupeTTL].
+	DedupeTTL time.Duration
+	// Optional: logs the rejected deliveries and failures. Defaults to slog.Default().
+	Logger *slog.Logger
+}
+
+// Receiver is the http.Handler verifying and processing the deliveries of a webhook.
+type Receiver struct {
+	cfg    Config
+	logger *slog.Logger
+	handle func(ctx context.Context, sig Signature, body []byte, h http.Header) error
+}
+
+// New returns the Receiver of the deliveries of cfg.Scheme, decoding their JSON body into T
+// for fn. Use json.RawMessage as T to decode the body in fn.
+func New[T any](cfg Config, fn HandlerFunc[T]) (*Receiver, error) {
+	if len(cfg.Secrets) == 0 || fn == nil {
+		return nil, errors.New("webhook: a receiver needs secrets and a handler")
+	}
+	for _, secret := range cfg.Secrets {
+		if len(secret) == 0 {
+			return nil, errors.New("webhook: empty secret")
+		}
+	}
+	if cfg.Scheme.Parse == nil || cfg.Scheme.Hash == nil {
+		return nil, errors.New("webhook: a receiver needs a scheme")
+	}
+	if cfg.Tolerance <= 0 {
+		cfg.Tolerance = DefaultTolerance
+	}
+	if cfg.MaxBodyBytes <= 0 {
+		cfg.MaxBodyBytes = DefaultMaxBodyBytes
+	}
+	if cfg.Store == nil {
+		cfg.Store = NewMemoryStore()
+	}
+	if cfg.DedupeTTL <= 0 {
+		cfg.DedupeTTL = DefaultDedupeTTL
+	}
+	logger := cfg.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	handle := func(ctx context.Context, sig Signature, body []byte, h http.Header) error {
+		e := Event[T]{ID: sig.ID, Type: sig.Type, Timestamp: sig.Timestamp, Body: body, Header: h}
+		if err := json.Unmarshal(body, &e.Payload); err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidPayload, err)
+		}
+		return fn(ctx, e)
+	}
+	return &Receiver{cfg: cfg, logger: logger, handle: handle}, nil
+}
+
+func (rc *Receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, rc.cfg.MaxBodyBytes))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+
+	sig, mac, err := rc.verify(r.Header, body)
+	if err != nil {
+		rc.logger.WarnContext(r.Context(), "webhook delivery rejected", slog.String("path", r.URL.Path), slog.Any("error", err))
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	}
+
+	// Deliveries without ID are identified by their signature, unique with the timestamp.
+	key := rc.cfg.Scheme.Name + ":" + sig.ID
+	if sig.ID == "" {
+		key = fmt.Sprintf("%s:%x", rc.cfg.Scheme.Name, mac)
+	}
+	first, err := rc.cfg.Store.Claim(r.Context(), key, rc.cfg.DedupeTTL)
+	if err != nil {
+		rc.logger.ErrorContext(r.Context(), "failed to record webhook delivery", slog.String("path", r.URL.Path), slog.Any("error", err))
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	if !first {
+		// Acknowledged, so that the service stops retrying, but not processed again.
+		rc.logger.DebugContext(r.Context(), "duplicate webhook delivery", slog.String("path", r.URL.Path), slog.String("id", sig.ID))
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if err := rc.handle(r.Context(), sig, body, r.Header); err != nil {
+		if releaseErr := rc.cfg.Store.Release(r.Context(), key); releaseErr != nil {
+			err = errors.Join(err, releaseErr)
+		}
+		if errors.Is(err, ErrInvalidPayload) {
+			rc.logger.WarnContext(r.Context(), "webhook delivery rejected", slog.String("path", r.URL.Path), slog.Any("error", err))
+			http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+			return
+		}
+		rc.logger.ErrorContext(r.Context(), "webhook handler failed", slog.String("path", r.URL.Path), slog.String("id", sig.ID), slog.Any("error", err))
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// verify checks the signature of a delivery and its timestamp, returning the matching MAC.
+func (rc *Receiver) verify(h http.Header, body []byte) (Signature, []byte, error) {
+	sig, err := rc.cfg.Scheme.Parse(h, body)
+	if err != nil {
+		return Signature{}, nil, err
+	}
+	if !sig.Timestamp.IsZero() {
+		if age := time.Since(sig.Timestamp); age > rc.cfg.Tolerance || age < -rc.cfg.Tolerance {
+			return Signature{}, nil, fmt.Errorf("%w: signed at %s", ErrExpired, sig.Timestamp.Format(time.RFC3339))
+		}
+	}
+	for _, secret := range rc.cfg.Secrets {
+		want := computeMAC(rc.cfg.Scheme.Hash, secret, sig.Payload)
+		for _, mac := range sig.MACs {
+			if hmac.Equal(mac, want) {
+				return sig, mac, nil
+			}
+		}
+	}
+	return Signature{}, nil, ErrInvalidSignature
+}
+
+func computeMAC(h func() hash.Hash, secret, payload []byte) []byte {
+	mac := hmac.New(h, secret)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
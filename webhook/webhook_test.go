@@ -0,0 +1,149 @@
+package webhook_test
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ancalabrese/gotth/webhook"
+)
+
+type payment struct {
+	ID     string `json:"id"`
+	Amount int    `json:"amount"`
+}
+
+const body = `{"id":"evt_1","amount":1200}`
+
+var (
+	secret    = []byte("current")
+	oldSecret = []byte("previous")
+)
+
+// delivery returns a request of body signed by scheme with secret at t.
+func delivery(scheme webhook.Scheme, body string, secret []byte, t time.Time) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/webhooks", strings.NewReader(body))
+	scheme.Sign(req.Header, []byte(body), secret, t)
+	return req
+}
+
+func serve(rc http.Handler, req *http.Request) int {
+	rr := httptest.NewRecorder()
+	rc.ServeHTTP(rr, req)
+	return rr.Code
+}
+
+func TestReceiver(t *testing.T) {
+	for _, scheme := range []webhook.Scheme{webhook.GitHub, webhook.Stripe, webhook.StandardWebhooks} {
+		t.Run(scheme.Name, func(t *testing.T) {
+			var events []webhook.Event[payment]
+			rc, err := webhook.New(webhook.Config{Secrets: [][]byte{secret, oldSecret}, Scheme: scheme, Logger: slog.New(slog.DiscardHandler)},
+				func(ctx context.Context, e webhook.Event[payment]) error {
+					events = append(events, e)
+					return nil
+				})
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+
+			req := delivery(scheme, body, secret, time.Now())
+			if code := serve(rc, req); code != http.StatusNoContent {
+				t.Fatalf("status = %d, want %d", code, http.StatusNoContent)
+			}
+			if len(events) != 1 || events[0].Payload != (payment{ID: "evt_1", Amount: 1200}) || string(events[0].Body) != body {
+				t.Fatalf("events = %+v", events)
+			}
+
+			// The same delivery is acknowledged but not processed again.
+			replay := httptest.NewRequest(http.MethodPost, "/webhooks", strings.NewReader(body))
+			replay.Header = req.Header
+			if code := serve(rc, replay); code != http.StatusOK || len(events) != 1 {
+				t.Errorf("replay = %d, %d events, want %d and no new event", code, len(events), http.StatusOK)
+			}
+			if code := serve(rc, delivery(scheme, body, oldSecret, time.Now())); code != http.StatusNoContent {
+				t.Errorf("status with the previous secret = %d, want %d", code, http.StatusNoContent)
+			}
+
+			tampered := delivery(scheme, body, secret, time.Now())
+			tampered.Body = http.NoBody
+			rejected := map[string]*http.Request{
+				"Tampered body":   tampered,
+				"Unknown secret":  delivery(scheme, body, []byte("other"), time.Now()),
+				"Missing headers": httptest.NewRequest(http.MethodPost, "/webhooks", strings.NewReader(body)),
+			}
+			if scheme.Name != "github" {
+				rejected["Expired"] = delivery(scheme, body, secret, time.Now().Add(-time.Hour))
+			}
+			for name, req := range rejected {
+				if code := serve(rc, req); code != http.StatusUnauthorized {
+					t.Errorf("%s: status = %d, want %d", name, code, http.StatusUnauthorized)
+				}
+			}
+			if len(events) != 2 {
+				t.Errorf("events = %d, want only the valid deliveries", len(events))
+			}
+		})
+	}
+}
+
+func TestReceiver_Failures(t *testing.T) {
+	fail := true
+	rc, err := webhook.New(webhook.Config{Secrets: [][]byte{secret}, Scheme: webhook.Stripe, MaxBodyBytes: 64, Logger: slog.New(slog.DiscardHandler)},
+		func(ctx context.Context, e webhook.Event[payment]) error {
+			if fail {
+				return errors.New("db down")
+			}
+			return nil
+		})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	// A failed delivery is processed when retried.
+	now := time.Now()
+	if code := serve(rc, delivery(webhook.Stripe, body, secret, now)); code != http.StatusInternalServerError {
+		t.Errorf("status of a failed handler = %d, want %d", code, http.StatusInternalServerError)
+	}
+	fail = false
+	if code := serve(rc, delivery(webhook.Stripe, body, secret, now)); code != http.StatusNoContent {
+		t.Errorf("status of the retry = %d, want %d", code, http.StatusNoContent)
+	}
+
+	if code := serve(rc, delivery(webhook.Stripe, `{"amount":"twelve"}`, secret, now)); code != http.StatusBadRequest {
+		t.Errorf("status of an invalid payload = %d, want %d", code, http.StatusBadRequest)
+	}
+	if code := serve(rc, delivery(webhook.Stripe, strings.Repeat(" ", 65)+body, secret, now)); code != http.StatusRequestEntityTooLarge {
+		t.Errorf("status of a large body = %d, want %d", code, http.StatusRequestEntityTooLarge)
+	}
+	if code := serve(rc, httptest.NewRequest(http.MethodGet, "/webhooks", nil)); code != http.StatusMethodNotAllowed {
+		t.Errorf("status of a GET = %d, want %d", code, http.StatusMethodNotAllowed)
+	}
+
+	if _, err := webhook.New(webhook.Config{Scheme: webhook.GitHub}, func(context.Context, webhook.Event[payment]) error { return nil }); err == nil {
+		t.Error("New() without secrets error = nil")
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	s := webhook.NewMemoryStore()
+	if ok, _ := s.Claim(ctx, "a", time.Hour); !ok {
+		t.Error("first Claim() = false")
+	}
+	if ok, _ := s.Claim(ctx, "a", time.Hour); ok {
+		t.Error("second Claim() = true")
+	}
+	s.Release(ctx, "a")
+	if ok, _ := s.Claim(ctx, "a", time.Nanosecond); !ok {
+		t.Error("Claim() after Release() = false")
+	}
+	time.Sleep(time.Millisecond)
+	if ok, _ := s.Claim(ctx, "a", time.Hour); !ok || s.Len() != 1 {
+		t.Error("Claim() of an expired key = false")
+	}
+}
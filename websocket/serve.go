@@ -0,0 +1,10 @@
+package websocket
+
+import "github.com/ancalabrese/gotth"
+
+// Serve upgrades the requests at path on ws to WebSocket connections handled by cfg. The
+// connections are closed when the server starts shutting down: the graceful shutdown doesn't
+// track hijacked connections.
+func Serve(ws *gotth.WebServer, path string, cfg Config) {
+	ws.Handle(path, ws.CloseOnShutdown(Handler(cfg)))
+}
@@ -0,0 +1,54 @@
+package websocket_test
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/ancalabrese/gotth"
+	"github.com/ancalabrese/gotth/websocket"
+	cws "github.com/coder/websocket"
+)
+
+func TestServe_ShutdownClosesConnections(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	ws, err := gotth.NewWithOptions(gotth.WithAddr(addr), gotth.WithLogger(slog.New(slog.DiscardHandler)))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	closed := make(chan struct{})
+	websocket.Serve(ws, "GET /ws", websocket.Config{OnClose: func(*websocket.Conn) { close(closed) }})
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- ws.Start(ctx) }()
+
+	var conn *cws.Conn
+	for range 50 {
+		if conn, _, err = cws.Dial(context.Background(), "ws://"+addr+"/ws", nil); err == nil {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	defer conn.CloseNow()
+
+	cancel()
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("connection not closed by the shutdown")
+	}
+	if err := <-done; err != nil {
+		t.Errorf("Start() error = %v", err)
+	}
+}
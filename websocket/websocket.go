@@ -0,0 +1,280 @@
+// Package websocket serves WebSocket connections for the htmx ws extension
+// (https://htmx.org/extensions/ws/), sending rendered templ fragments to the browser:
+//
+//	websocket.Serve(ws, "GET /chat", websocket.Config{
+//		OnMessage: func(c *websocket.Conn, msg websocket.Message) error {
+//			user := middlewares.GetUser(c.Context())
+//			return c.SendFragment(views.ChatMessage(user, msg.Values.Get("text")))
+//		},
+//	})
+//
+// and in the page: <div hx-ext="ws" ws-connect="/chat"><form ws-send>...</form></div>.
+// The connection context is the request context, so values set by the middlewares (e.g.,
+// the session user) are available to the callbacks.
+package websocket
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/a-h/templ"
+	cws "github.com/coder/websocket"
+)
+
+const (
+	DefaultSendBuffer   = 16
+	DefaultPingInterval = 30 * time.Second
+	DefaultWriteTimeout = 10 * time.Second
+	DefaultReadLimit    = 32 << 10
+)
+
+var (
+	// ErrClosed is returned when sending on a closed connection.
+	ErrClosed = errors.New("websocket connection closed")
+	// ErrSendBufferFull is returned when the client doesn't keep up with the messages sent to it.
+	ErrSendBufferFull = errors.New("websocket send buffer full")
+)
+
+// Config configures the WebSocket handler. Use [DefaultConfig] as a starting point.
+type Config struct {
+	// OnConnect is called once the connection is upgraded. Returning an error closes it.
+	OnConnect func(*Conn) error
+	// OnMessage is called for each message received from the client, one at a time.
+	// Returning an error closes the connection.
+	OnMessage func(*Conn, Message) error
+	// OnClose is called once the connection is closed.
+	OnClose func(*Conn)
+	// OriginPatterns authorizes cross-origin connections. The request host is always authorized.
+	OriginPatterns []string
+	// SendBuffer is the number of outgoing messages queued per connection.
+	SendBuffer int
+	// PingInterval is how often the client is pinged to detect dead connections.
+	PingInterval time.Duration
+	// WriteTimeout bounds each write to the client.
+	WriteTimeout time.Duration
+	// ReadLimit is the maximum size in bytes of a message from the client.
+	ReadLimit int64
+}
+
+// DefaultConfig returns the default Config, without callbacks.
+func DefaultConfig() Config {
+	return Config{
+		SendBuffer:   DefaultSendBuffer,
+		PingInterval: DefaultPingInterval,
+		WriteTimeout: DefaultWriteTimeout,
+		ReadLimit:    DefaultReadLimit,
+	}
+}
+
+// Message is a message received from the client. Messages sent by the htmx ws extension are
+// JSON objects holding the form values and the HTMX request headers, which are parsed into
+// Values and Headers.
+type Message struct {
+	Data    []byte
+	Values  url.Values
+	Headers http.Header
+}
+
+// parseMessage parses the htmx ws extension payload, leaving Values and Headers empty for any
+// other message.
+func parseMessage(data []byte) Message {
+	msg := Message{Data: data, Values: url.Values{}, Headers: http.Header{}}
+
+	var fields map[string]any
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return msg
+	}
+	for k, v := range fields {
+		if k == "HEADERS" {
+			if headers, ok := v.(map[string]any); ok {
+				for name, value := range headers {
+					if value != nil {
+						msg.Headers.Set(name, fmt.Sprint(value))
+					}
+				}
+			}
+			continue
+		}
+		switch v := v.(type) {
+		case nil:
+		case []any:
+			for _, e := range v {
+				msg.Values.Add(k, fmt.Sprint(e))
+			}
+		default:
+			msg.Values.Set(k, fmt.Sprint(v))
+		}
+	}
+	return msg
+}
+
+// Conn is a WebSocket connection. Its methods are safe for concurrent use.
+type Conn struct {
+	ctx  context.Context
+	conn *cws.Conn
+	send chan []byte
+
+	closeOnce sync.Once
+	mu        sync.RWMutex
+	closed    bool
+}
+
+// Context returns the connection context, which derives from the request context and is done
+// once the connection is closed.
+func (c *Conn) Context() context.Context {
+	return c.ctx
+}
+
+// Send queues a text message for the client. It doesn't block: it returns [ErrSendBufferFull]
+// when the client doesn't keep up and [ErrClosed] once the connection is closed.
+func (c *Conn) Send(msg []byte) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.closed {
+		return ErrClosed
+	}
+	select {
+	case c.send <- msg:
+		return nil
+	default:
+		return ErrSendBufferFull
+	}
+}
+
+// SendFragment renders component and sends it to the client. The htmx ws extension swaps the
+// elements of the fragment by id, as out-of-band swaps.
+func (c *Conn) SendFragment(component templ.Component) error {
+	var buf bytes.Buffer
+	if err := component.Render(c.ctx, &buf); err != nil {
+		return fmt.Errorf("failed to render fragment. err %w", err)
+	}
+	return c.Send(buf.Bytes())
+}
+
+// Close closes the connection with a normal closure status.
+func (c *Conn) Close() {
+	c.close(cws.StatusNormalClosure, "")
+}
+
+func (c *Conn) close(code cws.StatusCode, reason string) {
+	c.closeOnce.Do(func() {
+		c.mu.Lock()
+		c.closed = true
+		c.mu.Unlock()
+		// Close waits for the client to acknowledge, so don't hold up the caller. The read pump
+		// returns once the connection is closed.
+		go c.conn.Close(code, reason)
+	})
+}
+
+// Handler returns an http.Handler that upgrades requests to WebSocket connections and calls the
+// callbacks of cfg. Zero values in cfg fall back to the defaults.
+func Handler(cfg Config) http.Handler {
+	def := DefaultConfig()
+	if cfg.SendBuffer <= 0 {
+		cfg.SendBuffer = def.SendBuffer
+	}
+	if cfg.PingInterval <= 0 {
+		cfg.PingInterval = def.PingInterval
+	}
+	if cfg.WriteTimeout <= 0 {
+		cfg.WriteTimeout = def.WriteTimeout
+	}
+	if cfg.ReadLimit <= 0 {
+		cfg.ReadLimit = def.ReadLimit
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The server timeouts would otherwise cut long-lived connections short.
+		rc := http.NewResponseController(w)
+		rc.SetReadDeadline(time.Time{})
+		rc.SetWriteDeadline(time.Time{})
+
+		conn, err := cws.Accept(w, r, &cws.AcceptOptions{OriginPatterns: cfg.OriginPatterns})
+		if err != nil {
+			// Accept has already written the error response.
+			return
+		}
+		conn.SetReadLimit(cfg.ReadLimit)
+
+		ctx, cancel := context.WithCancel(r.Context())
+		defer cancel()
+		c := &Conn{ctx: ctx, conn: conn, send: make(chan []byte, cfg.SendBuffer)}
+
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.writePump(cfg.PingInterval, cfg.WriteTimeout)
+		}()
+
+		if cfg.OnConnect != nil {
+			if err := cfg.OnConnect(c); err != nil {
+				c.close(cws.StatusInternalError, "")
+			}
+		}
+		c.readPump(cfg.OnMessage)
+
+		c.close(cws.StatusNormalClosure, "")
+		cancel()
+		wg.Wait()
+		if cfg.OnClose != nil {
+			cfg.OnClose(c)
+		}
+	})
+}
+
+// readPump delivers the client messages until the connection is closed.
+func (c *Conn) readPump(onMessage func(*Conn, Message) error) {
+	for {
+		_, data, err := c.conn.Read(c.ctx)
+		if err != nil {
+			return
+		}
+		if onMessage == nil {
+			continue
+		}
+		if err := onMessage(c, parseMessage(data)); err != nil {
+			c.close(cws.StatusInternalError, "")
+			return
+		}
+	}
+}
+
+// writePump writes the queued messages and pings the client until the connection is closed.
+func (c *Conn) writePump(pingInterval, writeTimeout time.Duration) {
+	ticker := time.NewTicker(pingInterval)
+	defer ticker.Stop()
+
+	write := func(f func(ctx context.Context) error) bool {
+		ctx, cancel := context.WithTimeout(c.ctx, writeTimeout)
+		defer cancel()
+		if err := f(ctx); err != nil {
+			c.close(cws.StatusGoingAway, "")
+			return false
+		}
+		return true
+	}
+
+	for {
+		select {
+		case <-c.ctx.Done():
+			return
+		case msg := <-c.send:
+			if !write(func(ctx context.Context) error { return c.conn.Write(ctx, cws.MessageText, msg) }) {
+				return
+			}
+		case <-ticker.C:
+			if !write(c.conn.Ping) {
+				return
+			}
+		}
+	}
+}
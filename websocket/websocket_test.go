@@ -0,0 +1,137 @@
+package websocket_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/a-h/templ"
+	"github.com/ancalabrese/gotth/websocket"
+	cws "github.com/coder/websocket"
+)
+
+type userKey struct{}
+
+func withUser(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), userKey{}, "ada")))
+	})
+}
+
+func dial(t *testing.T, h http.Handler) *cws.Conn {
+	t.Helper()
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	conn, _, err := cws.Dial(ctx, "ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	t.Cleanup(func() { conn.CloseNow() })
+	return conn
+}
+
+func read(t *testing.T, conn *cws.Conn) string {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, data, err := conn.Read(ctx)
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	return string(data)
+}
+
+func TestHandler(t *testing.T) {
+	greeting := func(user, text string) templ.Component {
+		return templ.ComponentFunc(func(ctx context.Context, w io.Writer) error {
+			_, err := io.WriteString(w, `<div id="chat" hx-swap-oob="beforeend"><p>`+user+": "+text+"</p></div>")
+			return err
+		})
+	}
+
+	closed := make(chan struct{})
+	h := websocket.Handler(websocket.Config{
+		OnConnect: func(c *websocket.Conn) error {
+			return c.Send([]byte("welcome"))
+		},
+		OnMessage: func(c *websocket.Conn, msg websocket.Message) error {
+			if msg.Headers.Get("HX-Trigger") != "chat-form" {
+				return errors.New("missing HX-Trigger header")
+			}
+			user, _ := c.Context().Value(userKey{}).(string)
+			return c.SendFragment(greeting(user, msg.Values.Get("text")))
+		},
+		OnClose: func(c *websocket.Conn) {
+			if c.Send([]byte("late")) != websocket.ErrClosed {
+				t.Error("Send() after close must return ErrClosed")
+			}
+			close(closed)
+		},
+	})
+	conn := dial(t, withUser(h))
+
+	if got := read(t, conn); got != "welcome" {
+		t.Errorf("first message = %q, want %q", got, "welcome")
+	}
+
+	ctx := context.Background()
+	msg := `{"text":"hello","HEADERS":{"HX-Request":"true","HX-Trigger":"chat-form","HX-Target":null}}`
+	if err := conn.Write(ctx, cws.MessageText, []byte(msg)); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	want := `<div id="chat" hx-swap-oob="beforeend"><p>ada: hello</p></div>`
+	if got := read(t, conn); got != want {
+		t.Errorf("fragment = %q, want %q", got, want)
+	}
+
+	conn.Close(cws.StatusNormalClosure, "")
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("OnClose not called after the client closed the connection")
+	}
+}
+
+func TestHandler_OnMessageError(t *testing.T) {
+	h := websocket.Handler(websocket.Config{
+		OnMessage: func(c *websocket.Conn, msg websocket.Message) error {
+			return errors.New("boom")
+		},
+	})
+	conn := dial(t, h)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := conn.Write(ctx, cws.MessageText, []byte("hi")); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	_, _, err := conn.Read(ctx)
+	if status := cws.CloseStatus(err); status != cws.StatusInternalError {
+		t.Errorf("close status = %v, want %v (err %v)", status, cws.StatusInternalError, err)
+	}
+}
+
+func TestHandler_RejectsCrossOrigin(t *testing.T) {
+	srv := httptest.NewServer(websocket.Handler(websocket.DefaultConfig()))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, resp, err := cws.Dial(ctx, "ws"+strings.TrimPrefix(srv.URL, "http"), &cws.DialOptions{
+		HTTPHeader: http.Header{"Origin": {"https://evil.example"}},
+	})
+	if err == nil {
+		t.Fatal("Dial() error = nil, want cross-origin rejection")
+	}
+	if resp == nil || resp.StatusCode != http.StatusForbidden {
+		t.Errorf("response = %v, want status %d", resp, http.StatusForbidden)
+	}
+}
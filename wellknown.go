@@ -0,0 +1,165 @@
+package gotth
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// SecurityTxt describes the security.txt file (RFC 9116) served at /.well-known/security.txt.
+type SecurityTxt struct {
+	// Required: URIs to report security issues to (e.g., "mailto:security@example.com").
+	Contact []string
+	// Required: date after which the file should be considered stale.
+	Expires time.Time
+
+	// Optional fields
+	Encryption         []string // URIs of keys for encrypted communication
+	Acknowledgments    []string // URIs of the acknowledgments pages
+	PreferredLanguages []string // e.g., "en", "it"
+	Canonical          []string // URIs where the file is canonically served
+	Policy             []string // URIs of the vulnerability disclosure policy
+	Hiring             []string // URIs of security-related job openings
+}
+
+// Validate checks that the required fields are set.
+func (s SecurityTxt) Validate() error {
+	if len(s.Contact) == 0 {
+		return fmt.Errorf("security.txt requires at least one Contact")
+	}
+	if s.Expires.IsZero() {
+		return fmt.Errorf("security.txt requires Expires")
+	}
+	return nil
+}
+
+// String renders the security.txt content.
+func (s SecurityTxt) String() string {
+	var b strings.Builder
+	writeFields := func(name string, values []string) {
+		for _, v := range values {
+			fmt.Fprintf(&b, "%s: %s\n", name, v)
+		}
+	}
+
+	writeFields("Contact", s.Contact)
+	fmt.Fprintf(&b, "Expires: %s\n", s.Expires.UTC().Format(time.RFC3339))
+	writeFields("Encryption", s.Encryption)
+	writeFields("Acknowledgments", s.Acknowledgments)
+	if len(s.PreferredLanguages) > 0 {
+		fmt.Fprintf(&b, "Preferred-Languages: %s\n", strings.Join(s.PreferredLanguages, ", "))
+	}
+	writeFields("Canonical", s.Canonical)
+	writeFields("Policy", s.Policy)
+	writeFields("Hiring", s.Hiring)
+
+	return b.String()
+}
+
+// HumansTxtPerson is an entry of the TEAM or THANKS sections of humans.txt.
+type HumansTxtPerson struct {
+	Role     string // e.g., "Developer". Ignored in the THANKS section.
+	Name     string
+	Contact  string // e.g., an email address or website
+	Location string
+}
+
+// HumansTxt describes the humans.txt file (https://humanstxt.org) served at /humans.txt.
+type HumansTxt struct {
+	Team   []HumansTxtPerson
+	Thanks []HumansTxtPerson
+	// Site section
+	LastUpdate time.Time
+	Language   string
+	Standards  []string // e.g., "HTML5", "CSS3"
+	Components []string // e.g., "Gotth", "templ", "HTMX"
+	Software   []string
+}
+
+// String renders the humans.txt content.
+func (h HumansTxt) String() string {
+	var b strings.Builder
+	writePerson := func(p HumansTxtPerson, withRole bool) {
+		if withRole && p.Role != "" {
+			fmt.Fprintf(&b, "\t%s: %s\n", p.Role, p.Name)
+		} else {
+			fmt.Fprintf(&b, "\tName: %s\n", p.Name)
+		}
+		if p.Contact != "" {
+			fmt.Fprintf(&b, "\tContact: %s\n", p.Contact)
+		}
+		if p.Location != "" {
+			fmt.Fprintf(&b, "\tLocation: %s\n", p.Location)
+		}
+	}
+	writeSection := func(name string, people []HumansTxtPerson, withRole bool) {
+		if len(people) == 0 {
+			return
+		}
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "/* %s */\n", name)
+		for i, p := range people {
+			if i > 0 {
+				b.WriteString("\n")
+			}
+			writePerson(p, withRole)
+		}
+	}
+
+	writeSection("TEAM", h.Team, true)
+	writeSection("THANKS", h.Thanks, false)
+
+	var site strings.Builder
+	if !h.LastUpdate.IsZero() {
+		fmt.Fprintf(&site, "\tLast update: %s\n", h.LastUpdate.Format("2006/01/02"))
+	}
+	if h.Language != "" {
+		fmt.Fprintf(&site, "\tLanguage: %s\n", h.Language)
+	}
+	if len(h.Standards) > 0 {
+		fmt.Fprintf(&site, "\tStandards: %s\n", strings.Join(h.Standards, ", "))
+	}
+	if len(h.Components) > 0 {
+		fmt.Fprintf(&site, "\tComponents: %s\n", strings.Join(h.Components, ", "))
+	}
+	if len(h.Software) > 0 {
+		fmt.Fprintf(&site, "\tSoftware: %s\n", strings.Join(h.Software, ", "))
+	}
+	if site.Len() > 0 {
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString("/* SITE */\n")
+		b.WriteString(site.String())
+	}
+
+	return b.String()
+}
+
+// EnableSecurityTxt serves s at /.well-known/security.txt and redirects the legacy /security.txt
+// location to it.
+func (ws *WebServer) EnableSecurityTxt(s SecurityTxt) {
+	if err := s.Validate(); err != nil {
+		ws.registrationFailed(fmt.Errorf("failed to validate security.txt. err %w", err))
+		return
+	}
+
+	ws.handle("GET /.well-known/security.txt", textHandler(s.String()), RouteKindBuiltin, 0)
+	ws.handle("GET /security.txt", http.RedirectHandler("/.well-known/security.txt", http.StatusMovedPermanently), RouteKindBuiltin, 0)
+}
+
+// EnableHumansTxt serves h at /humans.txt.
+func (ws *WebServer) EnableHumansTxt(h HumansTxt) {
+	ws.handle("GET /humans.txt", textHandler(h.String()), RouteKindBuiltin, 0)
+}
+
+// textHandler serves content as plain text.
+func textHandler(content string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Write([]byte(content))
+	})
+}
@@ -0,0 +1,93 @@
+package gotth_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ancalabrese/gotth"
+)
+
+func TestSecurityTxt_String(t *testing.T) {
+	s := gotth.SecurityTxt{
+		Contact:            []string{"mailto:security@example.com", "https://example.com/security"},
+		Expires:            time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC),
+		PreferredLanguages: []string{"en", "it"},
+		Canonical:          []string{"https://example.com/.well-known/security.txt"},
+	}
+	expected := "Contact: mailto:security@example.com\n" +
+		"Contact: https://example.com/security\n" +
+		"Expires: 2030-01-02T03:04:05Z\n" +
+		"Preferred-Languages: en, it\n" +
+		"Canonical: https://example.com/.well-known/security.txt\n"
+
+	if got := s.String(); got != expected {
+		t.Errorf("String() =\n%q\nwant\n%q", got, expected)
+	}
+}
+
+func TestSecurityTxt_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       gotth.SecurityTxt
+		wantErr bool
+	}{
+		{name: "Valid", s: gotth.SecurityTxt{Contact: []string{"mailto:a@b.c"}, Expires: time.Now()}},
+		{name: "Missing contact", s: gotth.SecurityTxt{Expires: time.Now()}, wantErr: true},
+		{name: "Missing expiry", s: gotth.SecurityTxt{Contact: []string{"mailto:a@b.c"}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.s.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestHumansTxt_String(t *testing.T) {
+	h := gotth.HumansTxt{
+		Team: []gotth.HumansTxtPerson{
+			{Role: "Developer", Name: "Ada", Contact: "ada@example.com", Location: "London"},
+		},
+		Thanks:     []gotth.HumansTxtPerson{{Name: "Gopher"}},
+		LastUpdate: time.Date(2025, 5, 25, 0, 0, 0, 0, time.UTC),
+		Components: []string{"Gotth", "templ", "HTMX"},
+	}
+	expected := "/* TEAM */\n\tDeveloper: Ada\n\tContact: ada@example.com\n\tLocation: London\n\n" +
+		"/* THANKS */\n\tName: Gopher\n\n" +
+		"/* SITE */\n\tLast update: 2025/05/25\n\tComponents: Gotth, templ, HTMX\n"
+
+	if got := h.String(); got != expected {
+		t.Errorf("String() =\n%q\nwant\n%q", got, expected)
+	}
+}
+
+func TestWellKnownAutoRegistration(t *testing.T) {
+	ws, err := gotth.New(gotth.WebServerConfig{
+		SecurityTxt: &gotth.SecurityTxt{Contact: []string{"mailto:a@b.c"}, Expires: time.Now().Add(time.Hour)},
+		HumansTxt:   &gotth.HumansTxt{Language: "English"},
+	}, nil)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	tests := []struct {
+		path           string
+		expectedStatus int
+	}{
+		{path: "/.well-known/security.txt", expectedStatus: http.StatusOK},
+		{path: "/security.txt", expectedStatus: http.StatusMovedPermanently},
+		{path: "/humans.txt", expectedStatus: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			ws.Handler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("status = %d, want %d", rr.Code, tt.expectedStatus)
+			}
+		})
+	}
+}
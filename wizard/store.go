@@ -0,0 +1,94 @@
+package wizard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/ancalabrese/gotth/middlewares"
+	"github.com/ancalabrese/gotth/sessionstore"
+)
+
+// ErrNoState is returned by a Store when the request has no wizard state.
+var ErrNoState = errors.New("no wizard state")
+
+// Store keeps the State of a wizard between requests.
+type Store interface {
+	// Load returns the state of the request, or ErrNoState.
+	Load(r *http.Request) (State, error)
+	Save(w http.ResponseWriter, r *http.Request, state State) error
+	Clear(w http.ResponseWriter, r *http.Request) error
+}
+
+// SessionStore is a session store able to hold a State, such as the stores of the sessionstore
+// packages: cookie.Store[wizard.State] encrypts the state in the cookie itself, while
+// memory.Store or redis.Store[wizard.State] keep it server-side.
+type SessionStore[T any] interface {
+	middlewares.SessionStore
+	CreateSession(ctx context.Context, user T) (string, error)
+}
+
+type sessionStore[T any] struct {
+	store SessionStore[T]
+	cfg   middlewares.SessionConfig
+}
+
+// NewSessionStore returns a Store keeping the state in store, referenced by a cookie with the
+// attributes of cfg. Give each wizard its own cookie name. T must be State or any.
+func NewSessionStore[T any](store SessionStore[T], cfg middlewares.SessionConfig) (Store, error) {
+	if _, ok := any(State{}).(T); !ok {
+		return nil, fmt.Errorf("session store must hold wizard.State or any values")
+	}
+	return &sessionStore[T]{store: store, cfg: cfg}, nil
+}
+
+func (s *sessionStore[T]) Load(r *http.Request) (State, error) {
+	c, err := r.Cookie(s.cfg.CookieName())
+	if err != nil {
+		return State{}, ErrNoState
+	}
+	v, err := s.store.ExchangeSessionIDForUser(r.Context(), c.Value)
+	if err != nil {
+		// Expired or tampered states restart the wizard.
+		return State{}, ErrNoState
+	}
+	state, ok := v.(State)
+	if !ok {
+		return State{}, fmt.Errorf("unexpected wizard state of type %T", v)
+	}
+	return state, nil
+}
+
+// Save stores state in a new session, replacing the previous one.
+func (s *sessionStore[T]) Save(w http.ResponseWriter, r *http.Request, state State) error {
+	id, err := s.store.CreateSession(r.Context(), any(state).(T))
+	if err != nil {
+		return err
+	}
+	if err := s.invalidate(r); err != nil {
+		return err
+	}
+	middlewares.SetSession(w, id, s.cfg)
+	return nil
+}
+
+func (s *sessionStore[T]) Clear(w http.ResponseWriter, r *http.Request) error {
+	if err := s.invalidate(r); err != nil {
+		return err
+	}
+	middlewares.ClearSession(w, s.cfg)
+	return nil
+}
+
+func (s *sessionStore[T]) invalidate(r *http.Request) error {
+	c, err := r.Cookie(s.cfg.CookieName())
+	if err != nil {
+		return nil
+	}
+	err = s.store.InvalidateSession(r.Context(), nil, c.Value)
+	if errors.Is(err, sessionstore.ErrSessionNotFound) || errors.Is(err, sessionstore.ErrSessionExpired) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,196 @@
+// Package wizard drives multi-step forms, such as onboarding and checkout flows: each step is
+// validated on its own, the accepted values are kept between requests and users resume where
+// they left off.
+//
+//	cookieCfg := middlewares.DefaultSessionConfig()
+//	cookieCfg.Name = "checkout"
+//	states, _ := cookie.New[wizard.State](keys) // Or memory.New() to keep the state server-side
+//	store, _ := wizard.NewSessionStore[wizard.State](states, cookieCfg)
+//
+//	checkout := wizard.New(store,
+//		wizard.Step{Name: "address", Fields: []string{"street", "city"}, Validate: validateAddress},
+//		wizard.Step{Name: "payment", Fields: []string{"card"}, Validate: validatePayment},
+//	)
+//
+//	// POST /checkout
+//	res, err := checkout.Submit(w, r)
+//	if err != nil { ... }
+//	if res.Done {
+//		placeOrder(res.State.Data)
+//		checkout.Reset(w, r)
+//	}
+//	views.CheckoutStep(res.Step, res.Form).Render(r.Context(), w)
+package wizard
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+
+	"github.com/ancalabrese/gotth/forms"
+)
+
+// ErrInvalidStep is returned when navigating to a step the user hasn't reached yet.
+var ErrInvalidStep = errors.New("invalid wizard step")
+
+// Step is a step of a wizard.
+type Step struct {
+	Name string
+	// Fields are the form fields of the step. Other submitted fields are ignored, so that a step
+	// can't set the values of another one and skip its validation.
+	Fields []string
+	// Validate records the errors of the values submitted for the step on f, e.g. with f.Check.
+	// Values of previous steps are available in data.
+	Validate func(f *forms.Form, data url.Values)
+}
+
+// State is the progress of a user through a wizard.
+type State struct {
+	// Step is the index of the current step.
+	Step int `json:"s"`
+	// Reached is the index of the furthest step reached, which users can go back to.
+	Reached int `json:"r"`
+	// Data holds the accepted values of the completed steps.
+	Data url.Values `json:"d"`
+}
+
+// Wizard is a sequence of steps whose state is kept in a Store.
+type Wizard struct {
+	steps []Step
+	store Store
+}
+
+// New returns a Wizard going through steps, keeping its state in store.
+func New(store Store, steps ...Step) *Wizard {
+	return &Wizard{steps: steps, store: store}
+}
+
+// Steps returns the steps of the wizard.
+func (wz *Wizard) Steps() []Step {
+	return wz.steps
+}
+
+// Result is the outcome of a wizard request.
+type Result struct {
+	State State
+	// Step is the step to render, the one to resume from.
+	Step Step
+	// Form holds the values of the step, the submitted ones with their errors when invalid, or
+	// the previously accepted ones otherwise.
+	Form *forms.Form
+	// Done reports whether the last step has been completed. State.Data holds all the values.
+	Done bool
+}
+
+// Current returns the step where the user left off, with its previously accepted values.
+func (wz *Wizard) Current(r *http.Request) (Result, error) {
+	state, err := wz.load(r)
+	if err != nil {
+		return Result{}, err
+	}
+	return wz.result(state), nil
+}
+
+// Submit validates the values submitted for the current step. Valid values are stored and the
+// user moves to the next step, while invalid ones are returned in Result.Form with their errors
+// and the user stays on the step.
+func (wz *Wizard) Submit(w http.ResponseWriter, r *http.Request) (Result, error) {
+	state, err := wz.load(r)
+	if err != nil {
+		return Result{}, err
+	}
+	f, err := forms.Parse(r)
+	if err != nil {
+		return Result{}, err
+	}
+
+	step := wz.steps[state.Step]
+	values := url.Values{}
+	for _, field := range step.Fields {
+		if v, ok := f.Values[field]; ok {
+			values[field] = v
+		}
+	}
+	form := forms.New(values)
+	if step.Validate != nil {
+		step.Validate(form, state.Data)
+	}
+	if !form.Valid() {
+		res := wz.result(state)
+		res.Form = form
+		return res, nil
+	}
+
+	for field, v := range values {
+		state.Data[field] = v
+	}
+	if state.Step == len(wz.steps)-1 {
+		if err := wz.store.Save(w, r, state); err != nil {
+			return Result{}, fmt.Errorf("failed to save wizard state. err %w", err)
+		}
+		res := wz.result(state)
+		res.Done = true
+		return res, nil
+	}
+
+	state.Step++
+	state.Reached = max(state.Reached, state.Step)
+	if err := wz.store.Save(w, r, state); err != nil {
+		return Result{}, fmt.Errorf("failed to save wizard state. err %w", err)
+	}
+	return wz.result(state), nil
+}
+
+// GoTo moves the user to the step with the given index, e.g. to go back and edit a previous
+// step. Only the steps already reached are allowed.
+func (wz *Wizard) GoTo(w http.ResponseWriter, r *http.Request, step int) (Result, error) {
+	state, err := wz.load(r)
+	if err != nil {
+		return Result{}, err
+	}
+	if step < 0 || step > state.Reached {
+		return Result{}, ErrInvalidStep
+	}
+	state.Step = step
+	if err := wz.store.Save(w, r, state); err != nil {
+		return Result{}, fmt.Errorf("failed to save wizard state. err %w", err)
+	}
+	return wz.result(state), nil
+}
+
+// Reset discards the state, e.g. once the data of a completed wizard has been processed.
+func (wz *Wizard) Reset(w http.ResponseWriter, r *http.Request) error {
+	return wz.store.Clear(w, r)
+}
+
+// load returns the stored state, or the first step when there's none or it doesn't match the
+// steps (e.g., after a deploy changed them).
+func (wz *Wizard) load(r *http.Request) (State, error) {
+	if len(wz.steps) == 0 {
+		return State{}, errors.New("wizard has no steps")
+	}
+	state, err := wz.store.Load(r)
+	if err != nil && !errors.Is(err, ErrNoState) {
+		return State{}, fmt.Errorf("failed to load wizard state. err %w", err)
+	}
+	if err != nil || state.Step < 0 || state.Reached >= len(wz.steps) || state.Step > state.Reached {
+		state = State{}
+	}
+	if state.Data == nil {
+		state.Data = url.Values{}
+	}
+	return state, nil
+}
+
+func (wz *Wizard) result(state State) Result {
+	step := wz.steps[state.Step]
+	values := url.Values{}
+	for field, v := range state.Data {
+		if slices.Contains(step.Fields, field) {
+			values[field] = v
+		}
+	}
+	return Result{State: state, Step: step, Form: forms.New(values)}
+}
@@ -0,0 +1,166 @@
+package wizard_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/ancalabrese/gotth/forms"
+	"github.com/ancalabrese/gotth/middlewares"
+	"github.com/ancalabrese/gotth/sessionstore/cookie"
+	"github.com/ancalabrese/gotth/sessionstore/memory"
+	"github.com/ancalabrese/gotth/wizard"
+)
+
+// browser keeps the wizard cookie between requests.
+type browser struct {
+	t      *testing.T
+	cookie *http.Cookie
+}
+
+func (b *browser) do(form url.Values, f func(w http.ResponseWriter, r *http.Request)) {
+	b.t.Helper()
+	var r *http.Request
+	if form == nil {
+		r = httptest.NewRequest(http.MethodGet, "/checkout", nil)
+	} else {
+		r = httptest.NewRequest(http.MethodPost, "/checkout", strings.NewReader(form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	if b.cookie != nil {
+		r.AddCookie(b.cookie)
+	}
+	rr := httptest.NewRecorder()
+	f(rr, r)
+	for _, c := range rr.Result().Cookies() {
+		b.cookie = c
+		if c.MaxAge < 0 {
+			b.cookie = nil
+		}
+	}
+}
+
+func newWizard(t *testing.T, store wizard.Store) *wizard.Wizard {
+	t.Helper()
+	return wizard.New(store,
+		wizard.Step{
+			Name:   "address",
+			Fields: []string{"street"},
+			Validate: func(f *forms.Form, data url.Values) {
+				f.Check(f.Get("street") != "", "street", "Street is required")
+			},
+		},
+		wizard.Step{
+			Name:   "payment",
+			Fields: []string{"card"},
+			Validate: func(f *forms.Form, data url.Values) {
+				f.Check(len(f.Get("card")) == 4, "card", "Card must have 4 digits")
+			},
+		},
+	)
+}
+
+func TestWizard(t *testing.T) {
+	cfg := middlewares.DefaultSessionConfig()
+	cfg.Name = "checkout"
+
+	cookieStates, err := cookie.New[wizard.State]([][]byte{[]byte("0123456789abcdef0123456789abcdef")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	memoryStates := memory.New()
+	defer memoryStates.Close()
+
+	tests := []struct {
+		name  string
+		store func() (wizard.Store, error)
+	}{
+		{name: "Encrypted cookie", store: func() (wizard.Store, error) { return wizard.NewSessionStore[wizard.State](cookieStates, cfg) }},
+		{name: "Server-side session", store: func() (wizard.Store, error) { return wizard.NewSessionStore[any](memoryStates, cfg) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, err := tt.store()
+			if err != nil {
+				t.Fatalf("NewSessionStore() error = %v", err)
+			}
+			wz := newWizard(t, store)
+			b := &browser{t: t}
+			var res wizard.Result
+
+			check := func(f func(w http.ResponseWriter, r *http.Request) (wizard.Result, error)) func(w http.ResponseWriter, r *http.Request) {
+				return func(w http.ResponseWriter, r *http.Request) {
+					var err error
+					if res, err = f(w, r); err != nil {
+						t.Fatalf("unexpected error = %v", err)
+					}
+				}
+			}
+			current := check(func(w http.ResponseWriter, r *http.Request) (wizard.Result, error) { return wz.Current(r) })
+			submit := check(wz.Submit)
+
+			b.do(nil, current)
+			if res.Step.Name != "address" {
+				t.Fatalf("new visitor starts at %q, want %q", res.Step.Name, "address")
+			}
+
+			b.do(url.Values{"street": {""}}, submit)
+			if res.Step.Name != "address" || res.Form.Error("street") == "" {
+				t.Fatalf("invalid step: step = %q, errors = %v", res.Step.Name, res.Form.Errors())
+			}
+
+			// Values of other steps are ignored, so that their validation can't be skipped.
+			b.do(url.Values{"street": {"Main St"}, "card": {"bogus"}}, submit)
+			if res.Step.Name != "payment" || res.State.Data.Has("card") {
+				t.Fatalf("valid step: step = %q, data = %v", res.Step.Name, res.State.Data)
+			}
+
+			// Users resume where they left off.
+			b.do(nil, current)
+			if res.Step.Name != "payment" {
+				t.Fatalf("resumed at %q, want %q", res.Step.Name, "payment")
+			}
+
+			// And can go back to edit the steps they've completed, but not skip ahead.
+			b.do(nil, check(func(w http.ResponseWriter, r *http.Request) (wizard.Result, error) { return wz.GoTo(w, r, 0) }))
+			if res.Step.Name != "address" || res.Form.Get("street") != "Main St" {
+				t.Fatalf("GoTo(0): step = %q, street = %q", res.Step.Name, res.Form.Get("street"))
+			}
+			b.do(nil, func(w http.ResponseWriter, r *http.Request) {
+				if _, err := wz.GoTo(w, r, 2); !errors.Is(err, wizard.ErrInvalidStep) {
+					t.Errorf("GoTo(2) error = %v, want %v", err, wizard.ErrInvalidStep)
+				}
+			})
+			b.do(url.Values{"street": {"High St"}}, submit)
+
+			b.do(url.Values{"card": {"4242"}}, submit)
+			if !res.Done || res.State.Data.Get("street") != "High St" || res.State.Data.Get("card") != "4242" {
+				t.Fatalf("last step: done = %v, data = %v", res.Done, res.State.Data)
+			}
+
+			b.do(nil, func(w http.ResponseWriter, r *http.Request) {
+				if err := wz.Reset(w, r); err != nil {
+					t.Fatalf("Reset() error = %v", err)
+				}
+			})
+			b.do(nil, current)
+			if res.Step.Name != "address" || res.State.Data.Has("street") {
+				t.Errorf("after Reset: step = %q, data = %v", res.Step.Name, res.State.Data)
+			}
+		})
+	}
+}
+
+func TestNewSessionStore_WrongType(t *testing.T) {
+	users, err := cookie.New[string]([][]byte{[]byte("0123456789abcdef")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wizard.NewSessionStore[string](users, middlewares.DefaultSessionConfig()); err == nil {
+		t.Error("NewSessionStore() error = nil for a store of strings")
+	}
+}